@@ -0,0 +1,38 @@
+package app
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"sinarlog.com/config"
+	impl "sinarlog.com/internal/adapter/repo"
+	"sinarlog.com/internal/app/usecase"
+	"sinarlog.com/pkg/postgres"
+)
+
+// AccrueLeaves runs the leave accrual once at the given time and
+// exits. The scheduler of the app runs the accrual daily already,
+// hence this is meant for running it at another date. It only needs
+// Postgres. Running it more than once is safe.
+func AccrueLeaves(cfg *config.Config, at time.Time) {
+	ctx := context.Background()
+
+	pg := postgres.GetPostgres(
+		cfg.Db.URL,
+		postgres.MaxPoolSize(cfg.Db.MaxPoolSize),
+		postgres.MaxOpenCoon(cfg.Db.MaxOpenConn),
+		postgres.MaxConnLifetime(cfg.Db.MaxConnLifetime),
+	)
+	if err := impl.MigrateRelationalEntities(pg.ORM); err != nil {
+		log.Fatalf("unable to migrate the database: %s\n", err)
+	}
+
+	uc := usecase.NewLeaveAccrualUseCase(impl.NewLeaveRepo(pg.ORM))
+	report, err := uc.AccrueLeaveQuotas(ctx, at)
+	if err != nil {
+		log.Fatalf("leave accrual at %s failed after changing %d quotas: %s\n", report.At.Format(time.RFC3339), report.Quotas, err)
+	}
+
+	log.Printf("leave accrual at %s changed %d quotas with %d ledger entries\n", report.At.Format(time.RFC3339), report.Quotas, report.Entries)
+}
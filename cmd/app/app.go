@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"log"
 	"os"
 	"os/signal"
 	"syscall"
@@ -9,6 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"sinarlog.com/config"
 	"sinarlog.com/internal/composer"
+	"sinarlog.com/internal/delivery/middleware"
 	v2 "sinarlog.com/internal/delivery/v2"
 	"sinarlog.com/pkg/bucket"
 	"sinarlog.com/pkg/doorkeeper"
@@ -20,6 +22,7 @@ import (
 	"sinarlog.com/pkg/pubsub"
 	"sinarlog.com/pkg/rater"
 	"sinarlog.com/pkg/redis"
+	"sinarlog.com/pkg/scheduler"
 
 	_ "sinarlog.com/internal/utils"
 )
@@ -72,15 +75,12 @@ func Run(cfg *config.Config) {
 		doorkeeper.RegisterPublicPath(cfg.Doorkeeper.PubPath),
 		doorkeeper.RegisterOTPSecretLength(cfg.Doorkeeper.OTPSecretLength),
 		doorkeeper.RegisterOTPExpDuration(cfg.Doorkeeper.OTPExp),
+		doorkeeper.RegisterResetExpDuration(cfg.Doorkeeper.ResetExp),
+		doorkeeper.RegisterEncryptionKey(cfg.Doorkeeper.EncryptionKey),
 	)
 
-	// Rate Limitter
-	rt := rater.GetRater(app_context,
-		rater.RegisterRateLimitForEachClient(cfg.App.RaterLimit),
-		rater.RegisterBurstLimitForEachClient(cfg.App.BurstLimit),
-		rater.RegisterEvaluationInterval(cfg.App.RaterEvaluationInterval),
-		rater.RegisterDeletionTime(cfg.App.RaterDeletionTime),
-	)
+	// Rate Limitter, shared by the replicas through Redis
+	rt := rater.GetRater(rdis.Client)
 
 	// Mailer
 	ml := mailer.GetMailer(
@@ -89,21 +89,28 @@ func Run(cfg *config.Config) {
 		mailer.RegisterTemplatePath(cfg.App.MailerTemplatePath),
 	)
 
-	// PubSub
-	ps := pubsub.GetPubSubClient(
-		app_context,
-		pubsub.RegisterEnv(cfg.App.Environment),
-		pubsub.RegisterProjectId(cfg.App.GoogleProjectId),
-		pubsub.RegisterKey(cfg.App.GoogleServiceAccountPath),
-	)
+	// PubSub, only needed when the chats are not fanned out by Redis
+	var ps *pubsub.PubSub
+	if cfg.App.PubSubBackend == config.GCP_PUBSUB {
+		ps = pubsub.GetPubSubClient(
+			app_context,
+			pubsub.RegisterEnv(cfg.App.Environment),
+			pubsub.RegisterProjectId(cfg.App.GoogleProjectId),
+			pubsub.RegisterKey(cfg.App.GoogleServiceAccountPath),
+		)
+	}
 
 	// Firebase bucket
 	bkt := bucket.GetFirebaseBucket(app_context, cfg.Bucket.BucketName, cfg.Bucket.ServiceAccountPath)
 
+	// Scheduler, only the leader among the replicas runs the jobs
+	sch := scheduler.GetScheduler(app_context, rdis.Client)
+
 	// Composers .-.
-	serviceComposer := composer.NewServiceComposer(dk, rt, ml, bkt, rdis, ps)
+	serviceComposer := composer.NewServiceComposer(dk, rt, ml, bkt, rdis, ps, sch)
 	repoComposer := composer.NewRepoComposer(pg, rdis, mg, cfg.App.Environment)
 	usecaseComposer := composer.NewUseCaseComposer(repoComposer, serviceComposer)
+	usecaseComposer.SchedulerUseCase().RegisterJobs()
 
 	// Http
 	var deliveree *gin.Engine
@@ -112,7 +119,13 @@ func Run(cfg *config.Config) {
 		deliveree = gin.New()
 		deliveree.Use(gin.Recovery())
 	} else {
-		deliveree = gin.Default()
+		deliveree = gin.New()
+		deliveree.Use(middleware.GinLoggerMiddleware(), gin.Recovery())
+	}
+	// The client ip is only taken from X-Forwarded-For when the request
+	// comes through a trusted proxy, otherwise anyone could pick theirs
+	if err := deliveree.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Unable to set the trusted proxies %s\n", err)
 	}
 	v2.NewRouter(deliveree, logger, usecaseComposer)
 
@@ -5,23 +5,21 @@ import (
 	"os"
 	"strconv"
 	"strings"
-	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
 )
 
 type appConfig struct {
-	Environment             string
-	LogPath                 string
-	RaterLimit              int
-	BurstLimit              int
-	RaterEvaluationInterval time.Duration
-	RaterDeletionTime       time.Duration
-	DefaultPaginationSize   int
-	MailerEmailAddress      string
-	MailerEmailPassword     string
-	MailerTemplatePath      string
+	Environment           string
+	LogPath               string
+	DefaultPaginationSize int
+	MailerEmailAddress    string
+	MailerEmailPassword   string
+	MailerTemplatePath    string
+
+	// Either Google Cloud Pub/Sub or Redis Streams
+	PubSubBackend string
 
 	// Google Cloud Related
 	GoogleProjectId          string
@@ -39,40 +37,22 @@ func (c *Config) newAppConfig() {
 		MailerTemplatePath:  strings.ToLower(os.Getenv("MAILER_TEMPLATE_PATH")),
 		MailerEmailPassword: os.Getenv("MAILER_SENDER_PASSWORD"),
 
+		PubSubBackend: strings.ToUpper(os.Getenv("PUBSUB_BACKEND")),
+
 		GoogleProjectId:          os.Getenv("GOOGLE_PROJECT_ID"),
 		GoogleServiceAccountPath: strings.ToLower(os.Getenv("GOOGLE_KEY_PATH")),
 	}
 
+	if a.PubSubBackend == "" {
+		a.PubSubBackend = GCP_PUBSUB
+	}
+
 	defaultPaginationSize, err := strconv.Atoi(os.Getenv("DEFAULT_ROWS_PER_PAGE"))
 	if err != nil {
 		log.Fatalf("Unable to parse app pagination %s\n", err)
 	}
 	a.DefaultPaginationSize = defaultPaginationSize
 
-	raterEvInt, err := time.ParseDuration(os.Getenv("RATER_EVALUATION_INTERVAL"))
-	if err != nil {
-		log.Fatalf("Unable to parse app rate eval time %s\n", err)
-	}
-	a.RaterEvaluationInterval = raterEvInt
-
-	raterDelTime, err := time.ParseDuration(os.Getenv("RATER_DELETION_TIME"))
-	if err != nil {
-		log.Fatalf("Unable to parse rater del time %s\n", err)
-	}
-	a.RaterDeletionTime = raterDelTime
-
-	raterLimit, err := strconv.Atoi(os.Getenv("RATER_LIMIT"))
-	if err != nil {
-		log.Fatalf("Unable to parse rater limit %s\n", err)
-	}
-	a.RaterLimit = raterLimit
-
-	burstLimit, err := strconv.Atoi(os.Getenv("BURST_LIMIT"))
-	if err != nil {
-		log.Fatalf("Unable to parse burst limit %s\n", err)
-	}
-	a.BurstLimit = burstLimit
-
 	if err := a.validate(); err != nil {
 		log.Fatalf("FATAL - %s", err)
 	}
@@ -86,8 +66,6 @@ func (a appConfig) validate() error {
 	return validation.ValidateStruct(&a,
 		validation.Field(&a.DefaultPaginationSize, validation.Required, validation.Min(5)),
 		validation.Field(&a.LogPath, validation.Required),
-		validation.Field(&a.RaterDeletionTime, validation.Required, validation.By(validateEmptyDuration)),
-		validation.Field(&a.RaterEvaluationInterval, validation.Required, validation.By(validateEmptyDuration)),
 		validation.Field(&a.LogPath, validation.Required),
 		validation.Field(&a.Environment, validation.Required, validation.In(
 			PRODUCTION,
@@ -97,5 +75,6 @@ func (a appConfig) validate() error {
 		)),
 		validation.Field(&a.MailerEmailAddress, validation.Required, is.Email),
 		validation.Field(&a.MailerEmailPassword, validation.Required),
+		validation.Field(&a.PubSubBackend, validation.In(GCP_PUBSUB, REDIS_PUBSUB)),
 	)
 }
@@ -34,6 +34,12 @@ const (
 	DEVELOPMENT string = "DEVELOPMENT"
 )
 
+// The backends fanning out the chats
+const (
+	GCP_PUBSUB   string = "GCP"
+	REDIS_PUBSUB string = "REDIS"
+)
+
 // GetConfig function    either returns an already created
 // config instance or creates a new config instance if there
 // is none existing yet.
@@ -27,6 +27,12 @@ type doorkeeperConfig struct {
 	// --- OTP Config ---
 	OTPExp          time.Duration
 	OTPSecretLength int
+
+	// --- Password Reset Config ---
+	ResetExp time.Duration
+
+	// --- Encryption Config ---
+	EncryptionKey string
 }
 
 // newServerConfig method    has a Config receiver
@@ -40,6 +46,7 @@ func (c *Config) newDoorkeeperConfig() {
 		PubPath:       strings.ToLower(os.Getenv("DOORKEEPER_CERT_PUBLIC_PATH")),
 		Issuer:        strings.ToUpper(os.Getenv("DOORKEEPER_ISSUER")),
 		HashMethod:    strings.ToUpper(os.Getenv("DOORKEEPER_HASH_METHOD")),
+		EncryptionKey: os.Getenv("DOORKEEPER_ENCRYPTION_KEY"),
 	}
 
 	otpExp, err := time.ParseDuration(strings.ToLower(os.Getenv("DOORKEEPER_OTP_EXPIRATION_DURATION")))
@@ -66,6 +73,15 @@ func (c *Config) newDoorkeeperConfig() {
 	}
 	d.OTPSecretLength = otpSecretLength
 
+	// Optional, the doorkeeper has a default
+	if v := os.Getenv("DOORKEEPER_RESET_EXPIRATION_DURATION"); v != "" {
+		resetExp, err := time.ParseDuration(strings.ToLower(v))
+		if err != nil {
+			log.Fatalf("Error while parsing password reset expiration %s", err)
+		}
+		d.ResetExp = resetExp
+	}
+
 	if err := d.validate(); err != nil {
 		log.Fatalf("FATAL - %s", err)
 	}
@@ -93,5 +109,7 @@ func (d doorkeeperConfig) validate() error {
 		validation.Field(&d.AccessDuration, validation.Required, validation.By(validateEmptyDuration)),
 		validation.Field(&d.RefreshDuration, validation.Required, validation.By(validateEmptyDuration)),
 		validation.Field(&d.PrivPath, validation.Required.Error("Please provide a private key as it is required for encryption")),
+		validation.Field(&d.EncryptionKey, validation.Required.Error("Please provide an encryption key as it is required for encrypting the OTP secrets"),
+			validation.Length(32, 0).Error("encryption key must be at least 32 characters long")),
 	)
 }
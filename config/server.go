@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"net"
 	"os"
+	"strings"
 
 	"github.com/go-ozzo/ozzo-validation/is"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
@@ -11,6 +14,9 @@ import (
 type serverConfig struct {
 	Host string
 	Port string
+	// Ips or CIDRs of the proxies in front of the server, whose
+	// X-Forwarded-For is trusted to tell the client ip
+	TrustedProxies []string
 }
 
 // newServerConfig method    has a Config receiver
@@ -22,6 +28,12 @@ func (c *Config) newServerConfig() {
 		Port: os.Getenv("PORT"),
 	}
 
+	for _, v := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			s.TrustedProxies = append(s.TrustedProxies, v)
+		}
+	}
+
 	if err := s.validate(); err != nil {
 		log.Fatalf("%s", err)
 	}
@@ -35,5 +47,14 @@ func (d serverConfig) validate() error {
 	return validation.ValidateStruct(&d,
 		validation.Field(&d.Host, validation.When(d.Host != "", validation.Required, is.Host.Error("(serverConfig).validate: unrecognised host for server"))),
 		validation.Field(&d.Port, validation.Required, is.Port.Error("(serverConfig).validate: unrecognised port for server")),
+		validation.Field(&d.TrustedProxies, validation.Each(validation.By(func(value interface{}) error {
+			proxy, _ := value.(string)
+			if net.ParseIP(proxy) == nil {
+				if _, _, err := net.ParseCIDR(proxy); err != nil {
+					return fmt.Errorf("(serverConfig).validate: %s is neither an ip nor a CIDR", proxy)
+				}
+			}
+			return nil
+		}))),
 	)
 }
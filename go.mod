@@ -3,6 +3,7 @@ module sinarlog.com
 go 1.20
 
 require (
+	cloud.google.com/go/pubsub v1.33.0
 	cloud.google.com/go/storage v1.31.0
 	firebase.google.com/go/v4 v4.12.0
 	github.com/google/uuid v1.3.0
@@ -12,6 +13,7 @@ require (
 	github.com/redis/go-redis/v9 v9.0.5
 	github.com/xlzd/gotp v0.1.0
 	go.mongodb.org/mongo-driver v1.12.1
+	golang.org/x/time v0.3.0
 	google.golang.org/api v0.126.0
 	gorm.io/gorm v1.25.0
 )
@@ -23,7 +25,6 @@ require (
 	cloud.google.com/go/firestore v1.10.0 // indirect
 	cloud.google.com/go/iam v1.1.0 // indirect
 	cloud.google.com/go/longrunning v0.4.2 // indirect
-	cloud.google.com/go/pubsub v1.33.0 // indirect
 	github.com/MicahParks/keyfunc v1.9.0 // indirect
 	github.com/cespare/xxhash/v2 v2.2.0 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
@@ -89,7 +90,6 @@ require (
 	golang.org/x/net v0.14.0 // indirect
 	golang.org/x/sys v0.11.0 // indirect
 	golang.org/x/text v0.12.0 // indirect
-	golang.org/x/time v0.3.0
 	google.golang.org/protobuf v1.30.0 // indirect
 	gopkg.in/gomail.v2 v2.0.0-20160411212932-81ebce5c23df
 	gopkg.in/yaml.v3 v3.0.1 // indirect
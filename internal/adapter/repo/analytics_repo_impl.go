@@ -47,7 +47,35 @@ func (repo *analyticsRepo) GetAttendanceAndLeaveQuotaAnalyticsByEmployeeId(ctx c
 	res.LateClockIns = agg1.Lates
 	res.EarlyClockOuts = agg1.Earlies
 
-	row := repo.db.WithContext(ctx).Table("employee_leaves_quota").Where("employee_id = ?", employeeId).Select("yearly_count", "unpaid_count").Row()
+	// The remaining annual quota and the unpaid leave days taken this year
+	row := repo.db.WithContext(ctx).Raw(`
+	SELECT
+		COALESCE((
+			SELECT q.count
+			FROM employee_leave_quota AS q
+			WHERE q.employee_id = ? AND q.type = ? AND q.deleted_at IS NULL
+		), 0),
+		COALESCE((
+			SELECT SUM(l.working_days)
+			FROM leaves AS l
+			-- The leave type may have been removed since, hence the policy
+			-- removed last is taken when there is no live one
+			JOIN LATERAL (
+				SELECT lp.is_paid
+				FROM leave_policies AS lp
+				WHERE lp.code = l.type
+				ORDER BY lp.deleted_at IS NULL DESC, lp.deleted_at DESC
+				LIMIT 1
+			) AS p ON TRUE
+			WHERE
+			l.employee_id = ?
+			AND p.is_paid IS FALSE
+			AND l.approved_by_manager IS TRUE
+			AND l.approved_by_hr IS TRUE
+			AND l.cancelled_at IS NULL
+			AND EXTRACT(YEAR FROM l."from") = ?
+		), 0)
+	`, employeeId, entity.ANNUAL, employeeId, time.Now().In(utils.CURRENT_LOC).Year()).Row()
 	if err := row.Scan(&res.YearlyCount, &res.UnpaidCount); err != nil {
 		return res, err
 	}
@@ -80,7 +108,7 @@ func (repo *analyticsRepo) GetDashboardAnalyticsHr(ctx context.Context) (vo.HrDa
 
 	// Unpaids
 	if err := stm.Model(&entity.Leave{}).
-		Where("approved_by_manager IS TRUE AND approved_by_hr IS TRUE").
+		Where("approved_by_manager IS TRUE AND approved_by_hr IS TRUE AND cancelled_at IS NULL").
 		Where("created_at BETWEEN ? AND ?", utils.GetStartOfTheMonth(), utils.GetEndOfTheMonth()).
 		Where("type = ?", entity.UNPAID.String()).
 		Count(&res.ApprovedUnpaidLeaves).Error; err != nil {
@@ -89,7 +117,7 @@ func (repo *analyticsRepo) GetDashboardAnalyticsHr(ctx context.Context) (vo.HrDa
 
 	// Annual and Marriages
 	if err := stm.Model(&entity.Leave{}).
-		Where("approved_by_manager IS TRUE AND approved_by_hr IS TRUE").
+		Where("approved_by_manager IS TRUE AND approved_by_hr IS TRUE AND cancelled_at IS NULL").
 		Where("created_at BETWEEN ? AND ?", utils.GetStartOfTheMonth(), utils.GetEndOfTheMonth()).
 		Where("(type = ? OR type = ?)", entity.MARRIAGE.String(), entity.ANNUAL.String()).
 		Count(&res.ApprovedAnnualMarriageLeaves).Error; err != nil {
@@ -0,0 +1,206 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"sinarlog.com/internal/entity"
+)
+
+type approvalRepo struct {
+	db *gorm.DB
+}
+
+func NewApprovalRepo(db *gorm.DB) *approvalRepo {
+	return &approvalRepo{db}
+}
+
+func (repo *approvalRepo) GetApprovalChains(ctx context.Context) (entity.ApprovalChains, error) {
+	var chains entity.ApprovalChains
+
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.ApprovalChain{}).
+		Order("kind ASC").
+		Order("leave_type ASC").
+		Order("min_duration ASC").
+		Find(&chains).Error; err != nil {
+		return nil, err
+	}
+
+	return chains, nil
+}
+
+func (repo *approvalRepo) GetApprovalChainsByKind(ctx context.Context, kind entity.ApprovalKind) (entity.ApprovalChains, error) {
+	var chains entity.ApprovalChains
+
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.ApprovalChain{}).
+		Where("kind = ?", kind).
+		Find(&chains).Error; err != nil {
+		return nil, err
+	}
+
+	return chains, nil
+}
+
+func (repo *approvalRepo) GetApprovalChainById(ctx context.Context, id string) (entity.ApprovalChain, error) {
+	var chain entity.ApprovalChain
+
+	if err := repo.db.WithContext(ctx).
+		Model(&chain).
+		First(&chain, "id = ?", id).Error; err != nil {
+		return chain, err
+	}
+
+	return chain, nil
+}
+
+func (repo *approvalRepo) CreateApprovalChain(ctx context.Context, chain entity.ApprovalChain) error {
+	return repo.db.WithContext(ctx).Model(&chain).Create(&chain).Error
+}
+
+func (repo *approvalRepo) UpdateApprovalChain(ctx context.Context, chain entity.ApprovalChain) error {
+	return repo.db.WithContext(ctx).
+		Model(&chain).
+		Select("name", "kind", "leave_type", "min_duration", "steps").
+		Updates(&chain).Error
+}
+
+func (repo *approvalRepo) DeleteApprovalChain(ctx context.Context, id string) error {
+	return repo.db.WithContext(ctx).Delete(&entity.ApprovalChain{}, "id = ?", id).Error
+}
+
+// GetApprovalDelegations returns the delegations not ended yet
+// since the given time.
+func (repo *approvalRepo) GetApprovalDelegations(ctx context.Context, since time.Time) ([]entity.ApprovalDelegation, error) {
+	var delegations []entity.ApprovalDelegation
+
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.ApprovalDelegation{}).
+		Where(`"to" >= ?`, since).
+		Preload("Delegator").
+		Preload("Delegate").
+		Preload("CreatedBy").
+		Order(`"from" ASC`).
+		Find(&delegations).Error; err != nil {
+		return nil, err
+	}
+
+	return delegations, nil
+}
+
+// GetApprovalDelegationsByEmployeeId returns the delegations not
+// ended yet since the given time, given or received by the employee.
+func (repo *approvalRepo) GetApprovalDelegationsByEmployeeId(ctx context.Context, employeeId string, since time.Time) ([]entity.ApprovalDelegation, error) {
+	var delegations []entity.ApprovalDelegation
+
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.ApprovalDelegation{}).
+		Where("delegator_id = ? OR delegate_id = ?", employeeId, employeeId).
+		Where(`"to" >= ?`, since).
+		Preload("Delegator").
+		Preload("Delegate").
+		Preload("CreatedBy").
+		Order(`"from" ASC`).
+		Find(&delegations).Error; err != nil {
+		return nil, err
+	}
+
+	return delegations, nil
+}
+
+func (repo *approvalRepo) GetApprovalDelegationById(ctx context.Context, id string) (entity.ApprovalDelegation, error) {
+	var delegation entity.ApprovalDelegation
+
+	if err := repo.db.WithContext(ctx).
+		Model(&delegation).
+		First(&delegation, "id = ?", id).Error; err != nil {
+		return delegation, err
+	}
+
+	return delegation, nil
+}
+
+// GetActiveDelegatorIds returns the ids of the employees delegating
+// their approval authority to the delegate at the given time.
+func (repo *approvalRepo) GetActiveDelegatorIds(ctx context.Context, delegateId string, at time.Time) ([]string, error) {
+	var ids []string
+
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.ApprovalDelegation{}).
+		Where("delegate_id = ?", delegateId).
+		Where(`"from" <= ? AND "to" >= ?`, at, at).
+		Distinct().
+		Pluck("delegator_id", &ids).Error; err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (repo *approvalRepo) CreateApprovalDelegation(ctx context.Context, delegation entity.ApprovalDelegation) error {
+	return repo.db.WithContext(ctx).Model(&delegation).Create(&delegation).Error
+}
+
+func (repo *approvalRepo) DeleteApprovalDelegation(ctx context.Context, id string) error {
+	return repo.db.WithContext(ctx).Delete(&entity.ApprovalDelegation{}, "id = ?", id).Error
+}
+
+// saveApprovalSteps saves the decisions newly made on the approval
+// steps. A decided step without an id, such as the decision of a
+// delegate on a request without approval steps, is created. It
+// returns entity.ErrApprovalStepDecided if a step has been decided
+// meanwhile.
+func saveApprovalSteps(tx *gorm.DB, steps []entity.ApprovalStep) error {
+	for _, step := range steps {
+		if !step.IsNewlyDecided() {
+			continue
+		}
+
+		if step.Id == "" {
+			if err := tx.Create(&step).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		res := tx.Exec("UPDATE approval_steps SET acted_by_id = ?, acted_as_delegate = ?, approved = ?, reason = ?, action_at = ?, updated_at = NOW() WHERE id = ? AND approved IS NULL",
+			step.ActedByID,
+			step.ActedAsDelegate,
+			step.Approved,
+			step.Reason,
+			step.ActionAt,
+			step.Id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return entity.ErrApprovalStepDecided
+		}
+	}
+
+	return nil
+}
+
+// pendingApprovalStepSql returns the condition of a request whose
+// step waiting for a decision matches the condition on the step s.
+// A step waits once all the steps before it are approved.
+func pendingApprovalStepSql(column, id, cond string) string {
+	return `EXISTS (
+		SELECT 1 FROM approval_steps AS s
+		WHERE s.` + column + ` = ` + id + `
+			AND ` + cond + `
+			AND s.approved IS NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM approval_steps AS p
+				WHERE p.` + column + ` = s.` + column + `
+					AND p.sequence < s.sequence
+					AND (p.approved IS NULL OR p.approved IS FALSE)
+			)
+	)`
+}
+
+func orderApprovalSteps(db *gorm.DB) *gorm.DB {
+	return db.Order("sequence ASC")
+}
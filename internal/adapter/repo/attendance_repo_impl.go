@@ -77,6 +77,18 @@ func (repo *attendanceRepo) GetClockInOTPTimestamp(ctx context.Context, employee
 	return timestamp, nil
 }
 
+// ConsumeClockInOTPTimestamp removes the OTP timestamp of the employee
+// so the OTP is used once. It returns false if another request used it
+// first.
+func (repo *attendanceRepo) ConsumeClockInOTPTimestamp(ctx context.Context, employeeId string) (bool, error) {
+	deleted, err := repo.rdis.Del(ctx, fmt.Sprintf("%s:clockIn", employeeId)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return deleted == 1, nil
+}
+
 // GetTodaysAttendanceByEmployeeId queries the employee's today's attendance
 func (repo *attendanceRepo) GetTodaysAttendanceByEmployeeId(ctx context.Context, employeeId string) (entity.Attendance, error) {
 	var attendance entity.Attendance
@@ -94,9 +106,10 @@ func (repo *attendanceRepo) GetTodaysAttendanceByEmployeeId(ctx context.Context,
 	return attendance, nil
 }
 
-// EmployeeHasActiveAttendance checks whether an employee has an active attendance for today.
-// An active attendance is defined by clock_in_at value is not null or empty time and is between
-// the start and end of day, and clock_out_at value is null or an empty time.
+// EmployeeHasActiveAttendance checks whether an employee has an active attendance.
+// An active attendance is defined by clock_in_at value is within the last 24 hours, so
+// that a night shift crossing midnight remains active, and clock_out_at value is null or
+// an empty time.
 func (repo *attendanceRepo) EmployeeHasActiveAttendance(ctx context.Context, employeeId string) (bool, error) {
 	// If count = 0, then there are no active attendance.
 	var count int64
@@ -105,14 +118,11 @@ func (repo *attendanceRepo) EmployeeHasActiveAttendance(ctx context.Context, emp
 	if err := repo.db.WithContext(ctx).
 		Model(&entity.Attendance{}).
 		Where("employee_id = ?", employeeId).
-		Where("clock_in_at BETWEEN ? AND ?", utils.GetStartOfDay(), utils.GetEndOfDay()).
+		Where("clock_in_at > ?", time.Now().In(utils.CURRENT_LOC).Add(-24*time.Hour)).
 		Where("done_for_the_day = ?", false).
 		Where("closed_automatically IS NULL").
-		Where("clock_out_at NOT BETWEEN ? AND ? OR clock_out_at = ?",
-			utils.GetStartOfDay(),
-			utils.GetEndOfDay(),
-			emptyTime,
-		).Count(&count).Error; err != nil {
+		Where("(clock_out_at IS NULL OR clock_out_at = ?)", emptyTime).
+		Count(&count).Error; err != nil {
 		return false, err
 	}
 
@@ -129,12 +139,9 @@ func (repo *attendanceRepo) GetActiveAttendanceByEmployeeId(ctx context.Context,
 	if err := repo.db.WithContext(ctx).
 		Model(&attendance).
 		Where("employee_id = ?", employeeId).
-		Where("clock_in_at BETWEEN ? AND ?", utils.GetStartOfDay(), utils.GetEndOfDay()).
-		Where("clock_out_at NOT BETWEEN ? AND ? OR clock_out_at = ?",
-			utils.GetStartOfDay(),
-			utils.GetEndOfDay(),
-			time.Time{},
-		).
+		Where("clock_in_at > ?", time.Now().In(utils.CURRENT_LOC).Add(-24*time.Hour)).
+		Where("done_for_the_day = ?", false).
+		Where("(clock_out_at IS NULL OR clock_out_at = ?)", time.Time{}).
 		Order("created_at DESC").
 		First(&attendance).Error; err != nil {
 		return entity.Attendance{}, err
@@ -203,6 +210,9 @@ func (repo *attendanceRepo) GetOvertimeById(ctx context.Context, id string) (ent
 		Model(&overtime).
 		Preload("Attendance.Employee").
 		Preload("Manager").
+		Preload("ApprovalSteps", orderApprovalSteps).
+		Preload("ApprovalSteps.Approver").
+		Preload("ApprovalSteps.ActedBy").
 		First(&overtime, "id = ?", id).
 		Error; err != nil {
 		return overtime, err
@@ -211,50 +221,22 @@ func (repo *attendanceRepo) GetOvertimeById(ctx context.Context, id string) (ent
 	return overtime, nil
 }
 
-func (repo *attendanceRepo) GetIncomingOvertimeSubmissionsForManager(ctx context.Context, managerId string, q vo.IncomingOvertimeSubmissionsQuery) ([]entity.Overtime, vo.PaginationDTOResponse, error) {
-	pquery := q.Pagination.MustExtract()
-
-	var overtimes []entity.Overtime
-	var count int64
-
+// GetIncomingOvertimeSubmissionsForManager returns the overtimes
+// waiting on any of the approvers, which are the manager and the
+// managers delegating to the manager.
+func (repo *attendanceRepo) GetIncomingOvertimeSubmissionsForManager(ctx context.Context, approverIds []string, q vo.IncomingOvertimeSubmissionsQuery) ([]entity.Overtime, vo.PaginationDTOResponse, error) {
 	t := repo.db.WithContext(ctx).Model(&entity.Overtime{}).
 		Where("approved_by_manager IS NULL").
 		Where("action_by_manager_at IS NULL").
 		Where("closed_automatically IS NULL").
-		Where(`"overtimes"."manager_id" = ?`, managerId)
-
-	if q.Name != "" {
-		t = t.Joins(`INNER JOIN "attendances" ON "attendances"."id" = "overtimes"."attendance_id" INNER JOIN "employees" ON "employees"."id" = "attendances"."employee_id" AND "employees"."full_name" ILIKE ?`, utils.ToPatternMatching(q.Name))
-	}
-
-	if err := t.
-		Preload("Attendance.Employee").
-		Count(&count).
-		Order(utils.ToOrderSQL(pquery.OrderBy, pquery.Sort)).
-		Limit(pquery.Limit).
-		Offset(pquery.Offset).
-		Find(&overtimes).Error; err != nil {
-		return nil, vo.PaginationDTOResponse{}, err
-	}
+		// An overtime made before approval chains existed waits on its manager
+		Where(`(`+pendingApprovalStepSql("overtime_id", `"overtimes"."id"`, "s.approver_id IN ?")+` OR ("overtimes"."manager_id" IN ? AND NOT EXISTS (SELECT 1 FROM approval_steps AS s WHERE s.overtime_id = "overtimes"."id")))`, approverIds, approverIds)
 
-	return overtimes, pquery.Compress(count), nil
+	return repo.getIncomingOvertimeSubmissions(t, q)
 }
 
 func (repo *attendanceRepo) SaveProcessedOvertimeSubmissionByManager(ctx context.Context, overtime entity.Overtime) error {
-	if err := repo.db.
-		WithContext(ctx).
-		Model(&overtime).
-		Omit("attendance_id", "duration", "reason", "manager_id", "Attendance").
-		Updates(entity.Overtime{
-			ApprovedByManager: overtime.ApprovedByManager,
-			RejectionReason:   overtime.RejectionReason,
-			ActionByManagerAt: overtime.ActionByManagerAt,
-		}).
-		Error; err != nil {
-		return err
-	}
-
-	return nil
+	return repo.saveProcessedOvertimeSubmission(ctx, overtime)
 }
 
 func (repo *attendanceRepo) GetMyOvertimeSubmissions(ctx context.Context, employeeId string, q vo.MyOvertimeSubmissionsQuery) ([]entity.Overtime, vo.PaginationDTOResponse, error) {
@@ -302,7 +284,7 @@ func (repo *attendanceRepo) GetOvertimeSubmissionHistoryForManager(ctx context.C
 	var overtimes []entity.Overtime
 	var count int64
 
-	t := repo.db.WithContext(ctx).Model(&entity.Overtime{}).Where(`"overtimes"."manager_id" = ?`, managerId)
+	t := repo.db.WithContext(ctx).Model(&entity.Overtime{}).Where(`("overtimes"."manager_id" = ? OR EXISTS (SELECT 1 FROM approval_steps AS s WHERE s.overtime_id = "overtimes"."id" AND (s.approver_id = ? OR s.acted_by_id = ?)))`, managerId, managerId, managerId)
 
 	switch strings.ToLower(q.Status) {
 	case "approved":
@@ -338,6 +320,20 @@ func (repo *attendanceRepo) GetOvertimeSubmissionHistoryForManager(ctx context.C
 	return overtimes, pquery.Compress(count), nil
 }
 
+func (repo *attendanceRepo) GetIncomingOvertimeSubmissionsForHr(ctx context.Context, q vo.IncomingOvertimeSubmissionsQuery) ([]entity.Overtime, vo.PaginationDTOResponse, error) {
+	t := repo.db.WithContext(ctx).Model(&entity.Overtime{}).
+		Where("approved_by_manager IS NULL").
+		Where("action_by_manager_at IS NULL").
+		Where("closed_automatically IS NULL").
+		Where(pendingApprovalStepSql("overtime_id", `"overtimes"."id"`, "s.role = ?"), entity.APPROVER_HR)
+
+	return repo.getIncomingOvertimeSubmissions(t, q)
+}
+
+func (repo *attendanceRepo) SaveProcessedOvertimeSubmissionByHr(ctx context.Context, overtime entity.Overtime) error {
+	return repo.saveProcessedOvertimeSubmission(ctx, overtime)
+}
+
 func (repo *attendanceRepo) GetOvertimeSubmissionHistoryForHr(ctx context.Context, q vo.LeaveProposalHistoryQuery) ([]entity.Overtime, vo.PaginationDTOResponse, error) {
 	pquery := q.Pagination.MustExtract()
 	tquery, _ := q.TimeQuery.Extract()
@@ -504,7 +500,13 @@ func (repo *attendanceRepo) GetEmployeesAttendanceHistory(ctx context.Context, q
 		t = t.Where(`"attendances"."closed_automatically" IS TRUE`)
 	}
 
+	if q.Outside {
+		t = t.Where(`("attendances"."clock_in_outside_office" IS TRUE OR "attendances"."clock_out_outside_office" IS TRUE)`)
+	}
+
 	if err := t.Preload("Employee").
+		Preload("ClockInOffice").
+		Preload("ClockOutOffice").
 		Count(&count).
 		Order(utils.ToOrderSQL(pquery.OrderBy, pquery.Sort)).
 		Limit(pquery.Limit).
@@ -542,7 +544,13 @@ func (repo *attendanceRepo) GetEmployeesTodaysAttendances(ctx context.Context, q
 		t = t.Where(`"attendances"."closed_automatically" IS TRUE`)
 	}
 
+	if q.Outside {
+		t = t.Where(`("attendances"."clock_in_outside_office" IS TRUE OR "attendances"."clock_out_outside_office" IS TRUE)`)
+	}
+
 	if err := t.Preload("Employee.Job").
+		Preload("ClockInOffice").
+		Preload("ClockOutOffice").
 		Count(&count).
 		Order(utils.ToOrderSQL(pquery.OrderBy, pquery.Sort)).
 		Limit(pquery.Limit).
@@ -553,3 +561,116 @@ func (repo *attendanceRepo) GetEmployeesTodaysAttendances(ctx context.Context, q
 
 	return attendances, pquery.Compress(count), nil
 }
+
+func (repo *attendanceRepo) getIncomingOvertimeSubmissions(t *gorm.DB, q vo.IncomingOvertimeSubmissionsQuery) ([]entity.Overtime, vo.PaginationDTOResponse, error) {
+	pquery := q.Pagination.MustExtract()
+
+	var overtimes []entity.Overtime
+	var count int64
+
+	if q.Name != "" {
+		t = t.Joins(`INNER JOIN "attendances" ON "attendances"."id" = "overtimes"."attendance_id" INNER JOIN "employees" ON "employees"."id" = "attendances"."employee_id" AND "employees"."full_name" ILIKE ?`, utils.ToPatternMatching(q.Name))
+	}
+
+	if err := t.
+		Preload("Attendance.Employee").
+		Count(&count).
+		Order(utils.ToOrderSQL(pquery.OrderBy, pquery.Sort)).
+		Limit(pquery.Limit).
+		Offset(pquery.Offset).
+		Find(&overtimes).Error; err != nil {
+		return nil, vo.PaginationDTOResponse{}, err
+	}
+
+	return overtimes, pquery.Compress(count), nil
+}
+
+/*
+*********************************
+ACTOR: SYSTEM
+*********************************
+*/
+
+// GetOpenAttendances retrieves the attendances clocked in before the
+// given time and never clocked out.
+func (repo *attendanceRepo) GetOpenAttendances(ctx context.Context, before time.Time) ([]entity.Attendance, error) {
+	var attendances []entity.Attendance
+
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.Attendance{}).
+		Where("clock_in_at < ?", before).
+		Where("done_for_the_day = ?", false).
+		Where("closed_automatically IS NULL").
+		Where("(clock_out_at IS NULL OR clock_out_at = ?)", time.Time{}).
+		Find(&attendances).Error; err != nil {
+		return nil, err
+	}
+
+	return attendances, nil
+}
+
+// CloseAttendances closes the attendances, unless they have been
+// clocked out in the meantime.
+func (repo *attendanceRepo) CloseAttendances(ctx context.Context, ids []string) (int64, error) {
+	truee := true
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	res := repo.db.WithContext(ctx).
+		Model(&entity.Attendance{}).
+		Where("id IN ?", ids).
+		Where("done_for_the_day = ?", false).
+		Where("closed_automatically IS NULL").
+		Where("(clock_out_at IS NULL OR clock_out_at = ?)", time.Time{}).
+		Updates(map[string]any{
+			"done_for_the_day":     true,
+			"closed_automatically": &truee,
+		})
+
+	return res.RowsAffected, res.Error
+}
+
+// CloseStaleOvertimeSubmissions closes the overtimes submitted before
+// the given time and still waiting for a decision.
+func (repo *attendanceRepo) CloseStaleOvertimeSubmissions(ctx context.Context, before time.Time) (int64, error) {
+	truee := true
+
+	res := repo.db.WithContext(ctx).
+		Model(&entity.Overtime{}).
+		Where("created_at < ?", before).
+		Where("approved_by_manager IS NULL").
+		Where("closed_automatically IS NULL").
+		Update("closed_automatically", &truee)
+
+	return res.RowsAffected, res.Error
+}
+
+// saveProcessedOvertimeSubmission saves the decision of a step of
+// the approval chain, together with the final decision once made.
+func (repo *attendanceRepo) saveProcessedOvertimeSubmission(ctx context.Context, overtime entity.Overtime) error {
+	tx := repo.db.WithContext(ctx).Begin()
+
+	if overtime.ApprovedByManager != nil {
+		if err := tx.
+			Model(&overtime).
+			Omit("attendance_id", "duration", "reason", "manager_id", "Attendance", "ApprovalSteps").
+			Updates(entity.Overtime{
+				ApprovedByManager: overtime.ApprovedByManager,
+				RejectionReason:   overtime.RejectionReason,
+				ActionByManagerAt: overtime.ActionByManagerAt,
+			}).
+			Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := saveApprovalSteps(tx, overtime.ApprovalSteps); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
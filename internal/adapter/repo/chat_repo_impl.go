@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/entity/vo"
 	"sinarlog.com/internal/utils"
 )
 
@@ -36,10 +37,16 @@ func (repo *chatRepo) FindOrCreateRoom(ctx context.Context, room entity.Room) (e
 	return room, nil
 }
 
+// FindRoom finds the direct room between the participants. The rooms
+// created before the kinds have none and are direct rooms too.
 func (repo *chatRepo) FindRoom(ctx context.Context, room entity.Room) (entity.Room, error) {
 	if err := repo.roomColl.FindOne(ctx, bson.D{
 		{Key: "participants", Value: bson.D{
 			{Key: "$all", Value: room.Participants},
+			{Key: "$size", Value: len(room.Participants)},
+		}},
+		{Key: "kind", Value: bson.D{
+			{Key: "$nin", Value: bson.A{entity.GROUP_ROOM, entity.TEAM_ROOM}},
 		}},
 	}).Decode(&room); err != nil {
 		return room, err
@@ -64,6 +71,10 @@ func (repo *chatRepo) FindRoomByID(ctx context.Context, id string) (entity.Room,
 }
 
 func (repo *chatRepo) CreateRoom(ctx context.Context, room entity.Room) (entity.Room, error) {
+	now := primitive.NewDateTimeFromTime(time.Now().In(utils.CURRENT_LOC))
+	room.CreatedAt = now
+	room.UpdatedAt = now
+
 	res, err := repo.roomColl.InsertOne(ctx, room, options.InsertOne().SetComment("a new room has been created"))
 	if err != nil {
 		return room, err
@@ -74,45 +85,381 @@ func (repo *chatRepo) CreateRoom(ctx context.Context, room entity.Room) (entity.
 	return room, nil
 }
 
-func (repo *chatRepo) GetChatsByRoomId(ctx context.Context, roomId primitive.ObjectID, readerId string) ([]entity.Chat, error) {
-	var chats []entity.Chat
+// FindTeamRoom finds the team room of the manager.
+func (repo *chatRepo) FindTeamRoom(ctx context.Context, managerId string) (entity.Room, error) {
+	var room entity.Room
 
-	cursor, err := repo.chatColl.Find(ctx,
-		bson.D{{Key: "roomId", Value: roomId}},
-		options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}}),
-		options.Find().SetLimit(30),
-	)
+	if err := repo.roomColl.FindOne(ctx, bson.D{
+		{Key: "kind", Value: entity.TEAM_ROOM},
+		{Key: "teamOf", Value: managerId},
+	}).Decode(&room); err != nil {
+		return room, err
+	}
+
+	return room, nil
+}
+
+func (repo *chatRepo) GetRoomsByParticipant(ctx context.Context, employeeId string) ([]entity.Room, error) {
+	var rooms []entity.Room
+
+	cursor, err := repo.roomColl.Find(ctx, bson.D{{Key: "participants", Value: employeeId}})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cursor.All(ctx, &rooms); err != nil {
+		return nil, err
+	}
+
+	return rooms, nil
+}
+
+// GetRoomSummaries retrieves the rooms the employee takes part in along
+// with their last chat and how many chats of the others the employee
+// has not read.
+func (repo *chatRepo) GetRoomSummaries(ctx context.Context, employeeId string) ([]vo.RoomSummary, error) {
+	rooms, err := repo.GetRoomsByParticipant(ctx, employeeId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rooms) == 0 {
+		return []vo.RoomSummary{}, nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(rooms))
+	for _, v := range rooms {
+		ids = append(ids, v.Id)
+	}
+
+	cursor, err := repo.chatColl.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "roomId", Value: bson.D{{Key: "$in", Value: ids}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$roomId"},
+			{Key: "lastChat", Value: bson.D{{Key: "$first", Value: "$$ROOT"}}},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []struct {
+		RoomId   primitive.ObjectID `bson:"_id"`
+		LastChat entity.Chat        `bson:"lastChat"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	// Each room has its own read cursor
+	unreadFilters := make(bson.A, 0, len(rooms))
+	for _, v := range rooms {
+		unreadFilters = append(unreadFilters, unreadChatsFilter(v, employeeId))
+	}
+
+	cursor, err = repo.chatColl.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "$or", Value: unreadFilters}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$roomId"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	defer cursor.Close(ctx)
+	var unreads []struct {
+		RoomId primitive.ObjectID `bson:"_id"`
+		Count  int64              `bson:"count"`
+	}
+	if err := cursor.All(ctx, &unreads); err != nil {
+		return nil, err
+	}
+
+	unreadCounts := make(map[primitive.ObjectID]int64, len(unreads))
+	for _, v := range unreads {
+		unreadCounts[v.RoomId] = v.Count
+	}
 
-	for cursor.Next(ctx) {
-		var chat entity.Chat
-		if err := cursor.Decode(&chat); err != nil {
-			return nil, err
+	lastChats := make(map[primitive.ObjectID]entity.Chat, len(groups))
+	for _, v := range groups {
+		lastChats[v.RoomId] = v.LastChat
+	}
+
+	summaries := make([]vo.RoomSummary, 0, len(rooms))
+	for _, v := range rooms {
+		summary := vo.RoomSummary{Room: v, UnreadCount: unreadCounts[v.Id]}
+		if lastChat, ok := lastChats[v.Id]; ok {
+			summary.LastChat = &lastChat
 		}
-		chat.Read = true
-		chats = append(chats, chat)
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+// UpdateRoom saves the metadata, participants and admins of the room.
+func (repo *chatRepo) UpdateRoom(ctx context.Context, room entity.Room) error {
+	if _, err := repo.roomColl.UpdateByID(ctx, room.Id, bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "name", Value: room.Name},
+			{Key: "avatar", Value: room.Avatar},
+			{Key: "topic", Value: room.Topic},
+			{Key: "participants", Value: room.Participants},
+			{Key: "admins", Value: room.Admins},
+			{Key: "updatedAt", Value: primitive.NewDateTimeFromTime(time.Now().In(utils.CURRENT_LOC))},
+		}},
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateRoomMetadata saves the name, avatar and topic of the room only,
+// leaving its members to their own updates.
+func (repo *chatRepo) UpdateRoomMetadata(ctx context.Context, room entity.Room) error {
+	if _, err := repo.roomColl.UpdateByID(ctx, room.Id, bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "name", Value: room.Name},
+			{Key: "avatar", Value: room.Avatar},
+			{Key: "topic", Value: room.Topic},
+			{Key: "updatedAt", Value: primitive.NewDateTimeFromTime(time.Now().In(utils.CURRENT_LOC))},
+		}},
+	}); err != nil {
+		return err
 	}
 
-	if _, err := repo.chatColl.UpdateMany(ctx,
-		bson.D{
+	return nil
+}
+
+func (repo *chatRepo) AddRoomParticipants(ctx context.Context, roomId primitive.ObjectID, employeeIds []string) error {
+	if _, err := repo.roomColl.UpdateByID(ctx, roomId, bson.D{
+		{Key: "$addToSet", Value: bson.D{
+			{Key: "participants", Value: bson.D{{Key: "$each", Value: employeeIds}}},
+		}},
+		{Key: "$set", Value: bson.D{
+			{Key: "updatedAt", Value: primitive.NewDateTimeFromTime(time.Now().In(utils.CURRENT_LOC))},
+		}},
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RemoveRoomParticipant takes the employee out of the room, along with
+// their admin rights.
+func (repo *chatRepo) RemoveRoomParticipant(ctx context.Context, roomId primitive.ObjectID, employeeId string) error {
+	if _, err := repo.roomColl.UpdateByID(ctx, roomId, bson.D{
+		{Key: "$pull", Value: bson.D{
+			{Key: "participants", Value: employeeId},
+			{Key: "admins", Value: employeeId},
+		}},
+		{Key: "$set", Value: bson.D{
+			{Key: "updatedAt", Value: primitive.NewDateTimeFromTime(time.Now().In(utils.CURRENT_LOC))},
+		}},
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *chatRepo) UpdateRoomAdmins(ctx context.Context, roomId primitive.ObjectID, admins []string) error {
+	if _, err := repo.roomColl.UpdateByID(ctx, roomId, bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "admins", Value: admins},
+			{Key: "updatedAt", Value: primitive.NewDateTimeFromTime(time.Now().In(utils.CURRENT_LOC))},
+		}},
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetChatsByRoomId retrieves a page of the chats of the room, from the
+// oldest. The chats are paged by their ids, which follow the order they
+// are sent in.
+func (repo *chatRepo) GetChatsByRoomId(ctx context.Context, roomId primitive.ObjectID, q vo.ChatPageQuery) (vo.ChatPage, error) {
+	var page vo.ChatPage
+
+	if q.After != "" {
+		after, err := primitive.ObjectIDFromHex(q.After)
+		if err != nil {
+			return page, err
+		}
+
+		chats, hasNewer, err := repo.findChats(ctx, bson.D{
 			{Key: "roomId", Value: roomId},
-			{Key: "sender", Value: bson.D{
-				{Key: "$ne", Value: readerId},
-			}},
-		},
-		bson.D{
-			{Key: "$set", Value: bson.D{
-				{Key: "read", Value: true},
-			}},
-		},
-	); err != nil {
-		return nil, err
+			{Key: "_id", Value: bson.D{{Key: "$gt", Value: after}}},
+		}, 1, q.PageSize())
+		if err != nil {
+			return page, err
+		}
+
+		return vo.ChatPage{Chats: chats, HasOlder: true, HasNewer: hasNewer}, nil
+	}
+
+	filter := bson.D{{Key: "roomId", Value: roomId}}
+	if q.Before != "" {
+		before, err := primitive.ObjectIDFromHex(q.Before)
+		if err != nil {
+			return page, err
+		}
+		filter = append(filter, bson.E{Key: "_id", Value: bson.D{{Key: "$lt", Value: before}}})
+	}
+
+	chats, hasOlder, err := repo.findChats(ctx, filter, -1, q.PageSize())
+	if err != nil {
+		return page, err
+	}
+	reverseChats(chats)
+
+	return vo.ChatPage{Chats: chats, HasOlder: hasOlder, HasNewer: q.Before != ""}, nil
+}
+
+// GetChatContext retrieves the chat along with the chats around it,
+// up to the size on each side, from the oldest.
+func (repo *chatRepo) GetChatContext(ctx context.Context, roomId, chatId primitive.ObjectID, size int) (vo.ChatPage, error) {
+	var page vo.ChatPage
+
+	older, hasOlder, err := repo.findChats(ctx, bson.D{
+		{Key: "roomId", Value: roomId},
+		{Key: "_id", Value: bson.D{{Key: "$lte", Value: chatId}}},
+	}, -1, size+1)
+	if err != nil {
+		return page, err
+	}
+	reverseChats(older)
+
+	newer, hasNewer, err := repo.findChats(ctx, bson.D{
+		{Key: "roomId", Value: roomId},
+		{Key: "_id", Value: bson.D{{Key: "$gt", Value: chatId}}},
+	}, 1, size)
+	if err != nil {
+		return page, err
+	}
+
+	return vo.ChatPage{
+		Chats:    append(older, newer...),
+		HasOlder: hasOlder,
+		HasNewer: hasNewer,
+	}, nil
+}
+
+// SearchChats searches the words among the chats of the rooms through
+// the text index of the chats, from the latest.
+func (repo *chatRepo) SearchChats(ctx context.Context, roomIds []primitive.ObjectID, q vo.ChatSearchQuery) (vo.ChatPage, error) {
+	var page vo.ChatPage
+
+	filter := bson.D{
+		{Key: "$text", Value: bson.D{{Key: "$search", Value: q.Query}}},
+		{Key: "roomId", Value: bson.D{{Key: "$in", Value: roomIds}}},
+	}
+	if q.Before != "" {
+		before, err := primitive.ObjectIDFromHex(q.Before)
+		if err != nil {
+			return page, err
+		}
+		filter = append(filter, bson.E{Key: "_id", Value: bson.D{{Key: "$lt", Value: before}}})
+	}
+
+	chats, hasOlder, err := repo.findChats(ctx, filter, -1, q.PageSize())
+	if err != nil {
+		return page, err
+	}
+
+	return vo.ChatPage{Chats: chats, HasOlder: hasOlder, HasNewer: q.Before != ""}, nil
+}
+
+// findChats retrieves up to the limit of chats in the order of their
+// ids, and tells whether there are more.
+func (repo *chatRepo) findChats(ctx context.Context, filter bson.D, order, limit int) ([]entity.Chat, bool, error) {
+	chats := []entity.Chat{}
+
+	cursor, err := repo.chatColl.Find(ctx, filter,
+		options.Find().
+			SetSort(bson.D{{Key: "_id", Value: order}}).
+			SetLimit(int64(limit+1)),
+	)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := cursor.All(ctx, &chats); err != nil {
+		return nil, false, err
+	}
+
+	if len(chats) > limit {
+		return chats[:limit], true, nil
+	}
+
+	return chats, false, nil
+}
+
+func reverseChats(chats []entity.Chat) {
+	for i, j := 0, len(chats)-1; i < j; i, j = i+1, j-1 {
+		chats[i], chats[j] = chats[j], chats[i]
+	}
+}
+
+func (repo *chatRepo) FindChatById(ctx context.Context, roomId primitive.ObjectID, chatId string) (entity.Chat, error) {
+	var chat entity.Chat
+
+	_id, err := primitive.ObjectIDFromHex(chatId)
+	if err != nil {
+		return chat, err
+	}
+
+	if err := repo.chatColl.FindOne(ctx, bson.D{
+		{Key: "_id", Value: _id},
+		{Key: "roomId", Value: roomId},
+	}).Decode(&chat); err != nil {
+		return chat, err
+	}
+
+	return chat, nil
+}
+
+// UpdateReadCursor moves the read cursor of the participant up to the
+// chat, never back.
+func (repo *chatRepo) UpdateReadCursor(ctx context.Context, roomId primitive.ObjectID, employeeId string, chatId primitive.ObjectID) error {
+	if _, err := repo.roomColl.UpdateByID(ctx, roomId, bson.D{
+		{Key: "$max", Value: bson.D{
+			{Key: "readCursors." + employeeId, Value: chatId},
+		}},
+	}); err != nil {
+		return err
 	}
-	return chats, nil
+
+	return nil
+}
+
+// CountUnreadChats counts the chats of the others in the room after the
+// read cursor of the employee.
+func (repo *chatRepo) CountUnreadChats(ctx context.Context, room entity.Room, employeeId string) (int64, error) {
+	return repo.chatColl.CountDocuments(ctx, unreadChatsFilter(room, employeeId))
+}
+
+// unreadChatsFilter matches the chats of the others in the room after
+// the read cursor of the employee. Without a cursor, the chats from
+// before the cursors tell whether they are read themselves.
+func unreadChatsFilter(room entity.Room, employeeId string) bson.D {
+	filter := bson.D{
+		{Key: "roomId", Value: room.Id},
+		{Key: "sender", Value: bson.D{{Key: "$ne", Value: employeeId}}},
+	}
+
+	if cursor, ok := room.ReadCursors[employeeId]; ok {
+		return append(filter, bson.E{Key: "_id", Value: bson.D{{Key: "$gt", Value: cursor}}})
+	}
+
+	return append(filter, bson.E{Key: "read", Value: bson.D{{Key: "$ne", Value: true}}})
 }
 
 func (repo *chatRepo) CreateNewMessage(ctx context.Context, userId, roomId, message string) (entity.Chat, error) {
@@ -125,7 +472,6 @@ func (repo *chatRepo) CreateNewMessage(ctx context.Context, userId, roomId, mess
 		RoomId:  _id,
 		Sender:  userId,
 		Message: message,
-		Read:    false,
 		Timestamp: primitive.Timestamp{
 			T: uint32(time.Now().In(utils.CURRENT_LOC).Unix()),
 			I: 0,
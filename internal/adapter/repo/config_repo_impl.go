@@ -2,6 +2,8 @@ package repo
 
 import (
 	"context"
+	"strconv"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
@@ -24,6 +26,7 @@ func (repo *configRepo) GetConfiguration(ctx context.Context) (entity.Configurat
 
 	if err := repo.db.WithContext(ctx).
 		Model(&config).
+		Preload("OfficeLocations").
 		First(&config).Error; err != nil {
 		return config, err
 	}
@@ -48,6 +51,9 @@ func (repo *configRepo) SaveNextDayChangesAndLogs(ctx context.Context, config en
 		p.HSet(ctx, entity.CONFIG_KEY_NEXT_DAY, "officeEndTimeHour", config.OfficeEndTimeHour)
 		p.HSet(ctx, entity.CONFIG_KEY_NEXT_DAY, "officeEndTimeMinute", config.OfficeEndTimeMinute)
 		p.HSet(ctx, entity.CONFIG_KEY_NEXT_DAY, "acceptanceAttendanceInterval", config.AcceptanceAttendanceInterval)
+		p.HSet(ctx, entity.CONFIG_KEY_NEXT_DAY, "geofenceMode", string(config.GeofenceMode))
+		p.HSet(ctx, entity.CONFIG_KEY_NEXT_DAY, "whenApplied", logs.WhenApplied.Unix())
+		p.HSet(ctx, entity.CONFIG_KEY_NEXT_DAY, "changeLogId", logs.Id)
 		return nil
 	}); err != nil {
 		tx.Rollback()
@@ -70,6 +76,8 @@ func (repo *configRepo) SaveNextMonthChangesAndLogs(ctx context.Context, config
 		p.HSet(ctx, entity.CONFIG_KEY_NEXT_MONTH, "acceptanceLeaveInterval", config.AcceptanceLeaveInterval)
 		p.HSet(ctx, entity.CONFIG_KEY_NEXT_MONTH, "defaultYearlyQuota", config.DefaultYearlyQuota)
 		p.HSet(ctx, entity.CONFIG_KEY_NEXT_MONTH, "defaultMarriageQuota", config.DefaultMarriageQuota)
+		p.HSet(ctx, entity.CONFIG_KEY_NEXT_MONTH, "whenApplied", logs.WhenApplied.Unix())
+		p.HSet(ctx, entity.CONFIG_KEY_NEXT_MONTH, "changeLogId", logs.Id)
 		return nil
 	}); err != nil {
 		tx.Rollback()
@@ -80,6 +88,164 @@ func (repo *configRepo) SaveNextMonthChangesAndLogs(ctx context.Context, config
 	return nil
 }
 
+// GetPendingConfigChanges returns the changes of the scope held in
+// Redis along with their change logs. It returns false if nothing is
+// pending. Changes pending without a due time are due right away.
+func (repo *configRepo) GetPendingConfigChanges(ctx context.Context, scope entity.ConfigChangeScope) (entity.PendingConfigChanges, bool, error) {
+	pending := entity.PendingConfigChanges{Scope: scope}
+
+	values, err := repo.rdis.HGetAll(ctx, scope.Key()).Result()
+	if err != nil || len(values) == 0 {
+		return pending, false, err
+	}
+
+	if v, ok := values["whenApplied"]; ok {
+		whenApplied, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return pending, false, err
+		}
+		pending.WhenApplied = time.Unix(whenApplied, 0).In(utils.CURRENT_LOC)
+	}
+	pending.ChangeLogId = values["changeLogId"]
+
+	if err := repo.rdis.HGetAll(ctx, scope.Key()).Scan(&pending.Configuration); err != nil {
+		return pending, false, err
+	}
+
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.ConfigurationChangesLog{}).
+		Preload("UpdatedBy.Job").
+		Where("scope = ? AND applied_at IS NULL AND cancelled_at IS NULL", scope).
+		Order("created_at ASC").
+		Find(&pending.Logs).Error; err != nil {
+		return pending, false, err
+	}
+
+	return pending, true, nil
+}
+
+// ApplyConfigChanges moves the pending changes of the scope into the
+// configuration once they are due at the given time and marks their
+// change logs as applied, then removes them from Redis once
+// committed. It returns whether the changes were applied.
+func (repo *configRepo) ApplyConfigChanges(ctx context.Context, scope entity.ConfigChangeScope, at time.Time) (bool, error) {
+	pending, ok, err := repo.GetPendingConfigChanges(ctx, scope)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if at.Before(pending.WhenApplied) {
+		return false, nil
+	}
+
+	config, err := repo.GetConfiguration(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	values := pending.Configuration
+	changes := make(map[string]any)
+	switch scope {
+	case entity.CONFIG_NEXT_DAY:
+		start, end := config.OfficeStartTime, config.OfficeEndTime
+		changes["office_start_time"] = time.Date(start.Year(), start.Month(), start.Day(), values.OfficeStartTimeHour, values.OfficeStartTimeMinute, 0, 0, utils.CURRENT_LOC)
+		changes["office_end_time"] = time.Date(end.Year(), end.Month(), end.Day(), values.OfficeEndTimeHour, values.OfficeEndTimeMinute, 0, 0, utils.CURRENT_LOC)
+		changes["acceptance_attendance_interval"] = values.AcceptanceAttendanceInterval
+		if values.GeofenceMode != "" {
+			changes["geofence_mode"] = values.GeofenceMode
+		}
+	case entity.CONFIG_NEXT_MONTH:
+		changes["acceptance_leave_interval"] = values.AcceptanceLeaveInterval
+		changes["default_yearly_quota"] = values.DefaultYearlyQuota
+		changes["default_marriage_quota"] = values.DefaultMarriageQuota
+	}
+
+	tx := repo.db.WithContext(ctx).Begin()
+
+	if err := tx.Model(&config).Updates(changes).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	// Only the logs read along with the changes are applied, those of
+	// changes saved in the meantime stay pending
+	logIds := make([]string, 0, len(pending.Logs))
+	for _, v := range pending.Logs {
+		logIds = append(logIds, v.Id)
+	}
+
+	if len(logIds) > 0 {
+		if err := tx.Model(&entity.ConfigurationChangesLog{}).
+			Where("id IN ? AND applied_at IS NULL AND cancelled_at IS NULL", logIds).
+			Update("applied_at", at).Error; err != nil {
+			tx.Rollback()
+			return false, err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return false, err
+	}
+
+	// The changes are removed only once committed so that a failed
+	// commit leaves them pending for the next run
+	if err := repo.clearPendingConfigChanges(ctx, pending); err != nil {
+		return true, err
+	}
+
+	return true, nil
+}
+
+// clearPendingConfigChanges removes the pending changes of the scope
+// from Redis unless they were replaced since they were read. Each save
+// writes the id of its change log, hence changes saved again within
+// the same second are still told apart.
+func (repo *configRepo) clearPendingConfigChanges(ctx context.Context, pending entity.PendingConfigChanges) error {
+	key := pending.Scope.Key()
+
+	return repo.rdis.Watch(ctx, func(rtx *redis.Tx) error {
+		changeLogId, err := rtx.HGet(ctx, key, "changeLogId").Result()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		if changeLogId != pending.ChangeLogId {
+			return nil
+		}
+
+		_, err = rtx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			p.Del(ctx, key)
+			return nil
+		})
+		return err
+	}, key)
+}
+
+// CancelPendingConfigChanges marks the change logs of the pending
+// changes as cancelled, then discards the changes from Redis once
+// committed unless they were replaced since they were read.
+func (repo *configRepo) CancelPendingConfigChanges(ctx context.Context, pending entity.PendingConfigChanges, hrId string, at time.Time) error {
+	// Only the logs read along with the changes are cancelled, those
+	// of changes saved in the meantime stay pending
+	logIds := make([]string, 0, len(pending.Logs))
+	for _, v := range pending.Logs {
+		logIds = append(logIds, v.Id)
+	}
+
+	if len(logIds) > 0 {
+		if err := repo.db.WithContext(ctx).
+			Model(&entity.ConfigurationChangesLog{}).
+			Where("id IN ? AND applied_at IS NULL AND cancelled_at IS NULL", logIds).
+			Updates(map[string]any{
+				"cancelled_at":    at,
+				"cancelled_by_id": hrId,
+			}).Error; err != nil {
+			return err
+		}
+	}
+
+	return repo.clearPendingConfigChanges(ctx, pending)
+}
+
 func (repo *configRepo) GetConfigChangesLogs(ctx context.Context, q vo.CommonQuery) ([]entity.ConfigurationChangesLog, vo.PaginationDTOResponse, error) {
 	pquery := q.Pagination.MustExtract()
 
@@ -99,3 +265,43 @@ func (repo *configRepo) GetConfigChangesLogs(ctx context.Context, q vo.CommonQue
 
 	return changes, pquery.Compress(count), nil
 }
+
+func (repo *configRepo) GetOfficeLocations(ctx context.Context) ([]entity.OfficeLocation, error) {
+	var offices []entity.OfficeLocation
+
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.OfficeLocation{}).
+		Order("name ASC").
+		Find(&offices).Error; err != nil {
+		return nil, err
+	}
+
+	return offices, nil
+}
+
+func (repo *configRepo) GetOfficeLocationById(ctx context.Context, id string) (entity.OfficeLocation, error) {
+	var office entity.OfficeLocation
+
+	if err := repo.db.WithContext(ctx).
+		Model(&office).
+		First(&office, "id = ?", id).Error; err != nil {
+		return office, err
+	}
+
+	return office, nil
+}
+
+func (repo *configRepo) CreateOfficeLocation(ctx context.Context, office entity.OfficeLocation) error {
+	return repo.db.WithContext(ctx).Model(&office).Create(&office).Error
+}
+
+func (repo *configRepo) UpdateOfficeLocation(ctx context.Context, office entity.OfficeLocation) error {
+	return repo.db.WithContext(ctx).
+		Model(&office).
+		Select("name", "center", "radius", "polygon").
+		Updates(&office).Error
+}
+
+func (repo *configRepo) DeleteOfficeLocation(ctx context.Context, id string) error {
+	return repo.db.WithContext(ctx).Delete(&entity.OfficeLocation{}, "id = ?", id).Error
+}
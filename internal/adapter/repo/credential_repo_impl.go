@@ -2,10 +2,17 @@ package repo
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/utils"
 )
 
 type credentialRepo struct {
@@ -22,7 +29,7 @@ func (repo *credentialRepo) GetEmployeeByEmail(ctx context.Context, email string
 
 	if err := repo.db.WithContext(ctx).
 		Model(&employee).
-		Preload("Role").
+		Preload("Role.Permissions").
 		Preload("Job").
 		First(&employee, "email = ?", email).
 		Error; err != nil {
@@ -37,7 +44,8 @@ func (repo *credentialRepo) GetEmployeeByIdV2(ctx context.Context, id string) (e
 
 	if err := repo.db.WithContext(ctx).
 		Model(&employee).
-		Preload("Role").
+		Preload("Role.Permissions").
+		Preload("Job").
 		Take(&employee, "id = ?", id).Error; err != nil {
 		return employee, err
 	}
@@ -45,14 +53,655 @@ func (repo *credentialRepo) GetEmployeeByIdV2(ctx context.Context, id string) (e
 	return employee, nil
 }
 
+// UpdateEmployeePassword changes the password of the employee and
+// bumps the token version, which revokes the tokens issued before.
 func (repo *credentialRepo) UpdateEmployeePassword(ctx context.Context, employee entity.Employee) error {
 	if err := repo.db.
 		WithContext(ctx).
 		Model(&employee).
 		Where("id = ?", employee.Id).
-		Update("password", employee.Password).Error; err != nil {
+		Updates(map[string]any{
+			"password":      employee.Password,
+			"token_version": gorm.Expr("token_version + 1"),
+		}).Error; err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (repo *credentialRepo) BumpTokenVersion(ctx context.Context, employeeId string) error {
+	return repo.db.WithContext(ctx).
+		Model(&entity.Employee{}).
+		Where("id = ?", employeeId).
+		Update("token_version", gorm.Expr("token_version + 1")).Error
+}
+
+/*
+*************************************************
+SESSIONS
+*************************************************
+*/
+
+// rotateRefreshTokenScript replaces the refresh token of a session
+// only if the previous one is still the current one, hence a refresh
+// token is never accepted twice.
+var rotateRefreshTokenScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "refreshToken") == ARGV[1] then
+	redis.call("HSET", KEYS[1], "refreshToken", ARGV[2], "lastSeenAt", ARGV[3], "expiresAt", ARGV[4])
+	redis.call("PEXPIREAT", KEYS[1], ARGV[5])
+	return 1
+end
+return 0`)
+
+func sessionKey(id string) string {
+	return fmt.Sprintf("session:%s", id)
+}
+
+func employeeSessionsKey(employeeId string) string {
+	return fmt.Sprintf("%s:sessions", employeeId)
+}
+
+func (repo *credentialRepo) CreateSession(ctx context.Context, session entity.Session) error {
+	_, err := repo.redis.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HSet(ctx, sessionKey(session.Id),
+			"id", session.Id,
+			"employeeId", session.EmployeeID,
+			"refreshToken", session.RefreshTokenHash,
+			"device", session.Device,
+			"ip", session.IP,
+			"createdAt", session.CreatedAt.Unix(),
+			"lastSeenAt", session.LastSeenAt.Unix(),
+			"expiresAt", session.ExpiresAt.Unix(),
+			"tokenVersion", session.TokenVersion,
+		)
+		p.ExpireAt(ctx, sessionKey(session.Id), session.ExpiresAt)
+		p.SAdd(ctx, employeeSessionsKey(session.EmployeeID), session.Id)
+		return nil
+	})
+
+	return err
+}
+
+func (repo *credentialRepo) GetSessionById(ctx context.Context, id string) (entity.Session, error) {
+	values, err := repo.redis.HGetAll(ctx, sessionKey(id)).Result()
+	if err != nil {
+		return entity.Session{}, err
+	}
+	if len(values) == 0 {
+		return entity.Session{}, fmt.Errorf("session not found")
+	}
+
+	return parseSession(values)
+}
+
+// GetSessionsByEmployeeId returns the sessions of the employee which
+// have not expired, the most recently seen first. The expired ones
+// are forgotten along the way.
+func (repo *credentialRepo) GetSessionsByEmployeeId(ctx context.Context, employeeId string) ([]entity.Session, error) {
+	ids, err := repo.redis.SMembers(ctx, employeeSessionsKey(employeeId)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []entity.Session
+	var expired []any
+	for _, id := range ids {
+		values, err := repo.redis.HGetAll(ctx, sessionKey(id)).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(values) == 0 {
+			expired = append(expired, id)
+			continue
+		}
+
+		session, err := parseSession(values)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	if len(expired) != 0 {
+		if err := repo.redis.SRem(ctx, employeeSessionsKey(employeeId), expired...).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+// RotateSessionRefreshToken stores the new refresh token hash and
+// expiry of the session if prevHash is still its refresh token hash.
+// It returns false otherwise.
+func (repo *credentialRepo) RotateSessionRefreshToken(ctx context.Context, session entity.Session, prevHash string) (bool, error) {
+	rotated, err := rotateRefreshTokenScript.Run(ctx, repo.redis,
+		[]string{sessionKey(session.Id)},
+		prevHash,
+		session.RefreshTokenHash,
+		session.LastSeenAt.Unix(),
+		session.ExpiresAt.Unix(),
+		session.ExpiresAt.UnixMilli(),
+	).Int64()
+	if err != nil {
+		return false, err
+	}
+
+	return rotated == 1, nil
+}
+
+// touchSessionScript updates when the session was last seen, unless it
+// has expired meanwhile so it is not brought back without an expiry.
+var touchSessionScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+redis.call("HSET", KEYS[1], "lastSeenAt", ARGV[1])
+return 1
+`)
+
+func (repo *credentialRepo) TouchSession(ctx context.Context, id string, at time.Time) error {
+	return touchSessionScript.Run(ctx, repo.redis, []string{sessionKey(id)}, at.Unix()).Err()
+}
+
+func (repo *credentialRepo) DeleteSession(ctx context.Context, session entity.Session) error {
+	_, err := repo.redis.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, sessionKey(session.Id))
+		p.SRem(ctx, employeeSessionsKey(session.EmployeeID), session.Id)
+		return nil
+	})
+
+	return err
+}
+
+// DeleteSessionsByEmployeeId revokes every session of the employee.
+func (repo *credentialRepo) DeleteSessionsByEmployeeId(ctx context.Context, employeeId string) error {
+	ids, err := repo.redis.SMembers(ctx, employeeSessionsKey(employeeId)).Result()
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.redis.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		for _, id := range ids {
+			p.Del(ctx, sessionKey(id))
+		}
+		p.Del(ctx, employeeSessionsKey(employeeId))
+		return nil
+	})
+
+	return err
+}
+
+func parseSession(values map[string]string) (entity.Session, error) {
+	session := entity.Session{
+		Id:               values["id"],
+		EmployeeID:       values["employeeId"],
+		RefreshTokenHash: values["refreshToken"],
+		Device:           values["device"],
+		IP:               values["ip"],
+	}
+
+	for field, t := range map[string]*time.Time{
+		"createdAt":  &session.CreatedAt,
+		"lastSeenAt": &session.LastSeenAt,
+		"expiresAt":  &session.ExpiresAt,
+	} {
+		unix, err := strconv.ParseInt(values[field], 10, 64)
+		if err != nil {
+			return session, fmt.Errorf("invalid session %s: %w", field, err)
+		}
+		*t = time.Unix(unix, 0).In(utils.CURRENT_LOC)
+	}
+
+	if v, ok := values["tokenVersion"]; ok {
+		version, err := strconv.Atoi(v)
+		if err != nil {
+			return session, fmt.Errorf("invalid session tokenVersion: %w", err)
+		}
+		session.TokenVersion = version
+	}
+
+	return session, nil
+}
+
+/*
+*************************************************
+PASSWORD RESET
+*************************************************
+*/
+
+func passwordResetKey(hash string) string {
+	return fmt.Sprintf("passwordReset:%s", hash)
+}
+
+func employeePasswordResetKey(employeeId string) string {
+	return fmt.Sprintf("%s:passwordReset", employeeId)
+}
+
+// CountPasswordResetRequests counts the password reset requests of an
+// email address, including this one, made within the window. The
+// count and its expiry are set at once, so a count never outlives
+// the window.
+func (repo *credentialRepo) CountPasswordResetRequests(ctx context.Context, email string, window time.Duration) (int64, error) {
+	key := fmt.Sprintf("%s:forgotPassword", email)
+
+	var count *redis.IntCmd
+	if _, err := repo.redis.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		count = p.Incr(ctx, key)
+		p.ExpireNX(ctx, key, window)
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	return count.Val(), nil
+}
+
+// SavePasswordResetToken stores the hash of the password reset token
+// of the employee until it expires. The previous token of the employee
+// is no longer valid.
+func (repo *credentialRepo) SavePasswordResetToken(ctx context.Context, employeeId, hash string, exp time.Duration) error {
+	prev, err := repo.redis.Get(ctx, employeePasswordResetKey(employeeId)).Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	_, err = repo.redis.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		if prev != "" {
+			p.Del(ctx, passwordResetKey(prev))
+		}
+		p.Set(ctx, passwordResetKey(hash), employeeId, exp)
+		p.Set(ctx, employeePasswordResetKey(employeeId), hash, exp)
+		return nil
+	})
+
+	return err
+}
+
+// ConsumePasswordResetToken returns the id of the employee the password
+// reset token was issued to, and removes the token so it is used once.
+func (repo *credentialRepo) ConsumePasswordResetToken(ctx context.Context, hash string) (string, error) {
+	employeeId, err := repo.redis.GetDel(ctx, passwordResetKey(hash)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return "", fmt.Errorf("password reset token not found")
+		}
+		return "", err
+	}
+
+	if err := repo.redis.Del(ctx, employeePasswordResetKey(employeeId)).Err(); err != nil {
+		return "", err
+	}
+
+	return employeeId, nil
+}
+
+/*
+*************************************************
+OTP
+*************************************************
+*/
+
+// GetEmployeeOTPSecret queries the OTP secret of the employee, and
+// whether the employee has one.
+func (repo *credentialRepo) GetEmployeeOTPSecret(ctx context.Context, employeeId string) (entity.EmployeeOTPSecret, bool, error) {
+	var secret entity.EmployeeOTPSecret
+
+	if err := repo.db.WithContext(ctx).
+		Model(&secret).
+		First(&secret, "employee_id = ?", employeeId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return secret, false, nil
+		}
+		return secret, false, err
+	}
+
+	return secret, true, nil
+}
+
+// SaveEmployeeOTPSecret creates or replaces the OTP secret of the
+// employee.
+func (repo *credentialRepo) SaveEmployeeOTPSecret(ctx context.Context, secret entity.EmployeeOTPSecret) error {
+	return repo.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "employee_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"secret", "enrolled_at", "last_used_step", "updated_at"}),
+		}).
+		Create(&secret).Error
+}
+
+// UseOTPStep records the time step of an OTP accepted from the
+// authenticator app of the employee. It returns false if an OTP of
+// the same step or after was already accepted.
+func (repo *credentialRepo) UseOTPStep(ctx context.Context, employeeId string, step int64) (bool, error) {
+	res := repo.db.WithContext(ctx).
+		Model(&entity.EmployeeOTPSecret{}).
+		Where("employee_id = ? AND last_used_step < ?", employeeId, step).
+		Update("last_used_step", step)
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+// ResetEmployeeOTPSecret replaces the OTP secret of the employee,
+// forgets the recovery codes and records who did it.
+func (repo *credentialRepo) ResetEmployeeOTPSecret(ctx context.Context, secret entity.EmployeeOTPSecret, log entity.EmployeeDataHistoryLog) error {
+	tx := repo.db.WithContext(ctx).Begin()
+
+	if err := tx.Model(&secret).
+		Select("secret", "enrolled_at", "last_used_step").
+		Updates(&secret).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Where("employee_id = ?", secret.EmployeeID).
+		Delete(&entity.EmployeeRecoveryCode{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Omit("Employee", "UpdatedBy").Create(&log).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+/*
+*************************************************
+TWO-FACTOR LOGIN
+*************************************************
+*/
+
+func loginChallengeKey(hash string) string {
+	return fmt.Sprintf("loginChallenge:%s", hash)
+}
+
+// ReplaceRecoveryCodes replaces the recovery codes of the employee,
+// used or not, with new ones.
+func (repo *credentialRepo) ReplaceRecoveryCodes(ctx context.Context, employeeId string, hashes []string) error {
+	tx := repo.db.WithContext(ctx).Begin()
+
+	if err := tx.Where("employee_id = ?", employeeId).
+		Delete(&entity.EmployeeRecoveryCode{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	codes := make([]entity.EmployeeRecoveryCode, 0, len(hashes))
+	for _, hash := range hashes {
+		codes = append(codes, entity.EmployeeRecoveryCode{EmployeeID: employeeId, CodeHash: hash})
+	}
+
+	if len(codes) > 0 {
+		if err := tx.Create(&codes).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// UseRecoveryCode marks the recovery code of the employee as used. It
+// returns false if the code does not exist or was already used.
+func (repo *credentialRepo) UseRecoveryCode(ctx context.Context, employeeId, hash string, at time.Time) (bool, error) {
+	res := repo.db.WithContext(ctx).
+		Model(&entity.EmployeeRecoveryCode{}).
+		Where("employee_id = ? AND code_hash = ? AND used_at IS NULL", employeeId, hash).
+		Update("used_at", at)
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+func (repo *credentialRepo) CountUnusedRecoveryCodes(ctx context.Context, employeeId string) (int64, error) {
+	var count int64
+
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.EmployeeRecoveryCode{}).
+		Where("employee_id = ? AND used_at IS NULL", employeeId).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (repo *credentialRepo) SaveLoginChallenge(ctx context.Context, hash string, challenge entity.LoginChallenge) error {
+	_, err := repo.redis.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HSet(ctx, loginChallengeKey(hash),
+			"employeeId", challenge.EmployeeID,
+			"device", challenge.Device,
+			"ip", challenge.IP,
+			"expiresAt", challenge.ExpiresAt.Unix(),
+			"attempts", 0,
+		)
+		p.ExpireAt(ctx, loginChallengeKey(hash), challenge.ExpiresAt)
+		return nil
+	})
+
+	return err
+}
+
+func (repo *credentialRepo) GetLoginChallenge(ctx context.Context, hash string) (entity.LoginChallenge, error) {
+	values, err := repo.redis.HGetAll(ctx, loginChallengeKey(hash)).Result()
+	if err != nil {
+		return entity.LoginChallenge{}, err
+	}
+	if len(values) == 0 {
+		return entity.LoginChallenge{}, fmt.Errorf("login challenge not found")
+	}
+
+	challenge := entity.LoginChallenge{
+		EmployeeID: values["employeeId"],
+		Device:     values["device"],
+		IP:         values["ip"],
+	}
+
+	expiresAt, err := strconv.ParseInt(values["expiresAt"], 10, 64)
+	if err != nil {
+		return challenge, fmt.Errorf("invalid login challenge expiresAt: %w", err)
+	}
+	challenge.ExpiresAt = time.Unix(expiresAt, 0).In(utils.CURRENT_LOC)
+
+	return challenge, nil
+}
+
+// countChallengeAttemptsScript counts an attempt at a login challenge
+// unless it has expired, so an expired one is not brought back without
+// an expiry.
+var countChallengeAttemptsScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+return redis.call("HINCRBY", KEYS[1], "attempts", 1)
+`)
+
+// CountLoginChallengeAttempts counts the attempts at the login
+// challenge, including this one. It returns 0 once the challenge has
+// expired.
+func (repo *credentialRepo) CountLoginChallengeAttempts(ctx context.Context, hash string) (int64, error) {
+	return countChallengeAttemptsScript.Run(ctx, repo.redis, []string{loginChallengeKey(hash)}).Int64()
+}
+
+// ConsumeLoginChallenge removes the login challenge so it is completed
+// once. It returns false if another request completed it first.
+func (repo *credentialRepo) ConsumeLoginChallenge(ctx context.Context, hash string) (bool, error) {
+	deleted, err := repo.redis.Del(ctx, loginChallengeKey(hash)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return deleted == 1, nil
+}
+
+/*
+*************************************************
+LOCKOUT
+*************************************************
+*/
+
+// The subject of login failures is the id of an employee or an IP
+// address
+func loginFailuresKey(subject string) string {
+	return fmt.Sprintf("%s:loginFailures", subject)
+}
+
+func accountLockKey(employeeId string) string {
+	return fmt.Sprintf("%s:loginLock", employeeId)
+}
+
+func (repo *credentialRepo) GetLoginFailures(ctx context.Context, subject string) (entity.LoginFailures, error) {
+	values, err := repo.redis.HGetAll(ctx, loginFailuresKey(subject)).Result()
+	if err != nil || len(values) == 0 {
+		return entity.LoginFailures{}, err
+	}
+
+	return parseLoginFailures(values)
+}
+
+// RecordLoginFailure counts a failed login of the subject and restarts
+// the window the failures are counted within.
+func (repo *credentialRepo) RecordLoginFailure(ctx context.Context, subject string, at time.Time, window time.Duration) (entity.LoginFailures, error) {
+	var count *redis.IntCmd
+
+	_, err := repo.redis.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		count = p.HIncrBy(ctx, loginFailuresKey(subject), "count", 1)
+		p.HSet(ctx, loginFailuresKey(subject), "lastFailedAt", at.Unix())
+		p.Expire(ctx, loginFailuresKey(subject), window)
+		return nil
+	})
+	if err != nil {
+		return entity.LoginFailures{}, err
+	}
+
+	return entity.LoginFailures{Count: count.Val(), LastFailedAt: at}, nil
+}
+
+// takeLoginAttemptScript counts a login attempt as failed, and returns
+// the failures counted before it.
+var takeLoginAttemptScript = redis.NewScript(`
+local previous = redis.call("HMGET", KEYS[1], "count", "lastFailedAt")
+redis.call("HINCRBY", KEYS[1], "count", 1)
+redis.call("HSET", KEYS[1], "lastFailedAt", ARGV[1])
+redis.call("EXPIRE", KEYS[1], ARGV[2])
+return {previous[1] or "0", previous[2] or "0"}`)
+
+// releaseLoginAttemptScript uncounts a login attempt, and puts back
+// when the previous failure was unless another attempt has been
+// counted since.
+var releaseLoginAttemptScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+local count = redis.call("HINCRBY", KEYS[1], "count", -1)
+if count <= 0 then
+	redis.call("DEL", KEYS[1])
+elseif redis.call("HGET", KEYS[1], "lastFailedAt") == ARGV[1] then
+	if ARGV[2] == "0" then
+		redis.call("HDEL", KEYS[1], "lastFailedAt")
+	else
+		redis.call("HSET", KEYS[1], "lastFailedAt", ARGV[2])
+	end
+end
+return count`)
+
+// TakeLoginAttempt counts a login attempt of the subject as failed
+// before it is verified, hence parallel attempts are all counted. It
+// returns the failures before the attempt and along with it.
+func (repo *credentialRepo) TakeLoginAttempt(ctx context.Context, subject string, at time.Time, window time.Duration) (entity.LoginFailures, entity.LoginFailures, error) {
+	values, err := takeLoginAttemptScript.Run(ctx, repo.redis,
+		[]string{loginFailuresKey(subject)},
+		at.Unix(),
+		int64(window.Seconds()),
+	).StringSlice()
+	if err != nil {
+		return entity.LoginFailures{}, entity.LoginFailures{}, err
+	}
+
+	previous := map[string]string{"count": values[0]}
+	if values[1] != "0" {
+		previous["lastFailedAt"] = values[1]
+	}
+	failures, err := parseLoginFailures(previous)
+	if err != nil {
+		return entity.LoginFailures{}, entity.LoginFailures{}, err
+	}
+
+	return failures, entity.LoginFailures{Count: failures.Count + 1, LastFailedAt: at}, nil
+}
+
+// ReleaseLoginAttempt uncounts a login attempt of the subject taken at
+// the given time, which turns out not to have failed.
+func (repo *credentialRepo) ReleaseLoginAttempt(ctx context.Context, subject string, at time.Time, previous entity.LoginFailures) error {
+	var previousAt int64
+	if !previous.LastFailedAt.IsZero() {
+		previousAt = previous.LastFailedAt.Unix()
+	}
+
+	return releaseLoginAttemptScript.Run(ctx, repo.redis,
+		[]string{loginFailuresKey(subject)},
+		at.Unix(),
+		previousAt,
+	).Err()
+}
+
+func (repo *credentialRepo) ClearLoginFailures(ctx context.Context, subject string) error {
+	return repo.redis.Del(ctx, loginFailuresKey(subject)).Err()
+}
+
+func (repo *credentialRepo) LockAccount(ctx context.Context, employeeId string, until time.Time) error {
+	return repo.redis.Set(ctx, accountLockKey(employeeId), until.Unix(), time.Until(until)).Err()
+}
+
+// GetAccountLock returns until when the account of the employee is
+// locked, and whether it is.
+func (repo *credentialRepo) GetAccountLock(ctx context.Context, employeeId string) (time.Time, bool, error) {
+	until, err := repo.redis.Get(ctx, accountLockKey(employeeId)).Int64()
+	if err != nil {
+		if err == redis.Nil {
+			return time.Time{}, false, nil
+		}
+		return time.Time{}, false, err
+	}
+
+	return time.Unix(until, 0).In(utils.CURRENT_LOC), true, nil
+}
+
+// UnlockAccount lifts the lock of the account of the employee and
+// forgets its failed logins.
+func (repo *credentialRepo) UnlockAccount(ctx context.Context, employeeId string) error {
+	return repo.redis.Del(ctx, accountLockKey(employeeId), loginFailuresKey(employeeId)).Err()
+}
+
+func parseLoginFailures(values map[string]string) (entity.LoginFailures, error) {
+	var failures entity.LoginFailures
+
+	count, err := strconv.ParseInt(values["count"], 10, 64)
+	if err != nil {
+		return failures, fmt.Errorf("invalid login failures count: %w", err)
+	}
+	failures.Count = count
+
+	if v, ok := values["lastFailedAt"]; ok {
+		lastFailedAt, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return failures, fmt.Errorf("invalid login failures lastFailedAt: %w", err)
+		}
+		failures.LastFailedAt = time.Unix(lastFailedAt, 0).In(utils.CURRENT_LOC)
+	}
+
+	return failures, nil
+}
@@ -57,6 +57,60 @@ func (repo *employeeRepo) GetAllManagersList(ctx context.Context) ([]entity.Empl
 	return managers, nil
 }
 
+// GetStaffIdsByManagerId retrieves the ids of the employees who
+// currently report to the manager.
+func (repo *employeeRepo) GetStaffIdsByManagerId(ctx context.Context, managerId string) ([]string, error) {
+	var ids []string
+
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.Employee{}).
+		Where("manager_id = ?", managerId).
+		Where("resigned_at IS NULL").
+		Order("id").
+		Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// GetManagerIds retrieves the ids of the employees whom anyone who has
+// not resigned reports to.
+func (repo *employeeRepo) GetManagerIds(ctx context.Context) ([]string, error) {
+	var ids []string
+
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.Employee{}).
+		Where("manager_id IS NOT NULL").
+		Where("resigned_at IS NULL").
+		Distinct().
+		Pluck("manager_id", &ids).Error; err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// GetActiveEmployeeIds retrieves which of the ids belong to employees
+// who have not resigned.
+func (repo *employeeRepo) GetActiveEmployeeIds(ctx context.Context, ids []string) ([]string, error) {
+	var found []string
+
+	if len(ids) == 0 {
+		return found, nil
+	}
+
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.Employee{}).
+		Where("id IN ?", ids).
+		Where("resigned_at IS NULL").
+		Pluck("id", &found).Error; err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
 // GetEmployeeBiodataById retrieves all of the employees information.
 // This preloads all of the employee's associations regarding its biodata.
 func (repo *employeeRepo) GetEmployeeFullProfileById(ctx context.Context, id string) (entity.Employee, error) {
@@ -68,7 +122,7 @@ func (repo *employeeRepo) GetEmployeeFullProfileById(ctx context.Context, id str
 		Preload("Role").
 		Preload("Manager").
 		Preload("EmployeeBiodata").
-		Preload("EmployeeLeavesQuota").
+		Preload("EmployeeLeaveQuotas").
 		Preload("EmployeesEmergencyContacts").
 		First(&employee, "id = ?", id).Error; err != nil {
 		return employee, err
@@ -92,7 +146,7 @@ func (repo *employeeRepo) GetEmployeeSimpleInformationById(ctx context.Context,
 
 	if err := repo.db.WithContext(ctx).
 		Model(&employee).
-		Preload("Role").
+		Preload("Role.Permissions").
 		Preload("Job").
 		Preload("Manager").
 		Preload("EmployeeBiodata").
@@ -138,19 +192,6 @@ func (repo *employeeRepo) SetEmployeeStatusTo(ctx context.Context, employeeId st
 	return nil
 }
 
-func (repo *employeeRepo) GetLeaveQuotaByEmployeeId(ctx context.Context, employeeId string) (entity.EmployeeLeavesQuota, error) {
-	var quota entity.EmployeeLeavesQuota
-
-	if err := repo.db.WithContext(ctx).
-		Model(&entity.EmployeeLeavesQuota{}).
-		First(&quota, "employee_id = ?", employeeId).
-		Error; err != nil {
-		return quota, err
-	}
-
-	return quota, nil
-}
-
 func (repo *employeeRepo) GetBiodataByEmployeeId(ctx context.Context, id string) (entity.EmployeeBiodata, error) {
 	var biodata entity.EmployeeBiodata
 
@@ -164,20 +205,24 @@ func (repo *employeeRepo) GetBiodataByEmployeeId(ctx context.Context, id string)
 	return biodata, nil
 }
 
-func (repo *employeeRepo) GetAllEmployees(ctx context.Context, employeeId, role string, q vo.AllEmployeeQuery) ([]entity.Employee, vo.PaginationDTOResponse, error) {
+// GetAllEmployees lists the employees the requestee may see by their
+// read scope. Other employees who read everyone are hidden, and only
+// a requestee who reads everyone sees who has resigned.
+func (repo *employeeRepo) GetAllEmployees(ctx context.Context, employeeId string, scope entity.PermissionScope, q vo.AllEmployeeQuery) ([]entity.Employee, vo.PaginationDTOResponse, error) {
 	pquery := q.Pagination.MustExtract()
 
 	var employees []entity.Employee
 	var count int64
 
-	t := repo.db.WithContext(ctx).Model(&entity.Employee{}).Preload("Job")
+	readers := repo.db.Model(&entity.RolePermission{}).
+		Select("role_id").
+		Where("permission = ? AND scope = ?", entity.PERM_EMPLOYEE_READ, entity.SCOPE_ALL)
+
+	t := repo.db.WithContext(ctx).Model(&entity.Employee{}).Preload("Job").
+		Where(`"employees"."id" = ? OR "employees"."role_id" NOT IN (?)`, employeeId, readers)
 
-	switch role {
-	case "hr":
-		t = t.Joins(`INNER JOIN "roles" ON "roles"."id" = "employees"."role_id" AND ("employees"."id" = ? OR "roles"."code" <> ?)`, employeeId, "hr")
-	case "mngr", "staff":
-		t = t.Joins(`INNER JOIN "roles" ON "roles"."id" = "employees"."role_id" AND "roles"."code" <> ?`, "hr").
-			Where("resigned_at IS NULL").
+	if scope != entity.SCOPE_ALL {
+		t = t.Where("resigned_at IS NULL").
 			Where("resigned_by_id IS NULL")
 	}
 
@@ -201,12 +246,15 @@ func (repo *employeeRepo) GetAllEmployees(ctx context.Context, employeeId, role
 	return employees, pquery.Compress(count), nil
 }
 
+// UpdateEmployeeWorkInfo saves the employee except its credentials,
+// so a stale copy never restores an old password or token version.
 func (repo *employeeRepo) UpdateEmployeeWorkInfo(ctx context.Context, employee entity.Employee) error {
 	if err := repo.db.WithContext(ctx).
 		Model(&employee).
 		Omit("EmployeeBiodata").
 		Omit("EmployeesEmergencyContacts").
-		Omit("EmployeeLeavesQuota").
+		Omit("EmployeeLeaveQuotas").
+		Omit("Password", "TokenVersion").
 		Save(&employee).Error; err != nil {
 		return err
 	}
@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"context"
+
+	"sinarlog.com/internal/entity"
+)
+
+/*
+*********************************
+ACTOR: SYSTEM
+*********************************
+*/
+func (repo *leaveRepo) GetEmployeesForLeaveAccrual(ctx context.Context) ([]entity.Employee, error) {
+	var employees []entity.Employee
+
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.Employee{}).
+		Select("id", "join_date").
+		Preload("EmployeeLeaveQuotas").
+		Where("resigned_at IS NULL").
+		Find(&employees).Error; err != nil {
+		return nil, err
+	}
+
+	return employees, nil
+}
+
+// SaveLeaveQuotaAccrual locks the quota, lets accrue bring the locked
+// row up to date and saves it together with the ledger entries
+// accrue returns, which it also returns. Accruing the locked row
+// keeps the leaves taken and refunded meanwhile, and their carried
+// over days. A quota without an id is created, and a quota removed
+// meanwhile returns entity.ErrLeaveQuotaNotFound. An entry of a period
+// accrued already fails the whole accrual.
+func (repo *leaveRepo) SaveLeaveQuotaAccrual(ctx context.Context, quota entity.EmployeeLeaveQuota, accrue func(quota *entity.EmployeeLeaveQuota) []entity.LeaveQuotaLedger) ([]entity.LeaveQuotaLedger, error) {
+	tx := repo.db.WithContext(ctx).Begin()
+
+	if quota.Id != "" {
+		locked := tx.Raw(`
+		SELECT * FROM employee_leave_quota
+		WHERE id = ? AND deleted_at IS NULL
+		FOR UPDATE
+		`, quota.Id).Scan(&quota)
+		if locked.Error != nil {
+			tx.Rollback()
+			return nil, locked.Error
+		}
+		if locked.RowsAffected == 0 {
+			tx.Rollback()
+			return nil, entity.ErrLeaveQuotaNotFound
+		}
+	}
+
+	entries := accrue(&quota)
+
+	if len(entries) > 0 {
+		if err := tx.Model(&entity.LeaveQuotaLedger{}).Create(&entries).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if quota.Id == "" {
+		if err := tx.Model(&quota).Create(&quota).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		return entries, tx.Commit().Error
+	}
+
+	if err := tx.Model(&quota).Updates(map[string]any{
+		"count":                   quota.Count,
+		"year":                    quota.Year,
+		"next_accrual_at":         quota.NextAccrualAt,
+		"carried_over":            quota.CarriedOver,
+		"carried_over_expires_at": quota.CarriedOverExpiresAt,
+	}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return entries, tx.Commit().Error
+}
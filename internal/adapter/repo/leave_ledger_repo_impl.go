@@ -0,0 +1,102 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/entity/vo"
+	"sinarlog.com/internal/utils"
+)
+
+/*
+*********************************
+ACTOR: ALL
+*********************************
+*/
+func (repo *leaveRepo) GetLeaveQuotaLedger(ctx context.Context, employeeId string, q vo.LeaveQuotaLedgerQuery) ([]entity.LeaveQuotaLedger, vo.PaginationDTOResponse, error) {
+	q.Pagination.Order = "created_at"
+	q.Pagination.Sort = "DESC"
+	pquery := q.Pagination.MustExtract()
+	tquery, _ := q.TimeQuery.Extract()
+
+	var entries []entity.LeaveQuotaLedger
+	var count int64
+
+	t := repo.db.WithContext(ctx).
+		Model(&entity.LeaveQuotaLedger{}).
+		Preload("Actor").
+		Where("employee_id = ?", employeeId)
+
+	switch tquery.Option {
+	case 1:
+		t = t.Where("created_at BETWEEN ? AND ?", tquery.StartDate, tquery.EndDate)
+	case 2:
+		t = t.Where("EXTRACT(MONTH FROM created_at) = ?", tquery.Month).Where("EXTRACT(YEAR FROM created_at) = ?", tquery.Year)
+	}
+
+	if q.Type != "" {
+		t = t.Where("type = ?", q.Type)
+	}
+
+	if err := t.Count(&count).
+		Order(utils.ToOrderSQL(pquery.OrderBy, pquery.Sort)).
+		Limit(pquery.Limit).
+		Offset(pquery.Offset).
+		Find(&entries).Error; err != nil {
+		return nil, vo.PaginationDTOResponse{}, err
+	}
+
+	return entries, pquery.Compress(count), nil
+}
+
+/*
+*********************************
+ACTOR: HR
+*********************************
+*/
+
+// AdjustLeaveQuota changes the employee's remaining quota of a leave
+// type manually and records it in the ledger. An employee who has
+// no quota of the leave type yet is given an empty one to accrue
+// from the join date. It returns entity.ErrLeaveQuotaNotFound if the
+// quota has been removed, and entity.ErrNegativeLeaveQuota if the
+// adjustment takes more than what remains.
+func (repo *leaveRepo) AdjustLeaveQuota(ctx context.Context, entry entity.LeaveQuotaLedger) error {
+	tx := repo.db.WithContext(ctx).Begin()
+
+	if err := tx.Exec(`
+	INSERT INTO employee_leave_quota (employee_id, type, count, year, created_at, updated_at)
+	VALUES (?, ?, 0, ?, NOW(), NOW())
+	ON CONFLICT (employee_id, type) DO NOTHING
+	`, entry.EmployeeID, entry.Type, time.Now().In(utils.CURRENT_LOC).Year()).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Locks the quota until the adjustment is recorded
+	var balances []float64
+	if err := tx.Raw(`
+	SELECT count FROM employee_leave_quota
+	WHERE employee_id = ? AND type = ? AND deleted_at IS NULL
+	FOR UPDATE
+	`, entry.EmployeeID, entry.Type).Scan(&balances).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if len(balances) == 0 {
+		tx.Rollback()
+		return entity.ErrLeaveQuotaNotFound
+	}
+	if err := entry.CheckBalance(balances[0]); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := repo.recordLeaveQuota(tx, entry); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
@@ -0,0 +1,128 @@
+package repo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"sinarlog.com/internal/entity"
+)
+
+/*
+*********************************
+ACTOR: ALL
+*********************************
+*/
+func (repo *leaveRepo) GetLeavePolicies(ctx context.Context) ([]entity.LeavePolicy, error) {
+	var policies []entity.LeavePolicy
+
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.LeavePolicy{}).
+		Order("code ASC").
+		Find(&policies).Error; err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+func (repo *leaveRepo) GetLeavePolicyByCode(ctx context.Context, code entity.LeaveType) (entity.LeavePolicy, error) {
+	var policy entity.LeavePolicy
+
+	if err := repo.db.WithContext(ctx).
+		Model(&policy).
+		First(&policy, "code = ?", code).Error; err != nil {
+		return policy, err
+	}
+
+	return policy, nil
+}
+
+func (repo *leaveRepo) GetLeaveQuotasByEmployeeId(ctx context.Context, employeeId string) (entity.EmployeeLeavesQuota, error) {
+	var quotas entity.EmployeeLeavesQuota
+
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.EmployeeLeaveQuota{}).
+		Where("employee_id = ?", employeeId).
+		Order("type ASC").
+		Find(&quotas).Error; err != nil {
+		return nil, err
+	}
+
+	return quotas, nil
+}
+
+/*
+*********************************
+ACTOR: HR
+*********************************
+*/
+func (repo *leaveRepo) GetLeavePolicyById(ctx context.Context, id string) (entity.LeavePolicy, error) {
+	var policy entity.LeavePolicy
+
+	if err := repo.db.WithContext(ctx).
+		Model(&policy).
+		First(&policy, "id = ?", id).Error; err != nil {
+		return policy, err
+	}
+
+	return policy, nil
+}
+
+// CreateLeavePolicy creates the leave policy and gives its default
+// quota to each employee who has not resigned.
+func (repo *leaveRepo) CreateLeavePolicy(ctx context.Context, policy entity.LeavePolicy) error {
+	tx := repo.db.WithContext(ctx).Begin()
+
+	if err := tx.Model(&policy).Create(&policy).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := repo.grantLeavePolicyQuota(tx, policy); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// UpdateLeavePolicy updates the leave policy. An employee who has
+// no quota of the leave type yet is given its default quota.
+func (repo *leaveRepo) UpdateLeavePolicy(ctx context.Context, policy entity.LeavePolicy) error {
+	tx := repo.db.WithContext(ctx).Begin()
+
+	if err := tx.Model(&policy).Select("*").Omit("created_at", "deleted_at").Updates(&policy).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := repo.grantLeavePolicyQuota(tx, policy); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// DeleteLeavePolicy soft deletes the leave policy, which the leaves
+// requested before, pending ones included, still refer to.
+func (repo *leaveRepo) DeleteLeavePolicy(ctx context.Context, id string) error {
+	return repo.db.WithContext(ctx).Delete(&entity.LeavePolicy{}, "id = ?", id).Error
+}
+
+// grantLeavePolicyQuota creates the default quota of the leave
+// policy for each employee who has not resigned and has no quota
+// of the leave type yet.
+func (repo *leaveRepo) grantLeavePolicyQuota(tx *gorm.DB, policy entity.LeavePolicy) error {
+	if !policy.HasQuota {
+		return nil
+	}
+
+	return tx.Exec(`
+	INSERT INTO employee_leave_quota (employee_id, type, count, created_at, updated_at)
+	SELECT e.id, ?, ?, NOW(), NOW()
+	FROM employees AS e
+	WHERE e.resigned_at IS NULL AND e.deleted_at IS NULL
+	ON CONFLICT (employee_id, type) DO NOTHING
+	`, policy.Code, policy.DefaultQuota).Error
+}
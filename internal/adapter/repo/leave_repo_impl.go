@@ -2,7 +2,6 @@ package repo
 
 import (
 	"context"
-	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -26,25 +25,28 @@ func NewLeaveRepo(db *gorm.DB) *leaveRepo {
 ACTOR: STAFF and MANAGER
 *********************************
 */
-func (repo *leaveRepo) EmployeeIsOnLeaveToday(ctx context.Context, employeeId string) (bool, error) {
-	var count int64
+func (repo *leaveRepo) GetEmployeeLeavesToday(ctx context.Context, employeeId string) ([]entity.Leave, error) {
+	var leaves []entity.Leave
 
 	truee := true
 	now := time.Now().In(utils.CURRENT_LOC)
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, utils.CURRENT_LOC)
 	if err := repo.db.WithContext(ctx).
 		Model(&entity.Leave{}).
 		Where("employee_id = ?", employeeId).
-		Where(`? BETWEEN "leaves"."from" AND "leaves"."to"`, now).
+		Where(`"leaves"."from" < ? AND "leaves"."to" > ?`, startOfDay.AddDate(0, 0, 1), startOfDay).
 		Where("approved_by_manager IS NOT NULL").
 		Where("approved_by_manager = ?", &truee).
 		Where("approved_by_hr IS NOT NULL").
 		Where("approved_by_hr = ?", &truee).
-		Count(&count).
+		Where("cancelled_at IS NULL").
+		Order(`"leaves"."from" ASC`).
+		Find(&leaves).
 		Error; err != nil {
-		return false, err
+		return nil, err
 	}
 
-	return count != 0, nil
+	return leaves, nil
 }
 
 func (repo *leaveRepo) GetMyLeaveRequestsList(ctx context.Context, employeeId string, q vo.LeaveProposalHistoryQuery) ([]entity.Leave, vo.PaginationDTOResponse, error) {
@@ -79,13 +81,17 @@ func (repo *leaveRepo) GetMyLeaveRequestsList(ctx context.Context, employeeId st
 		approved_by_hr IS NULL
 		AND
 		closed_automatically IS NULL
+		AND
+		cancelled_at IS NULL
 		`)
 	case "approved":
-		t = t.Where("approved_by_manager IS TRUE AND approved_by_hr IS TRUE AND closed_automatically IS NULL")
+		t = t.Where("approved_by_manager IS TRUE AND approved_by_hr IS TRUE AND closed_automatically IS NULL AND cancelled_at IS NULL")
 	case "rejected":
 		t = t.Where("approved_by_manager IS NOT NULL AND (approved_by_manager IS FALSE OR approved_by_hr IS FALSE) AND closed_automatically IS NULL")
 	case "closed":
 		t = t.Where("closed_automatically IS TRUE")
+	case "cancelled":
+		t = t.Where("cancelled_at IS NOT NULL")
 	}
 
 	if err := t.Count(&count).
@@ -99,22 +105,23 @@ func (repo *leaveRepo) GetMyLeaveRequestsList(ctx context.Context, employeeId st
 	return leaves, pquery.Compress(count), nil
 }
 
-func (repo *leaveRepo) CheckDateAvailability(ctx context.Context, leave entity.Leave) (bool, error) {
+func (repo *leaveRepo) CheckDateAvailability(ctx context.Context, leave entity.Leave, excludeIds ...string) (bool, error) {
 	var count int64
 	var truee bool = true
 
-	if err := repo.db.WithContext(ctx).Model(&leave).
+	t := repo.db.WithContext(ctx).Model(&leave)
+	if len(excludeIds) > 0 {
+		t = t.Where(`"leaves"."id" NOT IN ?`, excludeIds)
+	}
+
+	if err := t.Where(`"leaves"."cancelled_at" IS NULL`).
 		Where(`
 			"employee_id" = ?
 			AND
-			(
-				("leaves"."from" BETWEEN ? AND ?)
-				OR
-				("leaves"."to" BETWEEN ? AND ?)
-				OR
-				(? BETWEEN "leaves"."from" AND "leaves"."to")
-			)
-		`, leave.EmployeeID, leave.From, leave.To, leave.From, leave.To, leave.From).
+			"leaves"."from" < ?
+			AND
+			"leaves"."to" > ?
+		`, leave.EmployeeID, leave.To, leave.From).
 		Where(`
 		(
 			(
@@ -155,25 +162,81 @@ func (repo *leaveRepo) CreateLeave(ctx context.Context, leave entity.Leave) erro
 		return err
 	}
 
-	for i := 0; i < len(leave.Childs)+1; i++ {
-		if i == 0 {
-			sql := repo.generateUpdateLeaveQuotaSql(leave.Type, false)
-			if sql == "" {
-				continue
-			}
-			if err := tx.Exec(sql, utils.CountNumberOfWorkingDays(leave.From, leave.To), leave.EmployeeID).Error; err != nil {
-				tx.Rollback()
-				return err
-			}
-		} else {
-			sql := repo.generateUpdateLeaveQuotaSql(leave.Childs[i-1].Type, false)
-			if sql == "" {
-				continue
-			}
-			if err := tx.Exec(sql, utils.CountNumberOfWorkingDays(leave.Childs[i-1].From, leave.Childs[i-1].To), leave.EmployeeID).Error; err != nil {
-				tx.Rollback()
-				return err
-			}
+	for _, v := range append([]entity.Leave{leave}, leave.Childs...) {
+		v.EmployeeID = leave.EmployeeID
+		if err := repo.recordLeave(tx, entity.LEDGER_LEAVE_TAKEN, v, &leave.EmployeeID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return nil
+}
+
+func (repo *leaveRepo) AmendLeave(ctx context.Context, amended entity.Leave, leave entity.Leave) error {
+	tx := repo.db.WithContext(ctx).Begin()
+
+	// Cancel the amended leave and returns back its quota
+	if err := repo.cancelLeave(tx, amended, &amended.EmployeeID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Session(&gorm.Session{FullSaveAssociations: true}).Model(&entity.Leave{}).Create(&leave).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, v := range append([]entity.Leave{leave}, leave.Childs...) {
+		v.EmployeeID = leave.EmployeeID
+		if err := repo.recordLeave(tx, entity.LEDGER_LEAVE_TAKEN, v, &leave.EmployeeID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return nil
+}
+
+func (repo *leaveRepo) SaveLeaveCancellation(ctx context.Context, actorId string, leave entity.Leave) error {
+	tx := repo.db.WithContext(ctx).Begin()
+
+	if err := tx.Exec(`UPDATE leaves SET
+		cancellation_reason = ?,
+		cancellation_requested_at = ?,
+		cancellation_approved_by_manager = ?,
+		cancellation_action_by_manager_at = ?,
+		cancellation_approved_by_hr = ?,
+		cancellation_action_by_hr_at = ?,
+		cancellation_rejection_reason = ?
+		WHERE id = ?`,
+		leave.CancellationReason,
+		leave.CancellationRequestedAt,
+		leave.CancellationApprovedByManager,
+		leave.CancellationActionByManagerAt,
+		leave.CancellationApprovedByHr,
+		leave.CancellationActionByHrAt,
+		leave.CancellationRejectionReason,
+		leave.Id).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Returns back the quota once the leave is cancelled
+	if leave.CancelledAt != nil {
+		if err := repo.cancelLeave(tx, leave, &actorId); err != nil {
+			tx.Rollback()
+			return err
 		}
 	}
 
@@ -190,21 +253,26 @@ func (repo *leaveRepo) CreateLeave(ctx context.Context, leave entity.Leave) erro
 ACTOR: MANAGER
 *********************************
 */
-func (repo *leaveRepo) GetIncomingLeaveProposalForManager(ctx context.Context, managerId string, q vo.IncomingLeaveProposals) ([]entity.Leave, vo.PaginationDTOResponse, error) {
+// GetIncomingLeaveProposalForManager returns the leaves waiting on
+// any of the approvers, which are the manager and the managers
+// delegating to the manager.
+func (repo *leaveRepo) GetIncomingLeaveProposalForManager(ctx context.Context, approverIds []string, q vo.IncomingLeaveProposals) ([]entity.Leave, vo.PaginationDTOResponse, error) {
 	pquery := q.Pagination.MustExtract()
 	tquery, _ := q.TimeQuery.Extract()
 
 	var leaves []entity.Leave
 	var count int64
 
-	// Only query the parent
+	// Only query the parent waiting on the approvers. A leave made
+	// before approval chains existed waits on its manager.
 	t := repo.db.WithContext(ctx).Model(&entity.Leave{}).
-		Where(`"leaves"."manager_id" = ?`, managerId).
+		Where(`(`+pendingApprovalStepSql("leave_id", `"leaves"."id"`, "s.approver_id IN ?")+` OR ("leaves"."manager_id" IN ? AND NOT EXISTS (SELECT 1 FROM approval_steps AS s WHERE s.leave_id = "leaves"."id")))`, approverIds, approverIds).
 		Where("approved_by_manager IS NULL").
 		Where("action_by_manager_at IS NULL").
 		Where("approved_by_hr IS NULL").
 		Where("action_by_hr_at IS NULL").
 		Where("closed_automatically IS NULL").
+		Where("cancelled_at IS NULL").
 		Where("parent_id IS NULL").
 		Preload("Childs")
 
@@ -234,57 +302,45 @@ func (repo *leaveRepo) GetIncomingLeaveProposalForManager(ctx context.Context, m
 	return leaves, pquery.Compress(count), nil
 }
 
-func (repo *leaveRepo) SaveProcessedLeaveByManager(ctx context.Context, leave entity.Leave) error {
+// SaveProcessedLeaveByManager saves the decision of a step of the
+// approval chain before HR. Only the leaves decided by the step
+// should be given, hence the quota of a rejected one is returned.
+func (repo *leaveRepo) SaveProcessedLeaveByManager(ctx context.Context, actorId string, leave entity.Leave) error {
 	tx := repo.db.WithContext(ctx).Begin()
 
-	for i := 0; i < len(leave.Childs)+1; i++ {
-		if i == 0 {
-			// Process the parent leaves
-			if err := tx.Exec("UPDATE leaves SET approved_by_manager = ?, action_by_manager_at = ?, rejection_reason = ? WHERE id = ?",
-				leave.ApprovedByManager,
-				leave.ActionByManagerAt,
-				leave.RejectionReason,
-				leave.Id).Error; err != nil {
-				tx.Rollback()
-				return err
-			}
+	for i, v := range append([]entity.Leave{leave}, leave.Childs...) {
+		if v.ApprovedByManager == nil {
+			continue
+		}
 
-			// Returns back the quota if the leave is rejected
-			if !*leave.ApprovedByManager {
-				sql := repo.generateUpdateLeaveQuotaSql(leave.Type, true)
-				if sql == "" {
-					continue
-				}
-				if err := tx.Exec(sql, utils.CountNumberOfWorkingDays(leave.From, leave.To), leave.EmployeeID).Error; err != nil {
-					tx.Rollback()
-					return err
-				}
+		if err := tx.Exec("UPDATE leaves SET approved_by_manager = ?, action_by_manager_at = ?, approved_by_hr = ?, action_by_hr_at = ?, rejection_reason = ? WHERE id = ?",
+			v.ApprovedByManager,
+			v.ActionByManagerAt,
+			v.ApprovedByHr,
+			v.ActionByHrAt,
+			v.RejectionReason,
+			v.Id).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		// Returns back the quota if the leave is rejected
+		if !*v.ApprovedByManager {
+			if i > 0 {
+				v.EmployeeID = leave.EmployeeID
 			}
-		} else {
-			// Process the child's leave
-			if err := tx.Exec("UPDATE leaves SET approved_by_manager = ?, action_by_manager_at = ?, rejection_reason = ? WHERE id = ?",
-				leave.Childs[i-1].ApprovedByManager,
-				leave.Childs[i-1].ActionByManagerAt,
-				leave.Childs[i-1].RejectionReason,
-				leave.Childs[i-1].Id).Error; err != nil {
+			if err := repo.recordLeave(tx, entity.LEDGER_LEAVE_REJECTED, v, &actorId); err != nil {
 				tx.Rollback()
 				return err
 			}
-
-			// Returns back the quota if the child leave is rejected
-			if !*leave.Childs[i-1].ApprovedByManager {
-				sql := repo.generateUpdateLeaveQuotaSql(leave.Childs[i-1].Type, true)
-				if sql == "" {
-					continue
-				}
-				if err := tx.Exec(sql, utils.CountNumberOfWorkingDays(leave.Childs[i-1].From, leave.Childs[i-1].To), leave.EmployeeID).Error; err != nil {
-					tx.Rollback()
-					return err
-				}
-			}
 		}
 	}
 
+	if err := saveApprovalSteps(tx, leave.ApprovalSteps); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
 		return err
@@ -303,18 +359,20 @@ func (repo *leaveRepo) GetLeaveProposalHistoryForManager(ctx context.Context, ma
 
 	t := repo.db.WithContext(ctx).
 		Model(&entity.Leave{}).
-		Where(`"leaves"."manager_id" = ?`, managerId).
+		Where(`("leaves"."manager_id" = ? OR EXISTS (SELECT 1 FROM approval_steps AS s WHERE s.leave_id = "leaves"."id" AND (s.approver_id = ? OR s.acted_by_id = ?)))`, managerId, managerId, managerId).
 		Where("parent_id IS NULL")
 
 	switch status {
 	case "pending":
-		t = t.Where("approved_by_manager IS TRUE AND approved_by_hr IS NULL AND closed_automatically IS NULL")
+		t = t.Where("approved_by_manager IS TRUE AND approved_by_hr IS NULL AND closed_automatically IS NULL AND cancelled_at IS NULL")
 	case "approved":
-		t = t.Where("approved_by_manager IS TRUE AND approved_by_hr IS TRUE AND closed_automatically IS NULL")
+		t = t.Where("approved_by_manager IS TRUE AND approved_by_hr IS TRUE AND closed_automatically IS NULL AND cancelled_at IS NULL")
 	case "rejected":
 		t = t.Where("approved_by_manager IS NOT NULL AND (approved_by_manager IS FALSE OR approved_by_hr IS FALSE) AND closed_automatically IS NULL")
 	case "closed":
 		t = t.Where("closed_automatically IS TRUE")
+	case "cancelled":
+		t = t.Where("cancelled_at IS NOT NULL")
 	default:
 		t = t.Where("approved_by_manager IS NOT NULL OR closed_automatically IS NOT NULL")
 	}
@@ -342,11 +400,27 @@ func (repo *leaveRepo) GetLeaveProposalHistoryForManager(ctx context.Context, ma
 	return leaves, pquery.Compress(count), nil
 }
 
+func (repo *leaveRepo) GetIncomingLeaveCancellationForManager(ctx context.Context, managerId string, q vo.IncomingLeaveProposals) ([]entity.Leave, vo.PaginationDTOResponse, error) {
+	t := repo.db.WithContext(ctx).Model(&entity.Leave{}).
+		Where(`"leaves"."manager_id" = ?`, managerId).
+		Where("cancellation_approved_by_manager IS NULL")
+
+	return repo.getIncomingLeaveCancellation(t, q)
+}
+
 /*
 *********************************
 ACTOR: HR
 *********************************
 */
+func (repo *leaveRepo) GetIncomingLeaveCancellationForHr(ctx context.Context, q vo.IncomingLeaveProposals) ([]entity.Leave, vo.PaginationDTOResponse, error) {
+	t := repo.db.WithContext(ctx).Model(&entity.Leave{}).
+		Where("cancellation_approved_by_manager IS TRUE").
+		Where("cancellation_approved_by_hr IS NULL")
+
+	return repo.getIncomingLeaveCancellation(t, q)
+}
+
 func (repo *leaveRepo) GetIncomingLeaveProposalForHr(ctx context.Context, q vo.IncomingLeaveProposals) ([]entity.Leave, vo.PaginationDTOResponse, error) {
 	pquery := q.Pagination.MustExtract()
 	tquery, _ := q.TimeQuery.Extract()
@@ -362,6 +436,7 @@ func (repo *leaveRepo) GetIncomingLeaveProposalForHr(ctx context.Context, q vo.I
 		Where("approved_by_hr IS NULL").
 		Where("action_by_hr_at IS NULL").
 		Where("closed_automatically IS NULL").
+		Where("cancelled_at IS NULL").
 		Where("parent_id IS NULL").
 		Preload("Childs")
 
@@ -407,11 +482,7 @@ func (repo *leaveRepo) SaveProcessedLeaveByHr(ctx context.Context, leave entity.
 
 			// Returns back the quota if the leave is rejected
 			if !*leave.ApprovedByHr {
-				sql := repo.generateUpdateLeaveQuotaSql(leave.Type, true)
-				if sql == "" {
-					continue
-				}
-				if err := tx.Exec(sql, utils.CountNumberOfWorkingDays(leave.From, leave.To), leave.EmployeeID).Error; err != nil {
+				if err := repo.recordLeave(tx, entity.LEDGER_LEAVE_REJECTED, leave, leave.HrID); err != nil {
 					tx.Rollback()
 					return err
 				}
@@ -432,11 +503,9 @@ func (repo *leaveRepo) SaveProcessedLeaveByHr(ctx context.Context, leave entity.
 
 				// Returns back the quota if the child leave is rejected
 				if !*leave.Childs[i-1].ApprovedByHr {
-					sql := repo.generateUpdateLeaveQuotaSql(leave.Childs[i-1].Type, true)
-					if sql == "" {
-						continue
-					}
-					if err := tx.Exec(sql, utils.CountNumberOfWorkingDays(leave.Childs[i-1].From, leave.Childs[i-1].To), leave.EmployeeID).Error; err != nil {
+					child := leave.Childs[i-1]
+					child.EmployeeID = leave.EmployeeID
+					if err := repo.recordLeave(tx, entity.LEDGER_LEAVE_REJECTED, child, leave.HrID); err != nil {
 						tx.Rollback()
 						return err
 					}
@@ -445,6 +514,11 @@ func (repo *leaveRepo) SaveProcessedLeaveByHr(ctx context.Context, leave entity.
 		}
 	}
 
+	if err := saveApprovalSteps(tx, leave.ApprovalSteps); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
 		return err
@@ -467,13 +541,15 @@ func (repo *leaveRepo) GetLeaveProposalHistoryForHr(ctx context.Context, q vo.Le
 
 	switch status {
 	case "pending":
-		t = t.Where("(approved_by_manager IS TRUE OR approved_by_manager IS NULL) AND approved_by_hr IS NULL AND closed_automatically IS NULL")
+		t = t.Where("(approved_by_manager IS TRUE OR approved_by_manager IS NULL) AND approved_by_hr IS NULL AND closed_automatically IS NULL AND cancelled_at IS NULL")
 	case "approved":
-		t = t.Where("approved_by_manager IS TRUE AND approved_by_hr IS TRUE AND closed_automatically IS NULL")
+		t = t.Where("approved_by_manager IS TRUE AND approved_by_hr IS TRUE AND closed_automatically IS NULL AND cancelled_at IS NULL")
 	case "rejected":
 		t = t.Where("(approved_by_manager IS FALSE OR approved_by_hr IS FALSE) AND closed_automatically IS NULL")
 	case "closed":
 		t = t.Where("closed_automatically IS TRUE")
+	case "cancelled":
+		t = t.Where("cancelled_at IS NOT NULL")
 	}
 
 	switch tquery.Option {
@@ -515,6 +591,9 @@ func (repo *leaveRepo) GetLeaveById(ctx context.Context, id string) (entity.Leav
 		Preload("Employee.Manager").
 		Preload("Manager").
 		Preload("Hr").
+		Preload("ApprovalSteps", orderApprovalSteps).
+		Preload("ApprovalSteps.Approver").
+		Preload("ApprovalSteps.ActedBy").
 		First(&leave, "id = ?", id).
 		Error; err != nil {
 		return leave, err
@@ -541,18 +620,25 @@ func (repo *leaveRepo) WhosTakingLeave(ctx context.Context, q vo.CommonQuery) (v
 		AND
 		approved_by_hr IS TRUE
 		AND
+		cancelled_at IS NULL
+		AND
 		(
 			"from" BETWEEN ? AND ?
 			OR
 			"to" BETWEEN ? AND ?
 		)
 	) AS d
-	JOIN leaves AS l ON d.leave_date BETWEEN l."from" AND l."to"
+	JOIN leaves AS l ON d.leave_date BETWEEN l."from" AND l."to" AND l.cancelled_at IS NULL
 	WHERE EXTRACT(DOW FROM d.leave_date) NOT IN (0, 6)
 	AND d.leave_date BETWEEN ? AND ?
+	AND (d.leave_date AT TIME ZONE ?)::date NOT IN (
+		SELECT (h.date AT TIME ZONE ?)::date
+		FROM holidays AS h
+		WHERE h.deleted_at IS NULL
+	)
 	GROUP BY d.leave_date
 	ORDER BY d.leave_date
-	`, startOfTheMonth.In(utils.CURRENT_LOC), endOfTheMonth.In(utils.CURRENT_LOC), startOfTheMonth.In(utils.CURRENT_LOC), endOfTheMonth.In(utils.CURRENT_LOC), startOfTheMonth, endOfTheMonth).Rows()
+	`, startOfTheMonth.In(utils.CURRENT_LOC), endOfTheMonth.In(utils.CURRENT_LOC), startOfTheMonth.In(utils.CURRENT_LOC), endOfTheMonth.In(utils.CURRENT_LOC), startOfTheMonth, endOfTheMonth, utils.CURRENT_LOC.String(), utils.CURRENT_LOC.String()).Rows()
 	if err != nil {
 		return nil, err
 	}
@@ -610,7 +696,7 @@ func (repo *leaveRepo) WhosTakingLeaveMobile(ctx context.Context, q vo.CommonQue
 	var leaves []entity.Leave
 	var count int64
 
-	t := repo.db.WithContext(ctx).Model(&entity.Leave{}).Where("approved_by_manager IS TRUE").Where("approved_by_hr IS TRUE")
+	t := repo.db.WithContext(ctx).Model(&entity.Leave{}).Where("approved_by_manager IS TRUE").Where("approved_by_hr IS TRUE").Where("cancelled_at IS NULL")
 
 	switch tquery.Option {
 	case 1:
@@ -636,28 +722,186 @@ func (repo *leaveRepo) WhosTakingLeaveMobile(ctx context.Context, q vo.CommonQue
 UTILS
 *************************************************
 */
-// generateUpdateLeaveQuotaSql generates an sql that will be used
-// to update the employee's leave quota depending on leave type
-// provided and whether it is reversed or not.
-func (repo *leaveRepo) generateUpdateLeaveQuotaSql(leaveType entity.LeaveType, reverse bool) string {
-	mapper := map[entity.LeaveType]string{
-		entity.ANNUAL:   "yearly_count",
-		entity.MARRIAGE: "marriage_count",
-		entity.UNPAID:   "unpaid_count",
-	}
-
-	switch leaveType {
-	case entity.UNPAID:
-		if reverse {
-			return fmt.Sprintf("UPDATE employee_leaves_quota SET %s = %s - ? WHERE employee_id = ?", mapper[leaveType], mapper[leaveType])
+// cancelLeave marks the leave and its overflows as cancelled and
+// returns back the quota that is still taken by them.
+func (repo *leaveRepo) cancelLeave(tx *gorm.DB, leave entity.Leave, actorId *string) error {
+	now := time.Now().In(utils.CURRENT_LOC)
+	if leave.CancelledAt != nil {
+		now = *leave.CancelledAt
+	}
+
+	if err := tx.Exec("UPDATE leaves SET cancelled_at = ? WHERE id = ? OR parent_id = ?", now, leave.Id, leave.Id).Error; err != nil {
+		return err
+	}
+
+	for _, v := range leave.RefundableLeaves() {
+		v.EmployeeID = leave.EmployeeID
+		if err := repo.recordLeave(tx, entity.LEDGER_LEAVE_CANCELLED, v, actorId); err != nil {
+			return err
 		}
-		return fmt.Sprintf("UPDATE employee_leaves_quota SET %s = %s + ? WHERE employee_id = ?", mapper[leaveType], mapper[leaveType])
-	case entity.ANNUAL, entity.MARRIAGE:
-		if reverse {
-			return fmt.Sprintf("UPDATE employee_leaves_quota SET %s = %s + ? WHERE employee_id = ?", mapper[leaveType], mapper[leaveType])
+	}
+
+	return nil
+}
+
+// getIncomingLeaveCancellation queries the pending cancellations
+// of approved parent leaves filtered by t.
+func (repo *leaveRepo) getIncomingLeaveCancellation(t *gorm.DB, q vo.IncomingLeaveProposals) ([]entity.Leave, vo.PaginationDTOResponse, error) {
+	pquery := q.Pagination.MustExtract()
+	tquery, _ := q.TimeQuery.Extract()
+
+	var leaves []entity.Leave
+	var count int64
+
+	t = t.Where("cancellation_requested_at IS NOT NULL").
+		Where("cancelled_at IS NULL").
+		Where("parent_id IS NULL").
+		Preload("Childs")
+
+	if q.Name != "" {
+		t = t.Joins(`INNER JOIN "employees" ON "employees"."id" = "leaves"."employee_id" AND "employees"."full_name" ILIKE ?`, utils.ToPatternMatching(q.Name)).Preload("Employee")
+	} else {
+		t = t.Preload("Employee")
+	}
+
+	switch tquery.Option {
+	case 1:
+		t = t.Where(gorm.Expr(`"leaves"."cancellation_requested_at" BETWEEN ? AND ?`, tquery.StartDate, tquery.EndDate))
+	case 2:
+		t = t.Where(`EXTRACT(MONTH FROM "leaves"."cancellation_requested_at") = ?`, tquery.Month).Where(`EXTRACT(YEAR FROM "leaves"."cancellation_requested_at") = ?`, tquery.Year)
+	}
+
+	if err := t.
+		Count(&count).
+		Order(utils.ToOrderSQL(pquery.OrderBy, pquery.Sort)).
+		Limit(pquery.Limit).
+		Offset(pquery.Offset).Find(&leaves).Error; err != nil {
+		return nil, vo.PaginationDTOResponse{}, err
+	}
+
+	return leaves, pquery.Compress(count), nil
+}
+
+/*
+*********************************
+ACTOR: SYSTEM
+*********************************
+*/
+
+// CloseStaleLeaveProposals closes the leaves still waiting for a
+// decision once they have started at the given time, together with
+// their overflows waiting for a decision. The quotas taken by the
+// closed leaves are returned.
+func (repo *leaveRepo) CloseStaleLeaveProposals(ctx context.Context, at time.Time) ([]entity.Leave, error) {
+	var leaves []entity.Leave
+	truee := true
+
+	tx := repo.db.WithContext(ctx).Begin()
+
+	if err := tx.
+		Model(&entity.Leave{}).
+		Where(`"leaves"."from" <= ?`, at).
+		Where(`(approved_by_manager IS NULL OR (approved_by_manager = ? AND approved_by_hr IS NULL))`, &truee).
+		Where("closed_automatically IS NULL").
+		Where("cancelled_at IS NULL").
+		Where("parent_id IS NULL").
+		Preload("Childs").
+		Find(&leaves).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	for _, leave := range leaves {
+		closed := []entity.Leave{leave}
+		for _, v := range leave.Childs {
+			// A rejected overflow has returned its quota already
+			if v.ClosedAutomatically == nil && (v.ApprovedByManager == nil || (*v.ApprovedByManager && v.ApprovedByHr == nil)) {
+				v.EmployeeID = leave.EmployeeID
+				closed = append(closed, v)
+			}
 		}
-		return fmt.Sprintf("UPDATE employee_leaves_quota SET %s = %s - ? WHERE employee_id = ?", mapper[leaveType], mapper[leaveType])
-	default:
-		return ""
+
+		for _, v := range closed {
+			if err := tx.Exec("UPDATE leaves SET closed_automatically = ?, updated_at = NOW() WHERE id = ?", &truee, v.Id).Error; err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+
+			if err := repo.recordLeave(tx, entity.LEDGER_LEAVE_CLOSED, v, nil); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
 	}
+
+	return leaves, nil
+}
+
+// recordLeave records the quota taken or returned by the leave. A
+// leave without working days recorded is counted without the
+// holidays.
+func (repo *leaveRepo) recordLeave(tx *gorm.DB, source entity.LeaveLedgerSource, leave entity.Leave, actorId *string) error {
+	var holidays []entity.Holiday
+	if leave.WorkingDays == 0 {
+		if err := tx.Model(&entity.Holiday{}).Find(&holidays).Error; err != nil {
+			return err
+		}
+	}
+
+	return repo.recordLeaveQuota(tx, entity.NewLeaveLedgerEntry(source, leave, actorId, entity.HolidayDates(holidays)...))
+}
+
+// recordLeaveQuota changes the employee's remaining quota of a
+// leave type by the amount of the ledger entry, then appends the
+// entry to the ledger. A leave type without quota has no quota
+// record, hence nothing is recorded. Taking a quota takes the days
+// carried over from the last year first, and returning the quota of
+// a leave returns the days it took from them there.
+func (repo *leaveRepo) recordLeaveQuota(tx *gorm.DB, entry entity.LeaveQuotaLedger) error {
+	var quotas []entity.EmployeeLeaveQuota
+	if err := tx.Raw(`
+	SELECT * FROM employee_leave_quota
+	WHERE employee_id = ? AND type = ? AND deleted_at IS NULL
+	FOR UPDATE
+	`, entry.EmployeeID, entry.Type).Scan(&quotas).Error; err != nil {
+		return err
+	}
+
+	if len(quotas) == 0 {
+		return nil
+	}
+	quota := quotas[0]
+
+	// The days carried over the leave took and has not been returned
+	var taken float64
+	if entry.LeaveID != nil && entry.Amount > 0 {
+		if err := tx.Model(&entity.LeaveQuotaLedger{}).
+			Select("COALESCE(-SUM(carried_over), 0)").
+			Where("leave_id = ?", *entry.LeaveID).
+			Scan(&taken).Error; err != nil {
+			return err
+		}
+	}
+	entry.CarriedOver = quota.CarriedOverPart(entry.Amount, taken, time.Now())
+
+	var balances []float64
+	if err := tx.Raw(`
+	UPDATE employee_leave_quota
+	SET
+		count = count + ?,
+		carried_over = carried_over + ?,
+		updated_at = NOW()
+	WHERE id = ?
+	RETURNING count
+	`, entry.Amount, entry.CarriedOver, quota.Id).Scan(&balances).Error; err != nil {
+		return err
+	}
+	entry.Balance = balances[0]
+
+	return tx.Model(&entity.LeaveQuotaLedger{}).Create(&entry).Error
 }
@@ -1,20 +1,177 @@
 package repo
 
-import "sinarlog.com/internal/entity"
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gorm.io/gorm"
+	"sinarlog.com/internal/entity"
+)
 
 func GetAllRelationalEntities() []any {
 	return []any{
 		&entity.Configuration{},
+		&entity.OfficeLocation{},
+		&entity.WorkSchedule{},
+		&entity.Shift{},
+		&entity.Holiday{},
 		&entity.Job{},
 		&entity.Role{},
+		&entity.RolePermission{},
 		&entity.Employee{},
 		&entity.EmployeeBiodata{},
 		&entity.EmployeesEmergencyContact{},
-		&entity.EmployeeLeavesQuota{},
+		&entity.LeavePolicy{},
+		&entity.EmployeeLeaveQuota{},
+		&entity.LeaveQuotaLedger{},
 		&entity.EmployeeDataHistoryLog{},
+		&entity.EmployeeOTPSecret{},
+		&entity.EmployeeRecoveryCode{},
 		&entity.Attendance{},
 		&entity.Leave{},
 		&entity.Overtime{},
+		&entity.ApprovalChain{},
+		&entity.ApprovalStep{},
+		&entity.ApprovalDelegation{},
+		&entity.SchedulerJobRun{},
 		&entity.ConfigurationChangesLog{},
 	}
 }
+
+// MigrateRelationalEntities migrates the tables of the relational
+// entities. A database from before roles could require a manager only
+// made the staff do so, hence the staff role keeps requiring one when
+// the column is added.
+func MigrateRelationalEntities(db *gorm.DB) error {
+	backfill := !db.Migrator().HasColumn(&entity.Role{}, "RequiresManager")
+
+	if err := db.AutoMigrate(GetAllRelationalEntities()...); err != nil {
+		return err
+	}
+
+	if backfill {
+		if err := db.Model(&entity.Role{}).
+			Where("code = ?", "staff").
+			Update("requires_manager", true).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetAllDocumentIndexes returns the indexes of the document
+// collections, by collection.
+func GetAllDocumentIndexes() map[string][]mongo.IndexModel {
+	return map[string][]mongo.IndexModel{
+		entity.Rooms: {
+			{Keys: bson.D{{Key: "participants", Value: 1}}},
+			// A manager has one team room
+			{
+				Keys: bson.D{{Key: "teamOf", Value: 1}},
+				Options: options.Index().
+					SetName("rooms_team_of_unique").
+					SetUnique(true).
+					SetPartialFilterExpression(bson.D{{Key: "kind", Value: entity.TEAM_ROOM}}),
+			},
+		},
+		entity.Chats: {
+			// Pages the chats of a room
+			{Keys: bson.D{{Key: "roomId", Value: 1}, {Key: "_id", Value: -1}}},
+			// Searches the chats of any rooms, a text index cannot be
+			// prefixed by the room to search several at once
+			{
+				Keys:    bson.D{{Key: "message", Value: "text"}},
+				Options: options.Index().SetName("chats_message_text"),
+			},
+		},
+	}
+}
+
+// MigrateDocumentIndexes creates the indexes of the document
+// collections. The team rooms were only indexed by kind and manager
+// before a manager could have a single one, hence the team rooms of a
+// manager are merged into the oldest and the old index is dropped
+// before the unique one is created.
+func MigrateDocumentIndexes(ctx context.Context, db *mongo.Database) error {
+	if err := mergeTeamRooms(ctx, db); err != nil {
+		return err
+	}
+
+	if _, err := db.Collection(entity.Rooms).Indexes().DropOne(ctx, "kind_1_teamOf_1"); err != nil {
+		var cmdErr mongo.CommandError
+		if !errors.As(err, &cmdErr) || (cmdErr.Name != "IndexNotFound" && cmdErr.Name != "NamespaceNotFound") {
+			return err
+		}
+	}
+
+	for coll, indexes := range GetAllDocumentIndexes() {
+		if _, err := db.Collection(coll).Indexes().CreateMany(ctx, indexes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mergeTeamRooms merges the team rooms of a manager into the oldest
+// one, along with their chats, members and admins.
+func mergeTeamRooms(ctx context.Context, db *mongo.Database) error {
+	rooms := db.Collection(entity.Rooms)
+
+	cursor, err := rooms.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "kind", Value: entity.TEAM_ROOM}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$teamOf"},
+			{Key: "rooms", Value: bson.D{{Key: "$push", Value: "$$ROOT"}}},
+		}}},
+		{{Key: "$match", Value: bson.D{{Key: "rooms.1", Value: bson.D{{Key: "$exists", Value: true}}}}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	var teams []struct {
+		Rooms []entity.Room `bson:"rooms"`
+	}
+	if err := cursor.All(ctx, &teams); err != nil {
+		return err
+	}
+
+	for _, team := range teams {
+		kept, duplicates := team.Rooms[0], team.Rooms[1:]
+
+		ids := make([]primitive.ObjectID, 0, len(duplicates))
+		var participants, admins []string
+		for _, v := range duplicates {
+			ids = append(ids, v.Id)
+			participants = append(participants, v.Participants...)
+			admins = append(admins, v.Admins...)
+		}
+
+		if _, err := db.Collection(entity.Chats).UpdateMany(ctx,
+			bson.D{{Key: "roomId", Value: bson.D{{Key: "$in", Value: ids}}}},
+			bson.D{{Key: "$set", Value: bson.D{{Key: "roomId", Value: kept.Id}}}},
+		); err != nil {
+			return err
+		}
+
+		if _, err := rooms.UpdateByID(ctx, kept.Id, bson.D{{Key: "$addToSet", Value: bson.D{
+			{Key: "participants", Value: bson.D{{Key: "$each", Value: append([]string{}, participants...)}}},
+			{Key: "admins", Value: bson.D{{Key: "$each", Value: append([]string{}, admins...)}}},
+		}}}); err != nil {
+			return err
+		}
+
+		if _, err := rooms.DeleteMany(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
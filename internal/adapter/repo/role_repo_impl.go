@@ -2,7 +2,9 @@ package repo
 
 import (
 	"context"
+	"errors"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
 	"sinarlog.com/internal/entity"
 )
@@ -18,9 +20,100 @@ func NewRoleRepo(db *gorm.DB) *roleRepo {
 func (repo *roleRepo) GetAllRoles(ctx context.Context) ([]entity.Role, error) {
 	var roles []entity.Role
 
-	if err := repo.db.WithContext(ctx).Model(entity.Role{}).Find(&roles).Error; err != nil {
+	if err := repo.db.WithContext(ctx).Model(entity.Role{}).Preload("Permissions").Find(&roles).Error; err != nil {
 		return nil, err
 	}
 
 	return roles, nil
 }
+
+func (repo *roleRepo) GetRoleById(ctx context.Context, id string) (entity.Role, error) {
+	var role entity.Role
+
+	if err := repo.db.WithContext(ctx).Model(&role).Preload("Permissions").First(&role, "id = ?", id).Error; err != nil {
+		return role, err
+	}
+
+	return role, nil
+}
+
+func (repo *roleRepo) GetRoleByCode(ctx context.Context, code string) (entity.Role, error) {
+	var role entity.Role
+
+	if err := repo.db.WithContext(ctx).Model(&role).Preload("Permissions").First(&role, "code = ?", code).Error; err != nil {
+		return role, err
+	}
+
+	return role, nil
+}
+
+// CreateRole saves the role with its permissions. It returns
+// entity.ErrRoleExists if the name or code is taken.
+func (repo *roleRepo) CreateRole(ctx context.Context, role entity.Role) error {
+	return translateRoleError(repo.db.WithContext(ctx).Create(&role).Error)
+}
+
+// UpdateRole renames the role, sets whether it requires a manager and
+// replaces its permissions with the ones of the role.
+func (repo *roleRepo) UpdateRole(ctx context.Context, role entity.Role) error {
+	tx := repo.db.WithContext(ctx).Begin()
+
+	if err := tx.Model(&role).Select("Name", "RequiresManager").Updates(entity.Role{Name: role.Name, RequiresManager: role.RequiresManager}).Error; err != nil {
+		tx.Rollback()
+		return translateRoleError(err)
+	}
+
+	if err := tx.Delete(&entity.RolePermission{}, "role_id = ?", role.Id).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for i := range role.Permissions {
+		role.Permissions[i].RoleID = role.Id
+	}
+	if len(role.Permissions) > 0 {
+		if err := tx.Create(&role.Permissions).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+func (repo *roleRepo) DeleteRole(ctx context.Context, id string) error {
+	tx := repo.db.WithContext(ctx).Begin()
+
+	if err := tx.Delete(&entity.RolePermission{}, "role_id = ?", id).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Delete(&entity.Role{}, "id = ?", id).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+func (repo *roleRepo) CountEmployeesByRoleId(ctx context.Context, id string) (int64, error) {
+	var count int64
+
+	if err := repo.db.WithContext(ctx).Model(&entity.Employee{}).Where("role_id = ?", id).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// translateRoleError tells a unique violation, of the name or the code
+// of a role, apart from the other errors.
+func translateRoleError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return entity.ErrRoleExists
+	}
+
+	return err
+}
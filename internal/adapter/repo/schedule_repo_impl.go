@@ -0,0 +1,232 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/utils"
+)
+
+type scheduleRepo struct {
+	db *gorm.DB
+}
+
+func NewScheduleRepo(db *gorm.DB) *scheduleRepo {
+	return &scheduleRepo{db}
+}
+
+func (repo *scheduleRepo) GetWorkSchedules(ctx context.Context) ([]entity.WorkSchedule, error) {
+	var schedules []entity.WorkSchedule
+
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.WorkSchedule{}).
+		Preload("Shifts", func(db *gorm.DB) *gorm.DB {
+			return db.Order("day ASC")
+		}).
+		Order("name ASC").
+		Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range schedules {
+		normalizeShiftTimes(&schedules[i])
+	}
+
+	return schedules, nil
+}
+
+func (repo *scheduleRepo) GetWorkScheduleById(ctx context.Context, id string) (entity.WorkSchedule, error) {
+	var schedule entity.WorkSchedule
+
+	if err := repo.db.WithContext(ctx).
+		Model(&schedule).
+		Preload("Shifts", func(db *gorm.DB) *gorm.DB {
+			return db.Order("day ASC")
+		}).
+		First(&schedule, "id = ?", id).Error; err != nil {
+		return schedule, err
+	}
+
+	normalizeShiftTimes(&schedule)
+
+	return schedule, nil
+}
+
+func (repo *scheduleRepo) CreateWorkSchedule(ctx context.Context, schedule entity.WorkSchedule) error {
+	return repo.db.WithContext(ctx).Model(&schedule).Create(&schedule).Error
+}
+
+// UpdateWorkSchedule updates the work schedule and replaces
+// all of its shifts with the given ones.
+func (repo *scheduleRepo) UpdateWorkSchedule(ctx context.Context, schedule entity.WorkSchedule) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&schedule).
+			Select("name", "cycle_start", "cycle_length").
+			Updates(&schedule).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("work_schedule_id = ?", schedule.Id).Delete(&entity.Shift{}).Error; err != nil {
+			return err
+		}
+
+		for i := range schedule.Shifts {
+			schedule.Shifts[i].Id = ""
+			schedule.Shifts[i].WorkScheduleID = schedule.Id
+		}
+
+		if len(schedule.Shifts) != 0 {
+			if err := tx.Create(&schedule.Shifts).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// DeleteWorkSchedule deletes the work schedule and unassigns it
+// from every employee and job. They then fall back to the default
+// work schedule.
+func (repo *scheduleRepo) DeleteWorkSchedule(ctx context.Context, id string) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.Employee{}).
+			Where("work_schedule_id = ?", id).
+			Update("work_schedule_id", nil).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&entity.Job{}).
+			Where("work_schedule_id = ?", id).
+			Update("work_schedule_id", nil).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("work_schedule_id = ?", id).Delete(&entity.Shift{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&entity.WorkSchedule{}, "id = ?", id).Error
+	})
+}
+
+// AssignWorkSchedule assigns the work schedule to the employees and
+// jobs. A nil scheduleId unassigns their work schedule instead.
+func (repo *scheduleRepo) AssignWorkSchedule(ctx context.Context, scheduleId *string, employeeIds []string, jobIds []string) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(employeeIds) != 0 {
+			if err := tx.Model(&entity.Employee{}).
+				Where("id IN ?", employeeIds).
+				Update("work_schedule_id", scheduleId).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(jobIds) != 0 {
+			if err := tx.Model(&entity.Job{}).
+				Where("id IN ?", jobIds).
+				Update("work_schedule_id", scheduleId).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetEmployeeWorkSchedule queries the work schedule assigned to
+// the employee, or else to the employee's job. It returns nil when
+// neither has a work schedule assigned.
+func (repo *scheduleRepo) GetEmployeeWorkSchedule(ctx context.Context, employeeId string) (*entity.WorkSchedule, error) {
+	var employee entity.Employee
+
+	if err := repo.db.WithContext(ctx).
+		Model(&employee).
+		Select("id", "job_id", "work_schedule_id").
+		Preload("Job").
+		First(&employee, "id = ?", employeeId).Error; err != nil {
+		return nil, err
+	}
+
+	scheduleId := employee.WorkScheduleID
+	if scheduleId == nil {
+		scheduleId = employee.Job.WorkScheduleID
+	}
+	if scheduleId == nil {
+		return nil, nil
+	}
+
+	schedule, err := repo.GetWorkScheduleById(ctx, *scheduleId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &schedule, nil
+}
+
+func (repo *scheduleRepo) GetHolidays(ctx context.Context) ([]entity.Holiday, error) {
+	var holidays []entity.Holiday
+
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.Holiday{}).
+		Order("date ASC").
+		Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+
+	return holidays, nil
+}
+
+func (repo *scheduleRepo) GetHolidaysByYear(ctx context.Context, year int) ([]entity.Holiday, error) {
+	var holidays []entity.Holiday
+
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.Holiday{}).
+		Where("date BETWEEN ? AND ?",
+			time.Date(year, time.January, 1, 0, 0, 0, 0, utils.CURRENT_LOC),
+			time.Date(year, time.December, 31, 23, 59, 59, 0, utils.CURRENT_LOC),
+		).
+		Order("date ASC").
+		Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+
+	return holidays, nil
+}
+
+func (repo *scheduleRepo) GetHolidayById(ctx context.Context, id string) (entity.Holiday, error) {
+	var holiday entity.Holiday
+
+	if err := repo.db.WithContext(ctx).
+		Model(&holiday).
+		First(&holiday, "id = ?", id).Error; err != nil {
+		return holiday, err
+	}
+
+	return holiday, nil
+}
+
+func (repo *scheduleRepo) CreateHolidays(ctx context.Context, holidays []entity.Holiday) error {
+	return repo.db.WithContext(ctx).Model(&entity.Holiday{}).Create(&holidays).Error
+}
+
+func (repo *scheduleRepo) UpdateHoliday(ctx context.Context, holiday entity.Holiday) error {
+	return repo.db.WithContext(ctx).
+		Model(&holiday).
+		Select("name", "date").
+		Updates(&holiday).Error
+}
+
+func (repo *scheduleRepo) DeleteHoliday(ctx context.Context, id string) error {
+	return repo.db.WithContext(ctx).Delete(&entity.Holiday{}, "id = ?", id).Error
+}
+
+func normalizeShiftTimes(schedule *entity.WorkSchedule) {
+	schedule.CycleStart = schedule.CycleStart.In(utils.CURRENT_LOC)
+	for i := range schedule.Shifts {
+		schedule.Shifts[i].StartTime = schedule.Shifts[i].StartTime.In(utils.CURRENT_LOC)
+		schedule.Shifts[i].EndTime = schedule.Shifts[i].EndTime.In(utils.CURRENT_LOC)
+	}
+}
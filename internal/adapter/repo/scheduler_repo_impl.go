@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/entity/vo"
+	"sinarlog.com/internal/utils"
+)
+
+type schedulerRepo struct {
+	db *gorm.DB
+}
+
+func NewSchedulerRepo(db *gorm.DB) *schedulerRepo {
+	return &schedulerRepo{db}
+}
+
+func (repo *schedulerRepo) CreateJobRun(ctx context.Context, run entity.SchedulerJobRun) (entity.SchedulerJobRun, error) {
+	if err := repo.db.WithContext(ctx).Model(&run).Create(&run).Error; err != nil {
+		return run, err
+	}
+
+	return run, nil
+}
+
+func (repo *schedulerRepo) SaveJobRun(ctx context.Context, run entity.SchedulerJobRun) error {
+	return repo.db.WithContext(ctx).
+		Model(&run).
+		Select("status", "finished_at", "affected", "summary", "error").
+		Updates(&run).Error
+}
+
+// GetLatestJobRuns returns the latest run of each job.
+func (repo *schedulerRepo) GetLatestJobRuns(ctx context.Context) ([]entity.SchedulerJobRun, error) {
+	var runs []entity.SchedulerJobRun
+
+	if err := repo.db.WithContext(ctx).
+		Raw(`SELECT DISTINCT ON (job_name) * FROM scheduler_job_runs ORDER BY job_name, started_at DESC`).
+		Scan(&runs).Error; err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+func (repo *schedulerRepo) GetJobRuns(ctx context.Context, jobName string, q vo.CommonQuery) ([]entity.SchedulerJobRun, vo.PaginationDTOResponse, error) {
+	pquery := q.Pagination.MustExtract()
+
+	var runs []entity.SchedulerJobRun
+	var count int64
+
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.SchedulerJobRun{}).
+		Where("job_name = ?", jobName).
+		Preload("TriggeredBy").
+		Count(&count).
+		Order(utils.ToOrderSQL(pquery.OrderBy, pquery.Sort)).
+		Limit(pquery.Limit).
+		Offset(pquery.Offset).
+		Find(&runs).Error; err != nil {
+		return nil, vo.PaginationDTOResponse{}, err
+	}
+
+	return runs, pquery.Compress(count), nil
+}
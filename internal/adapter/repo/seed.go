@@ -30,10 +30,13 @@ func (repo *seeder) Seed(ctx context.Context) error {
 	// Register your seeder functions here
 	seeders := []seederFunc{
 		repo.seedConfig,
+		repo.seedLeavePolicies,
+		repo.seedApprovalChains,
 		repo.seedRoles,
 		repo.seedJobs,
 		repo.seedHRs,
 		repo.seedDummyManager,
+		repo.migrateLegacyLeaveQuotas,
 	}
 
 	for _, f := range seeders {
@@ -66,12 +69,134 @@ func (repo *seeder) seedConfig(ctx context.Context) error {
 	return nil
 }
 
+// Seed the built-in leave types. The quota and notice of the leave
+// types follow the config.
+func (repo *seeder) seedLeavePolicies(ctx context.Context) error {
+	var config entity.Configuration
+	if err := repo.db.WithContext(ctx).First(&config).Error; err != nil {
+		return err
+	}
+
+	policies := []entity.LeavePolicy{
+		{
+			Code:                  entity.ANNUAL,
+			Name:                  "Annual",
+			HasQuota:              true,
+			DefaultQuota:          float64(config.DefaultYearlyQuota),
+			IsPaid:                true,
+			MinNoticeDays:         config.AcceptanceLeaveInterval,
+			AccrualPeriod:         entity.MONTHLY_ACCRUAL,
+			MaxCarryOver:          6,
+			CarryOverExpiryMonths: 3,
+			OverflowTargets:       []entity.LeaveType{entity.UNPAID},
+		},
+		{
+			Code:            entity.MARRIAGE,
+			Name:            "Marriage",
+			HasQuota:        true,
+			DefaultQuota:    float64(config.DefaultMarriageQuota),
+			IsPaid:          true,
+			MinNoticeDays:   config.AcceptanceLeaveInterval,
+			AccrualPeriod:   entity.NO_ACCRUAL,
+			OverflowTargets: []entity.LeaveType{entity.ANNUAL, entity.UNPAID},
+		},
+		{
+			Code:          entity.UNPAID,
+			Name:          "Unpaid",
+			MinNoticeDays: config.AcceptanceLeaveInterval,
+			AccrualPeriod: entity.NO_ACCRUAL,
+		},
+		{
+			Code:          entity.SICK,
+			Name:          "Sick",
+			IsPaid:        true,
+			AccrualPeriod: entity.NO_ACCRUAL,
+		},
+	}
+
+	for _, p := range policies {
+		err := repo.db.WithContext(ctx).Model(&entity.LeavePolicy{}).FirstOrCreate(&p, entity.LeavePolicy{Code: p.Code}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Seed the default approval chains once, so HR may change or
+// delete them afterwards.
+func (repo *seeder) seedApprovalChains(ctx context.Context) error {
+	var count int64
+	if err := repo.db.WithContext(ctx).Unscoped().Model(&entity.ApprovalChain{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	chains := []entity.ApprovalChain{
+		entity.DefaultApprovalChain(entity.LEAVE_APPROVAL),
+		entity.DefaultApprovalChain(entity.OVERTIME_APPROVAL),
+	}
+
+	return repo.db.WithContext(ctx).Model(&entity.ApprovalChain{}).Create(&chains).Error
+}
+
+// Move the quota of the legacy employee_leaves_quota table into a
+// quota of each leave type. Employees who have no quota yet are
+// given the default quota of each leave type.
+func (repo *seeder) migrateLegacyLeaveQuotas(ctx context.Context) error {
+	tx := repo.db.WithContext(ctx).Begin()
+
+	if tx.Migrator().HasTable("employee_leaves_quota") {
+		if err := tx.Exec(`
+		INSERT INTO employee_leave_quota (employee_id, type, count, created_at, updated_at)
+		SELECT q.employee_id, ?, q.yearly_count, NOW(), NOW()
+		FROM employee_leaves_quota AS q
+		WHERE q.deleted_at IS NULL
+		ON CONFLICT (employee_id, type) DO NOTHING
+		`, entity.ANNUAL).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Exec(`
+		INSERT INTO employee_leave_quota (employee_id, type, count, created_at, updated_at)
+		SELECT q.employee_id, ?, q.marriage_count, NOW(), NOW()
+		FROM employee_leaves_quota AS q
+		WHERE q.deleted_at IS NULL
+		ON CONFLICT (employee_id, type) DO NOTHING
+		`, entity.MARRIAGE).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	var policies []entity.LeavePolicy
+	if err := tx.Model(&entity.LeavePolicy{}).Find(&policies).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	leaveRepo := &leaveRepo{db: repo.db}
+	for _, p := range policies {
+		if err := leaveRepo.grantLeavePolicyQuota(tx, p); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
 // Seed roles for V2 done.
 func (repo *seeder) seedRoles(ctx context.Context) error {
 	roles := []entity.Role{
 		{
-			Name: "Staff",
-			Code: "staff",
+			Name:            "Staff",
+			Code:            "staff",
+			RequiresManager: true,
 		},
 		{
 			Name: "Manager",
@@ -84,15 +209,47 @@ func (repo *seeder) seedRoles(ctx context.Context) error {
 	}
 
 	for _, r := range roles {
-		err := repo.db.WithContext(ctx).Model(&entity.Role{}).FirstOrCreate(&r, r).Error
+		err := repo.db.WithContext(ctx).Model(&entity.Role{}).
+			Attrs(entity.Role{Name: r.Name, RequiresManager: r.RequiresManager}).
+			FirstOrCreate(&r, entity.Role{Code: r.Code}).Error
 		if err != nil {
 			return err
 		}
+
+		if err := repo.seedRolePermissions(ctx, r); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// Seed the default permissions of a built-in role, unless HR has
+// already granted it some.
+func (repo *seeder) seedRolePermissions(ctx context.Context, role entity.Role) error {
+	var count int64
+	if err := repo.db.WithContext(ctx).
+		Model(&entity.RolePermission{}).
+		Where("role_id = ?", role.Id).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	var grants []entity.RolePermission
+	for _, v := range entity.DefaultRolePermissions[role.Code] {
+		v.RoleID = role.Id
+		grants = append(grants, v)
+	}
+	if len(grants) == 0 {
+		return nil
+	}
+
+	return repo.db.WithContext(ctx).Create(&grants).Error
+}
+
 // Seed jobs for V2 done.
 func (repo *seeder) seedJobs(ctx context.Context) error {
 	jobs := []entity.Job{
@@ -174,10 +331,9 @@ func (repo *seeder) seedHRs(ctx context.Context) error {
 				PhoneNumber: "+62-812-3456-7899",
 			},
 		},
-		EmployeeLeavesQuota: entity.EmployeeLeavesQuota{
-			YearlyCount:   12,
-			UnpaidCount:   0,
-			MarriageCount: 3,
+		EmployeeLeaveQuotas: []entity.EmployeeLeaveQuota{
+			{Type: entity.ANNUAL, Count: 12},
+			{Type: entity.MARRIAGE, Count: 3},
 		},
 		RoleID: role.Id,
 		Role:   role,
@@ -240,10 +396,9 @@ func (repo *seeder) seedDummyManager(ctx context.Context) error {
 				PhoneNumber: "+62-812-3456-7897",
 			},
 		},
-		EmployeeLeavesQuota: entity.EmployeeLeavesQuota{
-			YearlyCount:   12,
-			UnpaidCount:   0,
-			MarriageCount: 3,
+		EmployeeLeaveQuotas: []entity.EmployeeLeaveQuota{
+			{Type: entity.ANNUAL, Count: 12},
+			{Type: entity.MARRIAGE, Count: 3},
 		},
 		RoleID: role.Id,
 		Role:   role,
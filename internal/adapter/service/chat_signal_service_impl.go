@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"sinarlog.com/internal/entity/vo"
+)
+
+var (
+	chatSignalOnce sync.Once
+	chatSignalSrv  *chatSignalService
+
+	chatSignalPrefix    string = "chat:signal:"
+	chatPresenceChannel string = "chat:presence"
+	// Each employee has a sorted set of their connections scored by
+	// when they expire, so the connections of a replica which is gone
+	// expire on their own
+	chatPresencePrefix string = "chat:online:"
+	// chatSignalBuffer is how many signals a slow listener may fall
+	// behind before the signals are dropped for it
+	chatSignalBuffer = 64
+)
+
+type chatSignalService struct {
+	rdis *redis.Client
+	// sub is the one subscription of the replica, to the presence
+	// channel and to the channels of the rooms it has listeners of
+	sub *redis.PubSub
+
+	// mu guards the rooms and their listeners, never across a call to
+	// Redis
+	mu    sync.Mutex
+	rooms map[string]*signalRoom
+}
+
+// signalRoom is a room whose signals are listened to on this replica.
+type signalRoom struct {
+	// setup serializes subscribing and unsubscribing the replica to the
+	// channel of the room, and guards subscribed
+	setup      sync.Mutex
+	subscribed bool
+
+	listeners []chan vo.ChatSignal
+	// joining is how many listeners wait for the subscription, so the
+	// room is kept for them
+	joining int
+}
+
+// idle tells whether nobody listens to the room nor is about to.
+func (room *signalRoom) idle() bool {
+	return len(room.listeners) == 0 && room.joining == 0
+}
+
+// NewChatSignalService returns the Redis backend of the chat signals,
+// which are sent through Redis Pub/Sub. Each replica subscribes once
+// to the presence channel and to the channels of the rooms it has
+// listeners of, then fans the signals out to the listeners.
+func NewChatSignalService(client *redis.Client) *chatSignalService {
+	if chatSignalSrv == nil {
+		chatSignalOnce.Do(func() {
+			chatSignalSrv = &chatSignalService{
+				rdis:  client,
+				sub:   client.Subscribe(context.Background(), chatPresenceChannel),
+				rooms: make(map[string]*signalRoom),
+			}
+
+			chatSignalSrv.fanOutInBackground()
+		})
+	}
+
+	return chatSignalSrv
+}
+
+// PublishSignal sends a presence signal to every room, and any other
+// signal to its room.
+func (s *chatSignalService) PublishSignal(ctx context.Context, signal vo.ChatSignal) error {
+	payload, err := json.Marshal(signal)
+	if err != nil {
+		return fmt.Errorf("json marshal error: %s", err)
+	}
+
+	channel := chatSignalPrefix + signal.RoomId
+	if signal.Type == vo.CHAT_SIGNAL_PRESENCE {
+		channel = chatPresenceChannel
+	}
+
+	return s.rdis.Publish(ctx, channel, payload).Err()
+}
+
+// SubscribeSignals registers the listener to the room until the
+// context is done.
+func (s *chatSignalService) SubscribeSignals(ctx context.Context, roomId string, channel chan vo.ChatSignal) error {
+	key := chatSignalPrefix + roomId
+
+	listener, err := s.addListener(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer s.removeListener(key, listener)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case signal := <-listener:
+			select {
+			case <-ctx.Done():
+				return nil
+			case channel <- signal:
+			}
+		}
+	}
+}
+
+// addListener registers a listener to the room. The first listener of
+// the room on this replica subscribes the replica to its channel.
+func (s *chatSignalService) addListener(ctx context.Context, key string) (chan vo.ChatSignal, error) {
+	s.mu.Lock()
+	room, ok := s.rooms[key]
+	if !ok {
+		room = &signalRoom{}
+		s.rooms[key] = room
+	}
+	room.joining++
+	s.mu.Unlock()
+
+	room.setup.Lock()
+	defer room.setup.Unlock()
+
+	if !room.subscribed {
+		if err := s.sub.Subscribe(ctx, key); err != nil {
+			// The subscription is still resumed on the next connection
+			s.sub.Unsubscribe(context.Background(), key)
+
+			s.mu.Lock()
+			room.joining--
+			s.forgetIdleRoom(key, room)
+			s.mu.Unlock()
+			return nil, err
+		}
+		room.subscribed = true
+	}
+
+	listener := make(chan vo.ChatSignal, chatSignalBuffer)
+
+	s.mu.Lock()
+	room.joining--
+	room.listeners = append(room.listeners, listener)
+	s.mu.Unlock()
+
+	return listener, nil
+}
+
+// removeListener unregisters the listener from the room. The last
+// listener of the room on this replica unsubscribes the replica from
+// its channel, unless another one joins meanwhile.
+func (s *chatSignalService) removeListener(key string, listener chan vo.ChatSignal) {
+	s.mu.Lock()
+	room, ok := s.rooms[key]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	for i, v := range room.listeners {
+		if v == listener {
+			room.listeners = append(room.listeners[:i], room.listeners[i+1:]...)
+			break
+		}
+	}
+	idle := room.idle()
+	s.mu.Unlock()
+
+	if !idle {
+		return
+	}
+
+	room.setup.Lock()
+	defer room.setup.Unlock()
+
+	s.mu.Lock()
+	idle = room.idle()
+	s.mu.Unlock()
+	if !idle {
+		return
+	}
+
+	if room.subscribed {
+		if err := s.sub.Unsubscribe(context.Background(), key); err != nil {
+			log.Printf("Unable to unsubscribe from %s: %s", key, err)
+		}
+		room.subscribed = false
+	}
+
+	// The room is forgotten only once it is unsubscribed from, so a
+	// listener joining it in the meantime subscribes again
+	s.mu.Lock()
+	s.forgetIdleRoom(key, room)
+	s.mu.Unlock()
+}
+
+// forgetIdleRoom removes the room once nobody listens to it. It must
+// be called with s.mu held.
+func (s *chatSignalService) forgetIdleRoom(key string, room *signalRoom) {
+	if room.idle() && s.rooms[key] == room {
+		delete(s.rooms, key)
+	}
+}
+
+// fanOutInBackground receives the signals of the subscription of the
+// replica, which resubscribes on its own once reconnected, and fans
+// them out.
+func (s *chatSignalService) fanOutInBackground() {
+	go func() {
+		for msg := range s.sub.Channel() {
+			var signal vo.ChatSignal
+			if err := json.Unmarshal([]byte(msg.Payload), &signal); err != nil {
+				log.Printf("Unable to decode signal of %s: %s", msg.Channel, err)
+				continue
+			}
+
+			s.fanOut(msg.Channel, signal)
+		}
+	}()
+}
+
+// fanOut sends a presence signal to every listener, and any other
+// signal to the listeners of its room, without waiting for a slow one,
+// which rather misses the signal.
+func (s *chatSignalService) fanOut(channel string, signal vo.ChatSignal) {
+	var listeners []chan vo.ChatSignal
+	s.mu.Lock()
+	if channel == chatPresenceChannel {
+		for _, room := range s.rooms {
+			listeners = append(listeners, room.listeners...)
+		}
+	} else if room, ok := s.rooms[channel]; ok {
+		listeners = append(listeners, room.listeners...)
+	}
+	s.mu.Unlock()
+
+	for _, listener := range listeners {
+		select {
+		case listener <- signal:
+		default:
+			log.Printf("A listener of %s is too slow, signal %s is dropped", channel, signal.Type)
+		}
+	}
+}
+
+func (s *chatSignalService) MarkOnline(ctx context.Context, employeeId, connectionId string, ttl time.Duration) (bool, error) {
+	key := chatPresencePrefix + employeeId
+	now := time.Now()
+
+	pipe := s.rdis.TxPipeline()
+	pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.UnixMilli(), 10))
+	added := pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.Add(ttl).UnixMilli()), Member: connectionId})
+	count := pipe.ZCard(ctx, key)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+
+	return added.Val() == 1 && count.Val() == 1, nil
+}
+
+func (s *chatSignalService) MarkOffline(ctx context.Context, employeeId, connectionId string) (bool, error) {
+	key := chatPresencePrefix + employeeId
+
+	pipe := s.rdis.TxPipeline()
+	pipe.ZRem(ctx, key, connectionId)
+	pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	count := pipe.ZCard(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+
+	return count.Val() == 0, nil
+}
+
+func (s *chatSignalService) GetOnline(ctx context.Context, employeeIds []string) ([]string, error) {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	pipe := s.rdis.Pipeline()
+	counts := make([]*redis.IntCmd, 0, len(employeeIds))
+	for _, v := range employeeIds {
+		counts = append(counts, pipe.ZCount(ctx, chatPresencePrefix+v, "("+now, "+inf"))
+	}
+	if len(counts) == 0 {
+		return []string{}, nil
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	online := []string{}
+	for i, v := range counts {
+		if v.Val() > 0 {
+			online = append(online, employeeIds[i])
+		}
+	}
+
+	return online, nil
+}
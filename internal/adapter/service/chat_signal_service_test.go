@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"sinarlog.com/internal/entity/vo"
+)
+
+func TestFanningOutSignals(t *testing.T) {
+	s := &chatSignalService{rooms: map[string]*signalRoom{
+		chatSignalPrefix + "a": {listeners: []chan vo.ChatSignal{make(chan vo.ChatSignal, 1), make(chan vo.ChatSignal, 1)}},
+		chatSignalPrefix + "b": {listeners: []chan vo.ChatSignal{make(chan vo.ChatSignal, 1)}},
+	}}
+	received := func(room string) []int {
+		var counts []int
+		for _, v := range s.rooms[chatSignalPrefix+room].listeners {
+			counts = append(counts, len(v))
+			for len(v) > 0 {
+				<-v
+			}
+		}
+		return counts
+	}
+
+	s.fanOut(chatSignalPrefix+"a", vo.ChatSignal{Type: vo.CHAT_SIGNAL_TYPING, RoomId: "a"})
+	if a, b := received("a"), received("b"); a[0] != 1 || a[1] != 1 || b[0] != 0 {
+		t.Fatalf("expected only the listeners of the room to receive its signal, got %v and %v", a, b)
+	}
+
+	s.fanOut(chatPresenceChannel, vo.ChatSignal{Type: vo.CHAT_SIGNAL_PRESENCE, EmployeeId: "e"})
+	if a, b := received("a"), received("b"); a[0] != 1 || a[1] != 1 || b[0] != 1 {
+		t.Fatalf("expected every listener to receive the presence, got %v and %v", a, b)
+	}
+
+	// A slow listener misses the signal rather than holding up the others
+	s.fanOut(chatSignalPrefix+"a", vo.ChatSignal{Type: vo.CHAT_SIGNAL_TYPING, RoomId: "a"})
+	s.fanOut(chatSignalPrefix+"a", vo.ChatSignal{Type: vo.CHAT_SIGNAL_TYPING, RoomId: "a"})
+	if a := received("a"); a[0] != 1 || a[1] != 1 {
+		t.Fatalf("expected the signal to be dropped for the full listeners, got %v", a)
+	}
+}
+
+// Runs against TEST_REDIS_ADDRESS, e.g. a local instance at
+// localhost:6379.
+func TestSharingTheSignalSubscription(t *testing.T) {
+	addr := os.Getenv("TEST_REDIS_ADDRESS")
+	if addr == "" {
+		t.Skip("no redis, set TEST_REDIS_ADDRESS")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("unable to reach redis at %s: %s", addr, err)
+	}
+	s := NewChatSignalService(client)
+
+	listen := func(roomId string) (chan vo.ChatSignal, context.CancelFunc) {
+		channel := make(chan vo.ChatSignal, 4)
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		go s.SubscribeSignals(ctx, roomId, channel)
+		return channel, cancel
+	}
+	a1, cancelA1 := listen("a")
+	a2, cancelA2 := listen("a")
+	b, cancelB := listen("b")
+	// Gives the subscriptions time to be set up before publishing
+	time.Sleep(time.Second)
+
+	ctx := context.Background()
+	s.PublishSignal(ctx, vo.ChatSignal{Type: vo.CHAT_SIGNAL_TYPING, RoomId: "a", EmployeeId: "e"})
+	s.PublishSignal(ctx, vo.ChatSignal{Type: vo.CHAT_SIGNAL_PRESENCE, EmployeeId: "e", Online: true})
+
+	expect := func(name string, channel chan vo.ChatSignal, types ...vo.ChatSignalType) {
+		for _, want := range types {
+			select {
+			case got := <-channel:
+				if got.Type != want {
+					t.Fatalf("expected %s to receive %s, got %s", name, want, got.Type)
+				}
+			case <-time.After(3 * time.Second):
+				t.Fatalf("expected %s to receive %s", name, want)
+			}
+		}
+	}
+	expect("a1", a1, vo.CHAT_SIGNAL_TYPING, vo.CHAT_SIGNAL_PRESENCE)
+	expect("a2", a2, vo.CHAT_SIGNAL_TYPING, vo.CHAT_SIGNAL_PRESENCE)
+	expect("b", b, vo.CHAT_SIGNAL_PRESENCE)
+
+	cancelA1()
+	cancelA2()
+	cancelB()
+	time.Sleep(time.Second)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.rooms) != 0 {
+		t.Fatalf("expected the rooms nobody listens to to be forgotten, got %v", s.rooms)
+	}
+}
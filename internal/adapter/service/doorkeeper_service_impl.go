@@ -2,17 +2,31 @@ package service
 
 import (
 	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/xlzd/gotp"
 	"golang.org/x/crypto/bcrypt"
 	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/entity/vo"
 	"sinarlog.com/internal/utils"
 	"sinarlog.com/pkg/doorkeeper"
 )
 
+// The time step of the OTPs, which is the default of authenticator apps
+const totpInterval = 30
+
 type doorkeeperService struct {
 	dk *doorkeeper.Doorkeeper
 }
@@ -35,30 +49,173 @@ func (s *doorkeeperService) VerifyPassword(hash string, password string) error {
 /*
 ---------- JWT Section ----------
 */
-func (s *doorkeeperService) GenerateToken(employee entity.Employee) (token string, err error) {
+func (s *doorkeeperService) GenerateToken(employee entity.Employee, sessionId string) (token string, err error) {
 	now := time.Now().In(utils.CURRENT_LOC)
 	claims := jwt.MapClaims{
 		"iss": s.dk.GetIssuer(),
 		"eat": now.Add(s.dk.AccessDuration).Unix(),
 		"iat": now.Unix(),
 		"id":  employee.Id,
+		"sid": sessionId,
+		"ver": employee.TokenVersion,
 		"nbf": now.Unix(),
 	}
 
 	return jwt.NewWithClaims(s.dk.GetSignMethod(), claims).SignedString(s.dk.GetPrivKey())
 }
 
-func (s *doorkeeperService) VerifyAndParseToken(ctx context.Context, tk string) (string, error) {
+// VerifyAndParseToken returns the employee, the session and the token
+// version of an access token. The caller must reject the token if its
+// version is not the current token version of the employee.
+func (s *doorkeeperService) VerifyAndParseToken(ctx context.Context, tk string) (vo.TokenClaims, error) {
 	claims, err := s.verifyAndGetClaims(tk)
 	if err != nil {
-		return "", err
+		return vo.TokenClaims{}, err
+	}
+
+	if err := s.verifyClaims(ctx, claims, "id", "sid", "ver"); err != nil {
+		return vo.TokenClaims{}, err
+	}
+
+	id, ok := claims["id"].(string)
+	if !ok {
+		return vo.TokenClaims{}, fmt.Errorf("invalid token claims")
+	}
+	sid, ok := claims["sid"].(string)
+	if !ok {
+		return vo.TokenClaims{}, fmt.Errorf("invalid token claims: missing session")
+	}
+	ver, ok := claims["ver"].(float64)
+	if !ok {
+		return vo.TokenClaims{}, fmt.Errorf("invalid token claims: missing token version")
+	}
+
+	return vo.TokenClaims{
+		EmployeeID:   id,
+		SessionID:    sid,
+		TokenVersion: int(ver),
+		ExpiresAt:    time.Unix(int64(claims["eat"].(float64)), 0).In(utils.CURRENT_LOC),
+	}, nil
+}
+
+/*
+---------- Refresh Token Section ----------
+*/
+
+// GenerateRefreshToken returns an opaque refresh token of the session
+// along with its hash, which is the only part to be stored.
+func (s *doorkeeperService) GenerateRefreshToken(sessionId string) (string, string, error) {
+	secret, err := s.randomSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	return sessionId + "." + secret, s.hashSecret(secret), nil
+}
+
+// ParseRefreshToken returns the session id and the hash of a refresh
+// token.
+func (s *doorkeeperService) ParseRefreshToken(tk string) (string, string, error) {
+	sessionId, secret, ok := strings.Cut(tk, ".")
+	if !ok || sessionId == "" || secret == "" {
+		return "", "", fmt.Errorf("malformed refresh token")
+	}
+
+	return sessionId, s.hashSecret(secret), nil
+}
+
+func (s *doorkeeperService) GetRefreshDuration() time.Duration {
+	return s.dk.RefreshDuration
+}
+
+/*
+---------- Password Reset Section ----------
+*/
+
+// GenerateResetToken returns a one-time password reset token along
+// with its hash, which is the only part to be stored.
+func (s *doorkeeperService) GenerateResetToken() (string, string, error) {
+	secret, err := s.randomSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	return secret, s.hashSecret(secret), nil
+}
+
+func (s *doorkeeperService) HashResetToken(tk string) string {
+	return s.hashSecret(tk)
+}
+
+func (s *doorkeeperService) GetResetExpDuration() time.Duration {
+	return s.dk.GetResetExpDuration()
+}
+
+/*
+---------- Two-Factor Section ----------
+*/
+
+// GenerateChallengeToken returns the token of a login awaiting its
+// second factor along with its hash, which is the only part to be
+// stored.
+func (s *doorkeeperService) GenerateChallengeToken() (string, string, error) {
+	secret, err := s.randomSecret()
+	if err != nil {
+		return "", "", err
 	}
 
-	if err := s.verifyClaims(ctx, claims, "id"); err != nil {
+	return secret, s.hashSecret(secret), nil
+}
+
+func (s *doorkeeperService) HashChallengeToken(tk string) string {
+	return s.hashSecret(tk)
+}
+
+// GenerateRecoveryCodes returns n recovery codes formatted as
+// xxxxx-xxxxx along with their hashes, which are the only part to be
+// stored.
+func (s *doorkeeperService) GenerateRecoveryCodes(n int) ([]string, []string, error) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+
+	for i := 0; i < n; i++ {
+		raw := make([]byte, 10)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(raw))[:10]
+		code = code[:5] + "-" + code[5:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, s.HashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode hashes a recovery code regardless of its case and of
+// the separator, which people often leave out when typing it.
+func (s *doorkeeperService) HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	code = strings.ReplaceAll(code, " ", "")
+
+	return s.hashSecret(code)
+}
+
+func (s *doorkeeperService) randomSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
 		return "", err
 	}
 
-	return claims["id"].(string), nil
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func (s *doorkeeperService) hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
 }
 
 func (s *doorkeeperService) verifyAndGetClaims(tk string) (jwt.MapClaims, error) {
@@ -149,13 +306,117 @@ func (s *doorkeeperService) validateKeys(ctx context.Context, obj map[string]any
 /*
 ---------- OTP Section ----------
 */
-func (s *doorkeeperService) GenerateOTP() (string, int64, time.Duration) {
+
+// GenerateOTPSecret returns a new TOTP secret along with its encrypted
+// form, which is the only one to be stored.
+func (s *doorkeeperService) GenerateOTPSecret() (string, string, error) {
+	secret := gotp.RandomSecret(s.dk.GetOTPSecretLength())
+	if secret == "" {
+		return "", "", fmt.Errorf("unable to generate an otp secret")
+	}
+
+	encrypted, err := s.encrypt(secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	return secret, encrypted, nil
+}
+
+func (s *doorkeeperService) DecryptOTPSecret(encrypted string) (string, error) {
+	return s.decrypt(encrypted)
+}
+
+// GenerateOTP returns the OTP of the secret to be sent by email, the
+// timestamp it is generated at and how long it is valid.
+func (s *doorkeeperService) GenerateOTP(secret string) (string, int64, time.Duration) {
 	now := time.Now().In(utils.CURRENT_LOC).Unix()
-	otp := s.dk.GetTOTP().At(now)
+	otp := gotp.NewDefaultTOTP(secret).At(now)
 
 	return otp, now, s.dk.GetOTPExpDuration()
 }
 
-func (s *doorkeeperService) VerifyOTP(otp string, timestamp int64) bool {
-	return s.dk.GetTOTP().Verify(otp, timestamp)
+// VerifyOTP verifies an OTP sent by email generated at the timestamp.
+func (s *doorkeeperService) VerifyOTP(secret, otp string, timestamp int64) bool {
+	return s.compareOTP(gotp.NewDefaultTOTP(secret), otp, timestamp)
+}
+
+// VerifyTOTP verifies an OTP from an authenticator app at the given
+// time, allowing one time step of clock drift either way. It returns
+// the time step the OTP belongs to.
+func (s *doorkeeperService) VerifyTOTP(secret, otp string, at time.Time) (int64, bool) {
+	totp := gotp.NewDefaultTOTP(secret)
+
+	for _, drift := range []int64{0, -1, 1} {
+		timestamp := at.Unix() + drift*totpInterval
+		if s.compareOTP(totp, otp, timestamp) {
+			return timestamp / totpInterval, true
+		}
+	}
+
+	return 0, false
+}
+
+func (s *doorkeeperService) compareOTP(totp *gotp.TOTP, otp string, timestamp int64) bool {
+	return subtle.ConstantTimeCompare([]byte(otp), []byte(totp.At(timestamp))) == 1
+}
+
+// OTPProvisioningURI returns the URI enrolling the secret in an
+// authenticator app, usually shown as a QR code.
+func (s *doorkeeperService) OTPProvisioningURI(secret, accountName string) string {
+	return gotp.NewDefaultTOTP(secret).ProvisioningUri(accountName, s.dk.GetIssuer())
+}
+
+/*
+---------- Encryption Section ----------
+*/
+
+// encrypt seals the plaintext with AES-256-GCM and returns the nonce
+// followed by the ciphertext, base64 encoded.
+func (s *doorkeeperService) encrypt(plaintext string) (string, error) {
+	gcm, err := s.gcm()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *doorkeeperService) decrypt(encrypted string) (string, error) {
+	gcm, err := s.gcm()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("malformed ciphertext")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+func (s *doorkeeperService) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.dk.GetEncryptionKey())
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
 }
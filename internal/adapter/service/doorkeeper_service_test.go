@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xlzd/gotp"
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/pkg/doorkeeper"
+)
+
+// testDoorkeeperService returns a doorkeeper signing with HMAC. The
+// doorkeeper is a singleton, hence it is set up by the first caller.
+func testDoorkeeperService(t *testing.T) *doorkeeperService {
+	key := filepath.Join(t.TempDir(), "hmac.key")
+	if err := os.WriteFile(key, []byte("sinarlog-test-signing-key"), 0600); err != nil {
+		t.Fatalf("unable to write the signing key: %s", err)
+	}
+
+	return NewDoorkeeperService(doorkeeper.GetDoorkeeper(
+		doorkeeper.RegisterSignMethod("HMAC", "384"),
+		doorkeeper.RegisterPrivatePath(key),
+		doorkeeper.RegisterIssuer("sinarlog-test"),
+		doorkeeper.RegisterEncryptionKey("sinarlog-test-encryption-key"),
+	))
+}
+
+func testEmployee(id string, tokenVersion int) entity.Employee {
+	return entity.Employee{BaseModelId: entity.BaseModelId{Id: id}, TokenVersion: tokenVersion}
+}
+
+func TestRotatingRefreshTokens(t *testing.T) {
+	s := testDoorkeeperService(t)
+
+	first, firstHash, err := s.GenerateRefreshToken("session")
+	if err != nil {
+		t.Fatalf("unable to generate a refresh token: %s", err)
+	}
+	second, secondHash, err := s.GenerateRefreshToken("session")
+	if err != nil {
+		t.Fatalf("unable to generate a refresh token: %s", err)
+	}
+	if first == second || firstHash == secondHash {
+		t.Fatalf("expected each rotation to issue a new refresh token")
+	}
+
+	sessionId, hash, err := s.ParseRefreshToken(first)
+	if err != nil || sessionId != "session" || hash != firstHash {
+		t.Fatalf("expected the refresh token to match its session and hash, got %s, %v", sessionId, err)
+	}
+
+	for _, malformed := range []string{"", "session", "session.", ".secret"} {
+		if _, _, err := s.ParseRefreshToken(malformed); err == nil {
+			t.Fatalf("expected %q to be rejected", malformed)
+		}
+	}
+}
+
+func TestAccessTokenOfSession(t *testing.T) {
+	s := testDoorkeeperService(t)
+
+	token, err := s.GenerateToken(testEmployee("employee", 0), "session")
+	if err != nil {
+		t.Fatalf("unable to generate an access token: %s", err)
+	}
+
+	claims, err := s.VerifyAndParseToken(context.Background(), token)
+	if err != nil {
+		t.Fatalf("unable to verify the access token: %s", err)
+	}
+	if claims.EmployeeID != "employee" || claims.SessionID != "session" {
+		t.Fatalf("expected the token of the employee's session, got %+v", claims)
+	}
+
+	if _, err := s.VerifyAndParseToken(context.Background(), token+"x"); err == nil {
+		t.Fatalf("expected a tampered token to be rejected")
+	}
+}
+
+func TestTokenVersionOfAccessToken(t *testing.T) {
+	s := testDoorkeeperService(t)
+
+	for _, version := range []int{0, 3} {
+		token, err := s.GenerateToken(testEmployee("employee", version), "session")
+		if err != nil {
+			t.Fatalf("unable to generate an access token: %s", err)
+		}
+
+		claims, err := s.VerifyAndParseToken(context.Background(), token)
+		if err != nil {
+			t.Fatalf("unable to verify the access token: %s", err)
+		}
+		if claims.TokenVersion != version {
+			t.Fatalf("expected the token version %d, got %d", version, claims.TokenVersion)
+		}
+	}
+}
+
+func TestPasswordResetToken(t *testing.T) {
+	s := testDoorkeeperService(t)
+
+	token, hash, err := s.GenerateResetToken()
+	if err != nil {
+		t.Fatalf("unable to generate a reset token: %s", err)
+	}
+	if token == hash || s.HashResetToken(token) != hash {
+		t.Fatalf("expected only the hash of the reset token to be stored")
+	}
+
+	other, _, err := s.GenerateResetToken()
+	if err != nil || other == token || s.HashResetToken(other) == hash {
+		t.Fatalf("expected each reset token to be unique")
+	}
+}
+
+func TestEncryptedOTPSecret(t *testing.T) {
+	s := testDoorkeeperService(t)
+
+	secret, encrypted, err := s.GenerateOTPSecret()
+	if err != nil {
+		t.Fatalf("unable to generate an otp secret: %s", err)
+	}
+	if encrypted == secret {
+		t.Fatalf("expected the otp secret to be stored encrypted")
+	}
+
+	decrypted, err := s.DecryptOTPSecret(encrypted)
+	if err != nil || decrypted != secret {
+		t.Fatalf("expected the otp secret back, got %q, %v", decrypted, err)
+	}
+
+	tampered := []byte(encrypted)
+	tampered[len(tampered)/2] ^= 1
+	if _, err := s.DecryptOTPSecret(string(tampered)); err == nil {
+		t.Fatalf("expected a tampered otp secret to be rejected")
+	}
+
+	otp, timestamp, _ := s.GenerateOTP(secret)
+	if !s.VerifyOTP(secret, otp, timestamp) {
+		t.Fatalf("expected the otp sent by email to be accepted")
+	}
+}
+
+func TestVerifyingAuthenticatorOTP(t *testing.T) {
+	s := testDoorkeeperService(t)
+	secret, _, err := s.GenerateOTPSecret()
+	if err != nil {
+		t.Fatalf("unable to generate an otp secret: %s", err)
+	}
+
+	at := time.Unix(1712553600, 0)
+	step := at.Unix() / totpInterval
+	otp := gotp.NewDefaultTOTP(secret).At(at.Unix())
+
+	cases := []struct {
+		name  string
+		drift time.Duration
+		ok    bool
+	}{
+		{"on time", 0, true},
+		{"a step late", totpInterval * time.Second, true},
+		{"a step early", -totpInterval * time.Second, true},
+		{"two steps late", 2 * totpInterval * time.Second, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, ok := s.VerifyTOTP(secret, otp, at.Add(c.drift))
+			if ok != c.ok || (ok && got != step) {
+				t.Fatalf("expected %v at the step %d, got %v at %d", c.ok, step, ok, got)
+			}
+		})
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	s := testDoorkeeperService(t)
+
+	codes, hashes, err := s.GenerateRecoveryCodes(10)
+	if err != nil || len(codes) != 10 || len(hashes) != 10 {
+		t.Fatalf("expected 10 recovery codes, got %d, %v", len(codes), err)
+	}
+
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		if len(code) != 11 || code[5] != '-' || seen[code] {
+			t.Fatalf("expected unique codes formatted as xxxxx-xxxxx, got %q", code)
+		}
+		seen[code] = true
+
+		if s.HashRecoveryCode(code) != hashes[i] {
+			t.Fatalf("expected the hash of %q to match", code)
+		}
+	}
+
+	// People often type the code in upper case or without the separator
+	code := codes[0]
+	for _, typed := range []string{code[:5] + code[6:], " " + strings.ToUpper(code) + " ", code[:5] + " " + code[6:]} {
+		if s.HashRecoveryCode(typed) != hashes[0] {
+			t.Fatalf("expected %q to match the recovery code %q", typed, code)
+		}
+	}
+}
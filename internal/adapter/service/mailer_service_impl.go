@@ -20,6 +20,8 @@ const (
 	PROCESSED_LEAVE_BY_MANAGER    string = "PROCESSED_LEAVE_BY_MANAGER"
 	PROCESSED_LEAVE_BY_HR         string = "PROCESSED_LEAVE_BY_HR"
 	FWD_LEAVE_PROPOSAL            string = "FWD_LEAVE_PROPOSAL"
+	ACCOUNT_LOCKED                string = "ACCOUNT_LOCKED"
+	TWO_FACTOR_CHANGED            string = "TWO_FACTOR_CHANGED"
 )
 
 type mailerService struct {
@@ -96,5 +98,13 @@ func (s *mailerService) loadBody(message *gomail.Message, body *bytes.Buffer, ma
 		t := template.Must(template.ParseFiles(s.mailer.TemplatePath + "/forward_leave_proposal.gohtml"))
 		t.ExecuteTemplate(body, "forward_leave_proposal.gohtml", data)
 		message.SetHeader("Subject", "Your Staff is Requesting a Leave")
+	case ACCOUNT_LOCKED:
+		t := template.Must(template.ParseFiles(s.mailer.TemplatePath + "/account_locked.gohtml"))
+		t.ExecuteTemplate(body, "account_locked.gohtml", data)
+		message.SetHeader("Subject", "Your Account Has Been Locked")
+	case TWO_FACTOR_CHANGED:
+		t := template.Must(template.ParseFiles(s.mailer.TemplatePath + "/two_factor_changed.gohtml"))
+		t.ExecuteTemplate(body, "two_factor_changed.gohtml", data)
+		message.SetHeader("Subject", "Your Two-Factor Authentication Has Changed")
 	}
 }
@@ -33,21 +33,9 @@ type collection struct {
 	mu      sync.Mutex
 }
 
-func init() {
-	t := time.NewTicker(10 * time.Second)
-
-	go func() {
-		for range t.C {
-			for key := range psService.collections {
-				if len(psService.collections[key].clients) == 0 {
-					psService.collections[key].topic.Stop()
-					delete(psService.collections, key)
-				}
-			}
-		}
-	}()
-}
-
+// NewPubSubService returns the Google Cloud Pub/Sub backend of the
+// chats, with a topic for each room and a subscription for each
+// listener of the room.
 func NewPubSubService(ps *pubsub.Client) *pubsubService {
 	if psService == nil {
 		once.Do(func() {
@@ -55,12 +43,32 @@ func NewPubSubService(ps *pubsub.Client) *pubsubService {
 
 			psService.collections = make(map[string]*collection)
 			psService.ps = ps
+
+			psService.cleanInBackground()
 		})
 	}
 
 	return psService
 }
 
+// cleanInBackground stops the topics no client listens to anymore.
+func (service *pubsubService) cleanInBackground() {
+	t := time.NewTicker(10 * time.Second)
+
+	go func() {
+		for range t.C {
+			service.mu.Lock()
+			for key := range service.collections {
+				if len(service.collections[key].clients) == 0 {
+					service.collections[key].topic.Stop()
+					delete(service.collections, key)
+				}
+			}
+			service.mu.Unlock()
+		}
+	}()
+}
+
 // PublshChat publishes a chat into the pubsub service.
 // This  will then be consumed by listeners, if there are.
 func (service *pubsubService) PublishChat(ctx context.Context, topicId string, publisherId string, payload entity.Chat) error {
@@ -87,8 +95,11 @@ func (service *pubsubService) PublishChat(ctx context.Context, topicId string, p
 
 // SubscribeChat lets a user to be subscribed to
 // a topic (in this case is a room) and will receive
-// incoming messages in that room.
-func (service *pubsubService) SubscribeChat(ctx context.Context, topicId, listenerId string, channel chan entity.Chat) error {
+// incoming messages in that room. The subscription of
+// the listener keeps the messages published while it
+// was away, hence they are received again without the
+// last seen chat.
+func (service *pubsubService) SubscribeChat(ctx context.Context, topicId, listenerId, lastSeenId string, channel chan entity.Chat) error {
 	topicId = roomPrefix + topicId
 	subId := topicId + "-" + listenerPrefix + listenerId
 	sub, err := service.findOrCreateSubscription(ctx, topicId, subId)
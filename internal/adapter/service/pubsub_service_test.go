@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sinarlog.com/internal/app/service"
+	"sinarlog.com/internal/entity"
+)
+
+// The same cases run against every backend available. Redis runs
+// against TEST_REDIS_ADDRESS, e.g. a local instance at localhost:6379,
+// and Google Cloud Pub/Sub against the emulator at PUBSUB_EMULATOR_HOST.
+func pubsubBackends(t *testing.T) map[string]service.IPubSubService {
+	backends := make(map[string]service.IPubSubService)
+
+	if addr := os.Getenv("TEST_REDIS_ADDRESS"); addr != "" {
+		client := redis.NewClient(&redis.Options{Addr: addr})
+		if err := client.Ping(context.Background()).Err(); err != nil {
+			t.Fatalf("unable to reach redis at %s: %s", addr, err)
+		}
+		backends["redis"] = NewRedisPubSubService(client)
+	}
+
+	if os.Getenv("PUBSUB_EMULATOR_HOST") != "" {
+		client, err := pubsub.NewClient(context.Background(), "sinarlog-test")
+		if err != nil {
+			t.Fatalf("unable to reach the pubsub emulator: %s", err)
+		}
+		backends["gcp"] = NewPubSubService(client)
+	}
+
+	if len(backends) == 0 {
+		t.Skip("no pubsub backend, set TEST_REDIS_ADDRESS or PUBSUB_EMULATOR_HOST")
+	}
+
+	return backends
+}
+
+// subscribe listens to the room in the background until the test ends
+// or the returned cancel is called.
+func subscribe(t *testing.T, ps service.IPubSubService, roomId, listenerId, lastSeenId string) (chan entity.Chat, context.CancelFunc) {
+	channel := make(chan entity.Chat)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	go func() {
+		if err := ps.SubscribeChat(ctx, roomId, listenerId, lastSeenId, channel); err != nil {
+			t.Errorf("unable to subscribe: %s", err)
+		}
+	}()
+
+	// Gives the subscription time to be set up before publishing
+	time.Sleep(3 * time.Second)
+
+	return channel, cancel
+}
+
+func publish(t *testing.T, ps service.IPubSubService, roomId, message string) entity.Chat {
+	_id, _ := primitive.ObjectIDFromHex(roomId)
+	chat := entity.Chat{
+		Id:        primitive.NewObjectID(),
+		RoomId:    _id,
+		Sender:    "sender",
+		Message:   message,
+		Timestamp: primitive.Timestamp{T: uint32(time.Now().Unix())},
+	}
+
+	if err := ps.PublishChat(context.Background(), roomId, chat.Sender, chat); err != nil {
+		t.Fatalf("unable to publish: %s", err)
+	}
+
+	return chat
+}
+
+func expectChat(t *testing.T, channel chan entity.Chat, want entity.Chat) {
+	select {
+	case got := <-channel:
+		if got.Id != want.Id || got.Message != want.Message {
+			t.Fatalf("expected chat %s %q, got %s %q", want.Id.Hex(), want.Message, got.Id.Hex(), got.Message)
+		}
+	case <-time.After(15 * time.Second):
+		t.Fatalf("expected chat %s %q, got nothing", want.Id.Hex(), want.Message)
+	}
+}
+
+func TestPubSubFansOutToEveryListener(t *testing.T) {
+	for name, ps := range pubsubBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			roomId := primitive.NewObjectID().Hex()
+
+			first, _ := subscribe(t, ps, roomId, "first", "")
+			second, _ := subscribe(t, ps, roomId, "second", "")
+
+			chat := publish(t, ps, roomId, "hello")
+			expectChat(t, first, chat)
+			expectChat(t, second, chat)
+		})
+	}
+}
+
+func TestPubSubResumesFromLastSeenChat(t *testing.T) {
+	for name, ps := range pubsubBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			roomId := primitive.NewObjectID().Hex()
+
+			channel, cancel := subscribe(t, ps, roomId, "listener", "")
+			seen := publish(t, ps, roomId, "before")
+			expectChat(t, channel, seen)
+			cancel()
+
+			// Published while the listener is away
+			missed := publish(t, ps, roomId, "while away")
+
+			channel, _ = subscribe(t, ps, roomId, "listener", seen.Id.Hex())
+			expectChat(t, channel, missed)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 
+	"sinarlog.com/internal/entity/vo"
 	"sinarlog.com/pkg/rater"
 )
 
@@ -14,12 +15,17 @@ func NewRaterService(rt *rater.Rater) *raterService {
 	return &raterService{rt}
 }
 
-func (service *raterService) IsClientAllowed(ctx context.Context, ip string) bool {
-	client, found := service.rt.GetClient(ip)
-	if !found {
-		client = service.rt.AddNewClient(ip)
-		return service.rt.IsClientAllowed(client)
+func (service *raterService) Allow(ctx context.Context, policy vo.RatePolicy, key string) (vo.RateLimit, error) {
+	res, err := service.rt.Allow(ctx, policy.Name+":"+key, policy.Limit, policy.Period)
+	if err != nil {
+		return vo.RateLimit{}, err
 	}
 
-	return service.rt.IsClientAllowed(client)
+	return vo.RateLimit{
+		Allowed:    res.Allowed,
+		Limit:      policy.Limit,
+		Remaining:  res.Remaining,
+		RetryAfter: res.RetryAfter,
+		ResetAfter: res.ResetAfter,
+	}, nil
 }
@@ -0,0 +1,495 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sinarlog.com/internal/entity"
+)
+
+var (
+	redisPsOnce    sync.Once
+	redisPsService *redisPubSubService
+
+	chatStreamPrefix string = "chat:room:"
+	// chatStreamMaxLen is about how many chats of a room are kept for
+	// the listeners coming back
+	chatStreamMaxLen int64 = 1000
+	// chatStreamIdleTTL is how long a room nobody chats in keeps its
+	// stream
+	chatStreamIdleTTL = 24 * time.Hour
+	// chatReadBlock is how long a read waits for new chats, hence how
+	// long a room newly listened to waits to be read
+	chatReadBlock = 2 * time.Second
+	// chatStreamClockSkew is how far the clocks of the replicas and
+	// Redis may be apart
+	chatStreamClockSkew = time.Minute
+	// chatListenerBuffer is how many chats a slow listener may fall
+	// behind before the chats are dropped for it
+	chatListenerBuffer = 64
+)
+
+type redisPubSubService struct {
+	client *redis.Client
+	// group is the consumer group of this replica on each stream, so
+	// every replica reads every chat of the rooms it has listeners of
+	group string
+
+	// mu guards the rooms and their listeners, never across a call to
+	// Redis
+	mu    sync.Mutex
+	rooms map[string]*streamRoom
+}
+
+// streamRoom is a room listened to on this replica.
+type streamRoom struct {
+	// setup serializes creating and destroying the consumer group of
+	// the replica on the stream, and guards grouped
+	setup   sync.Mutex
+	grouped bool
+
+	listeners []*streamListener
+	// joining is how many listeners wait for the consumer group, so
+	// the room is kept for them
+	joining int
+}
+
+// idle tells whether nobody listens to the room nor is about to.
+func (room *streamRoom) idle() bool {
+	return len(room.listeners) == 0 && room.joining == 0
+}
+
+type streamListener struct {
+	id      string
+	channel chan streamChat
+}
+
+type streamChat struct {
+	id   string
+	chat entity.Chat
+}
+
+// NewRedisPubSubService returns the Redis Streams backend of the
+// chats. Each room has a stream and each replica reads the streams of
+// the rooms it has listeners of through its own consumer group, then
+// fans the chats out to the listeners. A stream is trimmed to its
+// latest chats and expires once the room is idle.
+func NewRedisPubSubService(client *redis.Client) *redisPubSubService {
+	if redisPsService == nil {
+		redisPsOnce.Do(func() {
+			hostname, _ := os.Hostname()
+
+			redisPsService = &redisPubSubService{
+				client: client,
+				group:  hostname + "-" + uuid.NewString(),
+				rooms:  make(map[string]*streamRoom),
+			}
+
+			redisPsService.readInBackground()
+		})
+	}
+
+	return redisPsService
+}
+
+// PublishChat appends the chat to the stream of the room and keeps
+// the stream alive for another idle period.
+func (service *redisPubSubService) PublishChat(ctx context.Context, topicId string, publisherId string, payload entity.Chat) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("json marshal error: %s", err)
+	}
+
+	key := chatStreamPrefix + topicId
+	pipe := service.client.TxPipeline()
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: key,
+		MaxLen: chatStreamMaxLen,
+		Approx: true,
+		Values: map[string]any{
+			"id":        payload.Id.Hex(),
+			"publisher": publisherId,
+			"chat":      data,
+		},
+	})
+	pipe.Expire(ctx, key, chatStreamIdleTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SubscribeChat registers the listener to the room until the context
+// is done. With the last seen chat, the chats after it still kept in
+// the stream are sent first.
+func (service *redisPubSubService) SubscribeChat(ctx context.Context, topicId, listenerId, lastSeenId string, channel chan entity.Chat) error {
+	key := chatStreamPrefix + topicId
+
+	// Listens before catching up so no chat falls in between
+	listener, err := service.addListener(ctx, key, listenerId)
+	if err != nil {
+		return err
+	}
+	defer service.removeListener(key, listener)
+
+	var caughtUpTo string
+	if lastSeenId != "" {
+		missed, err := service.missedChats(ctx, key, lastSeenId)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range missed {
+			select {
+			case <-ctx.Done():
+				return nil
+			case channel <- v.chat:
+				caughtUpTo = v.id
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case v := <-listener.channel:
+			if caughtUpTo != "" && compareStreamIds(v.id, caughtUpTo) <= 0 {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case channel <- v.chat:
+			}
+		}
+	}
+}
+
+// UnregisterClient does nothing since a listener is unregistered once
+// its subscription is done.
+func (service *redisPubSubService) UnregisterClient(ctx context.Context, topicId, listenerId string) error {
+	return nil
+}
+
+// missedChats returns the chats of the stream after the last seen
+// chat. A stream entry is added after its chat is created, hence the
+// entries from the creation of the last seen chat onwards are enough,
+// give or take the clock skew between the replicas and Redis. If the
+// last seen chat is no longer kept, all of them are returned.
+func (service *redisPubSubService) missedChats(ctx context.Context, key, lastSeenId string) ([]streamChat, error) {
+	_id, err := primitive.ObjectIDFromHex(lastSeenId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid last seen chat: %w", err)
+	}
+
+	from := _id.Timestamp().Add(-chatStreamClockSkew).UnixMilli()
+	msgs, err := service.client.XRange(ctx, key, strconv.FormatInt(from, 10), "+").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var chats []streamChat
+	for _, msg := range msgs {
+		if msg.Values["id"] == lastSeenId {
+			chats = nil
+			continue
+		}
+
+		chat, err := decodeStreamChat(msg)
+		if err != nil {
+			log.Printf("Unable to decode chat %s of %s: %s", msg.ID, key, err)
+			continue
+		}
+		chats = append(chats, chat)
+	}
+
+	return chats, nil
+}
+
+// addListener registers the listener to the room. The first listener
+// of the room on this replica creates the consumer group of the
+// replica on the stream, which reads the chats published from then.
+func (service *redisPubSubService) addListener(ctx context.Context, key, listenerId string) (*streamListener, error) {
+	service.mu.Lock()
+	room, ok := service.rooms[key]
+	if !ok {
+		room = &streamRoom{}
+		service.rooms[key] = room
+	}
+	room.joining++
+	service.mu.Unlock()
+
+	room.setup.Lock()
+	defer room.setup.Unlock()
+
+	if !room.grouped {
+		if err := service.createGroup(ctx, key); err != nil {
+			service.mu.Lock()
+			room.joining--
+			service.forgetIdleRoom(key, room)
+			service.mu.Unlock()
+			return nil, err
+		}
+		service.removeStaleGroups(ctx, key)
+		room.grouped = true
+	}
+
+	listener := &streamListener{
+		id:      listenerId,
+		channel: make(chan streamChat, chatListenerBuffer),
+	}
+
+	service.mu.Lock()
+	room.joining--
+	room.listeners = append(room.listeners, listener)
+	service.mu.Unlock()
+
+	return listener, nil
+}
+
+// removeListener unregisters the listener from the room. The last
+// listener of the room on this replica destroys the consumer group of
+// the replica on the stream, unless another one joins meanwhile.
+func (service *redisPubSubService) removeListener(key string, listener *streamListener) {
+	service.mu.Lock()
+	room, ok := service.rooms[key]
+	if !ok {
+		service.mu.Unlock()
+		return
+	}
+	for i, v := range room.listeners {
+		if v == listener {
+			room.listeners = append(room.listeners[:i], room.listeners[i+1:]...)
+			break
+		}
+	}
+	idle := room.idle()
+	service.mu.Unlock()
+
+	if !idle {
+		return
+	}
+
+	room.setup.Lock()
+	defer room.setup.Unlock()
+
+	service.mu.Lock()
+	idle = room.idle()
+	service.mu.Unlock()
+	if !idle {
+		return
+	}
+
+	if room.grouped {
+		if err := service.client.XGroupDestroy(context.Background(), key, service.group).Err(); err != nil {
+			log.Printf("Unable to destroy the consumer group of %s: %s", key, err)
+		}
+		room.grouped = false
+	}
+
+	// The room is forgotten only once its group is destroyed, so a
+	// listener joining it in the meantime creates the group again
+	service.mu.Lock()
+	service.forgetIdleRoom(key, room)
+	service.mu.Unlock()
+}
+
+// forgetIdleRoom removes the room once nobody listens to it. It must
+// be called with service.mu held.
+func (service *redisPubSubService) forgetIdleRoom(key string, room *streamRoom) {
+	if room.idle() && service.rooms[key] == room {
+		delete(service.rooms, key)
+	}
+}
+
+// createGroup creates the consumer group of the replica on the
+// stream, and the stream itself if nobody has chatted in the room for
+// an idle period.
+func (service *redisPubSubService) createGroup(ctx context.Context, key string) error {
+	err := service.client.XGroupCreateMkStream(ctx, key, service.group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	return service.client.Expire(ctx, key, chatStreamIdleTTL).Err()
+}
+
+// removeStaleGroups destroys the consumer groups left on the stream by
+// the replicas which are gone, whose consumers have not read for an
+// idle period.
+func (service *redisPubSubService) removeStaleGroups(ctx context.Context, key string) {
+	groups, err := service.client.XInfoGroups(ctx, key).Result()
+	if err != nil {
+		return
+	}
+
+	for _, group := range groups {
+		if group.Name == service.group {
+			continue
+		}
+
+		consumers, err := service.client.XInfoConsumers(ctx, key, group.Name).Result()
+		if err != nil {
+			continue
+		}
+
+		stale := true
+		for _, consumer := range consumers {
+			if consumer.Idle < chatStreamIdleTTL {
+				stale = false
+				break
+			}
+		}
+		if stale {
+			service.client.XGroupDestroy(ctx, key, group.Name)
+		}
+	}
+}
+
+// readInBackground reads the new chats of the rooms listened to on
+// this replica, all of them at once, and fans them out.
+func (service *redisPubSubService) readInBackground() {
+	go func() {
+		ctx := context.Background()
+
+		for {
+			keys := service.listenedRooms()
+			if len(keys) == 0 {
+				time.Sleep(chatReadBlock)
+				continue
+			}
+
+			streams := append([]string{}, keys...)
+			for range keys {
+				streams = append(streams, ">")
+			}
+
+			res, err := service.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+				Group:    service.group,
+				Consumer: service.group,
+				Streams:  streams,
+				Count:    100,
+				Block:    chatReadBlock,
+			}).Result()
+			if err == redis.Nil {
+				continue
+			}
+			if err != nil {
+				// The stream of an idle room has expired along with the
+				// consumer group of the replica
+				if strings.HasPrefix(err.Error(), "NOGROUP") {
+					for _, key := range service.listenedRooms() {
+						service.createGroup(ctx, key)
+					}
+					continue
+				}
+
+				log.Printf("Unable to read the chat streams: %s", err)
+				time.Sleep(chatReadBlock)
+				continue
+			}
+
+			for _, stream := range res {
+				var ids []string
+				for _, msg := range stream.Messages {
+					ids = append(ids, msg.ID)
+
+					chat, err := decodeStreamChat(msg)
+					if err != nil {
+						log.Printf("Unable to decode chat %s of %s: %s", msg.ID, stream.Stream, err)
+						continue
+					}
+					service.fanOut(stream.Stream, chat)
+				}
+
+				if len(ids) > 0 {
+					service.client.XAck(ctx, stream.Stream, service.group, ids...)
+				}
+			}
+		}
+	}()
+}
+
+func (service *redisPubSubService) listenedRooms() []string {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	keys := make([]string, 0, len(service.rooms))
+	for key, room := range service.rooms {
+		if len(room.listeners) > 0 {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// fanOut sends the chat to every listener of the room without waiting
+// for a slow one, which rather misses the chat.
+func (service *redisPubSubService) fanOut(key string, chat streamChat) {
+	var listeners []*streamListener
+	service.mu.Lock()
+	if room, ok := service.rooms[key]; ok {
+		listeners = append(listeners, room.listeners...)
+	}
+	service.mu.Unlock()
+
+	for _, listener := range listeners {
+		select {
+		case listener.channel <- chat:
+		default:
+			log.Printf("Listener %s of %s is too slow, chat %s is dropped", listener.id, key, chat.id)
+		}
+	}
+}
+
+func decodeStreamChat(msg redis.XMessage) (streamChat, error) {
+	data, ok := msg.Values["chat"].(string)
+	if !ok {
+		return streamChat{}, fmt.Errorf("missing chat")
+	}
+
+	var chat entity.Chat
+	if err := json.Unmarshal([]byte(data), &chat); err != nil {
+		return streamChat{}, err
+	}
+
+	return streamChat{id: msg.ID, chat: chat}, nil
+}
+
+// compareStreamIds compares two stream entry ids, <ms>-<seq>, the way
+// strings.Compare does.
+func compareStreamIds(a, b string) int {
+	am, as := splitStreamId(a)
+	bm, bs := splitStreamId(b)
+
+	switch {
+	case am < bm || (am == bm && as < bs):
+		return -1
+	case am > bm || (am == bm && as > bs):
+		return 1
+	}
+
+	return 0
+}
+
+func splitStreamId(id string) (int64, int64) {
+	ms, seq, _ := strings.Cut(id, "-")
+	m, _ := strconv.ParseInt(ms, 10, 64)
+	s, _ := strconv.ParseInt(seq, 10, 64)
+
+	return m, s
+}
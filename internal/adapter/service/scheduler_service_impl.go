@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"sinarlog.com/internal/entity/vo"
+	"sinarlog.com/pkg/scheduler"
+)
+
+type schedulerService struct {
+	sch *scheduler.Scheduler
+}
+
+func NewSchedulerService(sch *scheduler.Scheduler) *schedulerService {
+	return &schedulerService{sch}
+}
+
+func (service *schedulerService) RegisterJob(name string, schedule vo.JobSchedule, run func(ctx context.Context) error) {
+	service.sch.Register(name, scheduler.Schedule(schedule), run)
+}
+
+func (service *schedulerService) GetScheduledJobs(ctx context.Context) ([]vo.ScheduledJob, error) {
+	infos, err := service.sch.Jobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []vo.ScheduledJob
+	for _, v := range infos {
+		job := vo.ScheduledJob{Name: v.Name, NextRunAt: v.NextRunAt}
+		if !v.LastRunAt.IsZero() {
+			last := v.LastRunAt
+			job.LastRunAt = &last
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+func (service *schedulerService) LockJob(ctx context.Context, name string, ttl time.Duration) (func(), bool, error) {
+	return service.sch.Lock(ctx, name, ttl)
+}
@@ -0,0 +1,24 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"sinarlog.com/internal/entity"
+)
+
+type IApprovalRepo interface {
+	GetApprovalChains(ctx context.Context) (entity.ApprovalChains, error)
+	GetApprovalChainsByKind(ctx context.Context, kind entity.ApprovalKind) (entity.ApprovalChains, error)
+	GetApprovalChainById(ctx context.Context, id string) (entity.ApprovalChain, error)
+	CreateApprovalChain(ctx context.Context, chain entity.ApprovalChain) error
+	UpdateApprovalChain(ctx context.Context, chain entity.ApprovalChain) error
+	DeleteApprovalChain(ctx context.Context, id string) error
+
+	GetApprovalDelegations(ctx context.Context, since time.Time) ([]entity.ApprovalDelegation, error)
+	GetApprovalDelegationsByEmployeeId(ctx context.Context, employeeId string, since time.Time) ([]entity.ApprovalDelegation, error)
+	GetApprovalDelegationById(ctx context.Context, id string) (entity.ApprovalDelegation, error)
+	GetActiveDelegatorIds(ctx context.Context, delegateId string, at time.Time) ([]string, error)
+	CreateApprovalDelegation(ctx context.Context, delegation entity.ApprovalDelegation) error
+	DeleteApprovalDelegation(ctx context.Context, id string) error
+}
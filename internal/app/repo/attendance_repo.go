@@ -12,6 +12,7 @@ type IAttendanceRepo interface {
 	EmployeeHasClockedInToday(ctx context.Context, employeeId string) (bool, error)
 	SaveClockInOTPTimestamp(ctx context.Context, emplooyeeId string, timestamp int64, exp time.Duration) error
 	GetClockInOTPTimestamp(ctx context.Context, employeeId string) (int64, error)
+	ConsumeClockInOTPTimestamp(ctx context.Context, employeeId string) (bool, error)
 	CreateNewAttendance(ctx context.Context, attendance entity.Attendance) error
 	EmployeeHasActiveAttendance(ctx context.Context, employeeId string) (bool, error)
 	GetTodaysAttendanceByEmployeeId(ctx context.Context, employeeId string) (entity.Attendance, error)
@@ -24,11 +25,18 @@ type IAttendanceRepo interface {
 	GetEmployeesAttendanceHistory(ctx context.Context, q vo.HistoryAttendancesQuery) ([]entity.Attendance, vo.PaginationDTOResponse, error)
 	GetEmployeesTodaysAttendances(ctx context.Context, q vo.HistoryAttendancesQuery) ([]entity.Attendance, vo.PaginationDTOResponse, error)
 
-	GetIncomingOvertimeSubmissionsForManager(ctx context.Context, managerId string, q vo.IncomingOvertimeSubmissionsQuery) ([]entity.Overtime, vo.PaginationDTOResponse, error)
+	GetIncomingOvertimeSubmissionsForManager(ctx context.Context, approverIds []string, q vo.IncomingOvertimeSubmissionsQuery) ([]entity.Overtime, vo.PaginationDTOResponse, error)
 	GetOvertimeById(ctx context.Context, overtimeId string) (entity.Overtime, error)
 	SaveProcessedOvertimeSubmissionByManager(ctx context.Context, overtime entity.Overtime) error
 	GetOvertimeSubmissionHistoryForManager(ctx context.Context, managerId string, q vo.LeaveProposalHistoryQuery) ([]entity.Overtime, vo.PaginationDTOResponse, error)
+	GetIncomingOvertimeSubmissionsForHr(ctx context.Context, q vo.IncomingOvertimeSubmissionsQuery) ([]entity.Overtime, vo.PaginationDTOResponse, error)
+	SaveProcessedOvertimeSubmissionByHr(ctx context.Context, overtime entity.Overtime) error
 	GetOvertimeSubmissionHistoryForHr(ctx context.Context, q vo.LeaveProposalHistoryQuery) ([]entity.Overtime, vo.PaginationDTOResponse, error)
 
 	GetMyOvertimeSubmissions(ctx context.Context, employeeId string, q vo.MyOvertimeSubmissionsQuery) ([]entity.Overtime, vo.PaginationDTOResponse, error)
+
+	// Scheduler
+	GetOpenAttendances(ctx context.Context, before time.Time) ([]entity.Attendance, error)
+	CloseAttendances(ctx context.Context, ids []string) (int64, error)
+	CloseStaleOvertimeSubmissions(ctx context.Context, before time.Time) (int64, error)
 }
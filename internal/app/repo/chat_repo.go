@@ -5,6 +5,7 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/entity/vo"
 )
 
 type IChatRepo interface {
@@ -12,7 +13,20 @@ type IChatRepo interface {
 	CreateRoom(ctx context.Context, room entity.Room) (entity.Room, error)
 	FindRoom(ctx context.Context, room entity.Room) (entity.Room, error)
 	FindRoomByID(ctx context.Context, id string) (entity.Room, error)
-	GetChatsByRoomId(ctx context.Context, roomId primitive.ObjectID, readerId string) ([]entity.Chat, error)
+	FindTeamRoom(ctx context.Context, managerId string) (entity.Room, error)
+	GetRoomsByParticipant(ctx context.Context, employeeId string) ([]entity.Room, error)
+	GetRoomSummaries(ctx context.Context, employeeId string) ([]vo.RoomSummary, error)
+	UpdateRoom(ctx context.Context, room entity.Room) error
+	UpdateRoomMetadata(ctx context.Context, room entity.Room) error
+	AddRoomParticipants(ctx context.Context, roomId primitive.ObjectID, employeeIds []string) error
+	RemoveRoomParticipant(ctx context.Context, roomId primitive.ObjectID, employeeId string) error
+	UpdateRoomAdmins(ctx context.Context, roomId primitive.ObjectID, admins []string) error
+	GetChatsByRoomId(ctx context.Context, roomId primitive.ObjectID, q vo.ChatPageQuery) (vo.ChatPage, error)
+	GetChatContext(ctx context.Context, roomId, chatId primitive.ObjectID, size int) (vo.ChatPage, error)
+	SearchChats(ctx context.Context, roomIds []primitive.ObjectID, q vo.ChatSearchQuery) (vo.ChatPage, error)
+	FindChatById(ctx context.Context, roomId primitive.ObjectID, chatId string) (entity.Chat, error)
+	UpdateReadCursor(ctx context.Context, roomId primitive.ObjectID, employeeId string, chatId primitive.ObjectID) error
+	CountUnreadChats(ctx context.Context, room entity.Room, employeeId string) (int64, error)
 
 	CreateNewMessage(ctx context.Context, userId, roomId, message string) (entity.Chat, error)
 }
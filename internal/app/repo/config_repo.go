@@ -2,6 +2,7 @@ package repo
 
 import (
 	"context"
+	"time"
 
 	"sinarlog.com/internal/entity"
 	"sinarlog.com/internal/entity/vo"
@@ -12,4 +13,13 @@ type IConfigRepo interface {
 	SaveNextDayChangesAndLogs(ctx context.Context, config entity.Configuration, logs entity.ConfigurationChangesLog) error
 	SaveNextMonthChangesAndLogs(ctx context.Context, config entity.Configuration, logs entity.ConfigurationChangesLog) error
 	GetConfigChangesLogs(ctc context.Context, q vo.CommonQuery) ([]entity.ConfigurationChangesLog, vo.PaginationDTOResponse, error)
+	GetPendingConfigChanges(ctx context.Context, scope entity.ConfigChangeScope) (entity.PendingConfigChanges, bool, error)
+	ApplyConfigChanges(ctx context.Context, scope entity.ConfigChangeScope, at time.Time) (bool, error)
+	CancelPendingConfigChanges(ctx context.Context, pending entity.PendingConfigChanges, hrId string, at time.Time) error
+
+	GetOfficeLocations(ctx context.Context) ([]entity.OfficeLocation, error)
+	GetOfficeLocationById(ctx context.Context, id string) (entity.OfficeLocation, error)
+	CreateOfficeLocation(ctx context.Context, office entity.OfficeLocation) error
+	UpdateOfficeLocation(ctx context.Context, office entity.OfficeLocation) error
+	DeleteOfficeLocation(ctx context.Context, id string) error
 }
@@ -2,6 +2,7 @@ package repo
 
 import (
 	"context"
+	"time"
 
 	"sinarlog.com/internal/entity"
 )
@@ -10,4 +11,44 @@ type ICredentialRepo interface {
 	GetEmployeeByEmail(ctx context.Context, email string) (entity.Employee, error)
 	GetEmployeeByIdV2(ctx context.Context, id string) (entity.Employee, error)
 	UpdateEmployeePassword(ctx context.Context, employee entity.Employee) error
+	BumpTokenVersion(ctx context.Context, employeeId string) error
+
+	// Sessions
+	CreateSession(ctx context.Context, session entity.Session) error
+	GetSessionById(ctx context.Context, id string) (entity.Session, error)
+	GetSessionsByEmployeeId(ctx context.Context, employeeId string) ([]entity.Session, error)
+	RotateSessionRefreshToken(ctx context.Context, session entity.Session, prevHash string) (bool, error)
+	TouchSession(ctx context.Context, id string, at time.Time) error
+	DeleteSession(ctx context.Context, session entity.Session) error
+	DeleteSessionsByEmployeeId(ctx context.Context, employeeId string) error
+
+	// Password reset
+	CountPasswordResetRequests(ctx context.Context, email string, window time.Duration) (int64, error)
+	SavePasswordResetToken(ctx context.Context, employeeId, hash string, exp time.Duration) error
+	ConsumePasswordResetToken(ctx context.Context, hash string) (string, error)
+
+	// OTP
+	GetEmployeeOTPSecret(ctx context.Context, employeeId string) (entity.EmployeeOTPSecret, bool, error)
+	SaveEmployeeOTPSecret(ctx context.Context, secret entity.EmployeeOTPSecret) error
+	UseOTPStep(ctx context.Context, employeeId string, step int64) (bool, error)
+	ResetEmployeeOTPSecret(ctx context.Context, secret entity.EmployeeOTPSecret, log entity.EmployeeDataHistoryLog) error
+
+	// Two-factor login
+	ReplaceRecoveryCodes(ctx context.Context, employeeId string, hashes []string) error
+	UseRecoveryCode(ctx context.Context, employeeId, hash string, at time.Time) (bool, error)
+	CountUnusedRecoveryCodes(ctx context.Context, employeeId string) (int64, error)
+	SaveLoginChallenge(ctx context.Context, hash string, challenge entity.LoginChallenge) error
+	GetLoginChallenge(ctx context.Context, hash string) (entity.LoginChallenge, error)
+	CountLoginChallengeAttempts(ctx context.Context, hash string) (int64, error)
+	ConsumeLoginChallenge(ctx context.Context, hash string) (bool, error)
+
+	// Lockout
+	GetLoginFailures(ctx context.Context, subject string) (entity.LoginFailures, error)
+	RecordLoginFailure(ctx context.Context, subject string, at time.Time, window time.Duration) (entity.LoginFailures, error)
+	TakeLoginAttempt(ctx context.Context, subject string, at time.Time, window time.Duration) (entity.LoginFailures, entity.LoginFailures, error)
+	ReleaseLoginAttempt(ctx context.Context, subject string, at time.Time, previous entity.LoginFailures) error
+	ClearLoginFailures(ctx context.Context, subject string) error
+	LockAccount(ctx context.Context, employeeId string, until time.Time) error
+	GetAccountLock(ctx context.Context, employeeId string) (time.Time, bool, error)
+	UnlockAccount(ctx context.Context, employeeId string) error
 }
@@ -17,7 +17,6 @@ type IEmployeeRepo interface {
 	GetBiodataByEmployeeId(ctx context.Context, employeeId string) (entity.EmployeeBiodata, error)
 	GetEmployeeFullProfileById(ctx context.Context, id string) (entity.Employee, error)
 	GetEmployeeFullNameById(ctx context.Context, id string) (string, error)
-	GetLeaveQuotaByEmployeeId(ctx context.Context, employeeId string) (entity.EmployeeLeavesQuota, error)
 	GetEmployeeSimpleInformationById(ctx context.Context, id string) (entity.Employee, error)
 
 	GetEmployeeChangesLog(ctx context.Context, employeeId string, q vo.CommonQuery) ([]entity.EmployeeDataHistoryLog, vo.PaginationDTOResponse, error)
@@ -25,5 +24,8 @@ type IEmployeeRepo interface {
 	SetEmployeeStatusTo(ctx context.Context, employeeId string, status entity.Status) error
 
 	GetAllManagersList(ctx context.Context) ([]entity.Employee, error)
-	GetAllEmployees(ctx context.Context, employeeId, role string, q vo.AllEmployeeQuery) ([]entity.Employee, vo.PaginationDTOResponse, error)
+	GetStaffIdsByManagerId(ctx context.Context, managerId string) ([]string, error)
+	GetManagerIds(ctx context.Context) ([]string, error)
+	GetActiveEmployeeIds(ctx context.Context, ids []string) ([]string, error)
+	GetAllEmployees(ctx context.Context, employeeId string, scope entity.PermissionScope, q vo.AllEmployeeQuery) ([]entity.Employee, vo.PaginationDTOResponse, error)
 }
@@ -2,26 +2,51 @@ package repo
 
 import (
 	"context"
+	"time"
 
 	"sinarlog.com/internal/entity"
 	"sinarlog.com/internal/entity/vo"
 )
 
 type ILeaveRepo interface {
-	EmployeeIsOnLeaveToday(ctx context.Context, employeeId string) (bool, error)
+	GetEmployeeLeavesToday(ctx context.Context, employeeId string) ([]entity.Leave, error)
 	GetMyLeaveRequestsList(ctx context.Context, employeeId string, q vo.LeaveProposalHistoryQuery) ([]entity.Leave, vo.PaginationDTOResponse, error)
-	CheckDateAvailability(ctx context.Context, leave entity.Leave) (bool, error)
+	CheckDateAvailability(ctx context.Context, leave entity.Leave, excludeIds ...string) (bool, error)
 	CreateLeave(ctx context.Context, leave entity.Leave) error
+	AmendLeave(ctx context.Context, amended entity.Leave, leave entity.Leave) error
+	SaveLeaveCancellation(ctx context.Context, actorId string, leave entity.Leave) error
 	GetLeaveById(ctx context.Context, id string) (entity.Leave, error)
 
-	GetIncomingLeaveProposalForManager(ctx context.Context, managerId string, q vo.IncomingLeaveProposals) ([]entity.Leave, vo.PaginationDTOResponse, error)
-	SaveProcessedLeaveByManager(ctx context.Context, leave entity.Leave) error
+	GetIncomingLeaveProposalForManager(ctx context.Context, approverIds []string, q vo.IncomingLeaveProposals) ([]entity.Leave, vo.PaginationDTOResponse, error)
+	SaveProcessedLeaveByManager(ctx context.Context, actorId string, leave entity.Leave) error
 	GetLeaveProposalHistoryForManager(ctx context.Context, managerId string, q vo.LeaveProposalHistoryQuery) ([]entity.Leave, vo.PaginationDTOResponse, error)
+	GetIncomingLeaveCancellationForManager(ctx context.Context, managerId string, q vo.IncomingLeaveProposals) ([]entity.Leave, vo.PaginationDTOResponse, error)
 
 	GetIncomingLeaveProposalForHr(ctx context.Context, q vo.IncomingLeaveProposals) ([]entity.Leave, vo.PaginationDTOResponse, error)
 	SaveProcessedLeaveByHr(ctx context.Context, leave entity.Leave) error
 	GetLeaveProposalHistoryForHr(ctx context.Context, q vo.LeaveProposalHistoryQuery) ([]entity.Leave, vo.PaginationDTOResponse, error)
+	GetIncomingLeaveCancellationForHr(ctx context.Context, q vo.IncomingLeaveProposals) ([]entity.Leave, vo.PaginationDTOResponse, error)
 
 	WhosTakingLeave(ctx context.Context, q vo.CommonQuery) (vo.WhosTakingLeaveList, error)
 	WhosTakingLeaveMobile(ctx context.Context, q vo.CommonQuery) ([]entity.Leave, vo.PaginationDTOResponse, error)
+
+	// Leave types
+	GetLeavePolicies(ctx context.Context) ([]entity.LeavePolicy, error)
+	GetLeavePolicyByCode(ctx context.Context, code entity.LeaveType) (entity.LeavePolicy, error)
+	GetLeavePolicyById(ctx context.Context, id string) (entity.LeavePolicy, error)
+	CreateLeavePolicy(ctx context.Context, policy entity.LeavePolicy) error
+	UpdateLeavePolicy(ctx context.Context, policy entity.LeavePolicy) error
+	DeleteLeavePolicy(ctx context.Context, id string) error
+	GetLeaveQuotasByEmployeeId(ctx context.Context, employeeId string) (entity.EmployeeLeavesQuota, error)
+
+	// Leave ledger
+	GetLeaveQuotaLedger(ctx context.Context, employeeId string, q vo.LeaveQuotaLedgerQuery) ([]entity.LeaveQuotaLedger, vo.PaginationDTOResponse, error)
+	AdjustLeaveQuota(ctx context.Context, entry entity.LeaveQuotaLedger) error
+
+	// Leave accrual
+	GetEmployeesForLeaveAccrual(ctx context.Context) ([]entity.Employee, error)
+	SaveLeaveQuotaAccrual(ctx context.Context, quota entity.EmployeeLeaveQuota, accrue func(quota *entity.EmployeeLeaveQuota) []entity.LeaveQuotaLedger) ([]entity.LeaveQuotaLedger, error)
+
+	// Scheduler
+	CloseStaleLeaveProposals(ctx context.Context, at time.Time) ([]entity.Leave, error)
 }
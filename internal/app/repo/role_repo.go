@@ -8,4 +8,10 @@ import (
 
 type IRoleRepo interface {
 	GetAllRoles(ctx context.Context) ([]entity.Role, error)
+	GetRoleById(ctx context.Context, id string) (entity.Role, error)
+	GetRoleByCode(ctx context.Context, code string) (entity.Role, error)
+	CreateRole(ctx context.Context, role entity.Role) error
+	UpdateRole(ctx context.Context, role entity.Role) error
+	DeleteRole(ctx context.Context, id string) error
+	CountEmployeesByRoleId(ctx context.Context, id string) (int64, error)
 }
@@ -0,0 +1,24 @@
+package repo
+
+import (
+	"context"
+
+	"sinarlog.com/internal/entity"
+)
+
+type IScheduleRepo interface {
+	GetWorkSchedules(ctx context.Context) ([]entity.WorkSchedule, error)
+	GetWorkScheduleById(ctx context.Context, id string) (entity.WorkSchedule, error)
+	CreateWorkSchedule(ctx context.Context, schedule entity.WorkSchedule) error
+	UpdateWorkSchedule(ctx context.Context, schedule entity.WorkSchedule) error
+	DeleteWorkSchedule(ctx context.Context, id string) error
+	AssignWorkSchedule(ctx context.Context, scheduleId *string, employeeIds []string, jobIds []string) error
+	GetEmployeeWorkSchedule(ctx context.Context, employeeId string) (*entity.WorkSchedule, error)
+
+	GetHolidays(ctx context.Context) ([]entity.Holiday, error)
+	GetHolidaysByYear(ctx context.Context, year int) ([]entity.Holiday, error)
+	GetHolidayById(ctx context.Context, id string) (entity.Holiday, error)
+	CreateHolidays(ctx context.Context, holidays []entity.Holiday) error
+	UpdateHoliday(ctx context.Context, holiday entity.Holiday) error
+	DeleteHoliday(ctx context.Context, id string) error
+}
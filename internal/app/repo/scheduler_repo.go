@@ -0,0 +1,15 @@
+package repo
+
+import (
+	"context"
+
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/entity/vo"
+)
+
+type ISchedulerRepo interface {
+	CreateJobRun(ctx context.Context, run entity.SchedulerJobRun) (entity.SchedulerJobRun, error)
+	SaveJobRun(ctx context.Context, run entity.SchedulerJobRun) error
+	GetLatestJobRuns(ctx context.Context) ([]entity.SchedulerJobRun, error)
+	GetJobRuns(ctx context.Context, jobName string, q vo.CommonQuery) ([]entity.SchedulerJobRun, vo.PaginationDTOResponse, error)
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"sinarlog.com/internal/entity/vo"
+)
+
+// IChatSignalService fans out the signals of the chat rooms, which are
+// not kept, and tracks who is online across the replicas.
+type IChatSignalService interface {
+	PublishSignal(ctx context.Context, signal vo.ChatSignal) error
+	// SubscribeSignals sends the signals of the room and the presence
+	// signals to the channel until the context is done.
+	SubscribeSignals(ctx context.Context, roomId string, channel chan vo.ChatSignal) error
+
+	// MarkOnline keeps the connection of the employee online for the
+	// ttl, and tells whether it is the only connection of the employee.
+	MarkOnline(ctx context.Context, employeeId, connectionId string, ttl time.Duration) (bool, error)
+	// MarkOffline ends the connection of the employee, and tells whether
+	// the employee has no connection left.
+	MarkOffline(ctx context.Context, employeeId, connectionId string) (bool, error)
+	// GetOnline returns which of the employees are online.
+	GetOnline(ctx context.Context, employeeIds []string) ([]string, error)
+}
@@ -5,16 +5,33 @@ import (
 	"time"
 
 	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/entity/vo"
 )
 
 type IDoorkeeperService interface {
 	HashPassword(pass string) ([]byte, error)
 	VerifyPassword(hash, password string) error
-	VerifyAndParseToken(ctx context.Context, tk string) (string, error)
+	VerifyAndParseToken(ctx context.Context, tk string) (vo.TokenClaims, error)
 
-	GenerateOTP() (string, int64, time.Duration)
-	VerifyOTP(otp string, timestamp int64) bool
+	GenerateOTPSecret() (string, string, error)
+	DecryptOTPSecret(encrypted string) (string, error)
+	GenerateOTP(secret string) (string, int64, time.Duration)
+	VerifyOTP(secret, otp string, timestamp int64) bool
+	VerifyTOTP(secret, otp string, at time.Time) (int64, bool)
+	OTPProvisioningURI(secret, accountName string) string
 
 	// V2
-	GenerateToken(employee entity.Employee) (string, error)
+	GenerateToken(employee entity.Employee, sessionId string) (string, error)
+	GenerateRefreshToken(sessionId string) (string, string, error)
+	ParseRefreshToken(tk string) (string, string, error)
+	GetRefreshDuration() time.Duration
+
+	GenerateResetToken() (string, string, error)
+	HashResetToken(tk string) string
+	GetResetExpDuration() time.Duration
+
+	GenerateChallengeToken() (string, string, error)
+	HashChallengeToken(tk string) string
+	GenerateRecoveryCodes(n int) ([]string, []string, error)
+	HashRecoveryCode(code string) string
 }
@@ -9,6 +9,8 @@ const (
 	PROCESSED_LEAVE_BY_MANAGER    string = "PROCESSED_LEAVE_BY_MANAGER"
 	PROCESSED_LEAVE_BY_HR         string = "PROCESSED_LEAVE_BY_HR"
 	FWD_LEAVE_PROPOSAL            string = "FWD_LEAVE_PROPOSAL"
+	ACCOUNT_LOCKED                string = "ACCOUNT_LOCKED"
+	TWO_FACTOR_CHANGED            string = "TWO_FACTOR_CHANGED"
 )
 
 type IMailerService interface {
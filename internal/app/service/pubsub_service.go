@@ -6,8 +6,17 @@ import (
 	"sinarlog.com/internal/entity"
 )
 
+// IPubSubService fans out the chats of a room, the topic, to its
+// listeners connected to any replica.
 type IPubSubService interface {
 	PublishChat(ctx context.Context, topicId string, publisherId string, payload entity.Chat) error
-	SubscribeChat(ctx context.Context, topicId, listenerId string, channel chan entity.Chat) error
+	// SubscribeChat sends the chats of the topic to the channel until
+	// the context is done. How a listener coming back receives the
+	// chats it missed depends on the backend. Redis sends the chats
+	// after lastSeenId still kept in the stream of the room. Google
+	// Cloud Pub/Sub ignores lastSeenId, the subscription of the
+	// listener holds the chats published since it was last
+	// subscribed, and a new listener misses the earlier ones.
+	SubscribeChat(ctx context.Context, topicId, listenerId, lastSeenId string, channel chan entity.Chat) error
 	UnregisterClient(ctx context.Context, topicId, listenerId string) error
 }
@@ -1,7 +1,13 @@
 package service
 
-import "context"
+import (
+	"context"
 
+	"sinarlog.com/internal/entity/vo"
+)
+
+// IRaterService limits the requests of each client, identified by a
+// key, shared by all the replicas.
 type IRaterService interface {
-	IsClientAllowed(ctx context.Context, ip string) bool
+	Allow(ctx context.Context, policy vo.RatePolicy, key string) (vo.RateLimit, error)
 }
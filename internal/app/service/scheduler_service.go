@@ -1,8 +1,20 @@
-// SCHEDULER is moved to its own repo because we were deploying it
-// in a horizontally scalable engine and we do not want to replicate
-// our scheduler. Do not expect the scheduler to work in this backend
-// service, because we do not initialize it.
-
 package service
 
-type ISchedulerService interface{}
+import (
+	"context"
+	"time"
+
+	"sinarlog.com/internal/entity/vo"
+)
+
+// ISchedulerService runs the jobs of the backend on their schedules.
+// Only one replica, the leader, runs the scheduled jobs, meanwhile
+// any replica may lock a job to run it right away.
+type ISchedulerService interface {
+	RegisterJob(name string, schedule vo.JobSchedule, run func(ctx context.Context) error)
+	GetScheduledJobs(ctx context.Context) ([]vo.ScheduledJob, error)
+	// LockJob locks the job on all replicas for at most the ttl. It
+	// returns false if the job is running already, otherwise the
+	// function unlocking the job.
+	LockJob(ctx context.Context, name string, ttl time.Duration) (func(), bool, error)
+}
@@ -0,0 +1,317 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sinarlog.com/internal/app/repo"
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/utils"
+)
+
+type approvalUseCase struct {
+	approvalRepo repo.IApprovalRepo
+	leaveRepo    repo.ILeaveRepo
+	emplRepo     repo.IEmployeeRepo
+}
+
+func NewApprovalUseCase(approvalRepo repo.IApprovalRepo, leaveRepo repo.ILeaveRepo, emplRepo repo.IEmployeeRepo) *approvalUseCase {
+	return &approvalUseCase{
+		approvalRepo: approvalRepo,
+		leaveRepo:    leaveRepo,
+		emplRepo:     emplRepo,
+	}
+}
+
+/*
+*********************************
+ACTOR: HR
+*********************************
+*/
+
+func (uc *approvalUseCase) RetrieveApprovalChains(ctx context.Context) (entity.ApprovalChains, error) {
+	chains, err := uc.approvalRepo.GetApprovalChains(ctx)
+	if err != nil {
+		return nil, NewRepositoryError("Approval Chain", err)
+	}
+
+	return chains, nil
+}
+
+// RegisterApprovalChain defines a new approval chain. It applies to
+// the requests made afterwards, meanwhile the pending requests keep
+// the steps they were given.
+func (uc *approvalUseCase) RegisterApprovalChain(ctx context.Context, payload entity.ApprovalChain) error {
+	if err := uc.validateApprovalChain(ctx, payload); err != nil {
+		return err
+	}
+
+	if err := uc.approvalRepo.CreateApprovalChain(ctx, payload); err != nil {
+		return NewRepositoryError("Approval Chain", err)
+	}
+
+	return nil
+}
+
+func (uc *approvalUseCase) UpdateApprovalChain(ctx context.Context, id string, payload entity.ApprovalChain) error {
+	chain, err := uc.approvalRepo.GetApprovalChainById(ctx, id)
+	if err != nil {
+		return NewNotFoundError("Approval Chain", err)
+	}
+
+	payload.Id = chain.Id
+	if err := uc.validateApprovalChain(ctx, payload); err != nil {
+		return err
+	}
+
+	if err := uc.approvalRepo.UpdateApprovalChain(ctx, payload); err != nil {
+		return NewRepositoryError("Approval Chain", err)
+	}
+
+	return nil
+}
+
+// RemoveApprovalChain deletes an approval chain. The requests it
+// applied to are approved by the next most specific chain, or else
+// by the default chain.
+func (uc *approvalUseCase) RemoveApprovalChain(ctx context.Context, id string) error {
+	if _, err := uc.approvalRepo.GetApprovalChainById(ctx, id); err != nil {
+		return NewNotFoundError("Approval Chain", err)
+	}
+
+	if err := uc.approvalRepo.DeleteApprovalChain(ctx, id); err != nil {
+		return NewRepositoryError("Approval Chain", err)
+	}
+
+	return nil
+}
+
+// validateApprovalChain validates the approval chain and makes sure
+// no other chain applies to the same requests.
+func (uc *approvalUseCase) validateApprovalChain(ctx context.Context, chain entity.ApprovalChain) error {
+	if err := chain.Validate(); err != nil {
+		return NewDomainError("Approval Chain", err)
+	}
+
+	if chain.LeaveType != "" {
+		if _, err := uc.leaveRepo.GetLeavePolicyByCode(ctx, chain.LeaveType); err != nil {
+			return NewDomainError("Approval Chain", fmt.Errorf("leave type %s does not exist", chain.LeaveType))
+		}
+	}
+
+	chains, err := uc.approvalRepo.GetApprovalChainsByKind(ctx, chain.Kind)
+	if err != nil {
+		return NewRepositoryError("Approval Chain", err)
+	}
+
+	for _, v := range chains {
+		if v.Id != chain.Id && v.LeaveType == chain.LeaveType && v.MinDuration == chain.MinDuration {
+			return NewConflictError("Approval Chain", fmt.Errorf("approval chain %s already applies to the same requests", v.Name))
+		}
+	}
+
+	return nil
+}
+
+func (uc *approvalUseCase) RetrieveApprovalDelegations(ctx context.Context) ([]entity.ApprovalDelegation, error) {
+	delegations, err := uc.approvalRepo.GetApprovalDelegations(ctx, time.Now().In(utils.CURRENT_LOC))
+	if err != nil {
+		return nil, NewRepositoryError("Approval Delegation", err)
+	}
+
+	return delegations, nil
+}
+
+func (uc *approvalUseCase) RevokeApprovalDelegation(ctx context.Context, id string) error {
+	if _, err := uc.approvalRepo.GetApprovalDelegationById(ctx, id); err != nil {
+		return NewNotFoundError("Approval Delegation", err)
+	}
+
+	if err := uc.approvalRepo.DeleteApprovalDelegation(ctx, id); err != nil {
+		return NewRepositoryError("Approval Delegation", err)
+	}
+
+	return nil
+}
+
+/*
+*********************************
+ACTOR: MANAGER
+*********************************
+*/
+
+// RetrieveMyApprovalDelegations returns the delegations given or
+// received by the manager which have not ended yet.
+func (uc *approvalUseCase) RetrieveMyApprovalDelegations(ctx context.Context, manager entity.Employee) ([]entity.ApprovalDelegation, error) {
+	delegations, err := uc.approvalRepo.GetApprovalDelegationsByEmployeeId(ctx, manager.Id, time.Now().In(utils.CURRENT_LOC))
+	if err != nil {
+		return nil, NewRepositoryError("Approval Delegation", err)
+	}
+
+	return delegations, nil
+}
+
+func (uc *approvalUseCase) RevokeMyApprovalDelegation(ctx context.Context, manager entity.Employee, id string) error {
+	delegation, err := uc.approvalRepo.GetApprovalDelegationById(ctx, id)
+	if err != nil {
+		return NewNotFoundError("Approval Delegation", err)
+	}
+
+	if delegation.DelegatorID != manager.Id {
+		return NewDomainError("Approval Delegation", fmt.Errorf("you are not allowed to revoke this delegation"))
+	}
+
+	if err := uc.approvalRepo.DeleteApprovalDelegation(ctx, id); err != nil {
+		return NewRepositoryError("Approval Delegation", err)
+	}
+
+	return nil
+}
+
+/*
+*********************************
+ACTOR: MANAGER, HR
+*********************************
+*/
+
+// DelegateApproval delegates the approval authority of the delegator
+// to the delegate. A manager delegates their own authority, meanwhile
+// HR delegates on behalf of a manager.
+func (uc *approvalUseCase) DelegateApproval(ctx context.Context, actor entity.Employee, payload entity.ApprovalDelegation) error {
+	payload.CreatedByID = actor.Id
+	if err := payload.Validate(); err != nil {
+		return NewDomainError("Approval Delegation", err)
+	}
+
+	if payload.To.Before(time.Now().In(utils.CURRENT_LOC)) {
+		return NewDomainError("Approval Delegation", fmt.Errorf("a delegation must not end in the past"))
+	}
+
+	// Both must approve the requests of their reports since only they
+	// see the incoming requests
+	for _, id := range []string{payload.DelegatorID, payload.DelegateID} {
+		employee, err := uc.emplRepo.GetEmployeeSimpleInformationById(ctx, id)
+		if err != nil {
+			return NewNotFoundError("Employee", err)
+		}
+		if !employee.CanWithin(entity.PERM_LEAVE_APPROVE, entity.SCOPE_DIRECT_REPORTS) {
+			return NewDomainError("Approval Delegation", fmt.Errorf("%s does not approve the requests of their reports", employee.FullName))
+		}
+		if employee.ResignedAt != nil || employee.Status == entity.RESIGNED {
+			return NewDomainError("Approval Delegation", fmt.Errorf("%s has resigned", employee.FullName))
+		}
+	}
+
+	// The delegator delegates to one employee at a time, and the
+	// delegate must not delegate their own authority meanwhile since
+	// the authority is not delegated any further
+	for _, id := range []string{payload.DelegatorID, payload.DelegateID} {
+		delegations, err := uc.approvalRepo.GetApprovalDelegationsByEmployeeId(ctx, id, payload.From)
+		if err != nil {
+			return NewRepositoryError("Approval Delegation", err)
+		}
+
+		for _, v := range delegations {
+			if v.DelegatorID == id && v.Overlaps(payload) {
+				return NewConflictError("Approval Delegation", fmt.Errorf("%s has already delegated their approval authority from %s to %s",
+					v.Delegator.FullName,
+					v.From.In(utils.CURRENT_LOC).Format(time.DateOnly),
+					v.To.In(utils.CURRENT_LOC).Format(time.DateOnly),
+				))
+			}
+		}
+	}
+
+	if err := uc.approvalRepo.CreateApprovalDelegation(ctx, payload); err != nil {
+		return NewRepositoryError("Approval Delegation", err)
+	}
+
+	return nil
+}
+
+/*
+*************************************************
+APPROVAL HELPERS
+*************************************************
+*/
+
+// getApproverIds returns the ids of the approvers whose requests the
+// employee decides at the given time, which are the employee and the
+// employees delegating their approval authority to the employee.
+func getApproverIds(ctx context.Context, approvalRepo repo.IApprovalRepo, employee entity.Employee, at time.Time) ([]string, error) {
+	delegatorIds, err := approvalRepo.GetActiveDelegatorIds(ctx, employee.Id, at)
+	if err != nil {
+		return nil, NewRepositoryError("Approval Delegation", err)
+	}
+
+	return append([]string{employee.Id}, delegatorIds...), nil
+}
+
+func isApprover(approverIds []string, id string) bool {
+	for _, v := range approverIds {
+		if v == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newApprovalSaveError returns a conflict error if a step of the
+// request has been decided by someone else meanwhile.
+func newApprovalSaveError(domain string, err error) error {
+	if errors.Is(err, entity.ErrApprovalStepDecided) {
+		return NewConflictError(domain, err)
+	}
+
+	return NewRepositoryError(domain, err)
+}
+
+// delegatedManagerStep records the decision of a delegate on a
+// request without approval steps as the decided step of its manager.
+func delegatedManagerStep(leaveId, overtimeId *string, managerId, actorId string, approved bool, reason string, at time.Time) []entity.ApprovalStep {
+	steps := entity.ApprovalSteps{{
+		LeaveID:    leaveId,
+		OvertimeID: overtimeId,
+		Sequence:   1,
+		Role:       entity.APPROVER_MANAGER,
+		ApproverID: &managerId,
+	}}
+	steps.Decide(0, actorId, approved, reason, at)
+
+	return steps
+}
+
+// buildApprovalSteps creates the approval steps of a request of the
+// employee from the approval chain applying to it. The department
+// head of the employee is the manager of the employee's manager.
+func buildApprovalSteps(
+	ctx context.Context,
+	approvalRepo repo.IApprovalRepo,
+	emplRepo repo.IEmployeeRepo,
+	employee entity.Employee,
+	kind entity.ApprovalKind,
+	leaveType entity.LeaveType,
+	duration float64,
+) (entity.ApprovalSteps, error) {
+	chains, err := approvalRepo.GetApprovalChainsByKind(ctx, kind)
+	if err != nil {
+		return nil, NewRepositoryError("Approval Chain", err)
+	}
+	chain := chains.Match(kind, leaveType, duration)
+
+	approvers := map[entity.ApproverRole]*string{
+		entity.APPROVER_MANAGER: employee.ManagerID,
+	}
+	if employee.ManagerID != nil {
+		manager, err := emplRepo.GetEmployeeById(ctx, *employee.ManagerID)
+		if err != nil {
+			return nil, NewRepositoryError("Employee", err)
+		}
+		approvers[entity.APPROVER_DEPARTMENT_HEAD] = manager.ManagerID
+	}
+
+	return chain.BuildSteps(approvers), nil
+}
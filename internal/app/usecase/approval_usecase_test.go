@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"sinarlog.com/internal/app/repo"
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/utils"
+)
+
+// fakeApprovalRepo has no approval chain defined by HR, and keeps
+// the approval delegations in memory.
+type fakeApprovalRepo struct {
+	repo.IApprovalRepo
+	delegations []entity.ApprovalDelegation
+	created     []entity.ApprovalDelegation
+}
+
+func (r *fakeApprovalRepo) GetApprovalChainsByKind(ctx context.Context, kind entity.ApprovalKind) (entity.ApprovalChains, error) {
+	return nil, nil
+}
+
+func (r *fakeApprovalRepo) GetApprovalDelegationsByEmployeeId(ctx context.Context, employeeId string, since time.Time) ([]entity.ApprovalDelegation, error) {
+	var res []entity.ApprovalDelegation
+	for _, v := range r.delegations {
+		if (v.DelegatorID == employeeId || v.DelegateID == employeeId) && !v.To.Before(since) {
+			res = append(res, v)
+		}
+	}
+	return res, nil
+}
+
+func (r *fakeApprovalRepo) GetActiveDelegatorIds(ctx context.Context, delegateId string, at time.Time) ([]string, error) {
+	var res []string
+	for _, v := range r.delegations {
+		if v.DelegateID == delegateId && v.IsActive(at) {
+			res = append(res, v.DelegatorID)
+		}
+	}
+	return res, nil
+}
+
+func (r *fakeApprovalRepo) CreateApprovalDelegation(ctx context.Context, delegation entity.ApprovalDelegation) error {
+	r.created = append(r.created, delegation)
+	return nil
+}
+
+// fakeApproverRepo knows the employees by their id.
+type fakeApproverRepo struct {
+	repo.IEmployeeRepo
+	employees map[string]entity.Employee
+}
+
+func (r fakeApproverRepo) GetEmployeeSimpleInformationById(ctx context.Context, id string) (entity.Employee, error) {
+	employee, ok := r.employees[id]
+	if !ok {
+		return employee, fmt.Errorf("record not found")
+	}
+	return employee, nil
+}
+
+// approver is an employee with the role of the given code.
+func approver(id, role string) entity.Employee {
+	employee := employeeWithId(id)
+	employee.FullName = id
+	employee.Role = entity.Role{Code: role, Permissions: entity.DefaultRolePermissions[role]}
+	return employee
+}
+
+// delegation delegates the approval authority of the delegator for
+// the given days, starting the given days after today.
+func delegation(delegatorId, delegateId string, after, days int) entity.ApprovalDelegation {
+	today := time.Now().In(utils.CURRENT_LOC)
+	from := time.Date(today.Year(), today.Month(), today.Day()+after, 0, 0, 0, 0, utils.CURRENT_LOC)
+	return entity.ApprovalDelegation{
+		DelegatorID: delegatorId,
+		Delegator:   &entity.Employee{FullName: delegatorId},
+		DelegateID:  delegateId,
+		From:        from,
+		To:          from.AddDate(0, 0, days).Add(-time.Second),
+	}
+}
+
+func TestDelegatingApproval(t *testing.T) {
+	// Manager a delegates to manager b from tomorrow for a week
+	existing := delegation("a", "b", 1, 7)
+
+	testCases := []struct {
+		name    string
+		payload entity.ApprovalDelegation
+		code    int
+	}{
+		{"The same dates", delegation("a", "c", 1, 7), 409},
+		{"The last day of the existing one", delegation("a", "c", 7, 3), 409},
+		{"Covering the existing one", delegation("a", "c", 0, 10), 409},
+		{"To a manager delegating meanwhile", delegation("c", "a", 3, 2), 409},
+		{"Right after the existing one", delegation("a", "c", 8, 3), 0},
+		{"Delegating to the delegate of another", delegation("c", "b", 1, 7), 0},
+		{"To oneself", delegation("a", "a", 10, 1), 422},
+		{"Ending in the past", delegation("c", "b", -3, 1), 422},
+		{"To staff", delegation("c", "s", 10, 1), 422},
+		{"To a missing employee", delegation("c", "x", 10, 1), 404},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			approvalRepo := &fakeApprovalRepo{delegations: []entity.ApprovalDelegation{existing}}
+			emplRepo := fakeApproverRepo{employees: map[string]entity.Employee{
+				"a": approver("a", "mngr"),
+				"b": approver("b", "mngr"),
+				"c": approver("c", "mngr"),
+				"s": approver("s", "staff"),
+			}}
+			uc := NewApprovalUseCase(approvalRepo, nil, emplRepo)
+
+			if err := uc.DelegateApproval(context.Background(), employeeWithId("hr"), tc.payload); errorCode(err) != tc.code {
+				t.Fatalf("expected code %d, got %v", tc.code, err)
+			}
+			if created := len(approvalRepo.created) == 1; created != (tc.code == 0) {
+				t.Fatalf("expected created to be %v, got %v", tc.code == 0, approvalRepo.created)
+			}
+			if tc.code == 0 && approvalRepo.created[0].CreatedByID != "hr" {
+				t.Fatalf("expected the delegation to be created by hr, got %s", approvalRepo.created[0].CreatedByID)
+			}
+		})
+	}
+}
@@ -16,8 +16,11 @@ import (
 type attendanceUseCase struct {
 	attRepo      repo.IAttendanceRepo
 	leaveRepo    repo.ILeaveRepo
+	approvalRepo repo.IApprovalRepo
 	configRepo   repo.IConfigRepo
 	emplRepo     repo.IEmployeeRepo
+	scheduleRepo repo.IScheduleRepo
+	credRepo     repo.ICredentialRepo
 	dkService    service.IDoorkeeperService
 	mailService  service.IMailerService
 	notifService service.INotifService
@@ -26,8 +29,11 @@ type attendanceUseCase struct {
 func NewAttendaceUseCase(
 	attRepo repo.IAttendanceRepo,
 	leaveRepo repo.ILeaveRepo,
+	approvalRepo repo.IApprovalRepo,
 	configRepo repo.IConfigRepo,
 	emplRepo repo.IEmployeeRepo,
+	scheduleRepo repo.IScheduleRepo,
+	credRepo repo.ICredentialRepo,
 	dkService service.IDoorkeeperService,
 	mailService service.IMailerService,
 	notifService service.INotifService,
@@ -35,8 +41,11 @@ func NewAttendaceUseCase(
 	return &attendanceUseCase{
 		attRepo:      attRepo,
 		leaveRepo:    leaveRepo,
+		approvalRepo: approvalRepo,
 		configRepo:   configRepo,
 		emplRepo:     emplRepo,
+		scheduleRepo: scheduleRepo,
+		credRepo:     credRepo,
 		dkService:    dkService,
 		mailService:  mailService,
 		notifService: notifService,
@@ -60,11 +69,18 @@ func (uc *attendanceUseCase) RequestClockIn(ctx context.Context, employee entity
 		return NewRepositoryError("Attendance", err)
 	}
 
-	// Checks if the employee is on leave
-	onLeave, err := uc.leaveRepo.EmployeeIsOnLeaveToday(ctx, employee.Id)
+	// Checks if the employee is on leave. A partial-day leave
+	// still allows the employee to work on the rest of the shift.
+	leaves, err := uc.leaveRepo.GetEmployeeLeavesToday(ctx, employee.Id)
 	if err != nil {
 		return NewRepositoryError("Leave", utils.AddError(fmt.Errorf("unable to identify if the employee today is on leave"), err))
 	}
+	onLeave := false
+	for _, leave := range leaves {
+		if !leave.IsPartialDay() {
+			onLeave = true
+		}
+	}
 	if employee.Status == entity.ON_LEAVE || onLeave {
 		return NewDomainError("Attendance", fmt.Errorf("unable to clock in if employee is on leave"))
 	}
@@ -75,25 +91,33 @@ func (uc *attendanceUseCase) RequestClockIn(ctx context.Context, employee entity
 		return NewRepositoryError("Configuration", err)
 	}
 
-	// Checks if clock in request is made after office end time
+	// Query the employee's work schedule
+	schedule, err := getEffectiveWorkSchedule(ctx, uc.scheduleRepo, config, employee.Id)
+	if err != nil {
+		return err
+	}
+
+	// Checks if clock in request is made after the shift's end time
 	now := time.Now().In(utils.CURRENT_LOC)
 	dur, _ := time.ParseDuration(config.AcceptanceAttendanceInterval)
-	officeEndTime := time.Date(
-		now.Year(),
-		now.Month(),
-		now.Day(),
-		config.OfficeEndTime.Hour(),
-		config.OfficeEndTime.Minute(),
-		config.OfficeEndTime.Second(),
-		config.OfficeEndTime.Nanosecond(),
-		utils.CURRENT_LOC,
-	)
-	if now.After(officeEndTime.Add(-dur)) {
-		return NewDomainError("Attendance", fmt.Errorf("clocking in after office's end time is not allowed"))
+	if shift, ok := schedule.ShiftAt(now); ok && now.After(shift.ExcludeLeaves(leaves).End.Add(-dur)) {
+		return NewDomainError("Attendance", fmt.Errorf("clocking in after shift's end time is not allowed"))
+	}
+
+	// Query the OTP secret of the employee, which is created along with
+	// the first OTP sent
+	otpSecret, secret, err := getOrCreateOTPSecret(ctx, uc.credRepo, uc.dkService, employee.Id)
+	if err != nil {
+		return err
+	}
+
+	// The authenticator app of the employee generates the OTP
+	if otpSecret.IsEnrolled() {
+		return nil
 	}
 
 	// Generate OTP
-	otp, timestamp, exp := uc.dkService.GenerateOTP()
+	otp, timestamp, exp := uc.dkService.GenerateOTP(secret)
 	if err := uc.attRepo.SaveClockInOTPTimestamp(ctx, employee.Id, timestamp, exp); err != nil {
 		return NewRepositoryError("Attendance", fmt.Errorf("unable to save otp: %w", err))
 	}
@@ -122,14 +146,8 @@ func (uc *attendanceUseCase) ClockIn(ctx context.Context, employee entity.Employ
 	}
 
 	// Validate OTP
-	timestamp, err := uc.attRepo.GetClockInOTPTimestamp(ctx, employee.Id)
-	if err == nil {
-		match := uc.dkService.VerifyOTP(req.OTP, timestamp)
-		if !match {
-			return NewClientError("Attendance", fmt.Errorf("OTP does not match"))
-		}
-	} else {
-		return NewRepositoryError("Attendance", err)
+	if err := uc.verifyClockInOTP(ctx, employee, req.OTP); err != nil {
+		return err
 	}
 
 	// Query the office configuration
@@ -138,6 +156,18 @@ func (uc *attendanceUseCase) ClockIn(ctx context.Context, employee entity.Employ
 		return NewRepositoryError("Configuration", err)
 	}
 
+	// Query the employee's work schedule
+	schedule, err := getEffectiveWorkSchedule(ctx, uc.scheduleRepo, config, employee.Id)
+	if err != nil {
+		return err
+	}
+
+	// Query the partial-day leaves taken today
+	leaves, err := uc.leaveRepo.GetEmployeeLeavesToday(ctx, employee.Id)
+	if err != nil {
+		return NewRepositoryError("Leave", err)
+	}
+
 	// Create and validate attendance
 	attendance := entity.Attendance{
 		EmployeeID:    employee.Id,
@@ -146,12 +176,19 @@ func (uc *attendanceUseCase) ClockIn(ctx context.Context, employee entity.Employ
 		DoneForTheDay: false,
 		ClockInLoc:    req.Loc,
 	}
-	if err := attendance.ValidateClockIn(config); err != nil {
+	if err := attendance.ValidateClockIn(config, schedule, leaves); err != nil {
+		return NewDomainError("Attendance", err)
+	}
+
+	// Checks whether the clock in is made inside one of the offices
+	fence, err := config.CheckGeofence(attendance.ClockInLoc)
+	if err != nil {
 		return NewDomainError("Attendance", err)
 	}
+	attendance.SetClockInGeofence(fence)
 
 	// Checks whether it is a late clock in
-	if attendance.IsLateClockIn(config) {
+	if attendance.IsLateClockIn(config, schedule, leaves) {
 		attendance.LateClockIn = true
 	}
 
@@ -243,15 +280,34 @@ func (uc *attendanceUseCase) ClockOut(ctx context.Context, employee entity.Emplo
 		return NewRepositoryError("Config", err)
 	}
 
+	// Query the employee's work schedule
+	schedule, err := getEffectiveWorkSchedule(ctx, uc.scheduleRepo, config, employee.Id)
+	if err != nil {
+		return err
+	}
+
+	// Query the partial-day leaves taken today
+	leaves, err := uc.leaveRepo.GetEmployeeLeavesToday(ctx, employee.Id)
+	if err != nil {
+		return NewRepositoryError("Leave", err)
+	}
+
 	// Modify and validate attendance
 	attendance.ClockOutAt = time.Now().In(utils.CURRENT_LOC)
 	attendance.ClockOutLoc = payload.Loc
 	attendance.DoneForTheDay = true
-	attendance.EarlyClockOut = attendance.IsEarlyClockOut(config)
+	attendance.EarlyClockOut = attendance.IsEarlyClockOut(config, schedule, leaves)
 	attendance.Employee.Status = entity.UNAVAILABLE
-	if err := attendance.ValidateClockOut(); err != nil {
+	if err := attendance.ValidateClockOut(config); err != nil {
+		return NewDomainError("Attendance", err)
+	}
+
+	// Checks whether the clock out is made inside one of the offices
+	fence, err := config.CheckGeofence(attendance.ClockOutLoc)
+	if err != nil {
 		return NewDomainError("Attendance", err)
 	}
+	attendance.SetClockOutGeofence(fence)
 
 	report, err := uc.createOvertimeOnAttendanceReport(ctx, attendance)
 	if err != nil {
@@ -270,12 +326,30 @@ func (uc *attendanceUseCase) ClockOut(ctx context.Context, employee entity.Emplo
 			return NewDomainError("Overtime", err)
 		}
 
+		// The approval chain applies by the overtime hours of the week
+		// including this submission
+		sum, err := uc.attRepo.SumWeeklyOvertimeDurationByEmployeeId(ctx, employee.Id)
+		if err != nil {
+			return NewRepositoryError("Overtime", err)
+		}
+		hours := (time.Duration(sum) + report.OvertimeAcceptedDuration).Hours()
+		steps, err := buildApprovalSteps(ctx, uc.approvalRepo, uc.emplRepo, attendance.Employee, entity.OVERTIME_APPROVAL, "", hours)
+		if err != nil {
+			return err
+		}
+		attendance.Overtime.ApprovalSteps = steps
+
 		if err := uc.attRepo.CloseAttendance(ctx, attendance); err != nil {
 			return NewRepositoryError("Attendance", err)
 		}
 
-		// Query the manager to be notifed about the overtime submission
-		manager, err := uc.emplRepo.GetEmployeeById(ctx, *attendance.Employee.ManagerID)
+		// An overtime going to HR right away has no one to notify
+		if steps[0].ApproverID == nil {
+			return nil
+		}
+
+		// Query the approver to be notifed about the overtime submission
+		manager, err := uc.emplRepo.GetEmployeeById(ctx, *steps[0].ApproverID)
 		if err != nil {
 			return NewErrorWithReport(
 				"Notification",
@@ -313,10 +387,22 @@ func (uc *attendanceUseCase) RetrieveMyAttendanceHistory(ctx context.Context, em
 }
 
 func (uc *attendanceUseCase) createOvertimeOnAttendanceReport(ctx context.Context, attendance entity.Attendance) (entity.OvertimeOnAttendanceReport, error) {
-	// Differentiate the case for weekend and weekday
-	switch attendance.ClockInAt.In(utils.CURRENT_LOC).Weekday() {
-	case time.Saturday, time.Sunday:
-		// Any attendance made on weekend is an overtime.
+	// Query configurations
+	config, err := uc.configRepo.GetConfiguration(ctx)
+	if err != nil {
+		return entity.OvertimeOnAttendanceReport{}, NewRepositoryError("Config", err)
+	}
+
+	// Query the employee's work schedule
+	schedule, err := getEffectiveWorkSchedule(ctx, uc.scheduleRepo, config, attendance.EmployeeID)
+	if err != nil {
+		return entity.OvertimeOnAttendanceReport{}, err
+	}
+
+	// Differentiate the case for day off and working day
+	shift, ok := schedule.ShiftAt(attendance.ClockInAt)
+	if !ok {
+		// Any attendance made on a day off is an overtime.
 		// Returns the report right away.
 		report := entity.OvertimeOnAttendanceReport{
 			IsOvertime:               true,
@@ -324,56 +410,56 @@ func (uc *attendanceUseCase) createOvertimeOnAttendanceReport(ctx context.Contex
 			OvertimeAcceptedDuration: time.Since(attendance.ClockInAt),
 		}
 		return report, nil
-	default:
-		// Query configurations
-		config, err := uc.configRepo.GetConfiguration(ctx)
-		if err != nil {
-			return entity.OvertimeOnAttendanceReport{}, NewRepositoryError("Config", err)
-		}
+	}
+
+	// Query the partial-day leaves taken today
+	leaves, err := uc.leaveRepo.GetEmployeeLeavesToday(ctx, attendance.EmployeeID)
+	if err != nil {
+		return entity.OvertimeOnAttendanceReport{}, NewRepositoryError("Leave", err)
+	}
 
-		// Set the time breakpoint
-		now := time.Now().In(utils.CURRENT_LOC)
-		workDur := now.Sub(attendance.ClockInAt)
-		officeWorkDur := config.OfficeWorkDuration()
-		var report entity.OvertimeOnAttendanceReport
+	// Set the time breakpoint
+	now := time.Now().In(utils.CURRENT_LOC)
+	workDur := now.Sub(attendance.ClockInAt)
+	shiftDur := shift.ExcludeLeaves(leaves).Duration()
+	var report entity.OvertimeOnAttendanceReport
+
+	// Indicates if the attendance duration is more than the shift duration
+	if workDur > shiftDur {
+		report.IsOvertime = true
+		report.IsOvertimeAvailable = true
+		report.OvertimeDuration = workDur - shiftDur
+		report.MaxAllowedDailyDuration = time.Duration(config.MaxOvertimeDailyDur) * time.Hour
+		report.MaxAllowedWeeklyDuration = time.Duration(config.MaxOvertimeWeeklyDur) * time.Hour
+
+		// Checks if the attendance is more than the allowed daily duration
+		if report.OvertimeDuration > report.MaxAllowedDailyDuration {
+			report.OvertimeAcceptedDuration = report.MaxAllowedDailyDuration
+			report.IsOvertimeLeakage = true
+		} else {
+			report.OvertimeAcceptedDuration = report.OvertimeDuration
+		}
 
-		// Indicates if the attendance duration is more than office work duration
-		if workDur > officeWorkDur {
-			report.IsOvertime = true
+		// Query the employee overtime for this week
+		sum, err := uc.attRepo.SumWeeklyOvertimeDurationByEmployeeId(ctx, attendance.EmployeeID)
+		if err != nil {
+			return report, NewRepositoryError("Attendance", err)
+		}
+		weeklySum := time.Duration(sum)
+
+		// Checks whether the weekly sum is more than max allowed weekly duration
+		if weeklySum >= report.MaxAllowedWeeklyDuration {
+			// Case where weekly sum is equal or more than max weekly dur
+			report.IsOvertimeAvailable = false
+			report.IsOvertimeLeakage = true
+		} else if report.OvertimeAcceptedDuration > report.MaxAllowedWeeklyDuration-weeklySum {
+			// Case where the daily dur is more than the remaining weekly dur
+			report.IsOvertimeLeakage = true
 			report.IsOvertimeAvailable = true
-			report.OvertimeDuration = workDur - officeWorkDur
-			report.MaxAllowedDailyDuration = time.Duration(config.MaxOvertimeDailyDur) * time.Hour
-			report.MaxAllowedWeeklyDuration = time.Duration(config.MaxOvertimeWeeklyDur) * time.Hour
-
-			// Checks if the attendance is more than the allowed daily duration
-			if report.OvertimeDuration > report.MaxAllowedDailyDuration {
-				report.OvertimeAcceptedDuration = report.MaxAllowedDailyDuration
-				report.IsOvertimeLeakage = true
-			} else {
-				report.OvertimeAcceptedDuration = report.OvertimeDuration
-			}
-
-			// Query the employee overtime for this week
-			sum, err := uc.attRepo.SumWeeklyOvertimeDurationByEmployeeId(ctx, attendance.EmployeeID)
-			if err != nil {
-				return report, NewRepositoryError("Attendance", err)
-			}
-			weeklySum := time.Duration(sum)
-
-			// Checks whether the weekly sum is more than max allowed weekly duration
-			if weeklySum >= report.MaxAllowedWeeklyDuration {
-				// Case where weekly sum is equal or more than max weekly dur
-				report.IsOvertimeAvailable = false
-				report.IsOvertimeLeakage = true
-			} else if report.OvertimeAcceptedDuration > report.MaxAllowedWeeklyDuration-weeklySum {
-				// Case where the daily dur is more than the remaining weekly dur
-				report.IsOvertimeLeakage = true
-				report.IsOvertimeAvailable = true
-				report.OvertimeAcceptedDuration = report.MaxAllowedWeeklyDuration - weeklySum
-			}
+			report.OvertimeAcceptedDuration = report.MaxAllowedWeeklyDuration - weeklySum
 		}
-		return report, nil
 	}
+	return report, nil
 }
 
 /*
@@ -401,16 +487,8 @@ func (uc *attendanceUseCase) RetrieveStaffAttendanceHistory(ctx context.Context,
 		return nil, vo.PaginationDTOResponse{}, NewClientError("Attendance", err)
 	}
 
-	// Verify that the employee is indeed under the manager
-	employee, err := uc.emplRepo.GetEmployeeById(ctx, employeeId)
-	if err != nil {
-		return nil, vo.PaginationDTOResponse{}, NewRepositoryError("Leave", err)
-	}
-	if employee.ManagerID == nil {
-		return nil, vo.PaginationDTOResponse{}, NewDomainError("Employee", fmt.Errorf("the employee you're requesting to view is not your staff"))
-	}
-	if *employee.ManagerID != manager.Id {
-		return nil, vo.PaginationDTOResponse{}, NewDomainError("Employee", fmt.Errorf("the employee you're requesting to view is not your staff"))
+	if _, err := authorizeOnEmployee(ctx, uc.emplRepo, manager, entity.PERM_ATTENDANCE_READ, employeeId); err != nil {
+		return nil, vo.PaginationDTOResponse{}, err
 	}
 
 	attendances, page, err := uc.attRepo.GetMyAttendancesHistory(ctx, employeeId, q)
@@ -468,6 +546,41 @@ func (uc *attendanceUseCase) RetrieveEmployeesTodaysAttendances(ctx context.Cont
 MAILER HELPERS
 *************************************************
 */
+// verifyClockInOTP verifies the OTP from the authenticator app of the
+// employee, or else the OTP sent by email. Either is accepted once.
+func (uc *attendanceUseCase) verifyClockInOTP(ctx context.Context, employee entity.Employee, otp string) error {
+	otpSecret, secret, found, err := getOTPSecret(ctx, uc.credRepo, uc.dkService, employee.Id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return NewClientError("Attendance", fmt.Errorf("request an OTP first"))
+	}
+
+	if otpSecret.IsEnrolled() {
+		return verifyAuthenticatorOTP(ctx, uc.credRepo, uc.dkService, otpSecret, secret, otp, "Attendance")
+	}
+
+	timestamp, err := uc.attRepo.GetClockInOTPTimestamp(ctx, employee.Id)
+	if err != nil {
+		return NewRepositoryError("Attendance", err)
+	}
+
+	if !uc.dkService.VerifyOTP(secret, otp, timestamp) {
+		return NewClientError("Attendance", fmt.Errorf("OTP does not match"))
+	}
+
+	consumed, err := uc.attRepo.ConsumeClockInOTPTimestamp(ctx, employee.Id)
+	if err != nil {
+		return NewRepositoryError("Attendance", err)
+	}
+	if !consumed {
+		return NewClientError("Attendance", fmt.Errorf("OTP has already been used"))
+	}
+
+	return nil
+}
+
 func (uc *attendanceUseCase) sendClockInOTPMail(receiverEmail string, data map[string]any) {
 	uc.mailService.SendEmail(receiverEmail, service.OTP, data)
 }
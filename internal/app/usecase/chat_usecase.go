@@ -2,65 +2,726 @@ package usecase
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
 
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"sinarlog.com/internal/app/repo"
 	"sinarlog.com/internal/app/service"
 	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/entity/vo"
 )
 
 type chatUseCase struct {
 	chatRepo  repo.IChatRepo
 	emplRepo  repo.IEmployeeRepo
 	psService service.IPubSubService
+	sgService service.IChatSignalService
 }
 
-func NewChatUseCase(chatRepo repo.IChatRepo, emplRepo repo.IEmployeeRepo, psService service.IPubSubService) *chatUseCase {
+// presenceHeartbeat is how often a connected employee is kept online. A
+// connection not kept online for a few heartbeats, e.g. its replica is
+// gone, is offline.
+const presenceHeartbeat = 30 * time.Second
+
+func NewChatUseCase(chatRepo repo.IChatRepo, emplRepo repo.IEmployeeRepo, psService service.IPubSubService, sgService service.IChatSignalService) *chatUseCase {
 	return &chatUseCase{
 		chatRepo:  chatRepo,
 		emplRepo:  emplRepo,
 		psService: psService,
+		sgService: sgService,
+	}
+}
+
+// OpenChat opens the room by its id, or else the direct room between
+// the participants which is created on the first time, along with the
+// latest page of its chats.
+func (uc *chatUseCase) OpenChat(ctx context.Context, user entity.Employee, room entity.Room) (entity.Room, vo.ChatPage, error) {
+	var err error
+
+	if !room.Id.IsZero() {
+		room, err = uc.JoinRoom(ctx, user, room.Id.Hex())
+		if err != nil {
+			return room, vo.ChatPage{}, err
+		}
+	} else {
+		if !room.HasParticipant(user.Id) {
+			return room, vo.ChatPage{}, NewForbiddenError(fmt.Errorf("you can only open a chat you take part in"))
+		}
+
+		room.Kind = entity.DIRECT_ROOM
+		if err := room.Validate(); err != nil {
+			return room, vo.ChatPage{}, NewDomainError("Room", err)
+		}
+
+		room, err = uc.chatRepo.FindOrCreateRoom(ctx, room)
+		if err != nil {
+			return room, vo.ChatPage{}, err
+		}
+	}
+
+	page, err := uc.chatRepo.GetChatsByRoomId(ctx, room.Id, vo.ChatPageQuery{})
+	if err != nil {
+		return room, page, NewRepositoryError("Chat", err)
+	}
+
+	return room, page, nil
+}
+
+// RetrieveChats pages the chats of the room the employee takes part in.
+func (uc *chatUseCase) RetrieveChats(ctx context.Context, user entity.Employee, roomId string, q vo.ChatPageQuery) (entity.Room, vo.ChatPage, error) {
+	room, err := uc.JoinRoom(ctx, user, roomId)
+	if err != nil {
+		return room, vo.ChatPage{}, err
+	}
+
+	if err := q.Validate(); err != nil {
+		return room, vo.ChatPage{}, NewDomainError("Chat", err)
+	}
+
+	page, err := uc.chatRepo.GetChatsByRoomId(ctx, room.Id, q)
+	if err != nil {
+		return room, page, NewRepositoryError("Chat", err)
+	}
+
+	return room, page, nil
+}
+
+// RetrieveChatContext retrieves the chat along with half a page of the
+// chats on each side of it, to jump to the chat.
+func (uc *chatUseCase) RetrieveChatContext(ctx context.Context, user entity.Employee, roomId, chatId string, q vo.ChatPageQuery) (entity.Room, vo.ChatPage, error) {
+	room, err := uc.JoinRoom(ctx, user, roomId)
+	if err != nil {
+		return room, vo.ChatPage{}, err
+	}
+
+	if err := q.Validate(); err != nil {
+		return room, vo.ChatPage{}, NewDomainError("Chat", err)
+	}
+
+	chat, err := uc.chatRepo.FindChatById(ctx, room.Id, chatId)
+	if err != nil {
+		return room, vo.ChatPage{}, NewNotFoundError("Chat", err)
+	}
+
+	page, err := uc.chatRepo.GetChatContext(ctx, room.Id, chat.Id, q.PageSize()/2)
+	if err != nil {
+		return room, page, NewRepositoryError("Chat", err)
+	}
+
+	return room, page, nil
+}
+
+// SearchChats searches the chats of a room the employee takes part in,
+// or of all their rooms without one.
+func (uc *chatUseCase) SearchChats(ctx context.Context, user entity.Employee, q vo.ChatSearchQuery) (vo.ChatPage, error) {
+	if err := q.Validate(); err != nil {
+		return vo.ChatPage{}, NewDomainError("Chat", err)
+	}
+
+	var rooms []entity.Room
+	if q.RoomId != "" {
+		room, err := uc.JoinRoom(ctx, user, q.RoomId)
+		if err != nil {
+			return vo.ChatPage{}, err
+		}
+		rooms = append(rooms, room)
+	} else {
+		var err error
+		rooms, err = uc.chatRepo.GetRoomsByParticipant(ctx, user.Id)
+		if err != nil {
+			return vo.ChatPage{}, NewRepositoryError("Room", err)
+		}
+	}
+
+	if len(rooms) == 0 {
+		return vo.ChatPage{Chats: []entity.Chat{}}, nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(rooms))
+	for _, v := range rooms {
+		ids = append(ids, v.Id)
+	}
+
+	page, err := uc.chatRepo.SearchChats(ctx, ids, q)
+	if err != nil {
+		return page, NewRepositoryError("Chat", err)
+	}
+
+	return page, nil
+}
+
+// JoinRoom returns the room if the employee takes part in it.
+func (uc *chatUseCase) JoinRoom(ctx context.Context, user entity.Employee, roomId string) (entity.Room, error) {
+	room, err := uc.chatRepo.FindRoomByID(ctx, roomId)
+	if err != nil {
+		return room, NewNotFoundError("Room", err)
+	}
+
+	if !room.HasParticipant(user.Id) {
+		return room, NewForbiddenError(fmt.Errorf("you do not take part in this room"))
+	}
+
+	return room, nil
+}
+
+// ListRooms lists the rooms the employee takes part in, with the
+// latest chatted in first and the newest of those nobody has chatted in
+// after them.
+func (uc *chatUseCase) ListRooms(ctx context.Context, user entity.Employee) ([]vo.RoomSummary, error) {
+	rooms, err := uc.chatRepo.GetRoomSummaries(ctx, user.Id)
+	if err != nil {
+		return nil, NewRepositoryError("Room", err)
+	}
+
+	sort.SliceStable(rooms, func(i, j int) bool {
+		a, b := rooms[i], rooms[j]
+		if (a.LastChat == nil) != (b.LastChat == nil) {
+			return a.LastChat != nil
+		}
+		if a.LastChat != nil {
+			return a.LastChat.Timestamp.After(b.LastChat.Timestamp)
+		}
+		return a.Room.CreatedAt > b.Room.CreatedAt
+	})
+
+	return rooms, nil
+}
+
+// CreateGroupRoom creates a named room of the employee and the members,
+// with the employee as its admin.
+func (uc *chatUseCase) CreateGroupRoom(ctx context.Context, user entity.Employee, room entity.Room, memberIds []string) (entity.Room, error) {
+	members, err := uc.activeEmployees(ctx, memberIds)
+	if err != nil {
+		return room, err
+	}
+
+	room.Kind = entity.GROUP_ROOM
+	room.CreatedBy = user.Id
+	room.Participants = []string{user.Id}
+	room.Admins = []string{user.Id}
+	room.TeamOf = ""
+	for _, v := range members {
+		if !room.HasParticipant(v) {
+			room.Participants = append(room.Participants, v)
+		}
+	}
+
+	if err := room.Validate(); err != nil {
+		return room, NewDomainError("Room", err)
+	}
+
+	room, err = uc.chatRepo.CreateRoom(ctx, room)
+	if err != nil {
+		return room, NewRepositoryError("Room", err)
+	}
+
+	return room, nil
+}
+
+// UpdateRoom changes the name, avatar and topic of a group or team room.
+// Only its admins may do so.
+func (uc *chatUseCase) UpdateRoom(ctx context.Context, user entity.Employee, roomId string, payload entity.Room) (entity.Room, error) {
+	room, err := uc.adminRoom(ctx, user, roomId)
+	if err != nil {
+		return room, err
+	}
+
+	if room.IsDirect() {
+		return room, NewDomainError("Room", fmt.Errorf("a direct room has no name, avatar nor topic"))
+	}
+
+	room.Name = payload.Name
+	room.Avatar = payload.Avatar
+	room.Topic = payload.Topic
+	if err := room.Validate(); err != nil {
+		return room, NewDomainError("Room", err)
+	}
+
+	if err := uc.chatRepo.UpdateRoomMetadata(ctx, room); err != nil {
+		return room, NewRepositoryError("Room", err)
+	}
+
+	return room, nil
+}
+
+// AddRoomMembers lets an admin of a group room add employees to it.
+func (uc *chatUseCase) AddRoomMembers(ctx context.Context, user entity.Employee, roomId string, memberIds []string) error {
+	room, err := uc.adminRoom(ctx, user, roomId)
+	if err != nil {
+		return err
+	}
+
+	if room.Kind != entity.GROUP_ROOM {
+		return NewDomainError("Room", fmt.Errorf("members can only be added to a group room"))
+	}
+
+	members, err := uc.activeEmployees(ctx, memberIds)
+	if err != nil {
+		return err
+	}
+	if len(members) == 0 {
+		return NewDomainError("Room", fmt.Errorf("at least one employee must be added"))
+	}
+
+	if err := uc.chatRepo.AddRoomParticipants(ctx, room.Id, members); err != nil {
+		return NewRepositoryError("Room", err)
+	}
+
+	return nil
+}
+
+// RemoveRoomMember lets an admin of a group room remove a member, or a
+// member leave the room. When the last admin leaves, the member who
+// has been in the room the longest becomes its admin.
+func (uc *chatUseCase) RemoveRoomMember(ctx context.Context, user entity.Employee, roomId, memberId string) error {
+	room, err := uc.JoinRoom(ctx, user, roomId)
+	if err != nil {
+		return err
+	}
+
+	if room.Kind != entity.GROUP_ROOM {
+		return NewDomainError("Room", fmt.Errorf("members can only be removed from a group room"))
+	}
+
+	if memberId != user.Id && !room.IsAdmin(user.Id) {
+		return NewForbiddenError(fmt.Errorf("only the admins of the room can remove its members"))
+	}
+
+	if !room.HasParticipant(memberId) {
+		return NewNotFoundError("Room member", fmt.Errorf("employee %s is not a member of the room", memberId))
+	}
+
+	if err := uc.chatRepo.RemoveRoomParticipant(ctx, room.Id, memberId); err != nil {
+		return NewRepositoryError("Room", err)
+	}
+	uc.publishRemoved(ctx, room.Id.Hex(), memberId)
+
+	admins := without(room.Admins, memberId)
+	participants := without(room.Participants, memberId)
+	if len(admins) == 0 && len(participants) > 0 {
+		if err := uc.chatRepo.UpdateRoomAdmins(ctx, room.Id, participants[:1]); err != nil {
+			return NewRepositoryError("Room", err)
+		}
+	}
+
+	return nil
+}
+
+// SetRoomAdmin lets an admin of a group room make a member an admin, or
+// no longer one. A room always keeps an admin.
+func (uc *chatUseCase) SetRoomAdmin(ctx context.Context, user entity.Employee, roomId, memberId string, admin bool) error {
+	room, err := uc.adminRoom(ctx, user, roomId)
+	if err != nil {
+		return err
+	}
+
+	if room.Kind != entity.GROUP_ROOM {
+		return NewDomainError("Room", fmt.Errorf("the admins of a %s room cannot be changed", room.Kind))
+	}
+
+	if !room.HasParticipant(memberId) {
+		return NewNotFoundError("Room member", fmt.Errorf("employee %s is not a member of the room", memberId))
+	}
+
+	admins := without(room.Admins, memberId)
+	if admin {
+		admins = append(admins, memberId)
+	}
+	if len(admins) == 0 {
+		return NewDomainError("Room", fmt.Errorf("the last admin of the room cannot step down"))
+	}
+
+	if err := uc.chatRepo.UpdateRoomAdmins(ctx, room.Id, admins); err != nil {
+		return NewRepositoryError("Room", err)
+	}
+
+	return nil
+}
+
+func (uc *chatUseCase) adminRoom(ctx context.Context, user entity.Employee, roomId string) (entity.Room, error) {
+	room, err := uc.JoinRoom(ctx, user, roomId)
+	if err != nil {
+		return room, err
+	}
+
+	if !room.IsAdmin(user.Id) {
+		return room, NewForbiddenError(fmt.Errorf("only the admins of the room can manage it"))
+	}
+
+	return room, nil
+}
+
+// activeEmployees returns the employees among the ids, who must all be
+// employees that have not resigned.
+func (uc *chatUseCase) activeEmployees(ctx context.Context, ids []string) ([]string, error) {
+	if err := validation.Validate(ids, validation.Each(is.UUID)); err != nil {
+		return nil, NewDomainError("Room", err)
+	}
+
+	found, err := uc.emplRepo.GetActiveEmployeeIds(ctx, ids)
+	if err != nil {
+		return nil, NewRepositoryError("Employee", err)
+	}
+
+	for _, v := range ids {
+		if !containsId(found, v) {
+			return nil, NewNotFoundError("Employee", fmt.Errorf("employee %s does not exist or has resigned", v))
+		}
 	}
+
+	return found, nil
+}
+
+// SyncTeamRooms brings the team rooms of the managers up to date with
+// who reports to them, once the manager of an employee changes.
+func (uc *chatUseCase) SyncTeamRooms(ctx context.Context, managerIds ...string) error {
+	var synced []string
+	for _, v := range managerIds {
+		if v == "" || containsId(synced, v) {
+			continue
+		}
+
+		if err := uc.syncTeamRoom(ctx, v); err != nil {
+			return err
+		}
+		synced = append(synced, v)
+	}
+
+	return nil
+}
+
+// SyncAllTeamRooms brings the team rooms of every manager with staff up
+// to date, which creates the ones missing.
+func (uc *chatUseCase) SyncAllTeamRooms(ctx context.Context) (int, error) {
+	managerIds, err := uc.emplRepo.GetManagerIds(ctx)
+	if err != nil {
+		return 0, NewRepositoryError("Employee", err)
+	}
+
+	if err := uc.SyncTeamRooms(ctx, managerIds...); err != nil {
+		return 0, err
+	}
+
+	return len(managerIds), nil
 }
 
-func (uc *chatUseCase) OpenChat(ctx context.Context, user entity.Employee, room entity.Room) (entity.Room, []entity.Chat, error) {
-	room, err := uc.chatRepo.FindOrCreateRoom(ctx, room)
+// syncTeamRoom makes the manager and their current staff the members of
+// the team room of the manager, which is created once the manager has
+// staff. The manager is always an admin of it.
+func (uc *chatUseCase) syncTeamRoom(ctx context.Context, managerId string) error {
+	staff, err := uc.emplRepo.GetStaffIdsByManagerId(ctx, managerId)
 	if err != nil {
-		return room, nil, err
+		return NewRepositoryError("Employee", err)
 	}
+	participants := append([]string{managerId}, staff...)
+
+	room, err := uc.chatRepo.FindTeamRoom(ctx, managerId)
+	if err == mongo.ErrNoDocuments {
+		if len(staff) == 0 {
+			return nil
+		}
+
+		name, err := uc.emplRepo.GetEmployeeFullNameById(ctx, managerId)
+		if err != nil {
+			return NewRepositoryError("Employee", err)
+		}
 
-	chats, err := uc.chatRepo.GetChatsByRoomId(ctx, room.Id, user.Id)
+		if _, err := uc.chatRepo.CreateRoom(ctx, entity.Room{
+			Kind:         entity.TEAM_ROOM,
+			Name:         fmt.Sprintf("Team %s", name),
+			Participants: participants,
+			Admins:       []string{managerId},
+			TeamOf:       managerId,
+			CreatedBy:    managerId,
+		}); err != nil {
+			// Created by a concurrent sync in the meantime
+			if mongo.IsDuplicateKeyError(err) {
+				return nil
+			}
+			return NewRepositoryError("Room", err)
+		}
+
+		return nil
+	}
 	if err != nil {
-		return room, nil, NewRepositoryError("Chat", err)
+		return NewRepositoryError("Room", err)
+	}
+
+	var admins []string
+	for _, v := range room.Admins {
+		if v == managerId || containsId(staff, v) {
+			admins = append(admins, v)
+		}
+	}
+	if !containsId(admins, managerId) {
+		admins = append([]string{managerId}, admins...)
+	}
+
+	if sameMembers(room.Participants, participants) && sameMembers(room.Admins, admins) {
+		return nil
+	}
+
+	removed := room.Participants
+	room.Participants = participants
+	room.Admins = admins
+	if err := uc.chatRepo.UpdateRoom(ctx, room); err != nil {
+		return NewRepositoryError("Room", err)
+	}
+
+	for _, v := range removed {
+		if !containsId(participants, v) {
+			uc.publishRemoved(ctx, room.Id.Hex(), v)
+		}
 	}
 
-	return room, chats, nil
+	return nil
 }
 
-func (uc *chatUseCase) SendMessage(ctx context.Context, userId, roomId, message string) (entity.Chat, error) {
-	if _, err := uc.chatRepo.FindRoomByID(ctx, roomId); err != nil {
-		return entity.Chat{}, NewClientError("Room", err)
+func (uc *chatUseCase) SendMessage(ctx context.Context, user entity.Employee, roomId, message string) (entity.Chat, error) {
+	if _, err := uc.JoinRoom(ctx, user, roomId); err != nil {
+		return entity.Chat{}, err
 	}
 
-	chat, err := uc.chatRepo.CreateNewMessage(ctx, userId, roomId, message)
+	chat, err := uc.chatRepo.CreateNewMessage(ctx, user.Id, roomId, message)
 	if err != nil {
 		return chat, NewRepositoryError("Chat", err)
 	}
 
-	if err := uc.psService.PublishChat(ctx, roomId, userId, chat); err != nil {
+	if err := uc.psService.PublishChat(ctx, roomId, user.Id, chat); err != nil {
 		return chat, NewServiceError("Chat", err)
 	}
 
 	return chat, nil
 }
 
-func (uc *chatUseCase) ListenMessage(ctx context.Context, userId, roomId string, channel chan entity.Chat) error {
-	if err := uc.psService.SubscribeChat(ctx, roomId, userId, channel); err != nil {
+func (uc *chatUseCase) ListenMessage(ctx context.Context, user entity.Employee, roomId, lastSeenId string, channel chan entity.Chat) error {
+	if _, err := uc.JoinRoom(ctx, user, roomId); err != nil {
+		return err
+	}
+
+	if err := uc.psService.SubscribeChat(ctx, roomId, user.Id, lastSeenId, channel); err != nil {
+		return NewServiceError("Chat", err)
+	}
+
+	return nil
+}
+
+// MarkRead moves the read cursor of the employee in the room up to the
+// chat and tells the room.
+func (uc *chatUseCase) MarkRead(ctx context.Context, user entity.Employee, roomId, chatId string) error {
+	room, err := uc.JoinRoom(ctx, user, roomId)
+	if err != nil {
+		return err
+	}
+
+	return uc.MarkRoomRead(ctx, user, room, chatId)
+}
+
+// MarkRoomRead is MarkRead in a room the employee has joined already,
+// e.g. the room of their socket.
+func (uc *chatUseCase) MarkRoomRead(ctx context.Context, user entity.Employee, room entity.Room, chatId string) error {
+	if !room.HasParticipant(user.Id) {
+		return NewForbiddenError(fmt.Errorf("you do not take part in this room"))
+	}
+
+	chat, err := uc.chatRepo.FindChatById(ctx, room.Id, chatId)
+	if err != nil {
+		return NewNotFoundError("Chat", err)
+	}
+
+	if err := uc.chatRepo.UpdateReadCursor(ctx, room.Id, user.Id, chat.Id); err != nil {
+		return NewRepositoryError("Chat", err)
+	}
+
+	if err := uc.sgService.PublishSignal(ctx, vo.ChatSignal{
+		Type:       vo.CHAT_SIGNAL_READ,
+		RoomId:     room.Id.Hex(),
+		EmployeeId: user.Id,
+		ChatId:     chat.Id.Hex(),
+	}); err != nil {
 		return NewServiceError("Chat", err)
 	}
 
 	return nil
 }
 
+// SetTyping tells the room, which the employee has joined already, the
+// employee starts or stops typing.
+func (uc *chatUseCase) SetTyping(ctx context.Context, user entity.Employee, room entity.Room, typing bool) error {
+	if !room.HasParticipant(user.Id) {
+		return NewForbiddenError(fmt.Errorf("you do not take part in this room"))
+	}
+
+	if err := uc.sgService.PublishSignal(ctx, vo.ChatSignal{
+		Type:       vo.CHAT_SIGNAL_TYPING,
+		RoomId:     room.Id.Hex(),
+		EmployeeId: user.Id,
+		Typing:     typing,
+	}); err != nil {
+		return NewServiceError("Chat", err)
+	}
+
+	return nil
+}
+
+// RetrieveUnreadCount counts the chats of the others in the room the
+// employee has not read.
+func (uc *chatUseCase) RetrieveUnreadCount(ctx context.Context, user entity.Employee, roomId string) (int64, error) {
+	room, err := uc.JoinRoom(ctx, user, roomId)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := uc.chatRepo.CountUnreadChats(ctx, room, user.Id)
+	if err != nil {
+		return 0, NewRepositoryError("Chat", err)
+	}
+
+	return count, nil
+}
+
+// RetrieveOnlineParticipants returns which of the other participants of
+// the room are online.
+func (uc *chatUseCase) RetrieveOnlineParticipants(ctx context.Context, user entity.Employee, roomId string) ([]string, error) {
+	room, err := uc.JoinRoom(ctx, user, roomId)
+	if err != nil {
+		return nil, err
+	}
+
+	online, err := uc.sgService.GetOnline(ctx, without(room.Participants, user.Id))
+	if err != nil {
+		return nil, NewServiceError("Presence", err)
+	}
+
+	return online, nil
+}
+
+// ListenSignals sends the signals of the room to the channel until the
+// context is done, other than the typing of the employee, and the
+// presence of the other participants. It ends with a forbidden error
+// once the employee is removed from the room.
+func (uc *chatUseCase) ListenSignals(ctx context.Context, user entity.Employee, roomId string, channel chan vo.ChatSignal) error {
+	room, err := uc.JoinRoom(ctx, user, roomId)
+	if err != nil {
+		return err
+	}
+
+	signals := make(chan vo.ChatSignal)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- uc.sgService.SubscribeSignals(ctx, roomId, signals)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			if err != nil {
+				return NewServiceError("Chat", err)
+			}
+			return nil
+		case signal := <-signals:
+			switch signal.Type {
+			case vo.CHAT_SIGNAL_REMOVED:
+				if signal.EmployeeId == user.Id {
+					return NewForbiddenError(fmt.Errorf("you no longer take part in this room"))
+				}
+			case vo.CHAT_SIGNAL_TYPING:
+				if signal.EmployeeId == user.Id {
+					continue
+				}
+			case vo.CHAT_SIGNAL_PRESENCE:
+				if signal.EmployeeId == user.Id || !room.HasParticipant(signal.EmployeeId) {
+					continue
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case channel <- signal:
+			}
+		}
+	}
+}
+
+// StayOnline keeps the employee online until the context is done. The
+// rooms are told once the employee comes online on their first
+// connection, and goes offline on their last one.
+func (uc *chatUseCase) StayOnline(ctx context.Context, user entity.Employee) error {
+	connectionId := uuid.NewString()
+	ttl := 3 * presenceHeartbeat
+
+	first, err := uc.sgService.MarkOnline(ctx, user.Id, connectionId, ttl)
+	if err != nil {
+		return NewServiceError("Presence", err)
+	}
+	if first {
+		uc.publishPresence(ctx, user.Id, true)
+	}
+
+	ticker := time.NewTicker(presenceHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// The employee may have expired while Redis was unreachable
+			first, err := uc.sgService.MarkOnline(ctx, user.Id, connectionId, ttl)
+			if err != nil {
+				log.Printf("Unable to keep %s online: %s", user.Id, err)
+				continue
+			}
+			if first {
+				uc.publishPresence(ctx, user.Id, true)
+			}
+		case <-ctx.Done():
+			// The context of the connection is done already
+			ctx := context.Background()
+
+			last, err := uc.sgService.MarkOffline(ctx, user.Id, connectionId)
+			if err != nil {
+				return NewServiceError("Presence", err)
+			}
+			if last {
+				uc.publishPresence(ctx, user.Id, false)
+			}
+
+			return nil
+		}
+	}
+}
+
+// publishRemoved tells the room the employee no longer takes part in
+// it, which ends the listening of the employee.
+func (uc *chatUseCase) publishRemoved(ctx context.Context, roomId, employeeId string) {
+	if err := uc.sgService.PublishSignal(ctx, vo.ChatSignal{
+		Type:       vo.CHAT_SIGNAL_REMOVED,
+		RoomId:     roomId,
+		EmployeeId: employeeId,
+	}); err != nil {
+		log.Printf("Unable to tell room %s that %s has been removed: %s", roomId, employeeId, err)
+	}
+}
+
+func (uc *chatUseCase) publishPresence(ctx context.Context, employeeId string, online bool) {
+	if err := uc.sgService.PublishSignal(ctx, vo.ChatSignal{
+		Type:       vo.CHAT_SIGNAL_PRESENCE,
+		EmployeeId: employeeId,
+		Online:     online,
+	}); err != nil {
+		log.Printf("Unable to tell the presence of %s: %s", employeeId, err)
+	}
+}
+
 func (uc *chatUseCase) DetachListener(ctx context.Context, userId, roomId string) error {
 	if err := uc.psService.UnregisterClient(ctx, roomId, userId); err != nil {
 		return NewServiceError("Chat", err)
@@ -68,3 +729,40 @@ func (uc *chatUseCase) DetachListener(ctx context.Context, userId, roomId string
 
 	return nil
 }
+
+func containsId(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// without returns the ids other than the one.
+func without(ids []string, id string) []string {
+	res := make([]string, 0, len(ids))
+	for _, v := range ids {
+		if v != id {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
+
+// sameMembers returns whether both hold the same ids in any order.
+func sameMembers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for _, v := range a {
+		if !containsId(b, v) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,362 @@
+package usecase
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"sinarlog.com/internal/app/repo"
+	"sinarlog.com/internal/app/service"
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/entity/vo"
+)
+
+// fakeChatRepo keeps a single room in memory. Calling a method it does
+// not fake panics.
+type fakeChatRepo struct {
+	repo.IChatRepo
+	room      *entity.Room
+	created   []entity.Room
+	summaries []vo.RoomSummary
+}
+
+func (r *fakeChatRepo) FindRoomByID(ctx context.Context, id string) (entity.Room, error) {
+	if r.room == nil || r.room.Id.Hex() != id {
+		return entity.Room{}, mongo.ErrNoDocuments
+	}
+	return *r.room, nil
+}
+
+func (r *fakeChatRepo) FindTeamRoom(ctx context.Context, managerId string) (entity.Room, error) {
+	if r.room == nil || r.room.TeamOf != managerId {
+		return entity.Room{}, mongo.ErrNoDocuments
+	}
+	return *r.room, nil
+}
+
+func (r *fakeChatRepo) CreateRoom(ctx context.Context, room entity.Room) (entity.Room, error) {
+	room.Id = primitive.NewObjectID()
+	r.created = append(r.created, room)
+	return room, nil
+}
+
+func (r *fakeChatRepo) UpdateRoom(ctx context.Context, room entity.Room) error {
+	*r.room = room
+	return nil
+}
+
+func (r *fakeChatRepo) RemoveRoomParticipant(ctx context.Context, roomId primitive.ObjectID, employeeId string) error {
+	r.room.Participants = without(r.room.Participants, employeeId)
+	r.room.Admins = without(r.room.Admins, employeeId)
+	return nil
+}
+
+func (r *fakeChatRepo) UpdateRoomAdmins(ctx context.Context, roomId primitive.ObjectID, admins []string) error {
+	r.room.Admins = admins
+	return nil
+}
+
+func (r *fakeChatRepo) GetRoomSummaries(ctx context.Context, employeeId string) ([]vo.RoomSummary, error) {
+	return r.summaries, nil
+}
+
+// fakeStaffRepo knows the staff of the managers.
+type fakeStaffRepo struct {
+	repo.IEmployeeRepo
+	staff map[string][]string
+}
+
+func (r *fakeStaffRepo) GetStaffIdsByManagerId(ctx context.Context, managerId string) ([]string, error) {
+	return r.staff[managerId], nil
+}
+
+func (r *fakeStaffRepo) GetEmployeeFullNameById(ctx context.Context, id string) (string, error) {
+	return "Budi", nil
+}
+
+// fakeChatSignalService records the signals published.
+type fakeChatSignalService struct {
+	service.IChatSignalService
+	signals []vo.ChatSignal
+}
+
+func (s *fakeChatSignalService) PublishSignal(ctx context.Context, signal vo.ChatSignal) error {
+	s.signals = append(s.signals, signal)
+	return nil
+}
+
+func testChatUseCase(room *entity.Room, staff map[string][]string) (*chatUseCase, *fakeChatRepo, *fakeChatSignalService) {
+	chatRepo := &fakeChatRepo{room: room}
+	sgService := &fakeChatSignalService{}
+	return NewChatUseCase(chatRepo, &fakeStaffRepo{staff: staff}, nil, sgService), chatRepo, sgService
+}
+
+func groupRoom(admins []string, participants ...string) *entity.Room {
+	return &entity.Room{
+		Id:           primitive.NewObjectID(),
+		Kind:         entity.GROUP_ROOM,
+		Participants: participants,
+		Admins:       admins,
+	}
+}
+
+func employeeWithId(id string) entity.Employee {
+	return entity.Employee{BaseModelId: entity.BaseModelId{Id: id}}
+}
+
+func TestJoiningRooms(t *testing.T) {
+	room := groupRoom([]string{"a"}, "a", "b")
+	uc, _, _ := testChatUseCase(room, nil)
+
+	testCases := []struct {
+		name   string
+		user   string
+		roomId string
+		code   int
+	}{
+		{"A member", "b", room.Id.Hex(), 0},
+		{"An outsider", "c", room.Id.Hex(), 403},
+		{"A missing room", "a", primitive.NewObjectID().Hex(), 404},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := uc.JoinRoom(context.Background(), employeeWithId(tc.user), tc.roomId); errorCode(err) != tc.code {
+				t.Fatalf("expected code %d, got %v", tc.code, err)
+			}
+		})
+	}
+}
+
+func TestRemovingRoomMembers(t *testing.T) {
+	testCases := []struct {
+		name       string
+		user       string
+		member     string
+		admins     []string
+		code       int
+		wantAdmins []string
+	}{
+		{"The last admin leaves", "a", "a", []string{"a"}, 0, []string{"b"}},
+		{"An admin leaves another admin", "a", "a", []string{"a", "c"}, 0, []string{"c"}},
+		{"An admin removes a member", "a", "c", []string{"a"}, 0, []string{"a"}},
+		{"A member leaves", "c", "c", []string{"a"}, 0, []string{"a"}},
+		{"A member removes another", "b", "c", []string{"a"}, 403, []string{"a"}},
+		{"An admin removes an outsider", "a", "d", []string{"a"}, 404, []string{"a"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			room := groupRoom(tc.admins, "a", "b", "c")
+			uc, _, sgService := testChatUseCase(room, nil)
+
+			err := uc.RemoveRoomMember(context.Background(), employeeWithId(tc.user), room.Id.Hex(), tc.member)
+			if errorCode(err) != tc.code {
+				t.Fatalf("expected code %d, got %v", tc.code, err)
+			}
+			if !reflect.DeepEqual(room.Admins, tc.wantAdmins) {
+				t.Fatalf("expected admins %v, got %v", tc.wantAdmins, room.Admins)
+			}
+			if tc.code == 0 && (len(sgService.signals) != 1 || sgService.signals[0].EmployeeId != tc.member) {
+				t.Fatalf("expected the removal of %s to be told, got %v", tc.member, sgService.signals)
+			}
+		})
+	}
+}
+
+func TestTheLastMemberLeavingKeepsNoAdmin(t *testing.T) {
+	room := groupRoom([]string{"a"}, "a")
+	uc, _, _ := testChatUseCase(room, nil)
+
+	if err := uc.RemoveRoomMember(context.Background(), employeeWithId("a"), room.Id.Hex(), "a"); err != nil {
+		t.Fatalf("expected the member to leave, got %s", err)
+	}
+	if len(room.Admins) != 0 || len(room.Participants) != 0 {
+		t.Fatalf("expected an empty room, got %v", room)
+	}
+}
+
+func TestSettingRoomAdmins(t *testing.T) {
+	testCases := []struct {
+		name       string
+		user       string
+		member     string
+		admin      bool
+		admins     []string
+		code       int
+		wantAdmins []string
+	}{
+		{"Promote a member", "a", "b", true, []string{"a"}, 0, []string{"a", "b"}},
+		{"Promote an admin again", "a", "a", true, []string{"a"}, 0, []string{"a"}},
+		{"Step down with another admin", "a", "a", false, []string{"a", "b"}, 0, []string{"b"}},
+		{"The last admin steps down", "a", "a", false, []string{"a"}, 422, []string{"a"}},
+		{"A member promotes themselves", "b", "b", true, []string{"a"}, 403, []string{"a"}},
+		{"Promote an outsider", "a", "d", true, []string{"a"}, 404, []string{"a"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			room := groupRoom(tc.admins, "a", "b", "c")
+			uc, _, _ := testChatUseCase(room, nil)
+
+			err := uc.SetRoomAdmin(context.Background(), employeeWithId(tc.user), room.Id.Hex(), tc.member, tc.admin)
+			if errorCode(err) != tc.code {
+				t.Fatalf("expected code %d, got %v", tc.code, err)
+			}
+			if !reflect.DeepEqual(room.Admins, tc.wantAdmins) {
+				t.Fatalf("expected admins %v, got %v", tc.wantAdmins, room.Admins)
+			}
+		})
+	}
+}
+
+func TestAdminsOfOtherRoomsCannotBeSet(t *testing.T) {
+	for _, kind := range []entity.RoomKind{entity.DIRECT_ROOM, entity.TEAM_ROOM} {
+		room := groupRoom([]string{"a"}, "a", "b")
+		room.Kind = kind
+		uc, _, _ := testChatUseCase(room, nil)
+
+		if err := uc.SetRoomAdmin(context.Background(), employeeWithId("a"), room.Id.Hex(), "b", true); errorCode(err) != 422 {
+			t.Fatalf("expected the admins of a %s room to be refused, got %v", kind, err)
+		}
+		if err := uc.RemoveRoomMember(context.Background(), employeeWithId("a"), room.Id.Hex(), "b"); errorCode(err) != 422 {
+			t.Fatalf("expected the members of a %s room to be kept, got %v", kind, err)
+		}
+	}
+}
+
+func TestCreatingTeamRooms(t *testing.T) {
+	uc, chatRepo, _ := testChatUseCase(nil, map[string][]string{"m": {"s1", "s2"}})
+
+	if err := uc.SyncTeamRooms(context.Background(), "m", "m", "n"); err != nil {
+		t.Fatalf("expected the team rooms to be synced, got %s", err)
+	}
+
+	if len(chatRepo.created) != 1 {
+		t.Fatalf("expected only the manager with staff to get a room, got %v", chatRepo.created)
+	}
+	room := chatRepo.created[0]
+	if room.Kind != entity.TEAM_ROOM || room.TeamOf != "m" || room.Name != "Team Budi" {
+		t.Fatalf("expected the team room of m, got %v", room)
+	}
+	if !reflect.DeepEqual(room.Participants, []string{"m", "s1", "s2"}) || !reflect.DeepEqual(room.Admins, []string{"m"}) {
+		t.Fatalf("expected the manager and staff with the manager as admin, got %v", room)
+	}
+}
+
+func TestReconcilingTeamRooms(t *testing.T) {
+	testCases := []struct {
+		name             string
+		participants     []string
+		admins           []string
+		staff            []string
+		wantParticipants []string
+		wantAdmins       []string
+		wantRemoved      []string
+	}{
+		{
+			"Up to date",
+			[]string{"m", "s1"}, []string{"m"}, []string{"s1"},
+			[]string{"m", "s1"}, []string{"m"}, nil,
+		},
+		{
+			"A staff joins and another leaves",
+			[]string{"m", "s1"}, []string{"m"}, []string{"s2"},
+			[]string{"m", "s2"}, []string{"m"}, []string{"s1"},
+		},
+		{
+			"A staff admin leaves",
+			[]string{"m", "s1", "s2"}, []string{"m", "s1", "s2"}, []string{"s2"},
+			[]string{"m", "s2"}, []string{"m", "s2"}, []string{"s1"},
+		},
+		{
+			"The manager is no longer an admin",
+			[]string{"s1", "m"}, []string{"s1"}, []string{"s1"},
+			[]string{"m", "s1"}, []string{"m", "s1"}, nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			room := &entity.Room{
+				Id:           primitive.NewObjectID(),
+				Kind:         entity.TEAM_ROOM,
+				TeamOf:       "m",
+				Participants: tc.participants,
+				Admins:       tc.admins,
+			}
+			uc, chatRepo, sgService := testChatUseCase(room, map[string][]string{"m": tc.staff})
+
+			if err := uc.SyncTeamRooms(context.Background(), "m"); err != nil {
+				t.Fatalf("expected the team room to be synced, got %s", err)
+			}
+
+			if len(chatRepo.created) != 0 {
+				t.Fatalf("expected no room to be created, got %v", chatRepo.created)
+			}
+			if !sameMembers(room.Participants, tc.wantParticipants) || !sameMembers(room.Admins, tc.wantAdmins) {
+				t.Fatalf("expected members %v and admins %v, got %v and %v", tc.wantParticipants, tc.wantAdmins, room.Participants, room.Admins)
+			}
+
+			var removed []string
+			for _, v := range sgService.signals {
+				if v.Type == vo.CHAT_SIGNAL_REMOVED {
+					removed = append(removed, v.EmployeeId)
+				}
+			}
+			if !reflect.DeepEqual(removed, tc.wantRemoved) {
+				t.Fatalf("expected %v to be told removed, got %v", tc.wantRemoved, removed)
+			}
+		})
+	}
+}
+
+func TestListingRooms(t *testing.T) {
+	chat := func(t uint32) *entity.Chat {
+		return &entity.Chat{Timestamp: primitive.Timestamp{T: t}}
+	}
+	room := func(name string, createdAt primitive.DateTime) entity.Room {
+		return entity.Room{Name: name, CreatedAt: createdAt}
+	}
+
+	uc, chatRepo, _ := testChatUseCase(nil, nil)
+	chatRepo.summaries = []vo.RoomSummary{
+		{Room: room("old unchatted", 1)},
+		{Room: room("old chat", 5), LastChat: chat(10)},
+		{Room: room("new unchatted", 9)},
+		{Room: room("new chat", 2), LastChat: chat(20)},
+		{Room: room("same second", 3), LastChat: &entity.Chat{Timestamp: primitive.Timestamp{T: 20, I: 1}}},
+	}
+
+	rooms, err := uc.ListRooms(context.Background(), employeeWithId("a"))
+	if err != nil {
+		t.Fatalf("expected the rooms to be listed, got %s", err)
+	}
+
+	var names []string
+	for _, v := range rooms {
+		names = append(names, v.Room.Name)
+	}
+	want := []string{"same second", "new chat", "old chat", "new unchatted", "old unchatted"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+}
+
+func TestTypingInJoinedRooms(t *testing.T) {
+	room := groupRoom([]string{"a"}, "a", "b")
+	uc, _, sgService := testChatUseCase(room, nil)
+
+	if err := uc.SetTyping(context.Background(), employeeWithId("b"), *room, true); err != nil {
+		t.Fatalf("expected the member to type, got %s", err)
+	}
+	if err := uc.SetTyping(context.Background(), employeeWithId("c"), *room, true); errorCode(err) != 403 {
+		t.Fatalf("expected an outsider to be refused, got %v", err)
+	}
+
+	if len(sgService.signals) != 1 || sgService.signals[0].RoomId != room.Id.Hex() || !sgService.signals[0].Typing {
+		t.Fatalf("expected the room to be told the member types, got %v", sgService.signals)
+	}
+}
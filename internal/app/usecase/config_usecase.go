@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"sinarlog.com/internal/app/repo"
 	"sinarlog.com/internal/entity"
 	"sinarlog.com/internal/entity/vo"
@@ -40,6 +41,98 @@ func (uc *configUseCase) RetrieveChangesLogs(ctx context.Context, q vo.CommonQue
 	return changes, page, nil
 }
 
+// RetrievePendingConfigChanges returns the configuration changes which
+// have not taken effect yet, along with when they take effect.
+func (uc *configUseCase) RetrievePendingConfigChanges(ctx context.Context) ([]entity.PendingConfigChanges, error) {
+	config, err := uc.configRepo.GetConfiguration(ctx)
+	if err != nil {
+		return nil, NewRepositoryError("Config", err)
+	}
+
+	var res []entity.PendingConfigChanges
+	for _, scope := range []entity.ConfigChangeScope{entity.CONFIG_NEXT_DAY, entity.CONFIG_NEXT_MONTH} {
+		pending, ok, err := uc.configRepo.GetPendingConfigChanges(ctx, scope)
+		if err != nil {
+			return nil, NewRepositoryError("Config", err)
+		}
+		if !ok {
+			continue
+		}
+
+		pending.Changes = pendingConfigChanges(config, pending)
+		res = append(res, pending)
+	}
+
+	return res, nil
+}
+
+// CancelPendingConfigChanges discards the configuration changes of the
+// scope before they take effect.
+func (uc *configUseCase) CancelPendingConfigChanges(ctx context.Context, hr entity.Employee, scope entity.ConfigChangeScope) error {
+	if err := validation.Validate(&scope, validation.In(entity.CONFIG_NEXT_DAY, entity.CONFIG_NEXT_MONTH)); err != nil {
+		return NewClientError("Config", fmt.Errorf("scope must be either NEXT_DAY or NEXT_MONTH"))
+	}
+
+	pending, ok, err := uc.configRepo.GetPendingConfigChanges(ctx, scope)
+	if err != nil {
+		return NewRepositoryError("Config", err)
+	}
+	if !ok {
+		return NewNotFoundError("Config", fmt.Errorf("there are no pending %s changes", scope))
+	}
+
+	now := time.Now().In(utils.CURRENT_LOC)
+	if !now.Before(pending.WhenApplied) {
+		return NewDomainError("Config", fmt.Errorf("the %s changes have already taken effect", scope))
+	}
+
+	if err := uc.configRepo.CancelPendingConfigChanges(ctx, pending, hr.Id, now); err != nil {
+		return NewRepositoryError("Config", err)
+	}
+
+	return nil
+}
+
+// pendingConfigChanges returns the previous and new values of the
+// fields the pending changes modify, in the format of the change logs.
+func pendingConfigChanges(config entity.Configuration, pending entity.PendingConfigChanges) entity.JSONB {
+	changes := make(entity.JSONB)
+	values := pending.Configuration
+
+	stringChange := func(field, prev, next string) {
+		if prev != next {
+			changes[field] = map[string]string{"prev": prev, "new": next}
+		}
+	}
+	intChange := func(field string, prev, next int) {
+		if prev != next {
+			changes[field] = map[string]int{"prev": prev, "new": next}
+		}
+	}
+
+	switch pending.Scope {
+	case entity.CONFIG_NEXT_DAY:
+		stringChange("office_start_time",
+			fmt.Sprintf("%s:%s", utils.PadIntegerTime(config.OfficeStartTime.Hour()), utils.PadIntegerTime(config.OfficeStartTime.Minute())),
+			fmt.Sprintf("%s:%s", utils.PadIntegerTime(values.OfficeStartTimeHour), utils.PadIntegerTime(values.OfficeStartTimeMinute)),
+		)
+		stringChange("office_end_time",
+			fmt.Sprintf("%s:%s", utils.PadIntegerTime(config.OfficeEndTime.Hour()), utils.PadIntegerTime(config.OfficeEndTime.Minute())),
+			fmt.Sprintf("%s:%s", utils.PadIntegerTime(values.OfficeEndTimeHour), utils.PadIntegerTime(values.OfficeEndTimeMinute)),
+		)
+		stringChange("acceptance_attendance_interval", config.AcceptanceAttendanceInterval, values.AcceptanceAttendanceInterval)
+		if values.GeofenceMode != "" {
+			stringChange("geofence_mode", string(config.GeofenceMode), string(values.GeofenceMode))
+		}
+	case entity.CONFIG_NEXT_MONTH:
+		intChange("acceptance_leave_interval", config.AcceptanceLeaveInterval, values.AcceptanceLeaveInterval)
+		intChange("default_yearly_quota", config.DefaultYearlyQuota, values.DefaultYearlyQuota)
+		intChange("default_marriage_quota", config.DefaultMarriageQuota, values.DefaultMarriageQuota)
+	}
+
+	return changes
+}
+
 func (uc *configUseCase) ChangeCompanyConfig(ctx context.Context, hr entity.Employee, payload entity.Configuration) error {
 	config, err := uc.configRepo.GetConfiguration(ctx)
 	if err != nil {
@@ -68,6 +161,7 @@ func (uc *configUseCase) changeCompanyConfigNextDay(ctx context.Context, hr enti
 	logs.UpdatedBy = hr
 	logs.UpdatedByID = hr.Id
 	logs.WhenApplied = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, utils.CURRENT_LOC)
+	logs.Scope = entity.CONFIG_NEXT_DAY
 
 	// Start time
 	if config.OfficeStartTime.Hour() != payload.OfficeStartTimeHour || config.OfficeStartTime.Minute() != payload.OfficeStartTimeMinute {
@@ -98,6 +192,21 @@ func (uc *configUseCase) changeCompanyConfigNextDay(ctx context.Context, hr enti
 	}
 	config.AcceptanceAttendanceInterval = payload.AcceptanceAttendanceInterval
 
+	// Geofence mode
+	if payload.GeofenceMode != "" {
+		if err := validation.Validate(&payload.GeofenceMode, validation.In(entity.GEOFENCE_FLAG, entity.GEOFENCE_REJECT)); err != nil {
+			return NewDomainError("Config", fmt.Errorf("geofence mode must be either FLAG or REJECT"))
+		}
+
+		if config.GeofenceMode != payload.GeofenceMode {
+			change := make(map[string]string)
+			change["prev"] = string(config.GeofenceMode)
+			change["new"] = string(payload.GeofenceMode)
+			changes["geofence_mode"] = any(change)
+		}
+		config.GeofenceMode = payload.GeofenceMode
+	}
+
 	if len(changes) != 0 {
 		logs.Changes = changes
 		if err := uc.configRepo.SaveNextDayChangesAndLogs(ctx, config, logs); err != nil {
@@ -119,6 +228,7 @@ func (uc *configUseCase) changeCompanyConfigNextMonth(ctx context.Context, hr en
 	logs.UpdatedBy = hr
 	logs.UpdatedByID = hr.Id
 	logs.WhenApplied = time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, utils.CURRENT_LOC)
+	logs.Scope = entity.CONFIG_NEXT_MONTH
 
 	if config.AcceptanceLeaveInterval != payload.AcceptanceLeaveInterval {
 		change := make(map[string]int)
@@ -153,3 +263,65 @@ func (uc *configUseCase) changeCompanyConfigNextMonth(ctx context.Context, hr en
 
 	return nil
 }
+
+func (uc *configUseCase) RetrieveOfficeLocations(ctx context.Context) ([]entity.OfficeLocation, error) {
+	offices, err := uc.configRepo.GetOfficeLocations(ctx)
+	if err != nil {
+		return nil, NewRepositoryError("Config", err)
+	}
+
+	return offices, nil
+}
+
+// RegisterOfficeLocation adds a new office site where employees
+// are allowed to clock in and out. It takes effect immediately.
+func (uc *configUseCase) RegisterOfficeLocation(ctx context.Context, payload entity.OfficeLocation) error {
+	if err := payload.Validate(); err != nil {
+		return NewDomainError("Office", err)
+	}
+
+	config, err := uc.configRepo.GetConfiguration(ctx)
+	if err != nil {
+		return NewRepositoryError("Config", err)
+	}
+	payload.ConfigurationID = config.Id
+
+	if err := uc.configRepo.CreateOfficeLocation(ctx, payload); err != nil {
+		return NewRepositoryError("Office", err)
+	}
+
+	return nil
+}
+
+func (uc *configUseCase) UpdateOfficeLocation(ctx context.Context, id string, payload entity.OfficeLocation) error {
+	office, err := uc.configRepo.GetOfficeLocationById(ctx, id)
+	if err != nil {
+		return NewNotFoundError("Office", err)
+	}
+
+	office.Name = payload.Name
+	office.Center = payload.Center
+	office.Radius = payload.Radius
+	office.Polygon = payload.Polygon
+	if err := office.Validate(); err != nil {
+		return NewDomainError("Office", err)
+	}
+
+	if err := uc.configRepo.UpdateOfficeLocation(ctx, office); err != nil {
+		return NewRepositoryError("Office", err)
+	}
+
+	return nil
+}
+
+func (uc *configUseCase) RemoveOfficeLocation(ctx context.Context, id string) error {
+	if _, err := uc.configRepo.GetOfficeLocationById(ctx, id); err != nil {
+		return NewNotFoundError("Office", err)
+	}
+
+	if err := uc.configRepo.DeleteOfficeLocation(ctx, id); err != nil {
+		return NewRepositoryError("Office", err)
+	}
+
+	return nil
+}
@@ -2,10 +2,14 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"strings"
+	"time"
 
-	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/google/uuid"
 	"sinarlog.com/internal/app/repo"
 	"sinarlog.com/internal/app/service"
 	"sinarlog.com/internal/entity"
@@ -18,6 +22,39 @@ const (
 	CHANGE_PASSWORD = "CHANGE_PASSWORD"
 )
 
+// An email address receives at most passwordResetLimit password reset
+// emails within passwordResetWindow
+const (
+	passwordResetLimit  = 3
+	passwordResetWindow = time.Hour
+)
+
+// A login awaiting its second factor expires after loginChallengeDuration
+// or loginChallengeAttempts attempts. Enrolling an authenticator app
+// issues recoveryCodeCount recovery codes.
+const (
+	loginChallengeDuration = 5 * time.Minute
+	loginChallengeAttempts = 5
+	recoveryCodeCount      = 10
+)
+
+// Failed logins are counted per account and per IP address within
+// loginFailureWindow. From the loginDelayAfter-th failure of an
+// account on, its next login waits loginDelayBase, doubled at each
+// failure up to loginDelayMax. The account is locked for
+// loginLockDuration at loginLockThreshold failures. An IP address is
+// refused at loginIPFailureLimit failures, which is higher as an
+// office shares one.
+const (
+	loginFailureWindow  = 15 * time.Minute
+	loginDelayAfter     = 3
+	loginDelayBase      = time.Second
+	loginDelayMax       = 30 * time.Second
+	loginLockThreshold  = 10
+	loginLockDuration   = 30 * time.Minute
+	loginIPFailureLimit = 50
+)
+
 type credentialUseCase struct {
 	repo          repo.ICredentialRepo
 	service       service.IDoorkeeperService
@@ -33,60 +70,339 @@ func (uc *credentialUseCase) Login(ctx context.Context, cred vo.Credential) (ent
 		return entity.Employee{}, vo.Credential{}, NewDomainError("Credentials", err)
 	}
 
+	now := time.Now().In(utils.CURRENT_LOC)
+
+	// Refuse an IP address guessing the passwords of many accounts
+	if cred.IP != "" {
+		failures, err := uc.repo.GetLoginFailures(ctx, cred.IP)
+		if err != nil {
+			return entity.Employee{}, vo.Credential{}, NewRepositoryError("Credentials", err)
+		}
+		if failures.Count >= loginIPFailureLimit {
+			return entity.Employee{}, vo.Credential{}, NewTooManyRequestsError("Credentials", fmt.Errorf("too many failed logins from your network, please try again later"))
+		}
+	}
+
 	employee, err := uc.repo.GetEmployeeByEmail(ctx, cred.Email)
 	if err != nil {
+		uc.recordLoginFailure(ctx, cred.IP, now)
 		return entity.Employee{}, vo.Credential{}, NewNotFoundError("Credentials", err)
 	}
 
+	previous, failures, err := uc.takeLoginAttempt(ctx, employee, now)
+	if err != nil {
+		return entity.Employee{}, vo.Credential{}, err
+	}
+
 	if err := uc.service.VerifyPassword(employee.Password, cred.Password); err != nil {
-		return entity.Employee{}, vo.Credential{}, NewUnauthorizedError(fmt.Errorf("your password does not match"))
+		uc.recordLoginFailure(ctx, cred.IP, now)
+		return entity.Employee{}, vo.Credential{}, uc.failLogin(ctx, employee, cred.IP, failures, now, NewUnauthorizedError(fmt.Errorf("your password does not match")))
 	}
 
+	// The failed logins are forgotten only once the second factor is
+	// given too, otherwise logging in again would allow guessing it
+	// for ever
+	uc.releaseLoginAttempt(ctx, employee.Id, now, previous)
+
 	if employee.ResignedAt != nil || employee.Status == entity.RESIGNED {
 		return entity.Employee{}, vo.Credential{}, NewUnauthorizedError(fmt.Errorf("you are no longer an employee of SinarLog"))
 	}
 
-	accessToken, err := uc.service.GenerateToken(employee)
-	cred.AccessToken = accessToken
+	// The second factor is asked for once the authenticator app is
+	// enrolled, and HR must enroll one before logging in
+	otpSecret, _, err := uc.repo.GetEmployeeOTPSecret(ctx, employee.Id)
+	if err != nil {
+		return entity.Employee{}, vo.Credential{}, NewRepositoryError("Credentials", err)
+	}
+	if otpSecret.IsEnrolled() || isTwoFactorMandatory(employee) {
+		cred, err = uc.startLoginChallenge(ctx, employee, cred)
+		if err != nil {
+			return entity.Employee{}, vo.Credential{}, err
+		}
+		cred.EnrollmentRequired = !otpSecret.IsEnrolled()
+
+		return employee, cred, nil
+	}
+
+	uc.clearLoginFailures(ctx, employee.Id)
+	cred, err = uc.startSession(ctx, employee, cred)
+	if err != nil {
+		return entity.Employee{}, vo.Credential{}, err
+	}
+
+	return employee, cred, nil
+}
+
+// Authorize returns the employee and the session of an access token.
+// The token is rejected once its session is revoked or expired, or
+// once the token version of the employee has been bumped.
+func (uc *credentialUseCase) Authorize(ctx context.Context, token string, perms ...entity.Permission) (entity.Employee, entity.Session, error) {
+	employee, session, _, err := uc.authorize(ctx, token, perms...)
+	return employee, session, err
+}
 
-	return employee, cred, err
+// AuthorizeSocket authorizes a long-lived connection such as a
+// websocket, and returns when its token expires so the connection
+// can be closed by then.
+func (uc *credentialUseCase) AuthorizeSocket(ctx context.Context, token string) (entity.Employee, time.Time, error) {
+	employee, _, claims, err := uc.authorize(ctx, token)
+	return employee, claims.ExpiresAt, err
 }
 
-func (uc *credentialUseCase) Authorize(ctx context.Context, token string, roles ...any) (entity.Employee, error) {
-	id, err := uc.service.VerifyAndParseToken(ctx, token)
+func (uc *credentialUseCase) authorize(ctx context.Context, token string, perms ...entity.Permission) (entity.Employee, entity.Session, vo.TokenClaims, error) {
+	claims, err := uc.service.VerifyAndParseToken(ctx, token)
 	if err != nil {
-		return entity.Employee{}, NewUnauthorizedError(err)
+		return entity.Employee{}, entity.Session{}, claims, NewUnauthorizedError(err)
 	}
 
-	employee, err := uc.repo.GetEmployeeByIdV2(ctx, id)
+	session, err := uc.repo.GetSessionById(ctx, claims.SessionID)
+	if err != nil || session.EmployeeID != claims.EmployeeID {
+		return entity.Employee{}, entity.Session{}, claims, NewUnauthorizedError(fmt.Errorf("your session has ended, please log in again"))
+	}
+
+	employee, err := uc.repo.GetEmployeeByIdV2(ctx, claims.EmployeeID)
 	if err != nil {
-		return entity.Employee{}, NewNotFoundError("Employee", err)
+		return entity.Employee{}, entity.Session{}, claims, NewNotFoundError("Employee", err)
+	}
+
+	if claims.TokenVersion != employee.TokenVersion {
+		return entity.Employee{}, entity.Session{}, claims, NewUnauthorizedError(fmt.Errorf("your account has changed, please log in again"))
 	}
 
-	if err := validation.Validate(employee.Role.Code,
-		validation.Required.Error("employee does not have a role"),
-		validation.In(roles...).Error("roes does not match"),
-	); err != nil {
-		return entity.Employee{}, NewForbiddenError(err)
+	if err := RequirePermissions(employee, entity.SCOPE_OWN, perms...); err != nil {
+		return entity.Employee{}, entity.Session{}, claims, err
+	}
+
+	if employee.ResignedAt != nil || employee.Status == entity.RESIGNED {
+		return entity.Employee{}, entity.Session{}, claims, NewUnauthorizedError(fmt.Errorf("you are no longer an employee of SinarLog"))
+	}
+
+	// The last seen time does not need to be precise
+	now := time.Now().In(utils.CURRENT_LOC)
+	if now.Sub(session.LastSeenAt) > time.Minute {
+		if err := uc.repo.TouchSession(ctx, session.Id, now); err != nil {
+			log.Printf("Unable to update the last seen time of session %s: %s", session.Id, err)
+		}
+		session.LastSeenAt = now
+	}
+
+	return employee, session, claims, nil
+}
+
+// RefreshSession issues a new access token and a new refresh token
+// of the session the refresh token belongs to. Each refresh token is
+// accepted once, hence a refresh token used again means it was
+// stolen, and the session is revoked.
+func (uc *credentialUseCase) RefreshSession(ctx context.Context, cred vo.Credential) (vo.Credential, error) {
+	sessionId, hash, err := uc.service.ParseRefreshToken(cred.RefreshToken)
+	if err != nil {
+		return vo.Credential{}, NewUnauthorizedError(err)
+	}
+
+	session, err := uc.repo.GetSessionById(ctx, sessionId)
+	if err != nil {
+		return vo.Credential{}, NewUnauthorizedError(fmt.Errorf("your session has ended, please log in again"))
+	}
+
+	if session.RefreshTokenHash != hash {
+		uc.revokeReusedSession(ctx, session)
+		return vo.Credential{}, NewUnauthorizedError(fmt.Errorf("this refresh token has already been used, please log in again"))
+	}
+
+	employee, err := uc.repo.GetEmployeeByIdV2(ctx, session.EmployeeID)
+	if err != nil {
+		return vo.Credential{}, NewNotFoundError("Employee", err)
 	}
 
 	if employee.ResignedAt != nil || employee.Status == entity.RESIGNED {
-		return entity.Employee{}, NewUnauthorizedError(fmt.Errorf("you are no longer an employee of SinarLog"))
+		if err := uc.repo.DeleteSession(ctx, session); err != nil {
+			return vo.Credential{}, NewRepositoryError("Session", err)
+		}
+		return vo.Credential{}, NewUnauthorizedError(fmt.Errorf("you are no longer an employee of SinarLog"))
 	}
 
-	return employee, nil
+	if session.TokenVersion != employee.TokenVersion {
+		if err := uc.repo.DeleteSession(ctx, session); err != nil {
+			return vo.Credential{}, NewRepositoryError("Session", err)
+		}
+		return vo.Credential{}, NewUnauthorizedError(fmt.Errorf("your account has changed, please log in again"))
+	}
+
+	refreshToken, refreshHash, err := uc.service.GenerateRefreshToken(session.Id)
+	if err != nil {
+		return vo.Credential{}, NewServiceError("Doorkeeper", err)
+	}
+
+	now := time.Now().In(utils.CURRENT_LOC)
+	session.RefreshTokenHash = refreshHash
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(uc.service.GetRefreshDuration())
+
+	rotated, err := uc.repo.RotateSessionRefreshToken(ctx, session, hash)
+	if err != nil {
+		return vo.Credential{}, NewRepositoryError("Session", err)
+	}
+	// Another refresh with the same token won the race
+	if !rotated {
+		uc.revokeReusedSession(ctx, session)
+		return vo.Credential{}, NewUnauthorizedError(fmt.Errorf("this refresh token has already been used, please log in again"))
+	}
+
+	accessToken, err := uc.service.GenerateToken(employee, session.Id)
+	if err != nil {
+		return vo.Credential{}, NewServiceError("Doorkeeper", err)
+	}
+
+	return vo.Credential{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// Logout revokes the session the employee is using.
+func (uc *credentialUseCase) Logout(ctx context.Context, session entity.Session) error {
+	if err := uc.repo.DeleteSession(ctx, session); err != nil {
+		return NewRepositoryError("Session", err)
+	}
+
+	return nil
+}
+
+func (uc *credentialUseCase) RetrieveMySessions(ctx context.Context, employee entity.Employee) ([]entity.Session, error) {
+	sessions, err := uc.repo.GetSessionsByEmployeeId(ctx, employee.Id)
+	if err != nil {
+		return nil, NewRepositoryError("Session", err)
+	}
+
+	return sessions, nil
+}
+
+func (uc *credentialUseCase) RevokeMySession(ctx context.Context, employee entity.Employee, id string) error {
+	session, err := uc.repo.GetSessionById(ctx, id)
+	if err != nil || session.EmployeeID != employee.Id {
+		return NewNotFoundError("Session", fmt.Errorf("session %s does not exist", id))
+	}
+
+	if err := uc.repo.DeleteSession(ctx, session); err != nil {
+		return NewRepositoryError("Session", err)
+	}
+
+	return nil
 }
 
+// startSession creates a new session of the employee on the device
+// of the credential and issues its tokens.
+func (uc *credentialUseCase) startSession(ctx context.Context, employee entity.Employee, cred vo.Credential) (vo.Credential, error) {
+	now := time.Now().In(utils.CURRENT_LOC)
+	session := entity.Session{
+		Id:         uuid.NewString(),
+		EmployeeID: employee.Id,
+		Device:     cred.Device,
+		IP:         cred.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(uc.service.GetRefreshDuration()),
+
+		TokenVersion: employee.TokenVersion,
+	}
+
+	refreshToken, refreshHash, err := uc.service.GenerateRefreshToken(session.Id)
+	if err != nil {
+		return cred, NewServiceError("Doorkeeper", err)
+	}
+	session.RefreshTokenHash = refreshHash
+
+	if err := uc.repo.CreateSession(ctx, session); err != nil {
+		return cred, NewRepositoryError("Session", err)
+	}
+
+	accessToken, err := uc.service.GenerateToken(employee, session.Id)
+	if err != nil {
+		return cred, NewServiceError("Doorkeeper", err)
+	}
+
+	cred.AccessToken = accessToken
+	cred.RefreshToken = refreshToken
+
+	return cred, nil
+}
+
+func (uc *credentialUseCase) revokeReusedSession(ctx context.Context, session entity.Session) {
+	log.Printf("Refresh token of session %s has been reused, revoking the session", session.Id)
+
+	if err := uc.repo.DeleteSession(ctx, session); err != nil {
+		log.Printf("Unable to revoke session %s: %s", session.Id, err)
+	}
+}
+
+// ForgotPassword emails a one-time link to reset the password of the
+// employee. It succeeds whether or not the email belongs to anyone, so
+// it does not tell who is an employee.
 func (uc *credentialUseCase) ForgotPassword(ctx context.Context, email string) error {
+	email = strings.TrimSpace(email)
+
+	// Limit the emails sent to the same address
+	count, err := uc.repo.CountPasswordResetRequests(ctx, strings.ToLower(email), passwordResetWindow)
+	if err != nil {
+		return NewRepositoryError("Credential", err)
+	}
+	if count > passwordResetLimit {
+		return NewTooManyRequestsError("Credential", fmt.Errorf("too many password reset requests, please try again later"))
+	}
+
 	// Get the employee
 	employee, err := uc.repo.GetEmployeeByEmail(ctx, email)
 	if err != nil {
+		return nil
+	}
+	if employee.ResignedAt != nil || employee.Status == entity.RESIGNED {
+		return nil
+	}
+
+	// Only the hash of the token is kept
+	token, hash, err := uc.service.GenerateResetToken()
+	if err != nil {
+		return NewServiceError("Doorkeeper", err)
+	}
+
+	exp := uc.service.GetResetExpDuration()
+	if err := uc.repo.SavePasswordResetToken(ctx, employee.Id, hash, exp); err != nil {
 		return NewRepositoryError("Credential", err)
 	}
 
-	// Update its password
-	password := utils.GenerateRandomPassword()
-	hashedPassword, err := uc.service.HashPassword(password)
+	// Send email
+	go uc.sendForgotPasswordMail(employee.Email, map[string]any{
+		"FullName":  employee.FullName,
+		"Token":     token,
+		"ExpiresIn": int(exp.Minutes()),
+	})
+
+	return nil
+}
+
+// ResetPassword changes the password of the employee the reset token
+// was issued to. The token is used once, and every session of the
+// employee is revoked.
+func (uc *credentialUseCase) ResetPassword(ctx context.Context, cred vo.Credential) error {
+	if err := cred.ValidatePasswordReset(); err != nil {
+		return NewDomainError("Credential", err)
+	}
+
+	if !utils.IsStrongPassword(cred.NewPassword) {
+		return NewDomainError("Credential", fmt.Errorf("password is not strong enough"))
+	}
+
+	employeeId, err := uc.repo.ConsumePasswordResetToken(ctx, uc.service.HashResetToken(cred.ResetToken))
+	if err != nil {
+		return NewUnauthorizedError(fmt.Errorf("this password reset link is invalid or has expired"))
+	}
+
+	employee, err := uc.repo.GetEmployeeByIdV2(ctx, employeeId)
+	if err != nil {
+		return NewNotFoundError("Employee", err)
+	}
+	if employee.ResignedAt != nil || employee.Status == entity.RESIGNED {
+		return NewUnauthorizedError(fmt.Errorf("you are no longer an employee of SinarLog"))
+	}
+
+	hashedPassword, err := uc.service.HashPassword(cred.NewPassword)
 	if err != nil {
 		return NewServiceError("Employee", err)
 	}
@@ -97,18 +413,665 @@ func (uc *credentialUseCase) ForgotPassword(ctx context.Context, email string) e
 		return NewRepositoryError("Crendetial", err)
 	}
 
+	// The previous password must not keep anyone logged in
+	if err := uc.repo.DeleteSessionsByEmployeeId(ctx, employee.Id); err != nil {
+		return NewRepositoryError("Session", err)
+	}
+
+	// Whoever was guessing the previous password no longer matters
+	if err := uc.repo.UnlockAccount(ctx, employee.Id); err != nil {
+		return NewRepositoryError("Credential", err)
+	}
+
+	return nil
+}
+
+// UnlockAccount lifts the lock of an employee account locked after too
+// many failed logins.
+func (uc *credentialUseCase) UnlockAccount(ctx context.Context, hr entity.Employee, employeeId string) error {
+	employee, err := uc.repo.GetEmployeeByIdV2(ctx, employeeId)
+	if err != nil {
+		return NewNotFoundError("Employee", err)
+	}
+
+	_, locked, err := uc.repo.GetAccountLock(ctx, employee.Id)
+	if err != nil {
+		return NewRepositoryError("Credential", err)
+	}
+	if !locked {
+		return NewClientError("Credential", fmt.Errorf("%s's account is not locked", employee.FullName))
+	}
+
+	if err := uc.repo.UnlockAccount(ctx, employee.Id); err != nil {
+		return NewRepositoryError("Credential", err)
+	}
+	log.Printf("Account of employee %s has been unlocked by %s", employee.Id, hr.Id)
+
+	return nil
+}
+
+// takeLoginAttempt refuses the login of a locked account, or of one
+// which must wait after its last failed login. Otherwise the attempt
+// is counted as failed before it is verified, hence parallel guesses
+// cannot all pass before any failure is counted. It returns the
+// failures before the attempt and along with it. Both the password
+// and the second factor are attempts.
+func (uc *credentialUseCase) takeLoginAttempt(ctx context.Context, employee entity.Employee, now time.Time) (entity.LoginFailures, entity.LoginFailures, error) {
+	until, locked, err := uc.repo.GetAccountLock(ctx, employee.Id)
+	if err != nil {
+		return entity.LoginFailures{}, entity.LoginFailures{}, NewRepositoryError("Credentials", err)
+	}
+	if locked {
+		return entity.LoginFailures{}, entity.LoginFailures{}, NewTooManyRequestsError("Credentials", fmt.Errorf("your account is locked until %s, please try again later or ask HR to unlock it", until.Format("15:04")))
+	}
+
+	previous, failures, err := uc.repo.TakeLoginAttempt(ctx, employee.Id, now, loginFailureWindow)
+	if err != nil {
+		return entity.LoginFailures{}, entity.LoginFailures{}, NewRepositoryError("Credentials", err)
+	}
+
+	if retryAt := previous.LastFailedAt.Add(loginDelay(previous.Count)); now.Before(retryAt) {
+		// The attempt is refused rather than failed
+		uc.releaseLoginAttempt(ctx, employee.Id, now, previous)
+
+		wait := int(math.Ceil(retryAt.Sub(now).Seconds()))
+		return entity.LoginFailures{}, entity.LoginFailures{}, NewTooManyRequestsError("Credentials", fmt.Errorf("too many failed logins, please try again in %d seconds", wait))
+	}
+
+	return previous, failures, nil
+}
+
+// releaseLoginAttempt uncounts a login attempt of the employee which
+// has not failed.
+func (uc *credentialUseCase) releaseLoginAttempt(ctx context.Context, employeeId string, now time.Time, previous entity.LoginFailures) {
+	if err := uc.repo.ReleaseLoginAttempt(ctx, employeeId, now, previous); err != nil {
+		log.Printf("Unable to release the login attempt of employee %s: %s", employeeId, err)
+	}
+}
+
+// clearLoginFailures forgets the failed logins of the employee once a
+// login completes.
+func (uc *credentialUseCase) clearLoginFailures(ctx context.Context, employeeId string) {
+	if err := uc.repo.ClearLoginFailures(ctx, employeeId); err != nil {
+		log.Printf("Unable to clear the failed logins of employee %s: %s", employeeId, err)
+	}
+}
+
+// failLogin returns why the login attempt failed, and locks the
+// account of the employee once its failed attempts reach the
+// threshold, telling its owner by email.
+func (uc *credentialUseCase) failLogin(ctx context.Context, employee entity.Employee, ip string, failures entity.LoginFailures, now time.Time, cause error) error {
+	if failures.Count < loginLockThreshold {
+		return cause
+	}
+
+	until := now.Add(loginLockDuration)
+	if err := uc.repo.LockAccount(ctx, employee.Id, until); err != nil {
+		log.Printf("Unable to lock the account of employee %s: %s", employee.Id, err)
+		return cause
+	}
+
 	// Send email
-	go uc.sendForgotPasswordMail(employee.Email, map[string]any{
-		"FullName": employee.FullName,
-		"Email":    employee.Email,
-		"Password": password,
+	go uc.sendAccountLockedMail(employee.Email, map[string]any{
+		"FullName":      employee.FullName,
+		"Attempts":      failures.Count,
+		"LastAttemptAt": now.Format(time.RFC1123),
+		"IP":            ip,
+		"LockedUntil":   until.Format(time.RFC1123),
 	})
 
+	return NewTooManyRequestsError("Credentials", fmt.Errorf("your account has been locked until %s after too many failed logins", until.Format("15:04")))
+}
+
+// recordLoginFailure counts a failed login from the IP address.
+func (uc *credentialUseCase) recordLoginFailure(ctx context.Context, ip string, now time.Time) {
+	if ip == "" {
+		return
+	}
+
+	if _, err := uc.repo.RecordLoginFailure(ctx, ip, now, loginFailureWindow); err != nil {
+		log.Printf("Unable to record the failed login from %s: %s", ip, err)
+	}
+}
+
+// loginDelay returns how long an account waits after its last failed
+// login before logging in again.
+func loginDelay(failures int64) time.Duration {
+	if failures < loginDelayAfter {
+		return 0
+	}
+
+	delay := loginDelayBase
+	for i := int64(loginDelayAfter); i < failures && delay < loginDelayMax; i++ {
+		delay *= 2
+	}
+	if delay > loginDelayMax {
+		delay = loginDelayMax
+	}
+
+	return delay
+}
+
+// RetrieveMyOTPStatus tells whether the employee generates OTPs with
+// an authenticator app, and how many recovery codes are left.
+func (uc *credentialUseCase) RetrieveMyOTPStatus(ctx context.Context, employee entity.Employee) (vo.OTPStatus, error) {
+	status := vo.OTPStatus{IsMandatory: isTwoFactorMandatory(employee)}
+
+	otpSecret, _, err := uc.repo.GetEmployeeOTPSecret(ctx, employee.Id)
+	if err != nil {
+		return status, NewRepositoryError("OTP", err)
+	}
+	if !otpSecret.IsEnrolled() {
+		return status, nil
+	}
+
+	left, err := uc.repo.CountUnusedRecoveryCodes(ctx, employee.Id)
+	if err != nil {
+		return status, NewRepositoryError("OTP", err)
+	}
+
+	status.IsEnrolled = true
+	status.EnrolledAt = otpSecret.EnrolledAt
+	status.RecoveryCodesLeft = left
+
+	return status, nil
+}
+
+// EnrollOTP issues a new OTP secret for the authenticator app of the
+// employee. The secret is only used once the employee confirms it with
+// an OTP generated by the app.
+func (uc *credentialUseCase) EnrollOTP(ctx context.Context, employee entity.Employee) (vo.OTPEnrollment, error) {
+	otpSecret, _, _, err := getOTPSecret(ctx, uc.repo, uc.service, employee.Id)
+	if err != nil {
+		return vo.OTPEnrollment{}, err
+	}
+
+	return uc.enrollOTP(ctx, employee, otpSecret)
+}
+
+// ConfirmOTPEnrollment enrolls the authenticator app of the employee
+// once it generates a valid OTP, and returns the recovery codes.
+func (uc *credentialUseCase) ConfirmOTPEnrollment(ctx context.Context, employee entity.Employee, otp string) ([]string, error) {
+	otpSecret, secret, found, err := getOTPSecret(ctx, uc.repo, uc.service, employee.Id)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, NewClientError("OTP", fmt.Errorf("start enrolling the authenticator app first"))
+	}
+
+	return uc.confirmOTPEnrollment(ctx, employee, otpSecret, secret, otp)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the employee,
+// which requires an OTP from the authenticator app.
+func (uc *credentialUseCase) RegenerateRecoveryCodes(ctx context.Context, employee entity.Employee, otp string) ([]string, error) {
+	otpSecret, secret, _, err := getOTPSecret(ctx, uc.repo, uc.service, employee.Id)
+	if err != nil {
+		return nil, err
+	}
+	if !otpSecret.IsEnrolled() {
+		return nil, NewClientError("OTP", fmt.Errorf("authenticator app has not been enrolled"))
+	}
+
+	if err := verifyAuthenticatorOTP(ctx, uc.repo, uc.service, otpSecret, secret, otp, "OTP"); err != nil {
+		return nil, err
+	}
+
+	return uc.issueRecoveryCodes(ctx, employee.Id)
+}
+
+// RemoveOTPEnrollment stops using the authenticator app of the
+// employee, which requires an OTP from the app or a recovery code so
+// a stolen access token cannot remove it. The secret is replaced so
+// the app can not be used again without enrolling it anew, and the
+// employee is told by email.
+func (uc *credentialUseCase) RemoveOTPEnrollment(ctx context.Context, employee entity.Employee, otp, recoveryCode string) error {
+	if isTwoFactorMandatory(employee) {
+		return NewDomainError("OTP", fmt.Errorf("two-factor authentication is mandatory for your role"))
+	}
+	if otp == "" && recoveryCode == "" {
+		return NewDomainError("OTP", fmt.Errorf("otp or recovery code is required"))
+	}
+
+	otpSecret, secret, _, err := getOTPSecret(ctx, uc.repo, uc.service, employee.Id)
+	if err != nil {
+		return err
+	}
+	if !otpSecret.IsEnrolled() {
+		return NewClientError("OTP", fmt.Errorf("authenticator app has not been enrolled"))
+	}
+
+	if recoveryCode != "" {
+		used, err := uc.repo.UseRecoveryCode(ctx, employee.Id, uc.service.HashRecoveryCode(recoveryCode), time.Now().In(utils.CURRENT_LOC))
+		if err != nil {
+			return NewRepositoryError("OTP", err)
+		}
+		if !used {
+			return NewClientError("OTP", fmt.Errorf("this recovery code is invalid or has been used"))
+		}
+	} else if err := verifyAuthenticatorOTP(ctx, uc.repo, uc.service, otpSecret, secret, otp, "OTP"); err != nil {
+		return err
+	}
+
+	_, encrypted, err := uc.service.GenerateOTPSecret()
+	if err != nil {
+		return NewServiceError("Doorkeeper", err)
+	}
+	otpSecret.Secret = encrypted
+	otpSecret.EnrolledAt = nil
+	otpSecret.LastUsedStep = 0
+
+	if err := uc.repo.SaveEmployeeOTPSecret(ctx, otpSecret); err != nil {
+		return NewRepositoryError("OTP", err)
+	}
+	if err := uc.repo.ReplaceRecoveryCodes(ctx, employee.Id, nil); err != nil {
+		return NewRepositoryError("OTP", err)
+	}
+
+	// Send email
+	go uc.sendTwoFactorChangedMail(employee.Email, map[string]any{
+		"FullName":  employee.FullName,
+		"Change":    "removed from",
+		"ChangedAt": time.Now().In(utils.CURRENT_LOC).Format(time.RFC1123),
+	})
+
+	return nil
+}
+
+// ResetTwoFactor removes the authenticator app of an employee who lost
+// it, so they enroll a new one at their next login. It is done by
+// another HR and recorded in the changes log of the employee.
+func (uc *credentialUseCase) ResetTwoFactor(ctx context.Context, hr entity.Employee, employeeId string) error {
+	if hr.Id == employeeId {
+		return NewDomainError("OTP", fmt.Errorf("your two-factor authentication must be reset by another HR"))
+	}
+
+	employee, err := uc.repo.GetEmployeeByIdV2(ctx, employeeId)
+	if err != nil {
+		return NewNotFoundError("Employee", err)
+	}
+
+	otpSecret, _, err := uc.repo.GetEmployeeOTPSecret(ctx, employee.Id)
+	if err != nil {
+		return NewRepositoryError("OTP", err)
+	}
+	if !otpSecret.IsEnrolled() {
+		return NewClientError("OTP", fmt.Errorf("%s has not enrolled two-factor authentication", employee.FullName))
+	}
+
+	_, encrypted, err := uc.service.GenerateOTPSecret()
+	if err != nil {
+		return NewServiceError("Doorkeeper", err)
+	}
+	otpSecret.Secret = encrypted
+	otpSecret.EnrolledAt = nil
+	otpSecret.LastUsedStep = 0
+
+	change := make(map[string]string)
+	change["prev"] = "Enrolled"
+	change["new"] = "Reset"
+	log := entity.EmployeeDataHistoryLog{
+		EmployeeID:  employee.Id,
+		UpdatedByID: hr.Id,
+		Changes:     entity.JSONB{"two_factor": any(change)},
+	}
+
+	// Persist
+	if err := uc.repo.ResetEmployeeOTPSecret(ctx, otpSecret, log); err != nil {
+		return NewRepositoryError("OTP", err)
+	}
+
+	// Whoever holds the lost device must not stay logged in
+	if err := uc.repo.DeleteSessionsByEmployeeId(ctx, employee.Id); err != nil {
+		return NewRepositoryError("Session", err)
+	}
+
 	return nil
 }
 
+/*
+*************************************************
+Two-factor login
+*************************************************
+*/
+
+// EnrollTwoFactor issues a new OTP secret for the authenticator app of
+// an employee who must enroll one to complete the login.
+func (uc *credentialUseCase) EnrollTwoFactor(ctx context.Context, cred vo.Credential) (vo.OTPEnrollment, error) {
+	if cred.ChallengeToken == "" {
+		return vo.OTPEnrollment{}, NewDomainError("Credentials", fmt.Errorf("challenge token is required"))
+	}
+
+	_, _, employee, err := uc.attemptLoginChallenge(ctx, cred.ChallengeToken)
+	if err != nil {
+		return vo.OTPEnrollment{}, err
+	}
+
+	otpSecret, _, _, err := getOTPSecret(ctx, uc.repo, uc.service, employee.Id)
+	if err != nil {
+		return vo.OTPEnrollment{}, err
+	}
+
+	return uc.enrollOTP(ctx, employee, otpSecret)
+}
+
+// VerifyLoginChallenge completes a login awaiting its second factor,
+// which is an OTP from the authenticator app or a recovery code. An
+// employee enrolling the app along with the login also gets the
+// recovery codes.
+func (uc *credentialUseCase) VerifyLoginChallenge(ctx context.Context, cred vo.Credential) (entity.Employee, vo.Credential, error) {
+	if err := cred.ValidateLoginChallenge(); err != nil {
+		return entity.Employee{}, vo.Credential{}, NewDomainError("Credentials", err)
+	}
+
+	hash, challenge, employee, err := uc.attemptLoginChallenge(ctx, cred.ChallengeToken)
+	if err != nil {
+		return entity.Employee{}, vo.Credential{}, err
+	}
+
+	// The second factor is guessed no faster than the password, and its
+	// failures lock the account just the same
+	now := time.Now().In(utils.CURRENT_LOC)
+	previous, failures, err := uc.takeLoginAttempt(ctx, employee, now)
+	if err != nil {
+		return entity.Employee{}, vo.Credential{}, err
+	}
+
+	recoveryCodes, err := uc.verifySecondFactor(ctx, employee, cred)
+	if err != nil {
+		var appErr AppError
+		if errors.As(err, &appErr) && appErr.Code >= 500 {
+			uc.releaseLoginAttempt(ctx, employee.Id, now, previous)
+			return entity.Employee{}, vo.Credential{}, err
+		}
+		return entity.Employee{}, vo.Credential{}, uc.failLogin(ctx, employee, challenge.IP, failures, now, err)
+	}
+
+	consumed, err := uc.repo.ConsumeLoginChallenge(ctx, hash)
+	if err != nil {
+		return entity.Employee{}, vo.Credential{}, NewRepositoryError("Credentials", err)
+	}
+	if !consumed {
+		return entity.Employee{}, vo.Credential{}, NewUnauthorizedError(fmt.Errorf("your login has expired, please log in again"))
+	}
+
+	uc.clearLoginFailures(ctx, employee.Id)
+
+	cred.Device = challenge.Device
+	cred.IP = challenge.IP
+	cred, err = uc.startSession(ctx, employee, cred)
+	if err != nil {
+		return entity.Employee{}, vo.Credential{}, err
+	}
+	cred.RecoveryCodes = recoveryCodes
+
+	return employee, cred, nil
+}
+
+// verifySecondFactor verifies the OTP from the authenticator app or
+// the recovery code given to complete the login. An employee enrolling
+// the app along with the login gets the recovery codes.
+func (uc *credentialUseCase) verifySecondFactor(ctx context.Context, employee entity.Employee, cred vo.Credential) ([]string, error) {
+	otpSecret, secret, found, err := getOTPSecret(ctx, uc.repo, uc.service, employee.Id)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, NewClientError("Credentials", fmt.Errorf("start enrolling the authenticator app first"))
+	}
+
+	switch {
+	case otpSecret.IsEnrolled() && cred.RecoveryCode != "":
+		used, err := uc.repo.UseRecoveryCode(ctx, employee.Id, uc.service.HashRecoveryCode(cred.RecoveryCode), time.Now().In(utils.CURRENT_LOC))
+		if err != nil {
+			return nil, NewRepositoryError("Credentials", err)
+		}
+		if !used {
+			return nil, NewUnauthorizedError(fmt.Errorf("this recovery code is invalid or has been used"))
+		}
+		return nil, nil
+	case otpSecret.IsEnrolled():
+		return nil, verifyAuthenticatorOTP(ctx, uc.repo, uc.service, otpSecret, secret, cred.OTP, "Credentials")
+	default:
+		// The authenticator app is enrolled along with the login
+		return uc.confirmOTPEnrollment(ctx, employee, otpSecret, secret, cred.OTP)
+	}
+}
+
+// startLoginChallenge holds the login until the employee gives the
+// second factor, and returns the token to give it with.
+func (uc *credentialUseCase) startLoginChallenge(ctx context.Context, employee entity.Employee, cred vo.Credential) (vo.Credential, error) {
+	token, hash, err := uc.service.GenerateChallengeToken()
+	if err != nil {
+		return cred, NewServiceError("Doorkeeper", err)
+	}
+
+	challenge := entity.LoginChallenge{
+		EmployeeID: employee.Id,
+		Device:     cred.Device,
+		IP:         cred.IP,
+		ExpiresAt:  time.Now().In(utils.CURRENT_LOC).Add(loginChallengeDuration),
+	}
+	if err := uc.repo.SaveLoginChallenge(ctx, hash, challenge); err != nil {
+		return cred, NewRepositoryError("Credentials", err)
+	}
+
+	cred.ChallengeToken = token
+
+	return cred, nil
+}
+
+// attemptLoginChallenge counts an attempt at the login challenge and
+// returns its hash, the challenge and the employee. The challenge is
+// dropped after too many attempts.
+func (uc *credentialUseCase) attemptLoginChallenge(ctx context.Context, token string) (string, entity.LoginChallenge, entity.Employee, error) {
+	hash := uc.service.HashChallengeToken(token)
+	expired := NewUnauthorizedError(fmt.Errorf("your login has expired, please log in again"))
+
+	challenge, err := uc.repo.GetLoginChallenge(ctx, hash)
+	if err != nil {
+		return hash, challenge, entity.Employee{}, expired
+	}
+
+	attempts, err := uc.repo.CountLoginChallengeAttempts(ctx, hash)
+	if err != nil {
+		return hash, challenge, entity.Employee{}, NewRepositoryError("Credentials", err)
+	}
+	if attempts == 0 {
+		return hash, challenge, entity.Employee{}, expired
+	}
+	if attempts > loginChallengeAttempts {
+		if _, err := uc.repo.ConsumeLoginChallenge(ctx, hash); err != nil {
+			log.Printf("Unable to drop login challenge of employee %s: %s", challenge.EmployeeID, err)
+		}
+		return hash, challenge, entity.Employee{}, NewTooManyRequestsError("Credentials", fmt.Errorf("too many attempts, please log in again"))
+	}
+
+	employee, err := uc.repo.GetEmployeeByIdV2(ctx, challenge.EmployeeID)
+	if err != nil {
+		return hash, challenge, employee, NewNotFoundError("Employee", err)
+	}
+	if employee.ResignedAt != nil || employee.Status == entity.RESIGNED {
+		return hash, challenge, employee, NewUnauthorizedError(fmt.Errorf("you are no longer an employee of SinarLog"))
+	}
+
+	return hash, challenge, employee, nil
+}
+
+// enrollOTP replaces the OTP secret awaiting enrollment with a new one
+// and returns what the authenticator app needs.
+func (uc *credentialUseCase) enrollOTP(ctx context.Context, employee entity.Employee, otpSecret entity.EmployeeOTPSecret) (vo.OTPEnrollment, error) {
+	var enrollment vo.OTPEnrollment
+
+	if otpSecret.IsEnrolled() {
+		return enrollment, NewConflictError("OTP", fmt.Errorf("authenticator app has already been enrolled"))
+	}
+
+	secret, encrypted, err := uc.service.GenerateOTPSecret()
+	if err != nil {
+		return enrollment, NewServiceError("Doorkeeper", err)
+	}
+	otpSecret.Secret = encrypted
+	otpSecret.LastUsedStep = 0
+
+	if err := uc.repo.SaveEmployeeOTPSecret(ctx, otpSecret); err != nil {
+		return enrollment, NewRepositoryError("OTP", err)
+	}
+
+	enrollment.Secret = secret
+	enrollment.ProvisioningURI = uc.service.OTPProvisioningURI(secret, employee.Email)
+
+	return enrollment, nil
+}
+
+// confirmOTPEnrollment enrolls the authenticator app once it generates
+// a valid OTP, and issues the recovery codes. The employee is told by
+// email, in case the app is not theirs.
+func (uc *credentialUseCase) confirmOTPEnrollment(ctx context.Context, employee entity.Employee, otpSecret entity.EmployeeOTPSecret, secret, otp string) ([]string, error) {
+	if otpSecret.IsEnrolled() {
+		return nil, NewConflictError("OTP", fmt.Errorf("authenticator app has already been enrolled"))
+	}
+
+	now := time.Now().In(utils.CURRENT_LOC)
+	step, ok := uc.service.VerifyTOTP(secret, otp, now)
+	if !ok {
+		return nil, NewClientError("OTP", fmt.Errorf("OTP does not match"))
+	}
+
+	otpSecret.EnrolledAt = &now
+	otpSecret.LastUsedStep = step
+	if err := uc.repo.SaveEmployeeOTPSecret(ctx, otpSecret); err != nil {
+		return nil, NewRepositoryError("OTP", err)
+	}
+
+	codes, err := uc.issueRecoveryCodes(ctx, otpSecret.EmployeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send email
+	go uc.sendTwoFactorChangedMail(employee.Email, map[string]any{
+		"FullName":  employee.FullName,
+		"Change":    "added to",
+		"ChangedAt": now.Format(time.RFC1123),
+	})
+
+	return codes, nil
+}
+
+func (uc *credentialUseCase) issueRecoveryCodes(ctx context.Context, employeeId string) ([]string, error) {
+	codes, hashes, err := uc.service.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, NewServiceError("Doorkeeper", err)
+	}
+
+	if err := uc.repo.ReplaceRecoveryCodes(ctx, employeeId, hashes); err != nil {
+		return nil, NewRepositoryError("OTP", err)
+	}
+
+	return codes, nil
+}
+
+// isTwoFactorMandatory tells whether the employee must log in with an
+// authenticator app, which is the case of those who can change the
+// company configuration or the data of everyone, such as HR.
+func isTwoFactorMandatory(employee entity.Employee) bool {
+	for _, perm := range []entity.Permission{entity.PERM_CONFIG_WRITE, entity.PERM_EMPLOYEE_UPDATE, entity.PERM_ROLE_WRITE} {
+		if scope, ok := employee.Role.ScopeOf(perm); ok && scope == entity.SCOPE_ALL {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (uc *credentialUseCase) sendForgotPasswordMail(receiver string, data map[string]any) {
 	if err := uc.mailerService.SendEmail(receiver, service.FORGOT_PASSWORD, data); err != nil {
 		log.Printf("Unable to send forgot password email: %s", err)
 	}
 }
+
+func (uc *credentialUseCase) sendAccountLockedMail(receiver string, data map[string]any) {
+	if err := uc.mailerService.SendEmail(receiver, service.ACCOUNT_LOCKED, data); err != nil {
+		log.Printf("Unable to send account locked email: %s", err)
+	}
+}
+
+func (uc *credentialUseCase) sendTwoFactorChangedMail(receiver string, data map[string]any) {
+	if err := uc.mailerService.SendEmail(receiver, service.TWO_FACTOR_CHANGED, data); err != nil {
+		log.Printf("Unable to send two-factor changed email: %s", err)
+	}
+}
+
+// getOTPSecret queries the OTP secret of the employee along with the
+// decrypted secret. It returns false if the employee has none yet.
+func getOTPSecret(ctx context.Context, credRepo repo.ICredentialRepo, dkService service.IDoorkeeperService, employeeId string) (entity.EmployeeOTPSecret, string, bool, error) {
+	otpSecret, found, err := credRepo.GetEmployeeOTPSecret(ctx, employeeId)
+	if err != nil {
+		return otpSecret, "", false, NewRepositoryError("OTP", err)
+	}
+	if !found {
+		return entity.EmployeeOTPSecret{EmployeeID: employeeId}, "", false, nil
+	}
+
+	secret, err := dkService.DecryptOTPSecret(otpSecret.Secret)
+	if err != nil {
+		return otpSecret, "", false, NewServiceError("Doorkeeper", err)
+	}
+
+	return otpSecret, secret, true, nil
+}
+
+// getOrCreateOTPSecret queries the OTP secret of the employee along
+// with the decrypted secret, and creates one if the employee has none
+// yet. It is only meant for sending an OTP, reading the secret must
+// not create it.
+func getOrCreateOTPSecret(ctx context.Context, credRepo repo.ICredentialRepo, dkService service.IDoorkeeperService, employeeId string) (entity.EmployeeOTPSecret, string, error) {
+	otpSecret, secret, found, err := getOTPSecret(ctx, credRepo, dkService, employeeId)
+	if err != nil || found {
+		return otpSecret, secret, err
+	}
+
+	secret, encrypted, err := dkService.GenerateOTPSecret()
+	if err != nil {
+		return otpSecret, "", NewServiceError("Doorkeeper", err)
+	}
+	otpSecret.Secret = encrypted
+
+	if err := credRepo.SaveEmployeeOTPSecret(ctx, otpSecret); err != nil {
+		return otpSecret, "", NewRepositoryError("OTP", err)
+	}
+
+	// Query it again as another request may have created it first
+	otpSecret, _, err = credRepo.GetEmployeeOTPSecret(ctx, employeeId)
+	if err != nil {
+		return otpSecret, "", NewRepositoryError("OTP", err)
+	}
+	secret, err = dkService.DecryptOTPSecret(otpSecret.Secret)
+	if err != nil {
+		return otpSecret, "", NewServiceError("Doorkeeper", err)
+	}
+
+	return otpSecret, secret, nil
+}
+
+// verifyAuthenticatorOTP verifies an OTP generated by the authenticator
+// app of the employee. An OTP is accepted once, and never after a later
+// one.
+func verifyAuthenticatorOTP(ctx context.Context, credRepo repo.ICredentialRepo, dkService service.IDoorkeeperService, otpSecret entity.EmployeeOTPSecret, secret, otp, domain string) error {
+	step, ok := dkService.VerifyTOTP(secret, otp, time.Now().In(utils.CURRENT_LOC))
+	if !ok {
+		return NewClientError(domain, fmt.Errorf("OTP does not match"))
+	}
+
+	used, err := credRepo.UseOTPStep(ctx, otpSecret.EmployeeID, step)
+	if err != nil {
+		return NewRepositoryError(domain, err)
+	}
+	if !used {
+		return NewClientError(domain, fmt.Errorf("OTP has already been used"))
+	}
+
+	return nil
+}
@@ -0,0 +1,453 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"sinarlog.com/internal/app/repo"
+	"sinarlog.com/internal/app/service"
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/entity/vo"
+)
+
+// fakeCredentialRepo keeps the login of a single employee in memory.
+// Calling a method it does not fake panics.
+type fakeCredentialRepo struct {
+	repo.ICredentialRepo
+	employee    entity.Employee
+	otpSecret   entity.EmployeeOTPSecret
+	failures    entity.LoginFailures
+	lockedUntil time.Time
+	challenge   entity.LoginChallenge
+	attempts    map[string]int64
+	codes       map[string]bool
+	session     entity.Session
+}
+
+func (r *fakeCredentialRepo) GetEmployeeByEmail(ctx context.Context, email string) (entity.Employee, error) {
+	return r.employee, nil
+}
+
+func (r *fakeCredentialRepo) GetEmployeeByIdV2(ctx context.Context, id string) (entity.Employee, error) {
+	return r.employee, nil
+}
+
+func (r *fakeCredentialRepo) GetEmployeeOTPSecret(ctx context.Context, employeeId string) (entity.EmployeeOTPSecret, bool, error) {
+	return r.otpSecret, r.otpSecret.Secret != "", nil
+}
+
+func (r *fakeCredentialRepo) UseOTPStep(ctx context.Context, employeeId string, step int64) (bool, error) {
+	return true, nil
+}
+
+func (r *fakeCredentialRepo) SaveEmployeeOTPSecret(ctx context.Context, secret entity.EmployeeOTPSecret) error {
+	r.otpSecret = secret
+	return nil
+}
+
+func (r *fakeCredentialRepo) ReplaceRecoveryCodes(ctx context.Context, employeeId string, hashes []string) error {
+	r.codes = make(map[string]bool)
+	for _, v := range hashes {
+		r.codes[v] = true
+	}
+	return nil
+}
+
+func (r *fakeCredentialRepo) UseRecoveryCode(ctx context.Context, employeeId, hash string, at time.Time) (bool, error) {
+	used := r.codes[hash]
+	delete(r.codes, hash)
+	return used, nil
+}
+
+func (r *fakeCredentialRepo) SaveLoginChallenge(ctx context.Context, hash string, challenge entity.LoginChallenge) error {
+	r.challenge = challenge
+	r.attempts[hash] = 0
+	return nil
+}
+
+func (r *fakeCredentialRepo) GetLoginChallenge(ctx context.Context, hash string) (entity.LoginChallenge, error) {
+	if _, ok := r.attempts[hash]; !ok {
+		return entity.LoginChallenge{}, fmt.Errorf("login challenge not found")
+	}
+	return r.challenge, nil
+}
+
+func (r *fakeCredentialRepo) CountLoginChallengeAttempts(ctx context.Context, hash string) (int64, error) {
+	if _, ok := r.attempts[hash]; !ok {
+		return 0, nil
+	}
+	r.attempts[hash]++
+	return r.attempts[hash], nil
+}
+
+func (r *fakeCredentialRepo) ConsumeLoginChallenge(ctx context.Context, hash string) (bool, error) {
+	_, ok := r.attempts[hash]
+	delete(r.attempts, hash)
+	return ok, nil
+}
+
+func (r *fakeCredentialRepo) CreateSession(ctx context.Context, session entity.Session) error {
+	return nil
+}
+
+func (r *fakeCredentialRepo) GetSessionById(ctx context.Context, id string) (entity.Session, error) {
+	if r.session.Id != id {
+		return entity.Session{}, fmt.Errorf("session not found")
+	}
+	return r.session, nil
+}
+
+func (r *fakeCredentialRepo) TouchSession(ctx context.Context, id string, at time.Time) error {
+	r.session.LastSeenAt = at
+	return nil
+}
+
+func (r *fakeCredentialRepo) TakeLoginAttempt(ctx context.Context, subject string, at time.Time, window time.Duration) (entity.LoginFailures, entity.LoginFailures, error) {
+	previous := r.failures
+	r.failures = entity.LoginFailures{Count: previous.Count + 1, LastFailedAt: at}
+	return previous, r.failures, nil
+}
+
+func (r *fakeCredentialRepo) ReleaseLoginAttempt(ctx context.Context, subject string, at time.Time, previous entity.LoginFailures) error {
+	r.failures.Count--
+	if r.failures.LastFailedAt.Equal(at) {
+		r.failures.LastFailedAt = previous.LastFailedAt
+	}
+	return nil
+}
+
+func (r *fakeCredentialRepo) ClearLoginFailures(ctx context.Context, subject string) error {
+	r.failures = entity.LoginFailures{}
+	return nil
+}
+
+func (r *fakeCredentialRepo) LockAccount(ctx context.Context, employeeId string, until time.Time) error {
+	r.lockedUntil = until
+	return nil
+}
+
+func (r *fakeCredentialRepo) GetAccountLock(ctx context.Context, employeeId string) (time.Time, bool, error) {
+	return r.lockedUntil, !r.lockedUntil.IsZero(), nil
+}
+
+// fakeDoorkeeperService accepts the password equal to the hash, the
+// OTP 123456, and the access token of the session "session" of the
+// employee, which expires at fakeTokenExpiresAt.
+type fakeDoorkeeperService struct {
+	service.IDoorkeeperService
+}
+
+var fakeTokenExpiresAt = time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+
+func (fakeDoorkeeperService) VerifyAndParseToken(ctx context.Context, tk string) (vo.TokenClaims, error) {
+	if tk != "access" {
+		return vo.TokenClaims{}, fmt.Errorf("token is invalid")
+	}
+	return vo.TokenClaims{EmployeeID: "employee", SessionID: "session", ExpiresAt: fakeTokenExpiresAt}, nil
+}
+
+func (fakeDoorkeeperService) VerifyPassword(hash, password string) error {
+	if hash != password {
+		return fmt.Errorf("password does not match")
+	}
+	return nil
+}
+
+func (fakeDoorkeeperService) GenerateChallengeToken() (string, string, error) {
+	return "challenge", "challenge-hash", nil
+}
+
+func (fakeDoorkeeperService) HashChallengeToken(tk string) string {
+	return tk + "-hash"
+}
+
+func (fakeDoorkeeperService) DecryptOTPSecret(encrypted string) (string, error) {
+	return encrypted, nil
+}
+
+func (fakeDoorkeeperService) VerifyTOTP(secret, otp string, at time.Time) (int64, bool) {
+	return at.Unix() / 30, otp == "123456"
+}
+
+func (fakeDoorkeeperService) GetRefreshDuration() time.Duration {
+	return time.Hour
+}
+
+func (fakeDoorkeeperService) GenerateRefreshToken(sessionId string) (string, string, error) {
+	return sessionId + ".refresh", "refresh-hash", nil
+}
+
+func (fakeDoorkeeperService) GenerateToken(employee entity.Employee, sessionId string) (string, error) {
+	return "access", nil
+}
+
+func (fakeDoorkeeperService) GenerateOTPSecret() (string, string, error) {
+	return "new-otp-secret", "new-otp-secret", nil
+}
+
+func (fakeDoorkeeperService) OTPProvisioningURI(secret, email string) string {
+	return "otpauth://totp/SinarLog:" + email + "?secret=" + secret
+}
+
+func (fakeDoorkeeperService) HashRecoveryCode(code string) string {
+	return code + "-hash"
+}
+
+type fakeMailerService struct{}
+
+func (fakeMailerService) SendEmail(receiver string, mailType string, data map[string]any) error {
+	return nil
+}
+
+func testCredentialUseCase() (*credentialUseCase, *fakeCredentialRepo) {
+	enrolledAt := time.Now()
+	credRepo := &fakeCredentialRepo{
+		employee:  entity.Employee{BaseModelId: entity.BaseModelId{Id: "employee"}, Password: "secret-password"},
+		otpSecret: entity.EmployeeOTPSecret{EmployeeID: "employee", Secret: "otp-secret", EnrolledAt: &enrolledAt},
+		attempts:  make(map[string]int64),
+		codes:     map[string]bool{"abcde-fghij-hash": true},
+	}
+
+	return NewCredentialUseCase(credRepo, fakeDoorkeeperService{}, fakeMailerService{}).(*credentialUseCase), credRepo
+}
+
+func errorCode(err error) int {
+	var appErr AppError
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return 0
+}
+
+func TestTwoFactorMandatoryForHr(t *testing.T) {
+	for code, mandatory := range map[string]bool{"staff": false, "mngr": false, "hr": true} {
+		employee := entity.Employee{Role: entity.Role{Code: code, Permissions: entity.DefaultRolePermissions[code]}}
+		if got := isTwoFactorMandatory(employee); got != mandatory {
+			t.Fatalf("expected two-factor to be mandatory for %s to be %v, got %v", code, mandatory, got)
+		}
+	}
+
+	// Updating the data of only some employees does not make two-factor
+	// mandatory
+	employee := entity.Employee{Role: entity.Role{Permissions: []entity.RolePermission{
+		{Permission: entity.PERM_EMPLOYEE_UPDATE, Scope: entity.SCOPE_DIRECT_REPORTS},
+	}}}
+	if isTwoFactorMandatory(employee) {
+		t.Fatalf("expected two-factor not to be mandatory without a permission over everyone")
+	}
+}
+
+func TestLoginDelay(t *testing.T) {
+	cases := []struct {
+		failures int64
+		delay    time.Duration
+	}{
+		{0, 0},
+		{loginDelayAfter - 1, 0},
+		{loginDelayAfter, loginDelayBase},
+		{loginDelayAfter + 1, 2 * loginDelayBase},
+		{loginDelayAfter + 2, 4 * loginDelayBase},
+		{loginLockThreshold - 1, loginDelayMax},
+		{100, loginDelayMax},
+	}
+
+	for _, c := range cases {
+		if got := loginDelay(c.failures); got != c.delay {
+			t.Fatalf("expected a delay of %s after %d failures, got %s", c.delay, c.failures, got)
+		}
+	}
+}
+
+func TestFailedSecondFactorsLockTheAccount(t *testing.T) {
+	uc, credRepo := testCredentialUseCase()
+	ctx := context.Background()
+	login := vo.Credential{Email: "employee@sinarlog.com", Password: "secret-password"}
+
+	var challenge vo.Credential
+	for i := 0; i < loginLockThreshold; i++ {
+		// Logging in again gives a new challenge, but keeps counting
+		// the wrong OTPs
+		if i%loginChallengeAttempts == 0 {
+			_, cred, err := uc.Login(ctx, login)
+			if err != nil || cred.ChallengeToken == "" {
+				t.Fatalf("expected the password to start a login challenge, got %v", err)
+			}
+			if credRepo.failures.Count != int64(i) {
+				t.Fatalf("expected %d failed logins to be kept, got %d", i, credRepo.failures.Count)
+			}
+			challenge = vo.Credential{ChallengeToken: cred.ChallengeToken, OTP: "000000"}
+		}
+
+		if _, _, err := uc.VerifyLoginChallenge(ctx, challenge); err == nil {
+			t.Fatalf("expected a wrong OTP to be refused")
+		}
+
+		// Waits out the delay after the failure
+		credRepo.failures.LastFailedAt = time.Time{}
+	}
+
+	if credRepo.lockedUntil.IsZero() {
+		t.Fatalf("expected the account to be locked after %d wrong OTPs", loginLockThreshold)
+	}
+	if _, _, err := uc.Login(ctx, login); errorCode(err) != 429 {
+		t.Fatalf("expected the login of a locked account to be refused, got %v", err)
+	}
+}
+
+func TestSecondFactorClearsFailedLogins(t *testing.T) {
+	uc, credRepo := testCredentialUseCase()
+	ctx := context.Background()
+
+	if _, _, err := uc.Login(ctx, vo.Credential{Email: "employee@sinarlog.com", Password: "wrong-password"}); errorCode(err) != 401 {
+		t.Fatalf("expected a wrong password to be refused, got %v", err)
+	}
+	credRepo.failures.LastFailedAt = time.Time{}
+
+	_, cred, err := uc.Login(ctx, vo.Credential{Email: "employee@sinarlog.com", Password: "secret-password"})
+	if err != nil {
+		t.Fatalf("unable to log in: %s", err)
+	}
+	if credRepo.failures.Count != 1 {
+		t.Fatalf("expected the failed login to be kept until the second factor, got %d", credRepo.failures.Count)
+	}
+
+	if _, _, err := uc.VerifyLoginChallenge(ctx, vo.Credential{ChallengeToken: cred.ChallengeToken, OTP: "000000"}); err == nil {
+		t.Fatalf("expected a wrong OTP to be refused")
+	}
+	if credRepo.failures.Count != 2 {
+		t.Fatalf("expected the wrong OTP to be counted, got %d failures", credRepo.failures.Count)
+	}
+
+	if _, cred, err = uc.VerifyLoginChallenge(ctx, vo.Credential{ChallengeToken: cred.ChallengeToken, OTP: "123456"}); err != nil || cred.AccessToken == "" {
+		t.Fatalf("expected the OTP to complete the login, got %v", err)
+	}
+	if credRepo.failures.Count != 0 {
+		t.Fatalf("expected the failed logins to be forgotten, got %d", credRepo.failures.Count)
+	}
+}
+
+func TestLoginAttemptWaitsAfterFailures(t *testing.T) {
+	uc, credRepo := testCredentialUseCase()
+	ctx := context.Background()
+
+	failedAt := time.Now()
+	credRepo.failures = entity.LoginFailures{Count: loginDelayAfter, LastFailedAt: failedAt}
+
+	if _, _, err := uc.Login(ctx, vo.Credential{Email: "employee@sinarlog.com", Password: "secret-password"}); errorCode(err) != 429 {
+		t.Fatalf("expected the login to wait after %d failures, got %v", loginDelayAfter, err)
+	}
+	if credRepo.failures.Count != loginDelayAfter || !credRepo.failures.LastFailedAt.Equal(failedAt) {
+		t.Fatalf("expected a refused attempt not to be counted, got %+v", credRepo.failures)
+	}
+}
+
+func TestRemovingOTPEnrollmentNeedsASecondFactor(t *testing.T) {
+	uc, credRepo := testCredentialUseCase()
+	ctx := context.Background()
+
+	cases := []struct {
+		name         string
+		otp          string
+		recoveryCode string
+		code         int
+	}{
+		{"without any second factor", "", "", 422},
+		{"with a wrong OTP", "000000", "", 400},
+		{"with a wrong recovery code", "", "zzzzz-zzzzz", 400},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := uc.RemoveOTPEnrollment(ctx, credRepo.employee, c.otp, c.recoveryCode); errorCode(err) != c.code {
+				t.Fatalf("expected the removal to be refused with %d, got %v", c.code, err)
+			}
+			if !credRepo.otpSecret.IsEnrolled() {
+				t.Fatalf("expected the authenticator app to stay enrolled")
+			}
+		})
+	}
+
+	if err := uc.RemoveOTPEnrollment(ctx, credRepo.employee, "", "abcde-fghij"); err != nil {
+		t.Fatalf("expected a recovery code to remove the authenticator app, got %v", err)
+	}
+	if credRepo.otpSecret.IsEnrolled() || len(credRepo.codes) != 0 {
+		t.Fatalf("expected the authenticator app and its recovery codes to be removed")
+	}
+}
+
+func TestOTPSecretIsOnlyCreatedOnEnrollment(t *testing.T) {
+	uc, credRepo := testCredentialUseCase()
+	credRepo.otpSecret = entity.EmployeeOTPSecret{}
+	ctx := context.Background()
+
+	status, err := uc.RetrieveMyOTPStatus(ctx, credRepo.employee)
+	if err != nil || status.IsEnrolled {
+		t.Fatalf("expected the authenticator app not to be enrolled, got %v", err)
+	}
+	if _, err := uc.ConfirmOTPEnrollment(ctx, credRepo.employee, "123456"); errorCode(err) != 400 {
+		t.Fatalf("expected confirming before enrolling to be refused with 400, got %v", err)
+	}
+	if credRepo.otpSecret.Secret != "" {
+		t.Fatalf("expected no OTP secret to be created before enrolling")
+	}
+
+	if _, err := uc.EnrollOTP(ctx, credRepo.employee); err != nil {
+		t.Fatalf("expected enrolling to start, got %v", err)
+	}
+	if credRepo.otpSecret.Secret == "" || credRepo.otpSecret.EmployeeID != credRepo.employee.Id {
+		t.Fatalf("expected enrolling to create the OTP secret of the employee")
+	}
+}
+
+func TestAuthorizingSockets(t *testing.T) {
+	resignedAt := time.Now()
+	testCases := []struct {
+		name    string
+		token   string
+		session entity.Session
+		prepare func(employee *entity.Employee)
+		code    int
+	}{
+		{"Valid token", "access", entity.Session{Id: "session", EmployeeID: "employee"}, nil, 0},
+		{"Invalid token", "forged", entity.Session{Id: "session", EmployeeID: "employee"}, nil, 401},
+		{"Revoked session", "access", entity.Session{}, nil, 401},
+		{"Session of another employee", "access", entity.Session{Id: "session", EmployeeID: "other"}, nil, 401},
+		{"Token version bumped", "access", entity.Session{Id: "session", EmployeeID: "employee"}, func(employee *entity.Employee) {
+			employee.TokenVersion++
+		}, 401},
+		{"Resigned employee", "access", entity.Session{Id: "session", EmployeeID: "employee"}, func(employee *entity.Employee) {
+			employee.ResignedAt = &resignedAt
+		}, 401},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, credRepo := testCredentialUseCase()
+			credRepo.session = tc.session
+			if tc.prepare != nil {
+				tc.prepare(&credRepo.employee)
+			}
+
+			employee, expiresAt, err := uc.AuthorizeSocket(context.Background(), tc.token)
+			if errorCode(err) != tc.code {
+				t.Fatalf("expected code %d, got %v", tc.code, err)
+			}
+			if tc.code != 0 {
+				return
+			}
+
+			if employee.Id != "employee" {
+				t.Fatalf("expected the employee of the token, got %s", employee.Id)
+			}
+			if !expiresAt.Equal(fakeTokenExpiresAt) {
+				t.Fatalf("expected the socket to expire with the token at %s, got %s", fakeTokenExpiresAt, expiresAt)
+			}
+			if credRepo.session.LastSeenAt.IsZero() {
+				t.Fatalf("expected the session to be touched")
+			}
+		})
+	}
+}
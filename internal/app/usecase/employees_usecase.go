@@ -6,6 +6,7 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"log"
 	"mime/multipart"
 	"time"
 
@@ -20,30 +21,36 @@ import (
 type employeesUseCase struct {
 	emplRepo    repo.IEmployeeRepo
 	configRepo  repo.IConfigRepo
+	leaveRepo   repo.ILeaveRepo
 	sharedRepo  repo.ISharedRepo
 	credRepo    repo.ICredentialRepo
 	dkService   service.IDoorkeeperService
 	mailService service.IMailerService
 	bktService  service.IBucketService
+	chatUC      IChatUseCase
 }
 
 func NewEmployeeUseCase(
 	emplRepo repo.IEmployeeRepo,
 	configRepo repo.IConfigRepo,
+	leaveRepo repo.ILeaveRepo,
 	sharedRepo repo.ISharedRepo,
 	credRepo repo.ICredentialRepo,
 	dkService service.IDoorkeeperService,
 	mailService service.IMailerService,
 	bktService service.IBucketService,
+	chatUC IChatUseCase,
 ) *employeesUseCase {
 	return &employeesUseCase{
 		emplRepo:    emplRepo,
 		configRepo:  configRepo,
+		leaveRepo:   leaveRepo,
 		sharedRepo:  sharedRepo,
 		credRepo:    credRepo,
 		dkService:   dkService,
 		mailService: mailService,
 		bktService:  bktService,
+		chatUC:      chatUC,
 	}
 }
 
@@ -54,12 +61,9 @@ ACTOR: ALL
 */
 func (uc *employeesUseCase) RetrieveEmployeesList(ctx context.Context, requestee entity.Employee, q vo.AllEmployeeQuery) ([]entity.Employee, vo.PaginationDTOResponse, error) {
 	q.Pagination.Order = "join_date"
-	role, err := uc.sharedRepo.GetRoleById(ctx, requestee.RoleID)
-	if err != nil {
-		return nil, vo.PaginationDTOResponse{}, NewRepositoryError("Role", err)
-	}
+	scope, _ := requestee.Role.ScopeOf(entity.PERM_EMPLOYEE_READ)
 
-	employees, page, err := uc.emplRepo.GetAllEmployees(ctx, requestee.Id, role.Code, q)
+	employees, page, err := uc.emplRepo.GetAllEmployees(ctx, requestee.Id, scope, q)
 	if err != nil {
 		return employees, page, NewRepositoryError("Employee", err)
 	}
@@ -85,15 +89,27 @@ func (uc *employeesUseCase) RegisterNewEmployee(ctx context.Context, creator, pa
 	payload.IsNewUser = true
 	payload.Status = entity.UNAVAILABLE
 	payload.Id = uuid.NewString()
-	payload.EmployeeLeavesQuota.EmployeeID = payload.Id
 
-	if !payload.EmployeeBiodata.MaritalStatus {
-		// Query config record
-		config, err := uc.configRepo.GetConfiguration(ctx)
-		if err != nil {
-			return NewRepositoryError("Configurations", err)
+	// Give the default quota of each leave type
+	policies, err := uc.leaveRepo.GetLeavePolicies(ctx)
+	if err != nil {
+		return NewRepositoryError("Leave Type", err)
+	}
+	for _, policy := range policies {
+		if !policy.HasQuota {
+			continue
+		}
+
+		quota := entity.EmployeeLeaveQuota{EmployeeID: payload.Id, Type: policy.Code, Count: policy.DefaultQuota}
+		if policy.Accrues() {
+			// An accruing leave type is accrued from the join date
+			quota.Count = 0
+			quota.Year = payload.JoinDate.Year()
+		}
+		if policy.Code == entity.MARRIAGE && payload.EmployeeBiodata.MaritalStatus {
+			quota.Count = 0
 		}
-		payload.EmployeeLeavesQuota.MarriageCount = config.DefaultMarriageQuota
+		payload.EmployeeLeaveQuotas = append(payload.EmployeeLeaveQuotas, quota)
 	}
 
 	// Query Role
@@ -142,6 +158,10 @@ func (uc *employeesUseCase) RegisterNewEmployee(ctx context.Context, creator, pa
 		return NewRepositoryError("Employee", err)
 	}
 
+	if payload.ManagerID != nil {
+		uc.syncTeamRooms(ctx, *payload.ManagerID)
+	}
+
 	// Prepare for sending email
 	dataForMail := map[string]any{
 		"FullName": payload.FullName,
@@ -172,51 +192,21 @@ func (uc *employeesUseCase) ViewManagersList(ctx context.Context) ([]entity.Empl
 }
 
 func (uc *employeesUseCase) RetrieveEmployeeFullProfile(ctx context.Context, requestee entity.Employee, employeeId string) (entity.Employee, error) {
-	if requestee.ManagerID == nil {
-		role, err := uc.sharedRepo.GetRoleById(ctx, requestee.RoleID)
-		if err != nil {
-			return entity.Employee{}, NewRepositoryError("Role", err)
-		}
-
-		switch role.Code {
-		case "hr":
-			employee, err := uc.emplRepo.GetEmployeeFullProfileById(ctx, employeeId)
-			if err != nil {
-				return entity.Employee{}, NewRepositoryError("Employee", err)
-			}
+	employee, err := uc.emplRepo.GetEmployeeSimpleInformationById(ctx, employeeId)
+	if err != nil {
+		return employee, NewRepositoryError("Employee", err)
+	}
 
-			return employee, nil
-		case "mngr":
-			employee, err := uc.emplRepo.GetEmployeeById(ctx, employeeId)
-			if err != nil {
-				return entity.Employee{}, NewRepositoryError("Employee", err)
-			}
-			if employee.ManagerID == nil {
-				employee, err = uc.emplRepo.GetEmployeeSimpleInformationById(ctx, employeeId)
-				if err != nil {
-					return employee, NewRepositoryError("Employee", err)
-				}
-				return employee, nil
-			} else if *employee.ManagerID == requestee.Id {
-				employee, err = uc.emplRepo.GetEmployeeFullProfileById(ctx, employeeId)
-				if err != nil {
-					return employee, NewRepositoryError("Employee", err)
-				}
-				return employee, nil
-			} else {
-				employee, err = uc.emplRepo.GetEmployeeSimpleInformationById(ctx, employeeId)
-				if err != nil {
-					return employee, NewRepositoryError("Employee", err)
-				}
-				return employee, nil
-			}
-		}
+	// Those not granted over the employee only see the simple information
+	if !requestee.Can(entity.PERM_EMPLOYEE_READ, &employee) {
+		return employee, nil
 	}
 
-	employee, err := uc.emplRepo.GetEmployeeSimpleInformationById(ctx, employeeId)
+	employee, err = uc.emplRepo.GetEmployeeFullProfileById(ctx, employeeId)
 	if err != nil {
 		return employee, NewRepositoryError("Employee", err)
 	}
+
 	return employee, nil
 }
 
@@ -241,6 +231,10 @@ func (uc *employeesUseCase) UpdateEmployeeData(ctx context.Context, hr entity.Em
 	if err != nil {
 		return NewRepositoryError("Employee", err)
 	}
+	var prevManagerId string
+	if employee.ManagerID != nil {
+		prevManagerId = *employee.ManagerID
+	}
 
 	logs.Employee = employee
 	logs.EmployeeID = employeeId
@@ -290,7 +284,7 @@ func (uc *employeesUseCase) UpdateEmployeeData(ctx context.Context, hr entity.Em
 			employee.Role = role
 			employee.RoleID = payload.RoleId
 
-			if employee.Role.Code != "staff" {
+			if !employee.Role.RequiresManager {
 				employee.ManagerID = nil
 				employee.Manager = nil
 			}
@@ -362,6 +356,30 @@ func (uc *employeesUseCase) UpdateEmployeeData(ctx context.Context, hr entity.Em
 		return NewRepositoryError("Employee", err)
 	}
 
+	// The team rooms follow who reports to whom, and a resigned
+	// employee leaves the team room of their manager
+	_, managerChanged := changes["manager"]
+	if _, statusChanged := changes["status"]; managerChanged || statusChanged {
+		var managerId string
+		if employee.ManagerID != nil {
+			managerId = *employee.ManagerID
+		}
+		uc.syncTeamRooms(ctx, prevManagerId, managerId)
+	}
+
+	// A new status or role revokes the tokens issued before, since
+	// they might grant what the employee is no longer allowed to do.
+	_, statusChanged := changes["status"]
+	_, roleChanged := changes["role"]
+	if statusChanged || roleChanged {
+		if err := uc.credRepo.BumpTokenVersion(ctx, employee.Id); err != nil {
+			return NewRepositoryError("Employee", err)
+		}
+		if err := uc.credRepo.DeleteSessionsByEmployeeId(ctx, employee.Id); err != nil {
+			return NewRepositoryError("Session", err)
+		}
+	}
+
 	return nil
 }
 
@@ -451,6 +469,11 @@ func (uc *employeesUseCase) UpdatePassword(ctx context.Context, employee entity.
 		return NewRepositoryError("Employee", err)
 	}
 
+	// Every session is revoked, including the current one
+	if err := uc.credRepo.DeleteSessionsByEmployeeId(ctx, employee.Id); err != nil {
+		return NewRepositoryError("Session", err)
+	}
+
 	return nil
 }
 
@@ -486,3 +509,12 @@ MAILER HELPERS
 func (uc *employeesUseCase) sendMailToNewEmployee(data map[string]any) {
 	uc.mailService.SendEmail(data["Email"].(string), service.CRED, data)
 }
+
+// syncTeamRooms brings the team rooms of the managers up to date. The
+// employee data is saved already, hence a failure is only logged and
+// the team rooms are brought up to date by the scheduler later.
+func (uc *employeesUseCase) syncTeamRooms(ctx context.Context, managerIds ...string) {
+	if err := uc.chatUC.SyncTeamRooms(ctx, managerIds...); err != nil {
+		log.Printf("Unable to sync the team rooms of %v: %s", managerIds, err)
+	}
+}
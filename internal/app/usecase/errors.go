@@ -15,6 +15,7 @@ var (
 	ErrConflictState       = errors.New("conflict state")
 	ErrUnauthorized        = errors.New("unauthorized action")
 	ErrForbidden           = errors.New("forbidden action")
+	ErrTooManyRequests     = errors.New("too many requests")
 )
 
 var ErrNameMapper = map[error]string{
@@ -27,6 +28,7 @@ var ErrNameMapper = map[error]string{
 	ErrConflictState:       "ConflictDuplicationError",
 	ErrUnauthorized:        "UnauthorizedError",
 	ErrForbidden:           "ForbiddenError",
+	ErrTooManyRequests:     "TooManyRequestsError",
 }
 
 type AppError struct {
@@ -113,3 +115,7 @@ func NewClientError(domain string, err error) error {
 func NewForbiddenError(err error) error {
 	return NewError("Credentials", 403, ErrForbidden, err)
 }
+
+func NewTooManyRequestsError(domain string, err error) error {
+	return NewError(domain, 429, ErrTooManyRequests, err)
+}
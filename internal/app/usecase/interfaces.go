@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"mime/multipart"
+	"time"
 
 	"sinarlog.com/internal/entity"
 	"sinarlog.com/internal/entity/vo"
@@ -10,18 +11,47 @@ import (
 
 type ICredentialUseCase interface {
 	Login(ctx context.Context, cred vo.Credential) (entity.Employee, vo.Credential, error)
-	Authorize(ctx context.Context, token string, roles ...any) (entity.Employee, error)
+	Authorize(ctx context.Context, token string, perms ...entity.Permission) (entity.Employee, entity.Session, error)
+	AuthorizeSocket(ctx context.Context, token string) (entity.Employee, time.Time, error)
 	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, cred vo.Credential) error
+	UnlockAccount(ctx context.Context, hr entity.Employee, employeeId string) error
+
+	RefreshSession(ctx context.Context, cred vo.Credential) (vo.Credential, error)
+	Logout(ctx context.Context, session entity.Session) error
+	RetrieveMySessions(ctx context.Context, employee entity.Employee) ([]entity.Session, error)
+	RevokeMySession(ctx context.Context, employee entity.Employee, id string) error
+
+	RetrieveMyOTPStatus(ctx context.Context, employee entity.Employee) (vo.OTPStatus, error)
+	EnrollOTP(ctx context.Context, employee entity.Employee) (vo.OTPEnrollment, error)
+	ConfirmOTPEnrollment(ctx context.Context, employee entity.Employee, otp string) ([]string, error)
+	RegenerateRecoveryCodes(ctx context.Context, employee entity.Employee, otp string) ([]string, error)
+	RemoveOTPEnrollment(ctx context.Context, employee entity.Employee, otp, recoveryCode string) error
+	ResetTwoFactor(ctx context.Context, hr entity.Employee, employeeId string) error
+
+	EnrollTwoFactor(ctx context.Context, cred vo.Credential) (vo.OTPEnrollment, error)
+	VerifyLoginChallenge(ctx context.Context, cred vo.Credential) (entity.Employee, vo.Credential, error)
 }
 
 type IConfigUseCase interface {
 	RetrieveConfiguration(ctx context.Context) (entity.Configuration, error)
 	ChangeCompanyConfig(ctx context.Context, hr entity.Employee, payload entity.Configuration) error
 	RetrieveChangesLogs(ctx context.Context, q vo.CommonQuery) ([]entity.ConfigurationChangesLog, vo.PaginationDTOResponse, error)
+	RetrievePendingConfigChanges(ctx context.Context) ([]entity.PendingConfigChanges, error)
+	CancelPendingConfigChanges(ctx context.Context, hr entity.Employee, scope entity.ConfigChangeScope) error
+
+	RetrieveOfficeLocations(ctx context.Context) ([]entity.OfficeLocation, error)
+	RegisterOfficeLocation(ctx context.Context, payload entity.OfficeLocation) error
+	UpdateOfficeLocation(ctx context.Context, id string, payload entity.OfficeLocation) error
+	RemoveOfficeLocation(ctx context.Context, id string) error
 }
 
 type IRoleUseCase interface {
 	RetrieveRoles(ctx context.Context) ([]entity.Role, error)
+	RetrievePermissions(ctx context.Context) map[entity.Permission]string
+	RegisterRole(ctx context.Context, payload entity.Role) error
+	UpdateRole(ctx context.Context, actor entity.Employee, id string, payload entity.Role) error
+	RemoveRole(ctx context.Context, id string) error
 }
 
 type IJobUseCase interface {
@@ -57,6 +87,8 @@ type IAttendanceUseCase interface {
 	RetrieveMyOvertimeSubmissions(ctx context.Context, employee entity.Employee, q vo.MyOvertimeSubmissionsQuery) ([]entity.Overtime, vo.PaginationDTOResponse, error)
 	RetrieveOvertimeSubmissionsHistoryForManager(ctx context.Context, manager entity.Employee, q vo.LeaveProposalHistoryQuery) ([]entity.Overtime, vo.PaginationDTOResponse, error)
 	RetrieveOvertimeSubmissionsHistoryForHr(ctx context.Context, q vo.LeaveProposalHistoryQuery) ([]entity.Overtime, vo.PaginationDTOResponse, error)
+	SeeIncomingOvertimeSubmissionsForHr(ctx context.Context, q vo.IncomingOvertimeSubmissionsQuery) ([]entity.Overtime, vo.PaginationDTOResponse, error)
+	TakeActionOnOvertimeSubmissionByHr(ctx context.Context, hr entity.Employee, action vo.OvertimeSubmissionAction) error
 
 	RetrieveMyStaffsAttendanceHistory(ctx context.Context, manager entity.Employee, q vo.HistoryAttendancesQuery) ([]entity.Attendance, vo.PaginationDTOResponse, error)
 	RetrieveEmployeesAttendanceHistory(ctx context.Context, q vo.HistoryAttendancesQuery) ([]entity.Attendance, vo.PaginationDTOResponse, error)
@@ -74,21 +106,77 @@ type ILeaveUseCase interface {
 	RetrieveMyQuotas(ctx context.Context, employee entity.Employee) (entity.EmployeeLeavesQuota, error)
 	RequestLeave(ctx context.Context, employee entity.Employee, leave entity.Leave) (entity.LeaveReport, error)
 	ApplyForLeave(ctx context.Context, employee entity.Employee, decision vo.UserLeaveDecision, attachment multipart.File) error
+	AmendLeave(ctx context.Context, employee entity.Employee, id string, decision vo.UserLeaveDecision) error
+	CancelLeave(ctx context.Context, employee entity.Employee, id string, reason string) error
 	RetrieveLeaveRequest(ctx context.Context, id string) (entity.Leave, error)
 
 	SeeIncomingLeaveProposalsForManager(ctx context.Context, manager entity.Employee, q vo.IncomingLeaveProposals) ([]entity.Leave, vo.PaginationDTOResponse, error)
 	TakeActionOnLeaveProposalForManager(ctx context.Context, manager entity.Employee, action vo.LeaveAction) error
 	RetrieveLeaveProposalsHistoryForManager(ctx context.Context, manager entity.Employee, q vo.LeaveProposalHistoryQuery) ([]entity.Leave, vo.PaginationDTOResponse, error)
+	SeeIncomingLeaveCancellationsForManager(ctx context.Context, manager entity.Employee, q vo.IncomingLeaveProposals) ([]entity.Leave, vo.PaginationDTOResponse, error)
+	TakeActionOnLeaveCancellationForManager(ctx context.Context, manager entity.Employee, action vo.LeaveCancellationAction) error
 
 	SeeIncomingLeaveProposalsForHr(ctx context.Context, q vo.IncomingLeaveProposals) ([]entity.Leave, vo.PaginationDTOResponse, error)
 	TakeActionOnLeaveProposalForHr(ctx context.Context, hr entity.Employee, action vo.LeaveAction) error
 	RetrieveLeaveProposalsHistoryForHr(ctx context.Context, q vo.LeaveProposalHistoryQuery) ([]entity.Leave, vo.PaginationDTOResponse, error)
+	SeeIncomingLeaveCancellationsForHr(ctx context.Context, q vo.IncomingLeaveProposals) ([]entity.Leave, vo.PaginationDTOResponse, error)
+	TakeActionOnLeaveCancellationForHr(ctx context.Context, hr entity.Employee, action vo.LeaveCancellationAction) error
 
 	RetrieveWhosTakingLeave(ctx context.Context, q vo.CommonQuery) (vo.WhosTakingLeaveList, error)
 	RetrieveWhosTakingLeaveMobile(ctx context.Context, q vo.CommonQuery) ([]entity.Leave, vo.PaginationDTOResponse, error)
 
 	RetrieveMyEmployeesLeaveHistory(ctx context.Context, manager entity.Employee, employeeId string, q vo.LeaveProposalHistoryQuery) ([]entity.Leave, vo.PaginationDTOResponse, error)
 	RetrieveAnEmployeeLeaves(ctx context.Context, employeeId string, q vo.LeaveProposalHistoryQuery) ([]entity.Leave, vo.PaginationDTOResponse, error)
+
+	RetrieveLeavePolicies(ctx context.Context) ([]entity.LeavePolicy, error)
+	RegisterLeavePolicy(ctx context.Context, payload entity.LeavePolicy) error
+	UpdateLeavePolicy(ctx context.Context, id string, payload entity.LeavePolicy) error
+	RemoveLeavePolicy(ctx context.Context, id string) error
+
+	RetrieveMyLeaveQuotaLedger(ctx context.Context, employee entity.Employee, q vo.LeaveQuotaLedgerQuery) ([]entity.LeaveQuotaLedger, vo.PaginationDTOResponse, error)
+	RetrieveAnEmployeeLeaveQuotaLedger(ctx context.Context, employeeId string, q vo.LeaveQuotaLedgerQuery) ([]entity.LeaveQuotaLedger, vo.PaginationDTOResponse, error)
+	AdjustLeaveQuota(ctx context.Context, hr entity.Employee, employeeId string, leaveType entity.LeaveType, amount float64, reason string) error
+}
+
+type IApprovalUseCase interface {
+	RetrieveApprovalChains(ctx context.Context) (entity.ApprovalChains, error)
+	RegisterApprovalChain(ctx context.Context, payload entity.ApprovalChain) error
+	UpdateApprovalChain(ctx context.Context, id string, payload entity.ApprovalChain) error
+	RemoveApprovalChain(ctx context.Context, id string) error
+
+	RetrieveApprovalDelegations(ctx context.Context) ([]entity.ApprovalDelegation, error)
+	RevokeApprovalDelegation(ctx context.Context, id string) error
+	RetrieveMyApprovalDelegations(ctx context.Context, manager entity.Employee) ([]entity.ApprovalDelegation, error)
+	RevokeMyApprovalDelegation(ctx context.Context, manager entity.Employee, id string) error
+	DelegateApproval(ctx context.Context, actor entity.Employee, payload entity.ApprovalDelegation) error
+}
+
+type ILeaveAccrualUseCase interface {
+	AccrueLeaveQuotas(ctx context.Context, at time.Time) (vo.LeaveAccrualReport, error)
+}
+
+type ISchedulerUseCase interface {
+	RegisterJobs()
+	RetrieveScheduledJobs(ctx context.Context) ([]vo.ScheduledJobStatus, error)
+	RetrieveJobRuns(ctx context.Context, name string, q vo.CommonQuery) ([]entity.SchedulerJobRun, vo.PaginationDTOResponse, error)
+	TriggerJob(ctx context.Context, hr entity.Employee, name string) error
+}
+
+type IScheduleUseCase interface {
+	RetrieveMySchedule(ctx context.Context, employee entity.Employee) (entity.WorkSchedule, error)
+
+	RetrieveWorkSchedules(ctx context.Context) ([]entity.WorkSchedule, error)
+	RetrieveWorkSchedule(ctx context.Context, id string) (entity.WorkSchedule, error)
+	RegisterWorkSchedule(ctx context.Context, payload entity.WorkSchedule) error
+	UpdateWorkSchedule(ctx context.Context, id string, payload entity.WorkSchedule) error
+	RemoveWorkSchedule(ctx context.Context, id string) error
+	AssignWorkSchedule(ctx context.Context, scheduleId *string, employeeIds []string, jobIds []string) error
+
+	RetrieveHolidays(ctx context.Context, year int) ([]entity.Holiday, error)
+	RegisterHoliday(ctx context.Context, payload entity.Holiday) error
+	UpdateHoliday(ctx context.Context, id string, payload entity.Holiday) error
+	RemoveHoliday(ctx context.Context, id string) error
+	ImportHolidays(ctx context.Context, payload []entity.Holiday) (int, error)
 }
 
 type IAnalyticsUseCase interface {
@@ -97,8 +185,27 @@ type IAnalyticsUseCase interface {
 }
 
 type IChatUseCase interface {
-	OpenChat(ctx context.Context, user entity.Employee, room entity.Room) (entity.Room, []entity.Chat, error)
-	SendMessage(ctx context.Context, userId, roomId, message string) (entity.Chat, error)
-	ListenMessage(ctx context.Context, userId, roomId string, channel chan entity.Chat) error
+	OpenChat(ctx context.Context, user entity.Employee, room entity.Room) (entity.Room, vo.ChatPage, error)
+	RetrieveChats(ctx context.Context, user entity.Employee, roomId string, q vo.ChatPageQuery) (entity.Room, vo.ChatPage, error)
+	RetrieveChatContext(ctx context.Context, user entity.Employee, roomId, chatId string, q vo.ChatPageQuery) (entity.Room, vo.ChatPage, error)
+	SearchChats(ctx context.Context, user entity.Employee, q vo.ChatSearchQuery) (vo.ChatPage, error)
+	JoinRoom(ctx context.Context, user entity.Employee, roomId string) (entity.Room, error)
+	ListRooms(ctx context.Context, user entity.Employee) ([]vo.RoomSummary, error)
+	SyncTeamRooms(ctx context.Context, managerIds ...string) error
+	SyncAllTeamRooms(ctx context.Context) (int, error)
+	CreateGroupRoom(ctx context.Context, user entity.Employee, room entity.Room, memberIds []string) (entity.Room, error)
+	UpdateRoom(ctx context.Context, user entity.Employee, roomId string, payload entity.Room) (entity.Room, error)
+	AddRoomMembers(ctx context.Context, user entity.Employee, roomId string, memberIds []string) error
+	RemoveRoomMember(ctx context.Context, user entity.Employee, roomId, memberId string) error
+	SetRoomAdmin(ctx context.Context, user entity.Employee, roomId, memberId string, admin bool) error
+	SendMessage(ctx context.Context, user entity.Employee, roomId, message string) (entity.Chat, error)
+	ListenMessage(ctx context.Context, user entity.Employee, roomId, lastSeenId string, channel chan entity.Chat) error
+	MarkRead(ctx context.Context, user entity.Employee, roomId, chatId string) error
+	MarkRoomRead(ctx context.Context, user entity.Employee, room entity.Room, chatId string) error
+	SetTyping(ctx context.Context, user entity.Employee, room entity.Room, typing bool) error
+	RetrieveUnreadCount(ctx context.Context, user entity.Employee, roomId string) (int64, error)
+	RetrieveOnlineParticipants(ctx context.Context, user entity.Employee, roomId string) ([]string, error)
+	ListenSignals(ctx context.Context, user entity.Employee, roomId string, channel chan vo.ChatSignal) error
+	StayOnline(ctx context.Context, user entity.Employee) error
 	DetachListener(ctx context.Context, userId, roomId string) error
 }
@@ -0,0 +1,95 @@
+package usecase
+
+// This usecase is used for accruing the leave quotas of employees
+// based on their leave types. It is run as a job, hence it has no
+// actor.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sinarlog.com/internal/app/repo"
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/entity/vo"
+	"sinarlog.com/internal/utils"
+)
+
+type leaveAccrualUseCase struct {
+	leaveRepo repo.ILeaveRepo
+}
+
+func NewLeaveAccrualUseCase(leaveRepo repo.ILeaveRepo) *leaveAccrualUseCase {
+	return &leaveAccrualUseCase{leaveRepo: leaveRepo}
+}
+
+// AccrueLeaveQuotas brings the leave quota of each employee who has
+// not resigned up to date at the given time. It accrues, carries
+// over, resets and expires the quotas according to their leave
+// types and records each change in the ledger. Running it again at
+// the same time changes nothing, so it is safe to be retried.
+func (uc *leaveAccrualUseCase) AccrueLeaveQuotas(ctx context.Context, at time.Time) (vo.LeaveAccrualReport, error) {
+	report := vo.LeaveAccrualReport{At: at.In(utils.CURRENT_LOC)}
+
+	policies, err := uc.leaveRepo.GetLeavePolicies(ctx)
+	if err != nil {
+		return report, NewRepositoryError("Leave Type", err)
+	}
+
+	employees, err := uc.leaveRepo.GetEmployeesForLeaveAccrual(ctx)
+	if err != nil {
+		return report, NewRepositoryError("Employee", err)
+	}
+
+	var errs error
+	for _, employee := range employees {
+		for _, policy := range policies {
+			if !policy.HasQuota {
+				continue
+			}
+
+			quota, ok := findLeaveQuota(employee.EmployeeLeaveQuotas, policy.Code)
+			if !ok {
+				if !policy.Accrues() {
+					continue
+				}
+				// An accruing leave type is accrued from the join date
+				quota = entity.EmployeeLeaveQuota{EmployeeID: employee.Id, Type: policy.Code, Year: report.At.Year()}
+			}
+
+			// The quota read is only to skip the ones up to date, it is
+			// accrued again once locked
+			read := quota
+			if entries := read.Accrue(policy, employee.JoinDate, report.At); len(entries) == 0 && read.Year == quota.Year && quota.Id != "" {
+				continue
+			}
+
+			entries, err := uc.leaveRepo.SaveLeaveQuotaAccrual(ctx, quota, func(quota *entity.EmployeeLeaveQuota) []entity.LeaveQuotaLedger {
+				return quota.Accrue(policy, employee.JoinDate, report.At)
+			})
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("failed to accrue %s leave of employee %s: %w", policy.Code, employee.Id, err))
+				continue
+			}
+			report.Quotas++
+			report.Entries += len(entries)
+		}
+	}
+
+	if errs != nil {
+		return report, NewRepositoryError("Leave", errs)
+	}
+
+	return report, nil
+}
+
+func findLeaveQuota(quotas []entity.EmployeeLeaveQuota, leaveType entity.LeaveType) (entity.EmployeeLeaveQuota, bool) {
+	for _, v := range quotas {
+		if v.Type == leaveType {
+			return v, true
+		}
+	}
+
+	return entity.EmployeeLeaveQuota{}, false
+}
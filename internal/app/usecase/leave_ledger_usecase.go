@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/entity/vo"
+)
+
+/*
+*********************************
+ACTOR: ALL
+*********************************
+*/
+
+// RetrieveMyLeaveQuotaLedger retrieves the history of the changes
+// of the current user's leave quotas.
+func (uc *leaveUseCase) RetrieveMyLeaveQuotaLedger(ctx context.Context, employee entity.Employee, q vo.LeaveQuotaLedgerQuery) ([]entity.LeaveQuotaLedger, vo.PaginationDTOResponse, error) {
+	entries, page, err := uc.leaveRepo.GetLeaveQuotaLedger(ctx, employee.Id, q)
+	if err != nil {
+		return nil, page, NewRepositoryError("Leave Ledger", err)
+	}
+
+	return entries, page, nil
+}
+
+/*
+*********************************
+ACTOR: HR
+*********************************
+*/
+
+// RetrieveAnEmployeeLeaveQuotaLedger retrieves the history of the
+// changes of an employee's leave quotas.
+func (uc *leaveUseCase) RetrieveAnEmployeeLeaveQuotaLedger(ctx context.Context, employeeId string, q vo.LeaveQuotaLedgerQuery) ([]entity.LeaveQuotaLedger, vo.PaginationDTOResponse, error) {
+	if _, err := uc.emplRepo.GetEmployeeById(ctx, employeeId); err != nil {
+		return nil, vo.PaginationDTOResponse{}, NewNotFoundError("Employee", err)
+	}
+
+	entries, page, err := uc.leaveRepo.GetLeaveQuotaLedger(ctx, employeeId, q)
+	if err != nil {
+		return nil, page, NewRepositoryError("Leave Ledger", err)
+	}
+
+	return entries, page, nil
+}
+
+// AdjustLeaveQuota adds or takes an amount of an employee's leave
+// quota manually. The reason of the adjustment is mandatory and is
+// recorded in the ledger together with the HR making it.
+func (uc *leaveUseCase) AdjustLeaveQuota(ctx context.Context, hr entity.Employee, employeeId string, leaveType entity.LeaveType, amount float64, reason string) error {
+	entry := entity.LeaveQuotaLedger{
+		EmployeeID: employeeId,
+		Type:       leaveType,
+		Source:     entity.LEDGER_ADJUSTMENT,
+		Amount:     amount,
+		Note:       strings.TrimSpace(reason),
+		ActorID:    &hr.Id,
+	}
+	if err := entry.ValidateAdjustment(); err != nil {
+		return NewDomainError("Leave Ledger", err)
+	}
+
+	employee, err := uc.emplRepo.GetEmployeeById(ctx, employeeId)
+	if err != nil {
+		return NewNotFoundError("Employee", err)
+	}
+	if employee.ResignedAt != nil {
+		return NewDomainError("Leave Ledger", fmt.Errorf("the leave quota of a resigned employee cannot be adjusted"))
+	}
+
+	policy, err := uc.leaveRepo.GetLeavePolicyByCode(ctx, leaveType)
+	if err != nil {
+		return NewNotFoundError("Leave Type", fmt.Errorf("leave type %s is not available", strings.ToLower(leaveType.String())))
+	}
+	if !policy.HasQuota {
+		return NewDomainError("Leave Ledger", fmt.Errorf("%s leave has no quota to adjust", strings.ToLower(policy.Name)))
+	}
+
+	if err := uc.leaveRepo.AdjustLeaveQuota(ctx, entry); err != nil {
+		switch {
+		case errors.Is(err, entity.ErrLeaveQuotaNotFound):
+			return NewNotFoundError("Leave Quota", err)
+		case errors.Is(err, entity.ErrNegativeLeaveQuota):
+			return NewDomainError("Leave Ledger", err)
+		}
+		return NewRepositoryError("Leave Ledger", err)
+	}
+
+	return nil
+}
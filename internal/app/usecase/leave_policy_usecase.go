@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"sinarlog.com/internal/entity"
+)
+
+/*
+*********************************
+ACTOR: ALL
+*********************************
+*/
+
+// RetrieveLeavePolicies retrieves the leave types an employee
+// may request together with their rules.
+func (uc *leaveUseCase) RetrieveLeavePolicies(ctx context.Context) ([]entity.LeavePolicy, error) {
+	policies, err := uc.leaveRepo.GetLeavePolicies(ctx)
+	if err != nil {
+		return nil, NewRepositoryError("Leave Type", err)
+	}
+
+	return policies, nil
+}
+
+/*
+*********************************
+ACTOR: HR
+*********************************
+*/
+
+// RegisterLeavePolicy defines a new leave type. Each employee is
+// given the default quota of the leave type right away.
+func (uc *leaveUseCase) RegisterLeavePolicy(ctx context.Context, payload entity.LeavePolicy) error {
+	if err := uc.validateLeavePolicy(ctx, payload); err != nil {
+		return err
+	}
+
+	if _, err := uc.leaveRepo.GetLeavePolicyByCode(ctx, payload.Code); err == nil {
+		return NewConflictError("Leave Type", fmt.Errorf("leave type %s already exists", payload.Code))
+	}
+
+	if err := uc.leaveRepo.CreateLeavePolicy(ctx, payload); err != nil {
+		return NewRepositoryError("Leave Type", err)
+	}
+
+	return nil
+}
+
+// UpdateLeavePolicy changes the rules of a leave type. The code of
+// a leave type cannot be changed since leaves and quotas refer to it.
+func (uc *leaveUseCase) UpdateLeavePolicy(ctx context.Context, id string, payload entity.LeavePolicy) error {
+	policy, err := uc.leaveRepo.GetLeavePolicyById(ctx, id)
+	if err != nil {
+		return NewNotFoundError("Leave Type", err)
+	}
+
+	payload.Id = policy.Id
+	payload.Code = policy.Code
+	payload.CreatedAt = policy.CreatedAt
+	if err := uc.validateLeavePolicy(ctx, payload); err != nil {
+		return err
+	}
+
+	if err := uc.leaveRepo.UpdateLeavePolicy(ctx, payload); err != nil {
+		return NewRepositoryError("Leave Type", err)
+	}
+
+	return nil
+}
+
+// RemoveLeavePolicy deletes a leave type so it can no longer be
+// requested. The pending leaves of the leave type are still decided
+// since it is only soft deleted. The built-in leave types cannot be
+// deleted.
+func (uc *leaveUseCase) RemoveLeavePolicy(ctx context.Context, id string) error {
+	policy, err := uc.leaveRepo.GetLeavePolicyById(ctx, id)
+	if err != nil {
+		return NewNotFoundError("Leave Type", err)
+	}
+
+	switch policy.Code {
+	case entity.ANNUAL, entity.UNPAID, entity.SICK, entity.MARRIAGE:
+		return NewDomainError("Leave Type", fmt.Errorf("built-in leave type %s cannot be deleted", policy.Code))
+	}
+
+	if err := uc.leaveRepo.DeleteLeavePolicy(ctx, id); err != nil {
+		return NewRepositoryError("Leave Type", err)
+	}
+
+	return nil
+}
+
+// validateLeavePolicy validates the leave type and makes sure each
+// of its overflow targets exists.
+func (uc *leaveUseCase) validateLeavePolicy(ctx context.Context, policy entity.LeavePolicy) error {
+	if err := policy.Validate(); err != nil {
+		return NewDomainError("Leave Type", err)
+	}
+
+	for _, code := range policy.OverflowTargets {
+		if _, err := uc.leaveRepo.GetLeavePolicyByCode(ctx, code); err != nil {
+			return NewDomainError("Leave Type", fmt.Errorf("overflow target %s does not exist", code))
+		}
+	}
+
+	return nil
+}
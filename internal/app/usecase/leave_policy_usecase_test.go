@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"sinarlog.com/internal/app/repo"
+	"sinarlog.com/internal/entity"
+)
+
+// fakeLeaveRepo keeps the leave policies, the leaves and the quotas
+// in memory. Calling a method it does not fake panics.
+type fakeLeaveRepo struct {
+	repo.ILeaveRepo
+	policies []entity.LeavePolicy
+	deleted  []string
+
+	leaves    map[string]entity.Leave
+	quotas    entity.EmployeeLeavesQuota
+	amended   []entity.Leave
+	cancelled []entity.Leave
+	processed []entity.Leave
+	// The approvers whose incoming leaves were queried
+	approverIds []string
+}
+
+func (r *fakeLeaveRepo) GetLeavePolicyById(ctx context.Context, id string) (entity.LeavePolicy, error) {
+	for _, v := range r.policies {
+		if v.Id == id {
+			return v, nil
+		}
+	}
+	return entity.LeavePolicy{}, fmt.Errorf("record not found")
+}
+
+func (r *fakeLeaveRepo) GetLeavePolicyByCode(ctx context.Context, code entity.LeaveType) (entity.LeavePolicy, error) {
+	for _, v := range r.policies {
+		if v.Code == code {
+			return v, nil
+		}
+	}
+	return entity.LeavePolicy{}, fmt.Errorf("record not found")
+}
+
+func (r *fakeLeaveRepo) DeleteLeavePolicy(ctx context.Context, id string) error {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+func leavePolicy(id string, code entity.LeaveType) entity.LeavePolicy {
+	return entity.LeavePolicy{BaseModelId: entity.BaseModelId{Id: id}, Code: code, Name: string(code)}
+}
+
+func TestRemovingLeavePolicies(t *testing.T) {
+	testCases := []struct {
+		name    string
+		id      string
+		code    int
+		deleted bool
+	}{
+		{"A custom leave type", "study", 0, true},
+		{"A built-in leave type", "annual", 422, false},
+		{"A missing leave type", "missing", 404, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			leaveRepo := &fakeLeaveRepo{policies: []entity.LeavePolicy{
+				leavePolicy("annual", entity.ANNUAL),
+				leavePolicy("study", "STUDY"),
+			}}
+			uc := NewLeaveUseCase(leaveRepo, nil, nil, nil, nil, nil, nil, nil)
+
+			if err := uc.RemoveLeavePolicy(context.Background(), tc.id); errorCode(err) != tc.code {
+				t.Fatalf("expected code %d, got %v", tc.code, err)
+			}
+			if deleted := len(leaveRepo.deleted) == 1; deleted != tc.deleted {
+				t.Fatalf("expected deleted to be %v, got %v", tc.deleted, leaveRepo.deleted)
+			}
+		})
+	}
+}
+
+func TestRegisteringLeavePolicies(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy entity.LeavePolicy
+		code   int
+	}{
+		{"An existing code", entity.LeavePolicy{Code: "STUDY", Name: "Study"}, 409},
+		{"A missing overflow target", entity.LeavePolicy{Code: "RELIGIOUS", Name: "Religious", OverflowTargets: []entity.LeaveType{"PILGRIMAGE"}}, 422},
+		{"An invalid leave type", entity.LeavePolicy{Code: "religious", Name: "Religious"}, 422},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			leaveRepo := &fakeLeaveRepo{policies: []entity.LeavePolicy{leavePolicy("study", "STUDY")}}
+			uc := NewLeaveUseCase(leaveRepo, nil, nil, nil, nil, nil, nil, nil)
+
+			if err := uc.RegisterLeavePolicy(context.Background(), tc.policy); errorCode(err) != tc.code {
+				t.Fatalf("expected code %d, got %v", tc.code, err)
+			}
+		})
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
 	"mime/multipart"
+	"strconv"
 	"strings"
 	"time"
 	"unicode"
@@ -20,8 +22,10 @@ import (
 
 type leaveUseCase struct {
 	leaveRepo    repo.ILeaveRepo
+	approvalRepo repo.IApprovalRepo
 	emplRepo     repo.IEmployeeRepo
 	configRepo   repo.IConfigRepo
+	scheduleRepo repo.IScheduleRepo
 	mailService  service.IMailerService
 	notifService service.INotifService
 	bktService   service.IBucketService
@@ -29,16 +33,20 @@ type leaveUseCase struct {
 
 func NewLeaveUseCase(
 	leaveRepo repo.ILeaveRepo,
+	approvalRepo repo.IApprovalRepo,
 	emplRepo repo.IEmployeeRepo,
 	configRepo repo.IConfigRepo,
+	scheduleRepo repo.IScheduleRepo,
 	mailService service.IMailerService,
 	notifService service.INotifService,
 	bktService service.IBucketService,
 ) *leaveUseCase {
 	return &leaveUseCase{
 		leaveRepo:    leaveRepo,
+		approvalRepo: approvalRepo,
 		emplRepo:     emplRepo,
 		configRepo:   configRepo,
+		scheduleRepo: scheduleRepo,
 		mailService:  mailService,
 		notifService: notifService,
 		bktService:   bktService,
@@ -79,9 +87,9 @@ func (uc *leaveUseCase) RetrieveLeaveRequest(ctx context.Context, id string) (en
 // and be changed for RetrieveMyLeaveAggregate to show
 // leave aggregates in the dashboard.
 func (uc *leaveUseCase) RetrieveMyQuotas(ctx context.Context, employee entity.Employee) (entity.EmployeeLeavesQuota, error) {
-	quota, err := uc.emplRepo.GetLeaveQuotaByEmployeeId(ctx, employee.Id)
+	quota, err := uc.leaveRepo.GetLeaveQuotasByEmployeeId(ctx, employee.Id)
 	if err != nil {
-		return quota, NewRepositoryError("Employee", err)
+		return quota, NewRepositoryError("Leave", err)
 	}
 
 	return quota, nil
@@ -93,29 +101,57 @@ func (uc *leaveUseCase) RetrieveMyQuotas(ctx context.Context, employee entity.Em
 // It then returns an error or a report regarding the leave
 // request.
 func (uc *leaveUseCase) RequestLeave(ctx context.Context, employee entity.Employee, leave entity.Leave) (entity.LeaveReport, error) {
+	report, _, err := uc.requestLeave(ctx, employee, leave, nil)
+	return report, err
+}
+
+// requestLeave does the checks of RequestLeave and returns the
+// report together with the employee's work schedule. When a leave
+// is amended, the amended leave is excluded from the overlap check
+// and its quota is counted as returned back to the employee.
+func (uc *leaveUseCase) requestLeave(ctx context.Context, employee entity.Employee, leave entity.Leave, amended *entity.Leave) (entity.LeaveReport, entity.WorkSchedule, error) {
 	leave.EmployeeID = employee.Id
 
+	// Query the employee's work schedule
+	config, err := uc.configRepo.GetConfiguration(ctx)
+	if err != nil {
+		return entity.LeaveReport{}, entity.WorkSchedule{}, NewRepositoryError("Config", err)
+	}
+	schedule, err := getEffectiveWorkSchedule(ctx, uc.scheduleRepo, config, employee.Id)
+	if err != nil {
+		return entity.LeaveReport{}, schedule, err
+	}
+
+	// Validate by leave domain
+	if err := leave.Validate(schedule); err != nil {
+		return entity.LeaveReport{}, schedule, NewDomainError("Leave", err)
+	}
+	leave.ApplySchedule(schedule)
+
 	// Check availability
-	isAvailable, err := uc.leaveRepo.CheckDateAvailability(ctx, leave)
+	var excludeIds []string
+	if amended != nil {
+		excludeIds = append(excludeIds, amended.Id)
+		for _, v := range amended.Childs {
+			excludeIds = append(excludeIds, v.Id)
+		}
+	}
+	isAvailable, err := uc.leaveRepo.CheckDateAvailability(ctx, leave, excludeIds...)
 	if err == nil {
 		if !isAvailable {
-			return entity.LeaveReport{}, NewDomainError("Leave", fmt.Errorf("there has been an overlap of dates in your leave requests"))
+			return entity.LeaveReport{}, schedule, NewDomainError("Leave", fmt.Errorf("there has been an overlap of dates in your leave requests"))
 		}
 	} else {
-		return entity.LeaveReport{}, NewRepositoryError("Leave", err)
-	}
-
-	// Validate by leave domain
-	if err := leave.Validate(); err != nil {
-		return entity.LeaveReport{}, NewDomainError("Leave", err)
+		return entity.LeaveReport{}, schedule, NewRepositoryError("Leave", err)
 	}
 
 	// Validate employee domain
 	if err := employee.ValidateLeave(); err != nil {
-		return entity.LeaveReport{}, NewDomainError("Employee", err)
+		return entity.LeaveReport{}, schedule, NewDomainError("Employee", err)
 	}
 
-	return uc.createLeaveReport(ctx, employee, leave)
+	report, err := uc.createLeaveReport(ctx, employee, leave, schedule, amended)
+	return report, schedule, err
 }
 
 // ApplyForLeave calls RequestLeave to do the neccessary
@@ -125,23 +161,151 @@ func (uc *leaveUseCase) RequestLeave(ctx context.Context, employee entity.Employ
 // to the leave request, and sends email to the manager (if the
 // requestee is not a manager).
 func (uc *leaveUseCase) ApplyForLeave(ctx context.Context, employee entity.Employee, decision vo.UserLeaveDecision, attachment multipart.File) error {
-	report, err := uc.RequestLeave(ctx, employee, decision.Parent)
+	report, schedule, err := uc.requestLeave(ctx, employee, decision.Parent, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.checkLeaveAttachment(ctx, report.RequestType, attachment != nil); err != nil {
+		return err
+	}
+
+	parent, err := uc.createLeaveFromDecision(employee, decision, report, schedule)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.applyApprovalChain(ctx, employee, &parent); err != nil {
+		return err
+	}
+
+	// If an attachment is provided, upload to the bucket
+	if attachment != nil {
+		url, err := uc.bktService.CreateLeaveAttachment(ctx, parent.Id, attachment)
+		if err != nil {
+			return NewServiceError("Bucket", err)
+		}
+		parent.AttachmentUrl = url
+	}
+
+	if err := uc.leaveRepo.CreateLeave(ctx, parent); err != nil {
+		uc.bktService.DeleteLeaveAttachment(ctx, parent.Id)
+		return NewRepositoryError("Leave", err)
+	}
+
+	return uc.notifyApproverOfLeaveRequest(ctx, employee, parent)
+}
+
+// AmendLeave changes a pending leave request of the employee.
+// The leave is cancelled and replaced by a new leave request
+// made from the decision, hence it goes through the manager
+// and HR approval from the start.
+func (uc *leaveUseCase) AmendLeave(ctx context.Context, employee entity.Employee, id string, decision vo.UserLeaveDecision) error {
+	leave, err := uc.retrieveMyLeave(ctx, employee, id)
+	if err != nil {
+		return err
+	}
+
+	if !leave.IsPending() {
+		return NewDomainError("Leave", fmt.Errorf("only a pending leave request can be amended. Please request a cancellation instead"))
+	}
+
+	report, schedule, err := uc.requestLeave(ctx, employee, decision.Parent, &leave)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.checkLeaveAttachment(ctx, report.RequestType, leave.AttachmentUrl != ""); err != nil {
+		return err
+	}
+
+	parent, err := uc.createLeaveFromDecision(employee, decision, report, schedule)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.applyApprovalChain(ctx, employee, &parent); err != nil {
+		return err
+	}
+	parent.AttachmentUrl = leave.AttachmentUrl
+
+	if err := uc.leaveRepo.AmendLeave(ctx, leave, parent); err != nil {
+		return NewRepositoryError("Leave", err)
+	}
+
+	return uc.notifyApproverOfLeaveRequest(ctx, employee, parent)
+}
+
+// CancelLeave cancels a leave request of the employee. A pending
+// leave is withdrawn right away, meanwhile an approved leave is
+// cancelled only after its cancellation is approved by the manager
+// and HR. The quota of a cancelled leave is returned back.
+func (uc *leaveUseCase) CancelLeave(ctx context.Context, employee entity.Employee, id string, reason string) error {
+	leave, err := uc.retrieveMyLeave(ctx, employee, id)
 	if err != nil {
 		return err
 	}
 
+	now := time.Now().In(utils.CURRENT_LOC)
+	leave.CancellationReason = reason
+
+	switch {
+	case leave.IsPending():
+		leave.CancellationRequestedAt = &now
+		leave.CancelledAt = &now
+	case leave.IsApproved():
+		if leave.HasPendingCancellation() {
+			return NewDomainError("Leave", fmt.Errorf("the cancellation of this leave is still waiting for approval"))
+		}
+		if err := leave.ValidateCancellation(); err != nil {
+			return NewDomainError("Leave", err)
+		}
+
+		leave.CancellationRequestedAt = &now
+		leave.CancellationApprovedByManager = nil
+		leave.CancellationActionByManagerAt = nil
+		leave.CancellationApprovedByHr = nil
+		leave.CancellationActionByHrAt = nil
+		leave.CancellationRejectionReason = ""
+
+		// A leave of a manager goes to HR right away
+		if leave.ManagerID == nil {
+			truee := true
+			leave.CancellationApprovedByManager = &truee
+			leave.CancellationActionByManagerAt = &now
+		}
+	default:
+		return NewDomainError("Leave", fmt.Errorf("unable to cancel a leave that has been rejected or closed"))
+	}
+
+	if err := uc.leaveRepo.SaveLeaveCancellation(ctx, employee.Id, leave); err != nil {
+		return NewRepositoryError("Leave", err)
+	}
+
+	return nil
+}
+
+// createLeaveFromDecision creates the parent leave together with
+// its overflows from the requestee's decision on the report.
+func (uc *leaveUseCase) createLeaveFromDecision(employee entity.Employee, decision vo.UserLeaveDecision, report entity.LeaveReport, schedule entity.WorkSchedule) (entity.Leave, error) {
+	// Place the requested leave on the schedule
+	requested := decision.Parent
+	requested.ApplySchedule(schedule)
+
 	// Start creating leave domain
 	var parentsEndDate time.Time
-	if report.IsLeaveLeakage {
-		parentsEndDate = utils.AddNumOfWorkingDays(time.Date(
+	if requested.IsPartialDay() {
+		parentsEndDate = requested.To
+	} else if report.IsLeaveLeakage {
+		parentsEndDate = schedule.AddWorkingDays(time.Date(
 			decision.Parent.From.Year(),
 			decision.Parent.From.Month(),
 			decision.Parent.From.Day(),
 			23, 59, 59, 0,
 			utils.CURRENT_LOC,
-		), report.RemainingQuotaForRequestedType-1)
+		), int(report.RemainingQuotaForRequestedType)-1)
 	} else {
-		parentsEndDate = utils.GetWorkingDay(time.Date(
+		parentsEndDate = schedule.NextWorkingDay(time.Date(
 			decision.Parent.To.Year(),
 			decision.Parent.To.Month(),
 			decision.Parent.To.Day(),
@@ -155,26 +319,24 @@ func (uc *leaveUseCase) ApplyForLeave(ctx context.Context, employee entity.Emplo
 		BaseModelId: entity.BaseModelId{Id: uuid.NewString()},
 		EmployeeID:  employee.Id,
 		Employee:    employee,
-		From:        decision.Parent.From,
+		From:        requested.From,
 		To:          parentsEndDate,
 		Type:        decision.Parent.Type,
 		Reason:      decision.Parent.Reason,
+		Granularity: requested.Granularity,
 	}
-
-	// Checks whether the requestee is a manager
-	if employee.ManagerID == nil {
-		now := time.Now().In(utils.CURRENT_LOC)
-		truee := true
-		parent.ApprovedByManager = &truee
-		parent.ActionByManagerAt = &now
+	if requested.IsPartialDay() {
+		parent.WorkingDays = requested.WorkingDays
 	} else {
-		parent.ManagerID = employee.ManagerID
+		parent.WorkingDays = float64(schedule.CountWorkingDays(parent.From, parent.To))
 	}
 
+	parent.ManagerID = employee.ManagerID
+
 	// Checks whether there has been a leave leakage
 	if report.IsLeaveLeakage {
 		if err := decision.ValidateExcessSumOfDays(report); err != nil {
-			return NewDomainError("Leave", err)
+			return parent, NewDomainError("Leave", err)
 		}
 
 		// This will be used for validating the leave excess types
@@ -183,7 +345,7 @@ func (uc *leaveUseCase) ApplyForLeave(ctx context.Context, employee entity.Emplo
 			types = append(types, any(v))
 		}
 
-		nextChildsStartDate := utils.GetWorkingDay(time.Date(
+		nextChildsStartDate := schedule.NextWorkingDay(time.Date(
 			parentsEndDate.Year(),
 			parentsEndDate.Month(),
 			parentsEndDate.Day()+1, 0, 0, 0, 0,
@@ -195,64 +357,88 @@ func (uc *leaveUseCase) ApplyForLeave(ctx context.Context, employee entity.Emplo
 			if v.Count != 0 {
 				// Validate leave excess type
 				if err := validation.Validate(&v.Type, validation.Required, validation.In(types...)); err != nil {
-					return NewDomainError("Leave", fmt.Errorf("the excess type of %s is not available in the option", strings.ToLower(v.Type.String())))
+					return parent, NewDomainError("Leave", fmt.Errorf("the excess type of %s is not available in the option", strings.ToLower(v.Type.String())))
 				}
 				// Validate leave excess quota
-				if v.Count > report.AvailableExcessQuotas[i] {
-					return NewDomainError("Leave", fmt.Errorf("the amount of excess for %s exceeded limit", v.Type))
+				if float64(v.Count) > report.AvailableExcessQuotas[i] {
+					return parent, NewDomainError("Leave", fmt.Errorf("the amount of excess for %s exceeded limit", v.Type))
 				}
 
 				parent.Childs = append(parent.Childs, entity.Leave{
-					EmployeeID: parent.EmployeeID,
-					From:       nextChildsStartDate,
-					To:         utils.AddNumOfWorkingDays(time.Date(nextChildsStartDate.Year(), nextChildsStartDate.Month(), nextChildsStartDate.Day(), 23, 59, 59, 0, utils.CURRENT_LOC), v.Count-1),
-					Type:       v.Type,
+					EmployeeID:  parent.EmployeeID,
+					From:        nextChildsStartDate,
+					To:          schedule.AddWorkingDays(time.Date(nextChildsStartDate.Year(), nextChildsStartDate.Month(), nextChildsStartDate.Day(), 23, 59, 59, 0, utils.CURRENT_LOC), v.Count-1),
+					Type:        v.Type,
+					WorkingDays: float64(v.Count),
 					Reason: fmt.Sprintf("This is an extension leave of %s made by %s from %s to %s. This reason is autogenerated.",
 						parent.Type,
 						utils.GetFirstNameFromFullName(employee.FullName),
 						parent.From.In(utils.CURRENT_LOC).Format(time.DateOnly),
 						parent.To.In(utils.CURRENT_LOC).Format(time.DateOnly)),
-					ParentID:          &parent.Id,
-					ManagerID:         parent.ManagerID,
-					ApprovedByManager: parent.ApprovedByManager,
-					ActionByManagerAt: parent.ActionByManagerAt,
+					ParentID:  &parent.Id,
+					ManagerID: parent.ManagerID,
 				})
-				nextChildsStartDate = utils.AddNumOfWorkingDays(time.Date(nextChildsStartDate.Year(), nextChildsStartDate.Month(), nextChildsStartDate.Day(), 0, 0, 0, 0, utils.CURRENT_LOC), v.Count)
+				nextChildsStartDate = schedule.AddWorkingDays(time.Date(nextChildsStartDate.Year(), nextChildsStartDate.Month(), nextChildsStartDate.Day(), 0, 0, 0, 0, utils.CURRENT_LOC), v.Count)
 			}
 		}
 	}
 
-	// If an attachment is provided, upload to the bucket
-	if attachment != nil {
-		url, err := uc.bktService.CreateLeaveAttachment(ctx, parent.Id, attachment)
-		if err != nil {
-			return NewServiceError("Bucket", err)
-		}
-		parent.AttachmentUrl = url
+	return parent, nil
+}
+
+// applyApprovalChain gives the leave the steps of the approval
+// chain applying to it. A leave without any step before HR, such
+// as the one of a manager, goes to HR right away.
+func (uc *leaveUseCase) applyApprovalChain(ctx context.Context, employee entity.Employee, parent *entity.Leave) error {
+	duration := parent.WorkingDays
+	for _, v := range parent.Childs {
+		duration += v.WorkingDays
 	}
 
-	if err := uc.leaveRepo.CreateLeave(ctx, parent); err != nil {
-		uc.bktService.DeleteLeaveAttachment(ctx, parent.Id)
-		return NewRepositoryError("Leave", err)
+	steps, err := buildApprovalSteps(ctx, uc.approvalRepo, uc.emplRepo, employee, entity.LEAVE_APPROVAL, parent.Type, duration)
+	if err != nil {
+		return err
+	}
+	parent.ApprovalSteps = steps
+
+	if !steps.HasReview() {
+		now := time.Now().In(utils.CURRENT_LOC)
+		truee := true
+		parent.ApprovedByManager = &truee
+		parent.ActionByManagerAt = &now
+		for i := range parent.Childs {
+			parent.Childs[i].ApprovedByManager = &truee
+			parent.Childs[i].ActionByManagerAt = &now
+		}
 	}
 
-	// Preparing to send notification only if requestee is a staff
-	if employee.ManagerID != nil {
-		manager, err := uc.emplRepo.GetEmployeeById(ctx, *employee.ManagerID)
+	return nil
+}
+
+// notifyApproverOfLeaveRequest notifies the approver of the step
+// of the approval chain that a leave request is waiting for an
+// action. HR is not notified.
+func (uc *leaveUseCase) notifyApproverOfLeaveRequest(ctx context.Context, employee entity.Employee, parent entity.Leave) error {
+	steps := entity.ApprovalSteps(parent.ApprovalSteps)
+	current, ok := steps.Current()
+
+	// Preparing to send notification only if the step is not HR's
+	if ok && steps[current].ApproverID != nil {
+		approver, err := uc.emplRepo.GetEmployeeById(ctx, *steps[current].ApproverID)
 		if err != nil {
 			return NewErrorWithReport(
 				"Leave",
 				500,
 				ErrUnexpected,
-				fmt.Errorf("unable to retrieve manager's record"),
-				"Your leave attendance has been successfully saved. However, we were unable to send your manager a notification. Please let your manager know directly of your leave request.",
+				fmt.Errorf("unable to retrieve approver's record"),
+				"Your leave attendance has been successfully saved. However, we were unable to send your approver a notification. Please let your manager know directly of your leave request.",
 			)
 		}
 
 		// Sending notification by redis pubsub or email
-		receivers, err := uc.notifService.SendLeaveRequestNotification(ctx, manager, employee)
+		receivers, err := uc.notifService.SendLeaveRequestNotification(ctx, approver, employee)
 		if err != nil || receivers == 0 {
-			go uc.sendLeaveRequestToManager(manager, employee, parent)
+			go uc.sendLeaveRequestToManager(approver, employee, parent)
 		}
 	}
 
@@ -269,16 +455,8 @@ func (uc *leaveUseCase) RetrieveMyEmployeesLeaveHistory(ctx context.Context, man
 		return nil, vo.PaginationDTOResponse{}, NewClientError("Leave", err)
 	}
 
-	// Verify that the employee is indeed under the manager
-	employee, err := uc.emplRepo.GetEmployeeById(ctx, employeeId)
-	if err != nil {
-		return nil, vo.PaginationDTOResponse{}, NewRepositoryError("Leave", err)
-	}
-	if employee.ManagerID == nil {
-		return nil, vo.PaginationDTOResponse{}, NewDomainError("Employee", fmt.Errorf("the employee you're requesting to view is not your staff"))
-	}
-	if *employee.ManagerID != manager.Id {
-		return nil, vo.PaginationDTOResponse{}, NewDomainError("Employee", fmt.Errorf("the employee you're requesting to view is not your staff"))
+	if _, err := authorizeOnEmployee(ctx, uc.emplRepo, manager, entity.PERM_LEAVE_READ, employeeId); err != nil {
+		return nil, vo.PaginationDTOResponse{}, err
 	}
 
 	leaves, page, err := uc.leaveRepo.GetMyLeaveRequestsList(ctx, employeeId, q)
@@ -347,103 +525,135 @@ UTILS
 // an overflow to other leave types. Hence, user is needed
 // to decide whether it wants to overflow to other types
 // or change the duration.
-func (uc *leaveUseCase) createLeaveReport(ctx context.Context, employee entity.Employee, leave entity.Leave) (entity.LeaveReport, error) {
+func (uc *leaveUseCase) createLeaveReport(ctx context.Context, employee entity.Employee, leave entity.Leave, schedule entity.WorkSchedule, amended *entity.Leave) (entity.LeaveReport, error) {
 	var report entity.LeaveReport
 
-	// Query employee's leave quota
-	quota, err := uc.emplRepo.GetLeaveQuotaByEmployeeId(ctx, employee.Id)
+	// Query the leave type
+	policy, err := uc.leaveRepo.GetLeavePolicyByCode(ctx, leave.Type)
+	if err != nil {
+		return report, NewNotFoundError("Leave Type", fmt.Errorf("leave type %s is not available", strings.ToLower(leave.Type.String())))
+	}
+
+	biodata, err := uc.emplRepo.GetBiodataByEmployeeId(ctx, employee.Id)
 	if err != nil {
 		return report, NewRepositoryError("Leave", err)
 	}
 
-	leaveDuration := utils.CountNumberOfWorkingDays(leave.From, leave.To)
+	if err := policy.CheckEligibility(employee, biodata); err != nil {
+		return report, NewDomainError("Leave", err)
+	}
 
-	switch leave.Type {
-	case entity.MARRIAGE:
-		if quota.MarriageCount <= 0 {
-			return report, NewDomainError("Leave", fmt.Errorf("there are no more quota for leave type %s. Please consider selecting other type", strings.ToLower(leave.Type.String())))
-		}
-		report.RequestType = entity.MARRIAGE
-		report.RemainingQuotaForRequestedType = quota.MarriageCount
+	if err := policy.CheckNotice(leave); err != nil {
+		return report, NewDomainError("Leave", err)
+	}
 
-		config, err := uc.configRepo.GetConfiguration(ctx)
-		if err != nil {
-			return report, NewRepositoryError("Config", err)
-		}
-		if utils.CountNumberOfDays(time.Now().In(utils.CURRENT_LOC), leave.From) <= config.AcceptanceLeaveInterval {
-			return report, NewDomainError("Leave", fmt.Errorf("a marriage leave request must be submitted %d days from now", config.AcceptanceLeaveInterval))
-		}
+	// Query employee's leave quota
+	quota, err := uc.leaveRepo.GetLeaveQuotasByEmployeeId(ctx, employee.Id)
+	if err != nil {
+		return report, NewRepositoryError("Leave", err)
+	}
+	if amended != nil {
+		quota.Refund(amended.RefundableLeaves(), schedule.Holidays()...)
+	}
 
-		biodata, err := uc.emplRepo.GetBiodataByEmployeeId(ctx, employee.Id)
-		if err != nil {
-			return report, NewRepositoryError("Leave", err)
-		}
+	leaveDuration := leave.Duration(schedule.Holidays()...)
+	remaining := quota.Remaining(policy)
+	report.RequestType = policy.Code
 
-		if biodata.MaritalStatus {
-			return report, NewDomainError("Leave", fmt.Errorf("employee is already married"))
+	if !policy.HasQuota {
+		// A leave type without quota is only limited by its duration
+		report.RemainingQuotaForRequestedType = -1
+		if leaveDuration > remaining {
+			return report, NewDomainError("Leave", fmt.Errorf("maximum allowed %s leave duration is %.0f days", strings.ToLower(policy.Name), remaining))
 		}
 
-		if leaveDuration > quota.MarriageCount {
-			report.IsLeaveLeakage = true
-			report.ExcessLeaveDuration = leaveDuration - quota.MarriageCount
+		return report, nil
+	}
 
-			// Available excess from here should be annual and unpaid
-			if quota.YearlyCount > 0 {
-				report.AvailableExcessTypes = append(report.AvailableExcessTypes, entity.ANNUAL)
-				report.AvailableExcessQuotas = append(report.AvailableExcessQuotas, quota.YearlyCount)
-			}
+	if remaining <= 0 {
+		return report, NewDomainError("Leave", fmt.Errorf("there are no more quota for leave type %s. Please consider selecting other type", strings.ToLower(leave.Type.String())))
+	}
+	report.RemainingQuotaForRequestedType = remaining
 
-			report.AvailableExcessTypes = append(report.AvailableExcessTypes, entity.UNPAID)
-			report.AvailableExcessQuotas = append(report.AvailableExcessQuotas, 365)
+	if leaveDuration > remaining {
+		excess, err := leaveLeakage(leave, leaveDuration, remaining)
+		if err != nil {
+			return report, NewDomainError("Leave", err)
 		}
+		report.IsLeaveLeakage = true
+		report.ExcessLeaveDuration = excess
+
+		// Available excess types are the overflow targets the
+		// employee is eligible for and still has quota of
+		for _, code := range policy.OverflowTargets {
+			target, err := uc.leaveRepo.GetLeavePolicyByCode(ctx, code)
+			if err != nil {
+				continue
+			}
+			if target.CheckEligibility(employee, biodata) != nil {
+				continue
+			}
 
-	case entity.ANNUAL:
-		if quota.YearlyCount <= 0 {
-			return report, NewDomainError("Leave", fmt.Errorf("there are no more quota for leave type %s. Please consider selecting other type", strings.ToLower(leave.Type.String())))
+			if targetRemaining := quota.Remaining(target); targetRemaining > 0 {
+				report.AvailableExcessTypes = append(report.AvailableExcessTypes, target.Code)
+				report.AvailableExcessQuotas = append(report.AvailableExcessQuotas, targetRemaining)
+			}
 		}
-		report.RequestType = entity.ANNUAL
-		report.RemainingQuotaForRequestedType = quota.YearlyCount
+	}
 
-		config, err := uc.configRepo.GetConfiguration(ctx)
-		if err != nil {
-			return report, NewRepositoryError("Config", err)
-		}
-		if utils.CountNumberOfDays(time.Now().In(utils.CURRENT_LOC), leave.From) < config.AcceptanceLeaveInterval {
-			return report, NewDomainError("Leave", fmt.Errorf("an annual leave request must be submitted %d days from now", config.AcceptanceLeaveInterval))
-		}
+	return report, nil
+}
 
-		if leaveDuration > quota.YearlyCount {
-			report.IsLeaveLeakage = true
-			report.ExcessLeaveDuration = leaveDuration - quota.YearlyCount
+// checkLeaveAttachment returns an error if the leave type requires
+// an attachment but none is provided.
+func (uc *leaveUseCase) checkLeaveAttachment(ctx context.Context, leaveType entity.LeaveType, hasAttachment bool) error {
+	policy, err := uc.leaveRepo.GetLeavePolicyByCode(ctx, leaveType)
+	if err != nil {
+		return NewRepositoryError("Leave Type", err)
+	}
 
-			// Available excess types from here are only unpaid...
-			report.AvailableExcessTypes = append(report.AvailableExcessTypes, entity.UNPAID)
-			report.AvailableExcessQuotas = append(report.AvailableExcessQuotas, 365)
-		}
+	if policy.RequiresAttachment && !hasAttachment {
+		return NewDomainError("Leave", fmt.Errorf("a %s leave request requires an attachment", strings.ToLower(policy.Name)))
+	}
 
-	case entity.UNPAID:
-		report.RequestType = entity.UNPAID
-		report.RemainingQuotaForRequestedType = 365
+	return nil
+}
 
-		if leaveDuration > report.RemainingQuotaForRequestedType {
-			return report, NewDomainError("Leave", fmt.Errorf("maximum allowed unpaid leave duration is %d days", report.RemainingQuotaForRequestedType))
-		}
+// retrieveMyLeave retrieves a parent leave of the employee to be
+// modified by the employee.
+func (uc *leaveUseCase) retrieveMyLeave(ctx context.Context, employee entity.Employee, id string) (entity.Leave, error) {
+	leave, err := uc.leaveRepo.GetLeaveById(ctx, id)
+	if err != nil {
+		return leave, NewRepositoryError("Leave", err)
+	}
 
-		config, err := uc.configRepo.GetConfiguration(ctx)
-		if err != nil {
-			return report, NewRepositoryError("Config", err)
-		}
-		if utils.CountNumberOfDays(time.Now().In(utils.CURRENT_LOC), leave.From) <= config.AcceptanceLeaveInterval {
-			return report, NewDomainError("Leave", fmt.Errorf("an unpaid leave request must be submitted %d days from now", config.AcceptanceLeaveInterval))
-		}
+	if leave.EmployeeID != employee.Id {
+		return leave, NewDomainError("Leave", fmt.Errorf("you are not allowed to modify this leave request"))
+	}
+	if leave.ParentID != nil {
+		return leave, NewDomainError("Leave", fmt.Errorf("modifying only the leave overflow is not allowed"))
+	}
+	if leave.CancelledAt != nil {
+		return leave, NewDomainError("Leave", fmt.Errorf("this leave has already been cancelled"))
+	}
 
-	case entity.SICK:
-		report.IsLeaveLeakage = false
-		report.RequestType = entity.SICK
-		report.RemainingQuotaForRequestedType = -1
+	return leave, nil
+}
+
+// leaveLeakage returns the excess of a leave duration over the
+// remaining quota of the requested type. The requested type only
+// covers the whole days of its remaining quota, so a partial-day
+// leave or a quota of less than a day cannot be overflowed to
+// other types.
+func leaveLeakage(leave entity.Leave, duration, remaining float64) (float64, error) {
+	if leave.IsPartialDay() || remaining < 1 {
+		return 0, fmt.Errorf("the remaining quota of %s days for leave type %s is not sufficient. Please consider selecting other type",
+			strconv.FormatFloat(remaining, 'f', -1, 64),
+			strings.ToLower(leave.Type.String()),
+		)
 	}
 
-	return report, nil
+	return duration - math.Floor(remaining), nil
 }
 
 /*
@@ -0,0 +1,328 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"sinarlog.com/internal/app/repo"
+	"sinarlog.com/internal/app/service"
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/entity/vo"
+	"sinarlog.com/internal/utils"
+)
+
+func (r *fakeLeaveRepo) GetLeaveById(ctx context.Context, id string) (entity.Leave, error) {
+	leave, ok := r.leaves[id]
+	if !ok {
+		return leave, fmt.Errorf("record not found")
+	}
+	return leave, nil
+}
+
+func (r *fakeLeaveRepo) CheckDateAvailability(ctx context.Context, leave entity.Leave, excludeIds ...string) (bool, error) {
+	return true, nil
+}
+
+func (r *fakeLeaveRepo) GetLeaveQuotasByEmployeeId(ctx context.Context, employeeId string) (entity.EmployeeLeavesQuota, error) {
+	// A copy, since refunding an amended leave changes the quotas
+	return append(entity.EmployeeLeavesQuota{}, r.quotas...), nil
+}
+
+func (r *fakeLeaveRepo) AmendLeave(ctx context.Context, amended entity.Leave, leave entity.Leave) error {
+	r.amended = append(r.amended, leave)
+	return nil
+}
+
+func (r *fakeLeaveRepo) SaveLeaveCancellation(ctx context.Context, actorId string, leave entity.Leave) error {
+	r.cancelled = append(r.cancelled, leave)
+	return nil
+}
+
+// fakeLeaveEmployeeRepo knows the employees, none of them has a
+// manager.
+type fakeLeaveEmployeeRepo struct {
+	repo.IEmployeeRepo
+}
+
+func (fakeLeaveEmployeeRepo) GetEmployeeById(ctx context.Context, id string) (entity.Employee, error) {
+	return employeeWithId(id), nil
+}
+
+func (fakeLeaveEmployeeRepo) GetBiodataByEmployeeId(ctx context.Context, employeeId string) (entity.EmployeeBiodata, error) {
+	return entity.EmployeeBiodata{}, nil
+}
+
+// fakeNotifService delivers every notification.
+type fakeNotifService struct {
+	service.INotifService
+}
+
+func (fakeNotifService) SendLeaveRequestNotification(ctx context.Context, receiver, sender entity.Employee) (int64, error) {
+	return 1, nil
+}
+
+// testLeaveUseCase gives the employee "e", managed by "m", an annual
+// quota overflowing to the study quota.
+func testLeaveUseCase(quotas entity.EmployeeLeavesQuota, leaves ...entity.Leave) (*leaveUseCase, *fakeLeaveRepo) {
+	annual := leavePolicy("annual", entity.ANNUAL)
+	annual.HasQuota = true
+	annual.OverflowTargets = []entity.LeaveType{"STUDY"}
+	study := leavePolicy("study", "STUDY")
+	study.HasQuota = true
+
+	leaveRepo := &fakeLeaveRepo{
+		policies: []entity.LeavePolicy{annual, study},
+		leaves:   make(map[string]entity.Leave),
+		quotas:   quotas,
+	}
+	for _, v := range leaves {
+		leaveRepo.leaves[v.Id] = v
+	}
+
+	uc := NewLeaveUseCase(leaveRepo, &fakeApprovalRepo{}, fakeLeaveEmployeeRepo{}, fakeConfigRepo{}, &fakeScheduleRepo{lookups: make(map[string]int)}, nil, fakeNotifService{}, nil)
+	return uc, leaveRepo
+}
+
+func leaveEmployee() entity.Employee {
+	employee := employeeWithId("e")
+	manager := "m"
+	employee.ManagerID = &manager
+	employee.ContractType = entity.FULL_TIME
+	return employee
+}
+
+// nextMonday returns the Monday of the week after next, hence a
+// leave from it has not started.
+func nextMonday() time.Time {
+	now := time.Now().In(utils.CURRENT_LOC)
+	days := (8-int(now.Weekday()))%7 + 7
+	return time.Date(now.Year(), now.Month(), now.Day()+days, 0, 0, 0, 0, utils.CURRENT_LOC)
+}
+
+// requestedLeave is a leave of the employee from the given day for
+// a number of working days.
+func requestedLeave(id string, leaveType entity.LeaveType, from time.Time, days int) entity.Leave {
+	return entity.Leave{
+		BaseModelId: entity.BaseModelId{Id: id},
+		EmployeeID:  "e",
+		From:        from,
+		To:          time.Date(from.Year(), from.Month(), from.Day()+days-1, 23, 59, 59, 0, utils.CURRENT_LOC),
+		Type:        leaveType,
+		Reason:      "Visiting my family back in my hometown",
+		WorkingDays: float64(days),
+	}
+}
+
+func approvedLeave(leave entity.Leave) entity.Leave {
+	truee := true
+	leave.ApprovedByManager = &truee
+	leave.ApprovedByHr = &truee
+	return leave
+}
+
+func TestAmendingLeaves(t *testing.T) {
+	monday := nextMonday()
+	// The pending leave took 2 annual days and overflowed 1 study day
+	pending := requestedLeave("pending", entity.ANNUAL, monday, 2)
+	pending.Childs = []entity.Leave{requestedLeave("overflow", "STUDY", monday.AddDate(0, 0, 2), 1)}
+	quotas := entity.EmployeeLeavesQuota{{Type: entity.ANNUAL, Count: 1}, {Type: "STUDY"}}
+
+	testCases := []struct {
+		name     string
+		decision vo.UserLeaveDecision
+		days     float64
+		overflow float64
+	}{
+		{
+			"Within the refunded annual quota",
+			vo.UserLeaveDecision{Parent: requestedLeave("", entity.ANNUAL, monday, 3)},
+			3, 0,
+		},
+		{
+			"Overflowing to the refunded study quota",
+			vo.UserLeaveDecision{
+				Parent:    requestedLeave("", entity.ANNUAL, monday, 4),
+				Overflows: []vo.LeaveOverflowsDecision{{Type: "STUDY", Count: 1}},
+			},
+			3, 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, leaveRepo := testLeaveUseCase(quotas, pending)
+
+			if err := uc.AmendLeave(context.Background(), leaveEmployee(), "pending", tc.decision); err != nil {
+				t.Fatalf("expected the leave to be amended, got %s", err)
+			}
+			if len(leaveRepo.amended) != 1 {
+				t.Fatalf("expected the amended leave to be saved, got %v", leaveRepo.amended)
+			}
+
+			leave := leaveRepo.amended[0]
+			if leave.WorkingDays != tc.days {
+				t.Fatalf("expected %v annual days, got %v", tc.days, leave.WorkingDays)
+			}
+			var overflow float64
+			for _, v := range leave.Childs {
+				overflow += v.WorkingDays
+			}
+			if overflow != tc.overflow {
+				t.Fatalf("expected %v study days, got %v", tc.overflow, overflow)
+			}
+			if leave.Id == "pending" || !leave.IsPending() {
+				t.Fatalf("expected a new pending leave, got %v", leave)
+			}
+		})
+	}
+}
+
+func TestLeavesNotAmended(t *testing.T) {
+	monday := nextMonday()
+	quotas := entity.EmployeeLeavesQuota{{Type: entity.ANNUAL, Count: 1}, {Type: "STUDY"}}
+	decision := vo.UserLeaveDecision{
+		Parent:    requestedLeave("", entity.ANNUAL, monday, 3),
+		Overflows: []vo.LeaveOverflowsDecision{{Type: "STUDY", Count: 1}},
+	}
+
+	testCases := []struct {
+		name  string
+		leave entity.Leave
+		code  int
+	}{
+		{"An approved leave", approvedLeave(requestedLeave("leave", entity.ANNUAL, monday, 2)), 422},
+		{"A leave of another employee", func() entity.Leave {
+			leave := requestedLeave("leave", entity.ANNUAL, monday, 2)
+			leave.EmployeeID = "other"
+			return leave
+		}(), 422},
+		// The rejected overflow has had its study day refunded already
+		{"A rejected overflow", func() entity.Leave {
+			leave := requestedLeave("leave", entity.ANNUAL, monday, 1)
+			falsee := false
+			leave.Childs = []entity.Leave{requestedLeave("overflow", "STUDY", monday.AddDate(0, 0, 1), 1)}
+			leave.Childs[0].ApprovedByManager = &falsee
+			return leave
+		}(), 422},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, leaveRepo := testLeaveUseCase(quotas, tc.leave)
+
+			if err := uc.AmendLeave(context.Background(), leaveEmployee(), "leave", decision); errorCode(err) != tc.code {
+				t.Fatalf("expected code %d, got %v", tc.code, err)
+			}
+			if len(leaveRepo.amended) != 0 {
+				t.Fatalf("expected the leave not to be amended, got %v", leaveRepo.amended)
+			}
+		})
+	}
+}
+
+func TestCancellingLeaves(t *testing.T) {
+	monday := nextMonday()
+	reason := "My family visit has been postponed"
+	managerless := func(leave entity.Leave) entity.Leave {
+		leave.ManagerID = nil
+		return leave
+	}
+	managed := func(leave entity.Leave) entity.Leave {
+		manager := "m"
+		leave.ManagerID = &manager
+		return leave
+	}
+
+	testCases := []struct {
+		name      string
+		leave     entity.Leave
+		cancelled bool
+		// Whether the manager step of the cancellation is approved
+		managerApproved bool
+	}{
+		{"A pending leave", managed(requestedLeave("leave", entity.ANNUAL, monday, 2)), true, false},
+		{"An approved leave", managed(approvedLeave(requestedLeave("leave", entity.ANNUAL, monday, 2))), false, false},
+		{"An approved leave of a manager", managerless(approvedLeave(requestedLeave("leave", entity.ANNUAL, monday, 2))), false, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, leaveRepo := testLeaveUseCase(nil, tc.leave)
+
+			if err := uc.CancelLeave(context.Background(), leaveEmployee(), "leave", reason); err != nil {
+				t.Fatalf("expected the leave to be cancelled, got %s", err)
+			}
+			if len(leaveRepo.cancelled) != 1 {
+				t.Fatalf("expected the cancellation to be saved, got %v", leaveRepo.cancelled)
+			}
+
+			leave := leaveRepo.cancelled[0]
+			if cancelled := leave.CancelledAt != nil; cancelled != tc.cancelled {
+				t.Fatalf("expected cancelled to be %v, got %v", tc.cancelled, leave.CancelledAt)
+			}
+			if leave.CancellationRequestedAt == nil || leave.CancellationReason != reason {
+				t.Fatalf("expected the cancellation to be requested, got %v", leave)
+			}
+			if approved := leave.CancellationApprovedByManager != nil && *leave.CancellationApprovedByManager; approved != tc.managerApproved {
+				t.Fatalf("expected the manager approval to be %v, got %v", tc.managerApproved, leave.CancellationApprovedByManager)
+			}
+			if !tc.cancelled && !leave.HasPendingCancellation() {
+				t.Fatalf("expected the cancellation to wait for approval")
+			}
+		})
+	}
+}
+
+func TestLeavesNotCancelled(t *testing.T) {
+	monday := nextMonday()
+	reason := "My family visit has been postponed"
+	yesterday := time.Now().In(utils.CURRENT_LOC).AddDate(0, 0, -1)
+	now := time.Now().In(utils.CURRENT_LOC)
+	truee, falsee := true, false
+
+	testCases := []struct {
+		name   string
+		leave  entity.Leave
+		reason string
+	}{
+		{"A started leave", approvedLeave(requestedLeave("leave", entity.ANNUAL, yesterday, 3)), reason},
+		{"Without a reason", approvedLeave(requestedLeave("leave", entity.ANNUAL, monday, 2)), ""},
+		{"A rejected leave", func() entity.Leave {
+			leave := requestedLeave("leave", entity.ANNUAL, monday, 2)
+			leave.ApprovedByManager = &falsee
+			return leave
+		}(), reason},
+		{"A pending cancellation", func() entity.Leave {
+			leave := approvedLeave(requestedLeave("leave", entity.ANNUAL, monday, 2))
+			leave.CancellationRequestedAt = &now
+			leave.CancellationApprovedByManager = &truee
+			return leave
+		}(), reason},
+		{"A cancelled leave", func() entity.Leave {
+			leave := requestedLeave("leave", entity.ANNUAL, monday, 2)
+			leave.CancelledAt = &now
+			return leave
+		}(), reason},
+		{"An overflow", func() entity.Leave {
+			leave := requestedLeave("leave", "STUDY", monday, 1)
+			parent := "parent"
+			leave.ParentID = &parent
+			return leave
+		}(), reason},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, leaveRepo := testLeaveUseCase(nil, tc.leave)
+
+			if err := uc.CancelLeave(context.Background(), leaveEmployee(), "leave", tc.reason); errorCode(err) != 422 {
+				t.Fatalf("expected code 422, got %v", err)
+			}
+			if len(leaveRepo.cancelled) != 0 {
+				t.Fatalf("expected the leave not to be cancelled, got %v", leaveRepo.cancelled)
+			}
+		})
+	}
+}
@@ -38,7 +38,13 @@ func (uc *attendanceUseCase) SeeIncomingOvertimeSubmissionsForManager(ctx contex
 	q.Pagination.Sort = "DESC"
 	q.Pagination.Order = "created_at"
 
-	overtimes, page, err := uc.attRepo.GetIncomingOvertimeSubmissionsForManager(ctx, manager.Id, q)
+	// Includes the overtimes of the managers delegating to the manager
+	approverIds, err := getApproverIds(ctx, uc.approvalRepo, manager, time.Now().In(utils.CURRENT_LOC))
+	if err != nil {
+		return nil, vo.PaginationDTOResponse{}, err
+	}
+
+	overtimes, page, err := uc.attRepo.GetIncomingOvertimeSubmissionsForManager(ctx, approverIds, q)
 	if err != nil {
 		return nil, page, NewRepositoryError("Overtime", err)
 	}
@@ -54,42 +60,37 @@ func (uc *attendanceUseCase) RetrieveOvertimeSubmission(ctx context.Context, ove
 	return overtime, nil
 }
 
+// TakeActionOnOvertimeSubmissionByManager decides the step of the
+// approval chain waiting on the manager. The overtime is decided
+// once its last step is approved, or right away once rejected.
 func (uc *attendanceUseCase) TakeActionOnOvertimeSubmissionByManager(ctx context.Context, manager entity.Employee, action vo.OvertimeSubmissionAction) error {
 	overtime, err := uc.attRepo.GetOvertimeById(ctx, action.Id)
 	if err != nil {
-		NewRepositoryError("Overtime", err)
+		return NewRepositoryError("Overtime", err)
 	}
 
-	// Checks if the overtime has been processed
-	if overtime.ApprovedByManager != nil && overtime.ActionByManagerAt != nil {
-		return NewDomainError("Overtime", fmt.Errorf("this overtime submission has been processed"))
+	if err := validateOvertimeSubmissionAction(overtime); err != nil {
+		return err
 	}
 
-	// Checks if the overtime has been closed automatically
-	if overtime.ClosedAutomatically != nil {
-		if *overtime.ClosedAutomatically {
-			return NewDomainError("Overtime", fmt.Errorf("unable to process a closed overtime submission"))
-		}
+	// Check if the overtime is waiting for the current user, either as
+	// the approver or as a delegate of the approver. An overtime
+	// without approval steps waits for its manager.
+	approverIds, err := getApproverIds(ctx, uc.approvalRepo, manager, time.Now().In(utils.CURRENT_LOC))
+	if err != nil {
+		return err
 	}
 
-	// Check if the overtime is intended for the current user
-	if *overtime.ManagerID != manager.Id {
+	steps := entity.ApprovalSteps(overtime.ApprovalSteps)
+	if len(steps) == 0 {
+		if overtime.ManagerID == nil || !isApprover(approverIds, *overtime.ManagerID) {
+			return NewDomainError("Overtime", fmt.Errorf("you are not allowed to process this overtime submission"))
+		}
+	} else if !steps.IsWaitingFor(approverIds...) {
 		return NewDomainError("Overtime", fmt.Errorf("you are not allowed to process this overtime submission"))
 	}
 
-	// Start processing the request
-	now := time.Now().In(utils.CURRENT_LOC)
-	overtime.ApprovedByManager = &action.Approved
-	overtime.ActionByManagerAt = &now
-	overtime.RejectionReason = action.Reason
-
-	if err := uc.attRepo.SaveProcessedOvertimeSubmissionByManager(ctx, overtime); err != nil {
-		return NewRepositoryError("Overtime", err)
-	}
-
-	go uc.sendProcessedOvertimeSubmissionByManagerEmail(overtime)
-
-	return nil
+	return uc.decideOvertimeSubmission(ctx, manager, overtime, action, uc.attRepo.SaveProcessedOvertimeSubmissionByManager)
 }
 
 func (uc *attendanceUseCase) RetrieveOvertimeSubmissionsHistoryForManager(ctx context.Context, manager entity.Employee, q vo.LeaveProposalHistoryQuery) ([]entity.Overtime, vo.PaginationDTOResponse, error) {
@@ -111,16 +112,8 @@ func (uc *attendanceUseCase) RetrieveMyEmployeesOvertime(ctx context.Context, ma
 	q.Pagination.Sort = "DESC"
 	q.Pagination.Order = "created_at"
 
-	// Verify that the employee is indeed under the manager
-	employee, err := uc.emplRepo.GetEmployeeById(ctx, employeeId)
-	if err != nil {
-		return nil, vo.PaginationDTOResponse{}, NewRepositoryError("Leave", err)
-	}
-	if employee.ManagerID == nil {
-		return nil, vo.PaginationDTOResponse{}, NewDomainError("Employee", fmt.Errorf("the employee you're requesting to view is not your staff"))
-	}
-	if *employee.ManagerID != manager.Id {
-		return nil, vo.PaginationDTOResponse{}, NewDomainError("Employee", fmt.Errorf("the employee you're requesting to view is not your staff"))
+	if _, err := authorizeOnEmployee(ctx, uc.emplRepo, manager, entity.PERM_OVERTIME_READ, employeeId); err != nil {
+		return nil, vo.PaginationDTOResponse{}, err
 	}
 
 	overtimes, page, err := uc.attRepo.GetMyOvertimeSubmissions(ctx, employeeId, q)
@@ -164,6 +157,114 @@ func (uc *attendanceUseCase) RetrieveAnEmployeeOvertimes(ctx context.Context, em
 	return overtimes, page, nil
 }
 
+func (uc *attendanceUseCase) SeeIncomingOvertimeSubmissionsForHr(ctx context.Context, q vo.IncomingOvertimeSubmissionsQuery) ([]entity.Overtime, vo.PaginationDTOResponse, error) {
+	q.Pagination.Sort = "DESC"
+	q.Pagination.Order = "created_at"
+
+	overtimes, page, err := uc.attRepo.GetIncomingOvertimeSubmissionsForHr(ctx, q)
+	if err != nil {
+		return nil, page, NewRepositoryError("Overtime", err)
+	}
+	return overtimes, page, nil
+}
+
+// TakeActionOnOvertimeSubmissionByHr decides the HR step of the
+// approval chain, which is the last step of an overtime.
+func (uc *attendanceUseCase) TakeActionOnOvertimeSubmissionByHr(ctx context.Context, hr entity.Employee, action vo.OvertimeSubmissionAction) error {
+	overtime, err := uc.attRepo.GetOvertimeById(ctx, action.Id)
+	if err != nil {
+		return NewRepositoryError("Overtime", err)
+	}
+
+	if err := validateOvertimeSubmissionAction(overtime); err != nil {
+		return err
+	}
+
+	steps := entity.ApprovalSteps(overtime.ApprovalSteps)
+	if current, ok := steps.Current(); !ok || steps[current].Role != entity.APPROVER_HR {
+		return NewDomainError("Overtime", fmt.Errorf("this overtime submission is not waiting for HR"))
+	}
+
+	return uc.decideOvertimeSubmission(ctx, hr, overtime, action, uc.attRepo.SaveProcessedOvertimeSubmissionByHr)
+}
+
+// validateOvertimeSubmissionAction checks that the overtime is still
+// waiting for a decision.
+func validateOvertimeSubmissionAction(overtime entity.Overtime) error {
+	// Checks if the overtime has been processed
+	if overtime.ApprovedByManager != nil && overtime.ActionByManagerAt != nil {
+		return NewDomainError("Overtime", fmt.Errorf("this overtime submission has been processed"))
+	}
+
+	// Checks if the overtime has been closed automatically
+	if overtime.ClosedAutomatically != nil {
+		if *overtime.ClosedAutomatically {
+			return NewDomainError("Overtime", fmt.Errorf("unable to process a closed overtime submission"))
+		}
+	}
+
+	return nil
+}
+
+// decideOvertimeSubmission records the decision of the actor on the
+// step of the approval chain waiting for a decision. The requestee
+// is notified once the overtime is decided, meanwhile the approver of
+// the next step is notified that the overtime is waiting.
+func (uc *attendanceUseCase) decideOvertimeSubmission(
+	ctx context.Context,
+	actor entity.Employee,
+	overtime entity.Overtime,
+	action vo.OvertimeSubmissionAction,
+	save func(context.Context, entity.Overtime) error,
+) error {
+	now := time.Now().In(utils.CURRENT_LOC)
+	steps := entity.ApprovalSteps(overtime.ApprovalSteps)
+	current, _ := steps.Current()
+
+	// Start processing the request
+	if !action.Approved || current >= len(steps)-1 {
+		overtime.ApprovedByManager = &action.Approved
+		overtime.ActionByManagerAt = &now
+		overtime.RejectionReason = action.Reason
+	}
+	if len(steps) > 0 {
+		steps.Decide(current, actor.Id, action.Approved, action.Reason, now)
+	} else if overtime.ManagerID != nil && *overtime.ManagerID != actor.Id {
+		// The decision of a delegate on an overtime without approval
+		// steps is recorded as the step of its manager
+		overtime.ApprovalSteps = delegatedManagerStep(nil, &overtime.Id, *overtime.ManagerID, actor.Id, action.Approved, action.Reason, now)
+	}
+
+	if err := save(ctx, overtime); err != nil {
+		return newApprovalSaveError("Overtime", err)
+	}
+
+	if overtime.ApprovedByManager != nil {
+		go uc.sendProcessedOvertimeSubmissionByManagerEmail(overtime)
+		return nil
+	}
+
+	// Lets the approver of the next step know the overtime is waiting
+	if next, ok := steps.Current(); ok && steps[next].ApproverID != nil {
+		approver, err := uc.emplRepo.GetEmployeeById(ctx, *steps[next].ApproverID)
+		if err != nil {
+			log.Printf("Unable to query the next approver of the overtime submission due to: %s", err.Error())
+			return nil
+		}
+
+		wasReceived, err := uc.notifService.SendOvertimeSubmissionNotification(ctx, approver, overtime.Attendance.Employee)
+		if err != nil {
+			log.Printf("unable to publish notification")
+		} else if wasReceived == 0 {
+			attendance := overtime.Attendance
+			attendance.Overtime = &overtime
+			go uc.sendOvertimeSubmissionEmail(approver, attendance)
+		}
+	}
+
+	return nil
+}
+
 /*
 *************************************************
 MAILER HELPERS
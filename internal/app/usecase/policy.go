@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"sinarlog.com/internal/app/repo"
+	"sinarlog.com/internal/entity"
+)
+
+// RequirePermissions checks that the role of the employee grants every
+// permission within the scope or a wider one.
+func RequirePermissions(employee entity.Employee, scope entity.PermissionScope, perms ...entity.Permission) error {
+	for _, perm := range perms {
+		if !employee.CanWithin(perm, scope) {
+			return NewForbiddenError(fmt.Errorf("you do not have the %s permission", perm))
+		}
+	}
+
+	return nil
+}
+
+// authorizeOnEmployee checks that the actor holds the permission over
+// the employee, and returns the employee.
+func authorizeOnEmployee(ctx context.Context, emplRepo repo.IEmployeeRepo, actor entity.Employee, perm entity.Permission, employeeId string) (entity.Employee, error) {
+	employee, err := emplRepo.GetEmployeeSimpleInformationById(ctx, employeeId)
+	if err != nil {
+		return employee, NewNotFoundError("Employee", err)
+	}
+
+	if !actor.Can(perm, &employee) {
+		return employee, NewForbiddenError(fmt.Errorf("you do not have the %s permission over %s", perm, employee.FullName))
+	}
+
+	return employee, nil
+}
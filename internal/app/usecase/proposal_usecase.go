@@ -28,7 +28,13 @@ func (uc *leaveUseCase) SeeIncomingLeaveProposalsForManager(ctx context.Context,
 		return nil, vo.PaginationDTOResponse{}, NewClientError("Query", err)
 	}
 
-	leaves, page, err := uc.leaveRepo.GetIncomingLeaveProposalForManager(ctx, manager.Id, q)
+	// Includes the leaves of the managers delegating to the manager
+	approverIds, err := getApproverIds(ctx, uc.approvalRepo, manager, time.Now().In(utils.CURRENT_LOC))
+	if err != nil {
+		return nil, vo.PaginationDTOResponse{}, err
+	}
+
+	leaves, page, err := uc.leaveRepo.GetIncomingLeaveProposalForManager(ctx, approverIds, q)
 	if err != nil {
 		return nil, page, NewRepositoryError("Leave", err)
 	}
@@ -36,6 +42,10 @@ func (uc *leaveUseCase) SeeIncomingLeaveProposalsForManager(ctx context.Context,
 	return leaves, page, nil
 }
 
+// TakeActionOnLeaveProposalForManager decides the step of the
+// approval chain waiting on the manager. The manager's approval of
+// the leave is decided once the last step before HR is decided,
+// meanwhile a rejection of any step rejects the leave right away.
 func (uc *leaveUseCase) TakeActionOnLeaveProposalForManager(ctx context.Context, manager entity.Employee, action vo.LeaveAction) error {
 	leave, err := uc.leaveRepo.GetLeaveById(ctx, action.Id)
 	if err != nil {
@@ -54,48 +64,76 @@ func (uc *leaveUseCase) TakeActionOnLeaveProposalForManager(ctx context.Context,
 		}
 	}
 
+	// Checks if the leave was withdrawn by the requestee
+	if leave.CancelledAt != nil {
+		return NewDomainError("Leave", fmt.Errorf("unable to process this leave because it has been cancelled"))
+	}
+
 	// Checks if this leave has been processed
 	if leave.ApprovedByManager != nil || leave.ApprovedByHr != nil || leave.ActionByHrAt != nil || leave.ActionByManagerAt != nil {
 		return NewDomainError("Leave", fmt.Errorf("unable to process your action because this leave has been proceesed"))
 	}
 
+	// Validate that its true the leave is waiting for the current user,
+	// either as the approver or as a delegate of the approver. A leave
+	// without approval steps waits for its manager.
+	now := time.Now().In(utils.CURRENT_LOC)
+	approverIds, err := getApproverIds(ctx, uc.approvalRepo, manager, now)
+	if err != nil {
+		return err
+	}
+
+	steps := entity.ApprovalSteps(leave.ApprovalSteps)
+	current, _ := steps.Current()
+	if len(steps) == 0 {
+		if leave.ManagerID == nil {
+			return NewDomainError("Leave", fmt.Errorf("this leave request is not assigned for you"))
+		} else if !isApprover(approverIds, *leave.ManagerID) {
+			return NewDomainError("Leave", fmt.Errorf("you are not allowed to process this leave request"))
+		}
+	} else if !steps.IsWaitingFor(approverIds...) {
+		return NewDomainError("Leave", fmt.Errorf("you are not allowed to process this leave request"))
+	}
+
 	// Validate entity
 	if err := action.Validate(); err != nil {
 		return NewDomainError("Leave", err)
 	}
 
-	// Checks whether the leave has a child
-	if leave.Childs != nil {
+	// Checks whether the leave has a child waiting for an action. An
+	// overflow rejected on an earlier step needs no action.
+	var pendingChildIds []any
+	for _, v := range leave.Childs {
+		if v.ApprovedByManager == nil {
+			pendingChildIds = append(pendingChildIds, v.Id)
+		}
+	}
+	if len(pendingChildIds) > 0 {
 		// Validate the length of leave child and payload child
-		if len(leave.Childs) != len(action.Childs) {
+		if len(pendingChildIds) != len(action.Childs) {
 			return NewDomainError("Leave", fmt.Errorf("each leave overflows must have an action provided. Please include all overflows into action"))
 		}
 
 		// Validate that its true each of
 		// the childs belongs to the parent
-		var childIds []any
-		for _, v := range leave.Childs {
-			childIds = append(childIds, v.Id)
-		}
 		for _, v := range action.Childs {
-			if err := validation.Validate(v.Id, validation.In(childIds...)); err != nil {
+			if err := validation.Validate(v.Id, validation.In(pendingChildIds...)); err != nil {
 				return NewDomainError("Leave", fmt.Errorf("an overflow leave id does not belong to the associated leave"))
 			}
 		}
 	}
 
-	// Validate that its true the leave is directed for the current user.
-	if leave.ManagerID == nil {
-		return NewDomainError("Leave", fmt.Errorf("this leave request is not assigned for you"))
-	} else if *leave.ManagerID != manager.Id {
-		return NewDomainError("Leave", fmt.Errorf("you are not allowed to process this leave request"))
-	}
-
 	// NOTES: If the parent leave is rejected, all the children
 	// will be rejected. On the other hand, if parent is approved
 	// its children may be individual approved or rejected.
-	now := time.Now().In(utils.CURRENT_LOC)
 	shouldSendNotif := false
+	// The last step before HR decides the manager's approval, and
+	// HR's approval too for a chain without HR
+	final := len(steps) == 0 || steps.IsLastReview(current)
+	approvedByHr := final && len(steps) > 0 && !steps.HasHr()
+
+	// Only the overflows decided by this action are saved
+	var decided []entity.Leave
 
 	// Check the action on the parent leave
 	if !action.Approved {
@@ -110,35 +148,82 @@ func (uc *leaveUseCase) TakeActionOnLeaveProposalForManager(ctx context.Context,
 		leave.ApprovedByManager = &action.Approved
 		leave.RejectionReason = action.Reason
 		for i := 0; i < len(leave.Childs); i++ {
-			leave.Childs[i].ActionByManagerAt = &now
-			leave.Childs[i].ApprovedByManager = &action.Approved
-			leave.Childs[i].RejectionReason = "This leave is rejected because the associated leave was rejected. All overflows will be automatically rejected if the main leave is rejected"
+			if leave.Childs[i].ApprovedByManager == nil {
+				leave.Childs[i].ActionByManagerAt = &now
+				leave.Childs[i].ApprovedByManager = &action.Approved
+				leave.Childs[i].RejectionReason = "This leave is rejected because the associated leave was rejected. All overflows will be automatically rejected if the main leave is rejected"
+				decided = append(decided, leave.Childs[i])
+			}
 		}
 	} else {
-		leave.ActionByManagerAt = &now
-		leave.ApprovedByManager = &action.Approved
+		if final {
+			leave.ActionByManagerAt = &now
+			leave.ApprovedByManager = &action.Approved
+			if approvedByHr {
+				leave.ActionByHrAt = &now
+				leave.ApprovedByHr = &action.Approved
+			}
+		}
+
 		for i := 0; i < len(leave.Childs); i++ {
-			leave.Childs[i].ApprovedByManager = &action.Childs[i].Approved
-			leave.Childs[i].ActionByManagerAt = &now
-			if !action.Childs[i].Approved {
+			if leave.Childs[i].ApprovedByManager != nil {
+				continue
+			}
+
+			// Find the approriate action
+			var k vo.LeaveAction
+			for _, v := range action.Childs {
+				if v.Id == leave.Childs[i].Id {
+					k = v
+				}
+			}
+
+			if !k.Approved {
 				shouldSendNotif = true
 				// Validate reason
-				if err := validation.Validate(&action.Childs[i].Reason, validation.Required, validation.Length(20, 1000)); err != nil {
+				if err := validation.Validate(&k.Reason, validation.Required, validation.Length(20, 1000)); err != nil {
 					return NewDomainError("Leave", fmt.Errorf("a rejected leave request must be provided with a reason"))
 				}
-				leave.Childs[i].RejectionReason = action.Childs[i].Reason
+				leave.Childs[i].RejectionReason = k.Reason
+			} else if !final {
+				// An approved overflow waits for the next step
+				continue
+			} else if approvedByHr {
+				leave.Childs[i].ApprovedByHr = &k.Approved
+				leave.Childs[i].ActionByHrAt = &now
 			}
+
+			leave.Childs[i].ApprovedByManager = &k.Approved
+			leave.Childs[i].ActionByManagerAt = &now
+			decided = append(decided, leave.Childs[i])
 		}
 	}
 
-	if err := uc.leaveRepo.SaveProcessedLeaveByManager(ctx, leave); err != nil {
-		return NewRepositoryError("Leave", err)
+	if len(steps) > 0 {
+		steps.Decide(current, manager.Id, action.Approved, action.Reason, now)
+		leave.ApprovalSteps = steps
+	} else if *leave.ManagerID != manager.Id {
+		// The decision of a delegate on a leave without approval
+		// steps is recorded as the step of its manager
+		leave.ApprovalSteps = delegatedManagerStep(&leave.Id, nil, *leave.ManagerID, manager.Id, action.Approved, action.Reason, now)
+	}
+
+	processed := leave
+	processed.Childs = decided
+	if err := uc.leaveRepo.SaveProcessedLeaveByManager(ctx, manager.Id, processed); err != nil {
+		return newApprovalSaveError("Leave", err)
 	}
 
-	if shouldSendNotif {
+	// The requestee is notified once the manager's approval is decided
+	if leave.ApprovedByManager != nil && (shouldSendNotif || approvedByHr) {
 		go uc.sendProcessedLeaveProposalByManager(leave)
 	}
 
+	// Lets the approver of the next step know the leave is waiting
+	if action.Approved && !final {
+		return uc.notifyApproverOfLeaveRequest(ctx, leave.Employee, leave)
+	}
+
 	return nil
 }
 
@@ -157,6 +242,64 @@ func (uc *leaveUseCase) RetrieveLeaveProposalsHistoryForManager(ctx context.Cont
 	return leaves, page, nil
 }
 
+func (uc *leaveUseCase) SeeIncomingLeaveCancellationsForManager(ctx context.Context, manager entity.Employee, q vo.IncomingLeaveProposals) ([]entity.Leave, vo.PaginationDTOResponse, error) {
+	// Set the order to cancellation request date descendingly
+	q.Pagination.Order = "cancellation_requested_at"
+	q.Pagination.Sort = "DESC"
+	if _, err := q.TimeQuery.Extract(); err != nil {
+		return nil, vo.PaginationDTOResponse{}, NewClientError("Query", err)
+	}
+
+	leaves, page, err := uc.leaveRepo.GetIncomingLeaveCancellationForManager(ctx, manager.Id, q)
+	if err != nil {
+		return nil, page, NewRepositoryError("Leave", err)
+	}
+
+	return leaves, page, nil
+}
+
+func (uc *leaveUseCase) TakeActionOnLeaveCancellationForManager(ctx context.Context, manager entity.Employee, action vo.LeaveCancellationAction) error {
+	// Validate entity
+	if err := action.Validate(); err != nil {
+		return NewDomainError("Leave", err)
+	}
+
+	leave, err := uc.leaveRepo.GetLeaveById(ctx, action.Id)
+	if err != nil {
+		return NewRepositoryError("Leave", err)
+	}
+
+	// Checks if the cancellation is waiting for the manager
+	if !leave.HasPendingCancellation() || leave.CancellationApprovedByManager != nil {
+		return NewDomainError("Leave", fmt.Errorf("this leave has no cancellation waiting for your action"))
+	}
+
+	// Validate that its true the leave is directed for the current user.
+	if leave.ManagerID == nil || *leave.ManagerID != manager.Id {
+		return NewDomainError("Leave", fmt.Errorf("you are not allowed to process this leave cancellation"))
+	}
+
+	if action.Approved && leave.HasStarted() {
+		return NewDomainError("Leave", fmt.Errorf("unable to approve the cancellation of a leave that has started"))
+	}
+
+	now := time.Now().In(utils.CURRENT_LOC)
+	leave.CancellationApprovedByManager = &action.Approved
+	leave.CancellationActionByManagerAt = &now
+	if !action.Approved {
+		if err := validation.Validate(&action.Reason, validation.Required, validation.Length(20, 1000)); err != nil {
+			return NewDomainError("Leave", fmt.Errorf("a rejected leave cancellation must be provided with a reason"))
+		}
+		leave.CancellationRejectionReason = action.Reason
+	}
+
+	if err := uc.leaveRepo.SaveLeaveCancellation(ctx, manager.Id, leave); err != nil {
+		return NewRepositoryError("Leave", err)
+	}
+
+	return nil
+}
+
 /*
 *********************************
 ACTOR: HR
@@ -196,6 +339,11 @@ func (uc *leaveUseCase) TakeActionOnLeaveProposalForHr(ctx context.Context, hr e
 		}
 	}
 
+	// Checks if the leave was withdrawn by the requestee
+	if leave.CancelledAt != nil {
+		return NewDomainError("Leave", fmt.Errorf("unable to process this leave because it has been cancelled"))
+	}
+
 	// Check whether this leave has been processed by manager and is approved
 	if leave.ApprovedByManager == nil {
 		return NewDomainError("Leave", fmt.Errorf("this leave has not been process by manager"))
@@ -293,8 +441,14 @@ func (uc *leaveUseCase) TakeActionOnLeaveProposalForHr(ctx context.Context, hr e
 		}
 	}
 
+	// Records the decision on the HR step of the approval chain
+	steps := entity.ApprovalSteps(leave.ApprovalSteps)
+	if current, ok := steps.Current(); ok && steps[current].Role == entity.APPROVER_HR {
+		steps.Decide(current, hr.Id, action.Approved, action.Reason, now)
+	}
+
 	if err := uc.leaveRepo.SaveProcessedLeaveByHr(ctx, leave); err != nil {
-		return NewRepositoryError("Leave", err)
+		return newApprovalSaveError("Leave", err)
 	}
 
 	go uc.sendProcessedLeaveProposalByHr(leave)
@@ -317,6 +471,67 @@ func (uc *leaveUseCase) RetrieveLeaveProposalsHistoryForHr(ctx context.Context,
 	return leaves, page, nil
 }
 
+func (uc *leaveUseCase) SeeIncomingLeaveCancellationsForHr(ctx context.Context, q vo.IncomingLeaveProposals) ([]entity.Leave, vo.PaginationDTOResponse, error) {
+	// Set the order to cancellation request date descendingly
+	q.Pagination.Order = "cancellation_requested_at"
+	q.Pagination.Sort = "DESC"
+	if _, err := q.TimeQuery.Extract(); err != nil {
+		return nil, vo.PaginationDTOResponse{}, NewClientError("Query", err)
+	}
+
+	leaves, page, err := uc.leaveRepo.GetIncomingLeaveCancellationForHr(ctx, q)
+	if err != nil {
+		return nil, page, NewRepositoryError("Leave", err)
+	}
+
+	return leaves, page, nil
+}
+
+// TakeActionOnLeaveCancellationForHr finalizes the cancellation of
+// an approved leave. Once approved, the leave and its overflows are
+// cancelled and their quota are returned back to the employee.
+func (uc *leaveUseCase) TakeActionOnLeaveCancellationForHr(ctx context.Context, hr entity.Employee, action vo.LeaveCancellationAction) error {
+	// Validate entity
+	if err := action.Validate(); err != nil {
+		return NewDomainError("Leave", err)
+	}
+
+	leave, err := uc.leaveRepo.GetLeaveById(ctx, action.Id)
+	if err != nil {
+		return NewRepositoryError("Leave", err)
+	}
+
+	// Checks if the cancellation has been approved by manager
+	if !leave.HasPendingCancellation() || leave.CancellationApprovedByHr != nil {
+		return NewDomainError("Leave", fmt.Errorf("this leave has no cancellation waiting for your action"))
+	}
+	if leave.CancellationApprovedByManager == nil {
+		return NewDomainError("Leave", fmt.Errorf("this leave cancellation has not been process by manager"))
+	}
+
+	if action.Approved && leave.HasStarted() {
+		return NewDomainError("Leave", fmt.Errorf("unable to approve the cancellation of a leave that has started"))
+	}
+
+	now := time.Now().In(utils.CURRENT_LOC)
+	leave.CancellationApprovedByHr = &action.Approved
+	leave.CancellationActionByHrAt = &now
+	if action.Approved {
+		leave.CancelledAt = &now
+	} else {
+		if err := validation.Validate(&action.Reason, validation.Required, validation.Length(20, 1000)); err != nil {
+			return NewDomainError("Leave", fmt.Errorf("a rejected leave cancellation must be provided with a reason"))
+		}
+		leave.CancellationRejectionReason = action.Reason
+	}
+
+	if err := uc.leaveRepo.SaveLeaveCancellation(ctx, hr.Id, leave); err != nil {
+		return NewRepositoryError("Leave", err)
+	}
+
+	return nil
+}
+
 /*
 *************************************************
 MAILER HELPERS
@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/entity/vo"
+)
+
+func (r *fakeLeaveRepo) GetIncomingLeaveProposalForManager(ctx context.Context, approverIds []string, q vo.IncomingLeaveProposals) ([]entity.Leave, vo.PaginationDTOResponse, error) {
+	r.approverIds = approverIds
+	return nil, vo.PaginationDTOResponse{}, nil
+}
+
+func (r *fakeLeaveRepo) SaveProcessedLeaveByManager(ctx context.Context, actorId string, leave entity.Leave) error {
+	r.processed = append(r.processed, leave)
+	return nil
+}
+
+// incomingLeave is a leave of the employee "e" waiting on the manager
+// "m", decided by the steps of the manager then HR unless legacy.
+func incomingLeave(legacy bool) entity.Leave {
+	leave := requestedLeave("3f7c2a9e-5d1b-4c8e-9a6f-2b4d8e1c7a05", entity.ANNUAL, nextMonday(), 2)
+	manager := "m"
+	leave.ManagerID = &manager
+	if !legacy {
+		leave.ApprovalSteps = entity.ApprovalChain{Steps: []entity.ApproverRole{entity.APPROVER_MANAGER, entity.APPROVER_HR}}.
+			BuildSteps(map[entity.ApproverRole]*string{entity.APPROVER_MANAGER: &manager})
+	}
+	return leave
+}
+
+func TestSeeingDelegatedLeaveProposals(t *testing.T) {
+	uc, leaveRepo := testLeaveUseCase(nil)
+	uc.approvalRepo = &fakeApprovalRepo{delegations: []entity.ApprovalDelegation{
+		// m is on leave, meanwhile the delegation of n has ended
+		delegation("m", "d", -1, 3),
+		delegation("n", "d", -5, 2),
+	}}
+
+	if _, _, err := uc.SeeIncomingLeaveProposalsForManager(context.Background(), employeeWithId("d"), vo.IncomingLeaveProposals{}); err != nil {
+		t.Fatalf("expected the incoming leaves, got %s", err)
+	}
+	if !reflect.DeepEqual(leaveRepo.approverIds, []string{"d", "m"}) {
+		t.Fatalf("expected the leaves waiting on d and m, got %v", leaveRepo.approverIds)
+	}
+}
+
+func TestDelegateDecidingLeaveProposals(t *testing.T) {
+	testCases := []struct {
+		name     string
+		actor    string
+		legacy   bool
+		code     int
+		delegate bool
+	}{
+		{"The manager", "m", false, 0, false},
+		{"The delegate of the manager", "d", false, 0, true},
+		{"The delegate on a leave without approval steps", "d", true, 0, true},
+		{"The delegate once the delegation ended", "x", false, 422, false},
+		{"Another manager", "o", false, 422, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			leave := incomingLeave(tc.legacy)
+			uc, leaveRepo := testLeaveUseCase(nil, leave)
+			uc.approvalRepo = &fakeApprovalRepo{delegations: []entity.ApprovalDelegation{
+				delegation("m", "d", -1, 3),
+				delegation("m", "x", -5, 2),
+			}}
+
+			action := vo.LeaveAction{Id: leave.Id, Approved: true}
+			if err := uc.TakeActionOnLeaveProposalForManager(context.Background(), employeeWithId(tc.actor), action); errorCode(err) != tc.code {
+				t.Fatalf("expected code %d, got %v", tc.code, err)
+			}
+			if tc.code != 0 {
+				if len(leaveRepo.processed) != 0 {
+					t.Fatalf("expected the leave not to be decided, got %v", leaveRepo.processed)
+				}
+				return
+			}
+
+			processed := leaveRepo.processed[0]
+			if processed.ApprovedByManager == nil || !*processed.ApprovedByManager || processed.ApprovedByHr != nil {
+				t.Fatalf("expected the leave to wait for HR, got %v", processed)
+			}
+			step := processed.ApprovalSteps[0]
+			if *step.ApproverID != "m" || *step.ActedByID != tc.actor || step.ActedAsDelegate != tc.delegate {
+				t.Fatalf("expected the step of m decided by %s as delegate %v, got %+v", tc.actor, tc.delegate, step)
+			}
+			if !step.IsNewlyDecided() {
+				t.Fatalf("expected the decided step to be saved")
+			}
+		})
+	}
+}
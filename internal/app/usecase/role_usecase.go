@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"sinarlog.com/internal/app/repo"
 	"sinarlog.com/internal/entity"
@@ -23,3 +25,80 @@ func (uc *roleUseCase) RetrieveRoles(ctx context.Context) ([]entity.Role, error)
 
 	return roles, nil
 }
+
+func (uc *roleUseCase) RetrievePermissions(ctx context.Context) map[entity.Permission]string {
+	return entity.Permissions
+}
+
+func (uc *roleUseCase) RegisterRole(ctx context.Context, payload entity.Role) error {
+	if err := payload.Validate(); err != nil {
+		return NewDomainError("Role", err)
+	}
+
+	if err := uc.repo.CreateRole(ctx, payload); err != nil {
+		if errors.Is(err, entity.ErrRoleExists) {
+			return NewConflictError("Role", fmt.Errorf("role %s already exists", payload.Code))
+		}
+		return NewRepositoryError("Role", err)
+	}
+
+	return nil
+}
+
+// UpdateRole renames the role and replaces its permissions. The code of
+// a role cannot be changed, and an HR cannot take away their own right
+// to manage the roles so there is always someone left to do so.
+func (uc *roleUseCase) UpdateRole(ctx context.Context, actor entity.Employee, id string, payload entity.Role) error {
+	role, err := uc.repo.GetRoleById(ctx, id)
+	if err != nil {
+		return NewNotFoundError("Role", err)
+	}
+
+	payload.Id = role.Id
+	payload.Code = role.Code
+	if err := payload.Validate(); err != nil {
+		return NewDomainError("Role", err)
+	}
+
+	if actor.RoleID == role.Id {
+		if scope, ok := payload.ScopeOf(entity.PERM_ROLE_WRITE); !ok || !scope.Includes(entity.SCOPE_ALL) {
+			return NewDomainError("Role", fmt.Errorf("you cannot take away your own permission to manage the roles"))
+		}
+	}
+
+	if err := uc.repo.UpdateRole(ctx, payload); err != nil {
+		if errors.Is(err, entity.ErrRoleExists) {
+			return NewConflictError("Role", fmt.Errorf("role %s already exists", payload.Name))
+		}
+		return NewRepositoryError("Role", err)
+	}
+
+	return nil
+}
+
+// RemoveRole deletes a role no employee holds. The seeded roles cannot
+// be deleted.
+func (uc *roleUseCase) RemoveRole(ctx context.Context, id string) error {
+	role, err := uc.repo.GetRoleById(ctx, id)
+	if err != nil {
+		return NewNotFoundError("Role", err)
+	}
+
+	if role.IsBuiltIn() {
+		return NewDomainError("Role", fmt.Errorf("built-in role %s cannot be deleted", role.Name))
+	}
+
+	count, err := uc.repo.CountEmployeesByRoleId(ctx, role.Id)
+	if err != nil {
+		return NewRepositoryError("Role", err)
+	}
+	if count > 0 {
+		return NewDomainError("Role", fmt.Errorf("role %s is still held by %d employee(s)", role.Name, count))
+	}
+
+	if err := uc.repo.DeleteRole(ctx, role.Id); err != nil {
+		return NewRepositoryError("Role", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,299 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sinarlog.com/internal/app/repo"
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/utils"
+)
+
+type scheduleUseCase struct {
+	scheduleRepo repo.IScheduleRepo
+	configRepo   repo.IConfigRepo
+}
+
+func NewScheduleUseCase(scheduleRepo repo.IScheduleRepo, configRepo repo.IConfigRepo) *scheduleUseCase {
+	return &scheduleUseCase{
+		scheduleRepo: scheduleRepo,
+		configRepo:   configRepo,
+	}
+}
+
+/*
+*********************************
+ACTOR: STAFF and MANAGER
+*********************************
+*/
+
+// RetrieveMySchedule retrieves the work schedule the employee
+// currently follows.
+func (uc *scheduleUseCase) RetrieveMySchedule(ctx context.Context, employee entity.Employee) (entity.WorkSchedule, error) {
+	config, err := uc.configRepo.GetConfiguration(ctx)
+	if err != nil {
+		return entity.WorkSchedule{}, NewRepositoryError("Config", err)
+	}
+
+	return getEffectiveWorkSchedule(ctx, uc.scheduleRepo, config, employee.Id)
+}
+
+/*
+*********************************
+ACTOR: HR
+*********************************
+*/
+
+func (uc *scheduleUseCase) RetrieveWorkSchedules(ctx context.Context) ([]entity.WorkSchedule, error) {
+	schedules, err := uc.scheduleRepo.GetWorkSchedules(ctx)
+	if err != nil {
+		return nil, NewRepositoryError("Schedule", err)
+	}
+
+	return schedules, nil
+}
+
+func (uc *scheduleUseCase) RetrieveWorkSchedule(ctx context.Context, id string) (entity.WorkSchedule, error) {
+	schedule, err := uc.scheduleRepo.GetWorkScheduleById(ctx, id)
+	if err != nil {
+		return schedule, NewNotFoundError("Schedule", err)
+	}
+
+	return schedule, nil
+}
+
+// RegisterWorkSchedule creates a new work schedule. It is not
+// followed by anyone until it is assigned to employees or jobs.
+func (uc *scheduleUseCase) RegisterWorkSchedule(ctx context.Context, payload entity.WorkSchedule) error {
+	if err := prepareWorkSchedule(&payload); err != nil {
+		return NewDomainError("Schedule", err)
+	}
+
+	if err := uc.scheduleRepo.CreateWorkSchedule(ctx, payload); err != nil {
+		return NewRepositoryError("Schedule", err)
+	}
+
+	return nil
+}
+
+// UpdateWorkSchedule replaces the cycle and the shifts of a work
+// schedule. The changes apply immediately to everyone following it.
+func (uc *scheduleUseCase) UpdateWorkSchedule(ctx context.Context, id string, payload entity.WorkSchedule) error {
+	schedule, err := uc.scheduleRepo.GetWorkScheduleById(ctx, id)
+	if err != nil {
+		return NewNotFoundError("Schedule", err)
+	}
+
+	schedule.Name = payload.Name
+	schedule.CycleStart = payload.CycleStart
+	schedule.CycleLength = payload.CycleLength
+	schedule.Shifts = payload.Shifts
+	if err := prepareWorkSchedule(&schedule); err != nil {
+		return NewDomainError("Schedule", err)
+	}
+
+	if err := uc.scheduleRepo.UpdateWorkSchedule(ctx, schedule); err != nil {
+		return NewRepositoryError("Schedule", err)
+	}
+
+	return nil
+}
+
+// RemoveWorkSchedule deletes a work schedule. Employees and jobs
+// following it go back to the default work schedule.
+func (uc *scheduleUseCase) RemoveWorkSchedule(ctx context.Context, id string) error {
+	if _, err := uc.scheduleRepo.GetWorkScheduleById(ctx, id); err != nil {
+		return NewNotFoundError("Schedule", err)
+	}
+
+	if err := uc.scheduleRepo.DeleteWorkSchedule(ctx, id); err != nil {
+		return NewRepositoryError("Schedule", err)
+	}
+
+	return nil
+}
+
+// AssignWorkSchedule assigns a work schedule to employees and jobs.
+// A nil scheduleId makes them follow the default work schedule.
+func (uc *scheduleUseCase) AssignWorkSchedule(ctx context.Context, scheduleId *string, employeeIds []string, jobIds []string) error {
+	if len(employeeIds) == 0 && len(jobIds) == 0 {
+		return NewClientError("Schedule", fmt.Errorf("at least one employee or job must be provided"))
+	}
+
+	if scheduleId != nil {
+		if _, err := uc.scheduleRepo.GetWorkScheduleById(ctx, *scheduleId); err != nil {
+			return NewNotFoundError("Schedule", err)
+		}
+	}
+
+	if err := uc.scheduleRepo.AssignWorkSchedule(ctx, scheduleId, employeeIds, jobIds); err != nil {
+		return NewRepositoryError("Schedule", err)
+	}
+
+	return nil
+}
+
+func (uc *scheduleUseCase) RetrieveHolidays(ctx context.Context, year int) ([]entity.Holiday, error) {
+	holidays, err := uc.scheduleRepo.GetHolidaysByYear(ctx, year)
+	if err != nil {
+		return nil, NewRepositoryError("Holiday", err)
+	}
+
+	return holidays, nil
+}
+
+// RegisterHoliday adds a holiday. There can only be one
+// holiday on a date.
+func (uc *scheduleUseCase) RegisterHoliday(ctx context.Context, payload entity.Holiday) error {
+	if err := payload.Validate(); err != nil {
+		return NewDomainError("Holiday", err)
+	}
+
+	holidays, err := uc.scheduleRepo.GetHolidays(ctx)
+	if err != nil {
+		return NewRepositoryError("Holiday", err)
+	}
+	for _, v := range holidays {
+		if v.DateKey() == payload.DateKey() {
+			return NewDomainError("Holiday", fmt.Errorf("there is already a holiday on %s", payload.DateKey()))
+		}
+	}
+
+	if err := uc.scheduleRepo.CreateHolidays(ctx, []entity.Holiday{payload}); err != nil {
+		return NewRepositoryError("Holiday", err)
+	}
+
+	return nil
+}
+
+func (uc *scheduleUseCase) UpdateHoliday(ctx context.Context, id string, payload entity.Holiday) error {
+	holiday, err := uc.scheduleRepo.GetHolidayById(ctx, id)
+	if err != nil {
+		return NewNotFoundError("Holiday", err)
+	}
+
+	holiday.Name = payload.Name
+	holiday.Date = payload.Date
+	if err := holiday.Validate(); err != nil {
+		return NewDomainError("Holiday", err)
+	}
+
+	holidays, err := uc.scheduleRepo.GetHolidays(ctx)
+	if err != nil {
+		return NewRepositoryError("Holiday", err)
+	}
+	for _, v := range holidays {
+		if v.Id != holiday.Id && v.DateKey() == holiday.DateKey() {
+			return NewDomainError("Holiday", fmt.Errorf("there is already a holiday on %s", holiday.DateKey()))
+		}
+	}
+
+	if err := uc.scheduleRepo.UpdateHoliday(ctx, holiday); err != nil {
+		return NewRepositoryError("Holiday", err)
+	}
+
+	return nil
+}
+
+func (uc *scheduleUseCase) RemoveHoliday(ctx context.Context, id string) error {
+	if _, err := uc.scheduleRepo.GetHolidayById(ctx, id); err != nil {
+		return NewNotFoundError("Holiday", err)
+	}
+
+	if err := uc.scheduleRepo.DeleteHoliday(ctx, id); err != nil {
+		return NewRepositoryError("Holiday", err)
+	}
+
+	return nil
+}
+
+// ImportHolidays registers the holidays of an imported calendar.
+// Holidays on a date that already has a holiday are skipped. It
+// returns the number of holidays registered.
+func (uc *scheduleUseCase) ImportHolidays(ctx context.Context, payload []entity.Holiday) (int, error) {
+	if len(payload) == 0 {
+		return 0, NewClientError("Holiday", fmt.Errorf("the calendar does not contain any holiday"))
+	}
+
+	holidays, err := uc.scheduleRepo.GetHolidays(ctx)
+	if err != nil {
+		return 0, NewRepositoryError("Holiday", err)
+	}
+
+	dates := make(map[string]bool)
+	for _, v := range holidays {
+		dates[v.DateKey()] = true
+	}
+
+	var imports []entity.Holiday
+	for _, v := range payload {
+		if err := v.Validate(); err != nil {
+			return 0, NewDomainError("Holiday", fmt.Errorf("%s: %w", v.DateKey(), err))
+		}
+
+		if dates[v.DateKey()] {
+			continue
+		}
+		dates[v.DateKey()] = true
+		imports = append(imports, v)
+	}
+
+	if len(imports) == 0 {
+		return 0, nil
+	}
+
+	if err := uc.scheduleRepo.CreateHolidays(ctx, imports); err != nil {
+		return 0, NewRepositoryError("Holiday", err)
+	}
+
+	return len(imports), nil
+}
+
+/*
+*************************************************
+UTILS
+*************************************************
+*/
+
+// prepareWorkSchedule validates the work schedule and its shifts,
+// then sets the shifts' start and end time from their hour and
+// minute.
+func prepareWorkSchedule(schedule *entity.WorkSchedule) error {
+	for i := range schedule.Shifts {
+		shift := &schedule.Shifts[i]
+		if err := shift.Validate(); err != nil {
+			return err
+		}
+
+		shift.StartTime = time.Date(2000, time.January, 1, shift.StartTimeHour, shift.StartTimeMinute, 0, 0, utils.CURRENT_LOC)
+		shift.EndTime = time.Date(2000, time.January, 1, shift.EndTimeHour, shift.EndTimeMinute, 0, 0, utils.CURRENT_LOC)
+	}
+
+	schedule.CycleStart = time.Date(schedule.CycleStart.Year(), schedule.CycleStart.Month(), schedule.CycleStart.Day(), 0, 0, 0, 0, utils.CURRENT_LOC)
+
+	return schedule.Validate()
+}
+
+// getEffectiveWorkSchedule returns the work schedule followed by
+// the employee. It is the employee's own work schedule, or else
+// the work schedule of its job, or else the company's default.
+// The returned schedule observes the holidays.
+func getEffectiveWorkSchedule(ctx context.Context, scheduleRepo repo.IScheduleRepo, config entity.Configuration, employeeId string) (entity.WorkSchedule, error) {
+	schedule, err := scheduleRepo.GetEmployeeWorkSchedule(ctx, employeeId)
+	if err != nil {
+		return entity.WorkSchedule{}, NewRepositoryError("Schedule", err)
+	}
+
+	if schedule == nil {
+		defaultSchedule := config.DefaultWorkSchedule()
+		schedule = &defaultSchedule
+	}
+
+	holidays, err := scheduleRepo.GetHolidays(ctx)
+	if err != nil {
+		return entity.WorkSchedule{}, NewRepositoryError("Holiday", err)
+	}
+
+	return schedule.WithHolidays(holidays), nil
+}
@@ -0,0 +1,374 @@
+package usecase
+
+// This usecase runs the jobs of the scheduler. The jobs are run on
+// their schedules by the leader replica, or right away by HR, and
+// each run is recorded so HR can observe what the jobs did.
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"sinarlog.com/internal/app/repo"
+	"sinarlog.com/internal/app/service"
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/entity/vo"
+	"sinarlog.com/internal/utils"
+)
+
+const (
+	JOB_APPLY_CONFIG_CHANGES = "apply-config-changes"
+	JOB_CLOSE_ATTENDANCES    = "close-attendances"
+	JOB_EXPIRE_PROPOSALS     = "expire-proposals"
+	JOB_ACCRUE_LEAVES        = "accrue-leaves"
+	JOB_SYNC_TEAM_ROOMS      = "sync-team-rooms"
+)
+
+// A job holds its lock at most this long, in case its replica dies
+// while running it
+const jobLockDuration = time.Hour
+
+// An attendance is left open this long after its shift ends, so an
+// employee working overtime can still clock out
+const attendanceCloseGrace = 4 * time.Hour
+
+type schedulerJob struct {
+	name        string
+	description string
+	schedule    vo.JobSchedule
+	// run returns the number of records changed and a summary
+	run func(ctx context.Context, at time.Time) (int, string, error)
+}
+
+type schedulerUseCase struct {
+	schedulerService service.ISchedulerService
+	schedulerRepo    repo.ISchedulerRepo
+	attRepo          repo.IAttendanceRepo
+	scheduleRepo     repo.IScheduleRepo
+	leaveRepo        repo.ILeaveRepo
+	configRepo       repo.IConfigRepo
+	accrualUC        ILeaveAccrualUseCase
+	chatUC           IChatUseCase
+}
+
+func NewSchedulerUseCase(
+	schedulerService service.ISchedulerService,
+	schedulerRepo repo.ISchedulerRepo,
+	attRepo repo.IAttendanceRepo,
+	scheduleRepo repo.IScheduleRepo,
+	leaveRepo repo.ILeaveRepo,
+	configRepo repo.IConfigRepo,
+	accrualUC ILeaveAccrualUseCase,
+	chatUC IChatUseCase,
+) *schedulerUseCase {
+	return &schedulerUseCase{
+		schedulerService: schedulerService,
+		schedulerRepo:    schedulerRepo,
+		attRepo:          attRepo,
+		scheduleRepo:     scheduleRepo,
+		leaveRepo:        leaveRepo,
+		configRepo:       configRepo,
+		accrualUC:        accrualUC,
+		chatUC:           chatUC,
+	}
+}
+
+// jobs are the jobs of the scheduler. They run right after midnight,
+// starting with the configuration since the other jobs depend on it,
+// except for closing the attendances which follows the shifts.
+func (uc *schedulerUseCase) jobs() []schedulerJob {
+	return []schedulerJob{
+		{
+			name:        JOB_APPLY_CONFIG_CHANGES,
+			description: "Applies the configuration changes once they take effect",
+			schedule:    vo.DailyAt(0, 0),
+			run:         uc.applyConfigChanges,
+		},
+		{
+			name:        JOB_CLOSE_ATTENDANCES,
+			description: "Closes the attendances left open well after their shift has ended",
+			schedule:    vo.HourlyAt(5),
+			run:         uc.closeStaleAttendances,
+		},
+		{
+			name:        JOB_EXPIRE_PROPOSALS,
+			description: "Closes the leaves not decided before they start and the overtimes not decided within their week",
+			schedule:    vo.DailyAt(0, 10),
+			run:         uc.expireStaleProposals,
+		},
+		{
+			name:        JOB_ACCRUE_LEAVES,
+			description: "Accrues, carries over, resets and expires the leave quotas",
+			schedule:    vo.DailyAt(0, 30),
+			run:         uc.accrueLeaves,
+		},
+		{
+			name:        JOB_SYNC_TEAM_ROOMS,
+			description: "Creates the missing team chat rooms and brings their members up to date",
+			schedule:    vo.DailyAt(1, 0),
+			run:         uc.syncTeamRooms,
+		},
+	}
+}
+
+func (uc *schedulerUseCase) findJob(name string) (schedulerJob, bool) {
+	for _, job := range uc.jobs() {
+		if job.name == name {
+			return job, true
+		}
+	}
+
+	return schedulerJob{}, false
+}
+
+// RegisterJobs registers the jobs to the scheduler so they run on
+// their schedules.
+func (uc *schedulerUseCase) RegisterJobs() {
+	for _, job := range uc.jobs() {
+		job := job
+		uc.schedulerService.RegisterJob(job.name, job.schedule, func(ctx context.Context) error {
+			unlock, ok, err := uc.schedulerService.LockJob(ctx, job.name, jobLockDuration)
+			if err != nil {
+				return err
+			}
+			// HR is running the job right now
+			if !ok {
+				log.Printf("job %s is skipped since it is already running\n", job.name)
+				return nil
+			}
+			defer unlock()
+
+			return uc.runJob(ctx, job, entity.JOB_SCHEDULED, nil)
+		})
+	}
+}
+
+/*
+*********************************
+ACTOR: HR
+*********************************
+*/
+
+// RetrieveScheduledJobs returns the jobs with their schedules and
+// their latest runs.
+func (uc *schedulerUseCase) RetrieveScheduledJobs(ctx context.Context) ([]vo.ScheduledJobStatus, error) {
+	scheduled, err := uc.schedulerService.GetScheduledJobs(ctx)
+	if err != nil {
+		return nil, NewServiceError("Scheduler", err)
+	}
+
+	runs, err := uc.schedulerRepo.GetLatestJobRuns(ctx)
+	if err != nil {
+		return nil, NewRepositoryError("Scheduler", err)
+	}
+
+	var jobs []vo.ScheduledJobStatus
+	for _, job := range uc.jobs() {
+		status := vo.ScheduledJobStatus{
+			Name:        job.name,
+			Description: job.description,
+			NextRunAt:   job.schedule(time.Now().In(utils.CURRENT_LOC)),
+		}
+
+		for _, v := range scheduled {
+			if v.Name == job.name {
+				status.NextRunAt = v.NextRunAt
+			}
+		}
+
+		for i := range runs {
+			if runs[i].JobName == job.name {
+				status.LatestRun = &runs[i]
+			}
+		}
+
+		jobs = append(jobs, status)
+	}
+
+	return jobs, nil
+}
+
+func (uc *schedulerUseCase) RetrieveJobRuns(ctx context.Context, name string, q vo.CommonQuery) ([]entity.SchedulerJobRun, vo.PaginationDTOResponse, error) {
+	if _, ok := uc.findJob(name); !ok {
+		return nil, vo.PaginationDTOResponse{}, NewNotFoundError("Scheduler", fmt.Errorf("job %s does not exist", name))
+	}
+
+	q.Pagination.Order = "started_at"
+	q.Pagination.Sort = "DESC"
+
+	runs, page, err := uc.schedulerRepo.GetJobRuns(ctx, name, q)
+	if err != nil {
+		return nil, page, NewRepositoryError("Scheduler", err)
+	}
+
+	return runs, page, nil
+}
+
+// TriggerJob runs the job right away in the background. It fails if
+// the job is already running on any replica.
+func (uc *schedulerUseCase) TriggerJob(ctx context.Context, hr entity.Employee, name string) error {
+	job, ok := uc.findJob(name)
+	if !ok {
+		return NewNotFoundError("Scheduler", fmt.Errorf("job %s does not exist", name))
+	}
+
+	unlock, ok, err := uc.schedulerService.LockJob(ctx, job.name, jobLockDuration)
+	if err != nil {
+		return NewServiceError("Scheduler", err)
+	}
+	if !ok {
+		return NewConflictError("Scheduler", fmt.Errorf("job %s is already running", name))
+	}
+
+	go func() {
+		defer unlock()
+
+		if err := uc.runJob(context.Background(), job, entity.JOB_MANUAL, &hr.Id); err != nil {
+			log.Printf("job %s triggered by %s failed: %s\n", job.name, hr.Id, err)
+		}
+	}()
+
+	return nil
+}
+
+/*
+*************************************************
+JOBS
+*************************************************
+*/
+
+// runJob runs the job and records the run. The job must be locked.
+func (uc *schedulerUseCase) runJob(ctx context.Context, job schedulerJob, trigger entity.JobTrigger, triggeredById *string) error {
+	now := time.Now().In(utils.CURRENT_LOC)
+
+	run, err := uc.schedulerRepo.CreateJobRun(ctx, entity.SchedulerJobRun{
+		JobName:       job.name,
+		Trigger:       trigger,
+		TriggeredByID: triggeredById,
+		Status:        entity.JOB_RUNNING,
+		StartedAt:     now,
+	})
+	if err != nil {
+		return err
+	}
+
+	affected, summary, jobErr := job.run(ctx, now)
+	run.Finish(affected, summary, jobErr, time.Now().In(utils.CURRENT_LOC))
+
+	if err := uc.schedulerRepo.SaveJobRun(ctx, run); err != nil {
+		return err
+	}
+
+	return jobErr
+}
+
+func (uc *schedulerUseCase) applyConfigChanges(ctx context.Context, at time.Time) (int, string, error) {
+	var applied []entity.ConfigChangeScope
+
+	for _, scope := range []entity.ConfigChangeScope{entity.CONFIG_NEXT_DAY, entity.CONFIG_NEXT_MONTH} {
+		ok, err := uc.configRepo.ApplyConfigChanges(ctx, scope, at)
+		if ok {
+			applied = append(applied, scope)
+		}
+		if err != nil {
+			return len(applied), fmt.Sprintf("applied %v", applied), err
+		}
+	}
+
+	if len(applied) == 0 {
+		return 0, "no configuration change is due", nil
+	}
+
+	return len(applied), fmt.Sprintf("applied %v", applied), nil
+}
+
+// closeStaleAttendances closes the attendances never clocked out once
+// their shift, overnight ones included, has ended a grace period ago.
+// An attendance on a day off is closed a grace period after the day.
+// An attendance whose work schedule cannot be found is skipped until
+// the next run, rather than holding up the others.
+func (uc *schedulerUseCase) closeStaleAttendances(ctx context.Context, at time.Time) (int, string, error) {
+	attendances, err := uc.attRepo.GetOpenAttendances(ctx, at.Add(-attendanceCloseGrace))
+	if err != nil {
+		return 0, "", err
+	}
+
+	config, err := uc.configRepo.GetConfiguration(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+
+	var stale []string
+	var skipped int
+	schedules := make(map[string]entity.WorkSchedule)
+	unscheduled := make(map[string]bool)
+	for _, v := range attendances {
+		if unscheduled[v.EmployeeID] {
+			skipped++
+			continue
+		}
+
+		schedule, ok := schedules[v.EmployeeID]
+		if !ok {
+			schedule, err = getEffectiveWorkSchedule(ctx, uc.scheduleRepo, config, v.EmployeeID)
+			if err != nil {
+				log.Printf("attendance %s is left open since the work schedule of %s cannot be found: %s\n", v.Id, v.EmployeeID, err)
+				unscheduled[v.EmployeeID] = true
+				skipped++
+				continue
+			}
+			schedules[v.EmployeeID] = schedule
+		}
+
+		clockIn := v.ClockInAt.In(utils.CURRENT_LOC)
+		end := time.Date(clockIn.Year(), clockIn.Month(), clockIn.Day()+1, 0, 0, 0, 0, utils.CURRENT_LOC)
+		if shift, ok := schedule.ShiftAt(clockIn); ok {
+			end = shift.End
+		}
+
+		if !at.Before(end.Add(attendanceCloseGrace)) {
+			stale = append(stale, v.Id)
+		}
+	}
+
+	closed, err := uc.attRepo.CloseAttendances(ctx, stale)
+	if err != nil {
+		return 0, "", err
+	}
+
+	if skipped > 0 {
+		return int(closed), fmt.Sprintf("closed %d attendances, skipped %d without a work schedule", closed, skipped), nil
+	}
+
+	return int(closed), fmt.Sprintf("closed %d attendances", closed), nil
+}
+
+// expireStaleProposals closes the leaves still waiting for a decision
+// once they start, and the overtimes still waiting for a decision
+// once their week is over since the overtime limit is weekly.
+func (uc *schedulerUseCase) expireStaleProposals(ctx context.Context, at time.Time) (int, string, error) {
+	leaves, err := uc.leaveRepo.CloseStaleLeaveProposals(ctx, at)
+	if err != nil {
+		return 0, "", err
+	}
+
+	overtimes, err := uc.attRepo.CloseStaleOvertimeSubmissions(ctx, utils.GetStartOfTheWeekFromDate(at))
+	if err != nil {
+		return len(leaves), fmt.Sprintf("closed %d leaves", len(leaves)), err
+	}
+
+	return len(leaves) + int(overtimes), fmt.Sprintf("closed %d leaves and %d overtimes", len(leaves), overtimes), nil
+}
+
+func (uc *schedulerUseCase) accrueLeaves(ctx context.Context, at time.Time) (int, string, error) {
+	report, err := uc.accrualUC.AccrueLeaveQuotas(ctx, at)
+
+	return report.Quotas, fmt.Sprintf("changed %d quotas with %d ledger entries", report.Quotas, report.Entries), err
+}
+
+func (uc *schedulerUseCase) syncTeamRooms(ctx context.Context, at time.Time) (int, string, error) {
+	managers, err := uc.chatUC.SyncAllTeamRooms(ctx)
+
+	return managers, fmt.Sprintf("synced the team rooms of %d managers", managers), err
+}
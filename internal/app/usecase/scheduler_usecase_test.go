@@ -0,0 +1,211 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"sinarlog.com/internal/app/repo"
+	"sinarlog.com/internal/app/service"
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/entity/vo"
+	"sinarlog.com/internal/utils"
+)
+
+// fakeAttendanceRepo keeps the open attendances in memory.
+type fakeAttendanceRepo struct {
+	repo.IAttendanceRepo
+	open   []entity.Attendance
+	closed []string
+}
+
+func (r *fakeAttendanceRepo) GetOpenAttendances(ctx context.Context, before time.Time) ([]entity.Attendance, error) {
+	return r.open, nil
+}
+
+func (r *fakeAttendanceRepo) CloseAttendances(ctx context.Context, ids []string) (int64, error) {
+	r.closed = append(r.closed, ids...)
+	return int64(len(ids)), nil
+}
+
+// fakeConfigRepo has the office hours from 09:00 to 17:00.
+type fakeConfigRepo struct {
+	repo.IConfigRepo
+}
+
+func (fakeConfigRepo) GetConfiguration(ctx context.Context) (entity.Configuration, error) {
+	return entity.Configuration{
+		OfficeStartTime: time.Date(2000, time.January, 1, 9, 0, 0, 0, utils.CURRENT_LOC),
+		OfficeEndTime:   time.Date(2000, time.January, 1, 17, 0, 0, 0, utils.CURRENT_LOC),
+	}, nil
+}
+
+// fakeScheduleRepo gives every employee the default work schedule,
+// except the ones whose work schedule cannot be found.
+type fakeScheduleRepo struct {
+	repo.IScheduleRepo
+	missing map[string]bool
+	lookups map[string]int
+}
+
+func (r *fakeScheduleRepo) GetEmployeeWorkSchedule(ctx context.Context, employeeId string) (*entity.WorkSchedule, error) {
+	r.lookups[employeeId]++
+	if r.missing[employeeId] {
+		return nil, fmt.Errorf("connection reset")
+	}
+	return nil, nil
+}
+
+func (r *fakeScheduleRepo) GetHolidays(ctx context.Context) ([]entity.Holiday, error) {
+	return nil, nil
+}
+
+// fakeSchedulerService keeps the registered jobs and the locks.
+type fakeSchedulerService struct {
+	service.ISchedulerService
+	jobs     map[string]func(ctx context.Context) error
+	locked   map[string]bool
+	unlocked chan string
+}
+
+func (s *fakeSchedulerService) RegisterJob(name string, schedule vo.JobSchedule, run func(ctx context.Context) error) {
+	s.jobs[name] = run
+}
+
+func (s *fakeSchedulerService) LockJob(ctx context.Context, name string, ttl time.Duration) (func(), bool, error) {
+	if s.locked[name] {
+		return nil, false, nil
+	}
+	s.locked[name] = true
+
+	return func() {
+		s.locked[name] = false
+		s.unlocked <- name
+	}, true, nil
+}
+
+// fakeSchedulerRepo records the runs of the jobs.
+type fakeSchedulerRepo struct {
+	repo.ISchedulerRepo
+	runs []entity.SchedulerJobRun
+}
+
+func (r *fakeSchedulerRepo) CreateJobRun(ctx context.Context, run entity.SchedulerJobRun) (entity.SchedulerJobRun, error) {
+	return run, nil
+}
+
+func (r *fakeSchedulerRepo) SaveJobRun(ctx context.Context, run entity.SchedulerJobRun) error {
+	r.runs = append(r.runs, run)
+	return nil
+}
+
+func testSchedulerUseCase(open ...entity.Attendance) (*schedulerUseCase, *fakeSchedulerService, *fakeSchedulerRepo, *fakeAttendanceRepo, *fakeScheduleRepo) {
+	schService := &fakeSchedulerService{
+		jobs:     make(map[string]func(ctx context.Context) error),
+		locked:   make(map[string]bool),
+		unlocked: make(chan string, 1),
+	}
+	schRepo := &fakeSchedulerRepo{}
+	attRepo := &fakeAttendanceRepo{open: open}
+	scheduleRepo := &fakeScheduleRepo{missing: map[string]bool{"b": true}, lookups: make(map[string]int)}
+
+	uc := NewSchedulerUseCase(schService, schRepo, attRepo, scheduleRepo, nil, fakeConfigRepo{}, nil, nil)
+	return uc, schService, schRepo, attRepo, scheduleRepo
+}
+
+func openAttendance(id, employeeId string, clockInAt time.Time) entity.Attendance {
+	return entity.Attendance{BaseModelId: entity.BaseModelId{Id: id}, EmployeeID: employeeId, ClockInAt: clockInAt}
+}
+
+func TestClosingStaleAttendances(t *testing.T) {
+	clock := func(day, hour int) time.Time {
+		return time.Date(2024, time.April, day, hour, 0, 0, 0, utils.CURRENT_LOC)
+	}
+
+	uc, _, _, attRepo, scheduleRepo := testSchedulerUseCase(
+		// Friday, its shift ended at 17:00
+		openAttendance("friday", "a", clock(12, 9)),
+		// The work schedule of b cannot be found
+		openAttendance("unscheduled", "b", clock(12, 9)),
+		openAttendance("unscheduled again", "b", clock(12, 10)),
+		// Saturday is a day off, which is closed at 04:00 on Sunday
+		openAttendance("saturday", "c", clock(13, 10)),
+	)
+
+	// Sunday, 14th April 2024 at 03:00
+	closed, summary, err := uc.closeStaleAttendances(context.Background(), clock(14, 3))
+	if err != nil {
+		t.Fatalf("expected the other attendances to be closed, got %s", err)
+	}
+
+	if closed != 1 || !reflect.DeepEqual(attRepo.closed, []string{"friday"}) {
+		t.Fatalf("expected only the attendance of friday to be closed, got %v", attRepo.closed)
+	}
+	if summary != "closed 1 attendances, skipped 2 without a work schedule" {
+		t.Fatalf("expected the skipped attendances to be summarized, got %q", summary)
+	}
+	if scheduleRepo.lookups["b"] != 1 {
+		t.Fatalf("expected the missing work schedule to be looked up once, got %d", scheduleRepo.lookups["b"])
+	}
+}
+
+func TestScheduledJobsSkipALockedJob(t *testing.T) {
+	uc, schService, schRepo, _, _ := testSchedulerUseCase()
+	uc.RegisterJobs()
+
+	for _, job := range uc.jobs() {
+		if _, ok := schService.jobs[job.name]; !ok {
+			t.Fatalf("expected job %s to be registered", job.name)
+		}
+	}
+	run := schService.jobs[JOB_CLOSE_ATTENDANCES]
+
+	// HR is running the job right now
+	schService.locked[JOB_CLOSE_ATTENDANCES] = true
+	if err := run(context.Background()); err != nil {
+		t.Fatalf("expected the locked job to be skipped, got %s", err)
+	}
+	if len(schRepo.runs) != 0 {
+		t.Fatalf("expected the locked job not to run, got %v", schRepo.runs)
+	}
+
+	schService.locked[JOB_CLOSE_ATTENDANCES] = false
+	if err := run(context.Background()); err != nil {
+		t.Fatalf("expected the job to run, got %s", err)
+	}
+	if len(schRepo.runs) != 1 || schRepo.runs[0].Status != entity.JOB_SUCCEEDED || schRepo.runs[0].Trigger != entity.JOB_SCHEDULED {
+		t.Fatalf("expected a scheduled run to be recorded, got %v", schRepo.runs)
+	}
+	if schService.locked[JOB_CLOSE_ATTENDANCES] {
+		t.Fatalf("expected the job to be unlocked once run")
+	}
+}
+
+func TestTriggeringJobs(t *testing.T) {
+	uc, schService, schRepo, _, _ := testSchedulerUseCase()
+	hr := entity.Employee{BaseModelId: entity.BaseModelId{Id: "hr"}}
+
+	if err := uc.TriggerJob(context.Background(), hr, "missing"); errorCode(err) != 404 {
+		t.Fatalf("expected a missing job to be not found, got %v", err)
+	}
+
+	schService.locked[JOB_CLOSE_ATTENDANCES] = true
+	if err := uc.TriggerJob(context.Background(), hr, JOB_CLOSE_ATTENDANCES); errorCode(err) != 409 {
+		t.Fatalf("expected a running job not to be triggered again, got %v", err)
+	}
+
+	schService.locked[JOB_CLOSE_ATTENDANCES] = false
+	if err := uc.TriggerJob(context.Background(), hr, JOB_CLOSE_ATTENDANCES); err != nil {
+		t.Fatalf("expected the job to be triggered, got %s", err)
+	}
+	select {
+	case <-schService.unlocked:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the triggered job to finish")
+	}
+	if len(schRepo.runs) != 1 || schRepo.runs[0].Trigger != entity.JOB_MANUAL || *schRepo.runs[0].TriggeredByID != "hr" {
+		t.Fatalf("expected a manual run by hr to be recorded, got %v", schRepo.runs)
+	}
+}
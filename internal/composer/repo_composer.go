@@ -22,6 +22,9 @@ type IRepoComposer interface {
 	LeaveRepo() repo.ILeaveRepo
 	AnalyticsRepo() repo.IAnalyticsRepo
 	ChatRepo() repo.IChatRepo
+	ScheduleRepo() repo.IScheduleRepo
+	ApprovalRepo() repo.IApprovalRepo
+	SchedulerRepo() repo.ISchedulerRepo
 
 	Migrate()
 }
@@ -90,15 +93,31 @@ func (c *repoComposer) ChatRepo() repo.IChatRepo {
 	return impl.NewChatRepo(c.mongo.Conn)
 }
 
+func (c *repoComposer) ScheduleRepo() repo.IScheduleRepo {
+	return impl.NewScheduleRepo(c.db.ORM)
+}
+
+func (c *repoComposer) ApprovalRepo() repo.IApprovalRepo {
+	return impl.NewApprovalRepo(c.db.ORM)
+}
+
+func (c *repoComposer) SchedulerRepo() repo.ISchedulerRepo {
+	return impl.NewSchedulerRepo(c.db.ORM)
+}
+
 // -------------- Setups --------------
 func (c *repoComposer) setToDebug() {
 	c.db.ORM = c.db.ORM.Debug()
 }
 
 func (c *repoComposer) Migrate() {
-	c.db.ORM.AutoMigrate(
-		impl.GetAllRelationalEntities()...,
-	)
+	if err := impl.MigrateRelationalEntities(c.db.ORM); err != nil {
+		log.Fatalf("Unable to migrate the database: %s", err)
+	}
+
+	if err := impl.MigrateDocumentIndexes(context.Background(), c.mongo.Conn); err != nil {
+		log.Fatalf("Unable to migrate the document indexes: %s", err)
+	}
 }
 
 func (c *repoComposer) Seed() {
@@ -9,6 +9,7 @@ import (
 	"sinarlog.com/pkg/pubsub"
 	"sinarlog.com/pkg/rater"
 	"sinarlog.com/pkg/redis"
+	"sinarlog.com/pkg/scheduler"
 )
 
 type IServiceComposer interface {
@@ -18,6 +19,8 @@ type IServiceComposer interface {
 	BucketService() service.IBucketService
 	NotifService() service.INotifService
 	PubSubService() service.IPubSubService
+	ChatSignalService() service.IChatSignalService
+	SchedulerService() service.ISchedulerService
 }
 
 type serviceComposer struct {
@@ -27,6 +30,7 @@ type serviceComposer struct {
 	bkt  *bucket.Bucket
 	rdis *redis.RedisClient
 	ps   *pubsub.PubSub
+	sch  *scheduler.Scheduler
 }
 
 func NewServiceComposer(
@@ -36,6 +40,7 @@ func NewServiceComposer(
 	bkt *bucket.Bucket,
 	rdis *redis.RedisClient,
 	ps *pubsub.PubSub,
+	sch *scheduler.Scheduler,
 ) IServiceComposer {
 	s := &serviceComposer{
 		dk:   dk,
@@ -44,6 +49,7 @@ func NewServiceComposer(
 		bkt:  bkt,
 		rdis: rdis,
 		ps:   ps,
+		sch:  sch,
 	}
 
 	return s
@@ -69,6 +75,20 @@ func (s *serviceComposer) NotifService() service.INotifService {
 	return impl.NewNotifService(s.rdis.Client)
 }
 
+// PubSubService returns the Redis Streams backend unless a Google
+// Cloud Pub/Sub client is given.
 func (s *serviceComposer) PubSubService() service.IPubSubService {
+	if s.ps == nil {
+		return impl.NewRedisPubSubService(s.rdis.Client)
+	}
+
 	return impl.NewPubSubService(s.ps.Client)
 }
+
+func (s *serviceComposer) ChatSignalService() service.IChatSignalService {
+	return impl.NewChatSignalService(s.rdis.Client)
+}
+
+func (s *serviceComposer) SchedulerService() service.ISchedulerService {
+	return impl.NewSchedulerService(s.sch)
+}
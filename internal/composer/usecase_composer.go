@@ -14,8 +14,12 @@ type IUseCaseComposer interface {
 	EmployeeUseCase() usecase.IEmployeeUseCase
 	AttendanceUseCase() usecase.IAttendanceUseCase
 	LeaveUseCase() usecase.ILeaveUseCase
+	LeaveAccrualUseCase() usecase.ILeaveAccrualUseCase
+	SchedulerUseCase() usecase.ISchedulerUseCase
+	ApprovalUseCase() usecase.IApprovalUseCase
 	AnalyticsUseCase() usecase.IAnalyticsUseCase
 	ChatUseCase() usecase.IChatUseCase
+	ScheduleUseCase() usecase.IScheduleUseCase
 }
 
 type useCaseComposer struct {
@@ -43,11 +47,13 @@ func (c *useCaseComposer) EmployeeUseCase() usecase.IEmployeeUseCase {
 	return usecase.NewEmployeeUseCase(
 		c.repo.EmployeeRepo(),
 		c.repo.ConfigRepo(),
+		c.repo.LeaveRepo(),
 		c.repo.SharedRepo(),
 		c.repo.CredentialRepo(),
 		c.service.DoorkeeperService(),
 		c.service.MailerService(),
 		c.service.BucketService(),
+		c.ChatUseCase(),
 	)
 }
 
@@ -63,8 +69,11 @@ func (c *useCaseComposer) AttendanceUseCase() usecase.IAttendanceUseCase {
 	return usecase.NewAttendaceUseCase(
 		c.repo.AttendanceRepo(),
 		c.repo.LeaveRepo(),
+		c.repo.ApprovalRepo(),
 		c.repo.ConfigRepo(),
 		c.repo.EmployeeRepo(),
+		c.repo.ScheduleRepo(),
+		c.repo.CredentialRepo(),
 		c.service.DoorkeeperService(),
 		c.service.MailerService(),
 		c.service.NotifService(),
@@ -74,18 +83,45 @@ func (c *useCaseComposer) AttendanceUseCase() usecase.IAttendanceUseCase {
 func (c *useCaseComposer) LeaveUseCase() usecase.ILeaveUseCase {
 	return usecase.NewLeaveUseCase(
 		c.repo.LeaveRepo(),
+		c.repo.ApprovalRepo(),
 		c.repo.EmployeeRepo(),
 		c.repo.ConfigRepo(),
+		c.repo.ScheduleRepo(),
 		c.service.MailerService(),
 		c.service.NotifService(),
 		c.service.BucketService(),
 	)
 }
 
+func (c *useCaseComposer) LeaveAccrualUseCase() usecase.ILeaveAccrualUseCase {
+	return usecase.NewLeaveAccrualUseCase(c.repo.LeaveRepo())
+}
+
+func (c *useCaseComposer) SchedulerUseCase() usecase.ISchedulerUseCase {
+	return usecase.NewSchedulerUseCase(
+		c.service.SchedulerService(),
+		c.repo.SchedulerRepo(),
+		c.repo.AttendanceRepo(),
+		c.repo.ScheduleRepo(),
+		c.repo.LeaveRepo(),
+		c.repo.ConfigRepo(),
+		c.LeaveAccrualUseCase(),
+		c.ChatUseCase(),
+	)
+}
+
+func (c *useCaseComposer) ApprovalUseCase() usecase.IApprovalUseCase {
+	return usecase.NewApprovalUseCase(c.repo.ApprovalRepo(), c.repo.LeaveRepo(), c.repo.EmployeeRepo())
+}
+
 func (c *useCaseComposer) AnalyticsUseCase() usecase.IAnalyticsUseCase {
 	return usecase.NewAnalyticsUseCase(c.repo.AnalyticsRepo())
 }
 
 func (c *useCaseComposer) ChatUseCase() usecase.IChatUseCase {
-	return usecase.NewChatUseCase(c.repo.ChatRepo(), c.repo.EmployeeRepo(), c.service.PubSubService())
+	return usecase.NewChatUseCase(c.repo.ChatRepo(), c.repo.EmployeeRepo(), c.service.PubSubService(), c.service.ChatSignalService())
+}
+
+func (c *useCaseComposer) ScheduleUseCase() usecase.IScheduleUseCase {
+	return usecase.NewScheduleUseCase(c.repo.ScheduleRepo(), c.repo.ConfigRepo())
 }
@@ -6,13 +6,17 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"sinarlog.com/internal/app/usecase"
+	"sinarlog.com/internal/entity"
 )
 
 type AuthHeader struct {
 	Authorization string `header:"Authorization" binding:"required"`
 }
 
-func (m *Middleware) AuthMiddleware(uc usecase.ICredentialUseCase, roles ...any) gin.HandlerFunc {
+// AuthMiddleware lets through the employees whose role grants every
+// permission, within any scope. Without permissions, any employee
+// logged in is let through.
+func (m *Middleware) AuthMiddleware(uc usecase.ICredentialUseCase, perms ...entity.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var auth AuthHeader
 		if err := c.Copy().ShouldBindHeader(&auth); err != nil {
@@ -21,12 +25,31 @@ func (m *Middleware) AuthMiddleware(uc usecase.ICredentialUseCase, roles ...any)
 		}
 
 		auth.Authorization = strings.ReplaceAll(auth.Authorization, "Bearer ", "")
-		user, err := uc.Authorize(c.Request.Context(), auth.Authorization, roles...)
+		user, session, err := uc.Authorize(c.Request.Context(), auth.Authorization, perms...)
 		if err != nil {
+			if appError, ok := err.(usecase.AppError); ok && appError.Type == usecase.ErrForbidden {
+				m.Forbidden(c, err)
+				return
+			}
 			m.Unauthorized(c, err)
 			return
 		}
 
 		m.addToContext(c, "user", user)
+		m.addToContext(c, "session", session)
+	}
+}
+
+// PermissionMiddleware lets through the employees whose role grants
+// every permission within the scope or a wider one. It must follow
+// AuthMiddleware.
+func (m *Middleware) PermissionMiddleware(scope entity.PermissionScope, perms ...entity.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := c.Keys["user"].(entity.Employee)
+
+		if err := usecase.RequirePermissions(user, scope, perms...); err != nil {
+			m.Forbidden(c, err)
+			return
+		}
 	}
 }
@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -43,3 +46,61 @@ func LogRequestMiddleware(logger *logger.AppLogger) gin.HandlerFunc {
 		}
 	}
 }
+
+// redactedQueryParams are the query parameters that hold secrets, e.g.
+// the access token of a websocket, which must not end up in the logs.
+var redactedQueryParams = []string{"token"}
+
+// GinLoggerMiddleware logs the requests like gin.Logger, with the
+// secrets in their query redacted.
+func GinLoggerMiddleware() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		var statusColor, methodColor, resetColor string
+		if param.IsOutputColor() {
+			statusColor = param.StatusCodeColor()
+			methodColor = param.MethodColor()
+			resetColor = param.ResetColor()
+		}
+
+		if param.Latency > time.Minute {
+			param.Latency = param.Latency.Truncate(time.Second)
+		}
+
+		return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			statusColor, param.StatusCode, resetColor,
+			param.Latency,
+			param.ClientIP,
+			methodColor, param.Method, resetColor,
+			redactPath(param.Path),
+			param.ErrorMessage,
+		)
+	})
+}
+
+// redactPath replaces the secrets in the query of the path.
+func redactPath(path string) string {
+	base, rawQuery, found := strings.Cut(path, "?")
+	if !found {
+		return path
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		// Unable to tell the secrets apart, hence none of it is logged
+		return base + "?REDACTED"
+	}
+
+	redacted := false
+	for _, v := range redactedQueryParams {
+		if query.Has(v) {
+			query.Set(v, "REDACTED")
+			redacted = true
+		}
+	}
+	if !redacted {
+		return path
+	}
+
+	return base + "?" + query.Encode()
+}
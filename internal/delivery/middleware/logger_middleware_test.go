@@ -0,0 +1,24 @@
+package middleware
+
+import "testing"
+
+func TestRedactingLoggedPaths(t *testing.T) {
+	testCases := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"No query", "/api/v2/chat/rooms", "/api/v2/chat/rooms"},
+		{"No secret", "/api/v2/chat/rooms?page=2", "/api/v2/chat/rooms?page=2"},
+		{"Token", "/api/v2/chat/messenger/room?token=abc.def&lastSeen=64", "/api/v2/chat/messenger/room?lastSeen=64&token=REDACTED"},
+		{"Malformed query", "/api/v2/chat/messenger/room?token=abc;%zz", "/api/v2/chat/messenger/room?REDACTED"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := redactPath(tc.path); got != tc.want {
+				t.Fatalf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
@@ -2,24 +2,76 @@ package middleware
 
 import (
 	"fmt"
-	"net"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"sinarlog.com/internal/app/service"
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/entity/vo"
 )
 
-// Thanks to: https://blog.logrocket.com/rate-limiting-go-application/
-func (m *Middleware) RateLimiterMiddleware(service service.IRaterService) gin.HandlerFunc {
+// RateLimiterMiddleware limits the requests of each client by the
+// policy. An authenticated client is counted by its employee id,
+// hence it must come after the AuthMiddleware, otherwise by its ip.
+// The ip is only taken from X-Forwarded-For when the request comes
+// through one of the trusted proxies of the engine.
+func (m *Middleware) RateLimiterMiddleware(service service.IRaterService, policy vo.RatePolicy) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ip, _, err := net.SplitHostPort(c.Request.RemoteAddr)
-		if err != nil {
-			m.UnexpectedError(c, fmt.Errorf("unable to identify client ip"))
-			return
+		m.limitRate(c, service, policy)
+	}
+}
+
+// APIRateLimiterMiddleware limits the requests only reading data by
+// the relaxed read policy and the others by the write policy.
+func (m *Middleware) APIRateLimiterMiddleware(service service.IRaterService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			m.limitRate(c, service, vo.READ_RATE_POLICY)
+		default:
+			m.limitRate(c, service, vo.WRITE_RATE_POLICY)
 		}
-		if service.IsClientAllowed(c.Request.Context(), ip) {
-			c.Next()
-		} else {
-			m.TooManyRequest(c)
+	}
+}
+
+func (m *Middleware) limitRate(c *gin.Context, service service.IRaterService, policy vo.RatePolicy) {
+	key := "ip:" + c.ClientIP()
+	if user, ok := c.Keys["user"].(entity.Employee); ok {
+		key = "employee:" + user.Id
+	}
+
+	limit, err := service.Allow(c.Request.Context(), policy, key)
+	if err != nil {
+		log.Printf("unable to check the %s rate limit of %s: %s\n", policy.Name, key, err)
+
+		// The secrets guarded by a strict policy must not be guessed
+		// without limit, the rest of the API stays up without Redis
+		if policy.FailClosed {
+			m.ServiceUnavailable(c)
+			return
 		}
+		c.Next()
+		return
 	}
+
+	c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Period.Seconds())))
+	c.Header("RateLimit-Limit", strconv.Itoa(limit.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(limit.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(limit.ResetAfter)))
+
+	if !limit.Allowed {
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(limit.RetryAfter)))
+		m.TooManyRequest(c)
+		return
+	}
+
+	c.Next()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
 }
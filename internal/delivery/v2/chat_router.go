@@ -2,13 +2,25 @@ package v2
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
 	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
+	"sinarlog.com/internal/app/service"
 	"sinarlog.com/internal/app/usecase"
 	"sinarlog.com/internal/delivery/middleware"
+	"sinarlog.com/internal/delivery/v2/dto"
 	"sinarlog.com/internal/delivery/v2/dto/mapper"
 	"sinarlog.com/internal/delivery/v2/model"
 	"sinarlog.com/internal/entity"
@@ -22,26 +34,85 @@ type ChatController struct {
 	model.BaseControllerV2
 	chatUC usecase.IChatUseCase
 	emplUC usecase.IEmployeeUseCase
+	credUC usecase.ICredentialUseCase
 }
 
 type Chatter struct {
 	roomId     string
-	chatterId  string
-	conn       *websocket.Conn
-	mu         sync.Mutex
+	lastSeenId string
+	user       entity.Employee
+	token      string
+	// room is the room as last joined, which is joined again along
+	// with the token being checked, so the typing and read frames need
+	// not look it up
+	room atomic.Pointer[entity.Room]
+	// typing tells whether the chatter has last told the room they are
+	// typing
+	typing atomic.Bool
+	conn   *websocket.Conn
+	mu     sync.Mutex
+	// limiter limits the frames the chatter sends, each of which is a
+	// write to the database or a publish
+	limiter    *rate.Limiter
 	controller *ChatController
 }
 
-func NewChatController(rg *gin.RouterGroup, credUC usecase.ICredentialUseCase, emplUC usecase.IEmployeeUseCase, chatUC usecase.IChatUseCase) {
+const (
+	// socketProtocol is the subprotocol a browser, which cannot set the
+	// Authorization header of a websocket, offers along with its token
+	// as "Sec-WebSocket-Protocol: bearer, <token>"
+	socketProtocol = "bearer"
+	// socketFrameRate is how many frames a chatter may send per second
+	// on average, in bursts of up to socketFrameBurst
+	socketFrameRate  = 5
+	socketFrameBurst = 20
+)
+
+// socketAuthInterval is how often the token of a chatter and their
+// place in the room are checked again, so a revoked session or a
+// removed member does not keep chatting.
+var socketAuthInterval = time.Minute
+
+var chatUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	Subprotocols:    []string{socketProtocol},
+	// The token is not a cookie, hence any origin is fine
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+func NewChatController(rg *gin.RouterGroup, credUC usecase.ICredentialUseCase, emplUC usecase.IEmployeeUseCase, chatUC usecase.IChatUseCase, srv service.IRaterService) {
 	controller := new(ChatController)
 	controller.chatUC = chatUC
 	controller.emplUC = emplUC
+	controller.credUC = credUC
 
 	// Normal HTTP
-	rg.GET("/friends", middleware.NewMiddleware().AuthMiddleware(credUC, "hr", "mngr", "staff"), controller.getFriendsHandler)
-	rg.PUT("/room", middleware.NewMiddleware().AuthMiddleware(credUC, "hr", "mngr", "staff"), controller.openRoomChatHandler)
-	// Websockets
-	rg.GET("/messenger/:roomId/:userId", controller.chattingHandler)
+	auth := middleware.NewMiddleware().AuthMiddleware(credUC)
+	api := middleware.NewMiddleware().APIRateLimiterMiddleware(srv)
+	rg.GET("/friends", auth, api, controller.getFriendsHandler)
+	rg.PUT("/room", auth, api, controller.openRoomChatHandler)
+	rg.GET("/search", auth, api, controller.searchChatsHandler)
+	rooms := rg.Group("/rooms", auth, api)
+	{
+		rooms.GET("", controller.getRoomsHandler)
+		rooms.POST("", controller.createRoomHandler)
+		rooms.PUT("/:id", controller.updateRoomHandler)
+		rooms.GET("/:id/chats", controller.getChatsHandler)
+		rooms.GET("/:id/chats/:chatId/context", controller.getChatContextHandler)
+		rooms.GET("/:id/unread", controller.getUnreadCountHandler)
+		rooms.PUT("/:id/read", controller.readRoomHandler)
+		rooms.POST("/:id/members", controller.addRoomMembersHandler)
+		rooms.DELETE("/:id/members/:employeeId", controller.removeRoomMemberHandler)
+		rooms.PUT("/:id/admins/:employeeId", controller.setRoomAdminHandler(true))
+		rooms.DELETE("/:id/admins/:employeeId", controller.setRoomAdminHandler(false))
+	}
+	// Websockets, authenticated by the token in the query or the
+	// subprotocols since browsers cannot send headers. A chatter
+	// reconnecting gives the last chat it has seen as lastSeen. Every
+	// frame either way is a JSON envelope, see dto.ChatEventRequest and
+	// dto.ChatEventResponse.
+	rg.GET("/messenger/:roomId", controller.chattingHandler)
 }
 
 func (controller *ChatController) getFriendsHandler(c *gin.Context) {
@@ -80,6 +151,207 @@ func (controller *ChatController) openRoomChatHandler(c *gin.Context) {
 	controller.Ok(c, mapper.MapOpenChatResponse(room, chats))
 }
 
+func (controller *ChatController) getRoomsHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	res, err := controller.chatUC.ListRooms(c.Request.Context(), user)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapRoomSummariesResponse(res))
+}
+
+func (controller *ChatController) createRoomHandler(c *gin.Context) {
+	var req dto.RoomRequest
+
+	user := c.Keys["user"].(entity.Employee)
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	room, err := controller.chatUC.CreateGroupRoom(c.Request.Context(), user, mapper.MapRoomRequestToDomain(req), req.Members)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Created(c, mapper.MapRoomDomainToResponse(room))
+}
+
+func (controller *ChatController) updateRoomHandler(c *gin.Context) {
+	var req dto.RoomRequest
+
+	user := c.Keys["user"].(entity.Employee)
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	room, err := controller.chatUC.UpdateRoom(c.Request.Context(), user, c.Param("id"), mapper.MapRoomRequestToDomain(req))
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapRoomDomainToResponse(room))
+}
+
+func (controller *ChatController) addRoomMembersHandler(c *gin.Context) {
+	var req dto.RoomMembersRequest
+
+	user := c.Keys["user"].(entity.Employee)
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	if err := controller.chatUC.AddRoomMembers(c.Request.Context(), user, c.Param("id"), req.Members); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+func (controller *ChatController) removeRoomMemberHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	if err := controller.chatUC.RemoveRoomMember(c.Request.Context(), user, c.Param("id"), c.Param("employeeId")); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+// setRoomAdminHandler makes the member an admin of the room, or no
+// longer one.
+func (controller *ChatController) setRoomAdminHandler(admin bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := c.Keys["user"].(entity.Employee)
+
+		if err := controller.chatUC.SetRoomAdmin(c.Request.Context(), user, c.Param("id"), c.Param("employeeId"), admin); err != nil {
+			controller.SummariesUseCaseError(c, err)
+			return
+		}
+
+		controller.Ok(c)
+	}
+}
+
+func (controller *ChatController) getChatsHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	q, err := parseChatPageQuery(c)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	room, page, err := controller.chatUC.RetrieveChats(c.Request.Context(), user, c.Param("id"), q)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapRoomChatPageResponse(room, page))
+}
+
+func (controller *ChatController) getChatContextHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	q, err := parseChatPageQuery(c)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	room, page, err := controller.chatUC.RetrieveChatContext(c.Request.Context(), user, c.Param("id"), c.Param("chatId"), q)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapRoomChatPageResponse(room, page))
+}
+
+func (controller *ChatController) searchChatsHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	q, err := parseChatPageQuery(c)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	page, err := controller.chatUC.SearchChats(c.Request.Context(), user, vo.ChatSearchQuery{
+		Query:         strings.TrimSpace(c.Query("q")),
+		RoomId:        c.Query("roomId"),
+		ChatPageQuery: q,
+	})
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapChatPageResponse(page))
+}
+
+// parseChatPageQuery parses the before and after cursors and the limit
+// of a page of chats.
+func parseChatPageQuery(c *gin.Context) (vo.ChatPageQuery, error) {
+	q := vo.ChatPageQuery{
+		Before: c.Query("before"),
+		After:  c.Query("after"),
+	}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return q, usecase.NewClientError("Limit", err)
+		}
+		q.Limit = limit
+	}
+
+	return q, nil
+}
+
+func (controller *ChatController) getUnreadCountHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	count, err := controller.chatUC.RetrieveUnreadCount(c.Request.Context(), user, c.Param("id"))
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, dto.UnreadCountResponse{RoomId: c.Param("id"), UnreadCount: count})
+}
+
+func (controller *ChatController) readRoomHandler(c *gin.Context) {
+	var req dto.ReadChatRequest
+
+	user := c.Keys["user"].(entity.Employee)
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	if err := controller.chatUC.MarkRead(c.Request.Context(), user, c.Param("id"), req.ChatId); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
 func (controller *ChatController) chattingHandler(c *gin.Context) {
 	// Checks whether the connection is websocket
 	if !c.IsWebsocket() {
@@ -87,32 +359,90 @@ func (controller *ChatController) chattingHandler(c *gin.Context) {
 		return
 	}
 
-	// Gets the user id and room id
-	userId := c.Param("userId")
-	roomId := c.Param("roomId")
+	// The sender is whoever the token belongs to
+	token := socketToken(c)
+	if token == "" {
+		controller.Unauthorized(c, usecase.NewUnauthorizedError(fmt.Errorf("this is a protected endpoint, it requires an auth token")))
+		return
+	}
+	user, expiresAt, err := controller.credUC.AuthorizeSocket(c.Request.Context(), token)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
 
-	conn, err := websocket.Upgrade(c.Writer, c.Request, nil, 1024, 1024)
+	room, err := controller.chatUC.JoinRoom(c.Request.Context(), user, c.Param("roomId"))
 	if err != nil {
-		controller.UnexpectedError(c, usecase.NewServiceError("Notification", fmt.Errorf("unable to upgrade connection")))
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+	// The signals of the room are published under its id as stored
+	roomId := room.Id.Hex()
+
+	// A malformed lastSeen would leave the socket unable to listen
+	lastSeenId := c.Query("lastSeen")
+	if err := validation.Validate(lastSeenId, is.MongoID); err != nil {
+		controller.ClientError(c, usecase.NewClientError("LastSeen", err))
+		return
+	}
+
+	// The upgrader replies with the error itself
+	conn, err := chatUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
 	}
 
 	chatter := &Chatter{
 		roomId:     roomId,
-		chatterId:  userId,
+		lastSeenId: lastSeenId,
+		user:       user,
+		token:      token,
 		conn:       conn,
+		limiter:    rate.NewLimiter(socketFrameRate, socketFrameBurst),
 		controller: controller,
 	}
+	chatter.room.Store(&room)
 
-	closed := make(chan int)
 	ctx, cancel := context.WithCancel(c.Request.Context())
 	defer cancel()
 
 	// Sender
-	go chatter.messageSender(ctx, closed)
+	go chatter.messageSender(ctx, cancel)
 	// Reader
-	go chatter.messageListener(ctx)
+	go chatter.messageListener(ctx, cancel)
+	go chatter.signalListener(ctx, cancel)
+	// Online while connected
+	go chatter.controller.chatUC.StayOnline(ctx, user)
+	// Ends the chat once the token expires or is revoked
+	go chatter.tokenWatcher(ctx, cancel, expiresAt)
+
+	<-ctx.Done()
+
+	// Unregister client
+	chatter.CloseConn()
+	chatter.controller.chatUC.DetachListener(context.Background(), user.Id, roomId)
+	// Nobody is left seeing a chatter who is gone as typing
+	if chatter.typing.Load() {
+		chatter.controller.chatUC.SetTyping(context.Background(), user, *chatter.room.Load(), false)
+	}
+}
+
+// socketToken returns the access token of a websocket upgrade from
+// the query or the subprotocols. The subprotocols are preferred, since
+// a query may end up in the logs of the proxies along the way.
+func socketToken(c *gin.Context) string {
+	if token := c.Query("token"); token != "" {
+		return token
+	}
+
+	protocols := websocket.Subprotocols(c.Request)
+	for i, v := range protocols {
+		if v == socketProtocol && i+1 < len(protocols) {
+			return protocols[i+1]
+		}
+	}
 
-	<-closed
+	return ""
 }
 
 /*
@@ -120,35 +450,57 @@ func (controller *ChatController) chattingHandler(c *gin.Context) {
 SOCKET MANAGEMENT HELPERS
 ***************************
 */
-func (client *Chatter) messageSender(ctx context.Context, closeChan chan int) {
+func (client *Chatter) messageSender(ctx context.Context, cancel context.CancelFunc) {
+	// The chat ends on any read error, a close frame included
+	defer cancel()
+
 	for {
-		_, message, err := client.conn.ReadMessage()
-		if ce, ok := err.(*websocket.CloseError); ok {
-			switch ce.Code {
-			case websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived:
-				// Close conn
-				client.CloseConn()
-				// Unregister client
-				client.controller.chatUC.DetachListener(ctx, client.chatterId, client.roomId)
-				// Let the subscriber goroutine know
-				closeChan <- 1
-				return
-			}
+		_, frame, err := client.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		if !client.limiter.Allow() {
+			client.WriteJSON(mapper.MapErrorToEventResponse(usecase.NewTooManyRequestsError("Chat", fmt.Errorf("too many events, slow down"))))
+			continue
 		}
 
-		_, err = client.controller.chatUC.SendMessage(ctx, client.chatterId, client.roomId, string(message))
+		var event dto.ChatEventRequest
+		if err := json.Unmarshal(frame, &event); err != nil {
+			client.WriteJSON(mapper.MapErrorToEventResponse(usecase.NewClientError("Chat", err)))
+			continue
+		}
+
+		switch event.Type {
+		case dto.CHAT_EVENT_MESSAGE:
+			_, err = client.controller.chatUC.SendMessage(ctx, client.user, client.roomId, event.Message)
+		case dto.CHAT_EVENT_TYPING:
+			err = client.controller.chatUC.SetTyping(ctx, client.user, *client.room.Load(), event.Typing)
+			if err == nil {
+				client.typing.Store(event.Typing)
+			}
+		case dto.CHAT_EVENT_READ:
+			err = client.controller.chatUC.MarkRoomRead(ctx, client.user, *client.room.Load(), event.ChatId)
+		default:
+			err = usecase.NewClientError("Chat", fmt.Errorf("unknown event type %q", event.Type))
+		}
 		if err != nil {
-			client.conn.WriteJSON(err)
+			client.WriteJSON(mapper.MapErrorToEventResponse(err))
 		}
 	}
 }
 
-func (client *Chatter) messageListener(ctx context.Context) {
+// messageListener sends the chats of the room, starting with those
+// missed since lastSeen. The chat ends once the chats cannot be
+// listened to, since the chatter would no longer receive any.
+func (client *Chatter) messageListener(ctx context.Context, cancel context.CancelFunc) {
 	channel := make(chan entity.Chat)
 
 	go func(ctx context.Context, channel chan entity.Chat) {
-		if err := client.controller.chatUC.ListenMessage(ctx, client.chatterId, client.roomId, channel); err != nil {
-			client.conn.WriteJSON(err)
+		if err := client.controller.chatUC.ListenMessage(ctx, client.user, client.roomId, client.lastSeenId, channel); err != nil {
+			client.WriteJSON(mapper.MapErrorToEventResponse(err))
+			client.WriteClose(websocket.CloseInternalServerErr, "unable to listen to the chats")
+			cancel()
 			return
 		}
 	}(ctx, channel)
@@ -158,11 +510,101 @@ func (client *Chatter) messageListener(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case chat := <-channel:
-			client.conn.WriteJSON(mapper.MapChatDomainToResponse(chat))
+			client.WriteJSON(mapper.MapChatToEventResponse(chat))
 		}
 	}
 }
 
+// signalListener sends the typing, read, presence and removal signals
+// of the room, starting with who of the room is online already. The
+// chat ends once the signals cannot be listened to, e.g. the chatter
+// has been removed from the room.
+func (client *Chatter) signalListener(ctx context.Context, cancel context.CancelFunc) {
+	channel := make(chan vo.ChatSignal)
+
+	go func(ctx context.Context, channel chan vo.ChatSignal) {
+		if err := client.controller.chatUC.ListenSignals(ctx, client.user, client.roomId, channel); err != nil {
+			client.WriteJSON(mapper.MapErrorToEventResponse(err))
+			client.WriteClose(websocket.ClosePolicyViolation, "unable to listen to the room")
+			cancel()
+			return
+		}
+	}(ctx, channel)
+
+	online, err := client.controller.chatUC.RetrieveOnlineParticipants(ctx, client.user, client.roomId)
+	if err != nil {
+		client.WriteJSON(mapper.MapErrorToEventResponse(err))
+	}
+	for _, v := range online {
+		client.WriteJSON(mapper.MapChatSignalToEventResponse(vo.ChatSignal{
+			Type:       vo.CHAT_SIGNAL_PRESENCE,
+			EmployeeId: v,
+			Online:     true,
+		}))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case signal := <-channel:
+			client.WriteJSON(mapper.MapChatSignalToEventResponse(signal))
+		}
+	}
+}
+
+// tokenWatcher closes the connection with a policy violation once the
+// token expires, or once it is no longer accepted, e.g. the session
+// has been revoked, or once the chatter no longer takes part in the
+// room, e.g. removed from it or moved to another team.
+func (client *Chatter) tokenWatcher(ctx context.Context, cancel context.CancelFunc, expiresAt time.Time) {
+	expiry := time.NewTimer(time.Until(expiresAt))
+	defer expiry.Stop()
+	ticker := time.NewTicker(socketAuthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-expiry.C:
+			client.WriteClose(websocket.ClosePolicyViolation, "token has expired")
+			cancel()
+			return
+		case <-ticker.C:
+			user, _, err := client.controller.credUC.AuthorizeSocket(ctx, client.token)
+			if err != nil {
+				client.WriteClose(websocket.ClosePolicyViolation, "token is no longer valid")
+				cancel()
+				return
+			}
+			room, err := client.controller.chatUC.JoinRoom(ctx, user, client.roomId)
+			if err != nil {
+				client.WriteClose(websocket.ClosePolicyViolation, "no longer a participant of the room")
+				cancel()
+				return
+			}
+			client.room.Store(&room)
+		}
+	}
+}
+
+// WriteJSON writes to the connection, which allows one writer at a
+// time.
+func (client *Chatter) WriteJSON(v any) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	client.conn.WriteJSON(v)
+}
+
+func (client *Chatter) WriteClose(code int, reason string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	client.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+}
+
 func (client *Chatter) CloseConn() {
 	client.mu.Lock()
 	defer client.mu.Unlock()
@@ -0,0 +1,145 @@
+package v2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"sinarlog.com/internal/app/usecase"
+	"sinarlog.com/internal/entity"
+)
+
+// fakeSocketCredUseCase accepts the token of the chatter while valid.
+type fakeSocketCredUseCase struct {
+	usecase.ICredentialUseCase
+	valid bool
+}
+
+func (uc fakeSocketCredUseCase) AuthorizeSocket(ctx context.Context, token string) (entity.Employee, time.Time, error) {
+	if !uc.valid {
+		return entity.Employee{}, time.Time{}, usecase.NewUnauthorizedError(fmt.Errorf("your session has ended, please log in again"))
+	}
+	return entity.Employee{}, time.Now().Add(time.Hour), nil
+}
+
+// fakeSocketChatUseCase lets the chatter join the room while a member.
+type fakeSocketChatUseCase struct {
+	usecase.IChatUseCase
+	member bool
+}
+
+func (uc fakeSocketChatUseCase) JoinRoom(ctx context.Context, user entity.Employee, roomId string) (entity.Room, error) {
+	if !uc.member {
+		return entity.Room{}, usecase.NewForbiddenError(fmt.Errorf("you do not take part in this room"))
+	}
+	return entity.Room{}, nil
+}
+
+// watchToken serves a socket whose token is watched until it expires at
+// expiresAt, and returns the close frame the client receives.
+func watchToken(t *testing.T, controller *ChatController, expiresAt time.Time) *websocket.CloseError {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := chatUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		chatter := &Chatter{token: "access", conn: conn, controller: controller}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		chatter.tokenWatcher(ctx, cancel, expiresAt)
+		// Waits for the client to receive the close frame
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("unable to dial the socket: %s", err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = conn.ReadMessage()
+	closeErr, ok := err.(*websocket.CloseError)
+	if !ok {
+		t.Fatalf("expected the socket to be closed, got %v", err)
+	}
+	return closeErr
+}
+
+func TestSocketClosesOnceTheTokenExpires(t *testing.T) {
+	controller := &ChatController{credUC: fakeSocketCredUseCase{valid: true}, chatUC: fakeSocketChatUseCase{member: true}}
+
+	closeErr := watchToken(t, controller, time.Now().Add(50*time.Millisecond))
+	if closeErr.Code != websocket.ClosePolicyViolation || closeErr.Text != "token has expired" {
+		t.Fatalf("expected the expired token to close the socket, got %s", closeErr)
+	}
+}
+
+func TestSocketClosesOnceNoLongerAuthorized(t *testing.T) {
+	defer func(interval time.Duration) { socketAuthInterval = interval }(socketAuthInterval)
+	socketAuthInterval = 20 * time.Millisecond
+
+	testCases := []struct {
+		name       string
+		controller *ChatController
+		reason     string
+	}{
+		{
+			"Session revoked",
+			&ChatController{credUC: fakeSocketCredUseCase{valid: false}, chatUC: fakeSocketChatUseCase{member: true}},
+			"token is no longer valid",
+		},
+		{
+			"Removed from the room",
+			&ChatController{credUC: fakeSocketCredUseCase{valid: true}, chatUC: fakeSocketChatUseCase{member: false}},
+			"no longer a participant of the room",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			closeErr := watchToken(t, tc.controller, time.Now().Add(time.Hour))
+			if closeErr.Code != websocket.ClosePolicyViolation || closeErr.Text != tc.reason {
+				t.Fatalf("expected the socket to be closed with %q, got %s", tc.reason, closeErr)
+			}
+		})
+	}
+}
+
+func TestSocketToken(t *testing.T) {
+	testCases := []struct {
+		name      string
+		query     string
+		protocols string
+		token     string
+	}{
+		{"Query", "?token=abc", "", "abc"},
+		{"Subprotocols", "", "bearer, abc", "abc"},
+		{"Subprotocols without a token", "", "bearer", ""},
+		{"Other subprotocols", "", "chat, abc", ""},
+		{"No token", "", "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/chat/messenger/room"+tc.query, nil)
+			if tc.protocols != "" {
+				c.Request.Header.Set("Sec-WebSocket-Protocol", tc.protocols)
+			}
+
+			if token := socketToken(c); token != tc.token {
+				t.Fatalf("expected token %q, got %q", tc.token, token)
+			}
+		})
+	}
+}
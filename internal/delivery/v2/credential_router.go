@@ -9,6 +9,8 @@ import (
 	"sinarlog.com/internal/delivery/v2/dto"
 	"sinarlog.com/internal/delivery/v2/dto/mapper"
 	"sinarlog.com/internal/delivery/v2/model"
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/entity/vo"
 )
 
 type CredentialController struct {
@@ -24,9 +26,18 @@ func NewCredentialController(rg *gin.RouterGroup, uc usecase.ICredentialUseCase,
 
 	r := rg.Group("/credentials")
 	{
-		// Rate limiting middleware
-		r.Use(middleware.NewMiddleware().RateLimiterMiddleware(srv))
-		r.POST("/login", controller.loginHandler)
+		// Each attempt is a guess at a password, an OTP or a token
+		limit := middleware.NewMiddleware().RateLimiterMiddleware(srv, vo.AUTH_RATE_POLICY)
+		r.POST("/login", limit, controller.loginHandler)
+		r.POST("/login/enroll", limit, controller.enrollTwoFactorHandler)
+		r.POST("/login/verify", limit, controller.verifyLoginChallengeHandler)
+		r.POST("/refresh", limit, controller.refreshHandler)
+
+		auth := middleware.NewMiddleware().AuthMiddleware(uc)
+		api := middleware.NewMiddleware().APIRateLimiterMiddleware(srv)
+		r.POST("/logout", auth, api, controller.logoutHandler)
+		r.GET("/sessions", auth, api, controller.getSessionsHandler)
+		r.DELETE("/sessions/:id", auth, api, controller.revokeSessionHandler)
 	}
 }
 
@@ -43,6 +54,8 @@ func (controller *CredentialController) loginHandler(c *gin.Context) {
 	}
 
 	cred := mapper.MapLoginRequestToCredentialVO(req)
+	cred.Device = c.Request.UserAgent()
+	cred.IP = c.ClientIP()
 
 	employee, cred, err := controller.uc.Login(c.Request.Context(), cred)
 	if err != nil {
@@ -50,5 +63,97 @@ func (controller *CredentialController) loginHandler(c *gin.Context) {
 		return
 	}
 
+	if cred.ChallengeToken != "" {
+		controller.Ok(c, mapper.MapToLoginChallengeResponse(cred))
+		return
+	}
+
+	controller.Ok(c, mapper.MapToLoginResponse(employee, cred))
+}
+
+func (controller *CredentialController) enrollTwoFactorHandler(c *gin.Context) {
+	var req dto.LoginChallengeRequest
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.ClientError(c, err)
+		return
+	}
+
+	res, err := controller.uc.EnrollTwoFactor(c.Request.Context(), mapper.MapLoginChallengeRequestToCredentialVO(req))
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapOTPEnrollmentToResponse(res))
+}
+
+func (controller *CredentialController) verifyLoginChallengeHandler(c *gin.Context) {
+	var req dto.LoginChallengeRequest
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.ClientError(c, err)
+		return
+	}
+
+	employee, cred, err := controller.uc.VerifyLoginChallenge(c.Request.Context(), mapper.MapLoginChallengeRequestToCredentialVO(req))
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
 	controller.Ok(c, mapper.MapToLoginResponse(employee, cred))
 }
+
+func (controller *CredentialController) refreshHandler(c *gin.Context) {
+	var req dto.RefreshRequest
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.ClientError(c, err)
+		return
+	}
+
+	cred := mapper.MapRefreshRequestToCredentialVO(req)
+	cred.Device = c.Request.UserAgent()
+	cred.IP = c.ClientIP()
+
+	cred, err := controller.uc.RefreshSession(c.Request.Context(), cred)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapToRefreshResponse(cred))
+}
+
+func (controller *CredentialController) logoutHandler(c *gin.Context) {
+	session := c.Keys["session"].(entity.Session)
+
+	if err := controller.uc.Logout(c.Request.Context(), session); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+func (controller *CredentialController) getSessionsHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+	session := c.Keys["session"].(entity.Session)
+
+	res, err := controller.uc.RetrieveMySessions(c.Request.Context(), user)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapSessionsToResponse(res, session.Id))
+}
+
+func (controller *CredentialController) revokeSessionHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	if err := controller.uc.RevokeMySession(c.Request.Context(), user, c.Param("id")); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
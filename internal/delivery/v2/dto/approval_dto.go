@@ -0,0 +1,50 @@
+package dto
+
+type ApprovalChainRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Kind        string   `json:"kind" binding:"required"`
+	LeaveType   string   `json:"leaveType"`
+	MinDuration float64  `json:"minDuration"`
+	Steps       []string `json:"steps" binding:"required"`
+}
+
+type ApprovalChainResponse struct {
+	Id          string   `json:"id,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	Kind        string   `json:"kind,omitempty"`
+	LeaveType   string   `json:"leaveType,omitempty"`
+	MinDuration float64  `json:"minDuration"`
+	Steps       []string `json:"steps"`
+}
+
+type ApprovalStepResponse struct {
+	Sequence        int                        `json:"sequence"`
+	Role            string                     `json:"role,omitempty"`
+	Approver        *BriefEmployeeListResponse `json:"approver,omitempty"`
+	ActedBy         *BriefEmployeeListResponse `json:"actedBy,omitempty"`
+	ActedAsDelegate bool                       `json:"actedAsDelegate"`
+	Approved        *bool                      `json:"approved,omitempty"`
+	Reason          string                     `json:"reason,omitempty"`
+	ActionAt        *string                    `json:"actionAt,omitempty"`
+}
+
+type ApprovalDelegationRequest struct {
+	// DelegatorId is only given by HR delegating on behalf of a
+	// manager
+	DelegatorId string `json:"delegatorId"`
+	DelegateId  string `json:"delegateId" binding:"required"`
+	From        string `json:"from" binding:"required"`
+	To          string `json:"to" binding:"required"`
+	Reason      string `json:"reason"`
+}
+
+type ApprovalDelegationResponse struct {
+	Id        string                     `json:"id"`
+	Delegator *BriefEmployeeListResponse `json:"delegator,omitempty"`
+	Delegate  *BriefEmployeeListResponse `json:"delegate,omitempty"`
+	From      string                     `json:"from"`
+	To        string                     `json:"to"`
+	Reason    string                     `json:"reason,omitempty"`
+	CreatedBy *BriefEmployeeListResponse `json:"createdBy,omitempty"`
+	IsActive  bool                       `json:"isActive"`
+}
@@ -42,18 +42,27 @@ type MyAttendanceHistory struct {
 }
 
 type EmployeesAttendanceHistory struct {
-	Id                  string  `json:"id,omitempty"`
-	Avatar              string  `json:"avatar,omitempty"`
-	FullName            string  `json:"fullName,omitempty"`
-	Email               string  `json:"email,omitempty"`
-	Position            string  `json:"position,omitempty"`
-	Date                string  `json:"date,omitempty"`
-	ClockInAt           string  `json:"clockInAt,omitempty"`
-	ClockOutAt          string  `json:"clockOutAt,omitempty"`
-	DoneForTheDay       bool    `json:"doneForTheDay"`
-	ClockInLoc          LatLong `json:"clockInLoc,omitempty"`
-	ClockOutLoc         LatLong `json:"clockOutLoc,omitempty"`
-	ClosedAutomatically bool    `json:"closedAutomatically,omitempty"`
-	LateClockIn         bool    `json:"lateClockIn"`
-	EarlyClockOut       bool    `json:"earlyClockOut"`
+	Id                  string              `json:"id,omitempty"`
+	Avatar              string              `json:"avatar,omitempty"`
+	FullName            string              `json:"fullName,omitempty"`
+	Email               string              `json:"email,omitempty"`
+	Position            string              `json:"position,omitempty"`
+	Date                string              `json:"date,omitempty"`
+	ClockInAt           string              `json:"clockInAt,omitempty"`
+	ClockOutAt          string              `json:"clockOutAt,omitempty"`
+	DoneForTheDay       bool                `json:"doneForTheDay"`
+	ClockInLoc          LatLong             `json:"clockInLoc,omitempty"`
+	ClockOutLoc         LatLong             `json:"clockOutLoc,omitempty"`
+	ClosedAutomatically bool                `json:"closedAutomatically,omitempty"`
+	LateClockIn         bool                `json:"lateClockIn"`
+	EarlyClockOut       bool                `json:"earlyClockOut"`
+	ClockInGeofence     *AttendanceGeofence `json:"clockInGeofence,omitempty"`
+	ClockOutGeofence    *AttendanceGeofence `json:"clockOutGeofence,omitempty"`
+}
+
+type AttendanceGeofence struct {
+	OutsideOffice bool    `json:"outsideOffice"`
+	OfficeId      string  `json:"officeId,omitempty"`
+	OfficeName    string  `json:"officeName,omitempty"`
+	Distance      float64 `json:"distance"`
 }
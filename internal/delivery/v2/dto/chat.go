@@ -1,22 +1,95 @@
 package dto
 
 type OpenChatResponse struct {
-	Room  RoomResponse   `json:"room"`
-	Chats []ChatResponse `json:"chats"`
+	Room RoomResponse `json:"room"`
+	ChatPageResponse
+}
+
+// ChatPageResponse is a page of chats. The id of the first chat is the
+// cursor to the older chats, and the one of the last chat to the newer.
+type ChatPageResponse struct {
+	Chats    []ChatResponse `json:"chats"`
+	HasOlder bool           `json:"hasOlder"`
+	HasNewer bool           `json:"hasNewer"`
 }
 
 type RoomResponse struct {
 	Id           string   `json:"id"`
+	Kind         string   `json:"kind"`
+	Name         string   `json:"name,omitempty"`
+	Avatar       string   `json:"avatar,omitempty"`
+	Topic        string   `json:"topic,omitempty"`
 	Participants []string `json:"participants"`
+	Admins       []string `json:"admins,omitempty"`
+	TeamOf       string   `json:"teamOf,omitempty"`
 	CreatedAt    string   `json:"createdAt"`
 }
 
+type RoomSummaryResponse struct {
+	RoomResponse
+	LastChat    *ChatResponse `json:"lastChat"`
+	UnreadCount int64         `json:"unreadCount"`
+}
+
+type RoomRequest struct {
+	Name    string   `json:"name" binding:"required"`
+	Avatar  string   `json:"avatar"`
+	Topic   string   `json:"topic"`
+	Members []string `json:"members"`
+}
+
+type RoomMembersRequest struct {
+	Members []string `json:"members" binding:"required"`
+}
+
 type ChatResponse struct {
-	Id        string `json:"id,omitempty"`
-	RoomId    string `json:"roomId,omitempty"`
-	Sender    string `json:"sender,omitempty"`
-	Message   string `json:"message,omitempty"`
-	Read      bool   `json:"read"`
-	SentAt    string `json:"sentAt,omitempty"`
-	Timestamp uint32 `json:"timestamp,omitempty"`
+	Id      string `json:"id,omitempty"`
+	RoomId  string `json:"roomId,omitempty"`
+	Sender  string `json:"sender,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Read is whether every other participant has read the chat
+	Read      bool     `json:"read"`
+	ReadBy    []string `json:"readBy,omitempty"`
+	SentAt    string   `json:"sentAt,omitempty"`
+	Timestamp uint32   `json:"timestamp,omitempty"`
+}
+
+type UnreadCountResponse struct {
+	RoomId      string `json:"roomId"`
+	UnreadCount int64  `json:"unreadCount"`
+}
+
+type ReadChatRequest struct {
+	ChatId string `json:"chatId" binding:"required"`
+}
+
+// The types of the frames of the chat websocket, each a JSON envelope
+const (
+	CHAT_EVENT_MESSAGE  = "message"
+	CHAT_EVENT_TYPING   = "typing"
+	CHAT_EVENT_READ     = "read"
+	CHAT_EVENT_PRESENCE = "presence"
+	CHAT_EVENT_REMOVED  = "removed"
+	// Only sent to the chatter whose frame has failed
+	CHAT_EVENT_ERROR = "error"
+)
+
+// ChatEventRequest is a frame a chatter sends. A message has its
+// message, a typing whether the chatter is typing, and a read the last
+// chat the chatter has read.
+type ChatEventRequest struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Typing  bool   `json:"typing"`
+	ChatId  string `json:"chatId"`
+}
+
+type ChatEventResponse struct {
+	Type       string        `json:"type"`
+	Chat       *ChatResponse `json:"chat,omitempty"`
+	EmployeeId string        `json:"employeeId,omitempty"`
+	ChatId     string        `json:"chatId,omitempty"`
+	Typing     *bool         `json:"typing,omitempty"`
+	Online     *bool         `json:"online,omitempty"`
+	Error      any           `json:"error,omitempty"`
 }
@@ -7,6 +7,7 @@ type GlobalConfigResponse struct {
 	AcceptanceLeaveInterval      int    `json:"acceptedLeaveInterval"`
 	DefaultYearlyQuota           int    `json:"defaultYearlyQuota"`
 	DefaultMarriageQuota         int    `json:"defaultMarriageQuota"`
+	GeofenceMode                 string `json:"geofenceMode,omitempty"`
 }
 
 type UpdateConfigRequest struct {
@@ -18,6 +19,7 @@ type UpdateConfigRequest struct {
 	AcceptanceLeaveInterval      int    `json:"acceptanceLeaveInterval"`
 	DefaultYearlyQuota           int    `json:"defaultYearlyQuota"`
 	DefaultMarriageQuota         int    `json:"defaultMarriageQuota"`
+	GeofenceMode                 string `json:"geofenceMode,omitempty"`
 }
 
 type ConfigChangesLogsResponse struct {
@@ -26,4 +28,29 @@ type ConfigChangesLogsResponse struct {
 	Changes     map[string]any            `json:"changes,omitempty"`
 	UpdatedAt   string                    `json:"updatedAt,omitempty"`
 	WhenApplied string                    `json:"whenApplied,omitempty"`
+	Scope       string                    `json:"scope,omitempty"`
+	AppliedAt   string                    `json:"appliedAt,omitempty"`
+	CancelledAt string                    `json:"cancelledAt,omitempty"`
+}
+
+type PendingConfigChangesResponse struct {
+	Scope       string                      `json:"scope"`
+	WhenApplied string                      `json:"whenApplied"`
+	Changes     map[string]any              `json:"changes"`
+	Logs        []ConfigChangesLogsResponse `json:"logs"`
+}
+
+type OfficeLocationRequest struct {
+	Name    string    `json:"name" binding:"required"`
+	Center  LatLong   `json:"center"`
+	Radius  float64   `json:"radius"`
+	Polygon []LatLong `json:"polygon,omitempty"`
+}
+
+type OfficeLocationResponse struct {
+	Id      string    `json:"id,omitempty"`
+	Name    string    `json:"name,omitempty"`
+	Center  LatLong   `json:"center,omitempty"`
+	Radius  float64   `json:"radius,omitempty"`
+	Polygon []LatLong `json:"polygon,omitempty"`
 }
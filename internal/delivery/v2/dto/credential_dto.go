@@ -15,8 +15,77 @@ type LoginResponse struct {
 	Job          JobResponse  `json:"job,omitempty"`
 	AccessToken  string       `json:"accessToken,omitempty"`
 	RefreshToken string       `json:"refreshToken,omitempty"`
+	// Issued once the authenticator app is enrolled at login
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type RefreshResponse struct {
+	AccessToken  string `json:"accessToken,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+}
+
+type SessionResponse struct {
+	Id         string `json:"id"`
+	Device     string `json:"device"`
+	IP         string `json:"ip"`
+	CreatedAt  string `json:"createdAt"`
+	LastSeenAt string `json:"lastSeenAt"`
+	ExpiresAt  string `json:"expiresAt"`
+	IsCurrent  bool   `json:"isCurrent"`
 }
 
 type ForgotPassword struct {
 	Email string `json:"email,omitempty" binding:"required"`
 }
+
+type ResetPasswordRequest struct {
+	Token           string `json:"token" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required"`
+	ConfirmPassword string `json:"confirmPassword" binding:"required"`
+}
+
+type LoginChallengeResponse struct {
+	ChallengeToken     string `json:"challengeToken"`
+	EnrollmentRequired bool   `json:"enrollmentRequired"`
+}
+
+type LoginChallengeRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	OTP            string `json:"otp"`
+	RecoveryCode   string `json:"recoveryCode"`
+}
+
+type OTPStatusResponse struct {
+	IsEnrolled        bool   `json:"isEnrolled"`
+	EnrolledAt        string `json:"enrolledAt,omitempty"`
+	IsMandatory       bool   `json:"isMandatory"`
+	RecoveryCodesLeft int64  `json:"recoveryCodesLeft"`
+}
+
+type OTPEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+type ConfirmOTPEnrollmentRequest struct {
+	OTP string `json:"otp" binding:"required"`
+}
+
+type RecoveryCodesRequest struct {
+	OTP string `json:"otp" binding:"required"`
+}
+
+// RemoveOTPEnrollmentRequest proves the authenticator app is removed
+// by its owner with an OTP from the app or a recovery code.
+type RemoveOTPEnrollmentRequest struct {
+	OTP          string `json:"otp"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
@@ -51,10 +51,8 @@ type EmployeeBiodataResponse struct {
 }
 
 type EmployeeLeaveQuotaResponse struct {
-	EmployeeID    string `json:"employeeId,omitempty"`
-	YearlyCount   int    `json:"yearlyCount"`
-	UnpaidCount   int    `json:"unpaidCount"`
-	MarriageCount int    `json:"marriageCount"`
+	Type  string  `json:"type"`
+	Count float64 `json:"count"`
 }
 
 type EmployeeEmergencyContactResponse struct {
@@ -84,7 +82,7 @@ type EmployeeFullProfileResponse struct {
 
 	Biodata           EmployeeBiodataResponse            `json:"biodata,omitempty"`
 	EmergencyContacts []EmployeeEmergencyContactResponse `json:"emergencyContacts,omitempty"`
-	LeaveQuota        EmployeeLeaveQuotaResponse         `json:"leaveQuota,omitempty"`
+	LeaveQuota        []EmployeeLeaveQuotaResponse       `json:"leaveQuota,omitempty"`
 	Logs              []EmployeeChangesLogs              `json:"logs,omitempty"`
 
 	ManagerID *string                    `json:"managerId,omitempty"`
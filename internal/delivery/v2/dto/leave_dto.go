@@ -1,24 +1,30 @@
 package dto
 
 type MyLeaveRequestListsResponse struct {
-	Id          string `json:"id,omitempty"`
-	RequestDate string `json:"requestDate,omitempty"`
-	From        string `json:"from,omitempty"`
-	To          string `json:"to,omitempty"`
-	Duration    int    `json:"duration,omitempty"`
-	Status      string `json:"status,omitempty"`
-	LeaveType   string `json:"leaveType,omitempty"`
+	Id          string  `json:"id,omitempty"`
+	RequestDate string  `json:"requestDate,omitempty"`
+	From        string  `json:"from,omitempty"`
+	To          string  `json:"to,omitempty"`
+	Duration    float64 `json:"duration,omitempty"`
+	Granularity string  `json:"granularity,omitempty"`
+	StartTime   string  `json:"startTime,omitempty"`
+	EndTime     string  `json:"endTime,omitempty"`
+	Status      string  `json:"status,omitempty"`
+	LeaveType   string  `json:"leaveType,omitempty"`
 }
 
 type LeaveRequest struct {
-	Id          string `json:"id,omitempty"`
-	From        string `json:"from,omitempty"`
-	To          string `json:"to,omitempty"`
-	Type        string `json:"type,omitempty"`
-	Reason      string `json:"reason,omitempty"`
-	Duration    int    `json:"duration,omitempty"`
-	RequestDate string `json:"requestDate,omitempty"`
-	Status      string `json:"status,omitempty"`
+	Id          string  `json:"id,omitempty"`
+	From        string  `json:"from,omitempty"`
+	To          string  `json:"to,omitempty"`
+	Type        string  `json:"type,omitempty"`
+	Reason      string  `json:"reason,omitempty"`
+	Duration    float64 `json:"duration,omitempty"`
+	Granularity string  `json:"granularity,omitempty"`
+	StartTime   string  `json:"startTime,omitempty"`
+	EndTime     string  `json:"endTime,omitempty"`
+	RequestDate string  `json:"requestDate,omitempty"`
+	Status      string  `json:"status,omitempty"`
 }
 
 type MyLeaveRequestDetailResponse struct {
@@ -26,7 +32,10 @@ type MyLeaveRequestDetailResponse struct {
 	RequestDate   string                     `json:"requestDate,omitempty"`
 	From          string                     `json:"from,omitempty"`
 	To            string                     `json:"to,omitempty"`
-	Duration      int                        `json:"duration,omitempty"`
+	Duration      float64                    `json:"duration,omitempty"`
+	Granularity   string                     `json:"granularity,omitempty"`
+	StartTime     string                     `json:"startTime,omitempty"`
+	EndTime       string                     `json:"endTime,omitempty"`
 	Type          string                     `json:"type,omitempty"`
 	Reason        string                     `json:"reason,omitempty"`
 	Status        string                     `json:"status"`
@@ -40,9 +49,11 @@ type MyLeaveRequestDetailResponse struct {
 	ActionByManagerAt *string `json:"actionByManagerAt,omitempty"`
 	RejectionReason   string  `json:"rejectionReason,omitempty"`
 
-	Parent              *LeaveRequest  `json:"parent,omitempty"`
-	Childs              []LeaveRequest `json:"childs,omitempty"`
-	ClosedAutomatically *bool          `json:"closedAutomatically,omitempty"`
+	Parent              *LeaveRequest              `json:"parent,omitempty"`
+	Childs              []LeaveRequest             `json:"childs,omitempty"`
+	ClosedAutomatically *bool                      `json:"closedAutomatically,omitempty"`
+	Cancellation        *LeaveCancellationResponse `json:"cancellation,omitempty"`
+	ApprovalSteps       []ApprovalStepResponse     `json:"approvalSteps,omitempty"`
 }
 
 type LeaveRequestDetailResponse struct {
@@ -50,7 +61,10 @@ type LeaveRequestDetailResponse struct {
 	Avatar        string                     `json:"avatar,omitempty"`
 	FullName      string                     `json:"fullName,omitempty"`
 	Email         string                     `json:"email,omitempty"`
-	Duration      int                        `json:"duration,omitempty"`
+	Duration      float64                    `json:"duration,omitempty"`
+	Granularity   string                     `json:"granularity,omitempty"`
+	StartTime     string                     `json:"startTime,omitempty"`
+	EndTime       string                     `json:"endTime,omitempty"`
 	RequestDate   string                     `json:"requestDate,omitempty"`
 	From          string                     `json:"from,omitempty"`
 	To            string                     `json:"to,omitempty"`
@@ -70,17 +84,19 @@ type LeaveRequestDetailResponse struct {
 	Parent              *LeaveRequest                `json:"parent,omitempty"`
 	Childs              []LeaveRequestDetailResponse `json:"childs,omitempty"`
 	ClosedAutomatically *bool                        `json:"closedAutomatically,omitempty"`
+	Cancellation        *LeaveCancellationResponse   `json:"cancellation,omitempty"`
+	ApprovalSteps       []ApprovalStepResponse       `json:"approvalSteps,omitempty"`
 }
 
 type LeaveRequestReportExcessResponse struct {
-	Type  string `json:"type,omitempty"`
-	Quota int    `json:"quota,omitempty"`
+	Type  string  `json:"type,omitempty"`
+	Quota float64 `json:"quota,omitempty"`
 }
 type LeaveRequestReportResponse struct {
 	IsLeaveLeakage                 bool                               `json:"isLeaveLeakage"`
-	ExcessLeaveDuration            int                                `json:"excessLeaveDuration"`
+	ExcessLeaveDuration            float64                            `json:"excessLeaveDuration"`
 	RequestType                    string                             `json:"requestType,omitempty"`
-	RemainingQuotaForRequestedType int                                `json:"remainingQuotaForRequestedType"`
+	RemainingQuotaForRequestedType float64                            `json:"remainingQuotaForRequestedType"`
 	Availables                     []LeaveRequestReportExcessResponse `json:"availables,omitempty"`
 }
 
@@ -93,3 +109,85 @@ type LeaveOverflowsDecision struct {
 	Type  string `json:"type,omitempty"`
 	Count int    `json:"count,omitempty"`
 }
+
+type LeaveCancellationRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+type LeaveCancellationResponse struct {
+	Reason            string  `json:"reason,omitempty"`
+	RequestedAt       string  `json:"requestedAt,omitempty"`
+	ApprovedByManager *bool   `json:"approvedByManager,omitempty"`
+	ApprovedByHr      *bool   `json:"approvedByHr,omitempty"`
+	ActionByManagerAt *string `json:"actionByManagerAt,omitempty"`
+	ActionByHrAt      *string `json:"actionByHrAt,omitempty"`
+	RejectionReason   string  `json:"rejectionReason,omitempty"`
+	CancelledAt       *string `json:"cancelledAt,omitempty"`
+}
+
+type IncomingLeaveCancellationResponse struct {
+	Id          string  `json:"id,omitempty"`
+	Avatar      string  `json:"avatar,omitempty"`
+	FullName    string  `json:"fullName,omitempty"`
+	RequestDate string  `json:"requestDate,omitempty"`
+	From        string  `json:"from,omitempty"`
+	To          string  `json:"to,omitempty"`
+	Duration    float64 `json:"duration,omitempty"`
+	Type        string  `json:"type,omitempty"`
+	Reason      string  `json:"reason,omitempty"`
+	Overflows   int     `json:"overflows,omitempty"`
+}
+
+type LeavePolicyRequest struct {
+	Code               string   `json:"code"`
+	Name               string   `json:"name" binding:"required"`
+	HasQuota           bool     `json:"hasQuota"`
+	DefaultQuota       float64  `json:"defaultQuota"`
+	IsPaid             bool     `json:"isPaid"`
+	RequiresAttachment bool     `json:"requiresAttachment"`
+	MinNoticeDays      int      `json:"minNoticeDays"`
+	AccrualPeriod      string   `json:"accrualPeriod"`
+	MaxCarryOver       float64  `json:"maxCarryOver"`
+	CarryOverExpiry    int      `json:"carryOverExpiryMonths"`
+	ResetYearly        bool     `json:"resetYearly"`
+	OverflowTargets    []string `json:"overflowTargets"`
+	EligibleGenders    []string `json:"eligibleGenders"`
+	EligibleContracts  []string `json:"eligibleContracts"`
+}
+
+type LeavePolicyResponse struct {
+	Id                 string   `json:"id,omitempty"`
+	Code               string   `json:"code,omitempty"`
+	Name               string   `json:"name,omitempty"`
+	HasQuota           bool     `json:"hasQuota"`
+	DefaultQuota       float64  `json:"defaultQuota"`
+	IsPaid             bool     `json:"isPaid"`
+	RequiresAttachment bool     `json:"requiresAttachment"`
+	MinNoticeDays      int      `json:"minNoticeDays"`
+	AccrualPeriod      string   `json:"accrualPeriod"`
+	MaxCarryOver       float64  `json:"maxCarryOver"`
+	CarryOverExpiry    int      `json:"carryOverExpiryMonths"`
+	ResetYearly        bool     `json:"resetYearly"`
+	OverflowTargets    []string `json:"overflowTargets"`
+	EligibleGenders    []string `json:"eligibleGenders"`
+	EligibleContracts  []string `json:"eligibleContracts"`
+}
+
+type LeaveQuotaLedgerResponse struct {
+	Id        string                     `json:"id,omitempty"`
+	Type      string                     `json:"type,omitempty"`
+	Source    string                     `json:"source,omitempty"`
+	Amount    float64                    `json:"amount"`
+	Balance   float64                    `json:"balance"`
+	Period    string                     `json:"period,omitempty"`
+	Note      string                     `json:"note,omitempty"`
+	LeaveId   *string                    `json:"leaveId,omitempty"`
+	Actor     *BriefEmployeeListResponse `json:"actor,omitempty"`
+	CreatedAt string                     `json:"createdAt,omitempty"`
+}
+
+type LeaveQuotaAdjustmentRequest struct {
+	Type   string  `json:"type" binding:"required"`
+	Amount float64 `json:"amount" binding:"required"`
+	Reason string  `json:"reason" binding:"required"`
+}
@@ -0,0 +1,138 @@
+package mapper
+
+import (
+	"fmt"
+	"time"
+
+	"sinarlog.com/internal/delivery/v2/dto"
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/utils"
+)
+
+/*
+*************************************************
+ENTITIES TO RESPONSE
+*************************************************
+*/
+func MapApprovalChainsToResponse(chains []entity.ApprovalChain) []dto.ApprovalChainResponse {
+	res := []dto.ApprovalChainResponse{}
+
+	for _, v := range chains {
+		chain := dto.ApprovalChainResponse{
+			Id:          v.Id,
+			Name:        v.Name,
+			Kind:        string(v.Kind),
+			LeaveType:   string(v.LeaveType),
+			MinDuration: v.MinDuration,
+			Steps:       []string{},
+		}
+		for _, step := range v.Steps {
+			chain.Steps = append(chain.Steps, string(step))
+		}
+
+		res = append(res, chain)
+	}
+
+	return res
+}
+
+func mapApprovalStepsToResponse(steps []entity.ApprovalStep) []dto.ApprovalStepResponse {
+	var res []dto.ApprovalStepResponse
+
+	for _, v := range steps {
+		step := dto.ApprovalStepResponse{
+			Sequence:        v.Sequence,
+			Role:            string(v.Role),
+			Approver:        mapBriefEmployee(v.Approver),
+			ActedBy:         mapBriefEmployee(v.ActedBy),
+			ActedAsDelegate: v.ActedAsDelegate,
+			Approved:        v.Approved,
+			Reason:          v.Reason,
+		}
+
+		if v.ActionAt != nil {
+			t := v.ActionAt.In(utils.CURRENT_LOC).Format(time.RFC1123)
+			step.ActionAt = &t
+		}
+
+		res = append(res, step)
+	}
+
+	return res
+}
+
+func MapApprovalDelegationsToResponse(delegations []entity.ApprovalDelegation) []dto.ApprovalDelegationResponse {
+	res := []dto.ApprovalDelegationResponse{}
+	now := time.Now().In(utils.CURRENT_LOC)
+
+	for _, v := range delegations {
+		res = append(res, dto.ApprovalDelegationResponse{
+			Id:        v.Id,
+			Delegator: mapBriefEmployee(v.Delegator),
+			Delegate:  mapBriefEmployee(v.Delegate),
+			From:      v.From.In(utils.CURRENT_LOC).Format(time.DateOnly),
+			To:        v.To.In(utils.CURRENT_LOC).Format(time.DateOnly),
+			Reason:    v.Reason,
+			CreatedBy: mapBriefEmployee(v.CreatedBy),
+			IsActive:  v.IsActive(now),
+		})
+	}
+
+	return res
+}
+
+func mapBriefEmployee(employee *entity.Employee) *dto.BriefEmployeeListResponse {
+	if employee == nil {
+		return nil
+	}
+
+	return &dto.BriefEmployeeListResponse{
+		Id:       employee.Id,
+		FullName: employee.FullName,
+		Email:    employee.Email,
+		Avatar:   employee.Avatar,
+	}
+}
+
+/*
+*************************************************
+REQUEST TO ENTITIES
+*************************************************
+*/
+func MapApprovalChainRequestToDomain(req dto.ApprovalChainRequest) entity.ApprovalChain {
+	chain := entity.ApprovalChain{
+		Name:        req.Name,
+		Kind:        entity.ApprovalKind(req.Kind),
+		LeaveType:   entity.LeaveType(req.LeaveType),
+		MinDuration: req.MinDuration,
+	}
+	for _, v := range req.Steps {
+		chain.Steps = append(chain.Steps, entity.ApproverRole(v))
+	}
+
+	return chain
+}
+
+// MapApprovalDelegationRequestToDomain maps the request of a delegation
+// lasting from the start of From to the end of To.
+func MapApprovalDelegationRequestToDomain(req dto.ApprovalDelegationRequest) (entity.ApprovalDelegation, error) {
+	res := entity.ApprovalDelegation{
+		DelegatorID: req.DelegatorId,
+		DelegateID:  req.DelegateId,
+		Reason:      req.Reason,
+	}
+
+	from, err := time.Parse(time.DateOnly, req.From)
+	if err != nil {
+		return res, fmt.Errorf("invalid start date format")
+	}
+	res.From = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, utils.CURRENT_LOC)
+
+	to, err := time.Parse(time.DateOnly, req.To)
+	if err != nil {
+		return res, fmt.Errorf("invalid end date format")
+	}
+	res.To = time.Date(to.Year(), to.Month(), to.Day(), 23, 59, 59, 0, utils.CURRENT_LOC)
+
+	return res, nil
+}
@@ -130,6 +130,28 @@ func MapEmployeesAttendanceLogToResponse(att []entity.Attendance) []dto.Employee
 			EarlyClockOut:       v.EarlyClockOut,
 		}
 
+		if v.ClockInOfficeID != nil {
+			a.ClockInGeofence = &dto.AttendanceGeofence{
+				OutsideOffice: v.ClockInOutsideOffice,
+				OfficeId:      *v.ClockInOfficeID,
+				Distance:      v.ClockInOfficeDistance,
+			}
+			if v.ClockInOffice != nil {
+				a.ClockInGeofence.OfficeName = v.ClockInOffice.Name
+			}
+		}
+
+		if v.ClockOutOfficeID != nil {
+			a.ClockOutGeofence = &dto.AttendanceGeofence{
+				OutsideOffice: v.ClockOutOutsideOffice,
+				OfficeId:      *v.ClockOutOfficeID,
+				Distance:      v.ClockOutOfficeDistance,
+			}
+			if v.ClockOutOffice != nil {
+				a.ClockOutGeofence.OfficeName = v.ClockOutOffice.Name
+			}
+		}
+
 		res = append(res, a)
 	}
 
@@ -1,25 +1,49 @@
 package mapper
 
 import (
+	"strings"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"sinarlog.com/internal/delivery/v2/dto"
 	"sinarlog.com/internal/entity"
 	"sinarlog.com/internal/entity/vo"
 	"sinarlog.com/internal/utils"
 )
 
-func MapOpenChatResponse(room entity.Room, chats []entity.Chat) dto.OpenChatResponse {
-	res := dto.OpenChatResponse{
-		Room: dto.RoomResponse{
-			Id:           room.Id.Hex(),
-			Participants: room.Participants,
-			CreatedAt:    room.CreatedAt.Time().In(utils.CURRENT_LOC).Format(time.RFC1123),
-		},
-		Chats: []dto.ChatResponse{},
+func MapOpenChatResponse(room entity.Room, page vo.ChatPage) dto.OpenChatResponse {
+	return dto.OpenChatResponse{
+		Room:             MapRoomDomainToResponse(room),
+		ChatPageResponse: MapRoomChatPageResponse(room, page),
+	}
+}
+
+// MapRoomChatPageResponse maps the page of the chats of the room along
+// with who has read them.
+func MapRoomChatPageResponse(room entity.Room, page vo.ChatPage) dto.ChatPageResponse {
+	res := dto.ChatPageResponse{
+		Chats:    []dto.ChatResponse{},
+		HasOlder: page.HasOlder,
+		HasNewer: page.HasNewer,
 	}
 
-	for _, v := range chats {
+	for _, v := range page.Chats {
+		res.Chats = append(res.Chats, MapRoomChatDomainToResponse(room, v))
+	}
+
+	return res
+}
+
+// MapChatPageResponse maps the page of chats which may be of different
+// rooms, without who has read them.
+func MapChatPageResponse(page vo.ChatPage) dto.ChatPageResponse {
+	res := dto.ChatPageResponse{
+		Chats:    []dto.ChatResponse{},
+		HasOlder: page.HasOlder,
+		HasNewer: page.HasNewer,
+	}
+
+	for _, v := range page.Chats {
 		res.Chats = append(res.Chats, MapChatDomainToResponse(v))
 	}
 
@@ -27,23 +51,122 @@ func MapOpenChatResponse(room entity.Room, chats []entity.Chat) dto.OpenChatResp
 }
 
 func MapOpenChatRoomRequestToDomain(req vo.OpenChatRequest) entity.Room {
+	if _id, err := primitive.ObjectIDFromHex(req.RoomId); err == nil {
+		return entity.Room{Id: _id, Participants: []string{req.SenderId}}
+	}
+
 	return entity.Room{
 		Participants: []string{req.SenderId, req.RecipientId},
 	}
 }
 
+func MapRoomDomainToResponse(room entity.Room) dto.RoomResponse {
+	kind := room.Kind
+	if room.IsDirect() {
+		kind = entity.DIRECT_ROOM
+	}
+
+	return dto.RoomResponse{
+		Id:           room.Id.Hex(),
+		Kind:         string(kind),
+		Name:         room.Name,
+		Avatar:       room.Avatar,
+		Topic:        room.Topic,
+		Participants: room.Participants,
+		Admins:       room.Admins,
+		TeamOf:       room.TeamOf,
+		CreatedAt:    room.CreatedAt.Time().In(utils.CURRENT_LOC).Format(time.RFC1123),
+	}
+}
+
+func MapRoomSummariesResponse(rooms []vo.RoomSummary) []dto.RoomSummaryResponse {
+	res := make([]dto.RoomSummaryResponse, 0, len(rooms))
+
+	for _, v := range rooms {
+		summary := dto.RoomSummaryResponse{
+			RoomResponse: MapRoomDomainToResponse(v.Room),
+			UnreadCount:  v.UnreadCount,
+		}
+		if v.LastChat != nil {
+			lastChat := MapRoomChatDomainToResponse(v.Room, *v.LastChat)
+			summary.LastChat = &lastChat
+		}
+		res = append(res, summary)
+	}
+
+	return res
+}
+
+func MapRoomRequestToDomain(req dto.RoomRequest) entity.Room {
+	return entity.Room{
+		Name:   strings.TrimSpace(req.Name),
+		Avatar: strings.TrimSpace(req.Avatar),
+		Topic:  strings.TrimSpace(req.Topic),
+	}
+}
+
 func MapChatDomainToResponse(chat entity.Chat) dto.ChatResponse {
 	return dto.ChatResponse{
 		Id:        chat.Id.Hex(),
 		RoomId:    chat.RoomId.Hex(),
 		Sender:    chat.Sender,
 		Message:   chat.Message,
-		Read:      chat.Read,
 		SentAt:    time.Unix(int64(chat.Timestamp.T), 0).In(utils.CURRENT_LOC).Format(time.RFC1123),
 		Timestamp: chat.Timestamp.T,
 	}
 }
 
+// MapRoomChatDomainToResponse maps the chat along with who of the room
+// has read it.
+func MapRoomChatDomainToResponse(room entity.Room, chat entity.Chat) dto.ChatResponse {
+	res := MapChatDomainToResponse(chat)
+	res.ReadBy = room.ReadBy(chat)
+
+	others := 0
+	for _, v := range room.Participants {
+		if v != chat.Sender {
+			others++
+		}
+	}
+	res.Read = others > 0 && len(res.ReadBy) == others
+
+	return res
+}
+
+func MapChatToEventResponse(chat entity.Chat) dto.ChatEventResponse {
+	res := MapChatDomainToResponse(chat)
+
+	return dto.ChatEventResponse{
+		Type: dto.CHAT_EVENT_MESSAGE,
+		Chat: &res,
+	}
+}
+
+func MapChatSignalToEventResponse(signal vo.ChatSignal) dto.ChatEventResponse {
+	res := dto.ChatEventResponse{
+		Type:       string(signal.Type),
+		EmployeeId: signal.EmployeeId,
+	}
+
+	switch signal.Type {
+	case vo.CHAT_SIGNAL_TYPING:
+		res.Typing = &signal.Typing
+	case vo.CHAT_SIGNAL_READ:
+		res.ChatId = signal.ChatId
+	case vo.CHAT_SIGNAL_PRESENCE:
+		res.Online = &signal.Online
+	}
+
+	return res
+}
+
+func MapErrorToEventResponse(err any) dto.ChatEventResponse {
+	return dto.ChatEventResponse{
+		Type:  dto.CHAT_EVENT_ERROR,
+		Error: err,
+	}
+}
+
 func MapFriendsList(friends []entity.Employee, userId string) []dto.BriefEmployeeListResponse {
 	for i, v := range friends {
 		if v.Id == userId {
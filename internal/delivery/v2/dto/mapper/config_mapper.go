@@ -21,6 +21,7 @@ func MapConfigResponse(data entity.Configuration) dto.GlobalConfigResponse {
 		AcceptanceLeaveInterval:      data.AcceptanceLeaveInterval,
 		DefaultYearlyQuota:           data.DefaultYearlyQuota,
 		DefaultMarriageQuota:         data.DefaultMarriageQuota,
+		GeofenceMode:                 string(data.GeofenceMode),
 	}
 }
 
@@ -34,14 +35,38 @@ func MapConfigDetailResponse(data entity.Configuration) dto.UpdateConfigRequest
 		AcceptanceLeaveInterval:      data.AcceptanceLeaveInterval,
 		DefaultYearlyQuota:           data.DefaultYearlyQuota,
 		DefaultMarriageQuota:         data.DefaultMarriageQuota,
+		GeofenceMode:                 string(data.GeofenceMode),
 	}
 }
 
+func MapOfficeLocationsToResponse(offices []entity.OfficeLocation) []dto.OfficeLocationResponse {
+	var res []dto.OfficeLocationResponse
+
+	for _, v := range offices {
+		office := dto.OfficeLocationResponse{
+			Id:   v.Id,
+			Name: v.Name,
+			Center: dto.LatLong{
+				Long: v.Center.X,
+				Lat:  v.Center.Y,
+			},
+			Radius: v.Radius,
+		}
+		for _, p := range v.Polygon {
+			office.Polygon = append(office.Polygon, dto.LatLong{Long: p.X, Lat: p.Y})
+		}
+
+		res = append(res, office)
+	}
+
+	return res
+}
+
 func MapConfigChangesLogToResponse(logs []entity.ConfigurationChangesLog) []dto.ConfigChangesLogsResponse {
 	var res []dto.ConfigChangesLogsResponse
 
 	for _, v := range logs {
-		res = append(res, dto.ConfigChangesLogsResponse{
+		log := dto.ConfigChangesLogsResponse{
 			Id: v.Id,
 			UpdatedBy: dto.BriefEmployeeListResponse{
 				Id:       v.UpdatedByID,
@@ -53,6 +78,30 @@ func MapConfigChangesLogToResponse(logs []entity.ConfigurationChangesLog) []dto.
 			Changes:     v.Changes,
 			UpdatedAt:   v.UpdatedAt.In(utils.CURRENT_LOC).Format(time.RFC1123),
 			WhenApplied: v.WhenApplied.In(utils.CURRENT_LOC).Format(time.RFC1123),
+			Scope:       string(v.Scope),
+		}
+		if v.AppliedAt != nil {
+			log.AppliedAt = v.AppliedAt.In(utils.CURRENT_LOC).Format(time.RFC1123)
+		}
+		if v.CancelledAt != nil {
+			log.CancelledAt = v.CancelledAt.In(utils.CURRENT_LOC).Format(time.RFC1123)
+		}
+
+		res = append(res, log)
+	}
+
+	return res
+}
+
+func MapPendingConfigChangesToResponse(pending []entity.PendingConfigChanges) []dto.PendingConfigChangesResponse {
+	res := []dto.PendingConfigChangesResponse{}
+
+	for _, v := range pending {
+		res = append(res, dto.PendingConfigChangesResponse{
+			Scope:       string(v.Scope),
+			WhenApplied: v.WhenApplied.In(utils.CURRENT_LOC).Format(time.RFC1123),
+			Changes:     v.Changes,
+			Logs:        MapConfigChangesLogToResponse(v.Logs),
 		})
 	}
 
@@ -74,5 +123,22 @@ func MapUpdateConfigToDomain(req dto.UpdateConfigRequest) entity.Configuration {
 		AcceptanceLeaveInterval:      req.AcceptanceLeaveInterval,
 		DefaultYearlyQuota:           req.DefaultYearlyQuota,
 		DefaultMarriageQuota:         req.DefaultMarriageQuota,
+		GeofenceMode:                 entity.GeofenceMode(req.GeofenceMode),
 	}
 }
+
+func MapOfficeLocationRequestToDomain(req dto.OfficeLocationRequest) entity.OfficeLocation {
+	office := entity.OfficeLocation{
+		Name: req.Name,
+		Center: entity.Point{
+			X: req.Center.Long,
+			Y: req.Center.Lat,
+		},
+		Radius: req.Radius,
+	}
+	for _, p := range req.Polygon {
+		office.Polygon = append(office.Polygon, entity.Point{X: p.Long, Y: p.Lat})
+	}
+
+	return office
+}
@@ -1,9 +1,12 @@
 package mapper
 
 import (
+	"time"
+
 	"sinarlog.com/internal/delivery/v2/dto"
 	"sinarlog.com/internal/entity"
 	"sinarlog.com/internal/entity/vo"
+	"sinarlog.com/internal/utils"
 )
 
 func MapLoginRequestToCredentialVO(req dto.LoginRequest) vo.Credential {
@@ -13,6 +16,20 @@ func MapLoginRequestToCredentialVO(req dto.LoginRequest) vo.Credential {
 	}
 }
 
+func MapRefreshRequestToCredentialVO(req dto.RefreshRequest) vo.Credential {
+	return vo.Credential{
+		RefreshToken: req.RefreshToken,
+	}
+}
+
+func MapResetPasswordRequestToCredentialVO(req dto.ResetPasswordRequest) vo.Credential {
+	return vo.Credential{
+		ResetToken:           req.Token,
+		NewPassword:          req.NewPassword,
+		ConfirmationPassword: req.ConfirmPassword,
+	}
+}
+
 func MapToLoginResponse(employee entity.Employee, cred vo.Credential) dto.LoginResponse {
 	return dto.LoginResponse{
 		ID:        employee.Id,
@@ -21,15 +38,77 @@ func MapToLoginResponse(employee entity.Employee, cred vo.Credential) dto.LoginR
 		Avatar:    employee.Avatar,
 		IsNewUser: employee.IsNewUser,
 		Role: dto.RoleResponse{
-			ID:   employee.RoleID,
-			Name: employee.Role.Name,
-			Code: employee.Role.Code,
+			ID:              employee.RoleID,
+			Name:            employee.Role.Name,
+			Code:            employee.Role.Code,
+			RequiresManager: employee.Role.RequiresManager,
 		},
 		Job: dto.JobResponse{
 			ID:   employee.JobID,
 			Name: employee.Job.Name,
 		},
+		AccessToken:   cred.AccessToken,
+		RefreshToken:  cred.RefreshToken,
+		RecoveryCodes: cred.RecoveryCodes,
+	}
+}
+
+func MapToLoginChallengeResponse(cred vo.Credential) dto.LoginChallengeResponse {
+	return dto.LoginChallengeResponse{
+		ChallengeToken:     cred.ChallengeToken,
+		EnrollmentRequired: cred.EnrollmentRequired,
+	}
+}
+
+func MapLoginChallengeRequestToCredentialVO(req dto.LoginChallengeRequest) vo.Credential {
+	return vo.Credential{
+		ChallengeToken: req.ChallengeToken,
+		OTP:            req.OTP,
+		RecoveryCode:   req.RecoveryCode,
+	}
+}
+
+func MapToRefreshResponse(cred vo.Credential) dto.RefreshResponse {
+	return dto.RefreshResponse{
 		AccessToken:  cred.AccessToken,
 		RefreshToken: cred.RefreshToken,
 	}
 }
+
+func MapSessionsToResponse(sessions []entity.Session, currentId string) []dto.SessionResponse {
+	res := []dto.SessionResponse{}
+
+	for _, v := range sessions {
+		res = append(res, dto.SessionResponse{
+			Id:         v.Id,
+			Device:     v.Device,
+			IP:         v.IP,
+			CreatedAt:  v.CreatedAt.In(utils.CURRENT_LOC).Format(time.RFC1123),
+			LastSeenAt: v.LastSeenAt.In(utils.CURRENT_LOC).Format(time.RFC1123),
+			ExpiresAt:  v.ExpiresAt.In(utils.CURRENT_LOC).Format(time.RFC1123),
+			IsCurrent:  v.Id == currentId,
+		})
+	}
+
+	return res
+}
+
+func MapOTPStatusToResponse(status vo.OTPStatus) dto.OTPStatusResponse {
+	res := dto.OTPStatusResponse{
+		IsEnrolled:        status.IsEnrolled,
+		IsMandatory:       status.IsMandatory,
+		RecoveryCodesLeft: status.RecoveryCodesLeft,
+	}
+	if status.EnrolledAt != nil {
+		res.EnrolledAt = status.EnrolledAt.In(utils.CURRENT_LOC).Format(time.RFC1123)
+	}
+
+	return res
+}
+
+func MapOTPEnrollmentToResponse(enrollment vo.OTPEnrollment) dto.OTPEnrollmentResponse {
+	return dto.OTPEnrollmentResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.ProvisioningURI,
+	}
+}
@@ -107,13 +107,17 @@ func MapEmployeeBiodataToResponse(biodata entity.EmployeeBiodata) dto.EmployeeBi
 	}
 }
 
-func MapEmployeeLeaveQuotaToResponse(quota entity.EmployeeLeavesQuota) dto.EmployeeLeaveQuotaResponse {
-	return dto.EmployeeLeaveQuotaResponse{
-		EmployeeID:    quota.EmployeeID,
-		YearlyCount:   quota.YearlyCount,
-		UnpaidCount:   quota.UnpaidCount,
-		MarriageCount: quota.MarriageCount,
+func MapEmployeeLeaveQuotaToResponse(quota entity.EmployeeLeavesQuota) []dto.EmployeeLeaveQuotaResponse {
+	res := []dto.EmployeeLeaveQuotaResponse{}
+
+	for _, v := range quota {
+		res = append(res, dto.EmployeeLeaveQuotaResponse{
+			Type:  string(v.Type),
+			Count: v.Count,
+		})
 	}
+
+	return res
 }
 
 func MapEmployeeEmergencyContactToResponse(contact entity.EmployeesEmergencyContact) dto.EmployeeEmergencyContactResponse {
@@ -157,11 +161,12 @@ func MapEmployeeFullProfileToResponse(employee entity.Employee) dto.EmployeeFull
 		Status:       string(employee.Status),
 		JoinDate:     employee.JoinDate.In(utils.CURRENT_LOC).Format(time.DateOnly),
 		Biodata:      MapEmployeeBiodataToResponse(employee.EmployeeBiodata),
-		LeaveQuota:   MapEmployeeLeaveQuotaToResponse(employee.EmployeeLeavesQuota),
+		LeaveQuota:   MapEmployeeLeaveQuotaToResponse(employee.EmployeeLeaveQuotas),
 		Role: dto.RoleResponse{
-			ID:   employee.RoleID,
-			Name: employee.Role.Name,
-			Code: employee.Role.Code,
+			ID:              employee.RoleID,
+			Name:            employee.Role.Name,
+			Code:            employee.Role.Code,
+			RequiresManager: employee.Role.RequiresManager,
 		},
 		Job: dto.JobResponse{
 			ID:   employee.JobID,
@@ -0,0 +1,190 @@
+package mapper
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sinarlog.com/internal/delivery/v2/dto"
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/utils"
+)
+
+/*
+*************************************************
+ENTITIES TO RESPONSE
+*************************************************
+*/
+func MapHolidaysToResponse(holidays []entity.Holiday) []dto.HolidayResponse {
+	var res []dto.HolidayResponse
+
+	for _, v := range holidays {
+		res = append(res, dto.HolidayResponse{
+			Id:   v.Id,
+			Name: v.Name,
+			Date: v.DateKey(),
+		})
+	}
+
+	return res
+}
+
+/*
+*************************************************
+REQUEST TO ENTITIES
+*************************************************
+*/
+func MapHolidayRequestToDomain(req dto.HolidayRequest) (entity.Holiday, error) {
+	res := entity.Holiday{Name: strings.TrimSpace(req.Name)}
+
+	date, err := time.Parse(time.DateOnly, req.Date)
+	if err != nil {
+		return res, fmt.Errorf("invalid holiday date format")
+	}
+	res.Date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, utils.CURRENT_LOC)
+
+	return res, nil
+}
+
+// MapHolidayCalendarToDomain parses an imported calendar file into
+// holidays. The format is decided by the file extension, either an
+// iCalendar (.ics) or a CSV (.csv) of date (YYYY-MM-DD) and name.
+func MapHolidayCalendarToDomain(filename string, file io.Reader) ([]entity.Holiday, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".ics":
+		return parseHolidaysICS(file)
+	case ".csv":
+		return parseHolidaysCSV(file)
+	default:
+		return nil, fmt.Errorf("calendar must be an ics or a csv file")
+	}
+}
+
+// parseHolidaysCSV parses rows of date and name. A header row
+// is skipped when its first column is not a date.
+func parseHolidaysCSV(file io.Reader) ([]entity.Holiday, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv file: %w", err)
+	}
+
+	var res []entity.Holiday
+	for i, row := range rows {
+		if len(row) < 2 {
+			return nil, fmt.Errorf("row %d must have a date and a name", i+1)
+		}
+
+		date, err := time.Parse(time.DateOnly, strings.TrimSpace(row[0]))
+		if err != nil {
+			if i == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("row %d has an invalid date format", i+1)
+		}
+
+		res = append(res, entity.Holiday{
+			Name: strings.TrimSpace(row[1]),
+			Date: time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, utils.CURRENT_LOC),
+		})
+	}
+
+	return res, nil
+}
+
+// parseHolidaysICS parses the all day events of an iCalendar file.
+// An event spanning several days becomes a holiday for each day.
+func parseHolidaysICS(file io.Reader) ([]entity.Holiday, error) {
+	raw, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unfold long content lines as described in RFC 5545
+	raw = bytes.ReplaceAll(raw, []byte("\r\n"), []byte("\n"))
+	raw = bytes.ReplaceAll(raw, []byte("\n "), nil)
+	raw = bytes.ReplaceAll(raw, []byte("\n\t"), nil)
+
+	var res []entity.Holiday
+	var inEvent bool
+	var name string
+	var start, end time.Time
+
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		prop, _, _ := strings.Cut(key, ";")
+
+		switch strings.ToUpper(prop) {
+		case "BEGIN":
+			if strings.EqualFold(value, "VEVENT") {
+				inEvent = true
+				name, start, end = "", time.Time{}, time.Time{}
+			}
+		case "SUMMARY":
+			name = unescapeICSText(value)
+		case "DTSTART":
+			if start, err = parseICSDate(value); err != nil {
+				return nil, err
+			}
+		case "DTEND":
+			if end, err = parseICSDate(value); err != nil {
+				return nil, err
+			}
+		case "END":
+			if !inEvent || !strings.EqualFold(value, "VEVENT") {
+				continue
+			}
+			inEvent = false
+
+			if start.IsZero() {
+				return nil, fmt.Errorf("event %q has no start date", name)
+			}
+			// The end date of an all day event is exclusive
+			if !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+			if utils.CountNumberOfDays(start, end) > 31 {
+				return nil, fmt.Errorf("event %q must not span more than 31 days", name)
+			}
+
+			for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+				res = append(res, entity.Holiday{Name: name, Date: day})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid ics file: %w", err)
+	}
+
+	return res, nil
+}
+
+func parseICSDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("invalid ics date %q", value)
+	}
+
+	date, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid ics date %q", value)
+	}
+
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, utils.CURRENT_LOC), nil
+}
+
+func unescapeICSText(value string) string {
+	return strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\N`, " ", `\\`, `\`).Replace(value)
+}
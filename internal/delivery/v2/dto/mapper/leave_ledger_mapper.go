@@ -0,0 +1,45 @@
+package mapper
+
+import (
+	"time"
+
+	"sinarlog.com/internal/delivery/v2/dto"
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/utils"
+)
+
+/*
+*************************************************
+ENTITIES TO RESPONSE
+*************************************************
+*/
+func MapLeaveQuotaLedgerToResponse(entries []entity.LeaveQuotaLedger) []dto.LeaveQuotaLedgerResponse {
+	res := []dto.LeaveQuotaLedgerResponse{}
+
+	for _, v := range entries {
+		entry := dto.LeaveQuotaLedgerResponse{
+			Id:        v.Id,
+			Type:      string(v.Type),
+			Source:    string(v.Source),
+			Amount:    v.Amount,
+			Balance:   v.Balance,
+			Period:    v.Period,
+			Note:      v.Note,
+			LeaveId:   v.LeaveID,
+			CreatedAt: v.CreatedAt.In(utils.CURRENT_LOC).Format(time.RFC1123),
+		}
+
+		if v.Actor != nil {
+			entry.Actor = &dto.BriefEmployeeListResponse{
+				Id:       v.Actor.Id,
+				FullName: v.Actor.FullName,
+				Email:    v.Actor.Email,
+				Avatar:   v.Actor.Avatar,
+			}
+		}
+
+		res = append(res, entry)
+	}
+
+	return res
+}
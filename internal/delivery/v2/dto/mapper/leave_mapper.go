@@ -33,9 +33,37 @@ func LeaveStatusMapper(v entity.Leave) string {
 		l = "CLOSED"
 	}
 
+	if v.CancelledAt != nil {
+		l = "CANCELLED"
+	}
+
 	return l
 }
 
+func leaveGranularity(v entity.Leave) string {
+	if v.Granularity == "" {
+		return string(entity.FULL_DAY)
+	}
+
+	return string(v.Granularity)
+}
+
+func leaveStartTime(v entity.Leave) string {
+	if !v.IsPartialDay() {
+		return ""
+	}
+
+	return v.From.In(utils.CURRENT_LOC).Format("15:04")
+}
+
+func leaveEndTime(v entity.Leave) string {
+	if !v.IsPartialDay() {
+		return ""
+	}
+
+	return v.To.In(utils.CURRENT_LOC).Format("15:04")
+}
+
 /*
 *************************************************
 REQUEST TO ENTITIES
@@ -43,8 +71,12 @@ REQUEST TO ENTITIES
 */
 func MapLeaveRequestToDomain(req dto.LeaveRequest) (entity.Leave, error) {
 	res := entity.Leave{
-		Reason: req.Reason,
-		Type:   entity.LeaveType(req.Type),
+		Reason:      req.Reason,
+		Type:        entity.LeaveType(req.Type),
+		Granularity: entity.LeaveGranularity(req.Granularity),
+	}
+	if res.Granularity == "" {
+		res.Granularity = entity.FULL_DAY
 	}
 
 	from, err := time.Parse(time.DateOnly, req.From)
@@ -52,7 +84,11 @@ func MapLeaveRequestToDomain(req dto.LeaveRequest) (entity.Leave, error) {
 		return res, fmt.Errorf("invalid start date format")
 	}
 	res.From = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, utils.CURRENT_LOC)
-	fmt.Println(from)
+
+	// A partial-day leave takes only a single day
+	if res.IsPartialDay() && req.To == "" {
+		req.To = req.From
+	}
 
 	to, err := time.Parse(time.DateOnly, req.To)
 	if err != nil {
@@ -60,6 +96,21 @@ func MapLeaveRequestToDomain(req dto.LeaveRequest) (entity.Leave, error) {
 	}
 	res.To = time.Date(to.Year(), to.Month(), to.Day(), 23, 59, 59, 0, utils.CURRENT_LOC)
 
+	// An hourly leave takes the hour range of the start date
+	if res.Granularity == entity.HOURLY {
+		start, err := time.Parse("15:04", req.StartTime)
+		if err != nil {
+			return res, fmt.Errorf("invalid start time format")
+		}
+		end, err := time.Parse("15:04", req.EndTime)
+		if err != nil {
+			return res, fmt.Errorf("invalid end time format")
+		}
+
+		res.From = time.Date(from.Year(), from.Month(), from.Day(), start.Hour(), start.Minute(), 0, 0, utils.CURRENT_LOC)
+		res.To = time.Date(from.Year(), from.Month(), from.Day(), end.Hour(), end.Minute(), 0, 0, utils.CURRENT_LOC)
+	}
+
 	return res, nil
 }
 
@@ -97,7 +148,10 @@ func MapMyLeaveRequestListToResponse(leaves []entity.Leave) []dto.MyLeaveRequest
 			From:        v.From.In(utils.CURRENT_LOC).Format(time.DateOnly),
 			To:          v.To.In(utils.CURRENT_LOC).Format(time.DateOnly),
 			LeaveType:   v.Type.String(),
-			Duration:    utils.CountNumberOfWorkingDays(v.From, v.To),
+			Duration:    v.Duration(),
+			Granularity: leaveGranularity(v),
+			StartTime:   leaveStartTime(v),
+			EndTime:     leaveEndTime(v),
 		}
 
 		l.Status = LeaveStatusMapper(v)
@@ -115,7 +169,10 @@ func MapMyLeaveRequestDetailToResponse(leave entity.Leave) dto.MyLeaveRequestDet
 		To:                  leave.To.In(utils.CURRENT_LOC).Format(time.DateOnly),
 		Type:                leave.Type.String(),
 		Reason:              leave.Reason,
-		Duration:            utils.CountNumberOfWorkingDays(leave.From, leave.To),
+		Duration:            leave.Duration(),
+		Granularity:         leaveGranularity(leave),
+		StartTime:           leaveStartTime(leave),
+		EndTime:             leaveEndTime(leave),
 		AttachmentUrl:       leave.AttachmentUrl,
 		ApprovedByHr:        leave.ApprovedByHr,
 		ApprovedByManager:   leave.ApprovedByManager,
@@ -124,6 +181,7 @@ func MapMyLeaveRequestDetailToResponse(leave entity.Leave) dto.MyLeaveRequestDet
 	}
 
 	res.Status = LeaveStatusMapper(leave)
+	res.Cancellation = mapLeaveCancellationToResponse(leave)
 
 	if leave.ActionByHrAt != nil {
 		s := leave.ActionByHrAt.In(utils.CURRENT_LOC).Format(time.RFC1123)
@@ -142,7 +200,10 @@ func MapMyLeaveRequestDetailToResponse(leave entity.Leave) dto.MyLeaveRequestDet
 			To:          leave.Parent.To.In(utils.CURRENT_LOC).Format(time.DateOnly),
 			Type:        leave.Parent.Type.String(),
 			Reason:      leave.Parent.Reason,
-			Duration:    utils.CountNumberOfWorkingDays(leave.Parent.From, leave.Parent.To),
+			Duration:    leave.Parent.Duration(),
+			Granularity: leaveGranularity(*leave.Parent),
+			StartTime:   leaveStartTime(*leave.Parent),
+			EndTime:     leaveEndTime(*leave.Parent),
 			RequestDate: leave.Parent.CreatedAt.In(utils.CURRENT_LOC).Format(time.DateOnly),
 		}
 
@@ -152,12 +213,15 @@ func MapMyLeaveRequestDetailToResponse(leave entity.Leave) dto.MyLeaveRequestDet
 	if leave.Childs != nil {
 		for _, v := range leave.Childs {
 			c := dto.LeaveRequest{
-				Id:       v.Id,
-				From:     v.From.In(utils.CURRENT_LOC).Format(time.DateOnly),
-				To:       v.To.In(utils.CURRENT_LOC).Format(time.DateOnly),
-				Type:     v.Type.String(),
-				Duration: utils.CountNumberOfWorkingDays(v.From, v.To),
-				Reason:   v.Reason,
+				Id:          v.Id,
+				From:        v.From.In(utils.CURRENT_LOC).Format(time.DateOnly),
+				To:          v.To.In(utils.CURRENT_LOC).Format(time.DateOnly),
+				Type:        v.Type.String(),
+				Duration:    v.Duration(),
+				Granularity: leaveGranularity(v),
+				StartTime:   leaveStartTime(v),
+				EndTime:     leaveEndTime(v),
+				Reason:      v.Reason,
 			}
 			c.Status = LeaveStatusMapper(v)
 			res.Childs = append(res.Childs, c)
@@ -182,6 +246,64 @@ func MapMyLeaveRequestDetailToResponse(leave entity.Leave) dto.MyLeaveRequestDet
 		}
 	}
 
+	res.ApprovalSteps = mapApprovalStepsToResponse(leave.ApprovalSteps)
+
+	return res
+}
+
+func MapIncomingLeaveCancellationsResponse(leaves []entity.Leave) []dto.IncomingLeaveCancellationResponse {
+	var res []dto.IncomingLeaveCancellationResponse
+
+	for _, v := range leaves {
+		d := dto.IncomingLeaveCancellationResponse{
+			Id:        v.Id,
+			Avatar:    v.Employee.Avatar,
+			FullName:  v.Employee.FullName,
+			From:      v.From.In(utils.CURRENT_LOC).Format(time.DateOnly),
+			To:        v.To.In(utils.CURRENT_LOC).Format(time.DateOnly),
+			Duration:  v.Duration(),
+			Type:      v.Type.String(),
+			Reason:    v.CancellationReason,
+			Overflows: len(v.Childs),
+		}
+		if v.CancellationRequestedAt != nil {
+			d.RequestDate = v.CancellationRequestedAt.In(utils.CURRENT_LOC).Format(time.DateOnly)
+		}
+
+		res = append(res, d)
+	}
+
+	return res
+}
+
+func mapLeaveCancellationToResponse(leave entity.Leave) *dto.LeaveCancellationResponse {
+	if leave.CancellationRequestedAt == nil {
+		return nil
+	}
+
+	res := &dto.LeaveCancellationResponse{
+		Reason:            leave.CancellationReason,
+		RequestedAt:       leave.CancellationRequestedAt.In(utils.CURRENT_LOC).Format(time.RFC1123),
+		ApprovedByManager: leave.CancellationApprovedByManager,
+		ApprovedByHr:      leave.CancellationApprovedByHr,
+		RejectionReason:   leave.CancellationRejectionReason,
+	}
+
+	if leave.CancellationActionByManagerAt != nil {
+		s := leave.CancellationActionByManagerAt.In(utils.CURRENT_LOC).Format(time.RFC1123)
+		res.ActionByManagerAt = &s
+	}
+
+	if leave.CancellationActionByHrAt != nil {
+		s := leave.CancellationActionByHrAt.In(utils.CURRENT_LOC).Format(time.RFC1123)
+		res.ActionByHrAt = &s
+	}
+
+	if leave.CancelledAt != nil {
+		s := leave.CancelledAt.In(utils.CURRENT_LOC).Format(time.RFC1123)
+		res.CancelledAt = &s
+	}
+
 	return res
 }
 
@@ -213,7 +335,10 @@ func MapLeaveRequestDetailToResponse(leave entity.Leave) dto.LeaveRequestDetailR
 		Avatar:              leave.Employee.Avatar,
 		FullName:            leave.Employee.FullName,
 		Email:               leave.Employee.Email,
-		Duration:            utils.CountNumberOfWorkingDays(leave.From, leave.To),
+		Duration:            leave.Duration(),
+		Granularity:         leaveGranularity(leave),
+		StartTime:           leaveStartTime(leave),
+		EndTime:             leaveEndTime(leave),
 		Reason:              leave.Reason,
 		AttachmentUrl:       leave.AttachmentUrl,
 		ApprovedByHr:        leave.ApprovedByHr,
@@ -223,6 +348,7 @@ func MapLeaveRequestDetailToResponse(leave entity.Leave) dto.LeaveRequestDetailR
 	}
 
 	res.Status = LeaveStatusMapper(leave)
+	res.Cancellation = mapLeaveCancellationToResponse(leave)
 
 	if leave.ActionByHrAt != nil {
 		s := leave.ActionByHrAt.In(utils.CURRENT_LOC).Format(time.RFC1123)
@@ -254,7 +380,10 @@ func MapLeaveRequestDetailToResponse(leave entity.Leave) dto.LeaveRequestDetailR
 				RequestDate:         v.CreatedAt.In(utils.CURRENT_LOC).Format(time.DateOnly),
 				From:                v.From.In(utils.CURRENT_LOC).Format(time.DateOnly),
 				To:                  v.To.In(utils.CURRENT_LOC).Format(time.DateOnly),
-				Duration:            utils.CountNumberOfWorkingDays(v.From, v.To),
+				Duration:            v.Duration(),
+				Granularity:         leaveGranularity(v),
+				StartTime:           leaveStartTime(v),
+				EndTime:             leaveEndTime(v),
 				Type:                v.Type.String(),
 				Reason:              v.Reason,
 				Status:              LeaveStatusMapper(v),
@@ -297,5 +426,7 @@ func MapLeaveRequestDetailToResponse(leave entity.Leave) dto.LeaveRequestDetailR
 		}
 	}
 
+	res.ApprovalSteps = mapApprovalStepsToResponse(leave.ApprovalSteps)
+
 	return res
 }
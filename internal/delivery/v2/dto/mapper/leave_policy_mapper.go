@@ -0,0 +1,91 @@
+package mapper
+
+import (
+	"strings"
+
+	"sinarlog.com/internal/delivery/v2/dto"
+	"sinarlog.com/internal/entity"
+)
+
+/*
+*************************************************
+ENTITIES TO RESPONSE
+*************************************************
+*/
+func MapLeavePoliciesToResponse(policies []entity.LeavePolicy) []dto.LeavePolicyResponse {
+	res := []dto.LeavePolicyResponse{}
+
+	for _, v := range policies {
+		res = append(res, MapLeavePolicyToResponse(v))
+	}
+
+	return res
+}
+
+func MapLeavePolicyToResponse(policy entity.LeavePolicy) dto.LeavePolicyResponse {
+	res := dto.LeavePolicyResponse{
+		Id:                 policy.Id,
+		Code:               string(policy.Code),
+		Name:               policy.Name,
+		HasQuota:           policy.HasQuota,
+		DefaultQuota:       policy.DefaultQuota,
+		IsPaid:             policy.IsPaid,
+		RequiresAttachment: policy.RequiresAttachment,
+		MinNoticeDays:      policy.MinNoticeDays,
+		AccrualPeriod:      string(policy.AccrualPeriod),
+		MaxCarryOver:       policy.MaxCarryOver,
+		CarryOverExpiry:    policy.CarryOverExpiryMonths,
+		ResetYearly:        policy.ResetYearly,
+		OverflowTargets:    []string{},
+		EligibleGenders:    []string{},
+		EligibleContracts:  []string{},
+	}
+
+	for _, v := range policy.OverflowTargets {
+		res.OverflowTargets = append(res.OverflowTargets, string(v))
+	}
+	for _, v := range policy.EligibleGenders {
+		res.EligibleGenders = append(res.EligibleGenders, string(v))
+	}
+	for _, v := range policy.EligibleContracts {
+		res.EligibleContracts = append(res.EligibleContracts, string(v))
+	}
+
+	return res
+}
+
+/*
+*************************************************
+REQUEST TO ENTITIES
+*************************************************
+*/
+func MapLeavePolicyRequestToDomain(req dto.LeavePolicyRequest) entity.LeavePolicy {
+	res := entity.LeavePolicy{
+		Code:                  entity.LeaveType(strings.ToUpper(strings.TrimSpace(req.Code))),
+		Name:                  strings.TrimSpace(req.Name),
+		HasQuota:              req.HasQuota,
+		DefaultQuota:          req.DefaultQuota,
+		IsPaid:                req.IsPaid,
+		RequiresAttachment:    req.RequiresAttachment,
+		MinNoticeDays:         req.MinNoticeDays,
+		AccrualPeriod:         entity.AccrualPeriod(strings.ToUpper(req.AccrualPeriod)),
+		MaxCarryOver:          req.MaxCarryOver,
+		CarryOverExpiryMonths: req.CarryOverExpiry,
+		ResetYearly:           req.ResetYearly,
+	}
+	if res.AccrualPeriod == "" {
+		res.AccrualPeriod = entity.NO_ACCRUAL
+	}
+
+	for _, v := range req.OverflowTargets {
+		res.OverflowTargets = append(res.OverflowTargets, entity.LeaveType(strings.ToUpper(v)))
+	}
+	for _, v := range req.EligibleGenders {
+		res.EligibleGenders = append(res.EligibleGenders, entity.Gender(strings.ToUpper(v)))
+	}
+	for _, v := range req.EligibleContracts {
+		res.EligibleContracts = append(res.EligibleContracts, entity.ContractType(strings.ToUpper(v)))
+	}
+
+	return res
+}
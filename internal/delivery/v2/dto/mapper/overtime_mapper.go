@@ -103,6 +103,8 @@ func MapOvertimeDetailToResponse(ov entity.Overtime) dto.OvertimeSubmissionDetai
 		}
 	}
 
+	res.ApprovalSteps = mapApprovalStepsToResponse(ov.ApprovalSteps)
+
 	return res
 }
 
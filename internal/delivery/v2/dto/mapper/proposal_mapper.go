@@ -24,7 +24,7 @@ func MapIncomingLeaveProposalsForManagerResponse(leaves []entity.Leave) []dto.In
 			RequestDate: v.CreatedAt.In(utils.CURRENT_LOC).Format(time.DateOnly),
 			From:        v.From.In(utils.CURRENT_LOC).Format(time.DateOnly),
 			To:          v.To.In(utils.CURRENT_LOC).Format(time.DateOnly),
-			Duration:    utils.CountNumberOfWorkingDays(v.From, v.To),
+			Duration:    v.Duration(),
 			Type:        v.Type.String(),
 			Status:      LeaveStatusMapper(v),
 		}
@@ -50,7 +50,7 @@ func MapIncomingLeaveProposalsForHrResponse(leaves []entity.Leave) []dto.Incomin
 			RequestDate: v.CreatedAt.In(utils.CURRENT_LOC).Format(time.DateOnly),
 			From:        v.From.In(utils.CURRENT_LOC).Format(time.DateOnly),
 			To:          v.To.In(utils.CURRENT_LOC).Format(time.DateOnly),
-			Duration:    utils.CountNumberOfWorkingDays(v.From, v.To),
+			Duration:    v.Duration(),
 			Type:        v.Type.String(),
 			Status:      LeaveStatusMapper(v),
 		}
@@ -75,7 +75,7 @@ func MapIncomingLeaveProposalDetailForManagerResponse(leave entity.Leave) dto.In
 		From:        leave.From.In(utils.CURRENT_LOC).Format(time.DateOnly),
 		To:          leave.To.In(utils.CURRENT_LOC).Format(time.DateOnly),
 		Reason:      leave.Reason,
-		Duration:    utils.CountNumberOfWorkingDays(leave.From, leave.To),
+		Duration:    leave.Duration(),
 		Type:        leave.Type.String(),
 		Status:      "PENDING",
 		Attachment:  leave.AttachmentUrl,
@@ -87,13 +87,15 @@ func MapIncomingLeaveProposalDetailForManagerResponse(leave entity.Leave) dto.In
 			From:     v.From.In(utils.CURRENT_LOC).Format(time.DateOnly),
 			To:       v.To.In(utils.CURRENT_LOC).Format(time.DateOnly),
 			Reason:   v.Reason,
-			Duration: utils.CountNumberOfWorkingDays(v.From, v.To),
+			Duration: v.Duration(),
 			Type:     v.Type.String(),
-			Status:   "PENDING",
+			// An overflow may be rejected on an earlier step
+			Status: LeaveStatusMapper(v),
 		}
 
 		res.Childs = append(res.Childs, d)
 	}
+	res.Steps = mapApprovalStepsToResponse(leave.ApprovalSteps)
 
 	return res
 }
@@ -109,7 +111,7 @@ func MapIncomingLeaveProposalDetailForHrResponse(leave entity.Leave) dto.Incomin
 		From:              leave.From.In(utils.CURRENT_LOC).Format(time.DateOnly),
 		To:                leave.To.In(utils.CURRENT_LOC).Format(time.DateOnly),
 		Reason:            leave.Reason,
-		Duration:          utils.CountNumberOfWorkingDays(leave.From, leave.To),
+		Duration:          leave.Duration(),
 		Type:              leave.Type.String(),
 		Status:            LeaveStatusMapper(leave),
 		Attachment:        leave.AttachmentUrl,
@@ -135,7 +137,7 @@ func MapIncomingLeaveProposalDetailForHrResponse(leave entity.Leave) dto.Incomin
 			From:              v.From.In(utils.CURRENT_LOC).Format(time.DateOnly),
 			To:                v.To.In(utils.CURRENT_LOC).Format(time.DateOnly),
 			Reason:            v.Reason,
-			Duration:          utils.CountNumberOfWorkingDays(v.From, v.To),
+			Duration:          v.Duration(),
 			Type:              v.Type.String(),
 			Status:            LeaveStatusMapper(v),
 			ApprovedByManager: v.ApprovedByManager,
@@ -147,5 +149,7 @@ func MapIncomingLeaveProposalDetailForHrResponse(leave entity.Leave) dto.Incomin
 		res.Childs = append(res.Childs, c)
 	}
 
+	res.ApprovalSteps = mapApprovalStepsToResponse(leave.ApprovalSteps)
+
 	return res
 }
@@ -1,6 +1,9 @@
 package mapper
 
 import (
+	"sort"
+	"strings"
+
 	"sinarlog.com/internal/delivery/v2/dto"
 	"sinarlog.com/internal/entity"
 )
@@ -10,9 +13,66 @@ func MapRolesResponse(roles []entity.Role) []dto.RoleResponse {
 
 	for _, v := range roles {
 		res = append(res, dto.RoleResponse{
-			ID:   v.Id,
-			Name: v.Name,
-			Code: v.Code,
+			ID:              v.Id,
+			Name:            v.Name,
+			Code:            v.Code,
+			RequiresManager: v.RequiresManager,
+		})
+	}
+
+	return res
+}
+
+func MapRoleDetailsResponse(roles []entity.Role) []dto.RoleDetailResponse {
+	res := []dto.RoleDetailResponse{}
+
+	for _, v := range roles {
+		role := dto.RoleDetailResponse{
+			ID:              v.Id,
+			Name:            v.Name,
+			Code:            v.Code,
+			RequiresManager: v.RequiresManager,
+			Permissions:     []dto.RolePermissionResponse{},
+		}
+		for _, grant := range v.Permissions {
+			role.Permissions = append(role.Permissions, dto.RolePermissionResponse{
+				Permission: string(grant.Permission),
+				Scope:      string(grant.Scope),
+			})
+		}
+		res = append(res, role)
+	}
+
+	return res
+}
+
+func MapPermissionsResponse(perms map[entity.Permission]string) []dto.PermissionResponse {
+	res := []dto.PermissionResponse{}
+
+	for perm, desc := range perms {
+		res = append(res, dto.PermissionResponse{
+			Permission:  string(perm),
+			Description: desc,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Permission < res[j].Permission
+	})
+
+	return res
+}
+
+func MapRoleRequestToDomain(req dto.RoleRequest) entity.Role {
+	res := entity.Role{
+		Name:            strings.TrimSpace(req.Name),
+		Code:            strings.ToLower(strings.TrimSpace(req.Code)),
+		RequiresManager: req.RequiresManager,
+	}
+
+	for _, v := range req.Permissions {
+		res.Permissions = append(res.Permissions, entity.RolePermission{
+			Permission: entity.Permission(strings.ToLower(v.Permission)),
+			Scope:      entity.PermissionScope(strings.ToUpper(v.Scope)),
 		})
 	}
 
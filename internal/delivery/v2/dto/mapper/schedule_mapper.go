@@ -0,0 +1,78 @@
+package mapper
+
+import (
+	"fmt"
+	"time"
+
+	"sinarlog.com/internal/delivery/v2/dto"
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/utils"
+)
+
+/*
+*************************************************
+ENTITIES TO RESPONSE
+*************************************************
+*/
+func MapWorkScheduleToResponse(schedule entity.WorkSchedule) dto.WorkScheduleResponse {
+	res := dto.WorkScheduleResponse{
+		Id:          schedule.Id,
+		Name:        schedule.Name,
+		CycleStart:  schedule.CycleStart.In(utils.CURRENT_LOC).Format(time.DateOnly),
+		CycleLength: schedule.CycleLength,
+		Shifts:      []dto.ShiftResponse{},
+	}
+
+	for _, v := range schedule.Shifts {
+		res.Shifts = append(res.Shifts, dto.ShiftResponse{
+			Day:       v.Day,
+			StartTime: v.StartTime.In(utils.CURRENT_LOC).Format(time.TimeOnly)[:5],
+			EndTime:   v.EndTime.In(utils.CURRENT_LOC).Format(time.TimeOnly)[:5],
+			Overnight: v.IsOvernight(),
+			FullDay:   v.FullDay,
+		})
+	}
+
+	return res
+}
+
+func MapWorkSchedulesToResponse(schedules []entity.WorkSchedule) []dto.WorkScheduleResponse {
+	var res []dto.WorkScheduleResponse
+
+	for _, v := range schedules {
+		res = append(res, MapWorkScheduleToResponse(v))
+	}
+
+	return res
+}
+
+/*
+*************************************************
+REQUEST TO ENTITIES
+*************************************************
+*/
+func MapWorkScheduleRequestToDomain(req dto.WorkScheduleRequest) (entity.WorkSchedule, error) {
+	res := entity.WorkSchedule{
+		Name:        req.Name,
+		CycleLength: req.CycleLength,
+	}
+
+	cycleStart, err := time.Parse(time.DateOnly, req.CycleStart)
+	if err != nil {
+		return res, fmt.Errorf("invalid cycle start date format")
+	}
+	res.CycleStart = time.Date(cycleStart.Year(), cycleStart.Month(), cycleStart.Day(), 0, 0, 0, 0, utils.CURRENT_LOC)
+
+	for _, v := range req.Shifts {
+		res.Shifts = append(res.Shifts, entity.Shift{
+			Day:             v.Day,
+			StartTimeHour:   v.StartTimeHour,
+			StartTimeMinute: v.StartTimeMinute,
+			EndTimeHour:     v.EndTimeHour,
+			EndTimeMinute:   v.EndTimeMinute,
+			FullDay:         v.FullDay,
+		})
+	}
+
+	return res, nil
+}
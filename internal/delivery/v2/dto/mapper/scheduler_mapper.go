@@ -0,0 +1,65 @@
+package mapper
+
+import (
+	"time"
+
+	"sinarlog.com/internal/delivery/v2/dto"
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/entity/vo"
+	"sinarlog.com/internal/utils"
+)
+
+/*
+*************************************************
+ENTITIES TO RESPONSE
+*************************************************
+*/
+func MapScheduledJobsToResponse(jobs []vo.ScheduledJobStatus) []dto.ScheduledJobResponse {
+	res := []dto.ScheduledJobResponse{}
+
+	for _, v := range jobs {
+		job := dto.ScheduledJobResponse{
+			Name:        v.Name,
+			Description: v.Description,
+			NextRunAt:   v.NextRunAt.In(utils.CURRENT_LOC).Format(time.RFC1123),
+		}
+		if v.LatestRun != nil {
+			run := mapJobRunToResponse(*v.LatestRun)
+			job.LatestRun = &run
+		}
+
+		res = append(res, job)
+	}
+
+	return res
+}
+
+func MapJobRunsToResponse(runs []entity.SchedulerJobRun) []dto.JobRunResponse {
+	res := []dto.JobRunResponse{}
+
+	for _, v := range runs {
+		res = append(res, mapJobRunToResponse(v))
+	}
+
+	return res
+}
+
+func mapJobRunToResponse(run entity.SchedulerJobRun) dto.JobRunResponse {
+	res := dto.JobRunResponse{
+		Id:          run.Id,
+		Trigger:     string(run.Trigger),
+		TriggeredBy: mapBriefEmployee(run.TriggeredBy),
+		Status:      string(run.Status),
+		StartedAt:   run.StartedAt.In(utils.CURRENT_LOC).Format(time.RFC1123),
+		Affected:    run.Affected,
+		Summary:     run.Summary,
+		Error:       run.Error,
+	}
+
+	if run.FinishedAt != nil {
+		t := run.FinishedAt.In(utils.CURRENT_LOC).Format(time.RFC1123)
+		res.FinishedAt = &t
+	}
+
+	return res
+}
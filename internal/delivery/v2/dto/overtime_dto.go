@@ -39,6 +39,7 @@ type OvertimeSubmissionDetailResponse struct {
 	RejectionReason     string                     `json:"rejectionReason,omitempty"`
 	Manager             *BriefEmployeeListResponse `json:"manager,omitempty"`
 	ClosedAutomatically bool                       `json:"closedAutomatically,omitempty"`
+	ApprovalSteps       []ApprovalStepResponse     `json:"approvalSteps,omitempty"`
 }
 
 type MyOvertimeSubmissionResponse struct {
@@ -1,16 +1,16 @@
 package dto
 
 type IncomingLeaveProposalsForManagerResponse struct {
-	Id          string `json:"id,omitempty"`
-	Avatar      string `json:"avatar,omitempty"`
-	FullName    string `json:"fullName,omitempty"`
-	RequestDate string `json:"requestDate,omitempty"`
-	From        string `json:"from,omitempty"`
-	To          string `json:"to,omitempty"`
-	Duration    int    `json:"duration,omitempty"`
-	Type        string `json:"type,omitempty"`
-	Status      string `json:"status,omitempty"`
-	Overflows   int    `json:"overflows,omitempty"`
+	Id          string  `json:"id,omitempty"`
+	Avatar      string  `json:"avatar,omitempty"`
+	FullName    string  `json:"fullName,omitempty"`
+	RequestDate string  `json:"requestDate,omitempty"`
+	From        string  `json:"from,omitempty"`
+	To          string  `json:"to,omitempty"`
+	Duration    float64 `json:"duration,omitempty"`
+	Type        string  `json:"type,omitempty"`
+	Status      string  `json:"status,omitempty"`
+	Overflows   int     `json:"overflows,omitempty"`
 }
 
 type IncomingLeaveProposalsForHrResponse IncomingLeaveProposalsForManagerResponse
@@ -24,11 +24,12 @@ type IncomingLeaveProposalDetailForManagerResponse struct {
 	From        string                                      `json:"from,omitempty"`
 	To          string                                      `json:"to,omitempty"`
 	Reason      string                                      `json:"reason,omitempty"`
-	Duration    int                                         `json:"duration,omitempty"`
+	Duration    float64                                     `json:"duration,omitempty"`
 	Type        string                                      `json:"type,omitempty"`
 	Status      string                                      `json:"status,omitempty"`
 	Attachment  string                                      `json:"attachment,omitempty"`
 	Childs      []IncomingLeaveProposalChildsDetailResponse `json:"childs,omitempty"`
+	Steps       []ApprovalStepResponse                      `json:"approvalSteps,omitempty"`
 }
 
 type IncomingLeaveProposalDetailForHrResponse struct {
@@ -41,7 +42,7 @@ type IncomingLeaveProposalDetailForHrResponse struct {
 	From              string                                      `json:"from,omitempty"`
 	To                string                                      `json:"to,omitempty"`
 	Reason            string                                      `json:"reason,omitempty"`
-	Duration          int                                         `json:"duration,omitempty"`
+	Duration          float64                                     `json:"duration,omitempty"`
 	Type              string                                      `json:"type,omitempty"`
 	Status            string                                      `json:"status,omitempty"`
 	Attachment        string                                      `json:"attachment,omitempty"`
@@ -50,6 +51,7 @@ type IncomingLeaveProposalDetailForHrResponse struct {
 	RejectionReason   string                                      `json:"rejectionReason,omitempty"`
 	Manager           *BriefEmployeeListResponse                  `json:"manager,omitempty"`
 	Childs            []IncomingLeaveProposalChildsDetailResponse `json:"childs,omitempty"`
+	ApprovalSteps     []ApprovalStepResponse                      `json:"approvalSteps,omitempty"`
 }
 
 type IncomingLeaveProposalChildsDetailResponse struct {
@@ -57,7 +59,7 @@ type IncomingLeaveProposalChildsDetailResponse struct {
 	From              string  `json:"from,omitempty"`
 	To                string  `json:"to,omitempty"`
 	Reason            string  `json:"reason,omitempty"`
-	Duration          int     `json:"duration,omitempty"`
+	Duration          float64 `json:"duration,omitempty"`
 	Type              string  `json:"type,omitempty"`
 	Status            string  `json:"status,omitempty"`
 	ApprovedByManager *bool   `json:"approvedByManager,omitempty"`
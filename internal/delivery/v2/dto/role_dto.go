@@ -1,7 +1,38 @@
 package dto
 
 type RoleResponse struct {
-	ID   string `json:"id,omitempty"`
-	Name string `json:"name,omitempty"`
-	Code string `json:"code,omitempty"`
+	ID              string `json:"id,omitempty"`
+	Name            string `json:"name,omitempty"`
+	Code            string `json:"code,omitempty"`
+	RequiresManager bool   `json:"requiresManager"`
+}
+
+type RolePermissionRequest struct {
+	Permission string `json:"permission" binding:"required"`
+	Scope      string `json:"scope" binding:"required"`
+}
+
+type RoleRequest struct {
+	Name            string                  `json:"name" binding:"required"`
+	Code            string                  `json:"code"`
+	RequiresManager bool                    `json:"requiresManager"`
+	Permissions     []RolePermissionRequest `json:"permissions"`
+}
+
+type RolePermissionResponse struct {
+	Permission string `json:"permission"`
+	Scope      string `json:"scope"`
+}
+
+type RoleDetailResponse struct {
+	ID              string                   `json:"id,omitempty"`
+	Name            string                   `json:"name,omitempty"`
+	Code            string                   `json:"code,omitempty"`
+	RequiresManager bool                     `json:"requiresManager"`
+	Permissions     []RolePermissionResponse `json:"permissions"`
+}
+
+type PermissionResponse struct {
+	Permission  string `json:"permission"`
+	Description string `json:"description"`
 }
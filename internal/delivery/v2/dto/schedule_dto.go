@@ -0,0 +1,58 @@
+package dto
+
+type ShiftRequest struct {
+	Day             int `json:"day"`
+	StartTimeHour   int `json:"startTimeHour"`
+	StartTimeMinute int `json:"startTimeMinute"`
+	EndTimeHour     int `json:"endTimeHour"`
+	EndTimeMinute   int `json:"endTimeMinute"`
+	// A full-day shift lasts 24 hours, hence it ends at the time it
+	// starts
+	FullDay bool `json:"fullDay"`
+}
+
+type WorkScheduleRequest struct {
+	Name        string         `json:"name" binding:"required"`
+	CycleStart  string         `json:"cycleStart" binding:"required"`
+	CycleLength int            `json:"cycleLength" binding:"required"`
+	Shifts      []ShiftRequest `json:"shifts"`
+}
+
+type AssignWorkScheduleRequest struct {
+	// A null work schedule makes the employees and
+	// jobs follow the default work schedule.
+	WorkScheduleId *string  `json:"workScheduleId"`
+	EmployeeIds    []string `json:"employeeIds"`
+	JobIds         []string `json:"jobIds"`
+}
+
+type ShiftResponse struct {
+	Day       int    `json:"day"`
+	StartTime string `json:"startTime,omitempty"`
+	EndTime   string `json:"endTime,omitempty"`
+	Overnight bool   `json:"overnight"`
+	FullDay   bool   `json:"fullDay"`
+}
+
+type WorkScheduleResponse struct {
+	Id          string          `json:"id,omitempty"`
+	Name        string          `json:"name,omitempty"`
+	CycleStart  string          `json:"cycleStart,omitempty"`
+	CycleLength int             `json:"cycleLength"`
+	Shifts      []ShiftResponse `json:"shifts"`
+}
+
+type HolidayRequest struct {
+	Name string `json:"name" binding:"required"`
+	Date string `json:"date" binding:"required"`
+}
+
+type HolidayResponse struct {
+	Id   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+	Date string `json:"date,omitempty"`
+}
+
+type ImportHolidaysResponse struct {
+	Imported int `json:"imported"`
+}
@@ -0,0 +1,20 @@
+package dto
+
+type ScheduledJobResponse struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	NextRunAt   string          `json:"nextRunAt"`
+	LatestRun   *JobRunResponse `json:"latestRun,omitempty"`
+}
+
+type JobRunResponse struct {
+	Id          string                     `json:"id"`
+	Trigger     string                     `json:"trigger"`
+	TriggeredBy *BriefEmployeeListResponse `json:"triggeredBy,omitempty"`
+	Status      string                     `json:"status"`
+	StartedAt   string                     `json:"startedAt"`
+	FinishedAt  *string                    `json:"finishedAt,omitempty"`
+	Affected    int                        `json:"affected"`
+	Summary     string                     `json:"summary,omitempty"`
+	Error       string                     `json:"error,omitempty"`
+}
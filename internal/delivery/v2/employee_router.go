@@ -3,10 +3,13 @@ package v2
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	"sinarlog.com/internal/app/service"
 	"sinarlog.com/internal/app/usecase"
+	"sinarlog.com/internal/delivery/middleware"
 	"sinarlog.com/internal/delivery/v2/dto"
 	"sinarlog.com/internal/delivery/v2/dto/mapper"
 	"sinarlog.com/internal/delivery/v2/model"
@@ -20,42 +23,52 @@ type EmployeeController struct {
 	leaveUC usecase.ILeaveUseCase
 	emplUC  usecase.IEmployeeUseCase
 	analUC  usecase.IAnalyticsUseCase
+	schedUC usecase.IScheduleUseCase
 }
 
-func NewEmployeeController(rg *gin.RouterGroup, attUC usecase.IAttendanceUseCase, leaveUC usecase.ILeaveUseCase, emplUC usecase.IEmployeeUseCase, analUC usecase.IAnalyticsUseCase) {
+func NewEmployeeController(rg *gin.RouterGroup, attUC usecase.IAttendanceUseCase, leaveUC usecase.ILeaveUseCase, emplUC usecase.IEmployeeUseCase, analUC usecase.IAnalyticsUseCase, schedUC usecase.IScheduleUseCase, srv service.IRaterService) {
 	controller := new(EmployeeController)
 	controller.attUC = attUC
 	controller.leaveUC = leaveUC
 	controller.emplUC = emplUC
 	controller.analUC = analUC
+	controller.schedUC = schedUC
 
-	att := rg.Group("/attendances")
+	can := middleware.NewMiddleware().PermissionMiddleware
+
+	att := rg.Group("/attendances", can(entity.SCOPE_OWN, entity.PERM_ATTENDANCE_CLOCK))
 	{
 		att.GET("/active", controller.getTodaysAttendance)
-		att.GET("/clockin", controller.requestClockInHandler)
-		att.POST("/clockin", controller.clockInHandler)
-		att.GET("/clockout", controller.requestClockOutHandler)
-		att.POST("/clockout", controller.clockOutHandler)
+		otp := middleware.NewMiddleware().RateLimiterMiddleware(srv, vo.OTP_RATE_POLICY)
+		att.GET("/clockin", otp, controller.requestClockInHandler)
+		att.POST("/clockin", otp, controller.clockInHandler)
+		att.GET("/clockout", otp, controller.requestClockOutHandler)
+		att.POST("/clockout", otp, controller.clockOutHandler)
 
 		att.GET("/history", controller.getMyAttendancesLog)
+		att.GET("/schedule", controller.getMyWorkScheduleHandler)
 	}
 
-	leaves := rg.Group("/leaves")
+	leaves := rg.Group("/leaves", can(entity.SCOPE_OWN, entity.PERM_LEAVE_APPLY))
 	{
 		leaves.GET("", controller.getMyLeaveRequestsHandler)
 		leaves.GET("/quota", controller.getEmployeeLeaveQuotaHandler)
+		leaves.GET("/quota/history", controller.getEmployeeLeaveQuotaLedgerHandler)
+		leaves.GET("/types", controller.getLeavePoliciesHandler)
 		leaves.GET("/:id", controller.getLeaveRequestById)
 		leaves.POST("/report", controller.getLeaveRequestReportHandler)
 		leaves.POST("", controller.applyForLeaveHandler)
+		leaves.PUT("/:id", controller.amendLeaveHandler)
+		leaves.POST("/:id/cancel", controller.cancelLeaveHandler)
 	}
 
-	ov := rg.Group("/overtimes")
+	ov := rg.Group("/overtimes", can(entity.SCOPE_OWN, entity.PERM_OVERTIME_READ))
 	{
 		ov.GET("", controller.getMyOvertimeSubmissions)
 		ov.GET("/:id", controller.getMyOvertimeSubmissionDetailHandler)
 	}
 
-	empl := rg.Group("/employees")
+	empl := rg.Group("/employees", can(entity.SCOPE_OWN, entity.PERM_EMPLOYEE_READ))
 	{
 		empl.GET("", controller.getEmployeeListHandler)
 		empl.GET(":id", controller.getEmployeeDetailHandler)
@@ -63,7 +76,7 @@ func NewEmployeeController(rg *gin.RouterGroup, attUC usecase.IAttendanceUseCase
 		empl.GET("/whos-taking-leave", controller.whosTakingLeaveHandler)
 	}
 
-	anal := rg.Group("/anal")
+	anal := rg.Group("/anal", can(entity.SCOPE_OWN, entity.PERM_ANALYTICS_READ))
 	{
 		anal.GET("/dashboard", controller.getDashboardAnalyticsHandler)
 	}
@@ -198,6 +211,35 @@ func (controller *EmployeeController) getEmployeeLeaveQuotaHandler(c *gin.Contex
 	controller.Ok(c, mapper.MapEmployeeLeaveQuotaToResponse(res))
 }
 
+func (controller *EmployeeController) getEmployeeLeaveQuotaLedgerHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+	q := vo.LeaveQuotaLedgerQuery{
+		CommonQuery: vo.CommonQuery{
+			Pagination: controller.ParsePagination(c),
+			TimeQuery:  controller.ParseTimeQuery(c),
+		},
+		Type: strings.ToUpper(c.Query("type")),
+	}
+
+	res, page, err := controller.leaveUC.RetrieveMyLeaveQuotaLedger(c.Request.Context(), user, q)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.OkWithPage(c, mapper.MapLeaveQuotaLedgerToResponse(res), page)
+}
+
+func (controller *EmployeeController) getLeavePoliciesHandler(c *gin.Context) {
+	res, err := controller.leaveUC.RetrieveLeavePolicies(c.Request.Context())
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapLeavePoliciesToResponse(res))
+}
+
 func (controller *EmployeeController) getLeaveRequestReportHandler(c *gin.Context) {
 	user := c.Keys["user"].(entity.Employee)
 
@@ -259,6 +301,46 @@ func (controller *EmployeeController) applyForLeaveHandler(c *gin.Context) {
 	controller.Created(c)
 }
 
+func (controller *EmployeeController) amendLeaveHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	var req dto.LeaveDecision
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.ClientError(c, usecase.NewClientError("Body", fmt.Errorf("body payload format is wrong")))
+		return
+	}
+
+	decision, err := mapper.MapLeaveDecisionToVO(req)
+	if err != nil {
+		controller.ClientError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	if err := controller.leaveUC.AmendLeave(c.Request.Context(), user, c.Param("id"), decision); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+func (controller *EmployeeController) cancelLeaveHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	var req dto.LeaveCancellationRequest
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.ClientError(c, usecase.NewClientError("Body", fmt.Errorf("body payload format is wrong")))
+		return
+	}
+
+	if err := controller.leaveUC.CancelLeave(c.Request.Context(), user, c.Param("id"), req.Reason); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
 func (controller *EmployeeController) getMyBiodataHandler(c *gin.Context) {
 	user := c.Keys["user"].(entity.Employee)
 
@@ -388,3 +470,15 @@ func (controller *EmployeeController) getMyOvertimeSubmissionDetailHandler(c *gi
 
 	controller.Ok(c, mapper.MapOvertimeDetailToResponse(res))
 }
+
+func (controller *EmployeeController) getMyWorkScheduleHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	res, err := controller.schedUC.RetrieveMySchedule(c.Request.Context(), user)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapWorkScheduleToResponse(res))
+}
@@ -3,6 +3,9 @@ package v2
 import (
 	"fmt"
 	"mime/multipart"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
@@ -13,6 +16,7 @@ import (
 	"sinarlog.com/internal/delivery/v2/model"
 	"sinarlog.com/internal/entity"
 	"sinarlog.com/internal/entity/vo"
+	"sinarlog.com/internal/utils"
 )
 
 type HrController struct {
@@ -22,6 +26,11 @@ type HrController struct {
 	attUC    usecase.IAttendanceUseCase
 	configUC usecase.IConfigUseCase
 	analUC   usecase.IAnalyticsUseCase
+	schedUC  usecase.IScheduleUseCase
+	apprUC   usecase.IApprovalUseCase
+	jobsUC   usecase.ISchedulerUseCase
+	credUC   usecase.ICredentialUseCase
+	roleUC   usecase.IRoleUseCase
 }
 
 func NewHrController(
@@ -31,6 +40,11 @@ func NewHrController(
 	attUC usecase.IAttendanceUseCase,
 	configUC usecase.IConfigUseCase,
 	analUC usecase.IAnalyticsUseCase,
+	schedUC usecase.IScheduleUseCase,
+	apprUC usecase.IApprovalUseCase,
+	jobsUC usecase.ISchedulerUseCase,
+	credUC usecase.ICredentialUseCase,
+	roleUC usecase.IRoleUseCase,
 ) {
 	controller := new(HrController)
 	controller.emplUC = emplUC
@@ -38,37 +52,57 @@ func NewHrController(
 	controller.attUC = attUC
 	controller.configUC = configUC
 	controller.analUC = analUC
+	controller.schedUC = schedUC
+	controller.apprUC = apprUC
+	controller.jobsUC = jobsUC
+	controller.credUC = credUC
+	controller.roleUC = roleUC
+
+	can := middleware.NewMiddleware().PermissionMiddleware
 
 	empl := rg.Group("/employees")
 	{
-		empl.GET("", controller.employeeListPagination(), controller.viewAllEmployeesHandler)
-		empl.GET("/:id", controller.viewEmployeeFullProfile)
-		empl.GET("/whos-taking-leave", controller.whosTakingLeaveHandler)
-		empl.GET("/managers", controller.fetchManagersList)
-
-		empl.POST("", controller.registerNewEmployeeHandler)
-		empl.PATCH("/:id", controller.updateEmployeeDataHandler)
-
-		empl.GET("/leaves/:employeeId", controller.getStaffEmployeeLeavesHandler)
-		empl.GET("/overtimes/:employeeId", controller.getStaffsOvertimesHandler)
-		empl.GET("/attendances/:employeeId", controller.getStaffAttendancesHandler)
-		empl.GET("/logs/:employeeId", controller.getEmployeeDataChangesLogHandler)
+		empl.GET("", can(entity.SCOPE_ALL, entity.PERM_EMPLOYEE_READ), controller.employeeListPagination(), controller.viewAllEmployeesHandler)
+		empl.GET("/:id", can(entity.SCOPE_ALL, entity.PERM_EMPLOYEE_READ), controller.viewEmployeeFullProfile)
+		empl.GET("/whos-taking-leave", can(entity.SCOPE_ALL, entity.PERM_LEAVE_READ), controller.whosTakingLeaveHandler)
+		empl.GET("/managers", can(entity.SCOPE_ALL, entity.PERM_EMPLOYEE_READ), controller.fetchManagersList)
+
+		empl.POST("", can(entity.SCOPE_ALL, entity.PERM_EMPLOYEE_CREATE), controller.registerNewEmployeeHandler)
+		empl.PATCH("/:id", can(entity.SCOPE_ALL, entity.PERM_EMPLOYEE_UPDATE), controller.updateEmployeeDataHandler)
+		empl.DELETE("/:id/two-factor", can(entity.SCOPE_ALL, entity.PERM_CREDENTIAL_MANAGE), controller.resetEmployeeTwoFactorHandler)
+		empl.DELETE("/:id/lockout", can(entity.SCOPE_ALL, entity.PERM_CREDENTIAL_MANAGE), controller.unlockEmployeeAccountHandler)
+
+		empl.GET("/leaves/:employeeId", can(entity.SCOPE_ALL, entity.PERM_LEAVE_READ), controller.getStaffEmployeeLeavesHandler)
+		empl.GET("/overtimes/:employeeId", can(entity.SCOPE_ALL, entity.PERM_OVERTIME_READ), controller.getStaffsOvertimesHandler)
+		empl.GET("/attendances/:employeeId", can(entity.SCOPE_ALL, entity.PERM_ATTENDANCE_READ), controller.getStaffAttendancesHandler)
+		empl.GET("/logs/:employeeId", can(entity.SCOPE_ALL, entity.PERM_EMPLOYEE_READ), controller.getEmployeeDataChangesLogHandler)
+		empl.GET("/leave-ledger/:employeeId", can(entity.SCOPE_ALL, entity.PERM_LEAVE_READ), controller.getEmployeeLeaveQuotaLedgerHandler)
+		empl.POST("/leave-ledger/:employeeId", can(entity.SCOPE_ALL, entity.PERM_LEAVE_ADJUST), controller.adjustEmployeeLeaveQuotaHandler)
 	}
 
 	proposals := rg.Group("/proposals")
 	{
-		proposals.GET("/leaves/incoming", controller.seeIncomingLeaveProposalsHandler)
-		proposals.GET("/leaves/incoming/:id", controller.seeIncomingLeaveProposalDetailHandler)
-		proposals.PATCH("/leaves/incoming", controller.takeActionOnLeaveProposalHandler)
+		leaves := proposals.Group("/leaves", can(entity.SCOPE_ALL, entity.PERM_LEAVE_APPROVE))
+		leaves.GET("/incoming", controller.seeIncomingLeaveProposalsHandler)
+		leaves.GET("/incoming/:id", controller.seeIncomingLeaveProposalDetailHandler)
+		leaves.PATCH("/incoming", controller.takeActionOnLeaveProposalHandler)
+
+		leaves.GET("/cancellations/incoming", controller.seeIncomingLeaveCancellationsHandler)
+		leaves.PATCH("/cancellations/incoming", controller.takeActionOnLeaveCancellationHandler)
 
-		proposals.GET("/leaves/history", controller.getLeaveProposalHistoryHandler)
-		proposals.GET("/leaves/history/:id", controller.getLeaveProposalHistoryDetailHandler)
+		leaves.GET("/history", controller.getLeaveProposalHistoryHandler)
+		leaves.GET("/history/:id", controller.getLeaveProposalHistoryDetailHandler)
 
-		proposals.GET("/overtimes/history", controller.getOvertimeSubmissionHistoryHandler)
-		proposals.GET("/overtimes/history/:id", controller.getOvertimeSubmissionHistoryDetailHandler)
+		overtimes := proposals.Group("/overtimes", can(entity.SCOPE_ALL, entity.PERM_OVERTIME_APPROVE))
+		overtimes.GET("/incoming", controller.seeIncomingOvertimeSubmissionsHandler)
+		overtimes.GET("/incoming/:id", controller.getOvertimeSubmissionHistoryDetailHandler)
+		overtimes.PATCH("/incoming", controller.takeActionOnOvertimeSubmissionHandler)
+
+		overtimes.GET("/history", controller.getOvertimeSubmissionHistoryHandler)
+		overtimes.GET("/history/:id", controller.getOvertimeSubmissionHistoryDetailHandler)
 	}
 
-	attendances := rg.Group("/attendances")
+	attendances := rg.Group("/attendances", can(entity.SCOPE_ALL, entity.PERM_ATTENDANCE_READ))
 	{
 		attendances.GET("/history", controller.getEmployeesAttendancesLog)
 		attendances.GET("/today", controller.getEmployeesTodaysAttendances)
@@ -76,12 +110,77 @@ func NewHrController(
 
 	cfg := rg.Group("/config")
 	{
-		cfg.GET("", controller.getConfigHandler)
-		cfg.GET("/logs", controller.getChangesLogsHandler)
-		cfg.PUT("", controller.updateConfigHandler)
+		cfg.GET("", can(entity.SCOPE_ALL, entity.PERM_CONFIG_READ), controller.getConfigHandler)
+		cfg.GET("/logs", can(entity.SCOPE_ALL, entity.PERM_CONFIG_READ), controller.getChangesLogsHandler)
+		cfg.GET("/pending", can(entity.SCOPE_ALL, entity.PERM_CONFIG_READ), controller.getPendingConfigChangesHandler)
+		cfg.DELETE("/pending/:scope", can(entity.SCOPE_ALL, entity.PERM_CONFIG_WRITE), controller.cancelPendingConfigChangesHandler)
+		cfg.PUT("", can(entity.SCOPE_ALL, entity.PERM_CONFIG_WRITE), controller.updateConfigHandler)
+
+		cfg.GET("/offices", can(entity.SCOPE_ALL, entity.PERM_CONFIG_READ), controller.getOfficeLocationsHandler)
+		cfg.POST("/offices", can(entity.SCOPE_ALL, entity.PERM_CONFIG_WRITE), controller.createOfficeLocationHandler)
+		cfg.PUT("/offices/:id", can(entity.SCOPE_ALL, entity.PERM_CONFIG_WRITE), controller.updateOfficeLocationHandler)
+		cfg.DELETE("/offices/:id", can(entity.SCOPE_ALL, entity.PERM_CONFIG_WRITE), controller.deleteOfficeLocationHandler)
+	}
+
+	sched := rg.Group("/schedules", can(entity.SCOPE_ALL, entity.PERM_SCHEDULE_WRITE))
+	{
+		sched.GET("", controller.getWorkSchedulesHandler)
+		sched.GET("/:id", controller.getWorkScheduleHandler)
+		sched.POST("", controller.createWorkScheduleHandler)
+		sched.PUT("/assignments", controller.assignWorkScheduleHandler)
+		sched.PUT("/:id", controller.updateWorkScheduleHandler)
+		sched.DELETE("/:id", controller.deleteWorkScheduleHandler)
+	}
+
+	holidays := rg.Group("/holidays", can(entity.SCOPE_ALL, entity.PERM_SCHEDULE_WRITE))
+	{
+		holidays.GET("", controller.getHolidaysHandler)
+		holidays.POST("", controller.createHolidayHandler)
+		holidays.POST("/import", controller.importHolidaysHandler)
+		holidays.PUT("/:id", controller.updateHolidayHandler)
+		holidays.DELETE("/:id", controller.deleteHolidayHandler)
+	}
+
+	leaveTypes := rg.Group("/leave-types", can(entity.SCOPE_ALL, entity.PERM_LEAVE_POLICY_WRITE))
+	{
+		leaveTypes.GET("", controller.getLeavePoliciesHandler)
+		leaveTypes.POST("", controller.createLeavePolicyHandler)
+		leaveTypes.PUT("/:id", controller.updateLeavePolicyHandler)
+		leaveTypes.DELETE("/:id", controller.deleteLeavePolicyHandler)
+	}
+
+	chains := rg.Group("/approval-chains", can(entity.SCOPE_ALL, entity.PERM_APPROVAL_WRITE))
+	{
+		chains.GET("", controller.getApprovalChainsHandler)
+		chains.POST("", controller.createApprovalChainHandler)
+		chains.PUT("/:id", controller.updateApprovalChainHandler)
+		chains.DELETE("/:id", controller.deleteApprovalChainHandler)
+	}
+
+	roles := rg.Group("/roles", can(entity.SCOPE_ALL, entity.PERM_ROLE_WRITE))
+	{
+		roles.GET("", controller.getRolesHandler)
+		roles.GET("/permissions", controller.getPermissionsHandler)
+		roles.POST("", controller.createRoleHandler)
+		roles.PUT("/:id", controller.updateRoleHandler)
+		roles.DELETE("/:id", controller.deleteRoleHandler)
+	}
+
+	delegations := rg.Group("/delegations", can(entity.SCOPE_ALL, entity.PERM_DELEGATION_WRITE))
+	{
+		delegations.GET("", controller.getApprovalDelegationsHandler)
+		delegations.POST("", controller.createApprovalDelegationHandler)
+		delegations.DELETE("/:id", controller.deleteApprovalDelegationHandler)
+	}
+
+	jobs := rg.Group("/scheduler/jobs", can(entity.SCOPE_ALL, entity.PERM_SCHEDULER_MANAGE))
+	{
+		jobs.GET("", controller.getScheduledJobsHandler)
+		jobs.GET("/:name/runs", controller.getJobRunsHandler)
+		jobs.POST("/:name/runs", controller.triggerJobHandler)
 	}
 
-	anal := rg.Group("/anal")
+	anal := rg.Group("/anal", can(entity.SCOPE_ALL, entity.PERM_ANALYTICS_READ))
 	{
 		anal.GET("", controller.getDashboardHrAnalyticsHandler)
 	}
@@ -239,6 +338,44 @@ func (controller *HrController) takeActionOnLeaveProposalHandler(c *gin.Context)
 	controller.Ok(c)
 }
 
+func (controller *HrController) seeIncomingLeaveCancellationsHandler(c *gin.Context) {
+	p := controller.ParsePagination(c)
+	t := controller.ParseTimeQuery(c)
+	n := c.Query("name")
+	q := vo.IncomingLeaveProposals{
+		CommonQuery: vo.CommonQuery{
+			Pagination: p,
+			TimeQuery:  t,
+		},
+		Name: n,
+	}
+
+	res, page, err := controller.leaveUC.SeeIncomingLeaveCancellationsForHr(c.Request.Context(), q)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.OkWithPage(c, mapper.MapIncomingLeaveCancellationsResponse(res), page)
+}
+
+func (controller *HrController) takeActionOnLeaveCancellationHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	var req vo.LeaveCancellationAction
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.ClientError(c, usecase.NewClientError("Body", fmt.Errorf("missing required fields")))
+		return
+	}
+
+	if err := controller.leaveUC.TakeActionOnLeaveCancellationForHr(c.Request.Context(), user, req); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
 func (controller *HrController) getLeaveProposalHistoryHandler(c *gin.Context) {
 	p := controller.ParsePagination(c)
 	t := controller.ParseTimeQuery(c)
@@ -312,6 +449,42 @@ func (controller *HrController) getOvertimeSubmissionHistoryHandler(c *gin.Conte
 	controller.OkWithPage(c, mapper.MapIncomingOvertimeSubmissionsToResponse(res), page)
 }
 
+func (controller *HrController) seeIncomingOvertimeSubmissionsHandler(c *gin.Context) {
+	p := controller.ParsePagination(c)
+	n := c.Query("name")
+	q := vo.IncomingOvertimeSubmissionsQuery{
+		CommonQuery: vo.CommonQuery{
+			Pagination: p,
+		},
+		Name: n,
+	}
+
+	res, page, err := controller.attUC.SeeIncomingOvertimeSubmissionsForHr(c.Request.Context(), q)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.OkWithPage(c, mapper.MapIncomingOvertimeSubmissionsToResponse(res), page)
+}
+
+func (controller *HrController) takeActionOnOvertimeSubmissionHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	var req vo.OvertimeSubmissionAction
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.ClientError(c, usecase.NewClientError("Body", fmt.Errorf("missing required fields")))
+		return
+	}
+
+	if err := controller.attUC.TakeActionOnOvertimeSubmissionByHr(c.Request.Context(), user, req); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
 func (controller *HrController) getOvertimeSubmissionHistoryDetailHandler(c *gin.Context) {
 	res, err := controller.attUC.RetrieveOvertimeSubmission(c.Request.Context(), c.Param("id"))
 	if err != nil {
@@ -331,10 +504,11 @@ func (controller *HrController) getEmployeesAttendancesLog(c *gin.Context) {
 			Pagination: p,
 			TimeQuery:  t,
 		},
-		Early:  c.Query("early") == "true",
-		Late:   c.Query("late") == "true",
-		Closed: c.Query("closed") == "true",
-		Name:   c.Query("name"),
+		Early:   c.Query("early") == "true",
+		Late:    c.Query("late") == "true",
+		Closed:  c.Query("closed") == "true",
+		Outside: c.Query("outside") == "true",
+		Name:    c.Query("name"),
 	}
 
 	res, page, err := controller.attUC.RetrieveEmployeesAttendanceHistory(c.Request.Context(), q)
@@ -353,10 +527,11 @@ func (controller *HrController) getEmployeesTodaysAttendances(c *gin.Context) {
 		CommonQuery: vo.CommonQuery{
 			Pagination: p,
 		},
-		Early:  c.Query("early") == "true",
-		Late:   c.Query("late") == "true",
-		Closed: c.Query("closed") == "true",
-		Name:   c.Query("name"),
+		Early:   c.Query("early") == "true",
+		Late:    c.Query("late") == "true",
+		Closed:  c.Query("closed") == "true",
+		Outside: c.Query("outside") == "true",
+		Name:    c.Query("name"),
 	}
 
 	res, page, err := controller.attUC.RetrieveEmployeesTodaysAttendances(c.Request.Context(), q)
@@ -454,6 +629,28 @@ func (controller *HrController) updateEmployeeDataHandler(c *gin.Context) {
 	controller.Ok(c)
 }
 
+func (controller *HrController) resetEmployeeTwoFactorHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	if err := controller.credUC.ResetTwoFactor(c.Request.Context(), user, c.Param("id")); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+func (controller *HrController) unlockEmployeeAccountHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	if err := controller.credUC.UnlockAccount(c.Request.Context(), user, c.Param("id")); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
 func (controller *HrController) getEmployeeDataChangesLogHandler(c *gin.Context) {
 	q := vo.CommonQuery{
 		Pagination: controller.ParsePagination(c),
@@ -468,6 +665,48 @@ func (controller *HrController) getEmployeeDataChangesLogHandler(c *gin.Context)
 	controller.OkWithPage(c, mapper.MapEmployeeChangesLogToResponse(res), page)
 }
 
+func (controller *HrController) getEmployeeLeaveQuotaLedgerHandler(c *gin.Context) {
+	q := vo.LeaveQuotaLedgerQuery{
+		CommonQuery: vo.CommonQuery{
+			Pagination: controller.ParsePagination(c),
+			TimeQuery:  controller.ParseTimeQuery(c),
+		},
+		Type: strings.ToUpper(c.Query("type")),
+	}
+
+	res, page, err := controller.leaveUC.RetrieveAnEmployeeLeaveQuotaLedger(c.Request.Context(), c.Param("employeeId"), q)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.OkWithPage(c, mapper.MapLeaveQuotaLedgerToResponse(res), page)
+}
+
+func (controller *HrController) adjustEmployeeLeaveQuotaHandler(c *gin.Context) {
+	var req dto.LeaveQuotaAdjustmentRequest
+	user := c.Keys["user"].(entity.Employee)
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	if err := controller.leaveUC.AdjustLeaveQuota(
+		c.Request.Context(),
+		user,
+		c.Param("employeeId"),
+		entity.LeaveType(strings.ToUpper(req.Type)),
+		req.Amount,
+		req.Reason,
+	); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Created(c)
+}
+
 func (controller *HrController) updateConfigHandler(c *gin.Context) {
 	var payload dto.UpdateConfigRequest
 	user := c.Keys["user"].(entity.Employee)
@@ -509,6 +748,500 @@ func (controller *HrController) getChangesLogsHandler(c *gin.Context) {
 	controller.OkWithPage(c, mapper.MapConfigChangesLogToResponse(res), page)
 }
 
+func (controller *HrController) getPendingConfigChangesHandler(c *gin.Context) {
+	res, err := controller.configUC.RetrievePendingConfigChanges(c.Request.Context())
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapPendingConfigChangesToResponse(res))
+}
+
+func (controller *HrController) cancelPendingConfigChangesHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+	scope := entity.ConfigChangeScope(strings.ToUpper(c.Param("scope")))
+
+	if err := controller.configUC.CancelPendingConfigChanges(c.Request.Context(), user, scope); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+func (controller *HrController) getOfficeLocationsHandler(c *gin.Context) {
+	res, err := controller.configUC.RetrieveOfficeLocations(c.Request.Context())
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapOfficeLocationsToResponse(res))
+}
+
+func (controller *HrController) createOfficeLocationHandler(c *gin.Context) {
+	var payload dto.OfficeLocationRequest
+
+	if err := c.ShouldBindBodyWith(&payload, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	if err := controller.configUC.RegisterOfficeLocation(c.Request.Context(), mapper.MapOfficeLocationRequestToDomain(payload)); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Created(c)
+}
+
+func (controller *HrController) updateOfficeLocationHandler(c *gin.Context) {
+	var payload dto.OfficeLocationRequest
+
+	if err := c.ShouldBindBodyWith(&payload, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	if err := controller.configUC.UpdateOfficeLocation(c.Request.Context(), c.Param("id"), mapper.MapOfficeLocationRequestToDomain(payload)); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+func (controller *HrController) deleteOfficeLocationHandler(c *gin.Context) {
+	if err := controller.configUC.RemoveOfficeLocation(c.Request.Context(), c.Param("id")); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+func (controller *HrController) getWorkSchedulesHandler(c *gin.Context) {
+	res, err := controller.schedUC.RetrieveWorkSchedules(c.Request.Context())
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapWorkSchedulesToResponse(res))
+}
+
+func (controller *HrController) getWorkScheduleHandler(c *gin.Context) {
+	res, err := controller.schedUC.RetrieveWorkSchedule(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapWorkScheduleToResponse(res))
+}
+
+func (controller *HrController) createWorkScheduleHandler(c *gin.Context) {
+	var req dto.WorkScheduleRequest
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	payload, err := mapper.MapWorkScheduleRequestToDomain(req)
+	if err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	if err := controller.schedUC.RegisterWorkSchedule(c.Request.Context(), payload); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Created(c)
+}
+
+func (controller *HrController) updateWorkScheduleHandler(c *gin.Context) {
+	var req dto.WorkScheduleRequest
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	payload, err := mapper.MapWorkScheduleRequestToDomain(req)
+	if err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	if err := controller.schedUC.UpdateWorkSchedule(c.Request.Context(), c.Param("id"), payload); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+func (controller *HrController) deleteWorkScheduleHandler(c *gin.Context) {
+	if err := controller.schedUC.RemoveWorkSchedule(c.Request.Context(), c.Param("id")); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+func (controller *HrController) getLeavePoliciesHandler(c *gin.Context) {
+	res, err := controller.leaveUC.RetrieveLeavePolicies(c.Request.Context())
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapLeavePoliciesToResponse(res))
+}
+
+func (controller *HrController) createLeavePolicyHandler(c *gin.Context) {
+	var req dto.LeavePolicyRequest
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	if err := controller.leaveUC.RegisterLeavePolicy(c.Request.Context(), mapper.MapLeavePolicyRequestToDomain(req)); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Created(c)
+}
+
+func (controller *HrController) updateLeavePolicyHandler(c *gin.Context) {
+	var req dto.LeavePolicyRequest
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	if err := controller.leaveUC.UpdateLeavePolicy(c.Request.Context(), c.Param("id"), mapper.MapLeavePolicyRequestToDomain(req)); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+func (controller *HrController) deleteLeavePolicyHandler(c *gin.Context) {
+	if err := controller.leaveUC.RemoveLeavePolicy(c.Request.Context(), c.Param("id")); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+func (controller *HrController) getRolesHandler(c *gin.Context) {
+	res, err := controller.roleUC.RetrieveRoles(c.Request.Context())
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapRoleDetailsResponse(res))
+}
+
+func (controller *HrController) getPermissionsHandler(c *gin.Context) {
+	controller.Ok(c, mapper.MapPermissionsResponse(controller.roleUC.RetrievePermissions(c.Request.Context())))
+}
+
+func (controller *HrController) createRoleHandler(c *gin.Context) {
+	var req dto.RoleRequest
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	if err := controller.roleUC.RegisterRole(c.Request.Context(), mapper.MapRoleRequestToDomain(req)); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Created(c)
+}
+
+func (controller *HrController) updateRoleHandler(c *gin.Context) {
+	var req dto.RoleRequest
+	user := c.Keys["user"].(entity.Employee)
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	if err := controller.roleUC.UpdateRole(c.Request.Context(), user, c.Param("id"), mapper.MapRoleRequestToDomain(req)); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+func (controller *HrController) deleteRoleHandler(c *gin.Context) {
+	if err := controller.roleUC.RemoveRole(c.Request.Context(), c.Param("id")); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+func (controller *HrController) getApprovalChainsHandler(c *gin.Context) {
+	res, err := controller.apprUC.RetrieveApprovalChains(c.Request.Context())
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapApprovalChainsToResponse(res))
+}
+
+func (controller *HrController) createApprovalChainHandler(c *gin.Context) {
+	var req dto.ApprovalChainRequest
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	if err := controller.apprUC.RegisterApprovalChain(c.Request.Context(), mapper.MapApprovalChainRequestToDomain(req)); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Created(c)
+}
+
+func (controller *HrController) updateApprovalChainHandler(c *gin.Context) {
+	var req dto.ApprovalChainRequest
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	if err := controller.apprUC.UpdateApprovalChain(c.Request.Context(), c.Param("id"), mapper.MapApprovalChainRequestToDomain(req)); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+func (controller *HrController) deleteApprovalChainHandler(c *gin.Context) {
+	if err := controller.apprUC.RemoveApprovalChain(c.Request.Context(), c.Param("id")); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+func (controller *HrController) getApprovalDelegationsHandler(c *gin.Context) {
+	res, err := controller.apprUC.RetrieveApprovalDelegations(c.Request.Context())
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapApprovalDelegationsToResponse(res))
+}
+
+func (controller *HrController) createApprovalDelegationHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	var req dto.ApprovalDelegationRequest
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	delegation, err := mapper.MapApprovalDelegationRequestToDomain(req)
+	if err != nil {
+		controller.ClientError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	if err := controller.apprUC.DelegateApproval(c.Request.Context(), user, delegation); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Created(c)
+}
+
+func (controller *HrController) deleteApprovalDelegationHandler(c *gin.Context) {
+	if err := controller.apprUC.RevokeApprovalDelegation(c.Request.Context(), c.Param("id")); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+func (controller *HrController) getScheduledJobsHandler(c *gin.Context) {
+	res, err := controller.jobsUC.RetrieveScheduledJobs(c.Request.Context())
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapScheduledJobsToResponse(res))
+}
+
+func (controller *HrController) getJobRunsHandler(c *gin.Context) {
+	q := vo.CommonQuery{Pagination: controller.ParsePagination(c)}
+
+	res, page, err := controller.jobsUC.RetrieveJobRuns(c.Request.Context(), c.Param("name"), q)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.OkWithPage(c, mapper.MapJobRunsToResponse(res), page)
+}
+
+// triggerJobHandler runs the job right away. The run is listed in
+// the runs of the job once it starts.
+func (controller *HrController) triggerJobHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	if err := controller.jobsUC.TriggerJob(c.Request.Context(), user, c.Param("name")); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Created(c)
+}
+
+func (controller *HrController) assignWorkScheduleHandler(c *gin.Context) {
+	var req dto.AssignWorkScheduleRequest
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	if err := controller.schedUC.AssignWorkSchedule(c.Request.Context(), req.WorkScheduleId, req.EmployeeIds, req.JobIds); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+func (controller *HrController) getHolidaysHandler(c *gin.Context) {
+	year, err := strconv.Atoi(c.DefaultQuery("year", strconv.Itoa(time.Now().In(utils.CURRENT_LOC).Year())))
+	if err != nil {
+		controller.ClientError(c, usecase.NewClientError("Query", fmt.Errorf("year must be a number")))
+		return
+	}
+
+	res, err := controller.schedUC.RetrieveHolidays(c.Request.Context(), year)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapHolidaysToResponse(res))
+}
+
+func (controller *HrController) createHolidayHandler(c *gin.Context) {
+	var req dto.HolidayRequest
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	payload, err := mapper.MapHolidayRequestToDomain(req)
+	if err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	if err := controller.schedUC.RegisterHoliday(c.Request.Context(), payload); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Created(c)
+}
+
+func (controller *HrController) updateHolidayHandler(c *gin.Context) {
+	var req dto.HolidayRequest
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	payload, err := mapper.MapHolidayRequestToDomain(req)
+	if err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	if err := controller.schedUC.UpdateHoliday(c.Request.Context(), c.Param("id"), payload); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+func (controller *HrController) deleteHolidayHandler(c *gin.Context) {
+	if err := controller.schedUC.RemoveHoliday(c.Request.Context(), c.Param("id")); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
+func (controller *HrController) importHolidaysHandler(c *gin.Context) {
+	file, header, err := c.Request.FormFile("calendar")
+	if err != nil {
+		controller.ClientError(c, usecase.NewClientError("Body", fmt.Errorf("calendar file is required")))
+		return
+	}
+	defer file.Close()
+
+	if err := controller.ValidateCalendarFileHeader(header); err != nil {
+		controller.ClientError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	payload, err := mapper.MapHolidayCalendarToDomain(header.Filename, file)
+	if err != nil {
+		controller.ClientError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	imported, err := controller.schedUC.ImportHolidays(c.Request.Context(), payload)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Created(c, dto.ImportHolidaysResponse{Imported: imported})
+}
+
 func (controller *HrController) getDashboardHrAnalyticsHandler(c *gin.Context) {
 	anal, err := controller.analUC.RetrieveDashboardAnalyticsHr(c.Request.Context())
 	if err != nil {
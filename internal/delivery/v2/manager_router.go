@@ -6,6 +6,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"sinarlog.com/internal/app/usecase"
+	"sinarlog.com/internal/delivery/middleware"
+	"sinarlog.com/internal/delivery/v2/dto"
 	"sinarlog.com/internal/delivery/v2/dto/mapper"
 	"sinarlog.com/internal/delivery/v2/model"
 	"sinarlog.com/internal/entity"
@@ -18,45 +20,61 @@ type ManagerController struct {
 	attUC   usecase.IAttendanceUseCase
 	analUC  usecase.IAnalyticsUseCase
 	emplUC  usecase.IEmployeeUseCase
+	apprUC  usecase.IApprovalUseCase
 }
 
-func NewManagerController(rg *gin.RouterGroup, leaveUC usecase.ILeaveUseCase, attUC usecase.IAttendanceUseCase, analUC usecase.IAnalyticsUseCase, emplUC usecase.IEmployeeUseCase) {
+func NewManagerController(rg *gin.RouterGroup, leaveUC usecase.ILeaveUseCase, attUC usecase.IAttendanceUseCase, analUC usecase.IAnalyticsUseCase, emplUC usecase.IEmployeeUseCase, apprUC usecase.IApprovalUseCase) {
 	controller := new(ManagerController)
 	controller.leaveUC = leaveUC
 	controller.attUC = attUC
 	controller.analUC = analUC
 	controller.emplUC = emplUC
+	controller.apprUC = apprUC
+
+	can := middleware.NewMiddleware().PermissionMiddleware
 
 	proposals := rg.Group("/proposals")
 	{
-		proposals.GET("/leaves/incoming", controller.seeIncomingLeaveProposalsHandler)
-		proposals.GET("/leaves/incoming/:id", controller.seeIncomingLeaveProposalDetailHandler)
-		proposals.PATCH("/leaves/incoming", controller.takeActionOnLeaveProposalHandler)
+		leaves := proposals.Group("/leaves", can(entity.SCOPE_DIRECT_REPORTS, entity.PERM_LEAVE_APPROVE))
+		leaves.GET("/incoming", controller.seeIncomingLeaveProposalsHandler)
+		leaves.GET("/incoming/:id", controller.seeIncomingLeaveProposalDetailHandler)
+		leaves.PATCH("/incoming", controller.takeActionOnLeaveProposalHandler)
+
+		leaves.GET("/cancellations/incoming", controller.seeIncomingLeaveCancellationsHandler)
+		leaves.PATCH("/cancellations/incoming", controller.takeActionOnLeaveCancellationHandler)
 
-		proposals.GET("/leaves/history", controller.getLeaveProposalHistoryHandler)
-		proposals.GET("/leaves/history/:id", controller.getLeaveProposalHistoryDetailHandler)
+		leaves.GET("/history", controller.getLeaveProposalHistoryHandler)
+		leaves.GET("/history/:id", controller.getLeaveProposalHistoryDetailHandler)
 
-		proposals.GET("/overtimes/incoming", controller.seeIncomingOvertimeSubmissionsHandler)
-		proposals.GET("/overtimes/incoming/:id", controller.seeIncomingOvertimeSubmissionDetailHandler)
-		proposals.PATCH("/overtimes/incoming", controller.takeActionOnOvertimeSubmissionHandler)
+		overtimes := proposals.Group("/overtimes", can(entity.SCOPE_DIRECT_REPORTS, entity.PERM_OVERTIME_APPROVE))
+		overtimes.GET("/incoming", controller.seeIncomingOvertimeSubmissionsHandler)
+		overtimes.GET("/incoming/:id", controller.seeIncomingOvertimeSubmissionDetailHandler)
+		overtimes.PATCH("/incoming", controller.takeActionOnOvertimeSubmissionHandler)
 
-		proposals.GET("/overtimes/history", controller.getOvertimeSubmissionHistoryHandler)
-		proposals.GET("/overtimes/history/:id", controller.seeIncomingOvertimeSubmissionDetailHandler)
+		overtimes.GET("/history", controller.getOvertimeSubmissionHistoryHandler)
+		overtimes.GET("/history/:id", controller.seeIncomingOvertimeSubmissionDetailHandler)
 
 	}
 
-	rg.GET("/attendances/history", controller.getStaffsAttendancesLog)
+	rg.GET("/attendances/history", can(entity.SCOPE_DIRECT_REPORTS, entity.PERM_ATTENDANCE_READ), controller.getStaffsAttendancesLog)
 
 	employees := rg.Group("/employees")
 	{
-		employees.GET("", controller.getEmployeeListHandler)
-		employees.GET(":id", controller.getEmployeeDetailHandler)
-		employees.GET("/leaves/:employeeId", controller.getStaffsLeaveRequestHandler)
-		employees.GET("/overtimes/:employeeId", controller.getStaffsOvertimeSubmissionsHandler)
-		employees.GET("/attendances/:employeeId", controller.getStaffsAttendanceHandler)
+		employees.GET("", can(entity.SCOPE_DIRECT_REPORTS, entity.PERM_EMPLOYEE_READ), controller.getEmployeeListHandler)
+		employees.GET(":id", can(entity.SCOPE_DIRECT_REPORTS, entity.PERM_EMPLOYEE_READ), controller.getEmployeeDetailHandler)
+		employees.GET("/leaves/:employeeId", can(entity.SCOPE_DIRECT_REPORTS, entity.PERM_LEAVE_READ), controller.getStaffsLeaveRequestHandler)
+		employees.GET("/overtimes/:employeeId", can(entity.SCOPE_DIRECT_REPORTS, entity.PERM_OVERTIME_READ), controller.getStaffsOvertimeSubmissionsHandler)
+		employees.GET("/attendances/:employeeId", can(entity.SCOPE_DIRECT_REPORTS, entity.PERM_ATTENDANCE_READ), controller.getStaffsAttendanceHandler)
+	}
+
+	delegations := rg.Group("/delegations", can(entity.SCOPE_OWN, entity.PERM_DELEGATION_WRITE))
+	{
+		delegations.GET("", controller.getMyApprovalDelegationsHandler)
+		delegations.POST("", controller.delegateApprovalHandler)
+		delegations.DELETE("/:id", controller.revokeApprovalDelegationHandler)
 	}
 
-	anal := rg.Group("/anal")
+	anal := rg.Group("/anal", can(entity.SCOPE_DIRECT_REPORTS, entity.PERM_ANALYTICS_READ))
 	{
 		anal.GET("/dashboard", controller.getDashboardAnalyticsHandler)
 	}
@@ -111,6 +129,45 @@ func (controller *ManagerController) takeActionOnLeaveProposalHandler(c *gin.Con
 	controller.Ok(c)
 }
 
+func (controller *ManagerController) seeIncomingLeaveCancellationsHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+	p := controller.ParsePagination(c)
+	t := controller.ParseTimeQuery(c)
+	n := c.Query("name")
+	q := vo.IncomingLeaveProposals{
+		CommonQuery: vo.CommonQuery{
+			Pagination: p,
+			TimeQuery:  t,
+		},
+		Name: n,
+	}
+
+	res, page, err := controller.leaveUC.SeeIncomingLeaveCancellationsForManager(c.Request.Context(), user, q)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.OkWithPage(c, mapper.MapIncomingLeaveCancellationsResponse(res), page)
+}
+
+func (controller *ManagerController) takeActionOnLeaveCancellationHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	var req vo.LeaveCancellationAction
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.ClientError(c, usecase.NewClientError("Body", fmt.Errorf("missing required fields")))
+		return
+	}
+
+	if err := controller.leaveUC.TakeActionOnLeaveCancellationForManager(c.Request.Context(), user, req); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
+
 func (controller *ManagerController) getDashboardAnalyticsHandler(c *gin.Context) {
 	user := c.Keys["user"].(entity.Employee)
 
@@ -362,3 +419,51 @@ func (controller *ManagerController) getEmployeeDetailHandler(c *gin.Context) {
 
 	controller.Ok(c, mapper.MapEmployeeFullProfileToResponse(res))
 }
+
+func (controller *ManagerController) getMyApprovalDelegationsHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	res, err := controller.apprUC.RetrieveMyApprovalDelegations(c.Request.Context(), user)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapApprovalDelegationsToResponse(res))
+}
+
+func (controller *ManagerController) delegateApprovalHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	var req dto.ApprovalDelegationRequest
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.ClientError(c, usecase.NewClientError("Body", fmt.Errorf("missing required fields")))
+		return
+	}
+
+	delegation, err := mapper.MapApprovalDelegationRequestToDomain(req)
+	if err != nil {
+		controller.ClientError(c, usecase.NewClientError("Body", err))
+		return
+	}
+	// A manager only delegates their own approval authority
+	delegation.DelegatorID = user.Id
+
+	if err := controller.apprUC.DelegateApproval(c.Request.Context(), user, delegation); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Created(c)
+}
+
+func (controller *ManagerController) revokeApprovalDelegationHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	if err := controller.apprUC.RevokeMyApprovalDelegation(c.Request.Context(), user, c.Param("id")); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
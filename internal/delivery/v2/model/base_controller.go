@@ -92,6 +92,10 @@ func (bc BaseControllerV2) Unauthorized(c *gin.Context, err error) {
 	bc.jsonErrResponse(c, http.StatusUnauthorized, err)
 }
 
+func (bc BaseControllerV2) Forbidden(c *gin.Context, err error) {
+	bc.jsonErrResponse(c, http.StatusForbidden, err)
+}
+
 func (bc BaseControllerV2) NotFound(c *gin.Context, err error) {
 	bc.jsonErrResponse(c, http.StatusNotFound, err)
 }
@@ -112,6 +116,10 @@ func (bc BaseControllerV2) TooManyRequest(c *gin.Context) {
 	bc.jsonErrResponse(c, http.StatusTooManyRequests, gin.H{"message": "Too many request, try again later"})
 }
 
+func (bc BaseControllerV2) ServiceUnavailable(c *gin.Context) {
+	bc.jsonErrResponse(c, http.StatusServiceUnavailable, gin.H{"message": "Service unavailable, try again later"})
+}
+
 func (bc BaseControllerV2) UnexpectedError(c *gin.Context, err error) {
 	bc.jsonErrResponse(c, http.StatusInternalServerError, err)
 }
@@ -135,6 +143,10 @@ func (bc BaseControllerV2) SummariesUseCaseError(c *gin.Context, err any) {
 			bc.Conflict(c, appError)
 		case usecase.ErrUnauthorized:
 			bc.Unauthorized(c, appError)
+		case usecase.ErrForbidden:
+			bc.Forbidden(c, appError)
+		case usecase.ErrTooManyRequests:
+			bc.jsonErrResponse(c, http.StatusTooManyRequests, appError)
 		}
 	} else {
 		bc.UnexpectedError(c, usecase.AppError{
@@ -182,6 +194,22 @@ func (bc BaseControllerV2) ValidateAttachmentFileHeader(header *multipart.FileHe
 	return nil
 }
 
+func (bc BaseControllerV2) ValidateCalendarFileHeader(header *multipart.FileHeader) error {
+	// Validate extension
+	split := strings.Split(header.Filename, ".")
+	ext := split[len(split)-1]
+	if err := validation.Validate(ext, validation.In("ics", "csv")); err != nil {
+		return fmt.Errorf("file must be an ics or a csv")
+	}
+
+	// Validate file size (compare in bytes)
+	if header.Size > 1e+6 {
+		return fmt.Errorf("file size is too large")
+	}
+
+	return nil
+}
+
 // ParsePagination method    parses a pagination request into a VO.
 // Keep in mind of these default values. Change in usecase if it
 // doesn't meet the usecase requirements.
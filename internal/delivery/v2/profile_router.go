@@ -5,7 +5,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	"sinarlog.com/internal/app/service"
 	"sinarlog.com/internal/app/usecase"
+	"sinarlog.com/internal/delivery/middleware"
 	"sinarlog.com/internal/delivery/v2/dto"
 	"sinarlog.com/internal/delivery/v2/dto/mapper"
 	"sinarlog.com/internal/delivery/v2/model"
@@ -16,17 +18,26 @@ import (
 type ProfileController struct {
 	model.BaseControllerV2
 	emplUC usecase.IEmployeeUseCase
+	credUC usecase.ICredentialUseCase
 }
 
-func NewProfileController(rg *gin.RouterGroup, emplUC usecase.IEmployeeUseCase) {
+func NewProfileController(rg *gin.RouterGroup, emplUC usecase.IEmployeeUseCase, credUC usecase.ICredentialUseCase, srv service.IRaterService) {
 	controller := new(ProfileController)
 	controller.emplUC = emplUC
+	controller.credUC = credUC
 
 	rg.GET("", controller.getMyProfileHandler)
 	rg.GET("/logs", controller.getMyChangesLog)
 	rg.PATCH("", controller.updateProfileDataHandler)
 	rg.PATCH("/update-password", controller.updatePasswordHandler)
 	rg.PATCH("/update-avatar", controller.updateAvatarHandler)
+
+	otp := middleware.NewMiddleware().RateLimiterMiddleware(srv, vo.OTP_RATE_POLICY)
+	rg.GET("/otp", controller.getOTPStatusHandler)
+	rg.POST("/otp/enroll", otp, controller.enrollOTPHandler)
+	rg.POST("/otp/enroll/confirm", otp, controller.confirmOTPEnrollmentHandler)
+	rg.POST("/otp/recovery-codes", otp, controller.regenerateRecoveryCodesHandler)
+	rg.DELETE("/otp", otp, controller.removeOTPEnrollmentHandler)
 }
 
 func (controller *ProfileController) getMyProfileHandler(c *gin.Context) {
@@ -121,3 +132,83 @@ func (controller *ProfileController) getMyChangesLog(c *gin.Context) {
 
 	controller.OkWithPage(c, mapper.MapEmployeeChangesLogToResponse(res), page)
 }
+
+func (controller *ProfileController) getOTPStatusHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	res, err := controller.credUC.RetrieveMyOTPStatus(c.Request.Context(), user)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapOTPStatusToResponse(res))
+}
+
+func (controller *ProfileController) enrollOTPHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	res, err := controller.credUC.EnrollOTP(c.Request.Context(), user)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, mapper.MapOTPEnrollmentToResponse(res))
+}
+
+func (controller *ProfileController) confirmOTPEnrollmentHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	var req dto.ConfirmOTPEnrollmentRequest
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	res, err := controller.credUC.ConfirmOTPEnrollment(c.Request.Context(), user, req.OTP)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, dto.RecoveryCodesResponse{RecoveryCodes: res})
+}
+
+func (controller *ProfileController) regenerateRecoveryCodesHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	var req dto.RecoveryCodesRequest
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	res, err := controller.credUC.RegenerateRecoveryCodes(c.Request.Context(), user, req.OTP)
+	if err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c, dto.RecoveryCodesResponse{RecoveryCodes: res})
+}
+
+func (controller *ProfileController) removeOTPEnrollmentHandler(c *gin.Context) {
+	user := c.Keys["user"].(entity.Employee)
+
+	var req dto.RemoveOTPEnrollmentRequest
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", err))
+		return
+	}
+
+	if err := controller.credUC.RemoveOTPEnrollment(c.Request.Context(), user, req.OTP, req.RecoveryCode); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
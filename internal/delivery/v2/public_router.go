@@ -5,10 +5,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	"sinarlog.com/internal/app/service"
 	"sinarlog.com/internal/app/usecase"
+	"sinarlog.com/internal/delivery/middleware"
 	"sinarlog.com/internal/delivery/v2/dto"
 	"sinarlog.com/internal/delivery/v2/dto/mapper"
 	"sinarlog.com/internal/delivery/v2/model"
+	"sinarlog.com/internal/entity/vo"
 )
 
 type PublicController struct {
@@ -25,6 +28,7 @@ func NewPublicController(
 	roleUC usecase.IRoleUseCase,
 	cfgUC usecase.IConfigUseCase,
 	credUC usecase.ICredentialUseCase,
+	srv service.IRaterService,
 ) {
 	controller := new(PublicController)
 	controller.jobUC = jobUC
@@ -32,10 +36,14 @@ func NewPublicController(
 	controller.cfgUC = cfgUC
 	controller.credUC = credUC
 
-	rg.GET("/roles", controller.getRolesHandler)
-	rg.GET("/jobs", controller.getJobsHandler)
-	rg.GET("/configs", controller.getGlobalConfig)
-	rg.POST("/forgot-password", controller.forgotPasswordHandler)
+	api := middleware.NewMiddleware().APIRateLimiterMiddleware(srv)
+	rg.GET("/roles", api, controller.getRolesHandler)
+	rg.GET("/jobs", api, controller.getJobsHandler)
+	rg.GET("/configs", api, controller.getGlobalConfig)
+
+	limit := middleware.NewMiddleware().RateLimiterMiddleware(srv, vo.AUTH_RATE_POLICY)
+	rg.POST("/forgot-password", limit, controller.forgotPasswordHandler)
+	rg.POST("/reset-password", limit, controller.resetPasswordHandler)
 }
 
 func (controller *PublicController) getRolesHandler(c *gin.Context) {
@@ -83,3 +91,19 @@ func (controller *PublicController) forgotPasswordHandler(c *gin.Context) {
 
 	controller.Ok(c)
 }
+
+func (controller *PublicController) resetPasswordHandler(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		controller.SummariesUseCaseError(c, usecase.NewClientError("Body", fmt.Errorf("error payload format, make sure you sent the right format")))
+		return
+	}
+
+	if err := controller.credUC.ResetPassword(c.Request.Context(), mapper.MapResetPasswordRequestToCredentialVO(req)); err != nil {
+		controller.SummariesUseCaseError(c, err)
+		return
+	}
+
+	controller.Ok(c)
+}
@@ -13,7 +13,10 @@ func NewRouter(r *gin.Engine, logger *logger.AppLogger, ucComposer composer.IUse
 
 	v2 := r.Group("/api/v2")
 	{
-		NewCredentialController(v2, ucComposer.CredentialUseCase(), ucComposer.RaterUseCase())
+		rater := ucComposer.RaterUseCase()
+		api := middleware.NewMiddleware().APIRateLimiterMiddleware(rater)
+
+		NewCredentialController(v2, ucComposer.CredentialUseCase(), rater)
 
 		ws := v2.Group("/ws")
 		{
@@ -22,32 +25,32 @@ func NewRouter(r *gin.Engine, logger *logger.AppLogger, ucComposer composer.IUse
 
 		chat := v2.Group("/chat")
 		{
-			NewChatController(chat, ucComposer.CredentialUseCase(), ucComposer.EmployeeUseCase(), ucComposer.ChatUseCase())
+			NewChatController(chat, ucComposer.CredentialUseCase(), ucComposer.EmployeeUseCase(), ucComposer.ChatUseCase(), rater)
 		}
 
 		pub := v2.Group("/pub")
 		{
-			NewPublicController(pub, ucComposer.JobUseCase(), ucComposer.RoleUseCase(), ucComposer.ConfigUseCase(), ucComposer.CredentialUseCase())
+			NewPublicController(pub, ucComposer.JobUseCase(), ucComposer.RoleUseCase(), ucComposer.ConfigUseCase(), ucComposer.CredentialUseCase(), rater)
 		}
 
-		hr := v2.Group("/hr", middleware.NewMiddleware().AuthMiddleware(ucComposer.CredentialUseCase(), "hr"))
+		hr := v2.Group("/hr", middleware.NewMiddleware().AuthMiddleware(ucComposer.CredentialUseCase()), api)
 		{
-			NewHrController(hr, ucComposer.EmployeeUseCase(), ucComposer.LeaveUseCase(), ucComposer.AttendanceUseCase(), ucComposer.ConfigUseCase(), ucComposer.AnalyticsUseCase())
+			NewHrController(hr, ucComposer.EmployeeUseCase(), ucComposer.LeaveUseCase(), ucComposer.AttendanceUseCase(), ucComposer.ConfigUseCase(), ucComposer.AnalyticsUseCase(), ucComposer.ScheduleUseCase(), ucComposer.ApprovalUseCase(), ucComposer.SchedulerUseCase(), ucComposer.CredentialUseCase(), ucComposer.RoleUseCase())
 		}
 
-		mngr := v2.Group("/mngr", middleware.NewMiddleware().AuthMiddleware(ucComposer.CredentialUseCase(), "mngr"))
+		mngr := v2.Group("/mngr", middleware.NewMiddleware().AuthMiddleware(ucComposer.CredentialUseCase()), api)
 		{
-			NewManagerController(mngr, ucComposer.LeaveUseCase(), ucComposer.AttendanceUseCase(), ucComposer.AnalyticsUseCase(), ucComposer.EmployeeUseCase())
+			NewManagerController(mngr, ucComposer.LeaveUseCase(), ucComposer.AttendanceUseCase(), ucComposer.AnalyticsUseCase(), ucComposer.EmployeeUseCase(), ucComposer.ApprovalUseCase())
 		}
 
-		empl := v2.Group("/empl", middleware.NewMiddleware().AuthMiddleware(ucComposer.CredentialUseCase(), "mngr", "staff"))
+		empl := v2.Group("/empl", middleware.NewMiddleware().AuthMiddleware(ucComposer.CredentialUseCase()), api)
 		{
-			NewEmployeeController(empl, ucComposer.AttendanceUseCase(), ucComposer.LeaveUseCase(), ucComposer.EmployeeUseCase(), ucComposer.AnalyticsUseCase())
+			NewEmployeeController(empl, ucComposer.AttendanceUseCase(), ucComposer.LeaveUseCase(), ucComposer.EmployeeUseCase(), ucComposer.AnalyticsUseCase(), ucComposer.ScheduleUseCase(), rater)
 		}
 
-		prfl := v2.Group("/profile", middleware.NewMiddleware().AuthMiddleware(ucComposer.CredentialUseCase(), "mngr", "staff", "hr"))
+		prfl := v2.Group("/profile", middleware.NewMiddleware().AuthMiddleware(ucComposer.CredentialUseCase()), api)
 		{
-			NewProfileController(prfl, ucComposer.EmployeeUseCase())
+			NewProfileController(prfl, ucComposer.EmployeeUseCase(), ucComposer.CredentialUseCase(), rater)
 		}
 	}
 }
@@ -0,0 +1,259 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// ErrApprovalStepDecided is returned when saving the decision of a
+// step which has been decided meanwhile.
+var ErrApprovalStepDecided = errors.New("the approval step has already been decided")
+
+// ApprovalKind is the kind of request an approval chain applies to.
+type ApprovalKind string
+
+const (
+	LEAVE_APPROVAL    ApprovalKind = "LEAVE"
+	OVERTIME_APPROVAL ApprovalKind = "OVERTIME"
+)
+
+// ApproverRole tells who approves a step of an approval chain,
+// relative to the requestee.
+type ApproverRole string
+
+const (
+	// APPROVER_MANAGER is the manager of the requestee
+	APPROVER_MANAGER ApproverRole = "MANAGER"
+	// APPROVER_DEPARTMENT_HEAD is the manager of the requestee's
+	// manager
+	APPROVER_DEPARTMENT_HEAD ApproverRole = "DEPARTMENT_HEAD"
+	// APPROVER_HR is any HR, hence it must be the last step
+	APPROVER_HR ApproverRole = "HR"
+)
+
+// ApprovalChain defines who approves a request and in which order.
+// A chain applies to the requests of its kind, of its leave type if
+// any, lasting more than MinDuration. MinDuration is in days for a
+// leave and in hours of overtime of the week for an overtime. For
+// example, an UNPAID leave chain with MinDuration 5 and steps of
+// MANAGER, DEPARTMENT_HEAD and HR applies to unpaid leaves of more
+// than 5 days.
+type ApprovalChain struct {
+	BaseModelId
+
+	Name        string       `gorm:"type:varchar(150)"`
+	Kind        ApprovalKind `gorm:"type:varchar(20)"`
+	LeaveType   LeaveType    `gorm:"type:varchar(100)"`
+	MinDuration float64
+	Steps       []ApproverRole `gorm:"serializer:json"`
+
+	BaseModelStamps
+	BaseModelSoftDelete
+}
+
+func (v ApprovalChain) Validate() error {
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.Name, validation.Required, validation.Length(3, 150)),
+		validation.Field(&v.Kind, validation.Required, validation.In(LEAVE_APPROVAL, OVERTIME_APPROVAL)),
+		validation.Field(&v.LeaveType, validation.When(v.Kind == OVERTIME_APPROVAL, validation.Empty.Error("an overtime approval chain must not have a leave type"))),
+		validation.Field(&v.MinDuration, validation.Min(0.0)),
+		validation.Field(&v.Steps,
+			validation.Required.Error("an approval chain must have at least one step"),
+			validation.Each(validation.In(APPROVER_MANAGER, APPROVER_DEPARTMENT_HEAD, APPROVER_HR)),
+			validation.By(func(value interface{}) error {
+				steps, _ := value.([]ApproverRole)
+				for i, step := range steps {
+					if step == APPROVER_HR && i != len(steps)-1 {
+						return fmt.Errorf("HR must be the last step of an approval chain")
+					}
+					if contains(steps[:i], step) {
+						return fmt.Errorf("each approver must appear only once in an approval chain")
+					}
+				}
+				return nil
+			}),
+		),
+	)
+}
+
+// Applies returns whether the chain applies to a request of the
+// leave type lasting the given duration.
+func (v ApprovalChain) Applies(leaveType LeaveType, duration float64) bool {
+	return (v.LeaveType == "" || v.LeaveType == leaveType) && duration > v.MinDuration
+}
+
+// BuildSteps creates the approval steps of a request from the
+// chain. The approvers are the ids of the employees of each role,
+// where a role without an employee, such as the department head of
+// a manager without one, is skipped. A request left without any
+// approver goes to HR.
+func (v ApprovalChain) BuildSteps(approvers map[ApproverRole]*string) []ApprovalStep {
+	var steps []ApprovalStep
+	var approved []string
+
+	for _, role := range v.Steps {
+		step := ApprovalStep{Sequence: len(steps) + 1, Role: role}
+
+		if role != APPROVER_HR {
+			id := approvers[role]
+			// The same employee approves a request only once
+			if id == nil || contains(approved, *id) {
+				continue
+			}
+			approved = append(approved, *id)
+			step.ApproverID = id
+		}
+
+		steps = append(steps, step)
+	}
+
+	if len(steps) == 0 {
+		steps = append(steps, ApprovalStep{Sequence: 1, Role: APPROVER_HR})
+	}
+
+	return steps
+}
+
+// ApprovalChains are the approval chains defined by HR.
+type ApprovalChains []ApprovalChain
+
+// Match returns the most specific chain applying to a request of
+// the kind. A chain of the leave type is preferred to a chain of
+// any leave type, then the chain with the highest MinDuration. The
+// default chain is returned if none applies.
+func (v ApprovalChains) Match(kind ApprovalKind, leaveType LeaveType, duration float64) ApprovalChain {
+	match := DefaultApprovalChain(kind)
+	found := false
+
+	for _, chain := range v {
+		if chain.Kind != kind || !chain.Applies(leaveType, duration) {
+			continue
+		}
+
+		specific, matchSpecific := chain.LeaveType != "", match.LeaveType != ""
+		if !found ||
+			(specific && !matchSpecific) ||
+			(specific == matchSpecific && chain.MinDuration > match.MinDuration) {
+			match = chain
+			found = true
+		}
+	}
+
+	return match
+}
+
+// DefaultApprovalChain is the chain of a request when HR has not
+// defined any applying to it. A leave is approved by the manager
+// then HR, meanwhile an overtime is approved by the manager only.
+func DefaultApprovalChain(kind ApprovalKind) ApprovalChain {
+	if kind == OVERTIME_APPROVAL {
+		return ApprovalChain{Name: "Default overtime approval", Kind: kind, Steps: []ApproverRole{APPROVER_MANAGER}}
+	}
+
+	return ApprovalChain{Name: "Default leave approval", Kind: kind, Steps: []ApproverRole{APPROVER_MANAGER, APPROVER_HR}}
+}
+
+// ApprovalStep is a step of the approval of a leave or an overtime.
+// The steps are decided one at a time by their Sequence. A step of
+// HR has no ApproverID since any HR may decide it, hence ActedByID
+// records who decided the step.
+type ApprovalStep struct {
+	BaseModelId
+
+	LeaveID    *string `gorm:"type:uuid;index;default:null"`
+	OvertimeID *string `gorm:"type:uuid;index;default:null"`
+	Sequence   int
+	Role       ApproverRole `gorm:"type:varchar(20)"`
+	ApproverID *string      `gorm:"type:uuid;default:null"`
+	Approver   *Employee
+	ActedByID  *string `gorm:"type:uuid;default:null"`
+	ActedBy    *Employee
+	// ActedAsDelegate is true if ActedByID decided the step on
+	// behalf of ApproverID through an ApprovalDelegation
+	ActedAsDelegate bool
+	Approved        *bool
+	Reason          string `gorm:"type:text"`
+	ActionAt        *time.Time
+
+	BaseModelStamps
+
+	// Set once the step is decided by Decide
+	decided bool
+}
+
+// IsNewlyDecided returns whether the step has been decided since it
+// was queried, hence its decision is to be saved.
+func (v ApprovalStep) IsNewlyDecided() bool {
+	return v.decided
+}
+
+// ApprovalSteps are the approval steps of a request ordered by
+// their sequence.
+type ApprovalSteps []ApprovalStep
+
+// Current returns the index of the step waiting for a decision. It
+// returns false once a step is rejected or all steps are approved.
+func (v ApprovalSteps) Current() (int, bool) {
+	for i, step := range v {
+		if step.Approved == nil {
+			return i, true
+		}
+		if !*step.Approved {
+			return i, false
+		}
+	}
+
+	return len(v), false
+}
+
+// IsWaitingFor returns whether the current step is to be decided
+// by any of the employees. A step of HR is never waiting for an
+// employee.
+func (v ApprovalSteps) IsWaitingFor(employeeIds ...string) bool {
+	i, ok := v.Current()
+
+	return ok && v[i].ApproverID != nil && contains(employeeIds, *v[i].ApproverID)
+}
+
+// IsLastReview returns whether no step other than the one of HR
+// comes after the i-th step.
+func (v ApprovalSteps) IsLastReview(i int) bool {
+	for _, step := range v[i+1:] {
+		if step.Role != APPROVER_HR {
+			return false
+		}
+	}
+
+	return true
+}
+
+// HasReview returns whether any step is decided by an employee
+// other than HR.
+func (v ApprovalSteps) HasReview() bool {
+	for _, step := range v {
+		if step.Role != APPROVER_HR {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasHr returns whether HR decides the last step.
+func (v ApprovalSteps) HasHr() bool {
+	return len(v) > 0 && v[len(v)-1].Role == APPROVER_HR
+}
+
+// Decide records the decision of the i-th step. An actor other than
+// the approver of the step decides it as a delegate.
+func (v ApprovalSteps) Decide(i int, actorId string, approved bool, reason string, at time.Time) {
+	v[i].ActedByID = &actorId
+	v[i].ActedAsDelegate = v[i].ApproverID != nil && *v[i].ApproverID != actorId
+	v[i].Approved = &approved
+	v[i].Reason = reason
+	v[i].ActionAt = &at
+	v[i].decided = true
+}
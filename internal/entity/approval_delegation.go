@@ -0,0 +1,63 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// ApprovalDelegation hands the approval authority of the delegator
+// to the delegate from From to To, such as while the delegator is on
+// leave. The delegate decides the requests waiting on the delegator
+// in the meantime, and the steps they decide are recorded as acted
+// as delegate. The authority is not delegated any further.
+type ApprovalDelegation struct {
+	BaseModelId
+
+	DelegatorID string `gorm:"type:uuid;index"`
+	Delegator   *Employee
+	DelegateID  string `gorm:"type:uuid;index"`
+	Delegate    *Employee
+	From        time.Time
+	To          time.Time
+	Reason      string `gorm:"type:text"`
+	// The delegator or HR on behalf of the delegator
+	CreatedByID string `gorm:"type:uuid"`
+	CreatedBy   *Employee
+
+	BaseModelStamps
+	BaseModelSoftDelete
+}
+
+func (v ApprovalDelegation) Validate() error {
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.DelegatorID, validation.Required),
+		validation.Field(&v.DelegateID,
+			validation.Required,
+			validation.NotIn(v.DelegatorID).Error("approval authority must not be delegated to oneself"),
+		),
+		validation.Field(&v.From, validation.Required),
+		validation.Field(&v.To,
+			validation.Required,
+			validation.By(func(value interface{}) error {
+				if v.To.Before(v.From) {
+					return fmt.Errorf("the end date must not be before the start date")
+				}
+				return nil
+			}),
+		),
+		validation.Field(&v.Reason, validation.Length(0, 1000)),
+	)
+}
+
+// IsActive returns whether the delegation is in effect at t.
+func (v ApprovalDelegation) IsActive(t time.Time) bool {
+	return !t.Before(v.From) && !t.After(v.To)
+}
+
+// Overlaps returns whether both delegations are in effect at the
+// same time.
+func (v ApprovalDelegation) Overlaps(o ApprovalDelegation) bool {
+	return !v.To.Before(o.From) && !o.To.Before(v.From)
+}
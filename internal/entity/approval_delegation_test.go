@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"sinarlog.com/internal/utils"
+)
+
+func TestApprovalDelegationDates(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2024, time.April, d, 0, 0, 0, 0, utils.CURRENT_LOC)
+	}
+	// From 8th to the end of 12th April 2024
+	delegation := ApprovalDelegation{DelegatorID: "a", DelegateID: "b", From: day(8), To: day(13).Add(-time.Second)}
+
+	overlaps := []struct {
+		name     string
+		from, to time.Time
+		overlaps bool
+	}{
+		{"Before", day(1), day(7), false},
+		{"Ending on the first day", day(1), day(8), true},
+		{"Within", day(9), day(10), true},
+		{"Covering", day(1), day(20), true},
+		{"Starting on the last day", day(12), day(20), true},
+		{"After", day(13), day(20), false},
+	}
+	for _, tc := range overlaps {
+		t.Run(tc.name, func(t *testing.T) {
+			other := ApprovalDelegation{From: tc.from, To: tc.to}
+			if delegation.Overlaps(other) != tc.overlaps || other.Overlaps(delegation) != tc.overlaps {
+				t.Fatalf("expected overlaps to be %v", tc.overlaps)
+			}
+		})
+	}
+
+	if !delegation.IsActive(day(8)) || !delegation.IsActive(day(12).Add(23*time.Hour)) {
+		t.Fatalf("expected the delegation to be active from its first to its last day")
+	}
+	if delegation.IsActive(day(8).Add(-time.Second)) || delegation.IsActive(day(13)) {
+		t.Fatalf("expected the delegation to be inactive outside its days")
+	}
+
+	if err := delegation.Validate(); err != nil {
+		t.Fatalf("expected the delegation to be valid, got %s", err)
+	}
+	reversed := delegation
+	reversed.From, reversed.To = delegation.To, delegation.From
+	if reversed.Validate() == nil {
+		t.Fatalf("expected a delegation ending before it starts to be invalid")
+	}
+}
@@ -0,0 +1,130 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildingApprovalSteps(t *testing.T) {
+	manager, head := "manager", "head"
+	chain := ApprovalChain{Steps: []ApproverRole{APPROVER_MANAGER, APPROVER_DEPARTMENT_HEAD, APPROVER_HR}}
+
+	cases := []struct {
+		name      string
+		chain     ApprovalChain
+		approvers map[ApproverRole]*string
+		roles     []ApproverRole
+	}{
+		{
+			name:      "every approver of the chain",
+			chain:     chain,
+			approvers: map[ApproverRole]*string{APPROVER_MANAGER: &manager, APPROVER_DEPARTMENT_HEAD: &head},
+			roles:     []ApproverRole{APPROVER_MANAGER, APPROVER_DEPARTMENT_HEAD, APPROVER_HR},
+		},
+		{
+			name:      "a manager without a department head",
+			chain:     chain,
+			approvers: map[ApproverRole]*string{APPROVER_MANAGER: &manager},
+			roles:     []ApproverRole{APPROVER_MANAGER, APPROVER_HR},
+		},
+		{
+			name:      "the same employee approves only once",
+			chain:     chain,
+			approvers: map[ApproverRole]*string{APPROVER_MANAGER: &manager, APPROVER_DEPARTMENT_HEAD: &manager},
+			roles:     []ApproverRole{APPROVER_MANAGER, APPROVER_HR},
+		},
+		{
+			name:      "a chain of HR only",
+			chain:     ApprovalChain{Steps: []ApproverRole{APPROVER_HR}},
+			approvers: map[ApproverRole]*string{APPROVER_MANAGER: &manager},
+			roles:     []ApproverRole{APPROVER_HR},
+		},
+		{
+			name:      "a request without any approver goes to HR",
+			chain:     DefaultApprovalChain(OVERTIME_APPROVAL),
+			approvers: map[ApproverRole]*string{},
+			roles:     []ApproverRole{APPROVER_HR},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			steps := c.chain.BuildSteps(c.approvers)
+
+			if len(steps) != len(c.roles) {
+				t.Fatalf("expected %d steps, got %+v", len(c.roles), steps)
+			}
+			for i, role := range c.roles {
+				if steps[i].Role != role || steps[i].Sequence != i+1 {
+					t.Fatalf("expected step %d to be %s, got %s at %d", i+1, role, steps[i].Role, steps[i].Sequence)
+				}
+				if (role == APPROVER_HR) != (steps[i].ApproverID == nil) {
+					t.Fatalf("expected only the step of HR to have no approver, got %+v", steps[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDecidingApprovalSteps(t *testing.T) {
+	manager, head, delegate := "manager", "head", "delegate"
+	now := time.Now()
+	build := func() ApprovalSteps {
+		return ApprovalChain{Steps: []ApproverRole{APPROVER_MANAGER, APPROVER_DEPARTMENT_HEAD, APPROVER_HR}}.
+			BuildSteps(map[ApproverRole]*string{APPROVER_MANAGER: &manager, APPROVER_DEPARTMENT_HEAD: &head})
+	}
+
+	t.Run("approving moves to the next step", func(t *testing.T) {
+		steps := build()
+		if !steps.IsWaitingFor(manager) || steps.IsWaitingFor(head) {
+			t.Fatalf("expected the steps to wait for the manager only")
+		}
+
+		steps.Decide(0, manager, true, "", now)
+		if i, ok := steps.Current(); !ok || i != 1 {
+			t.Fatalf("expected the second step to be waiting, got %d", i)
+		}
+		if !steps.IsWaitingFor(head) || steps[0].ActedAsDelegate || !steps[0].IsNewlyDecided() {
+			t.Fatalf("expected the department head to be next, got %+v", steps[0])
+		}
+		if steps[1].IsNewlyDecided() {
+			t.Fatalf("expected the second step to be undecided")
+		}
+	})
+
+	t.Run("a rejection ends the approval", func(t *testing.T) {
+		steps := build()
+		steps.Decide(0, manager, true, "", now)
+		steps.Decide(1, head, false, "busy month", now)
+
+		if i, ok := steps.Current(); ok || i != 1 {
+			t.Fatalf("expected the rejected step to end the approval, got %d", i)
+		}
+		if steps.IsWaitingFor(head) || steps[2].Approved != nil {
+			t.Fatalf("expected HR not to be asked after a rejection")
+		}
+	})
+
+	t.Run("a delegate decides on behalf of the approver", func(t *testing.T) {
+		steps := build()
+		steps.Decide(0, delegate, true, "", now)
+
+		if !steps[0].ActedAsDelegate || *steps[0].ActedByID != delegate {
+			t.Fatalf("expected the step to be decided by the delegate, got %+v", steps[0])
+		}
+	})
+
+	t.Run("every step approved", func(t *testing.T) {
+		steps := build()
+		for i, actor := range []string{manager, head, "hr"} {
+			steps.Decide(i, actor, true, "", now)
+		}
+
+		if i, ok := steps.Current(); ok || i != len(steps) {
+			t.Fatalf("expected no step to be waiting, got %d", i)
+		}
+		if steps[2].ActedAsDelegate {
+			t.Fatalf("expected HR never to act as a delegate")
+		}
+	})
+}
@@ -23,6 +23,17 @@ type Attendance struct {
 	EarlyClockOut       bool
 	ClosedAutomatically *bool
 
+	// Geofence results of the clock in and clock out location
+	// against the nearest office. Distances are in metres.
+	ClockInOutsideOffice   bool
+	ClockInOfficeID        *string `gorm:"type:uuid;default:null"`
+	ClockInOffice          *OfficeLocation
+	ClockInOfficeDistance  float64
+	ClockOutOutsideOffice  bool
+	ClockOutOfficeID       *string `gorm:"type:uuid;default:null"`
+	ClockOutOffice         *OfficeLocation
+	ClockOutOfficeDistance float64
+
 	Overtime *Overtime
 
 	BaseModelStamps
@@ -30,11 +41,12 @@ type Attendance struct {
 }
 
 // ValidateClockIn validates two things:
-// 1. The clock in time must not be greater than the office
-// end time minus the accepted attandance interval.
+// 1. The clock in time must not be greater than the end time of
+// the employee's shift, trimmed by the partial-day leaves taken on
+// that day, minus the accepted attandance interval.
 // 2. The location point must not be nil and is a valid (lat, long)
 // data type.
-func (v Attendance) ValidateClockIn(config Configuration) error {
+func (v Attendance) ValidateClockIn(config Configuration, schedule WorkSchedule, leaves []Leave) error {
 	var errs error
 
 	dur, err := time.ParseDuration(config.AcceptanceAttendanceInterval)
@@ -42,19 +54,8 @@ func (v Attendance) ValidateClockIn(config Configuration) error {
 		errs = utils.AddError(errs, err)
 	}
 
-	officeEndTime := time.Date(
-		v.ClockInAt.Year(),
-		v.ClockInAt.Month(),
-		v.ClockInAt.Day(),
-		config.OfficeEndTime.Hour(),
-		config.OfficeEndTime.Minute(),
-		config.OfficeEndTime.Second(),
-		config.OfficeEndTime.Nanosecond(),
-		utils.CURRENT_LOC,
-	)
-
-	if v.ClockInAt.After(officeEndTime.Add(-dur)) {
-		errs = utils.AddError(errs, fmt.Errorf("clock in after office's end time is not allowed"))
+	if shift, ok := v.shift(schedule, leaves); ok && v.ClockInAt.After(shift.End.Add(-dur)) {
+		errs = utils.AddError(errs, fmt.Errorf("clock in after shift's end time is not allowed"))
 	}
 
 	if err := v.ClockInLoc.Validate(); err != nil {
@@ -76,30 +77,50 @@ func (v Attendance) ValidateClockIn(config Configuration) error {
 }
 
 // IsLateClockIn returns whether the attendance is a late clock in
-// according to the passed office configuration entity or the day
-// the clock in is made.
-func (v Attendance) IsLateClockIn(config Configuration) bool {
-	if v.ClockInAt.Weekday() != time.Saturday && v.ClockInAt.Weekday() != time.Sunday {
-		interval, _ := time.ParseDuration(config.AcceptanceAttendanceInterval)
-
-		lateTime := time.Date(
-			v.ClockInAt.Year(),
-			v.ClockInAt.Month(),
-			v.ClockInAt.Day(),
-			config.OfficeStartTime.Hour(),
-			config.OfficeStartTime.Minute(),
-			config.OfficeStartTime.Second(),
-			config.OfficeStartTime.Nanosecond(),
-			utils.CURRENT_LOC,
-		).Add(interval)
-
-		return v.ClockInAt.After(lateTime)
+// according to the employee's shift and the accepted attendance
+// interval of the office configuration. A clock in on a day off
+// is never late, and a clock in after a first half leave is late
+// only when it is after the second half starts.
+func (v Attendance) IsLateClockIn(config Configuration, schedule WorkSchedule, leaves []Leave) bool {
+	shift, ok := v.shift(schedule, leaves)
+	if !ok {
+		return false
+	}
+
+	interval, _ := time.ParseDuration(config.AcceptanceAttendanceInterval)
+
+	return v.ClockInAt.After(shift.Start.Add(interval))
+}
+
+// SetClockInGeofence records the clock in geofence result
+// into the attendance.
+func (v *Attendance) SetClockInGeofence(fence Geofence) {
+	if fence.NearestOffice.Id == "" {
+		return
 	}
 
-	return false
+	v.ClockInOutsideOffice = fence.IsOutside
+	v.ClockInOfficeID = &fence.NearestOffice.Id
+	v.ClockInOfficeDistance = fence.Distance
 }
 
-func (v Attendance) ValidateClockOut() error {
+// SetClockOutGeofence records the clock out geofence result
+// into the attendance.
+func (v *Attendance) SetClockOutGeofence(fence Geofence) {
+	if fence.NearestOffice.Id == "" {
+		return
+	}
+
+	v.ClockOutOutsideOffice = fence.IsOutside
+	v.ClockOutOfficeID = &fence.NearestOffice.Id
+	v.ClockOutOfficeDistance = fence.Distance
+}
+
+// ValidateClockOut validates the clock out location. When the
+// company has no office configured, the clock out location must
+// be around 5km of the clock in location. Otherwise, the location
+// is checked against the offices by Configuration.CheckGeofence.
+func (v Attendance) ValidateClockOut(config Configuration) error {
 	var errs error
 
 	if err := v.ClockOutLoc.Validate(); err != nil {
@@ -117,30 +138,35 @@ func (v Attendance) ValidateClockOut() error {
 		errs = utils.AddError(errs, err)
 	}
 
-	if v.ClockInLoc.DistanceTo(v.ClockOutLoc) > 5000 {
+	if len(config.OfficeLocations) == 0 && v.ClockInLoc.DistanceTo(v.ClockOutLoc) > 5000 {
 		errs = utils.AddError(errs, fmt.Errorf("the distance between clock in and clock out location is too large. Please be around 5km around your clock in location"))
 	}
 
 	return errs
 }
 
-func (v Attendance) IsEarlyClockOut(config Configuration) bool {
-	if v.ClockInAt.Weekday() != time.Saturday && v.ClockInAt.Weekday() != time.Sunday {
-		interval, _ := time.ParseDuration(config.AcceptanceAttendanceInterval)
-
-		lateTime := time.Date(
-			v.ClockOutAt.Year(),
-			v.ClockOutAt.Month(),
-			v.ClockOutAt.Day(),
-			config.OfficeEndTime.Hour(),
-			config.OfficeEndTime.Minute(),
-			config.OfficeEndTime.Second(),
-			config.OfficeEndTime.Nanosecond(),
-			utils.CURRENT_LOC,
-		).Add(-interval)
-
-		return v.ClockOutAt.Before(lateTime)
+// IsEarlyClockOut returns whether the attendance is closed before
+// the end of the shift it was clocked in for, trimmed by the
+// partial-day leaves taken on that day. A clock out on a day off is
+// never early.
+func (v Attendance) IsEarlyClockOut(config Configuration, schedule WorkSchedule, leaves []Leave) bool {
+	shift, ok := v.shift(schedule, leaves)
+	if !ok {
+		return false
+	}
+
+	interval, _ := time.ParseDuration(config.AcceptanceAttendanceInterval)
+
+	return v.ClockOutAt.Before(shift.End.Add(-interval))
+}
+
+// shift returns the shift the attendance was clocked in for without
+// the partial-day leaves taken by the employee.
+func (v Attendance) shift(schedule WorkSchedule, leaves []Leave) (ShiftWindow, bool) {
+	shift, ok := schedule.ShiftAt(v.ClockInAt)
+	if !ok {
+		return shift, false
 	}
 
-	return false
+	return shift.ExcludeLeaves(leaves), true
 }
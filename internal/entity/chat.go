@@ -1,6 +1,11 @@
 package entity
 
 import (
+	"bytes"
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -9,17 +14,105 @@ var (
 	Chats string = "chats"
 )
 
+// RoomKind is what a room is for. The rooms created before the kinds
+// have none and are direct rooms.
+type RoomKind string
+
+const (
+	// A conversation between two employees, found by its participants
+	DIRECT_ROOM RoomKind = "DIRECT"
+	// A named room whose members are managed by its admins
+	GROUP_ROOM RoomKind = "GROUP"
+	// The room of a manager and all of their staff, whose members follow
+	// who reports to the manager
+	TEAM_ROOM RoomKind = "TEAM"
+)
+
 type Room struct {
 	Id           primitive.ObjectID `bson:"_id,omitempty"`
+	Kind         RoomKind           `bson:"kind,omitempty"`
+	Name         string             `bson:"name,omitempty"`
+	Avatar       string             `bson:"avatar,omitempty"`
+	Topic        string             `bson:"topic,omitempty"`
 	Participants []string           `bson:"participants,omitempty"`
-	CreatedAt    primitive.DateTime `bson:"createdAt,omitempty"`
+	Admins       []string           `bson:"admins,omitempty"`
+	// TeamOf is the manager of a team room
+	TeamOf string `bson:"teamOf,omitempty"`
+	// ReadCursors are the latest chat each participant has read, by the
+	// participant. Every chat up to it is read by the participant too.
+	ReadCursors map[string]primitive.ObjectID `bson:"readCursors,omitempty"`
+	CreatedBy   string                        `bson:"createdBy,omitempty"`
+	CreatedAt   primitive.DateTime            `bson:"createdAt,omitempty"`
+	UpdatedAt   primitive.DateTime            `bson:"updatedAt,omitempty"`
+}
+
+func (r Room) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Kind, validation.In(DIRECT_ROOM, GROUP_ROOM, TEAM_ROOM)),
+		validation.Field(&r.Name, validation.When(!r.IsDirect(), validation.Required, validation.Length(2, 100))),
+		validation.Field(&r.Avatar, validation.When(r.Avatar != "", is.URL)),
+		validation.Field(&r.Topic, validation.Length(0, 250)),
+		validation.Field(&r.Participants, validation.Required, validation.By(func(value interface{}) error {
+			participants := value.([]string)
+			if r.IsDirect() && len(participants) != 2 {
+				return fmt.Errorf("a direct room is between two employees")
+			}
+			for i, v := range participants {
+				if contains(participants[i+1:], v) {
+					return fmt.Errorf("%s takes part more than once", v)
+				}
+			}
+			return nil
+		})),
+	)
+}
+
+// IsDirect returns whether the room is a conversation between two
+// employees.
+func (r Room) IsDirect() bool {
+	return r.Kind == "" || r.Kind == DIRECT_ROOM
+}
+
+// HasParticipant returns whether the employee takes part in the room.
+func (r Room) HasParticipant(employeeId string) bool {
+	return contains(r.Participants, employeeId)
+}
+
+// IsAdmin returns whether the employee may manage the room.
+func (r Room) IsAdmin(employeeId string) bool {
+	return contains(r.Admins, employeeId)
+}
+
+// HasRead returns whether the participant has read up to the chat.
+func (r Room) HasRead(employeeId string, chat Chat) bool {
+	if chat.Sender == employeeId {
+		return true
+	}
+
+	cursor, ok := r.ReadCursors[employeeId]
+	return ok && bytes.Compare(cursor[:], chat.Id[:]) >= 0
+}
+
+// ReadBy returns the participants other than the sender who have read
+// the chat.
+func (r Room) ReadBy(chat Chat) []string {
+	readers := []string{}
+	for _, v := range r.Participants {
+		if v != chat.Sender && r.HasRead(v, chat) {
+			readers = append(readers, v)
+		}
+	}
+
+	return readers
 }
 
+// Chats do not hold whether they are read, which is told by the read
+// cursors of their room. The chats from before the cursors still have
+// a read field, set once anyone but their sender had opened the room.
 type Chat struct {
 	Id        primitive.ObjectID  `bson:"_id,omitempty"`
 	RoomId    primitive.ObjectID  `bson:"roomId,omitempty"`
 	Sender    string              `bson:"sender,omitempty"`
 	Message   string              `bson:"message,omitempty"`
-	Read      bool                `bson:"read"`
 	Timestamp primitive.Timestamp `bson:"timestamp,omitempty"`
 }
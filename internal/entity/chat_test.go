@@ -0,0 +1,77 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestValidatingRooms(t *testing.T) {
+	cases := []struct {
+		name  string
+		room  Room
+		valid bool
+	}{
+		{"a direct room", Room{Participants: []string{"ana", "budi"}}, true},
+		{"a direct room of three", Room{Kind: DIRECT_ROOM, Participants: []string{"ana", "budi", "citra"}}, false},
+		{"a direct room with oneself", Room{Kind: DIRECT_ROOM, Participants: []string{"ana", "ana"}}, false},
+		{"a group", Room{Kind: GROUP_ROOM, Name: "Payroll", Participants: []string{"ana", "budi", "citra"}}, true},
+		{"a group without a name", Room{Kind: GROUP_ROOM, Participants: []string{"ana", "budi"}}, false},
+		{"a group of duplicates", Room{Kind: GROUP_ROOM, Name: "Payroll", Participants: []string{"ana", "budi", "ana"}}, false},
+		{"a group with a malformed avatar", Room{Kind: GROUP_ROOM, Name: "Payroll", Avatar: "not a url", Participants: []string{"ana"}}, false},
+		{"a team without participants", Room{Kind: TEAM_ROOM, Name: "Ana's team", TeamOf: "ana"}, false},
+		{"an unknown kind", Room{Kind: "CHANNEL", Name: "Payroll", Participants: []string{"ana", "budi"}}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.room.Validate(); (err == nil) != c.valid {
+				t.Fatalf("expected valid to be %v, got %v", c.valid, err)
+			}
+		})
+	}
+}
+
+func TestReadingUpToACursor(t *testing.T) {
+	at := time.Date(2024, time.April, 1, 9, 0, 0, 0, time.UTC)
+	earlier := primitive.NewObjectIDFromTimestamp(at.Add(-time.Minute))
+	chat := Chat{Id: primitive.NewObjectIDFromTimestamp(at), Sender: "ana"}
+	later := primitive.NewObjectIDFromTimestamp(at.Add(time.Minute))
+
+	room := Room{
+		Kind:         GROUP_ROOM,
+		Participants: []string{"ana", "budi", "citra", "dewi", "eka"},
+		ReadCursors: map[string]primitive.ObjectID{
+			"budi":  earlier,
+			"citra": chat.Id,
+			"dewi":  later,
+		},
+	}
+
+	cases := []struct {
+		employee string
+		read     bool
+	}{
+		// The sender has always read their own chats
+		{"ana", true},
+		{"budi", false},
+		{"citra", true},
+		{"dewi", true},
+		// Without a cursor nothing is read yet
+		{"eka", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.employee, func(t *testing.T) {
+			if got := room.HasRead(c.employee, chat); got != c.read {
+				t.Fatalf("expected read to be %v, got %v", c.read, got)
+			}
+		})
+	}
+
+	readers := room.ReadBy(chat)
+	if len(readers) != 2 || readers[0] != "citra" || readers[1] != "dewi" {
+		t.Fatalf("expected the chat to be read by citra and dewi, got %v", readers)
+	}
+}
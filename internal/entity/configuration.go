@@ -13,22 +13,42 @@ const (
 	CONFIG_KEY_NEXT_MONTH = "configNextMonth"
 )
 
+// ConfigChangeScope tells when the configuration changes take effect,
+// either the next day or the next month.
+type ConfigChangeScope string
+
+const (
+	CONFIG_NEXT_DAY   ConfigChangeScope = "NEXT_DAY"
+	CONFIG_NEXT_MONTH ConfigChangeScope = "NEXT_MONTH"
+)
+
+// Key returns the Redis hash holding the changes of the scope until
+// they take effect.
+func (s ConfigChangeScope) Key() string {
+	if s == CONFIG_NEXT_MONTH {
+		return CONFIG_KEY_NEXT_MONTH
+	}
+	return CONFIG_KEY_NEXT_DAY
+}
+
 type Configuration struct {
 	BaseModelId
 
 	OfficeStartTime              time.Time
 	OfficeEndTime                time.Time
-	OfficeStartTimeHour          int    `gorm:"-:all" redis:"officeStartTimeHour"`
-	OfficeStartTimeMinute        int    `gorm:"-:all" redis:"officeStartTimeMinute"`
-	OfficeEndTimeHour            int    `gorm:"-:all" redis:"officeEndTimeHour"`
-	OfficeEndTimeMinute          int    `gorm:"-:all" redis:"officeEndTimeMinute"`
-	AcceptanceAttendanceInterval string `gorm:"type:varchar(50)" redis:"acceptanceAttendanceInterval"` // e.g. 30m, 1h, ...
-	AcceptanceLeaveInterval      int    `gorm:"default:7" redis:"acceptanceLeaveInterval"`             // days
-	DefaultYearlyQuota           int    `gorm:"default:12" redis:"defaultYearlyQuota"`                 // days
-	DefaultMarriageQuota         int    `gorm:"default:3" redis:"defaultMarriageQuota"`                // days
-	MaxOvertimeDailyDur          int    `gorm:"default:3" redis:"-"`                                   // hours
-	MaxOvertimeWeeklyDur         int    `gorm:"default:14" redis:"-"`                                  // hours
+	OfficeStartTimeHour          int          `gorm:"-:all" redis:"officeStartTimeHour"`
+	OfficeStartTimeMinute        int          `gorm:"-:all" redis:"officeStartTimeMinute"`
+	OfficeEndTimeHour            int          `gorm:"-:all" redis:"officeEndTimeHour"`
+	OfficeEndTimeMinute          int          `gorm:"-:all" redis:"officeEndTimeMinute"`
+	AcceptanceAttendanceInterval string       `gorm:"type:varchar(50)" redis:"acceptanceAttendanceInterval"` // e.g. 30m, 1h, ...
+	AcceptanceLeaveInterval      int          `gorm:"default:7" redis:"acceptanceLeaveInterval"`             // days
+	DefaultYearlyQuota           int          `gorm:"default:12" redis:"defaultYearlyQuota"`                 // days
+	DefaultMarriageQuota         int          `gorm:"default:3" redis:"defaultMarriageQuota"`                // days
+	MaxOvertimeDailyDur          int          `gorm:"default:3" redis:"-"`                                   // hours
+	MaxOvertimeWeeklyDur         int          `gorm:"default:14" redis:"-"`                                  // hours
+	GeofenceMode                 GeofenceMode `gorm:"type:varchar(50);default:'FLAG'" redis:"geofenceMode"`
 
+	OfficeLocations          []OfficeLocation
 	ConfigurationChangesLogs []ConfigurationChangesLog
 
 	BaseModelStamps
@@ -44,11 +64,33 @@ type ConfigurationChangesLog struct {
 	UpdatedBy       Employee
 	Changes         JSONB
 	WhenApplied     time.Time
+	Scope           ConfigChangeScope `gorm:"type:varchar(50)"`
+	// Set once the changes are applied to the configuration
+	AppliedAt *time.Time
+	// Set once HR cancels the changes before they take effect
+	CancelledAt   *time.Time
+	CancelledByID *string `gorm:"type:uuid;default:null"`
+	CancelledBy   *Employee
 
 	BaseModelStamps
 	BaseModelSoftDelete
 }
 
+// PendingConfigChanges are the configuration changes of a scope which
+// have not taken effect yet. Configuration holds the values to apply,
+// Changes the previous and new values of the changed fields, and Logs
+// the change logs made by HR for them.
+type PendingConfigChanges struct {
+	Scope       ConfigChangeScope
+	WhenApplied time.Time
+	// ChangeLogId is the change log of the latest save, which tells
+	// whether the changes were replaced since they were read
+	ChangeLogId   string
+	Configuration Configuration
+	Changes       JSONB
+	Logs          []ConfigurationChangesLog
+}
+
 // Returns the office work duration that is office end time - office start time
 func (c Configuration) OfficeWorkDuration() time.Duration {
 	now := time.Now().In(utils.CURRENT_LOC)
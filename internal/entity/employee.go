@@ -65,10 +65,13 @@ type Employee struct {
 	Status       Status       `gorm:"type:varchar(100);default:'UNAVAILABLE'"`
 	IsNewUser    bool
 	JoinDate     time.Time
+	// TokenVersion is bumped whenever the status, role or password
+	// changes, which revokes every token issued before
+	TokenVersion int `gorm:"default:0"`
 
 	EmployeeBiodata            EmployeeBiodata
 	EmployeesEmergencyContacts []EmployeesEmergencyContact
-	EmployeeLeavesQuota        EmployeeLeavesQuota
+	EmployeeLeaveQuotas        []EmployeeLeaveQuota
 	EmployeeDataHistoryLogs    []EmployeeDataHistoryLog
 
 	ManagerID    *string `gorm:"type:uuid"`
@@ -83,6 +86,11 @@ type Employee struct {
 	JobID        string `gorm:"type:uuid"`
 	Job          Job
 
+	// An employee without a work schedule follows the
+	// schedule of its job, or the default schedule.
+	WorkScheduleID *string `gorm:"type:uuid;default:null"`
+	WorkSchedule   *WorkSchedule
+
 	BaseModelStamps
 	BaseModelSoftDelete
 }
@@ -117,18 +125,6 @@ type EmployeesEmergencyContact struct {
 	BaseModelSoftDelete
 }
 
-type EmployeeLeavesQuota struct {
-	BaseModelId
-
-	EmployeeID    string `gorm:"type:uuid"`
-	YearlyCount   int    `gorm:"default:0"`
-	UnpaidCount   int    `gorm:"default:0"`
-	MarriageCount int    `gorm:"default:0"`
-
-	BaseModelStamps
-	BaseModelSoftDelete
-}
-
 type EmployeeDataHistoryLog struct {
 	BaseModelId
 
@@ -179,7 +175,7 @@ func (v Employee) ValidateNewEmployee() error {
 		validation.Field(&v.JoinDate, validation.Required),
 		validation.Field(&v.Avatar, validation.When(v.Avatar != "", validation.Required, is.URL)),
 		validation.Field(&v.Status, validation.Required, validation.In(AVAILABLE, UNAVAILABLE, ON_LEAVE)),
-		validation.Field(&v.ManagerID, validation.When(v.Role.Code == "staff",
+		validation.Field(&v.ManagerID, validation.When(v.Role.RequiresManager,
 			validation.Required,
 			validation.By(func(value interface{}) error {
 				v, ok := value.(*string)
@@ -188,7 +184,7 @@ func (v Employee) ValidateNewEmployee() error {
 				}
 				return validation.Validate(v, is.UUIDv4)
 			}),
-		).Else(validation.Nil.Error("a non-staff role must not have a manager"))),
+		).Else(validation.Nil.Error("an employee whose role has no manager must not have a manager"))),
 		validation.Field(&v.CreatedById, validation.Required, is.UUIDv4),
 		validation.Field(&v.EmployeeBiodata),
 		validation.Field(&v.EmployeesEmergencyContacts),
@@ -238,7 +234,7 @@ func (v Employee) ValidateUpdateWorkInfo() error {
 	return validation.ValidateStruct(&v,
 		validation.Field(&v.Status, validation.Required, validation.In(AVAILABLE, UNAVAILABLE, ON_LEAVE, RESIGNED)),
 		validation.Field(&v.ContractType, validation.Required, validation.In(FULL_TIME, CONTRACT, INTERN)),
-		validation.Field(&v.ManagerID, validation.When(v.Role.Code == "staff",
+		validation.Field(&v.ManagerID, validation.When(v.Role.RequiresManager,
 			validation.Required,
 			validation.By(func(value interface{}) error {
 				v, ok := value.(*string)
@@ -247,7 +243,7 @@ func (v Employee) ValidateUpdateWorkInfo() error {
 				}
 				return validation.Validate(v, is.UUIDv4)
 			}),
-		).Else(validation.Nil.Error("a non-staff role must not have a manager"))),
+		).Else(validation.Nil.Error("an employee whose role has no manager must not have a manager"))),
 		validation.Field(&v.EmployeeDataHistoryLogs),
 	)
 }
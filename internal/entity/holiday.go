@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"sinarlog.com/internal/utils"
+)
+
+// Holiday is a public holiday or a company wide day off. No one
+// is scheduled to work on a holiday. Hence, attendances made on
+// it are holiday overtimes and leaves do not count it as a leave
+// day.
+type Holiday struct {
+	BaseModelId
+
+	Name string    `gorm:"type:varchar(150)"`
+	Date time.Time `gorm:"index:,unique,where:deleted_at IS NULL"`
+
+	BaseModelStamps
+	BaseModelSoftDelete
+}
+
+func (v Holiday) Validate() error {
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.Name, validation.Required, validation.Length(3, 150)),
+		validation.Field(&v.Date, validation.Required.Error("holiday date is required")),
+	)
+}
+
+// HolidayDates returns the dates of the holidays.
+func HolidayDates(holidays []Holiday) []time.Time {
+	dates := make([]time.Time, 0, len(holidays))
+	for _, v := range holidays {
+		dates = append(dates, v.Date)
+	}
+
+	return dates
+}
+
+// DateKey returns the date of the holiday formatted as
+// YYYY-MM-DD in the current location.
+func (v Holiday) DateKey() string {
+	return v.Date.In(utils.CURRENT_LOC).Format(time.DateOnly)
+}
@@ -2,6 +2,7 @@ package entity
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
@@ -28,10 +29,25 @@ func (l LeaveType) String() string {
 	case MARRIAGE:
 		return "MARRIAGE"
 	default:
-		return ""
+		return string(l)
 	}
 }
 
+// LeaveGranularity tells which part of a day a leave takes.
+// A leave with a granularity other than FULL_DAY is a
+// partial-day leave and takes only one working day.
+type LeaveGranularity string
+
+const (
+	FULL_DAY LeaveGranularity = "FULL_DAY"
+	// FIRST_HALF takes the first half of the shift
+	FIRST_HALF LeaveGranularity = "FIRST_HALF"
+	// SECOND_HALF takes the second half of the shift
+	SECOND_HALF LeaveGranularity = "SECOND_HALF"
+	// HOURLY takes the hour range from From to To
+	HOURLY LeaveGranularity = "HOURLY"
+)
+
 type Leave struct {
 	BaseModelId
 
@@ -42,6 +58,11 @@ type Leave struct {
 	Type          LeaveType `gorm:"type:varchar(100)"`
 	Reason        string    `gorm:"type:text"`
 	AttachmentUrl string    `gorm:"type:varchar(255)"`
+	// The number of working days taken according to the
+	// employee's work schedule when the leave was requested.
+	// It is a fraction of a day for a partial-day leave.
+	WorkingDays float64
+	Granularity LeaveGranularity `gorm:"type:varchar(50);default:'FULL_DAY'"`
 
 	// A parent leave contains the original leave request.
 	Parent   *Leave
@@ -65,11 +86,147 @@ type Leave struct {
 	RejectionReason     string `gorm:"type:text"`
 	ClosedAutomatically *bool
 
+	// The steps of the approval chain of a parent leave. The steps
+	// before the one of HR stand for the manager's approval, hence
+	// ApprovedByManager is decided once they are all decided. A
+	// leave made before approval chains existed has no steps.
+	ApprovalSteps []ApprovalStep `gorm:"foreignKey:LeaveID"`
+
+	// A pending leave is withdrawn right away by its requestee,
+	// meanwhile cancelling an approved leave must be approved by
+	// the manager and HR before the leave is cancelled.
+	CancellationReason            string `gorm:"type:text"`
+	CancellationRequestedAt       *time.Time
+	CancellationApprovedByManager *bool
+	CancellationApprovedByHr      *bool
+	CancellationActionByManagerAt *time.Time
+	CancellationActionByHrAt      *time.Time
+	CancellationRejectionReason   string `gorm:"type:text"`
+	CancelledAt                   *time.Time
+
 	BaseModelStamps
 	BaseModelSoftDelete
 }
 
-func (v Leave) Validate() error {
+// Duration returns the number of working days of the leave.
+// Leaves made before work schedules existed have no working
+// days recorded, hence they are counted as Monday to Friday
+// except the given holidays.
+func (v Leave) Duration(holidays ...time.Time) float64 {
+	if v.WorkingDays > 0 {
+		return v.WorkingDays
+	}
+
+	return float64(utils.CountNumberOfWorkingDays(v.From, v.To, holidays...))
+}
+
+// IsPartialDay returns whether the leave takes only a part
+// of a working day.
+func (v Leave) IsPartialDay() bool {
+	return v.Granularity != "" && v.Granularity != FULL_DAY
+}
+
+// IsRejected returns whether the leave has been rejected either
+// by the manager or HR.
+func (v Leave) IsRejected() bool {
+	return (v.ApprovedByManager != nil && !*v.ApprovedByManager) ||
+		(v.ApprovedByHr != nil && !*v.ApprovedByHr)
+}
+
+// IsApproved returns whether the leave has been approved both
+// by the manager and HR.
+func (v Leave) IsApproved() bool {
+	return v.ApprovedByManager != nil && *v.ApprovedByManager &&
+		v.ApprovedByHr != nil && *v.ApprovedByHr
+}
+
+// IsPending returns whether the leave still waits for an action
+// of the manager or HR.
+func (v Leave) IsPending() bool {
+	return !v.IsRejected() && v.ApprovedByHr == nil &&
+		v.ClosedAutomatically == nil && v.CancelledAt == nil
+}
+
+// HasPendingCancellation returns whether the cancellation of the
+// leave still waits for an action of the manager or HR.
+func (v Leave) HasPendingCancellation() bool {
+	if v.CancellationRequestedAt == nil || v.CancelledAt != nil {
+		return false
+	}
+
+	return !(v.CancellationApprovedByManager != nil && !*v.CancellationApprovedByManager) &&
+		!(v.CancellationApprovedByHr != nil && !*v.CancellationApprovedByHr)
+}
+
+// RefundableLeaves returns the leave and its overflows whose
+// quota are still taken. A rejected leave has had its quota
+// returned when it was rejected.
+func (v Leave) RefundableLeaves() []Leave {
+	var res []Leave
+
+	if !v.IsRejected() {
+		res = append(res, v)
+	}
+	for _, child := range v.Childs {
+		if !child.IsRejected() {
+			res = append(res, child)
+		}
+	}
+
+	return res
+}
+
+// HasStarted returns whether the leave has started, hence some of
+// its days may have been taken already.
+func (v Leave) HasStarted() bool {
+	return !v.From.After(time.Now().In(utils.CURRENT_LOC))
+}
+
+// ValidateCancellation validates a cancellation of an approved
+// leave requested by the employee. A leave that has started cannot
+// be cancelled since its quota is refunded in full.
+func (v Leave) ValidateCancellation() error {
+	if v.HasStarted() {
+		return fmt.Errorf("unable to cancel a leave that has started")
+	}
+
+	return validation.Validate(&v.CancellationReason,
+		validation.Required.Error("a leave cancellation must be provided with a reason"),
+		validation.Length(20, 1000).Error("leave cancellation reason length must be between 20 and 1000"),
+	)
+}
+
+// ApplySchedule places the leave on the employee's schedule.
+// A half-day leave is set to the first or second half of the
+// shift on its date, and the working days taken are computed
+// as a fraction of the shift for a partial-day leave. The leave
+// must be validated first.
+func (v *Leave) ApplySchedule(schedule WorkSchedule) {
+	if !v.IsPartialDay() {
+		v.WorkingDays = float64(schedule.CountWorkingDays(v.From, v.To))
+		return
+	}
+
+	shift, ok := schedule.ShiftOn(v.From)
+	if !ok {
+		return
+	}
+	half := shift.Start.Add(shift.Duration() / 2)
+
+	switch v.Granularity {
+	case FIRST_HALF:
+		v.From, v.To = shift.Start, half
+		v.WorkingDays = 0.5
+	case SECOND_HALF:
+		v.From, v.To = half, shift.End
+		v.WorkingDays = 0.5
+	case HOURLY:
+		// Rounded to two decimals to keep the quota readable
+		v.WorkingDays = math.Round(float64(v.To.Sub(v.From))/float64(shift.Duration())*100) / 100
+	}
+}
+
+func (v Leave) Validate(schedule WorkSchedule) error {
 	err := validation.ValidateStruct(&v,
 		validation.Field(&v.From,
 			validation.Required.Error("leave rquest start date is required"),
@@ -81,43 +238,87 @@ func (v Leave) Validate() error {
 			validation.Required.Error("leave request reason is required"),
 			validation.Length(20, 1000).Error("leave request length must be between 20 and 1000")),
 		validation.Field(&v.Type, validation.Required.Error("leave request type is required")),
+		validation.Field(&v.Granularity, validation.In(FULL_DAY, FIRST_HALF, SECOND_HALF, HOURLY).Error("leave request granularity is not valid")),
 	)
 	if err != nil {
 		return err
 	}
 
-	// Checks whether the selected days all holidays
-	numDays := utils.CountNumberOfDays(v.From, v.To)
-	haveWeekDay := false
-	for i := 0; i < numDays; i++ {
-		day := v.From.Add(time.Duration(i) * 24 * time.Hour)
-		if day.Weekday() != time.Sunday && day.Weekday() != time.Saturday {
-			haveWeekDay = true
-			break
-		}
+	if v.IsPartialDay() {
+		return v.validatePartialDay(schedule)
 	}
 
-	if haveWeekDay {
+	// Checks whether the selected days all holidays
+	if schedule.CountWorkingDays(v.From, v.To) > 0 {
 		return nil
 	}
 
 	return fmt.Errorf("all selected days are holidays")
 }
 
+// validatePartialDay checks that a partial-day leave is on a
+// working day and, for an hourly leave, that the hour range
+// is within the shift.
+func (v Leave) validatePartialDay(schedule WorkSchedule) error {
+	shift, ok := schedule.ShiftOn(v.From)
+	if !ok {
+		return fmt.Errorf("a partial-day leave must be on a working day")
+	}
+
+	if v.Granularity != HOURLY {
+		return nil
+	}
+
+	if !v.To.After(v.From) {
+		return fmt.Errorf("an hourly leave must end after it starts")
+	}
+	if v.From.Before(shift.Start) || v.To.After(shift.End) {
+		return fmt.Errorf("an hourly leave must be within your shift from %s to %s",
+			shift.Start.Format("15:04"),
+			shift.End.Format("15:04"),
+		)
+	}
+	if v.To.Sub(v.From) >= shift.Duration() {
+		return fmt.Errorf("an hourly leave taking the whole shift must be a full day leave")
+	}
+
+	return nil
+}
+
+// ExcludeLeaves trims the shift by the partial-day leaves taken
+// at its start or at its end. For example, a shift from 09:00 to
+// 17:00 with a first half leave until 13:00 starts at 13:00.
+func (w ShiftWindow) ExcludeLeaves(leaves []Leave) ShiftWindow {
+	for _, leave := range leaves {
+		if !leave.IsPartialDay() || !leave.To.After(w.Start) || !leave.From.Before(w.End) {
+			continue
+		}
+
+		if !leave.From.After(w.Start) {
+			w.Start = leave.To
+		}
+		if !leave.To.Before(w.End) {
+			w.End = leave.From
+		}
+	}
+
+	return w
+}
+
 type LeaveReport struct {
 	// Whether the leaves quota exceeds the quota according to the type
 	IsLeaveLeakage bool
 	// The excessive leave duration as days
-	ExcessLeaveDuration int
+	ExcessLeaveDuration float64
 
 	// Stores the initial request type
 	RequestType LeaveType
 	// Stores the remaining quota for the request type
-	RemainingQuotaForRequestedType int
+	RemainingQuotaForRequestedType float64
 
 	// The available excess types to overflow the leakage
 	AvailableExcessTypes []LeaveType
 	// The available excess quota to overflow the leakage
 	// NOTES: Make unpaid count to 10 max
-	AvailableExcessQuotas []int
+	AvailableExcessQuotas []float64
 }
@@ -0,0 +1,175 @@
+package entity
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"sinarlog.com/internal/utils"
+)
+
+// AccrualPeriod tells how often a leave type accrues its quota.
+type AccrualPeriod string
+
+const (
+	NO_ACCRUAL AccrualPeriod = "NONE"
+	// MONTHLY_ACCRUAL accrues a twelfth of the default quota at
+	// the start of each month
+	MONTHLY_ACCRUAL AccrualPeriod = "MONTHLY"
+	// YEARLY_ACCRUAL accrues the default quota at the start of
+	// each year
+	YEARLY_ACCRUAL AccrualPeriod = "YEARLY"
+)
+
+// Accrues tells whether the leave type accrues its quota.
+func (v LeavePolicy) Accrues() bool {
+	return v.AccrualPeriod == MONTHLY_ACCRUAL || v.AccrualPeriod == YEARLY_ACCRUAL
+}
+
+// Accrue brings the quota up to date with the leave policy at the
+// given time. It accrues the periods not accrued yet, renews the
+// quota on each new year and expires the carried over days. Each
+// change of the quota is returned as a ledger entry, hence accruing
+// again at the same time changes nothing.
+func (v *EmployeeLeaveQuota) Accrue(policy LeavePolicy, joinDate, at time.Time) []LeaveQuotaLedger {
+	if !policy.HasQuota {
+		return nil
+	}
+
+	var entries []LeaveQuotaLedger
+	at = at.In(utils.CURRENT_LOC)
+
+	if v.Year == 0 {
+		// The quota is given in full for this year
+		v.Year = at.Year()
+		if policy.Accrues() {
+			next := startOfYear(v.Year + 1)
+			v.NextAccrualAt = &next
+		}
+	}
+
+	for {
+		if policy.Accrues() {
+			until := at
+			if endOfYear := startOfYear(v.Year + 1).Add(-time.Nanosecond); until.After(endOfYear) {
+				until = endOfYear
+			}
+			entries = append(entries, v.accrue(policy, joinDate, until)...)
+		}
+
+		if v.Year >= at.Year() {
+			break
+		}
+		entries = append(entries, v.renew(policy, v.Year+1)...)
+	}
+
+	return append(entries, v.expireCarriedOver(at)...)
+}
+
+// accrue accrues the periods of the quota year starting until the
+// given time.
+func (v *EmployeeLeaveQuota) accrue(policy LeavePolicy, joinDate, until time.Time) []LeaveQuotaLedger {
+	var entries []LeaveQuotaLedger
+
+	next := startOfYear(v.Year)
+	if v.NextAccrualAt != nil && v.NextAccrualAt.After(next) {
+		next = v.NextAccrualAt.In(utils.CURRENT_LOC)
+	}
+
+	for !next.After(until) && next.Year() == v.Year {
+		end := next.AddDate(1, 0, 0)
+		period := strconv.Itoa(next.Year())
+		quota := policy.DefaultQuota
+		if policy.AccrualPeriod == MONTHLY_ACCRUAL {
+			end = next.AddDate(0, 1, 0)
+			period = next.Format("2006-01")
+			quota /= 12
+		}
+
+		// An employee joining in the middle of the period accrues
+		// only the days after joining
+		if joinDate.After(next) {
+			quota *= math.Max(float64(utils.CountNumberOfDays(joinDate, end)), 0) / float64(utils.CountNumberOfDays(next, end))
+		}
+
+		if amount := roundQuota(quota); amount > 0 {
+			entries = append(entries, v.change(LEDGER_ACCRUAL, period, amount))
+		}
+		next = end
+	}
+	v.NextAccrualAt = &next
+
+	return entries
+}
+
+// renew moves the quota to the given year. At most MaxCarryOver days
+// of the remaining quota are carried over, then a leave type that
+// resets yearly is given its default quota.
+func (v *EmployeeLeaveQuota) renew(policy LeavePolicy, year int) []LeaveQuotaLedger {
+	start := startOfYear(year)
+	entries := v.expireCarriedOver(start)
+	v.Year = year
+
+	if !policy.Accrues() && !policy.ResetYearly {
+		return entries
+	}
+
+	period := strconv.Itoa(year)
+	carried := math.Min(v.Count, policy.MaxCarryOver)
+	if carried != v.Count {
+		entries = append(entries, v.change(LEDGER_CARRY_OVER, period, carried-v.Count))
+	}
+
+	v.CarriedOver = math.Max(carried, 0)
+	v.CarriedOverExpiresAt = nil
+	if v.CarriedOver > 0 && policy.CarryOverExpiryMonths > 0 {
+		expiry := start.AddDate(0, policy.CarryOverExpiryMonths, 0)
+		v.CarriedOverExpiresAt = &expiry
+	}
+
+	if policy.ResetYearly && policy.DefaultQuota > 0 {
+		entries = append(entries, v.change(LEDGER_RESET, period, policy.DefaultQuota))
+	}
+
+	return entries
+}
+
+// expireCarriedOver removes the carried over days not taken yet once
+// they expire.
+func (v *EmployeeLeaveQuota) expireCarriedOver(at time.Time) []LeaveQuotaLedger {
+	if v.CarriedOver <= 0 || v.CarriedOverExpiresAt == nil || v.CarriedOverExpiresAt.After(at) {
+		return nil
+	}
+
+	expired := math.Min(v.CarriedOver, math.Max(v.Count, 0))
+	v.CarriedOver = 0
+	v.CarriedOverExpiresAt = nil
+	if expired == 0 {
+		return nil
+	}
+
+	return []LeaveQuotaLedger{v.change(LEDGER_EXPIRY, strconv.Itoa(v.Year), -expired)}
+}
+
+func (v *EmployeeLeaveQuota) change(source LeaveLedgerSource, period string, amount float64) LeaveQuotaLedger {
+	v.Count = roundQuota(v.Count + amount)
+
+	return LeaveQuotaLedger{
+		EmployeeID: v.EmployeeID,
+		Type:       v.Type,
+		Source:     source,
+		Period:     period,
+		Amount:     roundQuota(amount),
+		Balance:    v.Count,
+	}
+}
+
+func startOfYear(year int) time.Time {
+	return time.Date(year, time.January, 1, 0, 0, 0, 0, utils.CURRENT_LOC)
+}
+
+// roundQuota rounds a quota to two decimal places to avoid the
+// floating point errors of accruing a fraction of a day.
+func roundQuota(quota float64) float64 {
+	return math.Round(quota*100) / 100
+}
@@ -0,0 +1,120 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"sinarlog.com/internal/utils"
+)
+
+func TestAccruingLeaveQuota(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, utils.CURRENT_LOC)
+	}
+	at := func(t time.Time) *time.Time { return &t }
+
+	monthly := LeavePolicy{Code: ANNUAL, HasQuota: true, DefaultQuota: 12, AccrualPeriod: MONTHLY_ACCRUAL}
+	yearly := LeavePolicy{Code: ANNUAL, HasQuota: true, DefaultQuota: 12, AccrualPeriod: YEARLY_ACCRUAL, MaxCarryOver: 5, CarryOverExpiryMonths: 3}
+
+	type entry struct {
+		source  LeaveLedgerSource
+		amount  float64
+		balance float64
+	}
+
+	cases := []struct {
+		name        string
+		policy      LeavePolicy
+		quota       EmployeeLeaveQuota
+		joinDate    time.Time
+		at          time.Time
+		entries     []entry
+		count       float64
+		carriedOver float64
+	}{
+		{
+			name:     "joining in the middle of a month accrues the days after joining",
+			policy:   monthly,
+			quota:    EmployeeLeaveQuota{Year: 2024},
+			joinDate: date(2024, time.March, 16),
+			at:       date(2024, time.April, 10),
+			entries: []entry{
+				{LEDGER_ACCRUAL, 0.52, 0.52},
+				{LEDGER_ACCRUAL, 1, 1.52},
+			},
+			count: 1.52,
+		},
+		{
+			name:     "a new year carries over at most the cap",
+			policy:   yearly,
+			quota:    EmployeeLeaveQuota{Year: 2023, Count: 8, NextAccrualAt: at(date(2024, time.January, 1))},
+			joinDate: date(2020, time.June, 1),
+			at:       date(2024, time.January, 2),
+			entries: []entry{
+				{LEDGER_CARRY_OVER, -3, 5},
+				{LEDGER_ACCRUAL, 12, 17},
+			},
+			count:       17,
+			carriedOver: 5,
+		},
+		{
+			name:   "carried over days not taken expire",
+			policy: yearly,
+			quota: EmployeeLeaveQuota{
+				Year:                 2024,
+				Count:                15,
+				NextAccrualAt:        at(date(2025, time.January, 1)),
+				CarriedOver:          3,
+				CarriedOverExpiresAt: at(date(2024, time.April, 1)),
+			},
+			joinDate: date(2020, time.June, 1),
+			at:       date(2024, time.April, 2),
+			entries: []entry{
+				{LEDGER_EXPIRY, -3, 12},
+			},
+			count: 12,
+		},
+		{
+			name:   "carried over days are kept until they expire",
+			policy: yearly,
+			quota: EmployeeLeaveQuota{
+				Year:                 2024,
+				Count:                15,
+				NextAccrualAt:        at(date(2025, time.January, 1)),
+				CarriedOver:          3,
+				CarriedOverExpiresAt: at(date(2024, time.April, 1)),
+			},
+			joinDate:    date(2020, time.June, 1),
+			at:          date(2024, time.March, 31),
+			count:       15,
+			carriedOver: 3,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			quota := c.quota
+			entries := quota.Accrue(c.policy, c.joinDate, c.at)
+
+			if len(entries) != len(c.entries) {
+				t.Fatalf("expected %d ledger entries, got %+v", len(c.entries), entries)
+			}
+			for i, v := range c.entries {
+				if entries[i].Source != v.source || entries[i].Amount != v.amount || entries[i].Balance != v.balance {
+					t.Fatalf("expected entry %d to be %+v, got %+v", i, v, entries[i])
+				}
+			}
+			if quota.Count != c.count || quota.CarriedOver != c.carriedOver {
+				t.Fatalf("expected %v days with %v carried over, got %v with %v", c.count, c.carriedOver, quota.Count, quota.CarriedOver)
+			}
+
+			// Accruing again at the same time changes nothing
+			if entries := quota.Accrue(c.policy, c.joinDate, c.at); len(entries) != 0 {
+				t.Fatalf("expected no change accruing again, got %+v", entries)
+			}
+			if quota.Count != c.count || quota.CarriedOver != c.carriedOver {
+				t.Fatalf("expected accruing again to keep %v days, got %v", c.count, quota.Count)
+			}
+		})
+	}
+}
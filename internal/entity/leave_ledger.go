@@ -0,0 +1,132 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// LeaveLedgerSource tells what changed a leave quota.
+type LeaveLedgerSource string
+
+const (
+	LEDGER_ACCRUAL    LeaveLedgerSource = "ACCRUAL"
+	LEDGER_CARRY_OVER LeaveLedgerSource = "CARRY_OVER"
+	LEDGER_EXPIRY     LeaveLedgerSource = "EXPIRY"
+	LEDGER_RESET      LeaveLedgerSource = "RESET"
+	// LEDGER_LEAVE_TAKEN takes the quota of a leave request
+	LEDGER_LEAVE_TAKEN LeaveLedgerSource = "LEAVE_TAKEN"
+	// LEDGER_LEAVE_REJECTED returns the quota of a rejected leave
+	LEDGER_LEAVE_REJECTED LeaveLedgerSource = "LEAVE_REJECTED"
+	// LEDGER_LEAVE_CANCELLED returns the quota of a cancelled leave
+	LEDGER_LEAVE_CANCELLED LeaveLedgerSource = "LEAVE_CANCELLED"
+	// LEDGER_LEAVE_CLOSED returns the quota of a leave closed
+	// automatically because it was not decided before it started
+	LEDGER_LEAVE_CLOSED LeaveLedgerSource = "LEAVE_CLOSED"
+	// LEDGER_ADJUSTMENT is a manual adjustment made by HR
+	LEDGER_ADJUSTMENT LeaveLedgerSource = "ADJUSTMENT"
+)
+
+var (
+	// ErrLeaveQuotaNotFound is returned when the employee has no
+	// quota of the leave type to change, e.g. it has been removed
+	ErrLeaveQuotaNotFound = errors.New("the employee has no quota of the leave type")
+	// ErrNegativeLeaveQuota is returned when an adjustment takes
+	// more than the remaining quota
+	ErrNegativeLeaveQuota = errors.New("the leave quota cannot become negative")
+)
+
+// LeaveQuotaLedger is an entry of a change of the leave quota of
+// an employee. Entries are only appended. An entry with a Period is
+// made by the accrual and is unique for its source, so a period is
+// never accrued twice. An entry of a leave refers to the leave.
+type LeaveQuotaLedger struct {
+	BaseModelId
+
+	EmployeeID string            `gorm:"type:uuid;index:idx_leave_quota_ledger_period,unique,where:period <> ''"`
+	Type       LeaveType         `gorm:"type:varchar(100);index:idx_leave_quota_ledger_period"`
+	Source     LeaveLedgerSource `gorm:"type:varchar(20);index:idx_leave_quota_ledger_period"`
+	Period     string            `gorm:"type:varchar(20);index:idx_leave_quota_ledger_period"`
+	Amount     float64
+	// CarriedOver is the part of Amount taken from, or returned to,
+	// the days carried over from the last year
+	CarriedOver float64 `gorm:"default:0"`
+	// Balance is the remaining quota after the change
+	Balance float64
+	Note    string `gorm:"type:text"`
+
+	LeaveID *string `gorm:"type:uuid;default:null"`
+	// ActorID is the employee making the change. It is nil for a
+	// change made by the system.
+	ActorID *string `gorm:"type:uuid;default:null"`
+	Actor   *Employee
+
+	CreatedAt time.Time
+}
+
+// ValidateAdjustment validates a manual adjustment of a leave quota.
+func (v LeaveQuotaLedger) ValidateAdjustment() error {
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.EmployeeID, validation.Required),
+		validation.Field(&v.Type, validation.Required.Error("leave type is required")),
+		validation.Field(&v.Amount,
+			validation.Required.Error("adjustment amount must not be zero"),
+			validation.Min(-MAX_LEAVE_DURATION),
+			validation.Max(MAX_LEAVE_DURATION),
+		),
+		validation.Field(&v.Note,
+			validation.Required.Error("adjustment reason is required"),
+			validation.Length(5, 500),
+		),
+		validation.Field(&v.ActorID, validation.NotNil),
+	)
+}
+
+// CheckBalance returns ErrNegativeLeaveQuota if the entry takes more
+// than the given remaining quota.
+func (v LeaveQuotaLedger) CheckBalance(balance float64) error {
+	if balance+v.Amount < 0 {
+		return fmt.Errorf("%w, only %.2f days remain", ErrNegativeLeaveQuota, balance)
+	}
+
+	return nil
+}
+
+// NewLeaveLedgerEntry creates a ledger entry of a leave taking or
+// returning its quota. The holidays are not counted as leave days.
+func NewLeaveLedgerEntry(source LeaveLedgerSource, leave Leave, actorId *string, holidays ...time.Time) LeaveQuotaLedger {
+	amount := leave.Duration(holidays...)
+	if source == LEDGER_LEAVE_TAKEN {
+		amount = -amount
+	}
+
+	return LeaveQuotaLedger{
+		EmployeeID: leave.EmployeeID,
+		Type:       leave.Type,
+		Source:     source,
+		Amount:     amount,
+		Note:       fmt.Sprintf("%s leave from %s to %s", leave.Type, leave.From.Format(time.DateOnly), leave.To.Format(time.DateOnly)),
+		LeaveID:    &leave.Id,
+		ActorID:    actorId,
+	}
+}
+
+// CarriedOverPart returns the part of a change of the quota taken
+// from, or returned to, the days carried over. Taking the quota
+// takes the days carried over first. Returning the quota of a leave
+// returns up to the given days the leave took from them, unless they
+// have expired meanwhile.
+func (v EmployeeLeaveQuota) CarriedOverPart(amount, taken float64, at time.Time) float64 {
+	if amount < 0 {
+		return -math.Min(math.Max(v.CarriedOver, 0), -amount)
+	}
+
+	if v.CarriedOverExpiresAt != nil && !v.CarriedOverExpiresAt.After(at) {
+		return 0
+	}
+
+	return math.Min(math.Max(taken, 0), amount)
+}
@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLeaveQuotaAdjustmentBalance(t *testing.T) {
+	cases := []struct {
+		name    string
+		amount  float64
+		balance float64
+		wantErr bool
+	}{
+		{"adding to an empty quota", 2, 0, false},
+		{"taking part of the quota", -3, 5, false},
+		{"taking the whole quota", -5, 5, false},
+		{"taking more than the quota", -5.5, 5, true},
+		{"taking from an empty quota", -1, 0, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := LeaveQuotaLedger{Amount: c.amount}.CheckBalance(c.balance)
+			if c.wantErr != errors.Is(err, ErrNegativeLeaveQuota) {
+				t.Fatalf("expected negative quota error %v, got %v", c.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCarriedOverPartOfAChange(t *testing.T) {
+	at := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	later := at.AddDate(0, 1, 0)
+	earlier := at.AddDate(0, -1, 0)
+
+	cases := []struct {
+		name   string
+		quota  EmployeeLeaveQuota
+		amount float64
+		taken  float64
+		want   float64
+	}{
+		{"taking less than carried over", EmployeeLeaveQuota{CarriedOver: 3}, -2, 0, -2},
+		{"taking more than carried over", EmployeeLeaveQuota{CarriedOver: 3}, -5, 0, -3},
+		{"taking without carried over", EmployeeLeaveQuota{}, -2, 0, 0},
+		{"returning what was taken from carried over", EmployeeLeaveQuota{CarriedOverExpiresAt: &later}, 5, 3, 3},
+		{"returning part of a leave", EmployeeLeaveQuota{CarriedOverExpiresAt: &later}, 1, 3, 1},
+		{"returning after carried over expired", EmployeeLeaveQuota{CarriedOverExpiresAt: &earlier}, 5, 3, 0},
+		{"returning a leave that took none", EmployeeLeaveQuota{CarriedOver: 3}, 5, 0, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.quota.CarriedOverPart(c.amount, c.taken, at); got != c.want {
+				t.Fatalf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package entity
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"sinarlog.com/internal/utils"
+)
+
+// MAX_LEAVE_DURATION is the maximum days of a leave request of a
+// leave type without quota.
+const MAX_LEAVE_DURATION float64 = 365
+
+// LeavePolicy is a leave type defined by HR together with the
+// rules of requesting it. The leave type is referred by its code,
+// hence ANNUAL, UNPAID, SICK and MARRIAGE are the built-in ones.
+type LeavePolicy struct {
+	BaseModelId
+
+	Code LeaveType `gorm:"type:varchar(100);index:,unique,where:deleted_at IS NULL"`
+	Name string    `gorm:"type:varchar(150)"`
+
+	// A leave type with quota is given DefaultQuota days yearly
+	// for each employee. A leave type without quota can be taken
+	// as long as MAX_LEAVE_DURATION days on each request.
+	HasQuota           bool
+	DefaultQuota       float64
+	IsPaid             bool
+	RequiresAttachment bool
+	MinNoticeDays      int
+
+	// A leave type with quota accrues its DefaultQuota monthly or
+	// yearly when AccrualPeriod is not NO_ACCRUAL. On a new year,
+	// at most MaxCarryOver days of the remaining quota are carried
+	// over and expire CarryOverExpiryMonths months later, where 0
+	// means they never expire. A leave type without accrual is only
+	// renewed to its DefaultQuota on a new year if ResetYearly.
+	AccrualPeriod         AccrualPeriod `gorm:"type:varchar(20);default:NONE"`
+	MaxCarryOver          float64
+	CarryOverExpiryMonths int
+	ResetYearly           bool
+
+	// The leave types to overflow the excess of a leave request
+	// when the quota is not sufficient.
+	OverflowTargets []LeaveType `gorm:"serializer:json"`
+	// Employees eligible to take the leave type. Empty means
+	// all employees are eligible.
+	EligibleGenders   []Gender       `gorm:"serializer:json"`
+	EligibleContracts []ContractType `gorm:"serializer:json"`
+
+	BaseModelStamps
+	BaseModelSoftDelete
+}
+
+// EmployeeLeaveQuota is the remaining quota of a leave type of
+// an employee.
+type EmployeeLeaveQuota struct {
+	BaseModelId
+
+	EmployeeID string    `gorm:"type:uuid;uniqueIndex:idx_employee_leave_quota_type"`
+	Type       LeaveType `gorm:"type:varchar(100);uniqueIndex:idx_employee_leave_quota_type"`
+	Count      float64   `gorm:"default:0"`
+
+	// Year is the leave year the quota belongs to. A zero Year is a
+	// quota given in full before it is accrued, hence it covers the
+	// whole year it is accrued the first time.
+	Year int
+	// NextAccrualAt is the start of the next accrual period not
+	// accrued yet. Nil means accruing from the join date.
+	NextAccrualAt *time.Time
+	// CarriedOver is the part of Count carried over from the last
+	// year, which is taken first and expires at CarriedOverExpiresAt.
+	CarriedOver          float64 `gorm:"default:0"`
+	CarriedOverExpiresAt *time.Time
+
+	BaseModelStamps
+	BaseModelSoftDelete
+}
+
+// EmployeeLeavesQuota is the remaining quota of each leave type
+// of an employee.
+type EmployeeLeavesQuota []EmployeeLeaveQuota
+
+func (v LeavePolicy) Validate() error {
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.Code,
+			validation.Required.Error("leave type code is required"),
+			validation.Match(regexp.MustCompile(`^[A-Z][A-Z_]{2,99}$`)).Error("leave type code must be uppercase letters or underscores"),
+		),
+		validation.Field(&v.Name, validation.Required, validation.Length(3, 150)),
+		validation.Field(&v.DefaultQuota, validation.Min(0.0), validation.Max(MAX_LEAVE_DURATION)),
+		validation.Field(&v.MinNoticeDays, validation.Min(0), validation.Max(365)),
+		validation.Field(&v.AccrualPeriod,
+			validation.In(NO_ACCRUAL, MONTHLY_ACCRUAL, YEARLY_ACCRUAL),
+			validation.When(!v.HasQuota, validation.In(NO_ACCRUAL).Error("a leave type without quota cannot accrue")),
+		),
+		validation.Field(&v.MaxCarryOver, validation.Min(0.0), validation.Max(MAX_LEAVE_DURATION)),
+		validation.Field(&v.CarryOverExpiryMonths, validation.Min(0), validation.Max(12)),
+		validation.Field(&v.ResetYearly,
+			validation.When(v.Accrues(), validation.Empty.Error("an accruing leave type is already renewed by its accrual")),
+			validation.When(!v.HasQuota, validation.Empty.Error("a leave type without quota cannot be reset")),
+		),
+		validation.Field(&v.OverflowTargets, validation.Each(validation.NotIn(v.Code).Error("a leave type must not overflow to itself"))),
+		validation.Field(&v.EligibleGenders, validation.Each(validation.In(M, F))),
+		validation.Field(&v.EligibleContracts, validation.Each(validation.In(FULL_TIME, CONTRACT, INTERN))),
+	)
+}
+
+// CheckEligibility returns an error if the employee is not eligible
+// to take the leave type.
+func (v LeavePolicy) CheckEligibility(employee Employee, biodata EmployeeBiodata) error {
+	if len(v.EligibleGenders) > 0 && !contains(v.EligibleGenders, biodata.Gender) {
+		return fmt.Errorf("%s leave is not available for your gender", strings.ToLower(v.Name))
+	}
+
+	if len(v.EligibleContracts) > 0 && !contains(v.EligibleContracts, employee.ContractType) {
+		return fmt.Errorf("%s leave is not available for your contract type", strings.ToLower(v.Name))
+	}
+
+	// A marriage leave is given only once
+	if v.Code == MARRIAGE && biodata.MaritalStatus {
+		return fmt.Errorf("employee is already married")
+	}
+
+	return nil
+}
+
+// CheckNotice returns an error if the leave is not requested more
+// than MinNoticeDays days before it starts.
+func (v LeavePolicy) CheckNotice(leave Leave) error {
+	if v.MinNoticeDays == 0 {
+		return nil
+	}
+
+	if utils.CountNumberOfDays(time.Now().In(utils.CURRENT_LOC), leave.From) <= v.MinNoticeDays {
+		return fmt.Errorf("a %s leave request must be submitted %d days from now", strings.ToLower(v.Name), v.MinNoticeDays)
+	}
+
+	return nil
+}
+
+// Remaining returns the remaining quota of the leave type. A leave
+// type without quota always has MAX_LEAVE_DURATION days remaining.
+func (v EmployeeLeavesQuota) Remaining(policy LeavePolicy) float64 {
+	if !policy.HasQuota {
+		return MAX_LEAVE_DURATION
+	}
+
+	for _, quota := range v {
+		if quota.Type == policy.Code {
+			return quota.Count
+		}
+	}
+
+	return 0
+}
+
+// Refund returns the quota taken by the leaves to the employee.
+// It is used to count the quota of an amended leave. The holidays
+// are not counted as leave days.
+func (v EmployeeLeavesQuota) Refund(leaves []Leave, holidays ...time.Time) {
+	for _, leave := range leaves {
+		for i := range v {
+			if v[i].Type == leave.Type {
+				v[i].Count += leave.Duration(holidays...)
+			}
+		}
+	}
+}
+
+func contains[T comparable](values []T, value T) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,86 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"sinarlog.com/internal/utils"
+)
+
+func TestValidatingLeavePolicies(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy LeavePolicy
+		valid  bool
+	}{
+		{"a leave type with quota", LeavePolicy{Code: "MATERNITY", Name: "Maternity", HasQuota: true, DefaultQuota: 90}, true},
+		{"a lowercase code", LeavePolicy{Code: "maternity", Name: "Maternity"}, false},
+		{"an accruing leave type", LeavePolicy{Code: "STUDY", Name: "Study", HasQuota: true, AccrualPeriod: MONTHLY_ACCRUAL}, true},
+		{"an accruing leave type without quota", LeavePolicy{Code: "STUDY", Name: "Study", AccrualPeriod: MONTHLY_ACCRUAL}, false},
+		{"an accruing leave type reset yearly", LeavePolicy{Code: "STUDY", Name: "Study", HasQuota: true, AccrualPeriod: YEARLY_ACCRUAL, ResetYearly: true}, false},
+		{"an overflow to itself", LeavePolicy{Code: "STUDY", Name: "Study", OverflowTargets: []LeaveType{"STUDY"}}, false},
+		{"a notice of over a year", LeavePolicy{Code: "STUDY", Name: "Study", MinNoticeDays: 366}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.policy.Validate(); (err == nil) != c.valid {
+				t.Fatalf("expected valid to be %v, got %v", c.valid, err)
+			}
+		})
+	}
+}
+
+func TestLeaveEligibility(t *testing.T) {
+	policy := LeavePolicy{Name: "Maternity", EligibleGenders: []Gender{F}, EligibleContracts: []ContractType{FULL_TIME}}
+
+	cases := []struct {
+		name     string
+		contract ContractType
+		gender   Gender
+		eligible bool
+	}{
+		{"an eligible employee", FULL_TIME, F, true},
+		{"another gender", FULL_TIME, M, false},
+		{"another contract", INTERN, F, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := policy.CheckEligibility(Employee{ContractType: c.contract}, EmployeeBiodata{Gender: c.gender})
+			if (err == nil) != c.eligible {
+				t.Fatalf("expected eligible to be %v, got %v", c.eligible, err)
+			}
+		})
+	}
+
+	marriage := LeavePolicy{Code: MARRIAGE, Name: "Marriage"}
+	if err := marriage.CheckEligibility(Employee{}, EmployeeBiodata{MaritalStatus: true}); err == nil {
+		t.Fatalf("expected a married employee not to take a marriage leave again")
+	}
+}
+
+func TestLeaveNotice(t *testing.T) {
+	policy := LeavePolicy{Name: "Annual", MinNoticeDays: 7}
+	now := time.Now().In(utils.CURRENT_LOC)
+
+	cases := []struct {
+		name   string
+		policy LeavePolicy
+		from   time.Time
+		valid  bool
+	}{
+		{"more than the notice", policy, now.Add(8 * 24 * time.Hour), true},
+		{"exactly the notice", policy, now.Add(7*24*time.Hour - time.Minute), false},
+		{"less than the notice", policy, now.Add(3 * 24 * time.Hour), false},
+		{"no notice required", LeavePolicy{Name: "Sick"}, now, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.policy.CheckNotice(Leave{From: c.from}); (err == nil) != c.valid {
+				t.Fatalf("expected valid to be %v, got %v", c.valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package entity
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"sinarlog.com/internal/utils"
+)
+
+func TestLegacyLeaveDurationWithHolidays(t *testing.T) {
+	// Monday, 8th April 2024 to Friday, 12th April 2024
+	leave := Leave{
+		From: time.Date(2024, time.April, 8, 0, 0, 0, 0, utils.CURRENT_LOC),
+		To:   time.Date(2024, time.April, 12, 23, 59, 59, 0, utils.CURRENT_LOC),
+	}
+	lebaran := HolidayDates([]Holiday{
+		{Date: time.Date(2024, time.April, 10, 0, 0, 0, 0, utils.CURRENT_LOC)},
+		{Date: time.Date(2024, time.April, 11, 0, 0, 0, 0, utils.CURRENT_LOC)},
+	})
+
+	if d := leave.Duration(); d != 5 {
+		t.Fatalf("expected 5 days, got %v", d)
+	}
+
+	if d := leave.Duration(lebaran...); d != 3 {
+		t.Fatalf("expected 3 days, got %v", d)
+	}
+
+	// The recorded working days are already counted without holidays
+	leave.WorkingDays = 4
+	if d := leave.Duration(lebaran...); d != 4 {
+		t.Fatalf("expected the recorded 4 days, got %v", d)
+	}
+
+	entry := NewLeaveLedgerEntry(LEDGER_LEAVE_TAKEN, Leave{From: leave.From, To: leave.To}, nil, lebaran...)
+	if entry.Amount != -3 {
+		t.Fatalf("expected the ledger to take 3 days, got %v", entry.Amount)
+	}
+}
+
+func TestRefundableLeaves(t *testing.T) {
+	truee, falsee := true, false
+	leave := func(id string, approved *bool, childs ...Leave) Leave {
+		return Leave{BaseModelId: BaseModelId{Id: id}, ApprovedByManager: approved, ApprovedByHr: approved, Childs: childs}
+	}
+
+	testCases := []struct {
+		name  string
+		leave Leave
+		ids   []string
+	}{
+		{"A pending leave", leave("parent", nil), []string{"parent"}},
+		{"An approved leave", leave("parent", &truee), []string{"parent"}},
+		{"An overflowed leave", leave("parent", &truee, leave("a", &truee), leave("b", &truee)), []string{"parent", "a", "b"}},
+		{"A rejected overflow", leave("parent", &truee, leave("a", &falsee), leave("b", nil)), []string{"parent", "b"}},
+		{"A rejected leave", leave("parent", &falsee, leave("a", &falsee)), nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var ids []string
+			for _, v := range tc.leave.RefundableLeaves() {
+				ids = append(ids, v.Id)
+			}
+			if !reflect.DeepEqual(ids, tc.ids) {
+				t.Fatalf("expected %v to be refunded, got %v", tc.ids, ids)
+			}
+		})
+	}
+}
+
+func TestRefundingQuotas(t *testing.T) {
+	quota := EmployeeLeavesQuota{{Type: ANNUAL, Count: 1}, {Type: UNPAID}}
+	// Monday, 8th April 2024 to Wednesday, 10th April 2024 with an
+	// unpaid overflow on Thursday
+	leave := Leave{
+		Type: ANNUAL,
+		From: time.Date(2024, time.April, 8, 0, 0, 0, 0, utils.CURRENT_LOC),
+		To:   time.Date(2024, time.April, 10, 23, 59, 59, 0, utils.CURRENT_LOC),
+		Childs: []Leave{{
+			Type:        UNPAID,
+			From:        time.Date(2024, time.April, 11, 0, 0, 0, 0, utils.CURRENT_LOC),
+			To:          time.Date(2024, time.April, 11, 23, 59, 59, 0, utils.CURRENT_LOC),
+			WorkingDays: 1,
+		}},
+	}
+	lebaran := HolidayDates([]Holiday{{Date: time.Date(2024, time.April, 10, 0, 0, 0, 0, utils.CURRENT_LOC)}})
+
+	quota.Refund(leave.RefundableLeaves(), lebaran...)
+	if quota[0].Count != 3 || quota[1].Count != 1 {
+		t.Fatalf("expected 3 annual and 1 unpaid days, got %v and %v", quota[0].Count, quota[1].Count)
+	}
+}
@@ -0,0 +1,128 @@
+package entity
+
+import (
+	"fmt"
+	"math"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type GeofenceMode string
+
+const (
+	// GEOFENCE_FLAG accepts attendances made outside every office
+	// but marks them so HR can audit them later.
+	GEOFENCE_FLAG GeofenceMode = "FLAG"
+	// GEOFENCE_REJECT refuses attendances made outside every office.
+	GEOFENCE_REJECT GeofenceMode = "REJECT"
+)
+
+// OfficeLocation is an office site where employees are allowed
+// to clock in and out. A site is either a circle, defined by its
+// center and radius, or a polygon when at least three vertices
+// are provided. The polygon takes precedence over the circle.
+type OfficeLocation struct {
+	BaseModelId
+
+	ConfigurationID string `gorm:"type:uuid"`
+	Name            string `gorm:"type:varchar(150)"`
+	Center          Point
+	Radius          float64 // metres
+	Polygon         Polygon `gorm:"type:jsonb"`
+
+	BaseModelStamps
+	BaseModelSoftDelete
+}
+
+func (v OfficeLocation) Validate() error {
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.Name, validation.Required, validation.Length(3, 150)),
+		validation.Field(&v.Center),
+		validation.Field(&v.Radius, validation.When(len(v.Polygon) == 0,
+			validation.Required.Error("an office without a polygon must have a radius"),
+			validation.Min(float64(10)).Error("office radius must be at least 10 metres"),
+		)),
+		validation.Field(&v.Polygon, validation.By(func(value interface{}) error {
+			polygon, ok := value.(Polygon)
+			if !ok {
+				return fmt.Errorf("invalid data type for office polygon")
+			}
+
+			if len(polygon) != 0 && len(polygon) < 3 {
+				return fmt.Errorf("an office polygon must have at least 3 vertices")
+			}
+
+			return nil
+		})),
+	)
+}
+
+// Contains returns whether the point p is inside the office site.
+func (v OfficeLocation) Contains(p Point) bool {
+	return v.DistanceTo(p) == 0
+}
+
+// DistanceTo calculates in metres the distance from the office's
+// boundary to the point p. It returns 0 if p is inside the office.
+func (v OfficeLocation) DistanceTo(p Point) float64 {
+	if len(v.Polygon) >= 3 {
+		return v.Polygon.DistanceTo(p)
+	}
+
+	return math.Max(0, v.Center.DistanceTo(p)-v.Radius)
+}
+
+// LocateOffice returns the office nearest to the point p together
+// with the distance in metres to that office. The returned bool
+// reports whether any office were found.
+func LocateOffice(offices []OfficeLocation, p Point) (OfficeLocation, float64, bool) {
+	var nearest OfficeLocation
+	distance := math.Inf(1)
+
+	for _, office := range offices {
+		if d := office.DistanceTo(p); d < distance {
+			nearest = office
+			distance = d
+		}
+	}
+
+	if len(offices) == 0 {
+		return nearest, 0, false
+	}
+
+	return nearest, distance, true
+}
+
+// Geofence holds the result of checking an attendance location
+// against the company's office sites.
+type Geofence struct {
+	// Whether the location is outside every office
+	IsOutside bool
+	// The nearest office from the location
+	NearestOffice OfficeLocation
+	// The distance in metres to the nearest office
+	Distance float64
+}
+
+// CheckGeofence checks the point p against the configured office
+// sites. It returns an empty geofence if no office is configured,
+// and an error if p is outside every office while the mode is
+// GEOFENCE_REJECT.
+func (c Configuration) CheckGeofence(p Point) (Geofence, error) {
+	nearest, distance, found := LocateOffice(c.OfficeLocations, p)
+	if !found {
+		return Geofence{}, nil
+	}
+
+	fence := Geofence{
+		IsOutside:     distance > 0,
+		NearestOffice: nearest,
+		Distance:      distance,
+	}
+
+	if fence.IsOutside && c.GeofenceMode == GEOFENCE_REJECT {
+		return fence, fmt.Errorf("you are %.0f metres away from %s. Please be inside one of the office areas", distance, nearest.Name)
+	}
+
+	return fence, nil
+}
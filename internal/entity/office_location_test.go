@@ -0,0 +1,115 @@
+package entity
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// A square of about 111 metres around Monas, Jakarta, where X is the
+// longitude and Y the latitude
+var testOfficeSquare = Polygon{
+	{X: 106.8270, Y: -6.1760},
+	{X: 106.8280, Y: -6.1760},
+	{X: 106.8280, Y: -6.1750},
+	{X: 106.8270, Y: -6.1750},
+}
+
+func TestOfficePolygonContains(t *testing.T) {
+	// An L shape missing the north east quarter of the square
+	lShape := Polygon{
+		{X: 106.8270, Y: -6.1760},
+		{X: 106.8280, Y: -6.1760},
+		{X: 106.8280, Y: -6.1755},
+		{X: 106.8275, Y: -6.1755},
+		{X: 106.8275, Y: -6.1750},
+		{X: 106.8270, Y: -6.1750},
+	}
+
+	cases := []struct {
+		name    string
+		polygon Polygon
+		point   Point
+		inside  bool
+	}{
+		{"the middle of the square", testOfficeSquare, Point{X: 106.8275, Y: -6.1755}, true},
+		{"east of the square", testOfficeSquare, Point{X: 106.8290, Y: -6.1755}, false},
+		{"north of the square", testOfficeSquare, Point{X: 106.8275, Y: -6.1740}, false},
+		{"the south west of the L", lShape, Point{X: 106.8272, Y: -6.1758}, true},
+		{"the missing corner of the L", lShape, Point{X: 106.8278, Y: -6.1752}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.polygon.Contains(c.point); got != c.inside {
+				t.Fatalf("expected inside to be %v, got %v", c.inside, got)
+			}
+			if d := c.polygon.DistanceTo(c.point); (d == 0) != c.inside {
+				t.Fatalf("expected a zero distance only inside, got %.2f metres", d)
+			}
+		})
+	}
+}
+
+func TestOfficePolygonDistance(t *testing.T) {
+	// 0.001 degree of latitude is about 111 metres
+	d := testOfficeSquare.DistanceTo(Point{X: 106.8275, Y: -6.1740})
+	if math.Abs(d-111) > 2 {
+		t.Fatalf("expected about 111 metres north of the square, got %.2f", d)
+	}
+
+	if d := (Polygon{}).DistanceTo(Point{X: 106.8275, Y: -6.1755}); !math.IsInf(d, 1) {
+		t.Fatalf("expected an empty polygon to be infinitely far, got %.2f", d)
+	}
+}
+
+func TestCheckingGeofence(t *testing.T) {
+	square := OfficeLocation{BaseModelId: BaseModelId{Id: "square"}, Name: "Monas", Polygon: testOfficeSquare}
+	circle := OfficeLocation{BaseModelId: BaseModelId{Id: "circle"}, Name: "Kota", Center: Point{X: 106.8130, Y: -6.1350}, Radius: 100}
+	config := Configuration{OfficeLocations: []OfficeLocation{square, circle}, GeofenceMode: GEOFENCE_FLAG}
+
+	// About 50 metres from the center of the circle
+	fence, err := config.CheckGeofence(Point{X: 106.8130, Y: -6.13545})
+	if err != nil || fence.IsOutside || fence.NearestOffice.Id != "circle" {
+		t.Fatalf("expected to be inside the circle, got %+v, %v", fence, err)
+	}
+
+	outside := Point{X: 106.8275, Y: -6.1740}
+	fence, err = config.CheckGeofence(outside)
+	if err != nil || !fence.IsOutside || fence.NearestOffice.Id != "square" {
+		t.Fatalf("expected to be flagged outside near the square, got %+v, %v", fence, err)
+	}
+
+	config.GeofenceMode = GEOFENCE_REJECT
+	if _, err := config.CheckGeofence(outside); err == nil {
+		t.Fatalf("expected to be rejected outside every office")
+	}
+
+	if fence, err := (Configuration{GeofenceMode: GEOFENCE_REJECT}).CheckGeofence(outside); err != nil || fence.IsOutside {
+		t.Fatalf("expected no geofence without any office, got %+v, %v", fence, err)
+	}
+}
+
+func TestScanningPolygons(t *testing.T) {
+	value, err := testOfficeSquare.Value()
+	if err != nil {
+		t.Fatalf("expected the polygon to be stored, got %s", err)
+	}
+
+	var polygon Polygon
+	if err := polygon.Scan(value); err != nil {
+		t.Fatalf("expected the polygon to be scanned, got %s", err)
+	}
+	if !reflect.DeepEqual(polygon, testOfficeSquare) {
+		t.Fatalf("expected %v, got %v", testOfficeSquare, polygon)
+	}
+
+	// A polygon not stored is left empty
+	var empty Polygon
+	if err := empty.Scan(nil); err != nil || empty != nil {
+		t.Fatalf("expected an empty polygon, got %v, %v", empty, err)
+	}
+	if err := empty.Scan("[]"); err == nil {
+		t.Fatalf("expected a string not to be scanned")
+	}
+}
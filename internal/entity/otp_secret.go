@@ -0,0 +1,39 @@
+package entity
+
+import "time"
+
+// EmployeeOTPSecret is the TOTP secret of an employee, encrypted at
+// rest. The OTPs are sent by email until the employee enrolls the
+// secret in an authenticator app, after which the app generates them
+// and they are also asked for at login.
+type EmployeeOTPSecret struct {
+	BaseModelId
+
+	EmployeeID string `gorm:"type:uuid;uniqueIndex"`
+	Employee   *Employee
+	Secret     string `gorm:"type:text"`
+	EnrolledAt *time.Time
+	// The time step of the last OTP accepted from the authenticator
+	// app. An OTP of the same step or before is a replay.
+	LastUsedStep int64
+
+	BaseModelStamps
+}
+
+// IsEnrolled returns whether the OTPs come from an authenticator app.
+func (s EmployeeOTPSecret) IsEnrolled() bool {
+	return s.EnrolledAt != nil
+}
+
+// EmployeeRecoveryCode lets an employee log in without the
+// authenticator app. Only its hash is stored, and it is used once.
+type EmployeeRecoveryCode struct {
+	BaseModelId
+
+	EmployeeID string `gorm:"type:uuid;index"`
+	Employee   *Employee
+	CodeHash   string `gorm:"type:varchar(64)"`
+	UsedAt     *time.Time
+
+	BaseModelStamps
+}
@@ -16,6 +16,8 @@ type Overtime struct {
 	Reason        string `gorm:"type:text"`
 	AttachmentUrl string `gorm:"type:varchar(255)"`
 
+	// ApprovedByManager is the final decision of the approval
+	// chain made at ActionByManagerAt, which may be decided by HR.
 	ManagerID           *string
 	Manager             *Employee
 	ApprovedByManager   *bool
@@ -23,6 +25,10 @@ type Overtime struct {
 	RejectionReason     string
 	ClosedAutomatically *bool
 
+	// The steps of the approval chain. An overtime made before
+	// approval chains existed has no steps.
+	ApprovalSteps []ApprovalStep `gorm:"foreignKey:OvertimeID"`
+
 	Attendance Attendance
 
 	BaseModelStamps
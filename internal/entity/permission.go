@@ -0,0 +1,198 @@
+package entity
+
+import (
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// Permission is an action an employee may be allowed to take. A role
+// bundles permissions, each granted within a scope.
+type Permission string
+
+const (
+	PERM_EMPLOYEE_READ      Permission = "employee.read"
+	PERM_EMPLOYEE_CREATE    Permission = "employee.create"
+	PERM_EMPLOYEE_UPDATE    Permission = "employee.update"
+	PERM_CREDENTIAL_MANAGE  Permission = "credential.manage"
+	PERM_ATTENDANCE_CLOCK   Permission = "attendance.clock"
+	PERM_ATTENDANCE_READ    Permission = "attendance.read"
+	PERM_LEAVE_APPLY        Permission = "leave.apply"
+	PERM_LEAVE_READ         Permission = "leave.read"
+	PERM_LEAVE_APPROVE      Permission = "leave.approve"
+	PERM_LEAVE_ADJUST       Permission = "leave.adjust"
+	PERM_LEAVE_POLICY_WRITE Permission = "leave_policy.write"
+	PERM_OVERTIME_READ      Permission = "overtime.read"
+	PERM_OVERTIME_APPROVE   Permission = "overtime.approve"
+	PERM_APPROVAL_WRITE     Permission = "approval.write"
+	PERM_DELEGATION_WRITE   Permission = "delegation.write"
+	PERM_SCHEDULE_WRITE     Permission = "schedule.write"
+	PERM_CONFIG_READ        Permission = "config.read"
+	PERM_CONFIG_WRITE       Permission = "config.write"
+	PERM_SCHEDULER_MANAGE   Permission = "scheduler.manage"
+	PERM_ANALYTICS_READ     Permission = "analytics.read"
+	PERM_ROLE_WRITE         Permission = "role.write"
+)
+
+// Permissions are every permission a role may be granted, with what
+// each allows.
+var Permissions = map[Permission]string{
+	PERM_EMPLOYEE_READ:      "View the full profile and changes log of employees",
+	PERM_EMPLOYEE_CREATE:    "Register new employees",
+	PERM_EMPLOYEE_UPDATE:    "Update the work information of employees",
+	PERM_CREDENTIAL_MANAGE:  "Reset the two-factor authentication and unlock the accounts of employees",
+	PERM_ATTENDANCE_CLOCK:   "Clock in and out",
+	PERM_ATTENDANCE_READ:    "View the attendances of employees",
+	PERM_LEAVE_APPLY:        "Apply for, amend and cancel leaves",
+	PERM_LEAVE_READ:         "View the leaves and leave quota of employees",
+	PERM_LEAVE_APPROVE:      "Approve or reject the leaves of employees",
+	PERM_LEAVE_ADJUST:       "Adjust the leave quota of employees",
+	PERM_LEAVE_POLICY_WRITE: "Manage the leave types",
+	PERM_OVERTIME_READ:      "View the overtimes of employees",
+	PERM_OVERTIME_APPROVE:   "Approve or reject the overtimes of employees",
+	PERM_APPROVAL_WRITE:     "Manage the approval chains",
+	PERM_DELEGATION_WRITE:   "Delegate the approval of requests",
+	PERM_SCHEDULE_WRITE:     "Manage the work schedules and holidays",
+	PERM_CONFIG_READ:        "View the company configuration",
+	PERM_CONFIG_WRITE:       "Change the company configuration and office locations",
+	PERM_SCHEDULER_MANAGE:   "View and trigger the scheduled jobs",
+	PERM_ANALYTICS_READ:     "View the analytics dashboard",
+	PERM_ROLE_WRITE:         "Manage the roles and their permissions",
+}
+
+// PermissionScope is whose data a permission is granted over. Each
+// scope includes the ones before it.
+type PermissionScope string
+
+const (
+	SCOPE_OWN            PermissionScope = "OWN"
+	SCOPE_DIRECT_REPORTS PermissionScope = "DIRECT_REPORTS"
+	// The direct reports and the reports of the direct reports, as the
+	// department head of the approval chains
+	SCOPE_DEPARTMENT PermissionScope = "DEPARTMENT"
+	SCOPE_ALL        PermissionScope = "ALL"
+)
+
+var permissionScopeRanks = map[PermissionScope]int{
+	SCOPE_OWN:            1,
+	SCOPE_DIRECT_REPORTS: 2,
+	SCOPE_DEPARTMENT:     3,
+	SCOPE_ALL:            4,
+}
+
+// Includes returns whether the scope covers the other one.
+func (s PermissionScope) Includes(other PermissionScope) bool {
+	return permissionScopeRanks[s] >= permissionScopeRanks[other]
+}
+
+// RolePermission grants a permission to a role within a scope.
+type RolePermission struct {
+	BaseModelId
+
+	RoleID     string          `gorm:"type:uuid;uniqueIndex:idx_role_permission"`
+	Permission Permission      `gorm:"type:varchar(100);uniqueIndex:idx_role_permission"`
+	Scope      PermissionScope `gorm:"type:varchar(20)"`
+
+	BaseModelStamps
+}
+
+func (v RolePermission) Validate() error {
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.Permission, validation.Required, validation.By(func(value interface{}) error {
+			if _, ok := Permissions[value.(Permission)]; !ok {
+				return fmt.Errorf("%s is not a permission", value)
+			}
+			return nil
+		})),
+		validation.Field(&v.Scope, validation.Required, validation.In(SCOPE_OWN, SCOPE_DIRECT_REPORTS, SCOPE_DEPARTMENT, SCOPE_ALL)),
+	)
+}
+
+// DefaultRolePermissions are the permissions the seeded roles start
+// with, by role code.
+var DefaultRolePermissions = map[string][]RolePermission{
+	"staff": {
+		{Permission: PERM_EMPLOYEE_READ, Scope: SCOPE_OWN},
+		{Permission: PERM_ATTENDANCE_CLOCK, Scope: SCOPE_OWN},
+		{Permission: PERM_ATTENDANCE_READ, Scope: SCOPE_OWN},
+		{Permission: PERM_LEAVE_APPLY, Scope: SCOPE_OWN},
+		{Permission: PERM_LEAVE_READ, Scope: SCOPE_OWN},
+		{Permission: PERM_OVERTIME_READ, Scope: SCOPE_OWN},
+		{Permission: PERM_ANALYTICS_READ, Scope: SCOPE_OWN},
+	},
+	"mngr": {
+		{Permission: PERM_EMPLOYEE_READ, Scope: SCOPE_DIRECT_REPORTS},
+		{Permission: PERM_ATTENDANCE_CLOCK, Scope: SCOPE_OWN},
+		{Permission: PERM_ATTENDANCE_READ, Scope: SCOPE_DIRECT_REPORTS},
+		{Permission: PERM_LEAVE_APPLY, Scope: SCOPE_OWN},
+		{Permission: PERM_LEAVE_READ, Scope: SCOPE_DIRECT_REPORTS},
+		{Permission: PERM_LEAVE_APPROVE, Scope: SCOPE_DIRECT_REPORTS},
+		{Permission: PERM_OVERTIME_READ, Scope: SCOPE_DIRECT_REPORTS},
+		{Permission: PERM_OVERTIME_APPROVE, Scope: SCOPE_DIRECT_REPORTS},
+		{Permission: PERM_DELEGATION_WRITE, Scope: SCOPE_OWN},
+		{Permission: PERM_ANALYTICS_READ, Scope: SCOPE_DIRECT_REPORTS},
+	},
+	"hr": {
+		{Permission: PERM_EMPLOYEE_READ, Scope: SCOPE_ALL},
+		{Permission: PERM_EMPLOYEE_CREATE, Scope: SCOPE_ALL},
+		{Permission: PERM_EMPLOYEE_UPDATE, Scope: SCOPE_ALL},
+		{Permission: PERM_CREDENTIAL_MANAGE, Scope: SCOPE_ALL},
+		{Permission: PERM_ATTENDANCE_READ, Scope: SCOPE_ALL},
+		{Permission: PERM_LEAVE_READ, Scope: SCOPE_ALL},
+		{Permission: PERM_LEAVE_APPROVE, Scope: SCOPE_ALL},
+		{Permission: PERM_LEAVE_ADJUST, Scope: SCOPE_ALL},
+		{Permission: PERM_LEAVE_POLICY_WRITE, Scope: SCOPE_ALL},
+		{Permission: PERM_OVERTIME_READ, Scope: SCOPE_ALL},
+		{Permission: PERM_OVERTIME_APPROVE, Scope: SCOPE_ALL},
+		{Permission: PERM_APPROVAL_WRITE, Scope: SCOPE_ALL},
+		{Permission: PERM_DELEGATION_WRITE, Scope: SCOPE_ALL},
+		{Permission: PERM_SCHEDULE_WRITE, Scope: SCOPE_ALL},
+		{Permission: PERM_CONFIG_READ, Scope: SCOPE_ALL},
+		{Permission: PERM_CONFIG_WRITE, Scope: SCOPE_ALL},
+		{Permission: PERM_SCHEDULER_MANAGE, Scope: SCOPE_ALL},
+		{Permission: PERM_ANALYTICS_READ, Scope: SCOPE_ALL},
+		{Permission: PERM_ROLE_WRITE, Scope: SCOPE_ALL},
+	},
+}
+
+// ScopeOf returns the scope the role is granted the permission within,
+// and whether it is granted at all.
+func (r Role) ScopeOf(perm Permission) (PermissionScope, bool) {
+	for _, v := range r.Permissions {
+		if v.Permission == perm {
+			return v.Scope, true
+		}
+	}
+
+	return "", false
+}
+
+// CanWithin returns whether the employee is granted the permission
+// within the scope or a wider one.
+func (e Employee) CanWithin(perm Permission, scope PermissionScope) bool {
+	granted, ok := e.Role.ScopeOf(perm)
+	return ok && granted.Includes(scope)
+}
+
+// Can is the policy deciding whether the employee may take the action
+// of the permission on the data of the target employee. Without a
+// target, it tells whether the permission is granted within any scope.
+// The department scope needs the manager of the target to be loaded.
+func (e Employee) Can(perm Permission, target *Employee) bool {
+	if target == nil {
+		return e.CanWithin(perm, SCOPE_OWN)
+	}
+
+	// The narrowest scope the target falls within
+	required := SCOPE_ALL
+	switch {
+	case target.Id == e.Id:
+		required = SCOPE_OWN
+	case target.ManagerID != nil && *target.ManagerID == e.Id:
+		required = SCOPE_DIRECT_REPORTS
+	case target.Manager != nil && target.Manager.ManagerID != nil && *target.Manager.ManagerID == e.Id:
+		required = SCOPE_DEPARTMENT
+	}
+
+	return e.CanWithin(perm, required)
+}
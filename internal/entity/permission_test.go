@@ -0,0 +1,105 @@
+package entity
+
+import "testing"
+
+func TestPermissionScopeIncludes(t *testing.T) {
+	if !SCOPE_ALL.Includes(SCOPE_DEPARTMENT) || !SCOPE_DEPARTMENT.Includes(SCOPE_DIRECT_REPORTS) || !SCOPE_OWN.Includes(SCOPE_OWN) {
+		t.Fatalf("expected a scope to include itself and the narrower ones")
+	}
+	if SCOPE_DIRECT_REPORTS.Includes(SCOPE_DEPARTMENT) || SCOPE_OWN.Includes(SCOPE_ALL) {
+		t.Fatalf("expected a scope not to include the wider ones")
+	}
+	if PermissionScope("").Includes(SCOPE_OWN) {
+		t.Fatalf("expected an unknown scope to include nothing")
+	}
+}
+
+func TestEmployeeCan(t *testing.T) {
+	head := Employee{BaseModelId: BaseModelId{Id: "head"}}
+	manager := Employee{BaseModelId: BaseModelId{Id: "manager"}, ManagerID: &head.Id}
+	staff := Employee{BaseModelId: BaseModelId{Id: "staff"}, ManagerID: &manager.Id, Manager: &manager}
+	stranger := Employee{BaseModelId: BaseModelId{Id: "stranger"}}
+
+	withPermissions := func(e Employee, code string, grants ...RolePermission) Employee {
+		e.Role = Role{Code: code, Permissions: grants}
+		return e
+	}
+	staff = withPermissions(staff, "staff", DefaultRolePermissions["staff"]...)
+	manager = withPermissions(manager, "mngr", DefaultRolePermissions["mngr"]...)
+	head = withPermissions(head, "head", RolePermission{Permission: PERM_LEAVE_APPROVE, Scope: SCOPE_DEPARTMENT})
+	hr := withPermissions(Employee{BaseModelId: BaseModelId{Id: "hr"}}, "hr", DefaultRolePermissions["hr"]...)
+
+	cases := []struct {
+		name   string
+		actor  Employee
+		perm   Permission
+		target *Employee
+		can    bool
+	}{
+		{"staff reading their own leaves", staff, PERM_LEAVE_READ, &staff, true},
+		{"staff reading the leaves of another", staff, PERM_LEAVE_READ, &stranger, false},
+		{"staff approving their own leave", staff, PERM_LEAVE_APPROVE, &staff, false},
+		{"a manager approving a direct report", manager, PERM_LEAVE_APPROVE, &staff, true},
+		{"a manager approving a stranger", manager, PERM_LEAVE_APPROVE, &stranger, false},
+		{"a department head approving a report of a report", head, PERM_LEAVE_APPROVE, &staff, true},
+		{"a department head approving a direct report", head, PERM_LEAVE_APPROVE, &manager, true},
+		{"a department head approving a stranger", head, PERM_LEAVE_APPROVE, &stranger, false},
+		{"HR approving anyone", hr, PERM_LEAVE_APPROVE, &stranger, true},
+		{"a manager without any target", manager, PERM_OVERTIME_APPROVE, nil, true},
+		{"staff without any target", staff, PERM_CONFIG_WRITE, nil, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.actor.Can(c.perm, c.target); got != c.can {
+				t.Fatalf("expected %v, got %v", c.can, got)
+			}
+		})
+	}
+}
+
+func TestValidatingRolePermissions(t *testing.T) {
+	role := Role{Name: "Auditor", Code: "auditor", Permissions: []RolePermission{
+		{Permission: PERM_LEAVE_READ, Scope: SCOPE_ALL},
+		{Permission: PERM_LEAVE_READ, Scope: SCOPE_OWN},
+	}}
+	if err := role.Validate(); err == nil {
+		t.Fatalf("expected a permission granted twice to be rejected")
+	}
+
+	if err := (RolePermission{Permission: "leave.delete", Scope: SCOPE_ALL}).Validate(); err == nil {
+		t.Fatalf("expected an unknown permission to be rejected")
+	}
+	if err := (RolePermission{Permission: PERM_LEAVE_READ, Scope: "TEAM"}).Validate(); err == nil {
+		t.Fatalf("expected an unknown scope to be rejected")
+	}
+
+	if !(Role{Code: "hr"}).IsBuiltIn() || (Role{Code: "auditor"}).IsBuiltIn() {
+		t.Fatalf("expected only the seeded roles to be built in")
+	}
+}
+
+func TestRolesThatRequireAManager(t *testing.T) {
+	manager := "7f3c1c52-4d0f-4a4b-9a52-3c1d8f0e2b6a"
+
+	cases := []struct {
+		name    string
+		role    Role
+		manager *string
+		valid   bool
+	}{
+		{"a role that requires a manager with one", Role{Code: "intern", RequiresManager: true}, &manager, true},
+		{"a role that requires a manager without one", Role{Code: "intern", RequiresManager: true}, nil, false},
+		{"a role without a manager", Role{Code: "director"}, nil, true},
+		{"a role without a manager given one", Role{Code: "director"}, &manager, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			employee := Employee{Status: AVAILABLE, ContractType: FULL_TIME, Role: c.role, ManagerID: c.manager}
+			if err := employee.ValidateUpdateWorkInfo(); (err == nil) != c.valid {
+				t.Fatalf("expected valid to be %v, got %v", c.valid, err)
+			}
+		})
+	}
+}
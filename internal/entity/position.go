@@ -6,7 +6,9 @@ type Job struct {
 
 	Name string `gorm:"type:varchar(100)"`
 
-	Employees []Employee
+	Employees      []Employee
+	WorkScheduleID *string `gorm:"type:uuid;default:null"`
+	WorkSchedule   *WorkSchedule
 
 	BaseModelStamps
 	BaseModelSoftDelete
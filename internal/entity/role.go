@@ -1,14 +1,58 @@
 package entity
 
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// ErrRoleExists is returned when saving a role whose name or code is
+// taken by another role.
+var ErrRoleExists = errors.New("a role with the same name or code already exists")
+
 // Example of a role is staff, manager, and HR
 type Role struct {
 	BaseModelId
 
 	Name string `gorm:"uniqueIndex;type:varchar(100)"`
-	Code string `gorm:"type:varchar(50)"`
+	Code string `gorm:"type:varchar(50);uniqueIndex:idx_roles_code,where:deleted_at IS NULL"`
+	// Whether the employees of the role report to a manager, who
+	// approves their requests
+	RequiresManager bool `gorm:"not null;default:false"`
 
-	Employees []Employee
+	Employees   []Employee
+	Permissions []RolePermission
 
 	BaseModelStamps
 	BaseModelSoftDelete
 }
+
+func (v Role) Validate() error {
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.Name, validation.Required, validation.Length(2, 100)),
+		validation.Field(&v.Code,
+			validation.Required.Error("role code is required"),
+			validation.Match(regexp.MustCompile(`^[a-z][a-z_]{1,49}$`)).Error("role code must be lowercase letters or underscores"),
+		),
+		validation.Field(&v.Permissions, validation.By(func(value interface{}) error {
+			grants, _ := value.([]RolePermission)
+			for i, grant := range grants {
+				for _, other := range grants[:i] {
+					if other.Permission == grant.Permission {
+						return fmt.Errorf("%s must be granted only once", grant.Permission)
+					}
+				}
+			}
+			return nil
+		})),
+	)
+}
+
+// IsBuiltIn returns whether the role is one of the seeded roles, which
+// the rest of the system refers to by code.
+func (v Role) IsBuiltIn() bool {
+	_, ok := DefaultRolePermissions[v.Code]
+	return ok
+}
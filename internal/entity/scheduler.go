@@ -0,0 +1,54 @@
+package entity
+
+import "time"
+
+// JobTrigger tells what started a run of a job of the scheduler.
+type JobTrigger string
+
+const (
+	JOB_SCHEDULED JobTrigger = "SCHEDULED"
+	// JOB_MANUAL is a run triggered by HR
+	JOB_MANUAL JobTrigger = "MANUAL"
+)
+
+type JobRunStatus string
+
+const (
+	JOB_RUNNING   JobRunStatus = "RUNNING"
+	JOB_SUCCEEDED JobRunStatus = "SUCCEEDED"
+	JOB_FAILED    JobRunStatus = "FAILED"
+)
+
+// SchedulerJobRun is a run of a job of the scheduler, such as
+// closing the attendances left open, kept so HR can observe what
+// the jobs did.
+type SchedulerJobRun struct {
+	BaseModelId
+
+	JobName string     `gorm:"type:varchar(100);index"`
+	Trigger JobTrigger `gorm:"type:varchar(20)"`
+	// TriggeredByID is nil for a scheduled run
+	TriggeredByID *string `gorm:"type:uuid;default:null"`
+	TriggeredBy   *Employee
+	Status        JobRunStatus `gorm:"type:varchar(20)"`
+	StartedAt     time.Time
+	FinishedAt    *time.Time
+	// Number of records changed by the run
+	Affected int
+	Summary  string `gorm:"type:text"`
+	Error    string `gorm:"type:text"`
+
+	BaseModelStamps
+}
+
+// Finish records the outcome of the run.
+func (v *SchedulerJobRun) Finish(affected int, summary string, err error, at time.Time) {
+	v.FinishedAt = &at
+	v.Affected = affected
+	v.Summary = summary
+	v.Status = JOB_SUCCEEDED
+	if err != nil {
+		v.Status = JOB_FAILED
+		v.Error = err.Error()
+	}
+}
@@ -0,0 +1,39 @@
+package entity
+
+import "time"
+
+// Session is a login of an employee on a device. It lives in Redis
+// until its refresh token expires or it is revoked, and the access
+// tokens issued for it are rejected once it is gone. Only the hash
+// of the current refresh token is kept, which is rotated at each
+// refresh.
+type Session struct {
+	Id               string
+	EmployeeID       string
+	RefreshTokenHash string
+	Device           string
+	IP               string
+	CreatedAt        time.Time
+	LastSeenAt       time.Time
+	ExpiresAt        time.Time
+	// The token version of the employee when the session started
+	TokenVersion int
+}
+
+// LoginChallenge is a login whose password has been verified but which
+// still awaits an OTP from the authenticator app of the employee, or a
+// recovery code. It lives in Redis for a few minutes and allows a few
+// attempts only.
+type LoginChallenge struct {
+	EmployeeID string
+	Device     string
+	IP         string
+	ExpiresAt  time.Time
+}
+
+// LoginFailures are the failed logins of an account or an IP address
+// within the counting window, which restarts at each failure.
+type LoginFailures struct {
+	Count        int64
+	LastFailedAt time.Time
+}
@@ -90,6 +90,85 @@ func (p Point) DistanceTo(v Point) float64 {
 	return R * c // in metres
 }
 
+// Polygon stores a closed area on the Earth as a list of vertices.
+// The last vertex is implicitly connected back to the first one.
+type Polygon []Point
+
+func (p Polygon) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(p)
+}
+
+func (p *Polygon) Scan(value any) error {
+	if value == nil {
+		return nil
+	}
+
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("(*Polygon).Scan: unsupported data type")
+	}
+
+	return json.Unmarshal(b, p)
+}
+
+// Contains checks whether the point v lies inside the polygon
+// using the ray casting algorithm.
+func (p Polygon) Contains(v Point) bool {
+	inside := false
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		if (p[i].Y > v.Y) != (p[j].Y > v.Y) &&
+			v.X < (p[j].X-p[i].X)*(v.Y-p[i].Y)/(p[j].Y-p[i].Y)+p[i].X {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// Calculates in metres the distance from the polygon's boundary
+// to the point v. It returns 0 if v is inside the polygon. Since
+// offices are small, the edges are projected onto a flat plane
+// around v before measuring.
+func (p Polygon) DistanceTo(v Point) float64 {
+	if len(p) == 0 {
+		return math.Inf(1)
+	}
+
+	if p.Contains(v) {
+		return 0
+	}
+
+	R := 6371e3
+	project := func(a Point) (float64, float64) {
+		x := (a.X - v.X) * math.Pi / 180 * math.Cos(v.Y*math.Pi/180) * R
+		y := (a.Y - v.Y) * math.Pi / 180 * R
+		return x, y
+	}
+
+	min := math.Inf(1)
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		ax, ay := project(p[j])
+		bx, by := project(p[i])
+
+		// Projects the origin (which is v) onto the edge ab
+		dx, dy := bx-ax, by-ay
+		t := 0.0
+		if dx != 0 || dy != 0 {
+			t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/(dx*dx+dy*dy)))
+		}
+
+		if d := math.Hypot(ax+t*dx, ay+t*dy); d < min {
+			min = d
+		}
+	}
+
+	return min
+}
+
 type JSONB map[string]interface{}
 
 func (a JSONB) Value() (driver.Value, error) {
@@ -1,10 +1,10 @@
 package vo
 
 type BriefLeaveAndAttendanceAnalytics struct {
-	YearlyCount    int `json:"yearlyCount"`
-	LateClockIns   int `json:"lateClockIns"`
-	EarlyClockOuts int `json:"earlyClockOuts"`
-	UnpaidCount    int `json:"unpaidCount"`
+	YearlyCount    float64 `json:"yearlyCount"`
+	LateClockIns   int     `json:"lateClockIns"`
+	EarlyClockOuts int     `json:"earlyClockOuts"`
+	UnpaidCount    float64 `json:"unpaidCount"`
 }
 
 type HrDashboardAnalytics struct {
@@ -1,7 +1,106 @@
 package vo
 
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"sinarlog.com/internal/entity"
+)
+
 type OpenChatRequest struct {
 	RoomId      string `json:"roomId"`
 	SenderId    string `json:"sender" binding:"required"`
 	RecipientId string `json:"recipient"`
 }
+
+// RoomSummary is a room as listed to one of its participants.
+type RoomSummary struct {
+	Room entity.Room
+	// LastChat is nil if nobody has chatted in the room yet
+	LastChat *entity.Chat
+	// UnreadCount is how many chats of the others the participant has
+	// not read
+	UnreadCount int64
+}
+
+// ChatSignalType is what a chat signal tells. Unlike the chats, the
+// signals are not kept, a listener only receives them while connected.
+type ChatSignalType string
+
+const (
+	// A participant starts or stops typing
+	CHAT_SIGNAL_TYPING ChatSignalType = "typing"
+	// A participant has read up to a chat
+	CHAT_SIGNAL_READ ChatSignalType = "read"
+	// An employee comes online or goes offline, told to every room
+	CHAT_SIGNAL_PRESENCE ChatSignalType = "presence"
+	// A participant no longer takes part in the room
+	CHAT_SIGNAL_REMOVED ChatSignalType = "removed"
+)
+
+type ChatSignal struct {
+	Type       ChatSignalType `json:"type"`
+	RoomId     string         `json:"roomId,omitempty"`
+	EmployeeId string         `json:"employeeId"`
+	ChatId     string         `json:"chatId,omitempty"`
+	Typing     bool           `json:"typing,omitempty"`
+	Online     bool           `json:"online,omitempty"`
+}
+
+const (
+	CHAT_PAGE_DEFAULT_LIMIT = 30
+	CHAT_PAGE_MAX_LIMIT     = 100
+)
+
+// ChatPageQuery pages the chats of a room by their ids, the chats
+// before a chat to scroll back or after one to scroll forward. Without
+// either, the page is the latest chats.
+type ChatPageQuery struct {
+	Before string
+	After  string
+	Limit  int
+}
+
+func (v ChatPageQuery) Validate() error {
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.Before, is.MongoID),
+		validation.Field(&v.After, is.MongoID, validation.When(v.Before != "", validation.Empty.Error("cannot be given along with before"))),
+		validation.Field(&v.Limit, validation.Min(0), validation.Max(CHAT_PAGE_MAX_LIMIT)),
+	)
+}
+
+// PageSize is the limit, or the default one if none is given.
+func (v ChatPageQuery) PageSize() int {
+	if v.Limit == 0 {
+		return CHAT_PAGE_DEFAULT_LIMIT
+	}
+
+	return v.Limit
+}
+
+// ChatSearchQuery searches the words among the chats of a room, or of
+// all the rooms of the employee without one. The results are paged
+// from the latest, before a chat to get the older ones.
+type ChatSearchQuery struct {
+	Query  string
+	RoomId string
+	ChatPageQuery
+}
+
+func (v ChatSearchQuery) Validate() error {
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.Query, validation.Required, validation.Length(2, 100)),
+		validation.Field(&v.RoomId, is.MongoID),
+		validation.Field(&v.After, validation.Empty.Error("search results can only be paged back")),
+		validation.Field(&v.ChatPageQuery),
+	)
+}
+
+// ChatPage is a page of chats, from the oldest except for the search
+// results which are from the latest.
+type ChatPage struct {
+	Chats []entity.Chat
+	// HasOlder is whether there are older chats than the page
+	HasOlder bool
+	// HasNewer is whether there are newer chats than the page
+	HasNewer bool
+}
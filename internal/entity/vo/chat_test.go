@@ -0,0 +1,47 @@
+package vo
+
+import (
+	"testing"
+)
+
+func TestValidatingChatPageQuery(t *testing.T) {
+	chatId := "660a6f80e13823a1b2c3d4e5"
+
+	cases := []struct {
+		name  string
+		query ChatPageQuery
+		valid bool
+		size  int
+	}{
+		{"the latest chats", ChatPageQuery{}, true, CHAT_PAGE_DEFAULT_LIMIT},
+		{"scrolling back", ChatPageQuery{Before: chatId, Limit: 50}, true, 50},
+		{"scrolling forward", ChatPageQuery{After: chatId}, true, CHAT_PAGE_DEFAULT_LIMIT},
+		{"scrolling both ways", ChatPageQuery{Before: chatId, After: chatId}, false, 0},
+		{"a malformed chat", ChatPageQuery{Before: "latest"}, false, 0},
+		{"too many chats", ChatPageQuery{Limit: CHAT_PAGE_MAX_LIMIT + 1}, false, 0},
+		{"a negative limit", ChatPageQuery{Limit: -1}, false, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.query.Validate()
+			if (err == nil) != c.valid {
+				t.Fatalf("expected valid to be %v, got %v", c.valid, err)
+			}
+			if c.valid && c.query.PageSize() != c.size {
+				t.Fatalf("expected a page of %d chats, got %d", c.size, c.query.PageSize())
+			}
+		})
+	}
+}
+
+func TestValidatingChatSearchQuery(t *testing.T) {
+	if err := (ChatSearchQuery{Query: "payroll"}).Validate(); err != nil {
+		t.Fatalf("expected a search of every room to be valid, got %v", err)
+	}
+
+	after := ChatSearchQuery{Query: "payroll", ChatPageQuery: ChatPageQuery{After: "660a6f80e13823a1b2c3d4e5"}}
+	if err := after.Validate(); err == nil {
+		t.Fatalf("expected the search results not to be paged forward")
+	}
+}
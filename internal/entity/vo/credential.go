@@ -13,9 +13,22 @@ type Credential struct {
 	OldPassword          string
 	NewPassword          string
 	ConfirmationPassword string
+	ResetToken           string
 
 	AccessToken  string
 	RefreshToken string
+
+	// A login awaiting its second factor, which is an OTP from the
+	// authenticator app or a recovery code
+	ChallengeToken     string
+	RecoveryCode       string
+	EnrollmentRequired bool
+	// Issued once the authenticator app is enrolled at login
+	RecoveryCodes []string
+
+	// The device and address the credential is used from
+	Device string
+	IP     string
 }
 
 func (v Credential) ValidateAuthentication() error {
@@ -27,3 +40,21 @@ func (v Credential) ValidateAuthentication() error {
 		),
 	)
 }
+
+func (v Credential) ValidatePasswordReset() error {
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.ResetToken, validation.Required.Error("reset token is required")),
+		validation.Field(&v.NewPassword, validation.Required.Error("new password is required")),
+		validation.Field(&v.ConfirmationPassword,
+			validation.Required.Error("confirmation password is required"),
+			validation.In(v.NewPassword).Error("your new password and confirmation password is not the same"),
+		),
+	)
+}
+
+func (v Credential) ValidateLoginChallenge() error {
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.ChallengeToken, validation.Required.Error("challenge token is required")),
+		validation.Field(&v.OTP, validation.When(v.RecoveryCode == "", validation.Required.Error("otp or recovery code is required"))),
+	)
+}
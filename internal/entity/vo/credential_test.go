@@ -0,0 +1,45 @@
+package vo
+
+import "testing"
+
+func TestValidatingPasswordReset(t *testing.T) {
+	cases := []struct {
+		name    string
+		cred    Credential
+		wantErr bool
+	}{
+		{"a matching new password", Credential{ResetToken: "token", NewPassword: "secret", ConfirmationPassword: "secret"}, false},
+		{"without the reset token", Credential{NewPassword: "secret", ConfirmationPassword: "secret"}, true},
+		{"without a new password", Credential{ResetToken: "token"}, true},
+		{"a mismatching confirmation", Credential{ResetToken: "token", NewPassword: "secret", ConfirmationPassword: "secret!"}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.cred.ValidatePasswordReset(); (err != nil) != c.wantErr {
+				t.Fatalf("expected an error %v, got %v", c.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidatingLoginChallenge(t *testing.T) {
+	cases := []struct {
+		name    string
+		cred    Credential
+		wantErr bool
+	}{
+		{"an otp", Credential{ChallengeToken: "token", OTP: "123456"}, false},
+		{"a recovery code", Credential{ChallengeToken: "token", RecoveryCode: "abcde-fghij"}, false},
+		{"without the challenge token", Credential{OTP: "123456"}, true},
+		{"without an otp nor a recovery code", Credential{ChallengeToken: "token"}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.cred.ValidateLoginChallenge(); (err != nil) != c.wantErr {
+				t.Fatalf("expected an error %v, got %v", c.wantErr, err)
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package vo
 
+import "time"
+
 type WhosTakingLeaveList map[string][]WhosTakingLeaveElements
 
 type WhosTakingLeaveElements struct {
@@ -14,3 +16,12 @@ type WhosTakingLeaveElements struct {
 	// Employee's role taking leave that day
 	Role string `json:"role,omitempty"`
 }
+
+// LeaveAccrualReport summarizes a run of the leave accrual.
+type LeaveAccrualReport struct {
+	At time.Time
+	// Number of quotas changed
+	Quotas int
+	// Number of ledger entries recorded
+	Entries int
+}
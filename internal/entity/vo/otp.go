@@ -0,0 +1,19 @@
+package vo
+
+import "time"
+
+// OTPEnrollment is what an authenticator app needs to generate the
+// OTPs of an employee.
+type OTPEnrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+// OTPStatus tells whether an employee generates OTPs with an
+// authenticator app, which then also guards the login.
+type OTPStatus struct {
+	IsEnrolled        bool
+	EnrolledAt        *time.Time
+	IsMandatory       bool
+	RecoveryCodesLeft int64
+}
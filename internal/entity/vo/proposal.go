@@ -24,7 +24,7 @@ func (v UserLeaveDecision) ValidateExcessSumOfDays(report entity.LeaveReport) er
 		sum += v.Count
 	}
 
-	if sum != report.ExcessLeaveDuration {
+	if float64(sum) != report.ExcessLeaveDuration {
 		return fmt.Errorf("the excessed leave durations is not equal to the required excess duration")
 	}
 
@@ -48,6 +48,21 @@ func (v LeaveAction) Validate() error {
 	)
 }
 
+type LeaveCancellationAction struct {
+	Id       string `json:"id,omitempty" binding:"required"`
+	Approved bool   `json:"approved,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+func (v LeaveCancellationAction) Validate() error {
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.Id,
+			validation.Required.Error("id field is required"),
+			is.UUIDv4.Error("id must be a uuid"),
+		),
+	)
+}
+
 type OvertimeSubmissionAction struct {
 	Id       string `json:"id,omitempty" binding:"required"`
 	Approved bool   `json:"approved,omitempty"`
@@ -31,8 +31,14 @@ type LeaveProposalHistoryQuery struct {
 
 type HistoryAttendancesQuery struct {
 	CommonQuery
-	Early  bool
-	Late   bool
-	Closed bool
-	Name   string
+	Early   bool
+	Late    bool
+	Closed  bool
+	Outside bool
+	Name    string
+}
+
+type LeaveQuotaLedgerQuery struct {
+	CommonQuery
+	Type string
 }
@@ -0,0 +1,36 @@
+package vo
+
+import "time"
+
+// RatePolicy allows each client at most Limit requests within the
+// period. Clients are counted separately for each policy. A policy
+// failing closed refuses the requests while the limit cannot be
+// checked, instead of letting them through.
+type RatePolicy struct {
+	Name       string
+	Limit      int
+	Period     time.Duration
+	FailClosed bool
+}
+
+var (
+	// Logging in, requesting a password reset and the like, where
+	// each request is a guess at a secret
+	AUTH_RATE_POLICY = RatePolicy{Name: "auth", Limit: 10, Period: time.Minute, FailClosed: true}
+	// Requesting and submitting the clock-in or clock-out OTP
+	OTP_RATE_POLICY = RatePolicy{Name: "otp", Limit: 5, Period: time.Minute, FailClosed: true}
+	// Any other request changing data
+	WRITE_RATE_POLICY = RatePolicy{Name: "write", Limit: 60, Period: time.Minute}
+	// Any other request only reading data
+	READ_RATE_POLICY = RatePolicy{Name: "read", Limit: 300, Period: time.Minute}
+)
+
+// RateLimit is the outcome of a request of a client against a rate
+// policy.
+type RateLimit struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	ResetAfter time.Duration
+}
@@ -0,0 +1,54 @@
+package vo
+
+import (
+	"time"
+
+	"sinarlog.com/internal/entity"
+	"sinarlog.com/internal/utils"
+)
+
+// JobSchedule returns the next run of a job after its last run.
+type JobSchedule func(last time.Time) time.Time
+
+// DailyAt runs a job every day at the given time.
+func DailyAt(hour, minute int) JobSchedule {
+	return func(last time.Time) time.Time {
+		last = last.In(utils.CURRENT_LOC)
+		next := time.Date(last.Year(), last.Month(), last.Day(), hour, minute, 0, 0, utils.CURRENT_LOC)
+		if !next.After(last) {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		return next
+	}
+}
+
+// HourlyAt runs a job every hour at the given minute.
+func HourlyAt(minute int) JobSchedule {
+	return func(last time.Time) time.Time {
+		last = last.In(utils.CURRENT_LOC)
+		next := time.Date(last.Year(), last.Month(), last.Day(), last.Hour(), minute, 0, 0, utils.CURRENT_LOC)
+		if !next.After(last) {
+			next = next.Add(time.Hour)
+		}
+
+		return next
+	}
+}
+
+// ScheduledJob tells when a job of the scheduler was last run by
+// its schedule and when it runs next.
+type ScheduledJob struct {
+	Name      string
+	LastRunAt *time.Time
+	NextRunAt time.Time
+}
+
+// ScheduledJobStatus is a job of the scheduler with its latest run,
+// which is nil if the job has never been run.
+type ScheduledJobStatus struct {
+	Name        string
+	Description string
+	NextRunAt   time.Time
+	LatestRun   *entity.SchedulerJobRun
+}
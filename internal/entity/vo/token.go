@@ -0,0 +1,13 @@
+package vo
+
+import "time"
+
+// TokenClaims are the claims of an access token. TokenVersion is the
+// token version of the employee when the token was issued, the token
+// is rejected once the employee's version moves on.
+type TokenClaims struct {
+	EmployeeID   string
+	SessionID    string
+	TokenVersion int
+	ExpiresAt    time.Time
+}
@@ -0,0 +1,270 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"sinarlog.com/internal/utils"
+)
+
+// WorkSchedule is a cycle of shifts assignable to an employee or to
+// a job. A weekly schedule has a cycle length of 7 starting on a
+// Monday, while a rotating shift pattern can have any length such
+// as 14 days for a two weeks rotation. A day in the cycle without
+// a shift is a day off.
+type WorkSchedule struct {
+	BaseModelId
+
+	Name        string `gorm:"type:varchar(150)"`
+	CycleStart  time.Time
+	CycleLength int `gorm:"default:7"` // days
+
+	Shifts []Shift
+
+	// Holidays observed by the schedule keyed by their date
+	holidays map[string]Holiday
+
+	BaseModelStamps
+	BaseModelSoftDelete
+}
+
+// Shift is the working hours of a day in a work schedule's cycle.
+// A shift whose end time is not after its start time crosses
+// midnight and ends on the next day. A shift ends at the time it
+// starts only if it is a full-day shift lasting 24 hours.
+type Shift struct {
+	BaseModelId
+
+	WorkScheduleID  string `gorm:"type:uuid"`
+	Day             int    // index of the day in the cycle, starts from 0
+	StartTime       time.Time
+	EndTime         time.Time
+	FullDay         bool `gorm:"default:false"`
+	StartTimeHour   int  `gorm:"-:all"`
+	StartTimeMinute int  `gorm:"-:all"`
+	EndTimeHour     int  `gorm:"-:all"`
+	EndTimeMinute   int  `gorm:"-:all"`
+
+	BaseModelStamps
+	BaseModelSoftDelete
+}
+
+// ShiftWindow is a shift placed on a specific date.
+type ShiftWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (w ShiftWindow) Duration() time.Duration {
+	return w.End.Sub(w.Start)
+}
+
+func (v WorkSchedule) Validate() error {
+	if err := validation.ValidateStruct(&v,
+		validation.Field(&v.Name, validation.Required, validation.Length(3, 150)),
+		validation.Field(&v.CycleStart, validation.Required),
+		validation.Field(&v.CycleLength, validation.Required, validation.Min(1), validation.Max(56)),
+		validation.Field(&v.Shifts, validation.Required.Error("a work schedule must have at least one shift")),
+	); err != nil {
+		return err
+	}
+
+	days := make(map[int]bool)
+	for _, shift := range v.Shifts {
+		if shift.Day < 0 || shift.Day >= v.CycleLength {
+			return fmt.Errorf("shift day must be between 0 and %d", v.CycleLength-1)
+		}
+		if days[shift.Day] {
+			return fmt.Errorf("there can only be one shift on the day %d of the cycle", shift.Day)
+		}
+		days[shift.Day] = true
+	}
+
+	return nil
+}
+
+func (v Shift) Validate() error {
+	if err := validation.ValidateStruct(&v,
+		validation.Field(&v.StartTimeHour, validation.Min(0), validation.Max(23)),
+		validation.Field(&v.StartTimeMinute, validation.Min(0), validation.Max(59)),
+		validation.Field(&v.EndTimeHour, validation.Min(0), validation.Max(23)),
+		validation.Field(&v.EndTimeMinute, validation.Min(0), validation.Max(59)),
+	); err != nil {
+		return err
+	}
+
+	// A shift ending at the time it starts would otherwise be taken
+	// as a 24 hours shift by mistake
+	sameTime := v.StartTimeHour == v.EndTimeHour && v.StartTimeMinute == v.EndTimeMinute
+	if sameTime && !v.FullDay {
+		return fmt.Errorf("the shift on the day %d must not end at the time it starts unless it is a full-day shift", v.Day)
+	}
+	if !sameTime && v.FullDay {
+		return fmt.Errorf("the full-day shift on the day %d must end at the time it starts", v.Day)
+	}
+
+	return nil
+}
+
+// IsOvernight returns whether the shift crosses midnight.
+func (v Shift) IsOvernight() bool {
+	startTime, endTime := v.StartTime.In(utils.CURRENT_LOC), v.EndTime.In(utils.CURRENT_LOC)
+	start := startTime.Hour()*60 + startTime.Minute()
+	end := endTime.Hour()*60 + endTime.Minute()
+
+	return end <= start
+}
+
+// dayOfCycle returns the index of the given date in the cycle.
+func (v WorkSchedule) dayOfCycle(date time.Time) int {
+	start := time.Date(v.CycleStart.Year(), v.CycleStart.Month(), v.CycleStart.Day(), 0, 0, 0, 0, utils.CURRENT_LOC)
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, utils.CURRENT_LOC)
+	diff := int(day.Sub(start).Round(24*time.Hour).Hours() / 24)
+
+	length := v.CycleLength
+	if length <= 0 {
+		length = 7
+	}
+
+	return ((diff % length) + length) % length
+}
+
+// WithHolidays returns a copy of the schedule observing the given
+// holidays. There is no shift on a holiday.
+func (v WorkSchedule) WithHolidays(holidays []Holiday) WorkSchedule {
+	v.holidays = make(map[string]Holiday, len(holidays))
+	for _, holiday := range holidays {
+		v.holidays[holiday.DateKey()] = holiday
+	}
+
+	return v
+}
+
+// HolidayOn returns the holiday observed on the given date. The
+// bool is false when the date is not a holiday.
+func (v WorkSchedule) HolidayOn(date time.Time) (Holiday, bool) {
+	holiday, ok := v.holidays[date.In(utils.CURRENT_LOC).Format(time.DateOnly)]
+	return holiday, ok
+}
+
+// Holidays returns the dates of the holidays observed by the
+// schedule.
+func (v WorkSchedule) Holidays() []time.Time {
+	dates := make([]time.Time, 0, len(v.holidays))
+	for _, holiday := range v.holidays {
+		dates = append(dates, holiday.Date)
+	}
+
+	return dates
+}
+
+// ShiftOn returns the shift that starts on the given date. The bool
+// is false when the date is a day off or a holiday.
+func (v WorkSchedule) ShiftOn(date time.Time) (ShiftWindow, bool) {
+	date = date.In(utils.CURRENT_LOC)
+	if _, ok := v.HolidayOn(date); ok {
+		return ShiftWindow{}, false
+	}
+
+	idx := v.dayOfCycle(date)
+
+	for _, shift := range v.Shifts {
+		if shift.Day != idx {
+			continue
+		}
+
+		startTime, endTime := shift.StartTime.In(utils.CURRENT_LOC), shift.EndTime.In(utils.CURRENT_LOC)
+		start := time.Date(date.Year(), date.Month(), date.Day(), startTime.Hour(), startTime.Minute(), 0, 0, utils.CURRENT_LOC)
+		end := time.Date(date.Year(), date.Month(), date.Day(), endTime.Hour(), endTime.Minute(), 0, 0, utils.CURRENT_LOC)
+		if shift.IsOvernight() {
+			end = end.AddDate(0, 0, 1)
+		}
+
+		return ShiftWindow{Start: start, End: end}, true
+	}
+
+	return ShiftWindow{}, false
+}
+
+// ShiftAt returns the shift the time t belongs to. An overnight shift
+// from the previous day is returned if it has not ended at t.
+// Otherwise, the shift that starts on the date of t is returned.
+func (v WorkSchedule) ShiftAt(t time.Time) (ShiftWindow, bool) {
+	t = t.In(utils.CURRENT_LOC)
+
+	if prev, ok := v.ShiftOn(t.AddDate(0, 0, -1)); ok && t.Before(prev.End) {
+		return prev, true
+	}
+
+	return v.ShiftOn(t)
+}
+
+// IsWorkingDay returns whether there is a shift starting on the date.
+func (v WorkSchedule) IsWorkingDay(date time.Time) bool {
+	_, ok := v.ShiftOn(date)
+	return ok
+}
+
+// CountWorkingDays counts the number of working days from
+// `from` to `to` according to the schedule.
+func (v WorkSchedule) CountWorkingDays(from, to time.Time) int {
+	days := utils.CountNumberOfDays(from, to)
+
+	var res int
+	for i := 0; i < days; i++ {
+		day := time.Date(from.Year(), from.Month(), from.Day()+i, 0, 0, 0, 0, utils.CURRENT_LOC)
+		if v.IsWorkingDay(day) {
+			res += 1
+		}
+	}
+
+	return res
+}
+
+// NextWorkingDay returns t if it is a working day. Otherwise, it
+// returns the closest working day after t.
+func (v WorkSchedule) NextWorkingDay(t time.Time) time.Time {
+	for i := 0; i < 366; i++ {
+		if v.IsWorkingDay(t) {
+			return t
+		}
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return t
+}
+
+// AddWorkingDays adds a number of working days to t.
+func (v WorkSchedule) AddWorkingDays(t time.Time, days int) time.Time {
+	for i := 0; days > 0 && i < 366*5; i++ {
+		t = t.AddDate(0, 0, 1)
+		if v.IsWorkingDay(t) {
+			days--
+		}
+	}
+
+	return t
+}
+
+// DefaultWorkSchedule returns the company wide Monday to Friday
+// schedule using the office start and end time. It is the schedule
+// of employees and jobs that have no work schedule assigned.
+func (c Configuration) DefaultWorkSchedule() WorkSchedule {
+	schedule := WorkSchedule{
+		Name: "Default",
+		// 3rd January 2000 is a Monday
+		CycleStart:  time.Date(2000, time.January, 3, 0, 0, 0, 0, utils.CURRENT_LOC),
+		CycleLength: 7,
+	}
+
+	for i := 0; i < 5; i++ {
+		schedule.Shifts = append(schedule.Shifts, Shift{
+			Day:       i,
+			StartTime: c.OfficeStartTime.In(utils.CURRENT_LOC),
+			EndTime:   c.OfficeEndTime.In(utils.CURRENT_LOC),
+		})
+	}
+
+	return schedule
+}
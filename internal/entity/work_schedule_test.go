@@ -0,0 +1,122 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"sinarlog.com/internal/utils"
+)
+
+func TestOvernightShiftAt(t *testing.T) {
+	clock := func(day, hour int) time.Time {
+		return time.Date(2024, time.April, day, hour, 0, 0, 0, utils.CURRENT_LOC)
+	}
+
+	// A night shift from 22:00 to 06:00 every other day starting on
+	// Monday, 1st April 2024
+	nights := WorkSchedule{
+		CycleStart:  clock(1, 0),
+		CycleLength: 2,
+		Shifts: []Shift{{
+			Day:       0,
+			StartTime: clock(1, 22),
+			EndTime:   clock(1, 6),
+		}},
+	}
+	if !nights.Shifts[0].IsOvernight() {
+		t.Fatalf("expected the night shift to be overnight")
+	}
+
+	cases := []struct {
+		name     string
+		schedule WorkSchedule
+		at       time.Time
+		shift    ShiftWindow
+		ok       bool
+	}{
+		{"before midnight", nights, clock(1, 23), ShiftWindow{clock(1, 22), clock(2, 6)}, true},
+		{"after midnight", nights, clock(2, 3), ShiftWindow{clock(1, 22), clock(2, 6)}, true},
+		{"once ended on a day off", nights, clock(2, 7), ShiftWindow{}, false},
+		{"before the shift of the day", nights, clock(3, 21), ShiftWindow{clock(3, 22), clock(4, 6)}, true},
+		{
+			name:     "after midnight of a holiday",
+			schedule: nights.WithHolidays([]Holiday{{Date: clock(1, 0)}}),
+			at:       clock(2, 3),
+			ok:       false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			shift, ok := c.schedule.ShiftAt(c.at)
+			if ok != c.ok || !shift.Start.Equal(c.shift.Start) || !shift.End.Equal(c.shift.End) {
+				t.Fatalf("expected %v from %s to %s, got %v from %s to %s", c.ok, c.shift.Start, c.shift.End, ok, shift.Start, shift.End)
+			}
+			if ok && shift.Duration() != 8*time.Hour {
+				t.Fatalf("expected an 8 hours shift, got %s", shift.Duration())
+			}
+		})
+	}
+}
+
+func TestCountingScheduleWorkingDays(t *testing.T) {
+	config := Configuration{
+		OfficeStartTime: time.Date(2024, time.January, 1, 9, 0, 0, 0, utils.CURRENT_LOC),
+		OfficeEndTime:   time.Date(2024, time.January, 1, 17, 0, 0, 0, utils.CURRENT_LOC),
+	}
+	// Wednesday, 10th April 2024
+	lebaran := time.Date(2024, time.April, 10, 0, 0, 0, 0, utils.CURRENT_LOC)
+	schedule := config.DefaultWorkSchedule().WithHolidays([]Holiday{{Date: lebaran}})
+
+	// Monday, 8th April 2024 to Sunday, 14th April 2024
+	from := time.Date(2024, time.April, 8, 0, 0, 0, 0, utils.CURRENT_LOC)
+	to := time.Date(2024, time.April, 14, 23, 59, 59, 0, utils.CURRENT_LOC)
+	if n := schedule.CountWorkingDays(from, to); n != 4 {
+		t.Fatalf("expected 4 working days, got %d", n)
+	}
+
+	if d := schedule.NextWorkingDay(lebaran); d.Day() != 11 {
+		t.Fatalf("expected the 11th as the next working day, got the %dth", d.Day())
+	}
+
+	if d := schedule.AddWorkingDays(from, 3); d.Day() != 12 {
+		t.Fatalf("expected to land on the 12th, got the %dth", d.Day())
+	}
+}
+
+func TestValidatingShifts(t *testing.T) {
+	cases := []struct {
+		name  string
+		shift Shift
+		valid bool
+	}{
+		{"a day shift", Shift{StartTimeHour: 9, EndTimeHour: 17}, true},
+		{"a night shift", Shift{StartTimeHour: 22, EndTimeHour: 6}, true},
+		{"ending at the time it starts", Shift{StartTimeHour: 8, StartTimeMinute: 30, EndTimeHour: 8, EndTimeMinute: 30}, false},
+		{"a full-day shift", Shift{StartTimeHour: 8, StartTimeMinute: 30, EndTimeHour: 8, EndTimeMinute: 30, FullDay: true}, true},
+		{"a full-day shift ending at another time", Shift{StartTimeHour: 8, EndTimeHour: 20, FullDay: true}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.shift.Validate(); (err == nil) != c.valid {
+				t.Fatalf("expected valid to be %v, got %v", c.valid, err)
+			}
+		})
+	}
+
+	// A full-day shift lasts until the same time on the next day
+	schedule := WorkSchedule{
+		CycleStart:  time.Date(2024, time.April, 1, 0, 0, 0, 0, utils.CURRENT_LOC),
+		CycleLength: 1,
+		Shifts: []Shift{{
+			StartTime: time.Date(2000, time.January, 1, 8, 0, 0, 0, utils.CURRENT_LOC),
+			EndTime:   time.Date(2000, time.January, 1, 8, 0, 0, 0, utils.CURRENT_LOC),
+			FullDay:   true,
+		}},
+	}
+	shift, ok := schedule.ShiftOn(time.Date(2024, time.April, 2, 0, 0, 0, 0, utils.CURRENT_LOC))
+	if !ok || shift.Duration() != 24*time.Hour {
+		t.Fatalf("expected a 24 hours shift, got %v", shift)
+	}
+}
@@ -67,15 +67,16 @@ func CountNumberOfDays(from, to time.Time) int {
 
 // CountNumberOfWorkingDays calls CountNumberOfDays
 // and then only count the number of days where it
-// is not a weekend. For example given Friday to Monday
-// it will return 2 since Saturday and Sunday is weekend.
-func CountNumberOfWorkingDays(from, to time.Time) int {
+// is not a weekend nor one of the given holidays. For
+// example given Friday to Monday it will return 2 since
+// Saturday and Sunday is weekend.
+func CountNumberOfWorkingDays(from, to time.Time, holidays ...time.Time) int {
 	days := CountNumberOfDays(from, to)
 
 	var res int
 	for i := 0; i < days; i++ {
 		day := time.Date(from.Year(), from.Month(), from.Day()+i, 0, 0, 0, 0, CURRENT_LOC)
-		if day.Weekday() != time.Sunday && day.Weekday() != time.Saturday {
+		if isWorkingDay(day, holidays) {
 			res += 1
 		}
 	}
@@ -90,10 +91,11 @@ func CountNumberOfDaysFromDuration(dur time.Duration) int {
 }
 
 // GetWorkingDay return a time of a day where it is usually
-// a working day such as Monday, Tuesday, to Friday. Hence,
-// given Saturday, it will return Monday the next week.
-func GetWorkingDay(t time.Time) time.Time {
-	if t.Weekday() != time.Sunday && t.Weekday() != time.Saturday {
+// a working day such as Monday, Tuesday, to Friday and is
+// not one of the given holidays. Hence, given Saturday, it
+// will return Monday the next week.
+func GetWorkingDay(t time.Time, holidays ...time.Time) time.Time {
+	if isWorkingDay(t, holidays) {
 		return t
 	}
 
@@ -101,7 +103,7 @@ func GetWorkingDay(t time.Time) time.Time {
 	for {
 		t := t.Add(time.Duration(i) * 24 * time.Hour)
 
-		if t.Weekday() != time.Sunday && t.Weekday() != time.Saturday {
+		if isWorkingDay(t, holidays) {
 			return t
 		}
 
@@ -109,10 +111,12 @@ func GetWorkingDay(t time.Time) time.Time {
 	}
 }
 
-func AddNumOfWorkingDays(t time.Time, days int) time.Time {
+// AddNumOfWorkingDays adds a number of working days to t,
+// skipping weekends and the given holidays.
+func AddNumOfWorkingDays(t time.Time, days int, holidays ...time.Time) time.Time {
 	for days != 0 {
 		t = t.AddDate(0, 0, 1)
-		if t.Weekday() != time.Sunday && t.Weekday() != time.Saturday {
+		if isWorkingDay(t, holidays) {
 			days--
 		}
 	}
@@ -120,6 +124,25 @@ func AddNumOfWorkingDays(t time.Time, days int) time.Time {
 	return t
 }
 
+// isWorkingDay returns whether t is neither a weekend
+// nor on the same date as one of the holidays.
+func isWorkingDay(t time.Time, holidays []time.Time) bool {
+	t = t.In(CURRENT_LOC)
+	if t.Weekday() == time.Sunday || t.Weekday() == time.Saturday {
+		return false
+	}
+
+	for _, holiday := range holidays {
+		y1, m1, d1 := t.Date()
+		y2, m2, d2 := holiday.In(CURRENT_LOC).Date()
+		if y1 == y2 && m1 == m2 && d1 == d2 {
+			return false
+		}
+	}
+
+	return true
+}
+
 // GetStartOfTheWeekFromDate return the start of the wwekday
 // from the given date. For example, if the given date is
 // Tuesday, June 20th 2023, then it will return Monday,
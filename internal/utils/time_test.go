@@ -29,3 +29,29 @@ func TestSanitizingTimeFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestCountingWorkingDaysWithHolidays(t *testing.T) {
+	// Monday, 8th April 2024 to Friday, 12th April 2024
+	from := time.Date(2024, time.April, 8, 0, 0, 0, 0, CURRENT_LOC)
+	to := time.Date(2024, time.April, 12, 23, 59, 59, 0, CURRENT_LOC)
+	lebaran := []time.Time{
+		time.Date(2024, time.April, 10, 0, 0, 0, 0, CURRENT_LOC),
+		time.Date(2024, time.April, 11, 0, 0, 0, 0, CURRENT_LOC),
+	}
+
+	if n := CountNumberOfWorkingDays(from, to); n != 5 {
+		t.Fatalf("expected 5 working days, got %d", n)
+	}
+
+	if n := CountNumberOfWorkingDays(from, to, lebaran...); n != 3 {
+		t.Fatalf("expected 3 working days, got %d", n)
+	}
+
+	if d := AddNumOfWorkingDays(from, 2, lebaran...); d.Day() != 12 {
+		t.Fatalf("expected to land on the 12th, got the %dth", d.Day())
+	}
+
+	if d := GetWorkingDay(lebaran[0], lebaran...); d.Day() != 12 {
+		t.Fatalf("expected the 12th as the next working day, got the %dth", d.Day())
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"time"
 
 	"sinarlog.com/cmd/app"
 	"sinarlog.com/config"
+	"sinarlog.com/internal/utils"
 )
 
 func main() {
@@ -28,5 +30,19 @@ func main() {
 
 	cfg := config.GetConfig()
 
+	// `go run main.go accrue-leaves [YYYY-MM-DD]` accrues the leave
+	// quotas at the given date, or now, instead of serving the app.
+	if len(os.Args) > 1 && os.Args[1] == "accrue-leaves" {
+		at := time.Now()
+		if len(os.Args) > 2 {
+			if at, err = time.ParseInLocation(time.DateOnly, os.Args[2], utils.CURRENT_LOC); err != nil {
+				log.Fatalf("invalid accrual date %s: %s\n", os.Args[2], err)
+			}
+		}
+
+		app.AccrueLeaves(cfg, at)
+		return
+	}
+
 	app.Run(cfg)
 }
@@ -21,7 +21,6 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/xlzd/gotp"
 )
 
 type Doorkeeper struct {
@@ -41,9 +40,14 @@ type Doorkeeper struct {
 	hashIter   int              // Special case for PBKD2F iterator
 
 	// --- OTP ---
-	totp            *gotp.TOTP
-	otpExpDuration  time.Duration
-	otpSecretLength int
+	otpExpDuration  time.Duration // Duration of an OTP sent by email
+	otpSecretLength int           // Length of the TOTP secret of each employee
+
+	// --- Encryption ---
+	encryptionKey []byte // AES-256 key encrypting the secrets at rest
+
+	// --- Password Reset ---
+	resetExpDuration time.Duration // Duration of a password reset token
 }
 
 var (
@@ -55,14 +59,15 @@ var (
 )
 
 var (
-	_defaultHasherFunc      = sha512.New384
-	_defaultHashKeyLen      = 64
-	_defaultHashIter        = 4096
-	_defaultSigningMethod   = jwt.SigningMethodHS384
-	_defaultAccessDuration  = 15 * time.Minute
-	_defaultRefreshDuration = 1 * time.Hour
-	_defaultOTPExpDuration  = 15 * time.Minute
-	_defaultOTPSecretLength = 16
+	_defaultHasherFunc       = sha512.New384
+	_defaultHashKeyLen       = 64
+	_defaultHashIter         = 4096
+	_defaultSigningMethod    = jwt.SigningMethodHS384
+	_defaultAccessDuration   = 15 * time.Minute
+	_defaultRefreshDuration  = 1 * time.Hour
+	_defaultOTPExpDuration   = 15 * time.Minute
+	_defaultOTPSecretLength  = 16
+	_defaultResetExpDuration = 30 * time.Minute
 )
 
 var (
@@ -82,6 +87,8 @@ func GetDoorkeeper(opts ...Option) *Doorkeeper {
 				signMethod:      _defaultSigningMethod,
 				otpExpDuration:  _defaultOTPExpDuration,
 				otpSecretLength: _defaultOTPSecretLength,
+
+				resetExpDuration: _defaultResetExpDuration,
 			}
 
 			for _, opt := range opts {
@@ -90,8 +97,9 @@ func GetDoorkeeper(opts ...Option) *Doorkeeper {
 
 			doorkeeperSingleInstance.loadSecretKeys()
 
-			secret := gotp.RandomSecret(doorkeeperSingleInstance.otpSecretLength)
-			doorkeeperSingleInstance.totp = gotp.NewDefaultTOTP(secret)
+			if doorkeeperSingleInstance.encryptionKey == nil {
+				log.Fatalln("doorkeeper requires an encryption key")
+			}
 		})
 	}
 
@@ -130,14 +138,22 @@ func (d *Doorkeeper) GetConcreteSignMethod() reflect.Type {
 	return reflect.TypeOf(d.signMethod)
 }
 
-func (d *Doorkeeper) GetTOTP() *gotp.TOTP {
-	return d.totp
+func (d *Doorkeeper) GetOTPSecretLength() int {
+	return d.otpSecretLength
+}
+
+func (d *Doorkeeper) GetEncryptionKey() []byte {
+	return d.encryptionKey
 }
 
 func (d *Doorkeeper) GetOTPExpDuration() time.Duration {
 	return d.otpExpDuration
 }
 
+func (d *Doorkeeper) GetResetExpDuration() time.Duration {
+	return d.resetExpDuration
+}
+
 func (d *Doorkeeper) loadSecretKeys() {
 	switch d.GetConcreteSignMethod() {
 	case HMAC_SIGN_METHOD_TYPE:
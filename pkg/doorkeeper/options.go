@@ -63,6 +63,25 @@ func RegisterOTPExpDuration(t time.Duration) Option {
 	}
 }
 
+// RegisterEncryptionKey derives the AES-256 key encrypting the secrets
+// at rest from the given secret.
+func RegisterEncryptionKey(secret string) Option {
+	return func(d *Doorkeeper) {
+		if secret != "" {
+			key := sha256.Sum256([]byte(secret))
+			d.encryptionKey = key[:]
+		}
+	}
+}
+
+func RegisterResetExpDuration(t time.Duration) Option {
+	return func(d *Doorkeeper) {
+		if t > 0 {
+			d.resetExpDuration = t
+		}
+	}
+}
+
 func RegisterHasherFunc(alg string) Option {
 	return func(d *Doorkeeper) {
 		switch alg {
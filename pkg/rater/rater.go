@@ -5,16 +5,11 @@ import (
 	"sync"
 	"time"
 
-	"golang.org/x/time/rate"
-	"sinarlog.com/internal/utils"
+	"github.com/redis/go-redis/v9"
 )
 
 var (
-	_defaultRateForEachClient rate.Limit = 2
-	_defaultBurstByEachClient int        = 2
-
-	_defaultIntervalEvaluation     = time.Minute
-	_defaultClientDeletionInterval = 3 * time.Minute
+	_defaultKeyPrefix = "rater"
 )
 
 var (
@@ -22,88 +17,92 @@ var (
 	once                sync.Once
 )
 
-type Rater struct {
-	clients map[string]*client
-	mu      sync.Mutex
-
-	rateForEachClient  rate.Limit
-	burstForEachClient int
+var (
+	// allowScript is the generic cell rate algorithm. The key holds
+	// the theoretical arrival time, when the allowance of the client
+	// is full again, in milliseconds. Each request moves it by the
+	// emission interval, and a request is allowed as long as it does
+	// not move it further than the period from now. It returns
+	// whether the request is allowed, the remaining requests, and
+	// the milliseconds until the next request is allowed and until
+	// the allowance is full again.
+	allowScript = redis.NewScript(`
+	redis.replicate_commands()
+
+	local limit = tonumber(ARGV[1])
+	local period = tonumber(ARGV[2])
+	local emission = period / limit
+
+	local time = redis.call("TIME")
+	local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+	local tat = tonumber(redis.call("GET", KEYS[1]))
+	if not tat or tat < now then
+		tat = now
+	end
+
+	local new_tat = tat + emission
+	local diff = now - (new_tat - period)
+	if diff < 0 then
+		return {0, 0, math.ceil(-diff), math.ceil(tat - now)}
+	end
+
+	redis.call("SET", KEYS[1], tostring(new_tat), "PX", math.ceil(new_tat - now))
+	return {1, math.floor(diff / emission), 0, math.ceil(new_tat - now)}`)
+)
 
-	intervalForEvaluation  time.Duration
-	clientDeletionInterval time.Duration
+// Result is the outcome of a request against a limit.
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until the next request is allowed, zero
+	// if the request is allowed
+	RetryAfter time.Duration
+	// ResetAfter is how long until the whole limit is available again
+	ResetAfter time.Duration
 }
 
-type client struct {
-	limiter  *rate.Limiter
-	lastSeen time.Time
+// Rater limits the requests of each client within a period. The
+// allowance of every client is kept in Redis, hence it is shared by
+// all the replicas and survives restarts. The allowance is refilled
+// evenly over the period rather than all at once at its end.
+type Rater struct {
+	client *redis.Client
+	prefix string
 }
 
-func GetRater(ctx context.Context, opts ...Option) *Rater {
+func GetRater(client *redis.Client, opts ...Option) *Rater {
 	if raterSingleInstance == nil {
 		once.Do(func() {
 			raterSingleInstance = &Rater{
-				clients: make(map[string]*client),
-
-				rateForEachClient:  _defaultRateForEachClient,
-				burstForEachClient: _defaultBurstByEachClient,
-
-				intervalForEvaluation:  _defaultIntervalEvaluation,
-				clientDeletionInterval: _defaultClientDeletionInterval,
+				client: client,
+				prefix: _defaultKeyPrefix,
 			}
 
 			for _, opt := range opts {
 				opt(raterSingleInstance)
 			}
-
-			raterSingleInstance.evaluateInBackground(ctx)
 		})
 	}
 
 	return raterSingleInstance
 }
 
-func (rater *Rater) evaluateInBackground(ctx context.Context) {
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				time.Sleep(rater.intervalForEvaluation)
-
-				rater.mu.Lock()
-				for ip, client := range rater.clients {
-					if time.Since(client.lastSeen) > rater.clientDeletionInterval {
-						delete(rater.clients, ip)
-					}
-				}
-				rater.mu.Unlock()
-			}
-		}
-	}()
-}
-
-func (rater *Rater) GetClient(ip string) (*client, bool) {
-	rater.mu.Lock()
-	defer rater.mu.Unlock()
-
-	client, found := rater.clients[ip]
-	return client, found
-}
-
-func (rater *Rater) AddNewClient(ip string) *client {
-	rater.mu.Lock()
-	defer rater.mu.Unlock()
-
-	client := &client{limiter: rate.NewLimiter(rater.rateForEachClient, rater.burstForEachClient), lastSeen: time.Now().In(utils.CURRENT_LOC)}
-	rater.clients[ip] = client
-	return client
-}
-
-func (rater *Rater) IsClientAllowed(client *client) bool {
-	rater.mu.Lock()
-	defer rater.mu.Unlock()
+// Allow takes a request of the client, identified by the key, out of
+// the limit of requests within the period.
+func (rater *Rater) Allow(ctx context.Context, key string, limit int, period time.Duration) (Result, error) {
+	res, err := allowScript.Run(ctx, rater.client,
+		[]string{rater.prefix + ":" + key},
+		limit, period.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
 
-	client.lastSeen = time.Now().In(utils.CURRENT_LOC)
-	return client.limiter.Allow()
+	return Result{
+		Allowed:    res[0] == 1,
+		Remaining:  int(res[1]),
+		RetryAfter: time.Duration(res[2]) * time.Millisecond,
+		ResetAfter: time.Duration(res[3]) * time.Millisecond,
+	}, nil
 }
@@ -0,0 +1,62 @@
+package rater
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// The rater runs against TEST_REDIS_ADDRESS, e.g. a local instance at
+// localhost:6379, since the algorithm is a script run by Redis.
+func testRater(t *testing.T) *Rater {
+	addr := os.Getenv("TEST_REDIS_ADDRESS")
+	if addr == "" {
+		t.Skip("no redis, set TEST_REDIS_ADDRESS")
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("unable to reach redis at %s: %s", addr, err)
+	}
+
+	return GetRater(client, RegisterKeyPrefix("rater-test"))
+}
+
+func TestAllowingWithinTheLimit(t *testing.T) {
+	rt := testRater(t)
+	ctx := context.Background()
+	key := fmt.Sprintf("client-%d", time.Now().UnixNano())
+
+	// A request is allowed every 100ms, with a burst of 5
+	limit, period := 5, 500*time.Millisecond
+	emission := period / time.Duration(limit)
+
+	for i := 0; i < limit; i++ {
+		res, err := rt.Allow(ctx, key, limit, period)
+		if err != nil {
+			t.Fatalf("unable to take a request: %s", err)
+		}
+		if !res.Allowed || res.Remaining != limit-i-1 || res.RetryAfter != 0 {
+			t.Fatalf("expected request %d to be allowed with %d remaining, got %+v", i+1, limit-i-1, res)
+		}
+	}
+
+	res, err := rt.Allow(ctx, key, limit, period)
+	if err != nil {
+		t.Fatalf("unable to take a request: %s", err)
+	}
+	if res.Allowed || res.RetryAfter <= 0 || res.RetryAfter > emission || res.ResetAfter > period {
+		t.Fatalf("expected the request over the limit to wait at most %s, got %+v", emission, res)
+	}
+
+	// The allowance is refilled evenly rather than at the end of the
+	// period
+	time.Sleep(res.RetryAfter)
+	if res, err := rt.Allow(ctx, key, limit, period); err != nil || !res.Allowed {
+		t.Fatalf("expected a request to be allowed once waited, got %+v, %v", res, err)
+	}
+}
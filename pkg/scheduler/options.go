@@ -0,0 +1,37 @@
+package scheduler
+
+import "time"
+
+// Option -.
+type Option func(*Scheduler)
+
+// RegisterKeyPrefix sets the prefix of the Redis keys of the
+// scheduler, which must be shared by all the replicas.
+func RegisterKeyPrefix(prefix string) Option {
+	return func(s *Scheduler) {
+		if prefix != "" {
+			s.prefix = prefix
+		}
+	}
+}
+
+// RegisterLeaseDuration sets how long a leader stays the leader
+// without renewing its lease. Another replica takes over at most
+// this long after the leader is gone.
+func RegisterLeaseDuration(t time.Duration) Option {
+	return func(s *Scheduler) {
+		if t > time.Duration(0) {
+			s.leaseDuration = t
+		}
+	}
+}
+
+// RegisterTickInterval sets how often the due jobs are checked and
+// the lease is renewed. It must be shorter than the lease duration.
+func RegisterTickInterval(t time.Duration) Option {
+	return func(s *Scheduler) {
+		if t > time.Duration(0) {
+			s.tickInterval = t
+		}
+	}
+}
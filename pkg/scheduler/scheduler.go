@@ -0,0 +1,309 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	_defaultKeyPrefix     = "scheduler"
+	_defaultLeaseDuration = 30 * time.Second
+	_defaultTickInterval  = 10 * time.Second
+)
+
+var (
+	schedulerSingleInstance *Scheduler
+	once                    sync.Once
+)
+
+var (
+	// renewScript extends the lease of a key only if it is still
+	// held by the given holder
+	renewScript = redis.NewScript(`
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	end
+	return 0`)
+	// releaseScript deletes a key only if it is still held by the
+	// given holder
+	releaseScript = redis.NewScript(`
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("DEL", KEYS[1])
+	end
+	return 0`)
+)
+
+// Schedule returns the next run of a job after its last run.
+type Schedule func(last time.Time) time.Time
+
+// Task is the work of a job.
+type Task func(ctx context.Context) error
+
+// JobInfo tells when a job was last run by its schedule and when
+// it runs next. LastRunAt is zero if the job has never been run.
+type JobInfo struct {
+	Name      string
+	LastRunAt time.Time
+	NextRunAt time.Time
+}
+
+type job struct {
+	name     string
+	schedule Schedule
+	task     Task
+}
+
+// Scheduler runs the registered jobs on their schedules. Every
+// replica of the app has a scheduler, however only the leader runs
+// the jobs. The leader holds a lease in Redis and renews it at each
+// tick, hence another replica takes over once the leader is gone.
+// The last run of each job is kept in Redis so a new leader does
+// not run a job again nor miss it.
+type Scheduler struct {
+	client *redis.Client
+	id     string
+
+	prefix        string
+	leaseDuration time.Duration
+	tickInterval  time.Duration
+
+	mu       sync.Mutex
+	jobs     map[string]*job
+	isLeader bool
+}
+
+// GetScheduler returns the scheduler and starts it in the background
+// until the context is done.
+func GetScheduler(ctx context.Context, client *redis.Client, opts ...Option) *Scheduler {
+	if schedulerSingleInstance == nil {
+		once.Do(func() {
+			hostname, _ := os.Hostname()
+
+			schedulerSingleInstance = &Scheduler{
+				client: client,
+				id:     hostname + "-" + uuid.NewString(),
+
+				prefix:        _defaultKeyPrefix,
+				leaseDuration: _defaultLeaseDuration,
+				tickInterval:  _defaultTickInterval,
+
+				jobs: make(map[string]*job),
+			}
+
+			for _, opt := range opts {
+				opt(schedulerSingleInstance)
+			}
+
+			schedulerSingleInstance.runInBackground(ctx)
+		})
+	}
+
+	return schedulerSingleInstance
+}
+
+// Register adds a job run by its schedule. Registering a job with
+// the same name replaces it.
+func (s *Scheduler) Register(name string, schedule Schedule, task Task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs[name] = &job{name: name, schedule: schedule, task: task}
+}
+
+// IsLeader returns whether this replica runs the scheduled jobs.
+func (s *Scheduler) IsLeader() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.isLeader
+}
+
+// Jobs returns the registered jobs ordered by name.
+func (s *Scheduler) Jobs(ctx context.Context) ([]JobInfo, error) {
+	var infos []JobInfo
+
+	for _, j := range s.registeredJobs() {
+		last, err := s.lastRunAt(ctx, j.name)
+		if err != nil {
+			return nil, err
+		}
+
+		info := JobInfo{Name: j.name, LastRunAt: last}
+		if last.IsZero() {
+			info.NextRunAt = j.schedule(time.Now())
+		} else {
+			info.NextRunAt = j.schedule(last)
+		}
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// Lock acquires the lock of a job, on any replica, for at most the
+// given duration. It returns false if the job is locked already.
+// The returned function releases the lock.
+func (s *Scheduler) Lock(ctx context.Context, name string, ttl time.Duration) (func(), bool, error) {
+	key := s.key("lock", name)
+	holder := uuid.NewString()
+
+	ok, err := s.client.SetNX(ctx, key, holder, ttl).Result()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	return func() {
+		if err := releaseScript.Run(context.Background(), s.client, []string{key}, holder).Err(); err != nil {
+			log.Printf("unable to release the lock of job %s: %s\n", name, err)
+		}
+	}, true, nil
+}
+
+func (s *Scheduler) runInBackground(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.tickInterval)
+		defer ticker.Stop()
+
+		for {
+			if s.elect(ctx) {
+				s.runDueJobs(ctx)
+			}
+
+			select {
+			case <-ctx.Done():
+				s.resign()
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// elect acquires or renews the lease of the leader and returns
+// whether this replica is the leader.
+func (s *Scheduler) elect(ctx context.Context) bool {
+	key := s.key("leader")
+	isLeader := s.IsLeader()
+
+	var err error
+	if isLeader {
+		var renewed int64
+		renewed, err = renewScript.Run(ctx, s.client, []string{key}, s.id, s.leaseDuration.Milliseconds()).Int64()
+		isLeader = err == nil && renewed == 1
+	}
+	if !isLeader && err == nil {
+		isLeader, err = s.client.SetNX(ctx, key, s.id, s.leaseDuration).Result()
+	}
+	if err != nil {
+		log.Printf("unable to elect the scheduler leader: %s\n", err)
+		isLeader = false
+	}
+
+	s.mu.Lock()
+	if isLeader != s.isLeader {
+		log.Printf("scheduler %s leadership changed to %t\n", s.id, isLeader)
+	}
+	s.isLeader = isLeader
+	s.mu.Unlock()
+
+	return isLeader
+}
+
+// resign gives up the lease so another replica takes over right away.
+func (s *Scheduler) resign() {
+	if !s.IsLeader() {
+		return
+	}
+
+	if err := releaseScript.Run(context.Background(), s.client, []string{s.key("leader")}, s.id).Err(); err != nil {
+		log.Printf("unable to resign the scheduler leadership: %s\n", err)
+	}
+}
+
+func (s *Scheduler) runDueJobs(ctx context.Context) {
+	now := time.Now()
+
+	for _, j := range s.registeredJobs() {
+		last, err := s.lastRunAt(ctx, j.name)
+		if err != nil {
+			log.Printf("unable to get the last run of job %s: %s\n", j.name, err)
+			continue
+		}
+
+		// A job never run before is scheduled from now on
+		if last.IsZero() {
+			if err := s.setLastRunAt(ctx, j.name, now); err != nil {
+				log.Printf("unable to schedule job %s: %s\n", j.name, err)
+			}
+			continue
+		}
+
+		if now.Before(j.schedule(last)) {
+			continue
+		}
+
+		// Records the run before running the job, so the job is not
+		// run again while it is still running
+		if err := s.setLastRunAt(ctx, j.name, now); err != nil {
+			log.Printf("unable to record the run of job %s: %s\n", j.name, err)
+			continue
+		}
+
+		go func(j *job) {
+			if err := j.task(ctx); err != nil {
+				log.Printf("job %s failed: %s\n", j.name, err)
+			}
+		}(j)
+	}
+}
+
+func (s *Scheduler) registeredJobs() []*job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]*job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].name < jobs[k].name })
+
+	return jobs
+}
+
+func (s *Scheduler) lastRunAt(ctx context.Context, name string) (time.Time, error) {
+	ms, err := s.client.Get(ctx, s.key("last", name)).Result()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, err
+	}
+
+	t, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.UnixMilli(t), nil
+}
+
+func (s *Scheduler) setLastRunAt(ctx context.Context, name string, t time.Time) error {
+	return s.client.Set(ctx, s.key("last", name), t.UnixMilli(), 0).Err()
+}
+
+func (s *Scheduler) key(parts ...string) string {
+	key := s.prefix
+	for _, v := range parts {
+		key += ":" + v
+	}
+
+	return key
+}
@@ -0,0 +1,144 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// The scheduler runs against TEST_REDIS_ADDRESS, e.g. a local instance
+// at localhost:6379, since the lease and the locks are kept in Redis.
+// Each scheduler stands for a replica sharing the prefix.
+func testSchedulers(t *testing.T, n int) []*Scheduler {
+	addr := os.Getenv("TEST_REDIS_ADDRESS")
+	if addr == "" {
+		t.Skip("no redis, set TEST_REDIS_ADDRESS")
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("unable to reach redis at %s: %s", addr, err)
+	}
+
+	prefix := fmt.Sprintf("scheduler-test-%d", time.Now().UnixNano())
+	var schedulers []*Scheduler
+	for i := 0; i < n; i++ {
+		schedulers = append(schedulers, &Scheduler{
+			client:        client,
+			id:            fmt.Sprintf("replica-%d", i),
+			prefix:        prefix,
+			leaseDuration: 500 * time.Millisecond,
+			tickInterval:  100 * time.Millisecond,
+			jobs:          make(map[string]*job),
+		})
+	}
+
+	return schedulers
+}
+
+func TestOnlyOneLeader(t *testing.T) {
+	schedulers := testSchedulers(t, 2)
+	ctx := context.Background()
+
+	if !schedulers[0].elect(ctx) {
+		t.Fatalf("expected the first replica to lead")
+	}
+	if schedulers[1].elect(ctx) {
+		t.Fatalf("expected the second replica not to lead while the first does")
+	}
+	// The leader keeps leading by renewing its lease
+	if !schedulers[0].elect(ctx) {
+		t.Fatalf("expected the leader to renew its lease")
+	}
+
+	schedulers[0].resign()
+	if !schedulers[1].elect(ctx) {
+		t.Fatalf("expected the second replica to take over once the leader resigns")
+	}
+	if schedulers[0].elect(ctx) {
+		t.Fatalf("expected the former leader not to lead again")
+	}
+}
+
+func TestLeaderGoneIsTakenOver(t *testing.T) {
+	schedulers := testSchedulers(t, 2)
+	ctx := context.Background()
+
+	if !schedulers[0].elect(ctx) {
+		t.Fatalf("expected the first replica to lead")
+	}
+
+	// The leader stops renewing its lease, e.g. its replica is gone
+	time.Sleep(schedulers[0].leaseDuration + 100*time.Millisecond)
+	if !schedulers[1].elect(ctx) {
+		t.Fatalf("expected the second replica to take over once the lease expires")
+	}
+	if schedulers[0].elect(ctx) {
+		t.Fatalf("expected the former leader to find out it no longer leads")
+	}
+}
+
+func TestLockingJobs(t *testing.T) {
+	schedulers := testSchedulers(t, 2)
+	ctx := context.Background()
+
+	unlock, ok, err := schedulers[0].Lock(ctx, "job", time.Second)
+	if err != nil || !ok {
+		t.Fatalf("expected the job to be locked, got %v, %v", ok, err)
+	}
+	if _, ok, err := schedulers[1].Lock(ctx, "job", time.Second); err != nil || ok {
+		t.Fatalf("expected another replica not to lock the running job, got %v, %v", ok, err)
+	}
+
+	unlock()
+	unlock2, ok, err := schedulers[1].Lock(ctx, "job", time.Second)
+	if err != nil || !ok {
+		t.Fatalf("expected the job to be locked once unlocked, got %v, %v", ok, err)
+	}
+
+	// Unlocking again does not release the lock held by the other
+	unlock()
+	if _, ok, err := schedulers[0].Lock(ctx, "job", time.Second); err != nil || ok {
+		t.Fatalf("expected a stale unlock not to release the lock of another, got %v, %v", ok, err)
+	}
+	unlock2()
+}
+
+func TestRunningDueJobs(t *testing.T) {
+	schedulers := testSchedulers(t, 1)
+	s := schedulers[0]
+	ctx := context.Background()
+
+	runs := make(chan struct{}, 2)
+	s.Register("job", func(last time.Time) time.Time { return last.Add(200 * time.Millisecond) }, func(ctx context.Context) error {
+		runs <- struct{}{}
+		return nil
+	})
+
+	// A job never run before is only scheduled
+	s.runDueJobs(ctx)
+	s.runDueJobs(ctx)
+	select {
+	case <-runs:
+		t.Fatalf("expected a new job not to run right away")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	s.runDueJobs(ctx)
+	s.runDueJobs(ctx)
+	select {
+	case <-runs:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the due job to run")
+	}
+	select {
+	case <-runs:
+		t.Fatalf("expected the due job to run once")
+	case <-time.After(100 * time.Millisecond):
+	}
+}